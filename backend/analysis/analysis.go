@@ -0,0 +1,90 @@
+// Package analysis corre las etapas de análisis que comparten la línea de
+// comandos, el servidor, el depurador, el LSP, el REPL de sesión, el
+// formateador, el testrunner y difftest: lexer, parser, AST, DclVisitor
+// y chequeo estático.
+//
+//	a := analysis.Parse(code)
+//	if a.Check() {
+//	    // a.DclVisitor y a.Program listos para ejecutar o traducir
+//	}
+//
+// Los errores de todas las etapas quedan en a.ErrorTable, en orden.
+package analysis
+
+import (
+	"log/slog"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/ast"
+	"main.go/checker"
+	"main.go/errors"
+	compiler "main.go/grammar"
+	"main.go/repl"
+)
+
+// Analysis es el resultado de analizar un programa.
+type Analysis struct {
+	Tree       antlr.ParseTree
+	Stream     *antlr.CommonTokenStream
+	ErrorTable *repl.ErrorTable
+	DclVisitor *repl.DclVisitor // nil hasta Check o si hubo errores léxicos o sintácticos
+	Program    *ast.Program     // nil hasta Check o si hubo errores léxicos o sintácticos
+
+	Log *slog.Logger // logger del DclVisitor; nil usa el del subsistema repl
+}
+
+// NewParser crea el parser de code con los listeners de errores léxicos y
+// sintácticos y la estrategia de recuperación de errores del proyecto. Los
+// errores se registran en errorTable.
+func NewParser(code string, errorTable *repl.ErrorTable) (*compiler.VLangGrammar, *antlr.CommonTokenStream) {
+	lexicalErrorListener := errors.NewLexicalErrorListener()
+	lexicalErrorListener.ErrorTable = errorTable
+	lexer := compiler.NewVLangLexer(antlr.NewInputStream(code))
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(lexicalErrorListener)
+
+	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+	parser := compiler.NewVLangGrammar(stream)
+	parser.BuildParseTrees = true
+
+	parser.RemoveErrorListeners()
+	parser.SetErrorHandler(errors.NewCustomErrorStrategy())
+	parser.AddErrorListener(errors.NewSyntaxErrorListener(errorTable))
+	return parser, stream
+}
+
+// Parse corre el lexer y el parser sobre code.
+func Parse(code string) *Analysis {
+	errorTable := repl.NewErrorTable()
+	parser, stream := NewParser(code, errorTable)
+	return &Analysis{
+		Tree:       parser.Program(),
+		Stream:     stream,
+		ErrorTable: errorTable,
+	}
+}
+
+// Analyze corre todas las etapas sobre code.
+func Analyze(code string) *Analysis {
+	a := Parse(code)
+	a.Check()
+	return a
+}
+
+// Check genera el AST y corre el DclVisitor y el chequeo estático si no hubo errores léxicos
+// o sintácticos, y devuelve true si el programa no tiene errores.
+func (a *Analysis) Check() bool {
+	if a.ErrorTable.HasErrors() {
+		return false
+	}
+
+	a.DclVisitor = repl.NewDclVisitor(a.ErrorTable)
+	if a.Log != nil {
+		a.DclVisitor.Log = a.Log
+	}
+	a.Program = ast.Lower(a.Tree)
+	a.DclVisitor.VisitProgram(a.Program)
+	checker.NewChecker(a.ErrorTable).Check(a.Program)
+	return !a.ErrorTable.HasErrors()
+}
@@ -2,18 +2,20 @@ package ast
 
 import (
 	"fmt"
+	"html"
+	"strconv"
 	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
-	compiler "main.go/grammar"
 )
 
 type ASTNode struct {
-	Type     string     `json:"type"`
-	Text     string     `json:"text"`
-	Line     int        `json:"line"`
-	Column   int        `json:"column"`
-	Children []*ASTNode `json:"children"`
+	Type      string     `json:"type"`
+	Text      string     `json:"text"`
+	Line      int        `json:"line"`
+	Column    int        `json:"column"`
+	ValueType string     `json:"valueType,omitempty"`
+	Children  []*ASTNode `json:"children"`
 }
 
 type ASTGenerator struct {
@@ -28,78 +30,113 @@ func NewASTGenerator() *ASTGenerator {
 
 // Generar AST nativo desde el ParseTree de ANTLR
 func GenerateNativeAST(tree antlr.ParseTree) *ASTNode {
-	generator := NewASTGenerator()
-	return generator.visit(tree)
+	return GenerateReportAST(Lower(tree))
 }
 
-func (g *ASTGenerator) visit(tree antlr.ParseTree) *ASTNode {
-	if tree == nil {
+// GenerateReportAST convierte el árbol tipado en el árbol que dibuja el
+// reporte.
+func GenerateReportAST(program *Program) *ASTNode {
+	if program == nil {
 		return nil
 	}
+	generator := NewASTGenerator()
+	return generator.visit(program)
+}
 
-	node := &ASTNode{
+func (g *ASTGenerator) visit(node Node) *ASTNode {
+	span := node.GetSpan()
+
+	reportNode := &ASTNode{
+		Type:     nodeTypeName(node),
+		Text:     truncateText(nodeText(node)),
+		Line:     span.Start.Line,
+		Column:   span.Start.Column,
 		Children: make([]*ASTNode, 0),
 	}
 
-	// Obtener información del nodo
-	switch t := tree.(type) {
-	case *compiler.ProgramContext:
-		node.Type = "Program"
-		node.Text = "program"
-		node.Line = 1
-		node.Column = 1
-	case *compiler.StmtContext:
-		node.Type = "Statement"
-		node.Text = truncateText(t.GetText())
-		if t.GetStart() != nil {
-			node.Line = t.GetStart().GetLine()
-			node.Column = t.GetStart().GetColumn()
-		}
-	case *compiler.Decl_stmtContext:
-		node.Type = "Declaration"
-		node.Text = truncateText(t.GetText())
-		if t.GetStart() != nil {
-			node.Line = t.GetStart().GetLine()
-			node.Column = t.GetStart().GetColumn()
-		}
-	case *compiler.ExpressionContext:
-		node.Type = "Expression"
-		node.Text = truncateText(t.GetText())
-		if t.GetStart() != nil {
-			node.Line = t.GetStart().GetLine()
-			node.Column = t.GetStart().GetColumn()
-		}
-	case antlr.TerminalNode:
-		node.Type = "Terminal"
-		node.Text = truncateText(t.GetText())
-		if t.GetSymbol() != nil {
-			node.Line = t.GetSymbol().GetLine()
-			node.Column = t.GetSymbol().GetColumn()
-		}
-	default:
-		// Para otros tipos de contexto
-		node.Type = getSimpleTypeName(fmt.Sprintf("%T", tree))
-		node.Text = truncateText(tree.GetText())
-		if ctx, ok := tree.(antlr.ParserRuleContext); ok && ctx.GetStart() != nil {
-			node.Line = ctx.GetStart().GetLine()
-			node.Column = ctx.GetStart().GetColumn()
-		}
+	if expr, ok := node.(Expr); ok {
+		reportNode.ValueType = expr.Type()
 	}
 
-	// Visitar hijos con conversión de tipo correcta
-	for i := 0; i < tree.GetChildCount(); i++ {
-		child := tree.GetChild(i)
+	if _, ok := node.(*Program); ok {
+		reportNode.Line = 1
+		reportNode.Column = 1
+	}
 
-		// Convertir antlr.Tree a antlr.ParseTree
-		if parseTreeChild, ok := child.(antlr.ParseTree); ok {
-			childNode := g.visit(parseTreeChild)
-			if childNode != nil {
-				node.Children = append(node.Children, childNode)
-			}
-		}
+	for _, child := range Children(node) {
+		reportNode.Children = append(reportNode.Children, g.visit(child))
 	}
 
-	return node
+	g.nodes = append(g.nodes, reportNode)
+	return reportNode
+}
+
+// nodeTypeName devuelve el nombre del nodo sin el paquete: *ast.VarDecl -> VarDecl
+func nodeTypeName(node Node) string {
+	name := fmt.Sprintf("%T", node)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// nodeText resume el contenido de un nodo para mostrarlo en el reporte.
+func nodeText(node Node) string {
+	switch n := node.(type) {
+	case *Program:
+		return "program"
+	case *VarDecl:
+		return n.Name.Name
+	case *AssignStmt:
+		return n.Op
+	case *FuncDecl:
+		return n.Name.Name
+	case *StructDecl:
+		return n.Name.Name
+	case *Param:
+		return n.Name.Name
+	case *Field:
+		return n.Name.Name
+	case *TypeRef:
+		return n.Syntax()
+	case *Ident:
+		return n.Name
+	case *SelectorExpr:
+		return "." + n.Sel.Name
+	case *CallExpr:
+		if id, ok := n.Fun.(*Ident); ok {
+			return id.Name + "()"
+		}
+		if sel, ok := n.Fun.(*SelectorExpr); ok {
+			return "." + sel.Sel.Name + "()"
+		}
+	case *Arg:
+		return n.Label
+	case *FieldInit:
+		return n.Name.Name
+	case *IntLit:
+		return n.Raw
+	case *FloatLit:
+		return n.Raw
+	case *StringLit:
+		return n.Raw
+	case *BoolLit:
+		return fmt.Sprintf("%t", n.Value)
+	case *NilLit:
+		return "nil"
+	case *UnaryExpr:
+		return n.Op
+	case *BinaryExpr:
+		return n.Op
+	case *IncDecExpr:
+		return n.X.Name + n.Op
+	case *StructLit:
+		return n.Name.Name
+	case *ReturnStmt:
+		return "return"
+	case *BreakStmt:
+		return "break"
+	case *ContinueStmt:
+		return "continue"
+	}
+	return ""
 }
 
 // Función auxiliar para truncar texto
@@ -110,25 +147,6 @@ func truncateText(text string) string {
 	return text
 }
 
-// Función auxiliar para obtener nombre simple del tipo
-func getSimpleTypeName(fullTypeName string) string {
-	// Extraer solo el nombre de la clase del tipo completo
-	parts := strings.Split(fullTypeName, ".")
-	if len(parts) > 0 {
-		typeName := parts[len(parts)-1]
-		// Remover "Context" del final si existe
-		if strings.HasSuffix(typeName, "Context") {
-			typeName = typeName[:len(typeName)-7]
-		}
-		// Remover "*" del inicio si existe
-		if strings.HasPrefix(typeName, "*") {
-			typeName = typeName[1:]
-		}
-		return typeName
-	}
-	return "Unknown"
-}
-
 // Generar SVG desde el AST nativo
 func GenerateASTSVG(astNode *ASTNode) string {
 	if astNode == nil {
@@ -146,6 +164,41 @@ func GenerateASTSVG(astNode *ASTNode) string {
 	return generator.Generate(astNode)
 }
 
+// GenerateASTDot devuelve el AST en el lenguaje DOT de Graphviz, para
+// dibujarlo con "dot -Tpng".
+func GenerateASTDot(root *ASTNode) string {
+	var b strings.Builder
+	b.WriteString("digraph AST {\n")
+	b.WriteString("\tnode [shape=box, fontname=\"monospace\"];\n")
+
+	next := 0
+	var visit func(node *ASTNode) int
+	visit = func(node *ASTNode) int {
+		id := next
+		next++
+
+		label := node.Type
+		if node.Text != "" {
+			label += "\n" + truncateText(node.Text)
+		}
+		if node.ValueType != "" {
+			label += "\n: " + node.ValueType
+		}
+		fmt.Fprintf(&b, "\tn%d [label=%s];\n", id, strconv.Quote(label))
+
+		for _, child := range node.Children {
+			fmt.Fprintf(&b, "\tn%d -> n%d;\n", id, visit(child))
+		}
+		return id
+	}
+	if root != nil {
+		visit(root)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
 type SVGGenerator struct {
 	width       int
 	height      int
@@ -274,7 +327,10 @@ func (g *SVGGenerator) drawNodes(svg *strings.Builder, node *ASTNode, positions
 	// Tooltip con información completa
 	tooltipText := fmt.Sprintf("Tipo: %s\nTexto: %s\nLínea: %d, Columna: %d",
 		node.Type, node.Text, node.Line, node.Column)
-	svg.WriteString(fmt.Sprintf(`<title>%s</title>`, tooltipText))
+	if node.ValueType != "" {
+		tooltipText += "\nTipo estático: " + node.ValueType
+	}
+	svg.WriteString(fmt.Sprintf(`<title>%s</title>`, html.EscapeString(tooltipText)))
 
 	// Dibujar hijos recursivamente
 	for _, child := range node.Children {
@@ -0,0 +1,872 @@
+package ast
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/antlr4-go/antlr/v4"
+	compiler "main.go/grammar"
+)
+
+// interpolationPattern reconoce $name y ${name} dentro de un string, con las
+// mismas reglas que InterpolateString del intérprete.
+var interpolationPattern = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_]*)\}|\$([a-zA-Z_][a-zA-Z0-9_]*)`)
+
+// Lower construye el árbol tipado a partir del parse tree de ANTLR y le
+// asigna los tipos estáticos. Es el único punto del backend que traduce los
+// contextos generados por la gramática a nodos propios.
+//
+// El checker, el linter, el LSP, el intérprete (DclVisitor y ReplVisitor), el
+// compilador de la VM, el IR y los reportes recorren solo el árbol tipado. El
+// ARM64Translator todavía recorre el parse tree y toma del árbol tipado los
+// tipos con Program.TypeOf y los literales con Program.NodeOf.
+func Lower(tree antlr.ParseTree) *Program {
+	return NewEnv().Lower(tree)
+}
+
+// Lower es como la función Lower, pero el programa ve las declaraciones
+// globales de env.
+func (e *Env) Lower(tree antlr.ParseTree) *Program {
+	l := &lowerer{nodes: make(map[antlr.ParserRuleContext]Node)}
+	program := &Program{nodes: l.nodes}
+
+	if ctx, ok := tree.(*compiler.ProgramContext); ok {
+		program.Span = spanOf(ctx)
+		program.Stmts = l.stmts(ctx.AllStmt())
+		l.record(program, ctx)
+	}
+
+	inferTypes(program, e)
+	return program
+}
+
+// LowerExpr construye el nodo de una expresión suelta, como las que evalúa el
+// REPL de sesión, con los tipos que le dan las declaraciones de env. Devuelve
+// nil si tree no es una expresión.
+func (e *Env) LowerExpr(tree antlr.ParseTree) Expr {
+	ctx, ok := tree.(compiler.IExpressionContext)
+	if !ok {
+		return nil
+	}
+
+	l := &lowerer{nodes: make(map[antlr.ParserRuleContext]Node)}
+	expr := l.expr(ctx)
+	if expr == nil {
+		return nil
+	}
+
+	stmt := &ExprStmt{stmtBase: stmtBase{Span: expr.GetSpan()}, X: expr}
+	inferTypes(&Program{Span: expr.GetSpan(), Stmts: []Stmt{stmt}, nodes: l.nodes}, e)
+	return expr
+}
+
+type lowerer struct {
+	nodes map[antlr.ParserRuleContext]Node
+}
+
+// record asocia cada contexto con el nodo que generó, para Program.NodeOf.
+// El último contexto es el más externo y es el que cita Text.
+func (l *lowerer) record(node Node, contexts ...antlr.ParserRuleContext) {
+	for _, ctx := range contexts {
+		if ctx != nil {
+			l.nodes[ctx] = node
+			if src, ok := node.(sourced); ok {
+				src.setSource(ctx)
+			}
+		}
+	}
+}
+
+// === POSICIONES ===
+
+func tokenStart(tok antlr.Token) Pos {
+	return Pos{Line: tok.GetLine(), Column: tok.GetColumn(), Offset: tok.GetStart()}
+}
+
+func tokenEnd(tok antlr.Token) Pos {
+	if tok.GetTokenType() == antlr.TokenEOF {
+		return tokenStart(tok)
+	}
+
+	text := tok.GetText()
+	end := Pos{Line: tok.GetLine(), Offset: tok.GetStop() + 1}
+
+	if i := strings.LastIndex(text, "\n"); i >= 0 {
+		end.Line += strings.Count(text, "\n")
+		end.Column = utf8.RuneCountInString(text[i+1:])
+	} else {
+		end.Column = tok.GetColumn() + utf8.RuneCountInString(text)
+	}
+	return end
+}
+
+func tokenSpan(tok antlr.Token) Span {
+	return Span{Start: tokenStart(tok), End: tokenEnd(tok)}
+}
+
+func spanOf(ctx antlr.ParserRuleContext) Span {
+	start, stop := ctx.GetStart(), ctx.GetStop()
+	if start == nil {
+		return Span{}
+	}
+	if stop == nil || stop.GetTokenIndex() < start.GetTokenIndex() {
+		stop = start
+	}
+	return Span{Start: tokenStart(start), End: tokenEnd(stop)}
+}
+
+func join(from, to Span) Span {
+	return Span{Start: from.Start, End: to.End}
+}
+
+func (l *lowerer) ident(node antlr.TerminalNode) *Ident {
+	if node == nil {
+		return nil
+	}
+	return l.identToken(node.GetSymbol())
+}
+
+func (l *lowerer) identToken(tok antlr.Token) *Ident {
+	if tok == nil {
+		return nil
+	}
+	return &Ident{exprBase: exprBase{Span: tokenSpan(tok)}, Name: tok.GetText()}
+}
+
+// === TIPOS ===
+
+func (l *lowerer) typeRef(ctx compiler.ITypeContext) *TypeRef {
+	typeCtx, ok := ctx.(*compiler.TypeContext)
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case typeCtx.Vector_type() != nil:
+		return l.vectorType(typeCtx.Vector_type())
+	case typeCtx.Matrix_type() != nil:
+		return l.matrixType(typeCtx.Matrix_type())
+	case typeCtx.ID() != nil:
+		return &TypeRef{Span: spanOf(typeCtx), Name: typeCtx.ID().GetText()}
+	}
+	return nil
+}
+
+func (l *lowerer) vectorType(ctx compiler.IVector_typeContext) *TypeRef {
+	vectorCtx, ok := ctx.(*compiler.Vector_typeContext)
+	if !ok || vectorCtx.ID() == nil {
+		return nil
+	}
+	return &TypeRef{Span: spanOf(vectorCtx), Name: vectorCtx.ID().GetText(), Dims: 1}
+}
+
+func (l *lowerer) matrixType(ctx compiler.IMatrix_typeContext) *TypeRef {
+	matrixCtx, ok := ctx.(*compiler.Matrix_typeContext)
+	if !ok || matrixCtx.ID() == nil {
+		return nil
+	}
+	return &TypeRef{Span: spanOf(matrixCtx), Name: matrixCtx.ID().GetText(), Dims: 2}
+}
+
+// === SENTENCIAS ===
+
+func (l *lowerer) stmts(list []compiler.IStmtContext) []Stmt {
+	stmts := make([]Stmt, 0, len(list))
+	for _, ctx := range list {
+		if stmt := l.stmt(ctx); stmt != nil {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+func (l *lowerer) stmt(ctx compiler.IStmtContext) Stmt {
+	stmtCtx, ok := ctx.(*compiler.StmtContext)
+	if !ok {
+		return nil
+	}
+
+	var stmt Stmt
+
+	switch {
+	case stmtCtx.Decl_stmt() != nil:
+		stmt = l.declStmt(stmtCtx.Decl_stmt())
+	case stmtCtx.Assign_stmt() != nil:
+		if assign := l.assignStmt(stmtCtx.Assign_stmt()); assign != nil {
+			stmt = assign
+		}
+	case stmtCtx.Block_ind() != nil:
+		if block, ok := stmtCtx.Block_ind().(*compiler.BlockIndContext); ok {
+			stmt = &BlockStmt{stmtBase: stmtBase{Span: spanOf(block)}, Stmts: l.stmts(block.AllStmt())}
+			l.record(stmt, block)
+		}
+	case stmtCtx.Transfer_stmt() != nil:
+		stmt = l.transferStmt(stmtCtx.Transfer_stmt())
+	case stmtCtx.If_stmt() != nil:
+		stmt = l.ifStmt(stmtCtx.If_stmt())
+	case stmtCtx.Switch_stmt() != nil:
+		stmt = l.switchStmt(stmtCtx.Switch_stmt())
+	case stmtCtx.While_stmt() != nil:
+		if whileCtx, ok := stmtCtx.While_stmt().(*compiler.WhileStmtContext); ok {
+			stmt = &WhileStmt{
+				stmtBase: stmtBase{Span: spanOf(whileCtx)},
+				Cond:     l.expr(whileCtx.Expression()),
+				Body:     l.stmts(whileCtx.AllStmt()),
+			}
+			l.record(stmt, whileCtx)
+		}
+	case stmtCtx.For_stmt() != nil:
+		stmt = l.forStmt(stmtCtx.For_stmt())
+	case stmtCtx.Func_call() != nil:
+		if call := l.funcCall(nil, stmtCtx.Func_call()); call != nil {
+			stmt = &ExprStmt{stmtBase: stmtBase{Span: call.Span}, X: call}
+		}
+	case stmtCtx.Vect_func() != nil:
+		if call := l.vectFunc(stmtCtx.Vect_func()); call != nil {
+			stmt = &ExprStmt{stmtBase: stmtBase{Span: call.GetSpan()}, X: call}
+		}
+	case stmtCtx.Func_dcl() != nil:
+		stmt = l.funcDecl(stmtCtx.Func_dcl())
+	case stmtCtx.Strct_dcl() != nil:
+		stmt = l.structDecl(stmtCtx.Strct_dcl())
+	}
+
+	if stmt == nil {
+		return nil
+	}
+
+	l.record(stmt, stmtCtx)
+	return stmt
+}
+
+func (l *lowerer) declStmt(ctx compiler.IDecl_stmtContext) Stmt {
+	var decl *VarDecl
+
+	switch declCtx := ctx.(type) {
+	case *compiler.MutVarDeclContext:
+		decl = &VarDecl{
+			Kind:    DeclMutTyped,
+			Name:    l.ident(declCtx.ID()),
+			TypeRef: l.typeRef(declCtx.Type_()),
+			Value:   l.expr(declCtx.Expression()),
+		}
+	case *compiler.ValueDeclContext:
+		decl = &VarDecl{
+			Kind:  DeclMutInferred,
+			Name:  l.ident(declCtx.ID()),
+			Value: l.expr(declCtx.Expression()),
+		}
+	case *compiler.ValDeclVecContext:
+		decl = &VarDecl{
+			Kind:    DeclMutZero,
+			Name:    l.ident(declCtx.ID()),
+			TypeRef: l.typeRef(declCtx.Type_()),
+		}
+	case *compiler.VarAssDeclContext:
+		decl = &VarDecl{
+			Kind:    DeclTyped,
+			Name:    l.ident(declCtx.ID()),
+			TypeRef: l.typeRef(declCtx.Type_()),
+			Value:   l.expr(declCtx.Expression()),
+		}
+	case *compiler.VarVectDeclContext:
+		typeRef := l.vectorType(declCtx.Vector_type())
+		decl = &VarDecl{
+			Kind:    DeclVector,
+			Name:    l.ident(declCtx.ID()),
+			TypeRef: typeRef,
+		}
+		if vector := l.vectorLit(declCtx.Vect_expr(), typeRef); vector != nil {
+			if typeRef != nil {
+				vector.Span = join(typeRef.Span, vector.Span)
+			}
+			decl.Value = vector
+		}
+	case *compiler.VarMatrixDeclContext:
+		typeRef := l.matrixType(declCtx.Matrix_type())
+		decl = &VarDecl{
+			Kind:    DeclMatrix,
+			Name:    l.ident(declCtx.ID()),
+			TypeRef: typeRef,
+		}
+		if matrix := l.matrixLit(declCtx.Matrix_expr(), typeRef); matrix != nil {
+			if typeRef != nil {
+				matrix.Span = join(typeRef.Span, matrix.Span)
+			}
+			decl.Value = matrix
+		}
+	default:
+		return nil
+	}
+
+	if decl.Name == nil {
+		return nil
+	}
+
+	declCtx := ctx.(antlr.ParserRuleContext)
+	decl.Span = spanOf(declCtx)
+	l.record(decl, declCtx)
+	return decl
+}
+
+func (l *lowerer) assignStmt(ctx compiler.IAssign_stmtContext) *AssignStmt {
+	var assign *AssignStmt
+
+	switch assignCtx := ctx.(type) {
+	case *compiler.AssignmentDeclContext:
+		assign = &AssignStmt{
+			Op:     "=",
+			Target: l.idPattern(nil, assignCtx.Id_pattern()),
+			Value:  l.expr(assignCtx.Expression()),
+		}
+	case *compiler.ArgAddAssigDeclContext:
+		assign = &AssignStmt{
+			Op:     assignCtx.GetOp().GetText(),
+			Target: l.idPattern(nil, assignCtx.Id_pattern()),
+			Value:  l.expr(assignCtx.Expression()),
+		}
+	case *compiler.VectorAssignContext:
+		assign = &AssignStmt{
+			Op:     assignCtx.GetOp().GetText(),
+			Target: l.vectItem(assignCtx.Vect_item()),
+			Value:  l.expr(assignCtx.Expression()),
+		}
+	default:
+		return nil
+	}
+
+	if assign.Target == nil {
+		return nil
+	}
+
+	assignCtx := ctx.(antlr.ParserRuleContext)
+	assign.Span = spanOf(assignCtx)
+	l.record(assign, assignCtx)
+	return assign
+}
+
+func (l *lowerer) transferStmt(ctx compiler.ITransfer_stmtContext) Stmt {
+	var stmt Stmt
+
+	switch transferCtx := ctx.(type) {
+	case *compiler.ReturnStmtContext:
+		stmt = &ReturnStmt{stmtBase: stmtBase{Span: spanOf(transferCtx)}, Value: l.expr(transferCtx.Expression())}
+	case *compiler.BreakStmtContext:
+		stmt = &BreakStmt{stmtBase: stmtBase{Span: spanOf(transferCtx)}}
+	case *compiler.ContinueStmtContext:
+		stmt = &ContinueStmt{stmtBase: stmtBase{Span: spanOf(transferCtx)}}
+	default:
+		return nil
+	}
+
+	l.record(stmt, ctx.(antlr.ParserRuleContext))
+	return stmt
+}
+
+func (l *lowerer) ifStmt(ctx compiler.IIf_stmtContext) Stmt {
+	ifCtx, ok := ctx.(*compiler.IfStmtContext)
+	if !ok {
+		return nil
+	}
+
+	stmt := &IfStmt{stmtBase: stmtBase{Span: spanOf(ifCtx)}}
+
+	for _, chain := range ifCtx.AllIf_chain() {
+		chainCtx, ok := chain.(*compiler.IfChainContext)
+		if !ok {
+			continue
+		}
+		branch := &IfBranch{
+			Span: spanOf(chainCtx),
+			Cond: l.expr(chainCtx.Expression()),
+			Body: l.stmts(chainCtx.AllStmt()),
+		}
+		stmt.Branches = append(stmt.Branches, branch)
+	}
+
+	if elseCtx, ok := ifCtx.Else_stmt().(*compiler.ElseStmtContext); ok {
+		stmt.Else = &BlockStmt{stmtBase: stmtBase{Span: spanOf(elseCtx)}, Stmts: l.stmts(elseCtx.AllStmt())}
+		l.record(stmt.Else, elseCtx)
+	}
+
+	l.record(stmt, ifCtx)
+	return stmt
+}
+
+func (l *lowerer) switchStmt(ctx compiler.ISwitch_stmtContext) Stmt {
+	switchCtx, ok := ctx.(*compiler.SwitchStmtContext)
+	if !ok {
+		return nil
+	}
+
+	stmt := &SwitchStmt{stmtBase: stmtBase{Span: spanOf(switchCtx)}, Tag: l.expr(switchCtx.Expression())}
+
+	for _, rawCase := range switchCtx.AllSwitch_case() {
+		caseCtx, ok := rawCase.(*compiler.SwitchCaseContext)
+		if !ok {
+			continue
+		}
+		stmt.Cases = append(stmt.Cases, &CaseClause{
+			Span:  spanOf(caseCtx),
+			Value: l.expr(caseCtx.Expression()),
+			Body:  l.stmts(caseCtx.AllStmt()),
+		})
+	}
+
+	if defaultCtx, ok := switchCtx.Default_case().(*compiler.DefaultCaseContext); ok {
+		stmt.Default = &CaseClause{Span: spanOf(defaultCtx), Body: l.stmts(defaultCtx.AllStmt())}
+	}
+
+	l.record(stmt, switchCtx)
+	return stmt
+}
+
+func (l *lowerer) forStmt(ctx compiler.IFor_stmtContext) Stmt {
+	var stmt Stmt
+
+	switch forCtx := ctx.(type) {
+	case *compiler.ForStmtCondContext:
+		stmt = &ForCondStmt{
+			stmtBase: stmtBase{Span: spanOf(forCtx)},
+			Cond:     l.expr(forCtx.Expression()),
+			Body:     l.stmts(forCtx.AllStmt()),
+		}
+	case *compiler.ForAssCondContext:
+		stmt = &ForClauseStmt{
+			stmtBase: stmtBase{Span: spanOf(forCtx)},
+			Init:     l.assignStmt(forCtx.Assign_stmt()),
+			Cond:     l.expr(forCtx.Expression(0)),
+			Post:     l.expr(forCtx.Expression(1)),
+			Body:     l.stmts(forCtx.AllStmt()),
+		}
+	case *compiler.ForStmtContext:
+		stmt = &ForRange{
+			stmtBase: stmtBase{Span: spanOf(forCtx)},
+			Index:    l.ident(forCtx.ID(0)),
+			Value:    l.ident(forCtx.ID(1)),
+			X:        l.expr(forCtx.Expression()),
+			Body:     l.stmts(forCtx.AllStmt()),
+		}
+	default:
+		return nil
+	}
+
+	l.record(stmt, ctx.(antlr.ParserRuleContext))
+	return stmt
+}
+
+func (l *lowerer) funcDecl(ctx compiler.IFunc_dclContext) Stmt {
+	funcCtx, ok := ctx.(*compiler.FuncDeclContext)
+	if !ok || funcCtx.ID() == nil {
+		return nil
+	}
+
+	decl := &FuncDecl{
+		stmtBase: stmtBase{Span: spanOf(funcCtx)},
+		Name:     l.ident(funcCtx.ID()),
+		Result:   l.typeRef(funcCtx.Type_()),
+		Body:     l.stmts(funcCtx.AllStmt()),
+	}
+
+	if paramList, ok := funcCtx.Param_list().(*compiler.ParamListContext); ok {
+		for _, rawParam := range paramList.AllFunc_param() {
+			paramCtx, ok := rawParam.(*compiler.FuncParamContext)
+			if !ok || paramCtx.ID() == nil {
+				continue
+			}
+			decl.Params = append(decl.Params, &Param{
+				Span: spanOf(paramCtx),
+				Name: l.ident(paramCtx.ID()),
+				Type: l.typeRef(paramCtx.Type_()),
+			})
+		}
+	}
+
+	l.record(decl, funcCtx)
+	return decl
+}
+
+func (l *lowerer) structDecl(ctx compiler.IStrct_dclContext) Stmt {
+	structCtx, ok := ctx.(*compiler.StructDeclContext)
+	if !ok || structCtx.ID() == nil {
+		return nil
+	}
+
+	decl := &StructDecl{stmtBase: stmtBase{Span: spanOf(structCtx)}, Name: l.ident(structCtx.ID())}
+
+	for _, prop := range structCtx.AllStruct_prop() {
+		attrCtx, ok := prop.(*compiler.StructAttrContext)
+		if !ok || attrCtx.ID() == nil {
+			continue
+		}
+		decl.Fields = append(decl.Fields, &Field{
+			Span: spanOf(attrCtx),
+			Type: l.typeRef(attrCtx.Type_()),
+			Name: l.ident(attrCtx.ID()),
+		})
+	}
+
+	l.record(decl, structCtx)
+	return decl
+}
+
+// === EXPRESIONES ===
+
+func (l *lowerer) expr(ctx compiler.IExpressionContext) Expr {
+	var expr Expr
+	var inner antlr.ParserRuleContext
+
+	switch exprCtx := ctx.(type) {
+	case *compiler.ParensExprContext:
+		if x := l.expr(exprCtx.Expression()); x != nil {
+			expr = &ParenExpr{exprBase: exprBase{Span: spanOf(exprCtx)}, X: x}
+		}
+	case *compiler.FuncCallExprContext:
+		if call := l.funcCall(nil, exprCtx.Func_call()); call != nil {
+			expr = call
+		}
+	case *compiler.IdPatternExprContext:
+		expr = l.idPattern(nil, exprCtx.Id_pattern())
+	case *compiler.VectorItemExprContext:
+		expr = l.vectItem(exprCtx.Vect_item())
+	case *compiler.VectorPropertyExprContext:
+		if propCtx, ok := exprCtx.Vect_prop().(*compiler.VectorPropertyContext); ok {
+			if base := l.vectItem(propCtx.Vect_item()); base != nil {
+				expr = l.idPattern(base, propCtx.Id_pattern())
+			}
+			inner = propCtx
+		}
+	case *compiler.VectorFuncCallExprContext:
+		expr = l.vectFunc(exprCtx.Vect_func())
+	case *compiler.LiteralExprContext:
+		expr = l.literal(exprCtx.Literal())
+	case *compiler.VectorExprContext:
+		if vector := l.vectorLit(exprCtx.Vect_expr(), nil); vector != nil {
+			expr = vector
+		}
+	case *compiler.RepeatingExprContext:
+		if repeatingCtx, ok := exprCtx.Repeating().(*compiler.RepeatingDeclContext); ok {
+			repeating := &RepeatingExpr{exprBase: exprBase{Span: spanOf(repeatingCtx)}}
+			if repeatingCtx.Vector_type() != nil {
+				repeating.TypeRef = l.vectorType(repeatingCtx.Vector_type())
+			} else {
+				repeating.TypeRef = l.matrixType(repeatingCtx.Matrix_type())
+			}
+			for i, id := range repeatingCtx.AllID() {
+				value := l.expr(repeatingCtx.Expression(i))
+				if value == nil {
+					continue
+				}
+				repeating.Args = append(repeating.Args, &Arg{
+					Span:  join(tokenSpan(id.GetSymbol()), value.GetSpan()),
+					Label: id.GetText(),
+					Value: value,
+				})
+			}
+			expr = repeating
+			inner = repeatingCtx
+		}
+	case *compiler.IncredecrContext:
+		expr = l.incDec(exprCtx.Incredecre())
+	case *compiler.UnaryExprContext:
+		if x := l.expr(exprCtx.Expression()); x != nil {
+			expr = &UnaryExpr{
+				exprBase: exprBase{Span: spanOf(exprCtx)},
+				Op:       exprCtx.GetOp().GetText(),
+				OpPos:    tokenStart(exprCtx.GetOp()),
+				X:        x,
+			}
+		}
+	case *compiler.BinaryExprContext:
+		left, right := l.expr(exprCtx.GetLeft()), l.expr(exprCtx.GetRight())
+		if left != nil && right != nil {
+			expr = &BinaryExpr{
+				exprBase: exprBase{Span: spanOf(exprCtx)},
+				Op:       exprCtx.GetOp().GetText(),
+				OpPos:    tokenStart(exprCtx.GetOp()),
+				Left:     left,
+				Right:    right,
+			}
+		}
+	case *compiler.StructInstantiationExprContext:
+		if exprCtx.ID() != nil {
+			structLit := &StructLit{exprBase: exprBase{Span: spanOf(exprCtx)}, Name: l.ident(exprCtx.ID())}
+			if paramList, ok := exprCtx.Struct_param_list().(*compiler.Struct_param_listContext); ok {
+				for _, rawParam := range paramList.AllStruct_param() {
+					paramCtx, ok := rawParam.(*compiler.Struct_paramContext)
+					if !ok || paramCtx.ID() == nil {
+						continue
+					}
+					structLit.Fields = append(structLit.Fields, &FieldInit{
+						Span:  spanOf(paramCtx),
+						Name:  l.ident(paramCtx.ID()),
+						Value: l.expr(paramCtx.Expression()),
+					})
+				}
+			}
+			expr = structLit
+		}
+	}
+
+	if expr == nil {
+		return nil
+	}
+
+	l.record(expr, ctx.(antlr.ParserRuleContext), inner)
+	return expr
+}
+
+// idPattern genera a.b.c como selectores anidados. Si base no es nil, el
+// patrón se aplica sobre base (v[0].count).
+func (l *lowerer) idPattern(base Expr, ctx compiler.IId_patternContext) Expr {
+	patternCtx, ok := ctx.(*compiler.IdPatternContext)
+	if !ok {
+		return nil
+	}
+
+	var expr Expr = base
+	for _, id := range patternCtx.AllID() {
+		sel := l.ident(id)
+		if expr == nil {
+			expr = sel
+			continue
+		}
+		expr = &SelectorExpr{exprBase: exprBase{Span: join(expr.GetSpan(), sel.Span)}, X: expr, Sel: sel}
+	}
+
+	if expr == nil {
+		return nil
+	}
+
+	l.record(expr, patternCtx)
+	return expr
+}
+
+// vectItem genera v[i][j] como índices anidados.
+func (l *lowerer) vectItem(ctx compiler.IVect_itemContext) Expr {
+	itemCtx, ok := ctx.(*compiler.VectorItemContext)
+	if !ok {
+		return nil
+	}
+
+	expr := l.idPattern(nil, itemCtx.Id_pattern())
+	if expr == nil {
+		return nil
+	}
+
+	brackets := itemCtx.AllRBRACK()
+	for i, indexCtx := range itemCtx.AllExpression() {
+		index := l.expr(indexCtx)
+		if index == nil {
+			return nil
+		}
+		span := join(expr.GetSpan(), index.GetSpan())
+		if i < len(brackets) {
+			span.End = tokenEnd(brackets[i].GetSymbol())
+		}
+		expr = &IndexExpr{exprBase: exprBase{Span: span}, X: expr, Index: index}
+	}
+
+	l.record(expr, itemCtx)
+	return expr
+}
+
+// vectFunc genera v[0].append(x) como una llamada cuyo Fun es un selector
+// sobre el índice.
+func (l *lowerer) vectFunc(ctx compiler.IVect_funcContext) Expr {
+	funcCtx, ok := ctx.(*compiler.VectorFuncCallContext)
+	if !ok {
+		return nil
+	}
+
+	base := l.vectItem(funcCtx.Vect_item())
+	if base == nil {
+		return nil
+	}
+
+	call := l.funcCall(base, funcCtx.Func_call())
+	if call == nil {
+		return nil
+	}
+
+	call.Span = join(base.GetSpan(), call.Span)
+	l.record(call, funcCtx)
+	return call
+}
+
+func (l *lowerer) funcCall(base Expr, ctx compiler.IFunc_callContext) *CallExpr {
+	callCtx, ok := ctx.(*compiler.FuncCallContext)
+	if !ok {
+		return nil
+	}
+
+	fun := l.idPattern(base, callCtx.Id_pattern())
+	if fun == nil {
+		return nil
+	}
+
+	call := &CallExpr{exprBase: exprBase{Span: spanOf(callCtx)}, Fun: fun}
+
+	if argList, ok := callCtx.Arg_list().(*compiler.ArgListContext); ok {
+		for _, rawArg := range argList.AllFunc_arg() {
+			argCtx, ok := rawArg.(*compiler.FuncArgContext)
+			if !ok {
+				continue
+			}
+
+			arg := &Arg{Span: spanOf(argCtx)}
+			if argCtx.ID() != nil {
+				arg.Label = argCtx.ID().GetText()
+			}
+			if argCtx.Id_pattern() != nil {
+				arg.Value = l.idPattern(nil, argCtx.Id_pattern())
+			} else {
+				arg.Value = l.expr(argCtx.Expression())
+			}
+			if arg.Value == nil {
+				continue
+			}
+
+			call.Args = append(call.Args, arg)
+			l.record(arg.Value, argCtx)
+		}
+	}
+
+	l.record(call, callCtx)
+	return call
+}
+
+func (l *lowerer) incDec(ctx compiler.IIncredecreContext) Expr {
+	var op string
+	var id antlr.TerminalNode
+
+	switch incCtx := ctx.(type) {
+	case *compiler.IncrementoContext:
+		op, id = "++", incCtx.ID()
+	case *compiler.DecrementoContext:
+		op, id = "--", incCtx.ID()
+	default:
+		return nil
+	}
+
+	x := l.ident(id)
+	if x == nil {
+		return nil
+	}
+
+	incCtx := ctx.(antlr.ParserRuleContext)
+	expr := &IncDecExpr{exprBase: exprBase{Span: spanOf(incCtx)}, Op: op, X: x}
+	l.record(expr, incCtx)
+	return expr
+}
+
+func (l *lowerer) vectorLit(ctx compiler.IVect_exprContext, typeRef *TypeRef) *VectorLit {
+	listCtx, ok := ctx.(*compiler.VectorItemLisContext)
+	if !ok {
+		return nil
+	}
+
+	vector := &VectorLit{exprBase: exprBase{Span: spanOf(listCtx)}, TypeRef: typeRef, Lbrace: tokenStart(listCtx.GetStart())}
+	for _, item := range listCtx.AllExpression() {
+		if elem := l.expr(item); elem != nil {
+			vector.Elems = append(vector.Elems, elem)
+		}
+	}
+
+	l.record(vector, listCtx)
+	return vector
+}
+
+func (l *lowerer) matrixLit(ctx compiler.IMatrix_exprContext, typeRef *TypeRef) *MatrixLit {
+	listCtx, ok := ctx.(*compiler.MatrixItemListContext)
+	if !ok {
+		return nil
+	}
+
+	matrix := &MatrixLit{exprBase: exprBase{Span: spanOf(listCtx)}, TypeRef: typeRef, Lbrace: tokenStart(listCtx.GetStart())}
+	for _, row := range listCtx.AllVect_expr() {
+		if vector := l.vectorLit(row, nil); vector != nil {
+			matrix.Rows = append(matrix.Rows, vector)
+		}
+	}
+
+	l.record(matrix, listCtx)
+	return matrix
+}
+
+func (l *lowerer) literal(ctx compiler.ILiteralContext) Expr {
+	var expr Expr
+
+	literalCtx, ok := ctx.(antlr.ParserRuleContext)
+	if !ok {
+		return nil
+	}
+	base := exprBase{Span: spanOf(literalCtx)}
+
+	switch litCtx := ctx.(type) {
+	case *compiler.IntLiteralContext:
+		value, _ := strconv.Atoi(litCtx.GetText())
+		expr = &IntLit{exprBase: base, Raw: litCtx.GetText(), Value: value}
+	case *compiler.FloatLiteralContext:
+		value, _ := strconv.ParseFloat(litCtx.GetText(), 64)
+		expr = &FloatLit{exprBase: base, Raw: litCtx.GetText(), Value: value}
+	case *compiler.StringLiteralContext:
+		expr = l.stringLit(base, litCtx.GetText())
+	case *compiler.InterpolatedStringLiteralContext:
+		expr = l.stringLit(base, litCtx.GetText())
+		l.record(expr, litCtx.Interpolated_string())
+	case *compiler.BoolLiteralContext:
+		expr = &BoolLit{exprBase: base, Value: litCtx.GetText() == "true"}
+	case *compiler.NilLiteralContext:
+		expr = &NilLit{exprBase: base}
+	default:
+		return nil
+	}
+
+	l.record(expr, literalCtx)
+	return expr
+}
+
+// stringLit procesa las secuencias de escape en el mismo orden que el
+// intérprete y separa las variables interpoladas.
+func (l *lowerer) stringLit(base exprBase, raw string) *StringLit {
+	value := raw
+	if len(value) >= 2 {
+		value = value[1 : len(value)-1]
+	}
+
+	value = strings.ReplaceAll(value, "\\\"", "\"")
+	value = strings.ReplaceAll(value, "\\\\", "\\")
+	value = strings.ReplaceAll(value, "\\n", "\n")
+	value = strings.ReplaceAll(value, "\\r", "\r")
+
+	literal := &StringLit{exprBase: base, Raw: raw, Value: value}
+
+	last := 0
+	for _, match := range interpolationPattern.FindAllStringSubmatchIndex(value, -1) {
+		if match[0] > last {
+			literal.Parts = append(literal.Parts, &StringPart{Text: value[last:match[0]]})
+		}
+
+		name := ""
+		if match[2] >= 0 {
+			name = value[match[2]:match[3]]
+		} else {
+			name = value[match[4]:match[5]]
+		}
+
+		// la variable no tiene posición propia dentro del literal, se usa
+		// la del string completo
+		literal.Parts = append(literal.Parts, &StringPart{
+			Var: &Ident{exprBase: exprBase{Span: base.Span}, Name: name},
+		})
+		last = match[1]
+	}
+	if last < len(value) {
+		literal.Parts = append(literal.Parts, &StringPart{Text: value[last:]})
+	}
+
+	return literal
+}
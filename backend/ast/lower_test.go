@@ -0,0 +1,223 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/ast"
+	interpeter "main.go/grammar"
+)
+
+// lower parsea code y devuelve el árbol tipado junto con el parse tree.
+func lower(t *testing.T, code string) (*ast.Program, antlr.ParseTree) {
+	t.Helper()
+	lexer := interpeter.NewVLangLexer(antlr.NewInputStream(code))
+	parser := interpeter.NewVLangGrammar(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	tree := parser.Program()
+	return ast.Lower(tree), tree
+}
+
+// decl devuelve la declaración de name en el nivel superior del programa.
+func decl(t *testing.T, program *ast.Program, name string) *ast.VarDecl {
+	t.Helper()
+	for _, stmt := range program.Stmts {
+		if d, ok := stmt.(*ast.VarDecl); ok && d.Name.Name == name {
+			return d
+		}
+	}
+	t.Fatalf("no se encontró la declaración de %s", name)
+	return nil
+}
+
+// Cada sentencia del parse tree genera su nodo, con sus partes resueltas.
+func TestLowerStatements(t *testing.T) {
+	program, _ := lower(t, "mut x int = 5\nmut v []int\nfn f(a int, b float) float {\n    return a * b\n}\n"+
+		"if x > 1 {\n    x = 2\n} else if x > 0 {\n    x += 1\n} else {\n    x -= 1\n}\nfor i, e in v {\n    println(i, e)\n}\n")
+	if len(program.Stmts) != 5 {
+		t.Fatalf("se esperaban 5 sentencias, se obtuvieron %d", len(program.Stmts))
+	}
+
+	x := decl(t, program, "x")
+	if x.Kind != ast.DeclMutTyped || x.TypeRef.String() != "int" || !x.Mutable() {
+		t.Errorf("declaración de x: %+v", x)
+	}
+	if lit, ok := x.Value.(*ast.IntLit); !ok || lit.Value != 5 {
+		t.Errorf("valor de x: %#v", x.Value)
+	}
+	if v := decl(t, program, "v"); v.Kind != ast.DeclMutZero || v.Value != nil || v.TypeRef.String() != "[]int" {
+		t.Errorf("declaración de v: %+v", v)
+	}
+
+	f, ok := program.Stmts[2].(*ast.FuncDecl)
+	if !ok {
+		t.Fatalf("se esperaba un FuncDecl, se obtuvo %T", program.Stmts[2])
+	}
+	if len(f.Params) != 2 || f.Params[1].Name.Name != "b" || f.Params[1].Type.String() != "float" || f.Result.String() != "float" {
+		t.Errorf("firma de f: %+v", f)
+	}
+
+	chain, ok := program.Stmts[3].(*ast.IfStmt)
+	if !ok {
+		t.Fatalf("se esperaba un IfStmt, se obtuvo %T", program.Stmts[3])
+	}
+	if len(chain.Branches) != 2 || chain.Else == nil {
+		t.Fatalf("se esperaban un if, un else if y un else: %+v", chain)
+	}
+	if assign, ok := chain.Branches[1].Body[0].(*ast.AssignStmt); !ok || assign.Op != "+=" {
+		t.Errorf("cuerpo del else if: %#v", chain.Branches[1].Body[0])
+	}
+
+	loop, ok := program.Stmts[4].(*ast.ForRange)
+	if !ok {
+		t.Fatalf("se esperaba un ForRange, se obtuvo %T", program.Stmts[4])
+	}
+	if loop.Index.Name != "i" || loop.Value.Name != "e" {
+		t.Errorf("variables del for: %s, %s", loop.Index.Name, loop.Value.Name)
+	}
+}
+
+// Los operadores respetan la precedencia de la gramática y cada nodo guarda
+// su posición: línea desde 1 y columna desde 0.
+func TestLowerExpressions(t *testing.T) {
+	program, _ := lower(t, "mut a = 1\nmut r = a + 2 * 3\n")
+	r := decl(t, program, "r")
+
+	sum, ok := r.Value.(*ast.BinaryExpr)
+	if !ok || sum.Op != "+" {
+		t.Fatalf("se esperaba una suma, se obtuvo %#v", r.Value)
+	}
+	if product, ok := sum.Right.(*ast.BinaryExpr); !ok || product.Op != "*" {
+		t.Errorf("se esperaba el producto a la derecha, se obtuvo %#v", sum.Right)
+	}
+
+	left, ok := sum.Left.(*ast.Ident)
+	if !ok || left.Name != "a" {
+		t.Fatalf("se esperaba a a la izquierda, se obtuvo %#v", sum.Left)
+	}
+	if left.Decl != decl(t, program, "a") {
+		t.Errorf("a no apunta a su declaración")
+	}
+
+	want := ast.Span{Start: ast.Pos{Line: 2, Column: 8, Offset: 18}, End: ast.Pos{Line: 2, Column: 17, Offset: 27}}
+	if sum.Span != want {
+		t.Errorf("span de la suma %+v, se esperaba %+v", sum.Span, want)
+	}
+	if sum.OpPos != (ast.Pos{Line: 2, Column: 10, Offset: 20}) {
+		t.Errorf("posición del operador %+v", sum.OpPos)
+	}
+}
+
+// Los tipos estáticos coinciden con los que produce el intérprete.
+func TestTypes(t *testing.T) {
+	program, _ := lower(t, "mut i = 7\nmut f = 2.5\nstruct Punto {\n    int x\n}\n"+
+		"fn doble(n int) int {\n    return n * 2\n}\n"+
+		"mut suma = i + f\nmut div = i / 2\nmut cmp = i < 9\nmut neg = !true\nmut s = \"ab\" + \"cd\"\nmut c = \"a\"\n"+
+		"mut v = {1, 2}\nmut e = v[0]\nmut n = v.count\nmut d = doble(i)\nmut p = Punto{x: 1}\nmut px = p.x\nmut a = atoi(\"3\")\n")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"suma", "float"},
+		{"div", "int"},
+		{"cmp", "bool"},
+		{"neg", "bool"},
+		{"s", "string"},
+		{"c", "rune"},
+		{"v", "[]int"},
+		{"e", "int"},
+		{"n", "int"},
+		{"d", "int"},
+		{"p", "Punto"},
+		{"px", "int"},
+		{"a", "int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decl(t, program, tt.name).Value.Type(); got != tt.want {
+				t.Errorf("tipo %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// NodeOf y TypeOf responden por los contextos del parse tree a los backends
+// que todavía lo recorren.
+func TestTypeOfContext(t *testing.T) {
+	program, tree := lower(t, "mut i = 7\nmut r = i * 1.5\n")
+
+	var product antlr.ParserRuleContext
+	var find func(node antlr.Tree)
+	find = func(node antlr.Tree) {
+		if ctx, ok := node.(antlr.ParserRuleContext); ok && ctx.GetText() == "i*1.5" && product == nil {
+			if _, ok := program.NodeOf(ctx).(*ast.BinaryExpr); ok {
+				product = ctx
+			}
+		}
+		for _, child := range node.GetChildren() {
+			find(child)
+		}
+	}
+	find(tree)
+
+	if product == nil {
+		t.Fatal("ningún contexto de i * 1.5 tiene su BinaryExpr")
+	}
+	if got := program.TypeOf(product); got != "float" {
+		t.Errorf("tipo %q, se esperaba float", got)
+	}
+	if program.TypeOf(tree.(antlr.ParserRuleContext)) != "" {
+		t.Errorf("el programa no es una expresión y no debería tener tipo")
+	}
+}
+
+// $name y ${name} interpolan una variable; como en el intérprete, ${...}
+// solo acepta un identificador y cualquier otra cosa queda como texto.
+func TestLowerInterpolation(t *testing.T) {
+	tests := []struct {
+		name         string
+		code         string
+		parts        []string // texto literal, o $nombre para una variable
+		interpolated bool
+	}{
+		{"$x", "\"n vale $n.\"", []string{"n vale ", "$n", "."}, true},
+		{"${x}", "\"${n}px\"", []string{"$n", "px"}, true},
+		{"seguidas", "\"$n${m}$n\"", []string{"$n", "$m", "$n"}, true},
+		{"${expr} no interpola", "\"${n + 1}\"", []string{"${n + 1}"}, false},
+		{"$ sin nombre", "\"$ 5 y $1\"", []string{"$ 5 y $1"}, false},
+		{"escapes", "\"a\\\"$n\\\"\"", []string{"a\"", "$n", "\""}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, _ := lower(t, "mut n = 1\nmut m = 2\nmut s = "+tt.code+"\n")
+			literal, ok := decl(t, program, "s").Value.(*ast.StringLit)
+			if !ok {
+				t.Fatalf("se esperaba un StringLit, se obtuvo %#v", decl(t, program, "s").Value)
+			}
+
+			var parts []string
+			for _, part := range literal.Parts {
+				if part.Var == nil {
+					parts = append(parts, part.Text)
+					continue
+				}
+				parts = append(parts, "$"+part.Var.Name)
+				if part.Var.Type() != "int" || part.Var.Decl == nil {
+					t.Errorf("la variable %s no se resolvió: tipo %q", part.Var.Name, part.Var.Type())
+				}
+			}
+			if len(parts) != len(tt.parts) {
+				t.Fatalf("partes %q, se esperaba %q", parts, tt.parts)
+			}
+			for i := range parts {
+				if parts[i] != tt.parts[i] {
+					t.Fatalf("partes %q, se esperaba %q", parts, tt.parts)
+				}
+			}
+			if literal.Interpolated() != tt.interpolated {
+				t.Errorf("Interpolated() = %v, se esperaba %v", literal.Interpolated(), tt.interpolated)
+			}
+		})
+	}
+}
@@ -0,0 +1,496 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// Pos es una posición dentro del código fuente. Line empieza en 1 y Column en
+// 0, igual que los tokens de ANTLR y la tabla de errores; Offset es el índice
+// del carácter dentro de la entrada.
+type Pos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// Span delimita el rango [Start, End) que ocupa un nodo en el código fuente.
+type Span struct {
+	Start Pos `json:"start"`
+	End   Pos `json:"end"`
+}
+
+// GetSpan permite que cualquier nodo que embeba Span cumpla la interfaz Node.
+func (s Span) GetSpan() Span {
+	return s
+}
+
+// Contains indica si la posición (line, column) cae dentro del span.
+func (s Span) Contains(line, column int) bool {
+	if line < s.Start.Line || line > s.End.Line {
+		return false
+	}
+	if line == s.Start.Line && column < s.Start.Column {
+		return false
+	}
+	if line == s.End.Line && column >= s.End.Column {
+		return false
+	}
+	return true
+}
+
+// Node es cualquier nodo del árbol tipado.
+type Node interface {
+	GetSpan() Span
+}
+
+// Stmt es un nodo que aparece como sentencia.
+type Stmt interface {
+	Node
+	stmtNode()
+}
+
+// Expr es un nodo que produce un valor. Type devuelve el tipo estático que le
+// asignó la inferencia, con los mismos nombres que usa el intérprete ("int",
+// "[]float", "[[]]int", nombre de struct...). Una cadena vacía significa que
+// el tipo no se pudo determinar.
+type Expr interface {
+	Node
+	Type() string
+	setType(string)
+}
+
+type stmtBase struct {
+	Span
+	src antlr.ParserRuleContext
+}
+
+func (stmtBase) stmtNode() {}
+
+func (s *stmtBase) setSource(ctx antlr.ParserRuleContext) {
+	s.src = ctx
+}
+
+func (s *stmtBase) source() antlr.ParserRuleContext {
+	return s.src
+}
+
+type exprBase struct {
+	Span
+	typ string
+	src antlr.ParserRuleContext
+}
+
+func (e *exprBase) setSource(ctx antlr.ParserRuleContext) {
+	e.src = ctx
+}
+
+func (e *exprBase) source() antlr.ParserRuleContext {
+	return e.src
+}
+
+func (e *exprBase) Type() string {
+	return e.typ
+}
+
+func (e *exprBase) setType(t string) {
+	e.typ = t
+}
+
+// sourced es un nodo que recuerda el contexto del parse tree del que salió.
+type sourced interface {
+	setSource(antlr.ParserRuleContext)
+	source() antlr.ParserRuleContext
+}
+
+// Text devuelve el código del que salió node tal como lo devuelve GetText en
+// el parse tree, sin espacios ni comentarios. El intérprete lo usa para citar
+// en sus errores las construcciones que no ejecuta.
+func Text(node Node) string {
+	if src, ok := node.(sourced); ok && src.source() != nil {
+		return src.source().GetText()
+	}
+	return ""
+}
+
+// === TIPOS ===
+
+// TypeRef es una anotación de tipo escrita en el código: int, []float, [][]bool
+// o el nombre de un struct.
+type TypeRef struct {
+	Span
+	Name string // tipo base
+	Dims int    // 0 escalar, 1 vector, 2 matriz
+}
+
+// String devuelve el nombre canónico del tipo tal como lo maneja el
+// intérprete ("[]int" para vectores y "[[]]int" para matrices).
+func (t *TypeRef) String() string {
+	if t == nil {
+		return ""
+	}
+	switch t.Dims {
+	case 1:
+		return "[]" + t.Name
+	case 2:
+		return "[[]]" + t.Name
+	}
+	return t.Name
+}
+
+// Syntax devuelve el tipo tal como se escribe en el código fuente.
+func (t *TypeRef) Syntax() string {
+	if t == nil {
+		return ""
+	}
+	return strings.Repeat("[]", t.Dims) + t.Name
+}
+
+// === SENTENCIAS ===
+
+// Program es la raíz del árbol.
+type Program struct {
+	Span
+	Stmts []Stmt
+
+	nodes map[antlr.ParserRuleContext]Node
+}
+
+// NodeOf devuelve el nodo que se generó a partir de un contexto del parse
+// tree. Sirve a los backends que todavía recorren el árbol de ANTLR para
+// consultar la información del árbol tipado.
+func (p *Program) NodeOf(ctx antlr.ParserRuleContext) Node {
+	if p == nil || ctx == nil {
+		return nil
+	}
+	return p.nodes[ctx]
+}
+
+// TypeOf devuelve el tipo estático de la expresión que se generó a partir de
+// ctx, o "" si no es una expresión o su tipo es desconocido.
+func (p *Program) TypeOf(ctx antlr.ParserRuleContext) string {
+	if expr, ok := p.NodeOf(ctx).(Expr); ok {
+		return expr.Type()
+	}
+	return ""
+}
+
+// DeclKind distingue las formas de declaración de variables de la gramática.
+type DeclKind int
+
+const (
+	DeclMutTyped    DeclKind = iota // mut x int = 5
+	DeclMutInferred                 // mut x = 5
+	DeclMutZero                     // mut x []int
+	DeclTyped                       // x int = 5
+	DeclVector                      // x = []int{1, 2}
+	DeclMatrix                      // x = [][]int{{1}, {2}}
+)
+
+// VarDecl declara una variable. TypeRef es nil cuando el tipo se infiere del
+// valor y Value es nil cuando la variable toma el valor por defecto.
+type VarDecl struct {
+	stmtBase
+	Kind    DeclKind
+	Name    *Ident
+	TypeRef *TypeRef
+	Value   Expr
+}
+
+// Mutable indica si la declaración usa mut.
+func (d *VarDecl) Mutable() bool {
+	return d.Kind == DeclMutTyped || d.Kind == DeclMutInferred || d.Kind == DeclMutZero
+}
+
+// AssignStmt asigna Value a Target con Op "=", "+=" o "-=". Target es un
+// Ident, un SelectorExpr o un IndexExpr.
+type AssignStmt struct {
+	stmtBase
+	Op     string
+	Target Expr
+	Value  Expr
+}
+
+// BlockStmt es un bloque { ... } que abre un nuevo scope.
+type BlockStmt struct {
+	stmtBase
+	Stmts []Stmt
+}
+
+// ExprStmt es una expresión usada como sentencia (llamadas a función).
+type ExprStmt struct {
+	stmtBase
+	X Expr
+}
+
+// ReturnStmt regresa de una función; Value es nil en un return vacío.
+type ReturnStmt struct {
+	stmtBase
+	Value Expr
+}
+
+type BreakStmt struct {
+	stmtBase
+}
+
+type ContinueStmt struct {
+	stmtBase
+}
+
+// IfBranch es un if o un else if de la cadena.
+type IfBranch struct {
+	Span
+	Cond Expr
+	Body []Stmt
+}
+
+// IfStmt es una cadena if / else if / else. Else es nil si no hay else.
+type IfStmt struct {
+	stmtBase
+	Branches []*IfBranch
+	Else     *BlockStmt
+}
+
+// CaseClause es un case del switch; en el default Value es nil.
+type CaseClause struct {
+	Span
+	Value Expr
+	Body  []Stmt
+}
+
+type SwitchStmt struct {
+	stmtBase
+	Tag     Expr
+	Cases   []*CaseClause
+	Default *CaseClause
+}
+
+type WhileStmt struct {
+	stmtBase
+	Cond Expr
+	Body []Stmt
+}
+
+// ForCondStmt es un for con solo condición: for cond { ... }
+type ForCondStmt struct {
+	stmtBase
+	Cond Expr
+	Body []Stmt
+}
+
+// ForClauseStmt es un for de tres partes: for init; cond; post { ... }
+type ForClauseStmt struct {
+	stmtBase
+	Init *AssignStmt
+	Cond Expr
+	Post Expr
+	Body []Stmt
+}
+
+// ForRange recorre X: for index, value in X { ... }
+type ForRange struct {
+	stmtBase
+	Index *Ident
+	Value *Ident
+	X     Expr
+	Body  []Stmt
+}
+
+// Param es un parámetro de función.
+type Param struct {
+	Span
+	Name *Ident
+	Type *TypeRef
+}
+
+// FuncDecl declara una función; Result es nil si no retorna valor.
+type FuncDecl struct {
+	stmtBase
+	Name   *Ident
+	Params []*Param
+	Result *TypeRef
+	Body   []Stmt
+}
+
+// Field es un atributo de un struct.
+type Field struct {
+	Span
+	Type *TypeRef
+	Name *Ident
+}
+
+type StructDecl struct {
+	stmtBase
+	Name   *Ident
+	Fields []*Field
+}
+
+// === EXPRESIONES ===
+
+// Ident es un nombre. En los usos, Decl apunta al nodo que lo declara
+// (*VarDecl, *Param, *ForRange, *FuncDecl o *StructDecl) una vez resueltos
+// los scopes.
+type Ident struct {
+	exprBase
+	Name string
+	Decl Node
+}
+
+// SelectorExpr accede a un atributo o método: X.Sel
+type SelectorExpr struct {
+	exprBase
+	X   Expr
+	Sel *Ident
+}
+
+// PathName devuelve el acceso a.b.c que forman un identificador y sus
+// selectores. ok es false si la cadena no parte de un identificador, como
+// en v[0].count.
+func PathName(expr Expr) (name string, ok bool) {
+	switch e := expr.(type) {
+	case *Ident:
+		return e.Name, true
+	case *SelectorExpr:
+		if base, ok := PathName(e.X); ok {
+			return base + "." + e.Sel.Name, true
+		}
+	}
+	return "", false
+}
+
+// IndexExpr accede a una posición: X[Index]
+type IndexExpr struct {
+	exprBase
+	X     Expr
+	Index Expr
+}
+
+// Arg es un argumento de llamada, con etiqueta opcional (v.remove(at 0)).
+type Arg struct {
+	Span
+	Label string
+	Value Expr
+}
+
+// CallExpr llama a Fun, que es un Ident o un SelectorExpr para métodos.
+type CallExpr struct {
+	exprBase
+	Fun  Expr
+	Args []*Arg
+}
+
+type IntLit struct {
+	exprBase
+	Raw   string
+	Value int
+}
+
+type FloatLit struct {
+	exprBase
+	Raw   string
+	Value float64
+}
+
+// StringPart es un fragmento de un string: texto literal o una variable
+// interpolada con $name o ${name}.
+type StringPart struct {
+	Text string
+	Var  *Ident
+}
+
+// StringLit es un literal de cadena. Value ya tiene resueltas las secuencias
+// de escape; Parts separa el texto de las variables interpoladas.
+type StringLit struct {
+	exprBase
+	Raw   string
+	Value string
+	Parts []*StringPart
+}
+
+// Interpolated indica si el literal contiene variables interpoladas.
+func (s *StringLit) Interpolated() bool {
+	for _, part := range s.Parts {
+		if part.Var != nil {
+			return true
+		}
+	}
+	return false
+}
+
+type BoolLit struct {
+	exprBase
+	Value bool
+}
+
+type NilLit struct {
+	exprBase
+}
+
+// UnaryExpr es !x o -x; OpPos es la posición del operador, donde el
+// intérprete reporta sus errores.
+type UnaryExpr struct {
+	exprBase
+	Op    string
+	OpPos Pos
+	X     Expr
+}
+
+type BinaryExpr struct {
+	exprBase
+	Op    string
+	OpPos Pos
+	Left  Expr
+	Right Expr
+}
+
+type ParenExpr struct {
+	exprBase
+	X Expr
+}
+
+// IncDecExpr es i++ o i--.
+type IncDecExpr struct {
+	exprBase
+	Op string
+	X  *Ident
+}
+
+// VectorLit es { a, b, c }; TypeRef solo existe en declaraciones []int{...}.
+// Lbrace es la posición de la llave de apertura, donde el intérprete reporta
+// los errores del literal aunque el span empiece en el tipo.
+type VectorLit struct {
+	exprBase
+	TypeRef *TypeRef
+	Lbrace  Pos
+	Elems   []Expr
+}
+
+// MatrixLit es { {a, b}, {c, d} } con su tipo [][]T.
+type MatrixLit struct {
+	exprBase
+	TypeRef *TypeRef
+	Lbrace  Pos
+	Rows    []*VectorLit
+}
+
+// RepeatingExpr es []int(repeating: 0, count: 5).
+type RepeatingExpr struct {
+	exprBase
+	TypeRef *TypeRef
+	Args    []*Arg
+}
+
+// FieldInit inicializa un atributo en una instancia de struct.
+type FieldInit struct {
+	Span
+	Name  *Ident
+	Value Expr
+}
+
+// StructLit es Nombre{ attr: valor, ... }
+type StructLit struct {
+	exprBase
+	Name   *Ident
+	Fields []*FieldInit
+}
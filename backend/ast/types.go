@@ -0,0 +1,480 @@
+package ast
+
+import (
+	"maps"
+	"strings"
+
+	"main.go/value"
+)
+
+// builtinResults es el tipo que retorna cada función nativa del intérprete.
+// append retorna el tipo de su primer argumento, por eso no aparece aquí.
+var builtinResults = map[string]string{
+	"print":      value.IVOR_NIL,
+	"println":    value.IVOR_NIL,
+	"atoi":       value.IVOR_INT,
+	"parseFloat": value.IVOR_FLOAT,
+	"TypeOf":     value.IVOR_STRING,
+	"indexOf":    value.IVOR_INT,
+	"join":       value.IVOR_STRING,
+	"len":        value.IVOR_INT,
+	"assert":     value.IVOR_NIL,
+	"assertEq":   value.IVOR_NIL,
+	"assertNear": value.IVOR_NIL,
+}
+
+// sampleValue devuelve un valor de muestra de un tipo primitivo, o nil si el
+// tipo no es primitivo. Los valores evitan casos especiales de las
+// estrategias (por ejemplo, la división entre cero).
+func sampleValue(t string) value.IVOR {
+	switch t {
+	case value.IVOR_INT:
+		return &value.IntValue{InternalValue: 1}
+	case value.IVOR_FLOAT:
+		return &value.FloatValue{InternalValue: 1}
+	case value.IVOR_STRING:
+		return &value.StringValue{InternalValue: "a"}
+	case value.IVOR_CHARACTER:
+		return &value.CharacterValue{InternalValue: "a"}
+	case value.IVOR_BOOL:
+		return &value.BoolValue{InternalValue: true}
+	case value.IVOR_NIL:
+		return value.DefaultNilValue
+	}
+	return nil
+}
+
+// BinaryResultType devuelve el tipo que produce el intérprete al aplicar op
+// sobre operandos de tipo left y right, o el mensaje de error que reportaría.
+// El resultado se obtiene evaluando las mismas estrategias que usa el
+// intérprete, así que ambos no pueden discrepar. Si algún tipo es
+// desconocido o no es primitivo, devuelve "" sin mensaje.
+func BinaryResultType(op, left, right string) (string, string) {
+	strat, ok := value.BinaryStrats[op]
+	leftValue, rightValue := sampleValue(left), sampleValue(right)
+	if !ok || leftValue == nil || rightValue == nil {
+		return "", ""
+	}
+
+	ok, msg, result := strat.Validate(leftValue, rightValue)
+	if !ok {
+		return "", msg
+	}
+	return result.Type(), ""
+}
+
+// UnaryResultType es el equivalente de BinaryResultType para ! y -.
+func UnaryResultType(op, operand string) (string, string) {
+	strat, ok := value.UnaryStrats[op]
+	operandValue := sampleValue(operand)
+	if !ok || operandValue == nil {
+		return "", ""
+	}
+
+	ok, msg, result := strat.Validate(operandValue)
+	if !ok {
+		return "", msg
+	}
+	return result.Type(), ""
+}
+
+// ElemType devuelve el tipo de un elemento al indexar un valor de tipo t:
+// []int -> int y [[]]int -> []int.
+func ElemType(t string) string {
+	switch {
+	case strings.HasPrefix(t, "[[]]"):
+		return "[]" + t[len("[[]]"):]
+	case strings.HasPrefix(t, "[]"):
+		return t[len("[]"):]
+	}
+	return ""
+}
+
+// === INFERENCIA ===
+
+type binding struct {
+	decl Node
+	typ  string
+}
+
+type typeScope struct {
+	parent *typeScope
+	names  map[string]binding
+}
+
+func (s *typeScope) lookup(name string) (binding, bool) {
+	for scope := s; scope != nil; scope = scope.parent {
+		if b, ok := scope.names[name]; ok {
+			return b, true
+		}
+	}
+	return binding{}, false
+}
+
+type inferrer struct {
+	global  *typeScope
+	scope   *typeScope
+	funcs   map[string]*FuncDecl
+	structs map[string]*StructDecl
+}
+
+// Env guarda las declaraciones globales de programas ya analizados. Un
+// programa bajado con Env.Lower las ve como si estuvieran escritas antes
+// que él; así se analiza cada entrada de una sesión sin volver a recorrer
+// las anteriores.
+type Env struct {
+	names   map[string]binding
+	funcs   map[string]*FuncDecl
+	structs map[string]*StructDecl
+}
+
+func NewEnv() *Env {
+	return &Env{
+		names:   make(map[string]binding),
+		funcs:   make(map[string]*FuncDecl),
+		structs: make(map[string]*StructDecl),
+	}
+}
+
+// Declare agrega a env las variables, funciones y structs globales de
+// program, para los programas que se bajen después.
+func (e *Env) Declare(program *Program) {
+	for _, stmt := range program.Stmts {
+		switch decl := stmt.(type) {
+		case *VarDecl:
+			e.names[decl.Name.Name] = binding{decl: decl, typ: decl.Name.Type()}
+		case *FuncDecl:
+			e.funcs[decl.Name.Name] = decl
+		case *StructDecl:
+			e.structs[decl.Name.Name] = decl
+		}
+	}
+}
+
+// inferTypes resuelve los nombres y asigna el tipo estático de cada
+// expresión del programa. Las declaraciones de env son visibles, pero env
+// no cambia.
+func inferTypes(program *Program, env *Env) {
+	global := &typeScope{names: maps.Clone(env.names)}
+	in := &inferrer{
+		global:  global,
+		scope:   global,
+		funcs:   maps.Clone(env.funcs),
+		structs: maps.Clone(env.structs),
+	}
+
+	for _, stmt := range program.Stmts {
+		switch decl := stmt.(type) {
+		case *FuncDecl:
+			in.funcs[decl.Name.Name] = decl
+		case *StructDecl:
+			in.structs[decl.Name.Name] = decl
+		}
+	}
+
+	for _, stmt := range program.Stmts {
+		if _, ok := stmt.(*FuncDecl); !ok {
+			in.stmt(stmt)
+		}
+	}
+
+	// las funciones se declaran en el scope global, así que ven todas las
+	// variables globales sin importar dónde aparezcan
+	for _, stmt := range program.Stmts {
+		if decl, ok := stmt.(*FuncDecl); ok {
+			in.funcDecl(decl)
+		}
+	}
+}
+
+func (in *inferrer) push() {
+	in.scope = &typeScope{parent: in.scope, names: make(map[string]binding)}
+}
+
+func (in *inferrer) pop() {
+	in.scope = in.scope.parent
+}
+
+func (in *inferrer) declare(id *Ident, decl Node, typ string) {
+	if id == nil {
+		return
+	}
+	id.Decl = decl
+	id.setType(typ)
+	in.scope.names[id.Name] = binding{decl: decl, typ: typ}
+}
+
+func (in *inferrer) body(stmts []Stmt) {
+	in.push()
+	for _, stmt := range stmts {
+		in.stmt(stmt)
+	}
+	in.pop()
+}
+
+func (in *inferrer) stmt(stmt Stmt) {
+	switch s := stmt.(type) {
+	case *VarDecl:
+		typ := ""
+		if s.Value != nil {
+			typ = in.expr(s.Value)
+		}
+		if s.TypeRef != nil {
+			typ = s.TypeRef.String()
+		}
+		in.declare(s.Name, s, typ)
+
+	case *AssignStmt:
+		in.expr(s.Target)
+		in.expr(s.Value)
+
+	case *BlockStmt:
+		in.body(s.Stmts)
+
+	case *ExprStmt:
+		in.expr(s.X)
+
+	case *ReturnStmt:
+		in.expr(s.Value)
+
+	case *IfStmt:
+		for _, branch := range s.Branches {
+			in.expr(branch.Cond)
+			in.body(branch.Body)
+		}
+		if s.Else != nil {
+			in.body(s.Else.Stmts)
+		}
+
+	case *SwitchStmt:
+		in.expr(s.Tag)
+		for _, clause := range s.Cases {
+			in.expr(clause.Value)
+			in.body(clause.Body)
+		}
+		if s.Default != nil {
+			in.body(s.Default.Body)
+		}
+
+	case *WhileStmt:
+		in.expr(s.Cond)
+		in.body(s.Body)
+
+	case *ForCondStmt:
+		in.expr(s.Cond)
+		in.body(s.Body)
+
+	case *ForClauseStmt:
+		in.push()
+		if s.Init != nil {
+			in.stmt(s.Init)
+		}
+		in.expr(s.Cond)
+		in.expr(s.Post)
+		in.body(s.Body)
+		in.pop()
+
+	case *ForRange:
+		elem := ElemType(in.expr(s.X))
+		if elem == "" && in.exprType(s.X) == value.IVOR_STRING {
+			elem = value.IVOR_CHARACTER
+		}
+		in.push()
+		in.declare(s.Index, s, value.IVOR_INT)
+		in.declare(s.Value, s, elem)
+		in.body(s.Body)
+		in.pop()
+
+	case *FuncDecl:
+		in.funcDecl(s)
+
+	case *StructDecl:
+		s.Name.Decl = s
+	}
+}
+
+func (in *inferrer) funcDecl(decl *FuncDecl) {
+	decl.Name.Decl = decl
+	decl.Name.setType(value.IVOR_FUNCTION)
+
+	saved := in.scope
+	in.scope = &typeScope{parent: in.global, names: make(map[string]binding)}
+	for _, param := range decl.Params {
+		in.declare(param.Name, param, param.Type.String())
+	}
+	for _, stmt := range decl.Body {
+		in.stmt(stmt)
+	}
+	in.scope = saved
+}
+
+func (in *inferrer) exprType(expr Expr) string {
+	if expr == nil {
+		return ""
+	}
+	return expr.Type()
+}
+
+// expr asigna el tipo de expr y de todas sus subexpresiones, y lo devuelve.
+func (in *inferrer) expr(expr Expr) string {
+	if expr == nil {
+		return ""
+	}
+
+	typ := ""
+
+	switch e := expr.(type) {
+	case *Ident:
+		if b, ok := in.scope.lookup(e.Name); ok {
+			e.Decl = b.decl
+			typ = b.typ
+		} else if decl, ok := in.funcs[e.Name]; ok {
+			e.Decl = decl
+			typ = value.IVOR_FUNCTION
+		}
+
+	case *SelectorExpr:
+		typ = in.selector(e)
+
+	case *IndexExpr:
+		typ = ElemType(in.expr(e.X))
+		in.expr(e.Index)
+
+	case *CallExpr:
+		typ = in.call(e)
+
+	case *IntLit:
+		typ = value.IVOR_INT
+
+	case *FloatLit:
+		typ = value.IVOR_FLOAT
+
+	case *StringLit:
+		typ = value.IVOR_STRING
+		for _, part := range e.Parts {
+			if part.Var != nil {
+				in.expr(part.Var)
+			}
+		}
+		// el intérprete convierte los literales de un solo carácter en rune
+		if !e.Interpolated() && len(e.Value) == 1 {
+			typ = value.IVOR_CHARACTER
+		}
+
+	case *BoolLit:
+		typ = value.IVOR_BOOL
+
+	case *NilLit:
+		typ = value.IVOR_NIL
+
+	case *UnaryExpr:
+		typ, _ = UnaryResultType(e.Op, in.expr(e.X))
+
+	case *BinaryExpr:
+		typ, _ = BinaryResultType(e.Op, in.expr(e.Left), in.expr(e.Right))
+
+	case *ParenExpr:
+		typ = in.expr(e.X)
+
+	case *IncDecExpr:
+		typ = in.expr(e.X)
+
+	case *VectorLit:
+		for _, elem := range e.Elems {
+			in.expr(elem)
+		}
+		switch {
+		case e.TypeRef != nil:
+			typ = e.TypeRef.String()
+		case len(e.Elems) == 0:
+			typ = "[]"
+		case e.Elems[0].Type() != "":
+			typ = "[]" + e.Elems[0].Type()
+		}
+
+	case *MatrixLit:
+		for _, row := range e.Rows {
+			in.expr(row)
+		}
+		if e.TypeRef != nil {
+			typ = e.TypeRef.String()
+		}
+
+	case *RepeatingExpr:
+		for _, arg := range e.Args {
+			in.expr(arg.Value)
+		}
+		typ = e.TypeRef.String()
+
+	case *StructLit:
+		for _, field := range e.Fields {
+			in.expr(field.Value)
+		}
+		if decl, ok := in.structs[e.Name.Name]; ok {
+			e.Name.Decl = decl
+			typ = e.Name.Name
+		}
+	}
+
+	expr.setType(typ)
+	return typ
+}
+
+func (in *inferrer) selector(e *SelectorExpr) string {
+	base := in.expr(e.X)
+
+	if decl, ok := in.structs[base]; ok {
+		for _, field := range decl.Fields {
+			if field.Name.Name == e.Sel.Name {
+				e.Sel.Decl = field
+				e.Sel.setType(field.Type.String())
+				return field.Type.String()
+			}
+		}
+		return ""
+	}
+
+	if ElemType(base) != "" {
+		switch e.Sel.Name {
+		case "count":
+			e.Sel.setType(value.IVOR_INT)
+			return value.IVOR_INT
+		case "isEmpty":
+			e.Sel.setType(value.IVOR_BOOL)
+			return value.IVOR_BOOL
+		}
+	}
+
+	return ""
+}
+
+func (in *inferrer) call(e *CallExpr) string {
+	argTypes := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		argTypes[i] = in.expr(arg.Value)
+	}
+
+	id, ok := e.Fun.(*Ident)
+	if !ok {
+		// métodos de vectores y structs: solo se resuelve el receptor
+		if sel, ok := e.Fun.(*SelectorExpr); ok {
+			in.expr(sel.X)
+		}
+		return ""
+	}
+
+	if decl, ok := in.funcs[id.Name]; ok {
+		id.Decl = decl
+		id.setType(value.IVOR_FUNCTION)
+		if decl.Result == nil {
+			return value.IVOR_NIL
+		}
+		return decl.Result.String()
+	}
+
+	if id.Name == "append" && len(argTypes) > 0 {
+		return argTypes[0]
+	}
+
+	return builtinResults[id.Name]
+}
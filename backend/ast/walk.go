@@ -0,0 +1,158 @@
+package ast
+
+// Children devuelve los hijos directos de un nodo en el orden en que
+// aparecen en el código fuente.
+func Children(node Node) []Node {
+	var list []Node
+
+	add := func(children ...Node) {
+		for _, child := range children {
+			if child != nil && !isNilNode(child) {
+				list = append(list, child)
+			}
+		}
+	}
+	addStmts := func(stmts []Stmt) {
+		for _, stmt := range stmts {
+			add(stmt)
+		}
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		addStmts(n.Stmts)
+	case *VarDecl:
+		add(n.Name, n.TypeRef, n.Value)
+	case *AssignStmt:
+		add(n.Target, n.Value)
+	case *BlockStmt:
+		addStmts(n.Stmts)
+	case *ExprStmt:
+		add(n.X)
+	case *ReturnStmt:
+		add(n.Value)
+	case *IfStmt:
+		for _, branch := range n.Branches {
+			add(branch)
+		}
+		add(n.Else)
+	case *IfBranch:
+		add(n.Cond)
+		addStmts(n.Body)
+	case *SwitchStmt:
+		add(n.Tag)
+		for _, clause := range n.Cases {
+			add(clause)
+		}
+		add(n.Default)
+	case *CaseClause:
+		add(n.Value)
+		addStmts(n.Body)
+	case *WhileStmt:
+		add(n.Cond)
+		addStmts(n.Body)
+	case *ForCondStmt:
+		add(n.Cond)
+		addStmts(n.Body)
+	case *ForClauseStmt:
+		add(n.Init, n.Cond, n.Post)
+		addStmts(n.Body)
+	case *ForRange:
+		add(n.Index, n.Value, n.X)
+		addStmts(n.Body)
+	case *FuncDecl:
+		add(n.Name)
+		for _, param := range n.Params {
+			add(param)
+		}
+		add(n.Result)
+		addStmts(n.Body)
+	case *Param:
+		add(n.Name, n.Type)
+	case *StructDecl:
+		add(n.Name)
+		for _, field := range n.Fields {
+			add(field)
+		}
+	case *Field:
+		add(n.Type, n.Name)
+	case *SelectorExpr:
+		add(n.X, n.Sel)
+	case *IndexExpr:
+		add(n.X, n.Index)
+	case *CallExpr:
+		add(n.Fun)
+		for _, arg := range n.Args {
+			add(arg)
+		}
+	case *Arg:
+		add(n.Value)
+	case *StringLit:
+		for _, part := range n.Parts {
+			add(part.Var)
+		}
+	case *UnaryExpr:
+		add(n.X)
+	case *BinaryExpr:
+		add(n.Left, n.Right)
+	case *ParenExpr:
+		add(n.X)
+	case *IncDecExpr:
+		add(n.X)
+	case *VectorLit:
+		add(n.TypeRef)
+		for _, elem := range n.Elems {
+			add(elem)
+		}
+	case *MatrixLit:
+		add(n.TypeRef)
+		for _, row := range n.Rows {
+			add(row)
+		}
+	case *RepeatingExpr:
+		add(n.TypeRef)
+		for _, arg := range n.Args {
+			add(arg)
+		}
+	case *StructLit:
+		add(n.Name)
+		for _, field := range n.Fields {
+			add(field)
+		}
+	case *FieldInit:
+		add(n.Name, n.Value)
+	}
+
+	return list
+}
+
+// Inspect recorre el árbol en profundidad llamando a f con cada nodo. Si f
+// devuelve false no se visitan los hijos de ese nodo.
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || isNilNode(node) || !f(node) {
+		return
+	}
+	for _, child := range Children(node) {
+		Inspect(child, f)
+	}
+}
+
+// isNilNode detecta punteros nil guardados en la interfaz Node, como un
+// *BlockStmt vacío en IfStmt.Else.
+func isNilNode(node Node) bool {
+	switch n := node.(type) {
+	case *Ident:
+		return n == nil
+	case *TypeRef:
+		return n == nil
+	case *BlockStmt:
+		return n == nil
+	case *CaseClause:
+		return n == nil
+	case *AssignStmt:
+		return n == nil
+	case *VectorLit:
+		return n == nil
+	}
+	return false
+}
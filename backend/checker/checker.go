@@ -0,0 +1,608 @@
+package checker
+
+import (
+	"fmt"
+	"maps"
+
+	"main.go/ast"
+	"main.go/repl"
+	"main.go/value"
+)
+
+// Checker valida el programa completo sobre el árbol tipado antes de
+// ejecutarlo. Reporta en la tabla de errores los mismos mensajes que el
+// intérprete daría al llegar a cada línea, pero sin depender de que esa
+// línea se ejecute. Solo reporta cuando los tipos involucrados se conocen
+// con certeza; lo que la inferencia no pudo resolver queda para el
+// intérprete.
+type Checker struct {
+	ErrorTable *repl.ErrorTable
+
+	scope    *scope
+	function *ast.FuncDecl
+	loops    int
+	switches int
+	funcs    map[string]*ast.FuncDecl
+	structs  map[string]*ast.StructDecl
+	globals  map[string]bool // variables globales de los programas pasados a Declare
+	errors   int
+}
+
+// scope guarda los nombres declarados en cada bloque para detectar
+// redeclaraciones en el mismo ámbito.
+type scope struct {
+	parent *scope
+	names  map[string]bool
+}
+
+func NewChecker(errorTable *repl.ErrorTable) *Checker {
+	return &Checker{
+		ErrorTable: errorTable,
+		funcs:      make(map[string]*ast.FuncDecl),
+		structs:    make(map[string]*ast.StructDecl),
+		globals:    make(map[string]bool),
+	}
+}
+
+// Check recorre el programa y devuelve true si no encontró errores. Las
+// declaraciones de los programas pasados a Declare son visibles, como en
+// una sesión donde cada entrada continúa a las anteriores.
+func (c *Checker) Check(program *ast.Program) bool {
+	if program == nil {
+		return true
+	}
+
+	funcs, structs := c.funcs, c.structs
+	c.funcs, c.structs = maps.Clone(funcs), maps.Clone(structs)
+	defer func() { c.funcs, c.structs = funcs, structs }()
+
+	c.errors = 0
+	c.scope = &scope{names: maps.Clone(c.globals)}
+	global := c.scope
+
+	for _, stmt := range program.Stmts {
+		switch decl := stmt.(type) {
+		case *ast.FuncDecl:
+			c.funcs[decl.Name.Name] = decl
+		case *ast.StructDecl:
+			c.structs[decl.Name.Name] = decl
+		}
+	}
+
+	for _, stmt := range program.Stmts {
+		if _, ok := stmt.(*ast.FuncDecl); !ok {
+			c.stmt(stmt)
+		}
+	}
+
+	// igual que en el intérprete, el scope de cada función es hijo del global
+	for _, stmt := range program.Stmts {
+		if decl, ok := stmt.(*ast.FuncDecl); ok {
+			c.scope = global
+			c.funcDecl(decl)
+		}
+	}
+	c.scope = global
+
+	return c.errors == 0
+}
+
+// Declare agrega las variables, funciones y structs globales de program a
+// las que ven los próximos Check.
+func (c *Checker) Declare(program *ast.Program) {
+	for _, stmt := range program.Stmts {
+		switch decl := stmt.(type) {
+		case *ast.VarDecl:
+			c.globals[decl.Name.Name] = true
+		case *ast.FuncDecl:
+			c.funcs[decl.Name.Name] = decl
+		case *ast.StructDecl:
+			c.structs[decl.Name.Name] = decl
+		}
+	}
+}
+
+func (c *Checker) report(pos ast.Pos, msg string) {
+	c.errors++
+	c.ErrorTable.AddError(pos.Line, pos.Column, msg, repl.SemanticError)
+}
+
+func (c *Checker) push() {
+	c.scope = &scope{parent: c.scope, names: make(map[string]bool)}
+}
+
+func (c *Checker) pop() {
+	c.scope = c.scope.parent
+}
+
+func (c *Checker) body(stmts []ast.Stmt) {
+	c.push()
+	for _, stmt := range stmts {
+		c.stmt(stmt)
+	}
+	c.pop()
+}
+
+// === SENTENCIAS ===
+
+func (c *Checker) stmt(stmt ast.Stmt) {
+	switch s := stmt.(type) {
+	case *ast.VarDecl:
+		c.varDecl(s)
+
+	case *ast.AssignStmt:
+		c.assign(s)
+
+	case *ast.BlockStmt:
+		c.body(s.Stmts)
+
+	case *ast.ExprStmt:
+		c.expr(s.X)
+
+	case *ast.ReturnStmt:
+		c.returnStmt(s)
+
+	case *ast.BreakStmt:
+		if c.loops == 0 && c.switches == 0 {
+			c.report(s.Start, "La sentencia break debe estar dentro de un ciclo o un switch")
+		}
+
+	case *ast.ContinueStmt:
+		if c.loops == 0 {
+			c.report(s.Start, "La sentencia continue debe estar dentro de un ciclo")
+		}
+
+	case *ast.IfStmt:
+		for _, branch := range s.Branches {
+			c.expr(branch.Cond)
+			if !isType(branch.Cond, value.IVOR_BOOL) {
+				c.report(branch.Start, "La condicion del if debe ser un booleano")
+			}
+			c.body(branch.Body)
+		}
+		if s.Else != nil {
+			c.body(s.Else.Stmts)
+		}
+
+	case *ast.SwitchStmt:
+		c.expr(s.Tag)
+		c.switches++
+		for _, clause := range s.Cases {
+			c.expr(clause.Value)
+			c.body(clause.Body)
+		}
+		if s.Default != nil {
+			c.body(s.Default.Body)
+		}
+		c.switches--
+
+	case *ast.WhileStmt:
+		c.expr(s.Cond)
+		if !isType(s.Cond, value.IVOR_BOOL) {
+			c.report(s.Start, "La condición del while debe ser un booleano")
+		}
+		c.loop(s.Body)
+
+	case *ast.ForCondStmt:
+		c.expr(s.Cond)
+		if !isType(s.Cond, value.IVOR_BOOL) {
+			c.report(s.Start, "La condición del for debe ser un booleano")
+		}
+		c.loop(s.Body)
+
+	case *ast.ForClauseStmt:
+		c.push()
+		if s.Init != nil {
+			c.assign(s.Init)
+		}
+		c.expr(s.Cond)
+		if !isType(s.Cond, value.IVOR_BOOL) {
+			c.report(s.Start, "La condición del for debe ser un booleano")
+		}
+		c.expr(s.Post)
+		c.loop(s.Body)
+		c.pop()
+
+	case *ast.ForRange:
+		c.expr(s.X)
+		if typ := s.X.Type(); typ != "" && !repl.IsVectorType(typ) && typ != value.IVOR_STRING {
+			c.report(s.Start, "El valor del for debe ser un vector o una cadena")
+		}
+		c.push()
+		c.scope.names[s.Index.Name] = true
+		if s.Value != nil {
+			c.scope.names[s.Value.Name] = true
+		}
+		c.loop(s.Body)
+		c.pop()
+
+	case *ast.FuncDecl:
+		// el DclVisitor ya reporta las funciones fuera del scope global
+	}
+}
+
+func (c *Checker) loop(stmts []ast.Stmt) {
+	c.loops++
+	c.body(stmts)
+	c.loops--
+}
+
+func (c *Checker) varDecl(s *ast.VarDecl) {
+	if s.Value != nil {
+		c.expr(s.Value)
+	}
+
+	if c.scope.names[s.Name.Name] {
+		c.report(s.Start, "La variable '"+s.Name.Name+"' ya existe en el ámbito actual")
+		return
+	}
+	c.scope.names[s.Name.Name] = true
+
+	if s.Value == nil {
+		return
+	}
+
+	if s.TypeRef == nil {
+		if s.Value.Type() == "[]" {
+			c.report(s.Start, "No se puede inferir el tipo de un vector vacio '"+s.Name.Name+"'")
+		}
+		return
+	}
+
+	if msg := assignMessage(s.TypeRef.String(), s.Value.Type()); msg != "" {
+		c.report(s.Start, msg)
+	}
+}
+
+func (c *Checker) assign(s *ast.AssignStmt) {
+	if s.Op == "=" {
+		c.expr(s.Target)
+	} else if !c.reference(s.Target, s.Start) {
+		c.expr(s.Value)
+		return
+	}
+	c.expr(s.Value)
+
+	id, ok := s.Target.(*ast.Ident)
+	if !ok {
+		return
+	}
+
+	valueType := s.Value.Type()
+	if s.Op != "=" {
+		typ, msg := ast.BinaryResultType(s.Op[:1], id.Type(), valueType)
+		if msg != "" {
+			c.report(s.Start, msg)
+			return
+		}
+		valueType = typ
+	}
+
+	if msg := assignMessage(id.Type(), valueType); msg != "" {
+		c.report(s.Start, msg)
+	}
+}
+
+func (c *Checker) returnStmt(s *ast.ReturnStmt) {
+	c.expr(s.Value)
+
+	if c.function == nil {
+		c.report(s.Start, "La sentencia return debe estar dentro de una funcion")
+		return
+	}
+
+	expected := value.IVOR_NIL
+	pos := s.Start
+	if c.function.Result != nil {
+		expected = c.function.Result.String()
+		pos = c.function.Result.Start
+	}
+
+	got := value.IVOR_NIL
+	if s.Value != nil {
+		got = s.Value.Type()
+	}
+
+	// el intérprete exige el tipo exacto, sin conversión implícita
+	if got != "" && got != expected {
+		c.report(pos, fmt.Sprintf("Tipo de retorno invalido, se esperaba %s, se obtuvo %s", expected, got))
+	}
+}
+
+func (c *Checker) funcDecl(decl *ast.FuncDecl) {
+	saved := c.function
+	c.function = decl
+
+	c.push()
+	for _, param := range decl.Params {
+		c.scope.names[param.Name.Name] = true
+	}
+	for _, stmt := range decl.Body {
+		c.stmt(stmt)
+	}
+	c.pop()
+
+	if decl.Result != nil && !terminates(decl.Body) {
+		c.report(decl.Result.Start, fmt.Sprintf("La funcion %s no retorna un valor de tipo %s en todos los caminos", decl.Name.Name, decl.Result.String()))
+	}
+
+	c.function = saved
+}
+
+// terminates indica si una lista de sentencias termina siempre con un
+// return, sin importar el camino que tome la ejecución. Basta con que una
+// de ellas retorne siempre: lo que sigue no se ejecuta.
+func terminates(stmts []ast.Stmt) bool {
+	for _, stmt := range stmts {
+		if alwaysReturns(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+// alwaysReturns indica si la sentencia retorna en todos sus caminos.
+func alwaysReturns(stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case *ast.ReturnStmt:
+		return s.Value != nil
+
+	case *ast.BlockStmt:
+		return terminates(s.Stmts)
+
+	case *ast.IfStmt:
+		if s.Else == nil || !terminates(s.Else.Stmts) {
+			return false
+		}
+		for _, branch := range s.Branches {
+			if !terminates(branch.Body) {
+				return false
+			}
+		}
+		return true
+
+	case *ast.SwitchStmt:
+		if s.Default == nil || !terminates(s.Default.Body) || breaks(s.Default.Body) {
+			return false
+		}
+		for _, clause := range s.Cases {
+			if !terminates(clause.Body) || breaks(clause.Body) {
+				return false
+			}
+		}
+		return true
+
+	// while true { ... } y for true { ... } sin break solo salen con return
+	case *ast.WhileStmt:
+		return isTrue(s.Cond) && !breaks(s.Body)
+
+	case *ast.ForCondStmt:
+		return isTrue(s.Cond) && !breaks(s.Body)
+	}
+
+	return false
+}
+
+// isTrue indica si la condición es el literal true, con o sin paréntesis.
+func isTrue(cond ast.Expr) bool {
+	for {
+		paren, ok := cond.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		cond = paren.X
+	}
+	lit, ok := cond.(*ast.BoolLit)
+	return ok && lit.Value
+}
+
+// breaks indica si el cuerpo contiene un break que lo abandona, sin contar
+// los que pertenecen a ciclos o switches anidados.
+func breaks(stmts []ast.Stmt) bool {
+	found := false
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(node ast.Node) bool {
+			switch node.(type) {
+			case *ast.BreakStmt:
+				found = true
+			case *ast.WhileStmt, *ast.ForCondStmt, *ast.ForClauseStmt, *ast.ForRange, *ast.SwitchStmt, *ast.FuncDecl:
+				return false
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// === EXPRESIONES ===
+
+func (c *Checker) expr(expr ast.Expr) {
+	if expr == nil {
+		return
+	}
+
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if e.Decl == nil && !c.isCallable(e.Name) {
+			c.report(e.Start, "Variable '"+e.Name+"' no encontrada")
+		}
+
+	case *ast.SelectorExpr:
+		c.expr(e.X)
+
+	case *ast.IndexExpr:
+		if !c.reference(e.X, e.Start) {
+			return
+		}
+		c.expr(e.Index)
+		if typ := e.Index.Type(); typ != "" && typ != value.IVOR_INT {
+			c.report(e.Start, "Los índices deben ser enteros")
+		}
+
+	case *ast.CallExpr:
+		c.call(e)
+
+	case *ast.StringLit:
+		for _, part := range e.Parts {
+			if part.Var != nil && part.Var.Decl == nil {
+				c.report(e.Start, fmt.Sprintf("Variable '%s' no encontrada en interpolación de string", part.Var.Name))
+			}
+		}
+
+	case *ast.UnaryExpr:
+		c.expr(e.X)
+		if _, msg := ast.UnaryResultType(e.Op, e.X.Type()); msg != "" {
+			c.report(e.OpPos, msg)
+		}
+
+	case *ast.BinaryExpr:
+		c.expr(e.Left)
+		c.expr(e.Right)
+		if _, msg := ast.BinaryResultType(e.Op, e.Left.Type(), e.Right.Type()); msg != "" {
+			c.report(e.OpPos, msg)
+		}
+
+	case *ast.ParenExpr:
+		c.expr(e.X)
+
+	case *ast.IncDecExpr:
+		c.expr(e.X)
+		if typ := e.X.Type(); typ != "" && typ != value.IVOR_INT {
+			c.report(e.Start, "El operador "+e.Op+" solo puede aplicarse a variables de tipo int")
+		}
+
+	case *ast.VectorLit:
+		for _, elem := range e.Elems {
+			c.expr(elem)
+		}
+		for _, elem := range e.Elems[min(1, len(e.Elems)):] {
+			first, typ := e.Elems[0].Type(), elem.Type()
+			if first != "" && typ != "" && first != typ {
+				c.report(e.Start, "Todos los items de la coleccion deben ser del mismo tipo")
+				break
+			}
+		}
+
+	case *ast.MatrixLit:
+		for _, row := range e.Rows {
+			c.expr(row)
+		}
+
+	case *ast.RepeatingExpr:
+		for _, arg := range e.Args {
+			c.expr(arg.Value)
+		}
+
+	case *ast.StructLit:
+		for _, field := range e.Fields {
+			c.expr(field.Value)
+		}
+	}
+}
+
+// reference revisa una expresión que el intérprete busca directamente como
+// variable (la base de un índice, un argumento, el destino de += y -=).
+// Esos casos usan un mensaje propio, reportado en pos. Devuelve false si la
+// variable no existe.
+func (c *Checker) reference(expr ast.Expr, pos ast.Pos) bool {
+	if id, ok := expr.(*ast.Ident); ok && id.Decl == nil && !c.isCallable(id.Name) {
+		c.report(pos, "Variable "+id.Name+" no encontrada")
+		return false
+	}
+	c.expr(expr)
+	return true
+}
+
+// isCallable indica si name es una función, una función nativa o un struct,
+// nombres que no se resuelven como variables.
+func (c *Checker) isCallable(name string) bool {
+	if _, ok := c.funcs[name]; ok {
+		return true
+	}
+	if _, ok := c.structs[name]; ok {
+		return true
+	}
+	_, ok := repl.DefaultBuiltInFunctions[name]
+	return ok
+}
+
+func (c *Checker) call(e *ast.CallExpr) {
+	for _, arg := range e.Args {
+		c.reference(arg.Value, arg.Start)
+	}
+
+	id, ok := e.Fun.(*ast.Ident)
+	if !ok {
+		if sel, ok := e.Fun.(*ast.SelectorExpr); ok {
+			c.expr(sel.X)
+		}
+		return
+	}
+
+	if !c.isCallable(id.Name) {
+		c.report(e.Start, "La funcion "+id.Name+" no existe")
+		return
+	}
+
+	decl, ok := c.funcs[id.Name]
+	if !ok {
+		return
+	}
+
+	if len(e.Args) != len(decl.Params) {
+		c.report(e.Start, "Numero de argumentos invalido")
+		return
+	}
+
+	for i, param := range decl.Params {
+		expected, got := param.Type.String(), e.Args[i].Value.Type()
+		if !compatible(expected, got) {
+			c.report(e.Start, fmt.Sprintf("Tipo de argumento %s invalido, esperado %s, recibido %s", param.Name.Name, expected, got))
+		}
+	}
+}
+
+// === COMPATIBILIDAD ===
+
+// known indica si el tipo es uno que el intérprete maneja con certeza: un
+// primitivo o un vector de primitivos.
+func known(typ string) bool {
+	return typ == value.IVOR_INT || typ == value.IVOR_FLOAT || typ == value.IVOR_STRING ||
+		typ == value.IVOR_CHARACTER || typ == value.IVOR_BOOL || typ == value.IVOR_NIL ||
+		repl.IsVectorType(typ)
+}
+
+// compatible replica value.ImplicitCast sobre tipos estáticos. Si alguno de
+// los tipos no se conoce se asume compatible.
+func compatible(target, typ string) bool {
+	if target == typ || !known(target) || !known(typ) {
+		return true
+	}
+	if target == value.IVOR_FLOAT && typ == value.IVOR_INT {
+		return true
+	}
+	return target == value.IVOR_STRING && typ == value.IVOR_CHARACTER
+}
+
+// assignMessage replica Variable.TypeValidation: devuelve el mensaje que
+// reportaría el intérprete al guardar un valor de tipo typ en una variable
+// de tipo target, o "" si la asignación es válida o no se puede decidir.
+func assignMessage(target, typ string) string {
+	if repl.IsVectorType(target) && repl.IsVectorType(typ) {
+		if repl.RemoveBrackets(target) != repl.RemoveBrackets(typ) {
+			return "Type mismatch: No se puede asignar un vector de tipo " + typ + " a una variable de tipo " + target
+		}
+		return ""
+	}
+	if repl.IsVectorType(target) && typ == "[]" {
+		return ""
+	}
+	if !compatible(target, typ) {
+		return "Type mismatch: No se puede asignar un valor de tipo " + typ + " a una variable de tipo " + target
+	}
+	return ""
+}
+
+func isType(expr ast.Expr, typ string) bool {
+	return expr == nil || expr.Type() == "" || expr.Type() == typ
+}
@@ -0,0 +1,89 @@
+package checker_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/ast"
+	"main.go/checker"
+	interpeter "main.go/grammar"
+	"main.go/repl"
+)
+
+// check corre el chequeo sobre code y devuelve los mensajes de error.
+func check(t *testing.T, code string) []string {
+	t.Helper()
+	lexer := interpeter.NewVLangLexer(antlr.NewInputStream(code))
+	parser := interpeter.NewVLangGrammar(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	tree := parser.Program()
+
+	errorTable := repl.NewErrorTable()
+	checker.NewChecker(errorTable).Check(ast.Lower(tree))
+
+	var messages []string
+	for _, err := range errorTable.Errors {
+		messages = append(messages, err.Msg)
+	}
+	return messages
+}
+
+// Programas sin errores: ninguno debe reportar nada.
+func TestCheckAccepts(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"return antes de otra sentencia", "fn f(x int) int {\n    return x\n    println(\"x\")\n}\nprintln(f(1))\n"},
+		{"if con else", "fn f(x int) int {\n    if x > 0 {\n        return 1\n    } else {\n        return 2\n    }\n}\nprintln(f(1))\n"},
+		{"if sin else seguido de return", "fn f(x int) int {\n    if x > 0 {\n        return 1\n    }\n    return 2\n}\nprintln(f(1))\n"},
+		{"while true", "fn f(x int) int {\n    while true {\n        return x\n    }\n}\nprintln(f(1))\n"},
+		{"for true", "fn f(x int) int {\n    for true {\n        return x\n    }\n}\nprintln(f(1))\n"},
+		{"bloque que retorna", "fn f(x int) int {\n    {\n        return x\n    }\n}\nprintln(f(1))\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := check(t, tt.code); len(errs) > 0 {
+				t.Errorf("errores inesperados: %v", errs)
+			}
+		})
+	}
+}
+
+// Cada programa tiene un error y el chequeo debe reportarlo.
+func TestCheckReports(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"sin return en todos los caminos", "fn f(x int) int {\n    if x > 0 {\n        return 1\n    }\n}\nprintln(f(1))\n", "no retorna un valor de tipo int en todos los caminos"},
+		{"while true con break", "fn f(x int) int {\n    while true {\n        break\n    }\n}\nprintln(f(1))\n", "no retorna un valor de tipo int en todos los caminos"},
+		{"while con condición", "fn f(x int) int {\n    while x > 0 {\n        return x\n    }\n}\nprintln(f(1))\n", "no retorna un valor de tipo int en todos los caminos"},
+		{"variable no declarada", "println(y)\n", "Variable y no encontrada"},
+		{"función no declarada", "g(1)\n", "La funcion g no existe"},
+		{"cantidad de argumentos", "fn f(x int) int {\n    return x\n}\nmut r = f(1, 2)\n", "Numero de argumentos invalido"},
+		{"tipo de argumento", "fn f(x int) int {\n    return x\n}\nmut r = f(\"ab\")\n", "Tipo de argumento x invalido, esperado int, recibido string"},
+		{"tipo de retorno", "fn f(x int) int {\n    return \"ab\"\n}\nprintln(f(1))\n", "Tipo de retorno invalido, se esperaba int, se obtuvo string"},
+		{"operandos incompatibles", "mut a = 1 + true\n", "No es posible realizar la operación '+' con los tipos 'int' y 'bool'"},
+		{"condición no booleana", "if 1 {\n    println(1)\n}\n", "La condicion del if debe ser un booleano"},
+		{"condición del while no booleana", "mut i = 0\nwhile i {\n    i = 1\n}\n", "La condición del while debe ser un booleano"},
+		{"declaración en una rama que no se ejecuta", "if false {\n    mut x int = \"a\"\n}\n", "No se puede asignar un valor de tipo rune a una variable de tipo int"},
+		{"asignación de otro tipo", "mut x = 1\nx = \"a\"\n", "No se puede asignar un valor de tipo rune a una variable de tipo int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := check(t, tt.code)
+			if len(errs) == 0 {
+				t.Fatal("no se reportó ningún error")
+			}
+			for _, err := range errs {
+				if strings.Contains(err, tt.want) {
+					return
+				}
+			}
+			t.Errorf("se esperaba un error con %q, se obtuvo %v", tt.want, errs)
+		})
+	}
+}
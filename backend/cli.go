@@ -0,0 +1,681 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"main.go/analysis"
+	"main.go/ast"
+	"main.go/compiler"
+	"main.go/compiler/ir"
+	"main.go/dap"
+	"main.go/difftest"
+	"main.go/format"
+	"main.go/lint"
+	"main.go/logging"
+	"main.go/lsp"
+	"main.go/repl"
+	"main.go/session"
+	"main.go/testrunner"
+	"main.go/vm"
+)
+
+// Códigos de salida de la línea de comandos.
+const (
+	exitOK     = 0 // sin errores; las advertencias del linter no cuentan
+	exitErrors = 1 // el programa tiene errores léxicos, sintácticos, semánticos o de ejecución
+	exitUsage  = 2 // argumentos inválidos o archivo ilegible
+)
+
+const usage = `Uso: vlang <comando> [opciones] [archivo]
+
+Comandos:
+  run [--engine repl|vm] archivo          ejecuta el programa
+  check [--lint config.json] archivos...  análisis léxico, sintáctico y semántico, sin ejecutar
+  compile [--emit asm|ir] [-O 0|1] [-o salida.s] archivo
+                                          genera el ensamblador ARM64 o el IR
+  ast [--format json|dot|svg] archivo     imprime el AST del programa
+  test [--format text|json|junit] [-o reporte] archivos...
+                                          ejecuta las funciones test_* de cada archivo
+  difftest [-n 100] [-seed 1] [-O 0|1] [--emulator] [archivos...]
+                                          compara el intérprete con el binario ARM64
+  repl                                    sesión interactiva (:reset, :vars, :type expr)
+  fmt [-w] [archivos...]                  formatea el código
+  serve [--port 8080]                     levanta el servidor HTTP del IDE
+  dap                                     Debug Adapter Protocol por stdio
+  lsp                                     Language Server Protocol por stdio
+
+Sin comando se levanta el servidor HTTP. Con "-" como archivo se lee la
+entrada estándar.
+
+compile traduce por el IR. Lo que el IR todavía no representa (vectores y
+matrices, structs, métodos, for in, nil, funciones nativas, funciones
+anidadas y la asignación a un elemento o atributo) lo traduce el traductor
+directo, sin las optimizaciones del IR, y se avisa en stderr; con --emit ir
+es un error.
+
+La salida del programa va a stdout; los errores y advertencias van a stderr
+como "archivo:línea:columna: tipo: mensaje".
+
+Códigos de salida: 0 sin errores, 1 el programa tiene errores, 2 uso
+incorrecto o archivo ilegible.
+`
+
+// runCLI ejecuta el comando de args y devuelve el código de salida.
+func runCLI(args []string) int {
+	if len(args) == 0 {
+		return cmdServe(nil)
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "run":
+		return cmdRun(args)
+	case "check":
+		return cmdCheck(args)
+	case "compile":
+		return cmdCompile(args)
+	case "ast":
+		return cmdAST(args)
+	case "test":
+		return cmdTest(args)
+	case "difftest":
+		return cmdDifftest(args)
+	case "repl":
+		return cmdRepl(args)
+	case "serve":
+		return cmdServe(args)
+	case "fmt":
+		return cmdFmt(args)
+	case "dap":
+		if err := dap.ServeStdio(); err != nil {
+			fmt.Fprintf(os.Stderr, "vlang dap: %v\n", err)
+			return exitErrors
+		}
+		return exitOK
+	case "lsp":
+		if err := lsp.ServeStdio(); err != nil {
+			fmt.Fprintf(os.Stderr, "vlang lsp: %v\n", err)
+			return exitErrors
+		}
+		return exitOK
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return exitOK
+	}
+
+	fmt.Fprintf(os.Stderr, "comando desconocido %q\n\n%s", command, usage)
+	return exitUsage
+}
+
+// === OPCIONES ===
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso de vlang %s:\n", name)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags acepta las opciones antes o después de los archivos, como en
+// "vlang compile prog.vch -o prog.s", y devuelve los archivos.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var files []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return files, nil
+		}
+		files = append(files, args[0])
+		args = args[1:]
+	}
+}
+
+// singleFile interpreta las opciones de un comando que recibe exactamente un
+// archivo. Si algo falla devuelve el código de salida a usar.
+func singleFile(fs *flag.FlagSet, args []string) (string, int, bool) {
+	files, err := parseFlags(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return "", exitOK, false
+	}
+	if err != nil {
+		return "", exitUsage, false
+	}
+	if len(files) != 1 {
+		fmt.Fprintf(os.Stderr, "vlang %s: se esperaba un archivo\n", fs.Name())
+		fs.Usage()
+		return "", exitUsage, false
+	}
+	return files[0], exitOK, true
+}
+
+// readSource lee el archivo, o la entrada estándar si path es "-".
+func readSource(path string) (string, error) {
+	if path == "-" {
+		code, err := io.ReadAll(os.Stdin)
+		return string(code), err
+	}
+	code, err := os.ReadFile(path)
+	return string(code), err
+}
+
+// === ANÁLISIS ===
+
+// compilation es el análisis de un archivo.
+type compilation struct {
+	path string
+	*analysis.Analysis
+}
+
+// parseFile corre el lexer y el parser sobre el archivo.
+func parseFile(path string) (*compilation, error) {
+	code, err := readSource(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseSource(path, code), nil
+}
+
+// parseSource corre el lexer y el parser sobre code; path solo se usa en los
+// reportes.
+func parseSource(path, code string) *compilation {
+	return &compilation{path: path, Analysis: analysis.Parse(code)}
+}
+
+// sourceFile es la ruta del programa para la directiva .file, vacía si se
+// leyó de la entrada estándar.
+func (c *compilation) sourceFile() string {
+	if c.path == "-" {
+		return ""
+	}
+	return c.path
+}
+
+// run ejecuta el programa ya chequeado con engine y devuelve su consola. Lo
+// que la VM no soporta se ejecuta con el intérprete, y fallback dice por qué.
+func (c *compilation) run(engine string) (console *repl.Console, fallback *vm.UnsupportedError) {
+	if engine == engineVM {
+		program, err := vm.Compile(c.Program, c.DclVisitor)
+		if err == nil {
+			machine := vm.NewMachine(program, c.ErrorTable)
+			machine.Run()
+			return machine.Console, nil
+		}
+		errors.As(err, &fallback)
+	}
+
+	visitor := repl.NewVisitor(c.DclVisitor)
+	visitor.VisitProgram(c.Program)
+	return visitor.Console, fallback
+}
+
+// lint agrega a la tabla las advertencias del linter. Solo tiene sentido
+// después de check.
+func (c *compilation) lint(config lint.Config) {
+	if c.Program == nil {
+		return
+	}
+	c.Stream.Fill()
+	lint.NewLinter(c.ErrorTable, config).Lint(c.Program, c.Stream.GetAllTokens())
+}
+
+// report escribe los errores y advertencias de la tabla en stderr.
+func (c *compilation) report() {
+	for _, err := range c.ErrorTable.Errors {
+		kind := err.GetDisplayName()
+		if err.Rule != "" {
+			kind += " [" + err.Rule + "]"
+		}
+		fmt.Fprintf(os.Stderr, "%s:%d:%d: %s: %s\n", c.path, err.Line, err.Column, kind, err.Msg)
+	}
+}
+
+// exitCode es el código de salida según la tabla de errores.
+func (c *compilation) exitCode() int {
+	if c.ErrorTable.HasErrors() {
+		return exitErrors
+	}
+	return exitOK
+}
+
+// === COMANDOS ===
+
+// cmdRun implementa "run": analiza el programa y, si no tiene errores, lo
+// ejecuta. La salida del programa va a stdout aunque haya un error de
+// ejecución a mitad de camino.
+func cmdRun(args []string) int {
+	fs := newFlagSet("run")
+	engine := fs.String("engine", engineREPL, `motor de ejecución: "repl" o "vm"`)
+	path, code, ok := singleFile(fs, args)
+	if !ok {
+		return code
+	}
+	if *engine != engineREPL && *engine != engineVM {
+		fmt.Fprintf(os.Stderr, "vlang run: motor desconocido %q, se esperaba %q o %q\n", *engine, engineREPL, engineVM)
+		return exitUsage
+	}
+
+	c, err := parseFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if !c.Check() {
+		c.report()
+		return exitErrors
+	}
+
+	console, fallback := c.run(*engine)
+	if fallback != nil {
+		fmt.Fprintf(os.Stderr, "%s:%d:%d: Advertencia: la VM no soporta %s; se ejecuta con el intérprete\n",
+			path, fallback.Line, fallback.Column, fallback.Construct)
+	}
+
+	fmt.Print(console.GetOutput())
+	c.report()
+	return c.exitCode()
+}
+
+// cmdCheck implementa "check": análisis léxico, sintáctico, semántico y
+// linter de cada archivo, sin ejecutarlos.
+func cmdCheck(args []string) int {
+	fs := newFlagSet("check")
+	lintPath := fs.String("lint", "", "configuración del linter en JSON")
+	files, err := parseFlags(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "vlang check: se esperaba al menos un archivo")
+		fs.Usage()
+		return exitUsage
+	}
+
+	var config lint.Config
+	if *lintPath != "" {
+		if config, err = lint.LoadConfig(*lintPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+	}
+
+	status := exitOK
+	for _, path := range files {
+		c, err := parseFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		c.Check()
+		c.lint(config)
+		c.report()
+		if c.exitCode() != exitOK {
+			status = exitErrors
+		}
+	}
+	return status
+}
+
+// cmdCompile implementa "compile": traduce el programa a ensamblador ARM64,
+// o con --emit ir lo muestra en la representación intermedia. Sin -o la
+// salida va a stdout. Si el programa usa algo que el IR no representa, el
+// ensamblador sale del traductor directo y se avisa en stderr; --emit ir
+// termina con error.
+func cmdCompile(args []string) int {
+	fs := newFlagSet("compile")
+	output := fs.String("o", "-", `archivo de salida, "-" para stdout`)
+	emit := fs.String("emit", emitASM, `qué generar: "asm" o "ir"`)
+	level := fs.Int("O", ir.O0, "nivel de optimización: 0 o 1")
+	path, code, ok := singleFile(fs, args)
+	if !ok {
+		return code
+	}
+	if *emit != emitASM && *emit != emitIR {
+		fmt.Fprintf(os.Stderr, "vlang compile: salida desconocida %q, se esperaba %q o %q\n", *emit, emitASM, emitIR)
+		return exitUsage
+	}
+	if *level < ir.O0 || *level > ir.O1 {
+		fmt.Fprintf(os.Stderr, "vlang compile: nivel de optimización desconocido %d, se esperaba %d o %d\n", *level, ir.O0, ir.O1)
+		return exitUsage
+	}
+
+	c, err := parseFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if !c.Check() {
+		c.report()
+		return exitErrors
+	}
+
+	var assembly string
+	if *emit == emitIR {
+		irCode, err := compiler.EmitIR(c.Tree, *level)
+		var unsupported *ir.UnsupportedError
+		if errors.As(err, &unsupported) {
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: Error IR: el IR no soporta %s; compile --emit asm lo traduce con el traductor directo\n",
+				path, unsupported.Line, unsupported.Column, unsupported.Construct)
+			return exitErrors
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: Error IR: %s\n", path, err)
+			return exitErrors
+		}
+		assembly = irCode
+	} else {
+		arm64Code, translationErrors, fallback := translateToARM64(c.Tree, c.sourceFile(), *level, logging.New(logging.Compiler))
+		if fallback != nil {
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: Advertencia: el IR no soporta %s; se compila con el traductor directo\n",
+				path, fallback.Line, fallback.Column, fallback.Construct)
+		}
+		if len(translationErrors) > 0 {
+			for _, msg := range translationErrors {
+				fmt.Fprintf(os.Stderr, "%s: Error ARM64: %s\n", path, msg)
+			}
+			return exitErrors
+		}
+		assembly = arm64Code
+	}
+
+	if *output == "-" {
+		fmt.Print(assembly)
+		return exitOK
+	}
+	if err := os.WriteFile(*output, []byte(assembly), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	return exitOK
+}
+
+// cmdAST implementa "ast": imprime el árbol tipado en JSON, DOT o SVG. No
+// necesita que el programa pase el chequeo semántico, solo el sintáctico.
+func cmdAST(args []string) int {
+	fs := newFlagSet("ast")
+	outputFormat := fs.String("format", "json", "formato de salida: json, dot o svg")
+	path, code, ok := singleFile(fs, args)
+	if !ok {
+		return code
+	}
+	switch *outputFormat {
+	case "json", "dot", "svg":
+	default:
+		fmt.Fprintf(os.Stderr, "vlang ast: formato desconocido %q, se esperaba json, dot o svg\n", *outputFormat)
+		return exitUsage
+	}
+
+	c, err := parseFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if c.ErrorTable.HasErrors() {
+		c.report()
+		return exitErrors
+	}
+
+	root := ast.GenerateReportAST(ast.Lower(c.Tree))
+	switch *outputFormat {
+	case "json":
+		data, err := json.MarshalIndent(root, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitErrors
+		}
+		fmt.Println(string(data))
+	case "dot":
+		fmt.Print(ast.GenerateASTDot(root))
+	case "svg":
+		fmt.Println(ast.GenerateASTSVG(root))
+	}
+	return exitOK
+}
+
+// cmdRepl implementa "repl": lee entradas de stdin y las ejecuta en una
+// misma sesión hasta el fin de la entrada. Los prompts solo se muestran si
+// stdin es una terminal.
+func cmdRepl(args []string) int {
+	fs := newFlagSet("repl")
+	files, err := parseFlags(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+	if len(files) > 0 {
+		fmt.Fprintf(os.Stderr, "vlang repl: argumentos inesperados: %s\n", strings.Join(files, " "))
+		return exitUsage
+	}
+
+	interactive := false
+	if info, err := os.Stdin.Stat(); err == nil {
+		interactive = info.Mode()&os.ModeCharDevice != 0
+	}
+	prompt := func(s *session.Session) {
+		if !interactive {
+			return
+		}
+		if s.Pending() {
+			fmt.Print("...... ")
+		} else {
+			fmt.Print("vlang> ")
+		}
+	}
+
+	s := session.NewSession()
+	if interactive {
+		fmt.Println("VLang REPL. Comandos: :reset, :vars, :type expr. Ctrl+D para salir.")
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	prompt(s)
+	for scanner.Scan() {
+		result := s.Input(scanner.Text())
+		fmt.Print(result.Output)
+		if result.Value != "" {
+			fmt.Println(result.Value)
+		}
+		for _, err := range result.Errors {
+			fmt.Fprintf(os.Stderr, "%d:%d: %s: %s\n", err.Line, err.Column, err.GetDisplayName(), err.Msg)
+		}
+		prompt(s)
+	}
+	if interactive {
+		fmt.Println()
+	}
+	return exitOK
+}
+
+// cmdServe implementa "serve": el servidor HTTP del IDE.
+func cmdServe(args []string) int {
+	fs := newFlagSet("serve")
+	port := fs.Int("port", 8080, "puerto del servidor HTTP")
+	files, err := parseFlags(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+	if len(files) > 0 {
+		fmt.Fprintf(os.Stderr, "vlang serve: argumentos inesperados: %s\n", strings.Join(files, " "))
+		return exitUsage
+	}
+
+	if err := serve(*port); err != nil {
+		httpLog.Error("el servidor terminó", "error", err)
+		return exitErrors
+	}
+	return exitOK
+}
+
+// cmdTest implementa "test": ejecuta las pruebas de cada archivo y escribe el
+// reporte. Termina con exitErrors si alguna prueba no pasó o algún archivo no
+// compila.
+func cmdTest(args []string) int {
+	fs := newFlagSet("test")
+	reportFormat := fs.String("format", testrunner.FormatText, `formato del reporte: "text", "json" o "junit"`)
+	output := fs.String("o", "-", `archivo del reporte, "-" para stdout`)
+	files, err := parseFlags(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "vlang test: se esperaba al menos un archivo")
+		fs.Usage()
+		return exitUsage
+	}
+
+	var suites []*testrunner.Suite
+	for _, path := range files {
+		code, err := readSource(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		suites = append(suites, testrunner.Run(path, code))
+	}
+
+	var report strings.Builder
+	if err := testrunner.Write(&report, *reportFormat, suites); err != nil {
+		fmt.Fprintf(os.Stderr, "vlang test: %v\n", err)
+		return exitUsage
+	}
+	if *output == "-" {
+		fmt.Print(report.String())
+	} else if err := os.WriteFile(*output, []byte(report.String()), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	for _, suite := range suites {
+		if !suite.OK() {
+			return exitErrors
+		}
+	}
+	return exitOK
+}
+
+// arm64Executor ejecuta el ensamblador como el IDE: en qemu si están
+// instaladas las herramientas ARM64 y emulate es falso, o si no en el
+// emulador.
+func arm64Executor(emulate bool) difftest.Executor {
+	if emulate || !arm64Toolchain() {
+		return difftest.Emulate
+	}
+	return func(assembly string) (string, error) {
+		output, ok, msg := executeARM64Assembly(assembly)
+		if !ok {
+			if code := exitCode(msg); code > 0 {
+				return output, &difftest.ExitError{Code: code}
+			}
+			return output, errors.New(msg)
+		}
+		return output, nil
+	}
+}
+
+// cmdDifftest implementa "difftest": ejecuta cada archivo en el intérprete y
+// compilado a ARM64 y compara las salidas. Sin archivos compara n programas
+// aleatorios; el programa i usa la semilla seed+i, así que cualquiera se
+// reproduce con "-seed <semilla> -n 1". Las divergencias se minimizan antes
+// de mostrarlas. Termina con exitErrors si alguna salida difiere.
+func cmdDifftest(args []string) int {
+	fs := newFlagSet("difftest")
+	count := fs.Int("n", 100, "cantidad de programas aleatorios a comparar si no se pasan archivos")
+	seed := fs.Int64("seed", 1, "semilla del primer programa aleatorio")
+	useEmulator := fs.Bool("emulator", false, "usa el emulador aunque estén instaladas las herramientas ARM64")
+	level := fs.Int("O", ir.O0, "nivel de optimización del compilador: 0 o 1")
+	files, err := parseFlags(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+
+	harness := difftest.NewHarness(arm64Executor(*useEmulator))
+	harness.Translate = difftest.CompileAt(*level)
+
+	type program struct{ name, code string }
+	var programs []program
+	for _, path := range files {
+		code, err := readSource(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		programs = append(programs, program{path, code})
+	}
+	if len(files) == 0 {
+		for i := range *count {
+			s := *seed + int64(i)
+			programs = append(programs, program{fmt.Sprintf("semilla %d", s), difftest.NewGenerator(s).Program()})
+		}
+	}
+
+	counts := map[string]int{}
+	for _, p := range programs {
+		result := harness.Compare(p.code)
+		counts[result.Status]++
+		switch result.Status {
+		case difftest.Match:
+			fmt.Printf("ok       %s\n", p.name)
+		case difftest.Skipped:
+			fmt.Printf("omitido  %s: %s\n", p.name, result.Reason)
+		case difftest.Diverged:
+			fmt.Printf("DIVERGE  %s: %s\n", p.name, result.Reason)
+			minimized := harness.Minimize(p.code)
+			fmt.Printf("    programa mínimo:\n")
+			for _, line := range strings.Split(strings.TrimRight(minimized, "\n"), "\n") {
+				fmt.Printf("        %s\n", line)
+			}
+			if r := harness.Compare(minimized); r.Status == difftest.Diverged {
+				fmt.Printf("    %s\n", r.Reason)
+			}
+		}
+	}
+
+	fmt.Printf("\n%d programas: %d coinciden, %d divergen, %d omitidos\n",
+		len(programs), counts[difftest.Match], counts[difftest.Diverged], counts[difftest.Skipped])
+	if counts[difftest.Diverged] > 0 {
+		return exitErrors
+	}
+	return exitOK
+}
+
+// cmdFmt implementa "fmt"; un archivo con errores de sintaxis no se formatea
+// y termina con exitErrors.
+func cmdFmt(args []string) int {
+	err := formatFiles(args)
+	if err == nil {
+		return exitOK
+	}
+	fmt.Fprintln(os.Stderr, err)
+
+	var formatErr *format.Error
+	if errors.As(err, &formatErr) {
+		return exitErrors
+	}
+	return exitUsage
+}
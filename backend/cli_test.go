@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// cli ejecuta la línea de comandos con args y devuelve el código de salida y
+// lo que escribió en stdout y stderr.
+func cli(t *testing.T, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	dir := t.TempDir()
+	outFile, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errFile, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	savedOut, savedErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outFile, errFile
+	code = runCLI(args)
+	os.Stdout, os.Stderr = savedOut, savedErr
+	outFile.Close()
+	errFile.Close()
+
+	out, _ := os.ReadFile(outFile.Name())
+	errOut, _ := os.ReadFile(errFile.Name())
+	return code, string(out), string(errOut)
+}
+
+// program escribe code en un archivo temporal y devuelve su ruta.
+func program(t *testing.T, code string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "programa.vch")
+	if err := os.WriteFile(path, []byte(code), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Cada comando sale con 0 sin errores, 1 si el programa tiene errores y 2 si
+// los argumentos son inválidos o el archivo no se puede leer.
+func TestExitCodes(t *testing.T) {
+	valid := "mut x = 41\nx += 1\nprintln(x)\n"
+	syntaxError := "mut = 5\n"
+	semanticError := "println(y)\n"
+	runtimeError := "mut v []int = {1, 2}\nmut i = 5\nprintln(v[i])\n"
+	unusedVariable := "fn f() {\n    mut sinUso = 1\n}\nf()\n"
+	missing := filepath.Join(t.TempDir(), "no_existe.vch")
+	output := filepath.Join(t.TempDir(), "salida.s")
+
+	tests := []struct {
+		name   string
+		args   []string
+		want   int
+		stderr string // fragmento esperado en stderr
+	}{
+		{"run sin errores", []string{"run", program(t, valid)}, exitOK, ""},
+		{"run con la VM", []string{"run", "--engine", "vm", program(t, valid)}, exitOK, ""},
+		{"run con error de sintaxis", []string{"run", program(t, syntaxError)}, exitErrors, "Sintáctico"},
+		{"run con error semántico", []string{"run", program(t, semanticError)}, exitErrors, "Variable y no encontrada"},
+		{"run con error de ejecución", []string{"run", program(t, runtimeError)}, exitErrors, ""},
+		{"run sin archivo", []string{"run"}, exitUsage, ""},
+		{"run con archivo ilegible", []string{"run", missing}, exitUsage, ""},
+		{"run con motor desconocido", []string{"run", "--engine", "jit", program(t, valid)}, exitUsage, "motor desconocido"},
+
+		{"check sin errores", []string{"check", program(t, valid)}, exitOK, ""},
+		{"check con advertencias", []string{"check", program(t, unusedVariable)}, exitOK, "Advertencia"},
+		{"check con error", []string{"check", program(t, valid), program(t, semanticError)}, exitErrors, "Variable y no encontrada"},
+		{"check sin archivos", []string{"check"}, exitUsage, ""},
+		{"check con archivo ilegible", []string{"check", missing}, exitUsage, ""},
+
+		{"compile sin errores", []string{"compile", "-o", output, program(t, valid)}, exitOK, ""},
+		{"compile a IR", []string{"compile", "--emit", "ir", "-o", output, program(t, valid)}, exitOK, ""},
+		{"compile con error", []string{"compile", "-o", output, program(t, semanticError)}, exitErrors, ""},
+		{"compile a IR sin soporte", []string{"compile", "--emit", "ir", "-o", output, program(t, runtimeError)}, exitErrors, "Error IR"},
+		{"compile con salida desconocida", []string{"compile", "--emit", "obj", program(t, valid)}, exitUsage, "salida desconocida"},
+		{"compile con nivel desconocido", []string{"compile", "-O", "3", program(t, valid)}, exitUsage, "nivel de optimización desconocido"},
+		{"compile con archivo ilegible", []string{"compile", missing}, exitUsage, ""},
+
+		{"comando desconocido", []string{"ejecutar"}, exitUsage, "comando desconocido"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := cli(t, tt.args...)
+			if code != tt.want {
+				t.Errorf("código %d, se esperaba %d; stderr:\n%s", code, tt.want, stderr)
+			}
+			if !strings.Contains(stderr, tt.stderr) {
+				t.Errorf("stderr no contiene %q:\n%s", tt.stderr, stderr)
+			}
+		})
+	}
+}
+
+// run imprime la salida del programa en stdout, y compile escribe el
+// ensamblador en el archivo de -o con la ruta del programa en .file.
+func TestCommandOutput(t *testing.T) {
+	code, stdout, _ := cli(t, "run", program(t, "println(\"hola\")\n"))
+	if code != exitOK || !strings.HasPrefix(stdout, "hola\n") {
+		t.Errorf("run: código %d, salida %q", code, stdout)
+	}
+
+	output := filepath.Join(t.TempDir(), "salida.s")
+	source := program(t, "println(1)\n")
+	if code, _, stderr := cli(t, "compile", "-o", output, source); code != exitOK {
+		t.Fatalf("compile: código %d\n%s", code, stderr)
+	}
+	assembly, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(assembly), "_start") {
+		t.Errorf("el ensamblador no tiene _start:\n%s", assembly)
+	}
+	if want := fmt.Sprintf(".file 1 %q\n", source); !strings.HasPrefix(string(assembly), want) {
+		t.Errorf("se esperaba la directiva %q", strings.TrimSpace(want))
+	}
+}
+
+// dap y lsp salen con 1 y el error en stderr si la entrada no respeta el
+// encuadre del protocolo, en vez de terminar el proceso.
+func TestServeErrors(t *testing.T) {
+	for _, command := range []string{"dap", "lsp"} {
+		t.Run(command, func(t *testing.T) {
+			input := filepath.Join(t.TempDir(), "stdin")
+			if err := os.WriteFile(input, []byte("Content-Length: x\r\n\r\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			stdin, err := os.Open(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stdin.Close()
+			saved := os.Stdin
+			os.Stdin = stdin
+			defer func() { os.Stdin = saved }()
+
+			code, _, stderr := cli(t, command)
+			if code != exitErrors || !strings.Contains(stderr, "vlang "+command+": ") {
+				t.Errorf("código %d, stderr %q", code, stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package arm64
+
+import "fmt"
+
+// Marcos de las funciones de usuario, según AAPCS64. Cada llamada tiene su
+// propio marco, así una función recursiva no pisa las variables de la
+// llamada anterior:
+//
+//	x29 + 0      x29 y x30 de quien llamó (registro de marco)
+//	x29 + 16     parámetros y variables locales, 8 bytes cada uno
+//	x29 + frame  argumentos que no entraron en registros
+//
+// Debajo del marco quedan los registros callee-saved que usan los
+// temporales (ver temps.go). Las variables del programa principal siguen en
+// el área fija que apunta VB.
+
+// frameRecord es el lugar del par x29, x30 al inicio del marco.
+const frameRecord = 16
+
+// BeginFunction abre el marco de una función de usuario: las variables que
+// se declaren hasta EndFunction son locales. También empieza a contar los
+// registros de temporales que usa la función.
+func (g *ARM64Generator) BeginFunction() {
+	g.usedTemps = 0
+	g.locals = make(map[string]int)
+	g.frameSize = frameRecord
+}
+
+// EndFunction cierra el marco abierto con BeginFunction.
+func (g *ARM64Generator) EndFunction() {
+	g.locals = nil
+}
+
+// FrameSize es el tamaño del marco de la función actual, alineado a 16.
+func (g *ARM64Generator) FrameSize() int {
+	return (g.frameSize + 15) &^ 15
+}
+
+// DeclaredInScope indica si name ya tiene lugar en el alcance actual: el
+// marco de la función, o el área de variables fuera de una función.
+func (g *ARM64Generator) DeclaredInScope(name string) bool {
+	if g.locals != nil {
+		_, exists := g.locals[name]
+		return exists
+	}
+	_, exists := g.variables[name]
+	return exists
+}
+
+// variableAddress devuelve el operando de memoria de una variable: las
+// locales se direccionan desde x29 y las del programa principal desde VB.
+func (g *ARM64Generator) variableAddress(name string) string {
+	if offset, exists := g.locals[name]; exists {
+		return fmt.Sprintf("[%s, #%d]", FP, offset)
+	}
+	return fmt.Sprintf("[%s, #%d]", VB, g.GetVariableOffset(name))
+}
+
+// EmitPrologue reserva el marco de la función actual y guarda en su
+// comienzo el registro de marco. Va después de declarar los parámetros y
+// las locales, cuando se conoce el tamaño.
+func (g *ARM64Generator) EmitPrologue() {
+	g.Comment(fmt.Sprintf("Prólogo: marco de %d bytes", g.FrameSize()))
+	g.adjustSP("sub", g.FrameSize())
+	g.Emit(fmt.Sprintf("stp %s, %s, [sp]", FP, LR))
+	g.Emit(fmt.Sprintf("mov %s, sp", FP))
+}
+
+// EmitEpilogue libera el marco y vuelve a quien llamó. Los registros
+// callee-saved ya tienen que estar restaurados.
+func (g *ARM64Generator) EmitEpilogue() {
+	g.Comment("Epílogo")
+	g.Emit(fmt.Sprintf("mov sp, %s", FP))
+	g.Emit(fmt.Sprintf("ldp %s, %s, [sp]", FP, LR))
+	g.adjustSP("add", g.FrameSize())
+	g.Emit("ret")
+}
+
+// StackArgument devuelve el operando de memoria del argumento que quien
+// llamó dejó en offset de su área de argumentos.
+func (g *ARM64Generator) StackArgument(offset int) string {
+	return fmt.Sprintf("[%s, #%d]", FP, g.FrameSize()+offset)
+}
+
+// adjustSP suma o resta value a sp. Los inmediatos de add y sub tienen 12
+// bits, los marcos más grandes pasan por x16.
+func (g *ARM64Generator) adjustSP(op string, value int) {
+	if value < 4096 {
+		g.Emit(fmt.Sprintf("%s sp, sp, #%d", op, value))
+		return
+	}
+	g.Emit(fmt.Sprintf("mov x16, #%d", value))
+	g.Emit(fmt.Sprintf("%s sp, sp, x16", op))
+}
+
+// === CONVENCIÓN DE LLAMADA ===
+
+// ArgLocation es el lugar de un argumento en una llamada.
+type ArgLocation struct {
+	Register string // x0-x7 o d0-d7; vacío si va en el stack
+	Offset   int    // offset en el área de argumentos, si va en el stack
+}
+
+// AssignArguments ubica los argumentos de una llamada según AAPCS64: los
+// enteros, bools y strings en x0-x7 y los float en d0-d7, cada grupo en
+// orden. Los que no entran van en el stack, en lugares de 8 bytes en el
+// orden de los argumentos. También devuelve el tamaño del área del stack,
+// alineado a 16; quien llama la reserva justo antes del bl.
+func AssignArguments(floats []bool) ([]ArgLocation, int) {
+	locations := make([]ArgLocation, len(floats))
+	ints, doubles, stack := 0, 0, 0
+	for i, float := range floats {
+		switch {
+		case float && doubles < 8:
+			locations[i].Register = fmt.Sprintf("d%d", doubles)
+			doubles++
+		case !float && ints < 8:
+			locations[i].Register = fmt.Sprintf("x%d", ints)
+			ints++
+		default:
+			locations[i].Offset = stack
+			stack += 8
+		}
+	}
+	return locations, (stack + 15) &^ 15
+}
@@ -2,30 +2,47 @@ package arm64
 
 import (
 	"fmt"
+	"log/slog"
+	"strconv"
 	"strings"
+
+	"main.go/logging"
 )
 
 // ARM64Generator maneja la generación de código ARM64
 type ARM64Generator struct {
-	instructions []string          // Lista de instrucciones generadas
+	instructions []line            // Lista de instrucciones generadas
+	position     Position          // sentencia de la que sale lo que se emite
 	labelCount   int               // Contador para etiquetas únicas
 	variables    map[string]int    // Offset de variables en el stack
 	stackOffset  int               // Offset actual del stack (crece hacia abajo)
 	stringData   []string          // Para almacenar datos de strings
 	stringCount  int               // Contador para strings únicos
 	stringMap    map[string]string // texto -> etiqueta Elimina duplicados
+	floatData    []string          // constantes double de la sección .data
+	floatMap     map[float64]string
+	tempDepth    int            // temporales vivos (ver temps.go)
+	usedTemps    int            // registros de temporales usados en la función actual
+	variablesAt  int            // posición de la reserva de variables en _start
+	locals       map[string]int // offset desde x29 de las locales (ver frame.go)
+	frameSize    int            // bytes usados del marco de la función actual
+
+	SourceFile string       // ruta del programa en la directiva .file; vacía usa DefaultSourceFile
+	Log        *slog.Logger // logger del subsistema compiler
 }
 
 // NewARM64Generator crea un nuevo generador
 func NewARM64Generator() *ARM64Generator {
 	return &ARM64Generator{
-		instructions: make([]string, 0),
+		instructions: make([]line, 0),
 		labelCount:   0,
 		variables:    make(map[string]int),
 		stackOffset:  0,
 		stringData:   make([]string, 0),
 		stringCount:  0,
 		stringMap:    make(map[string]string),
+		floatMap:     make(map[float64]string),
+		Log:          logging.New(logging.Compiler),
 	}
 }
 
@@ -33,7 +50,6 @@ func NewARM64Generator() *ARM64Generator {
 func (g *ARM64Generator) AddStringLiteral(text string) string {
 	// NUEVO: Verificar si el string ya existe
 	if existingLabel, exists := g.stringMap[text]; exists {
-		fmt.Printf("🔄 String \"%s\" ya existe como %s, reutilizando\n", text, existingLabel)
 		return existingLabel
 	}
 
@@ -48,25 +64,39 @@ func (g *ARM64Generator) AddStringLiteral(text string) string {
 	stringDef := fmt.Sprintf("%s: .asciz \"%s\"", stringLabel, text)
 	g.stringData = append(g.stringData, stringDef)
 
-	fmt.Printf("✅ Nuevo string \"%s\" creado como %s\n", text, stringLabel)
+	g.Log.Debug("nuevo string", "text", text, "label", stringLabel)
 	return stringLabel
 }
 
+// AddFloatLiteral registra una constante double y devuelve su etiqueta. Los
+// flotantes se cargan de memoria porque mov solo acepta inmediatos enteros.
+func (g *ARM64Generator) AddFloatLiteral(value float64) string {
+	if existingLabel, exists := g.floatMap[value]; exists {
+		return existingLabel
+	}
+
+	floatLabel := fmt.Sprintf("flt_%d", len(g.floatData))
+	g.floatMap[value] = floatLabel
+	g.floatData = append(g.floatData, fmt.Sprintf("%s: .double %s", floatLabel, strconv.FormatFloat(value, 'g', -1, 64)))
+	return floatLabel
+}
+
 // === GESTIÓN DE INSTRUCCIONES ===
 
-// Emit añade una instrucción ARM64 con indentación
+// Emit añade una instrucción ARM64 con indentación. Guarda junto a ella la
+// posición actual (ver SetPosition)
 func (g *ARM64Generator) Emit(instruction string) {
-	g.instructions = append(g.instructions, "    "+instruction)
+	g.instructions = append(g.instructions, line{"    " + instruction, g.position})
 }
 
 // EmitRaw añade una instrucción sin indentación (para etiquetas)
 func (g *ARM64Generator) EmitRaw(instruction string) {
-	g.instructions = append(g.instructions, instruction)
+	g.instructions = append(g.instructions, line{instruction, g.position})
 }
 
 // Comment añade un comentario explicativo
 func (g *ARM64Generator) Comment(comment string) {
-	g.instructions = append(g.instructions, "    // "+comment)
+	g.instructions = append(g.instructions, line{"    // " + comment, g.position})
 }
 
 // === GESTIÓN DE ETIQUETAS ===
@@ -85,8 +115,15 @@ func (g *ARM64Generator) SetLabel(label string) {
 
 // === GESTIÓN DE VARIABLES ===
 
-// DeclareVariable reserva espacio para una variable en el stack
+// DeclareVariable reserva espacio para una variable en el stack: en el marco
+// si hay una función abierta, si no en el área de variables
 func (g *ARM64Generator) DeclareVariable(name string) {
+	if g.locals != nil {
+		g.locals[name] = g.frameSize
+		g.frameSize += 8
+		return
+	}
+
 	g.stackOffset += 8 // Cada variable ocupa 8 bytes en ARM64
 	g.variables[name] = g.stackOffset
 	g.Comment(fmt.Sprintf("Variable '%s' declarada en offset %d", name, g.stackOffset))
@@ -100,8 +137,12 @@ func (g *ARM64Generator) GetVariableOffset(name string) int {
 	return 0 // Si no existe, retorna 0 (esto debería manejarse como error)
 }
 
-// VariableExists verifica si una variable ya fue declarada
+// VariableExists verifica si una variable ya fue declarada, local o del
+// programa principal
 func (g *ARM64Generator) VariableExists(name string) bool {
+	if _, exists := g.locals[name]; exists {
+		return true
+	}
 	_, exists := g.variables[name]
 	return exists
 }
@@ -116,16 +157,14 @@ func (g *ARM64Generator) LoadImmediate(register string, value int) {
 
 // LoadVariable carga una variable del stack a un registro
 func (g *ARM64Generator) LoadVariable(register, varName string) {
-	offset := g.GetVariableOffset(varName)
 	g.Comment(fmt.Sprintf("Cargar variable '%s' en %s", varName, register))
-	g.Emit(fmt.Sprintf("ldr %s, [sp, #%d]", register, offset))
+	g.Emit(fmt.Sprintf("ldr %s, %s", register, g.variableAddress(varName)))
 }
 
 // StoreVariable guarda un registro en una variable del stack
 func (g *ARM64Generator) StoreVariable(register, varName string) {
-	offset := g.GetVariableOffset(varName)
 	g.Comment(fmt.Sprintf("Guardar %s en variable '%s'", register, varName))
-	g.Emit(fmt.Sprintf("str %s, [sp, #%d]", register, offset))
+	g.Emit(fmt.Sprintf("str %s, %s", register, g.variableAddress(varName)))
 }
 
 // === OPERACIONES ARITMÉTICAS ===
@@ -191,16 +230,17 @@ func (g *ARM64Generator) JumpIfZero(register, label string) {
 
 // === OPERACIONES DE STACK ===
 
-// Push guarda un registro en el stack
+// Push guarda un registro en el stack. Usa 16 bytes porque sp debe quedar
+// alineado a 16 para accederlo.
 func (g *ARM64Generator) Push(register string) {
 	g.Comment(fmt.Sprintf("Push %s al stack", register))
-	g.Emit(fmt.Sprintf("str %s, [sp, #-8]!", register))
+	g.Emit(fmt.Sprintf("str %s, [sp, #-16]!", register))
 }
 
 // Pop recupera un valor del stack a un registro
 func (g *ARM64Generator) Pop(register string) {
 	g.Comment(fmt.Sprintf("Pop del stack a %s", register))
-	g.Emit(fmt.Sprintf("ldr %s, [sp], #8", register))
+	g.Emit(fmt.Sprintf("ldr %s, [sp], #16", register))
 }
 
 // === LLAMADAS A FUNCIONES ===
@@ -215,25 +255,33 @@ func (g *ARM64Generator) CallFunction(funcName string) {
 
 // GenerateHeader genera el header del programa ARM64
 func (g *ARM64Generator) GenerateHeader() {
-	g.EmitRaw(".data")
-
-	// NUEVO: Agregar todos los strings a la sección .data
-	for _, stringDef := range g.stringData {
-		g.EmitRaw(stringDef)
-	}
-
-	g.EmitRaw("") // Línea vacía para separación
 	g.EmitRaw(".text")
 	g.EmitRaw(".global _start")
 	g.EmitRaw("")
 	g.EmitRaw("_start:")
 	g.Comment("=== INICIO DEL PROGRAMA ===")
 
-	// Configurar el stack inicial si hay variables
-	if g.stackOffset > 0 {
-		g.Comment(fmt.Sprintf("Reservar %d bytes para variables locales", g.stackOffset))
-		g.Emit(fmt.Sprintf("sub sp, sp, #%d", g.stackOffset))
+	// La reserva del área de variables se inserta aquí con ReserveVariables
+	g.variablesAt = len(g.instructions)
+
+	// Las variables se direccionan desde x28, que queda fijo aunque sp se
+	// mueva con los temporales apilados o dentro de una función
+	g.Emit(fmt.Sprintf("mov %s, sp", VB))
+}
+
+// ReserveVariables inserta al inicio del programa la reserva del área de
+// variables. Va al final de la traducción porque las funciones de usuario
+// declaran sus parámetros después de emitir el header. El tamaño cubre el
+// último offset y queda alineado a 16 bytes.
+func (g *ARM64Generator) ReserveVariables() {
+	if g.stackOffset == 0 {
+		return
 	}
+	size := (g.stackOffset + 8 + 15) / 16 * 16
+	g.insertAt(g.variablesAt, []string{
+		fmt.Sprintf("    // Reservar %d bytes para variables locales", size),
+		fmt.Sprintf("    sub sp, sp, #%d", size),
+	})
 }
 
 // GenerateFooter genera el footer del programa ARM64
@@ -252,22 +300,102 @@ func (g *ARM64Generator) GenerateFooter() {
 	g.Emit("svc #0")      // Llamada al sistema
 }
 
+// GenerateFloatData emite las constantes flotantes en su propia sección
+// .data. Va al final del programa porque los literales se registran durante
+// la segunda pasada, después de emitir el header.
+func (g *ARM64Generator) GenerateFloatData() {
+	if len(g.floatData) == 0 {
+		return
+	}
+
+	g.EmitRaw("")
+	g.EmitRaw(".data")
+	g.EmitRaw(".balign 8")
+	for _, floatDef := range g.floatData {
+		g.EmitRaw(floatDef)
+	}
+}
+
+// GenerateStringData emite los strings registrados en su propia sección
+// .data. Va al final del programa para incluir los que se registraron
+// durante la traducción.
+func (g *ARM64Generator) GenerateStringData() {
+	if len(g.stringData) == 0 {
+		return
+	}
+
+	g.EmitRaw("")
+	g.EmitRaw(".data")
+	for _, stringDef := range g.stringData {
+		g.EmitRaw(stringDef)
+	}
+}
+
+// EscapeString escribe s como el contenido de un .asciz: AddStringLiteral
+// recibe el texto ya escapado.
+func EscapeString(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c == '\n':
+			sb.WriteString("\\n")
+		case c == '\t':
+			sb.WriteString("\\t")
+		case c < ' ' || c >= 0x7f:
+			fmt.Fprintf(&sb, "\\%03o", c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
 // === SALIDA FINAL ===
 
-// GetCode retorna todo el código generado como string
+// GetCode retorna todo el código generado como string, con las directivas
+// .file y .loc de las posiciones registradas (ver sourcemap.go)
 func (g *ARM64Generator) GetCode() string {
-	return strings.Join(g.instructions, "\n")
+	var code []string
+	if g.hasPositions() {
+		source := g.SourceFile
+		if source == "" {
+			source = DefaultSourceFile
+		}
+		code = append(code, fmt.Sprintf(".file 1 %q", source))
+	}
+
+	// Un .loc cada vez que cambia la sentencia de origen
+	var current Position
+	for _, l := range g.instructions {
+		if l.pos != current && strings.TrimSpace(l.text) != "" {
+			code = append(code, locDirective(l.pos))
+			current = l.pos
+		}
+		code = append(code, l.text)
+	}
+	return strings.Join(code, "\n")
 }
 
 // Reset limpia el generador para empezar un nuevo programa
 func (g *ARM64Generator) Reset() {
-	g.instructions = make([]string, 0)
+	g.instructions = make([]line, 0)
+	g.position = Position{}
 	g.labelCount = 0
 	g.variables = make(map[string]int)
 	g.stackOffset = 0
 	g.stringData = make([]string, 0)
 	g.stringCount = 0
 	g.stringMap = make(map[string]string) // NUEVO
+	g.floatData = make([]string, 0)
+	g.floatMap = make(map[float64]string)
+	g.tempDepth = 0
+	g.usedTemps = 0
+	g.variablesAt = 0
+	g.locals = nil
+	g.frameSize = 0
 }
 
 // === UTILIDADES DE DEBUG ===
@@ -0,0 +1,171 @@
+package arm64
+
+import "strings"
+
+// Peephole simplifica pares de instrucciones vecinas que el generador deja
+// al traducir cada operación por separado:
+//
+//	str x0, [sp, #8]        ->  str x0, [sp, #8]
+//	ldr x1, [sp, #8]            mov x1, x0
+//
+//	mov x1, x0              ->  mov x1, x0
+//	mov x0, x1
+//
+//	str x0, [sp, #-16]!     ->  mov x1, x0
+//	ldr x1, [sp], #16
+//
+// También quita los mov de un registro a sí mismo y los saltos a la etiqueta
+// que sigue. Los comentarios, las líneas en blanco y las directivas .loc no
+// separan a dos instrucciones, una etiqueta sí: alguien puede saltar a ella.
+func Peephole(code string) string {
+	lines := strings.Split(code, "\n")
+	for changed := true; changed; {
+		changed = false
+		for i := 0; i < len(lines); i++ {
+			first, ok := parseInstruction(lines[i])
+			if !ok {
+				continue
+			}
+			j := nextLine(lines, i)
+			if j == len(lines) {
+				break
+			}
+
+			// mov x0, x0
+			if first.op == "mov" && len(first.args) == 2 && first.args[0] == first.args[1] && isXRegister(first.args[0]) {
+				lines = remove(lines, i)
+				changed = true
+				i--
+				continue
+			}
+
+			// b etiqueta / etiqueta:
+			if first.op == "b" && len(first.args) == 1 && strings.TrimSpace(lines[j]) == first.args[0]+":" {
+				lines = remove(lines, i)
+				changed = true
+				i--
+				continue
+			}
+
+			second, ok := parseInstruction(lines[j])
+			if !ok {
+				continue
+			}
+			if dropFirst, replacement, ok := combine(first, second); ok {
+				if replacement == "" {
+					lines = remove(lines, j)
+				} else {
+					lines[j] = "    " + replacement
+				}
+				if dropFirst {
+					lines = remove(lines, i)
+				}
+				changed = true
+				i--
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// combine simplifica el par first, second: dropFirst indica si first sobra
+// y second es lo que reemplaza a la segunda, vacío si también sobra.
+func combine(first, second instruction) (dropFirst bool, replacement string, ok bool) {
+	switch {
+	// mov a, b / mov b, a: el segundo no cambia nada
+	case first.op == "mov" && second.op == "mov" && len(first.args) == 2 && len(second.args) == 2 &&
+		first.args[0] == second.args[1] && first.args[1] == second.args[0] && isXRegister(first.args[1]):
+		return false, "", true
+
+	// str a, [m] / ldr b, [m]: b ya está en a
+	case first.op == "str" && second.op == "ldr" && len(first.args) == 2 && len(second.args) == 2 &&
+		first.args[1] == second.args[1] && !strings.HasSuffix(first.args[1], "!") &&
+		isXRegister(first.args[0]) && isXRegister(second.args[0]):
+		return false, move(second.args[0], first.args[0]), true
+
+	// push a / pop b: el stack queda como estaba
+	case first.op == "str" && second.op == "ldr" && len(first.args) == 2 && len(second.args) == 3 &&
+		first.args[1] == "[sp, #-16]!" && second.args[1] == "[sp]" && second.args[2] == "#16" &&
+		isXRegister(first.args[0]) && isXRegister(second.args[0]):
+		return true, move(second.args[0], first.args[0]), true
+	}
+	return false, "", false
+}
+
+func move(dst, src string) string {
+	if dst == src {
+		return ""
+	}
+	return "mov " + dst + ", " + src
+}
+
+type instruction struct {
+	op   string
+	args []string
+}
+
+// parseInstruction separa una línea con una instrucción en su operación y
+// sus operandos. Las etiquetas, directivas y comentarios no son
+// instrucciones.
+func parseInstruction(line string) (instruction, bool) {
+	if !strings.HasPrefix(line, " ") {
+		return instruction{}, false
+	}
+	if comment := strings.Index(line, "//"); comment >= 0 {
+		line = line[:comment]
+	}
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, ".") || strings.HasSuffix(line, ":") {
+		return instruction{}, false
+	}
+
+	op, rest, _ := strings.Cut(line, " ")
+	var args []string
+	depth, start := 0, 0
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(rest[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(rest[start:]); last != "" {
+		args = append(args, last)
+	}
+	return instruction{op: op, args: args}, true
+}
+
+// nextLine devuelve la siguiente línea después de i que no es un comentario
+// ni está en blanco.
+func nextLine(lines []string, i int) int {
+	for j := i + 1; j < len(lines); j++ {
+		line := strings.TrimSpace(lines[j])
+		if line != "" && !strings.HasPrefix(line, "//") && !strings.HasPrefix(line, ".loc ") {
+			return j
+		}
+	}
+	return len(lines)
+}
+
+func remove(lines []string, i int) []string {
+	return append(lines[:i], lines[i+1:]...)
+}
+
+// isXRegister indica si operand es un registro de 64 bits x0-x30.
+func isXRegister(operand string) bool {
+	if len(operand) < 2 || operand[0] != 'x' {
+		return false
+	}
+	for _, c := range operand[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package arm64_test
+
+import (
+	"testing"
+
+	"main.go/compiler/arm64"
+)
+
+func TestPeephole(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+	}{
+		{
+			"mov a sí mismo",
+			"    mov x0, x0\n    add x0, x0, #1",
+			"    add x0, x0, #1",
+		},
+		{
+			// mov w0, w0 pone en cero los 32 bits altos
+			"mov de registros w",
+			"    mov w0, w0\n    ret",
+			"    mov w0, w0\n    ret",
+		},
+		{
+			"salto a la etiqueta siguiente",
+			"    b .Lfin\n    // fin\n.Lfin:\n    ret",
+			"    // fin\n.Lfin:\n    ret",
+		},
+		{
+			"mov de ida y vuelta",
+			"    mov x1, x0\n    mov x0, x1\n    ret",
+			"    mov x1, x0\n    ret",
+		},
+		{
+			"store y load de la misma dirección",
+			"    str x0, [sp, #8]\n    ldr x1, [sp, #8]",
+			"    str x0, [sp, #8]\n    mov x1, x0",
+		},
+		{
+			"store y load al mismo registro",
+			"    str x0, [sp, #8]\n\n    ldr x0, [sp, #8]\n    ret",
+			"    str x0, [sp, #8]\n\n    ret",
+		},
+		{
+			"push y pop",
+			"    str x0, [sp, #-16]!\n    ldr x1, [sp], #16\n    ret",
+			"    mov x1, x0\n    ret",
+		},
+		{
+			"push y pop del mismo registro",
+			"    str x0, [sp, #-16]!\n    ldr x0, [sp], #16\n    ret",
+			"    ret",
+		},
+		{
+			// alguien puede saltar a la etiqueta con otro valor en x1
+			"etiqueta en el medio",
+			"    str x0, [sp, #8]\n.L1:\n    ldr x1, [sp, #8]",
+			"    str x0, [sp, #8]\n.L1:\n    ldr x1, [sp, #8]",
+		},
+		{
+			"direcciones distintas",
+			"    str x0, [sp, #8]\n    ldr x1, [sp, #16]",
+			"    str x0, [sp, #8]\n    ldr x1, [sp, #16]",
+		},
+		{
+			// el load con writeback no lee lo que guardó el store
+			"store con writeback",
+			"    str x0, [sp, #-16]!\n    ldr x1, [sp, #-16]!",
+			"    str x0, [sp, #-16]!\n    ldr x1, [sp, #-16]!",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := arm64.Peephole(tt.before); got != tt.after {
+				t.Errorf("resultado distinto\n--- esperado\n%s\n--- obtenido\n%s", tt.after, got)
+			}
+		})
+	}
+}
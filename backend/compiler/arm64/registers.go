@@ -42,6 +42,7 @@ const (
 	// Aliases para registros especiales
 	FP = "x29" // Frame Pointer
 	LR = "x30" // Link Register
+	VB = "x28" // Variable Base: inicio del área de variables
 
 	// Stack Pointer y registros especiales
 	SP  = "sp"  // Stack Pointer
@@ -114,12 +115,19 @@ CONVENCIONES PARA NUESTRO TRADUCTOR:
    - 64: write (para print)
    - 93: exit (para terminar programa)
 
-4. X19-X28: Para valores que necesitan preservarse
-   - Variables importantes durante llamadas a funciones
-   - Contadores de bucles
+4. X19-X27: Temporales de expresiones (ver temps.go)
+   - Operandos izquierdos y argumentos ya evaluados
+   - Sobreviven a las llamadas a funciones
 
-5. SP: Stack Pointer
-   - Para almacenar variables locales
+5. X28 (VB): Base del área de variables
+   - Se fija en _start y no cambia
+
+6. X29 (FP): Marco de la función de usuario (ver frame.go)
+   - Parámetros y locales desde x29 + 16
+   - Argumentos del stack arriba del marco
+
+7. SP: Stack Pointer
+   - Área de variables reservada en _start
    - Gestión del stack
 
 EJEMPLO DE USO:
@@ -0,0 +1,491 @@
+package arm64
+
+// Runtime de los programas compilados: un heap que crece con brk, los
+// vectores, los strings (ver strings.go) y los errores de ejecución.
+//
+// Un vector es la dirección de una cabecera de 24 bytes en el heap:
+//
+//	[v + 0]   largo
+//	[v + 8]   capacidad
+//	[v + 16]  dirección de los elementos, 8 bytes cada uno
+//
+// Cuando se llena, los elementos pasan a un bloque del doble de capacidad y
+// la cabecera no se mueve, así las variables que apuntan al vector lo siguen
+// viendo. Una matriz es un vector de vectores (sus filas).
+
+// Tipos de elemento que print_vector recibe en x1.
+const (
+	ElemInt    = 0
+	ElemFloat  = 1
+	ElemBool   = 2
+	ElemString = 3
+	ElemRows   = 4 // se suma al tipo de los elementos de las filas de una matriz
+)
+
+// runtimeFunctions son las funciones del runtime, en el orden en que se
+// emiten.
+var runtimeFunctions = []struct {
+	name string
+	code func(sl *StandardLibrary) string
+}{
+	{"runtime_error", (*StandardLibrary).GetRuntimeError},
+	{"alloc", (*StandardLibrary).GetAlloc},
+	{"vec_new", (*StandardLibrary).GetVecNew},
+	{"vec_addr", (*StandardLibrary).GetVecAddr},
+	{"vec_append", (*StandardLibrary).GetVecAppend},
+	{"vec_copy", (*StandardLibrary).GetVecCopy},
+	{"vec_repeat", (*StandardLibrary).GetVecRepeat},
+	{"vec_remove", (*StandardLibrary).GetVecRemove},
+	{"vec_remove_last", (*StandardLibrary).GetVecRemoveLast},
+	{"print_vector", (*StandardLibrary).GetPrintVector},
+	{"vec_index_of", (*StandardLibrary).GetVecIndexOf},
+	{"str_len", (*StandardLibrary).GetStrLen},
+	{"str_copy", (*StandardLibrary).GetStrCopy},
+	{"str_concat", (*StandardLibrary).GetStrConcat},
+	{"str_compare", (*StandardLibrary).GetStrCompare},
+	{"str_put_char", (*StandardLibrary).GetStrPutChar},
+	{"str_put_integer", (*StandardLibrary).GetStrPutInteger},
+	{"str_from_int", (*StandardLibrary).GetStrFromInt},
+	{"format_float", (*StandardLibrary).GetFormatFloat},
+	{"str_from_float", (*StandardLibrary).GetStrFromFloat},
+	{"str_from_bool", (*StandardLibrary).GetStrFromBool},
+	{"str_to_double", (*StandardLibrary).GetStrToDouble},
+	{"str_parse_float", (*StandardLibrary).GetStrParseFloat},
+	{"str_atoi", (*StandardLibrary).GetStrAtoi},
+	{"str_join", (*StandardLibrary).GetStrJoin},
+}
+
+// GetRuntimeFunctions retorna el código de las funciones del runtime que se
+// usaron, seguido de sus datos. No incluye las de impresión que llaman.
+func (sl *StandardLibrary) GetRuntimeFunctions() string {
+	var code string
+	for _, function := range runtimeFunctions {
+		if sl.IsUsed(function.name) {
+			code += function.code(sl) + "\n"
+		}
+	}
+	if code == "" {
+		return ""
+	}
+	return code + sl.GetRuntimeData()
+}
+
+// GetRuntimeError retorna la función que termina el programa con un error
+func (sl *StandardLibrary) GetRuntimeError() string {
+	return `
+runtime_error:
+    // Escribe un mensaje en stderr y termina con código 1
+    // Input: x0 = dirección del mensaje (terminado en null)
+    mov x19, x0
+    mov x20, #0
+
+runtime_error_len:
+    ldrb w1, [x19, x20]
+    cbz w1, runtime_error_write
+    add x20, x20, #1
+    b runtime_error_len
+
+runtime_error_write:
+    mov x0, #2                   // stderr
+    mov x1, x19
+    mov x2, x20
+    mov x8, #64                  // write syscall
+    svc #0
+    mov x0, #1                   // código de salida
+    mov x8, #93                  // exit syscall
+    svc #0`
+}
+
+// GetAlloc retorna el asignador de memoria: entrega bloques consecutivos
+// del heap y nunca los libera. Pide memoria al sistema con brk de a 64 KiB
+// como mínimo.
+func (sl *StandardLibrary) GetAlloc() string {
+	return `
+alloc:
+    // Reserva memoria del heap
+    // Input: x0 = bytes
+    // Output: x0 = dirección del bloque, alineada a 16 y en cero
+    stp x29, x30, [sp, #-16]!    // Guardar registros
+    stp x19, x20, [sp, #-16]!
+
+    add x19, x0, #15
+    lsr x19, x19, #4
+    lsl x19, x19, #4             // x19 = bytes redondeados a 16
+
+    adr x9, heap_next
+    ldr x20, [x9]                // x20 = primer byte libre
+    cbnz x20, alloc_fits
+
+    // Primera reserva: el heap empieza en el break del proceso
+    mov x0, #0
+    mov x8, #214                 // brk syscall
+    svc #0
+    adr x9, heap_end
+    str x0, [x9]
+    add x0, x0, #15
+    lsr x0, x0, #4
+    lsl x20, x0, #4
+
+alloc_fits:
+    add x10, x20, x19            // x10 = primer byte libre después del bloque
+    adr x9, heap_end
+    ldr x11, [x9]
+    cmp x10, x11
+    b.ls alloc_done
+
+    // Agrandar el heap; brk devuelve el break anterior si no puede
+    mov x0, #65536
+    add x0, x10, x0
+    mov x8, #214                 // brk syscall
+    svc #0
+    cmp x0, x10
+    b.lo alloc_failed
+    adr x9, heap_end
+    str x0, [x9]
+
+alloc_done:
+    adr x9, heap_next
+    str x10, [x9]
+    mov x0, x20
+    ldp x19, x20, [sp], #16      // Restaurar registros
+    ldp x29, x30, [sp], #16
+    ret
+
+alloc_failed:
+    adr x0, err_memory
+    b runtime_error`
+}
+
+// GetVecNew retorna la función que crea un vector vacío
+func (sl *StandardLibrary) GetVecNew() string {
+	return `
+vec_new:
+    // Crea un vector vacío
+    // Input: x0 = capacidad inicial (4 como mínimo)
+    // Output: x0 = vector
+    stp x29, x30, [sp, #-16]!    // Guardar registros
+    stp x19, x20, [sp, #-16]!
+
+    mov x1, #4
+    cmp x0, x1
+    csel x19, x0, x1, hi         // x19 = capacidad
+
+    mov x0, #24
+    bl alloc
+    mov x20, x0                  // x20 = cabecera
+    lsl x0, x19, #3
+    bl alloc
+
+    str xzr, [x20]               // largo
+    str x19, [x20, #8]           // capacidad
+    str x0, [x20, #16]           // elementos
+    mov x0, x20
+
+    ldp x19, x20, [sp], #16      // Restaurar registros
+    ldp x29, x30, [sp], #16
+    ret`
+}
+
+// GetVecAddr retorna la función que calcula la dirección de un elemento y
+// controla que el índice esté en rango
+func (sl *StandardLibrary) GetVecAddr() string {
+	return `
+vec_addr:
+    // Dirección de un elemento de un vector
+    // Input: x0 = vector, x1 = índice
+    // Output: x0 = dirección del elemento
+    // Solo modifica x0 y x9; un índice fuera de rango termina el programa
+    ldr x9, [x0]                 // largo
+    cmp x1, x9
+    b.hs vec_addr_range          // sin signo, así los negativos también fallan
+    ldr x9, [x0, #16]
+    add x0, x9, x1, lsl #3
+    ret
+
+vec_addr_range:
+    adr x0, err_index
+    b runtime_error`
+}
+
+// GetVecAppend retorna la función que agrega un elemento al final
+func (sl *StandardLibrary) GetVecAppend() string {
+	return `
+vec_append:
+    // Agrega un elemento al final, duplicando la capacidad si hace falta
+    // Input: x0 = vector, x1 = valor
+    // Output: x0 = vector
+    stp x29, x30, [sp, #-16]!    // Guardar registros
+    stp x19, x20, [sp, #-16]!
+    stp x21, x22, [sp, #-16]!
+
+    mov x19, x0                  // x19 = vector
+    mov x20, x1                  // x20 = valor
+    ldr x21, [x19]               // x21 = largo
+    ldr x0, [x19, #8]
+    cmp x21, x0
+    b.lo vec_append_store
+
+    // Sin lugar: pasar los elementos a un bloque del doble de capacidad
+    lsl x22, x0, #1              // x22 = capacidad nueva
+    lsl x0, x22, #3
+    bl alloc
+    ldr x1, [x19, #16]
+    mov x2, #0
+
+vec_append_copy:
+    cmp x2, x21
+    b.hs vec_append_grown
+    ldr x3, [x1, x2, lsl #3]
+    str x3, [x0, x2, lsl #3]
+    add x2, x2, #1
+    b vec_append_copy
+
+vec_append_grown:
+    str x22, [x19, #8]
+    str x0, [x19, #16]
+
+vec_append_store:
+    ldr x0, [x19, #16]
+    str x20, [x0, x21, lsl #3]
+    add x21, x21, #1
+    str x21, [x19]
+    mov x0, x19
+
+    ldp x21, x22, [sp], #16      // Restaurar registros
+    ldp x19, x20, [sp], #16
+    ldp x29, x30, [sp], #16
+    ret`
+}
+
+// GetVecCopy retorna la función que copia un vector. Con más de un nivel
+// también copia los elementos, como las filas de una matriz.
+func (sl *StandardLibrary) GetVecCopy() string {
+	return `
+vec_copy:
+    // Copia un vector
+    // Input: x0 = vector, x1 = niveles (1 vector, 2 matriz)
+    // Output: x0 = copia
+    stp x29, x30, [sp, #-16]!    // Guardar registros
+    stp x19, x20, [sp, #-16]!
+    stp x21, x22, [sp, #-16]!
+
+    mov x19, x0                  // x19 = original
+    sub x20, x1, #1              // x20 = niveles de los elementos
+    ldr x0, [x19]
+    bl vec_new
+    mov x21, x0                  // x21 = copia
+    mov x22, #0                  // x22 = índice
+
+vec_copy_loop:
+    ldr x0, [x19]
+    cmp x22, x0
+    b.hs vec_copy_done
+    ldr x0, [x19, #16]
+    ldr x0, [x0, x22, lsl #3]
+    cbz x20, vec_copy_append
+    mov x1, x20
+    bl vec_copy
+
+vec_copy_append:
+    mov x1, x0
+    mov x0, x21
+    bl vec_append
+    add x22, x22, #1
+    b vec_copy_loop
+
+vec_copy_done:
+    mov x0, x21
+    ldp x21, x22, [sp], #16      // Restaurar registros
+    ldp x19, x20, [sp], #16
+    ldp x29, x30, [sp], #16
+    ret`
+}
+
+// GetVecRepeat retorna la función que crea un vector con un valor repetido
+func (sl *StandardLibrary) GetVecRepeat() string {
+	return `
+vec_repeat:
+    // Crea un vector con count veces el mismo valor
+    // Input: x0 = count (negativo equivale a 0), x1 = valor,
+    //        x2 = niveles a copiar del valor en cada elemento (0 si no es
+    //        un vector)
+    // Output: x0 = vector
+    stp x29, x30, [sp, #-16]!    // Guardar registros
+    stp x19, x20, [sp, #-16]!
+    stp x21, x22, [sp, #-16]!
+
+    mov x19, x1                  // x19 = valor
+    mov x20, x2                  // x20 = niveles
+    cmp x0, #0
+    csel x22, x0, xzr, gt        // x22 = elementos que faltan
+    mov x0, x22
+    bl vec_new
+    mov x21, x0                  // x21 = vector
+
+vec_repeat_loop:
+    cbz x22, vec_repeat_done
+    mov x0, x19
+    cbz x20, vec_repeat_append
+    mov x1, x20
+    bl vec_copy
+
+vec_repeat_append:
+    mov x1, x0
+    mov x0, x21
+    bl vec_append
+    sub x22, x22, #1
+    b vec_repeat_loop
+
+vec_repeat_done:
+    mov x0, x21
+    ldp x21, x22, [sp], #16      // Restaurar registros
+    ldp x19, x20, [sp], #16
+    ldp x29, x30, [sp], #16
+    ret`
+}
+
+// GetVecRemove retorna la función que quita el elemento de un índice
+func (sl *StandardLibrary) GetVecRemove() string {
+	return `
+vec_remove:
+    // Quita un elemento y corre los siguientes un lugar
+    // Input: x0 = vector, x1 = índice
+    stp x29, x30, [sp, #-16]!    // Guardar registros
+
+    mov x2, x0                   // x2 = vector
+    bl vec_addr                  // x0 = dirección del elemento
+    ldr x3, [x2]
+    sub x3, x3, #1
+    str x3, [x2]                 // largo nuevo
+    sub x3, x3, x1               // x3 = elementos a correr
+
+vec_remove_shift:
+    cbz x3, vec_remove_done
+    ldr x4, [x0, #8]
+    str x4, [x0]
+    add x0, x0, #8
+    sub x3, x3, #1
+    b vec_remove_shift
+
+vec_remove_done:
+    ldp x29, x30, [sp], #16      // Restaurar registros
+    ret`
+}
+
+// GetVecRemoveLast retorna la función que quita el último elemento
+func (sl *StandardLibrary) GetVecRemoveLast() string {
+	return `
+vec_remove_last:
+    // Quita el último elemento
+    // Input: x0 = vector; si está vacío termina el programa
+    ldr x9, [x0]
+    cbz x9, vec_remove_last_empty
+    sub x9, x9, #1
+    str x9, [x0]
+    ret
+
+vec_remove_last_empty:
+    adr x0, err_empty
+    b runtime_error`
+}
+
+// GetPrintVector retorna la función que imprime un vector como
+// formatVector, o una matriz como formatMatrix. Usa print_integer,
+// print_float, print_bool, print_string y print_char.
+func (sl *StandardLibrary) GetPrintVector() string {
+	return `
+print_vector:
+    // Imprime un vector: [ 1 2 3 ], o [ ] si está vacío
+    // Input: x0 = vector, x1 = tipo de los elementos (ver ElemInt...)
+    ldr x9, [x0]
+    cbnz x9, print_vector_items
+    adr x0, vec_empty_str
+    b print_string
+
+print_vector_items:
+    // Las filas de una matriz se imprimen con corchetes aunque estén vacías
+    stp x29, x30, [sp, #-16]!    // Guardar registros
+    stp x19, x20, [sp, #-16]!
+    stp x21, x22, [sp, #-16]!
+
+    mov x19, x0                  // x19 = vector
+    mov x20, x1                  // x20 = tipo de los elementos
+    mov x21, #0                  // x21 = índice
+    adr x0, vec_open_str
+    bl print_string
+
+print_vector_loop:
+    ldr x0, [x19]
+    cmp x21, x0
+    b.hs print_vector_close
+    cbz x21, print_vector_item
+    mov x0, #32                  // ASCII espacio
+    bl print_char
+
+print_vector_item:
+    ldr x0, [x19, #16]
+    ldr x0, [x0, x21, lsl #3]
+    cmp x20, #4
+    b.lo print_vector_scalar
+    sub x1, x20, #4
+    bl print_vector_items
+    b print_vector_next
+
+print_vector_scalar:
+    cmp x20, #1
+    b.eq print_vector_float
+    cmp x20, #2
+    b.eq print_vector_bool
+    cmp x20, #3
+    b.eq print_vector_string
+    bl print_integer
+    b print_vector_next
+
+print_vector_float:
+    fmov d0, x0
+    mov x1, #4                   // decimales, como print
+    bl print_float
+    b print_vector_next
+
+print_vector_bool:
+    bl print_bool
+    b print_vector_next
+
+print_vector_string:
+    bl print_string
+
+print_vector_next:
+    add x21, x21, #1
+    b print_vector_loop
+
+print_vector_close:
+    adr x0, vec_close_str
+    bl print_string
+    ldp x21, x22, [sp], #16      // Restaurar registros
+    ldp x19, x20, [sp], #16
+    ldp x29, x30, [sp], #16
+    ret`
+}
+
+// GetRuntimeData retorna los datos del runtime: el estado del heap, el
+// buffer de las conversiones a string y los mensajes de error
+func (sl *StandardLibrary) GetRuntimeData() string {
+	return `
+// === DATOS DEL RUNTIME ===
+.data
+.balign 8
+heap_next:      .quad 0
+heap_end:       .quad 0
+str_cursor:     .quad 0
+err_index:      .asciz "Error de ejecución: índice fuera de rango\n"
+err_empty:      .asciz "Error de ejecución: el vector está vacío\n"
+err_memory:     .asciz "Error de ejecución: memoria agotada\n"
+err_parse_int:  .asciz "Error de ejecución: no se pudo convertir el valor a int\n"
+err_parse_float: .asciz "Error de ejecución: no se pudo convertir el valor a float\n"
+vec_empty_str:  .asciz "[ ]"
+vec_open_str:   .asciz "[ "
+vec_close_str:  .asciz " ]"
+str_true:       .asciz "true"
+str_false:      .asciz "false"
+`
+}
@@ -0,0 +1,489 @@
+package arm64
+
+import (
+	"fmt"
+
+	"main.go/ast"
+	"main.go/compiler/ir"
+)
+
+// Select emite el programa ARM64 de un módulo del IR. Cada variable local y
+// cada registro virtual tiene su lugar en el marco de la función, direccionado
+// desde sp; las globales van en .data. Para operar, los valores se cargan en
+// x0 y x1 (los float se pasan a d0 y d1) y el resultado se guarda de vuelta.
+//
+// Las llamadas siguen AAPCS64 (ver AssignArguments): los argumentos van en
+// x0-x7 y d0-d7 y los que sobran en el stack; el resultado vuelve en x0, o
+// en d0 si es float. Las funciones solo usan registros caller-saved, así que
+// el prólogo guarda únicamente x29 y x30.
+func Select(module *ir.Module, source string) string {
+	s := &selector{
+		g:      NewARM64Generator(),
+		stdlib: NewStandardLibrary(),
+	}
+	s.g.SourceFile = source
+
+	s.g.EmitRaw(".text")
+	s.g.EmitRaw(".global _start")
+	for _, f := range module.AllFunctions() {
+		s.g.EmitRaw("")
+		s.function(f)
+	}
+
+	s.g.SetPosition(Position{})
+	s.g.EmitRaw("")
+	s.g.EmitRaw("// === LIBRERÍA ESTÁNDAR ===")
+	s.g.EmitRaw(s.stdlib.GetPrintFunctions())
+	if runtime := s.stdlib.GetRuntimeFunctions(); runtime != "" {
+		s.g.EmitRaw("")
+		s.g.EmitRaw("// === RUNTIME ===")
+		s.g.EmitRaw(runtime)
+	}
+	s.g.EmitRaw(s.stdlib.GetStandardData())
+
+	s.g.GenerateStringData()
+	s.g.GenerateFloatData()
+	if len(module.Globals) > 0 {
+		s.g.EmitRaw("")
+		s.g.EmitRaw(".data")
+		s.g.EmitRaw(".balign 8")
+		for _, global := range module.Globals {
+			s.g.EmitRaw(fmt.Sprintf("%s: .quad 0", globalLabel(global)))
+		}
+	}
+
+	return s.g.GetCode()
+}
+
+type selector struct {
+	g      *ARM64Generator
+	stdlib *StandardLibrary
+
+	fn    *ir.Function
+	next  *ir.Block       // bloque que se emite a continuación del actual
+	vars  map[*ir.Var]int // offset desde sp de cada local
+	regs  []int           // offset desde sp de cada registro virtual
+	frame int
+	shift int // bytes que sp bajó desde el marco, mientras se arma una llamada
+}
+
+// functionLabel es la etiqueta de una función: el programa principal es
+// _start y las de usuario llevan un prefijo para no chocar con la librería.
+func functionLabel(name string) string {
+	if name == ir.MainName {
+		return name
+	}
+	return "func_" + name
+}
+
+// position convierte una posición del árbol en la que registra el
+// generador.
+func position(pos ast.Pos) Position {
+	return Position{Line: pos.Line, Column: pos.Column}
+}
+
+func globalLabel(v *ir.Var) string {
+	return "glb_" + v.Name
+}
+
+func (s *selector) blockLabel(b *ir.Block) string {
+	return fmt.Sprintf(".L%s.%s", s.fn.Name, b.Name)
+}
+
+// function emite una función: el prólogo reserva el marco con un lugar de 8
+// bytes por local y por registro virtual, alineado a 16.
+func (s *selector) function(f *ir.Function) {
+	s.fn = f
+	s.vars = make(map[*ir.Var]int)
+	s.regs = make([]int, f.NumRegs())
+
+	offset := 0
+	for _, local := range f.Locals {
+		s.vars[local] = offset
+		offset += 8
+	}
+	for i := range s.regs {
+		s.regs[i] = offset
+		offset += 8
+	}
+	s.frame = (offset + 15) &^ 15
+
+	s.g.SetPosition(position(f.Pos))
+	s.g.SetLabel(functionLabel(f.Name))
+	s.g.Comment(fmt.Sprintf("Prólogo: marco de %d bytes", s.frame))
+	s.g.Emit("stp x29, x30, [sp, #-16]!")
+	s.g.Emit("mov x29, sp")
+	if s.frame > 0 {
+		s.addImmediate("sp", "sp", -s.frame)
+	}
+	// los argumentos del stack quedaron arriba del registro de marco; se
+	// copian con x9 para no pisar los de x0-x7
+	locations, _ := AssignArguments(floatParams(f.Params))
+	for i, param := range f.Params {
+		if register := locations[i].Register; register != "" {
+			s.g.Emit(fmt.Sprintf("str %s, %s", register, s.address(s.vars[param])))
+			continue
+		}
+		s.g.Emit(fmt.Sprintf("ldr x9, [x29, #%d]", frameRecord+locations[i].Offset))
+		s.g.Emit(fmt.Sprintf("str x9, %s", s.address(s.vars[param])))
+	}
+
+	for i, b := range f.Blocks {
+		s.next = nil
+		if i+1 < len(f.Blocks) {
+			s.next = f.Blocks[i+1]
+		}
+		if i > 0 {
+			s.g.SetLabel(s.blockLabel(b))
+		}
+		for _, instr := range b.Instrs {
+			s.g.SetPosition(position(instr.Pos))
+			s.g.Comment(instr.String())
+			s.instr(instr)
+		}
+	}
+}
+
+// === OPERANDOS ===
+
+// address devuelve el operando de memoria del lugar en offset. Si el offset
+// no entra en el inmediato de ldr/str, arma la dirección en x17.
+func (s *selector) address(offset int) string {
+	offset += s.shift
+	if offset <= 32760 {
+		return fmt.Sprintf("[sp, #%d]", offset)
+	}
+	s.loadImmediate("x17", int64(offset))
+	s.g.Emit("add x17, sp, x17")
+	return "[x17]"
+}
+
+// addImmediate emite dst = src + value, para sp y los offsets del marco.
+func (s *selector) addImmediate(dst, src string, value int) {
+	op := "add"
+	if value < 0 {
+		op, value = "sub", -value
+	}
+	if value < 4096 {
+		s.g.Emit(fmt.Sprintf("%s %s, %s, #%d", op, dst, src, value))
+		return
+	}
+	s.loadImmediate("x16", int64(value))
+	s.g.Emit(fmt.Sprintf("%s %s, %s, x16", op, dst, src))
+}
+
+// loadImmediate carga una constante entera de 64 bits: con un mov si entra
+// en 16 bits y si no de a 16 bits con movz y movk.
+func (s *selector) loadImmediate(reg string, value int64) {
+	if value >= -65536 && value < 65536 {
+		s.g.Emit(fmt.Sprintf("mov %s, #%d", reg, value))
+		return
+	}
+	bits := uint64(value)
+	s.g.Emit(fmt.Sprintf("movz %s, #%d", reg, bits&0xffff))
+	for shift := 16; shift < 64; shift += 16 {
+		if chunk := (bits >> shift) & 0xffff; chunk != 0 {
+			s.g.Emit(fmt.Sprintf("movk %s, #%d, lsl #%d", reg, chunk, shift))
+		}
+	}
+}
+
+// load deja val en el registro reg; los float quedan como bits.
+func (s *selector) load(reg string, val ir.Value) {
+	switch v := val.(type) {
+	case *ir.Reg:
+		s.g.Emit(fmt.Sprintf("ldr %s, %s", reg, s.address(s.regs[v.ID])))
+	case ir.Const:
+		switch v.T {
+		case ir.Float:
+			s.g.Emit(fmt.Sprintf("adr x16, %s", s.g.AddFloatLiteral(v.Float)))
+			s.g.Emit(fmt.Sprintf("ldr %s, [x16]", reg))
+		case ir.String:
+			s.g.Emit(fmt.Sprintf("adr %s, %s", reg, s.g.AddStringLiteral(EscapeString(v.Str))))
+		default:
+			s.loadImmediate(reg, v.Int)
+		}
+	}
+}
+
+// define guarda en el lugar de dst el resultado que quedó en x0.
+func (s *selector) define(dst *ir.Reg) {
+	s.g.Emit(fmt.Sprintf("str x0, %s", s.address(s.regs[dst.ID])))
+}
+
+// === INSTRUCCIONES ===
+
+var intOps = map[ir.Op]string{ir.OpAdd: "add", ir.OpSub: "sub", ir.OpMul: "mul", ir.OpDiv: "sdiv"}
+
+var floatOps = map[ir.Op]string{ir.OpAdd: "fadd", ir.OpSub: "fsub", ir.OpMul: "fmul", ir.OpDiv: "fdiv"}
+
+// Condiciones de cset tras cmp y tras fcmp. Con fcmp, mi y ls son falsas si
+// algún operando es NaN, igual que < y <= en Go.
+var (
+	intConditions   = map[ir.Op]string{ir.OpEq: "eq", ir.OpNe: "ne", ir.OpLt: "lt", ir.OpLe: "le", ir.OpGt: "gt", ir.OpGe: "ge"}
+	floatConditions = map[ir.Op]string{ir.OpEq: "eq", ir.OpNe: "ne", ir.OpLt: "mi", ir.OpLe: "ls", ir.OpGt: "gt", ir.OpGe: "ge"}
+)
+
+func (s *selector) instr(instr *ir.Instr) {
+	switch op := instr.Op; {
+	case op == ir.OpCopy:
+		s.load("x0", instr.Args[0])
+		s.define(instr.Dst)
+
+	case op == ir.OpMod:
+		s.load("x0", instr.Args[0])
+		s.load("x1", instr.Args[1])
+		s.g.Emit("sdiv x2, x0, x1")
+		s.g.Emit("msub x0, x2, x1, x0")
+		s.define(instr.Dst)
+
+	case op == ir.OpAdd && instr.Type == ir.String:
+		s.load("x0", instr.Args[0])
+		s.load("x1", instr.Args[1])
+		s.callRuntime("str_concat")
+		s.define(instr.Dst)
+
+	case intOps[op] != "" && instr.Type == ir.Float:
+		s.loadFloats(instr.Args)
+		s.g.Emit(fmt.Sprintf("%s d0, d0, d1", floatOps[op]))
+		s.g.Emit("fmov x0, d0")
+		s.define(instr.Dst)
+
+	case intOps[op] != "":
+		s.load("x0", instr.Args[0])
+		s.load("x1", instr.Args[1])
+		s.g.Emit(fmt.Sprintf("%s x0, x0, x1", intOps[op]))
+		s.define(instr.Dst)
+
+	case op == ir.OpNeg && instr.Type == ir.Float:
+		s.loadFloats(instr.Args)
+		s.g.Emit("fneg d0, d0")
+		s.g.Emit("fmov x0, d0")
+		s.define(instr.Dst)
+
+	case op == ir.OpNeg:
+		s.load("x0", instr.Args[0])
+		s.g.Emit("neg x0, x0")
+		s.define(instr.Dst)
+
+	case op == ir.OpNot:
+		s.load("x0", instr.Args[0])
+		s.g.Emit("eor x0, x0, #1")
+		s.define(instr.Dst)
+
+	case op.IsComparison() && instr.Type == ir.Float:
+		s.loadFloats(instr.Args)
+		s.g.Emit("fcmp d0, d1")
+		s.g.Emit(fmt.Sprintf("cset x0, %s", floatConditions[op]))
+		s.define(instr.Dst)
+
+	case op.IsComparison() && instr.Type == ir.String:
+		s.load("x0", instr.Args[0])
+		s.load("x1", instr.Args[1])
+		s.callRuntime("str_compare")
+		s.g.Emit("cmp x0, #0")
+		s.g.Emit(fmt.Sprintf("cset x0, %s", intConditions[op]))
+		s.define(instr.Dst)
+
+	case op.IsComparison():
+		s.load("x0", instr.Args[0])
+		s.load("x1", instr.Args[1])
+		s.g.Emit("cmp x0, x1")
+		s.g.Emit(fmt.Sprintf("cset x0, %s", intConditions[op]))
+		s.define(instr.Dst)
+
+	case op == ir.OpIntToFloat:
+		s.load("x0", instr.Args[0])
+		s.g.Emit("scvtf d0, x0")
+		s.g.Emit("fmov x0, d0")
+		s.define(instr.Dst)
+
+	case op == ir.OpToString:
+		s.toString(instr)
+
+	case op == ir.OpLoad:
+		if instr.Var.Global {
+			s.g.Emit(fmt.Sprintf("adr x16, %s", globalLabel(instr.Var)))
+			s.g.Emit("ldr x0, [x16]")
+		} else {
+			s.g.Emit(fmt.Sprintf("ldr x0, %s", s.address(s.vars[instr.Var])))
+		}
+		s.define(instr.Dst)
+
+	case op == ir.OpStore:
+		s.load("x0", instr.Args[0])
+		if instr.Var.Global {
+			s.g.Emit(fmt.Sprintf("adr x16, %s", globalLabel(instr.Var)))
+			s.g.Emit("str x0, [x16]")
+		} else {
+			s.g.Emit(fmt.Sprintf("str x0, %s", s.address(s.vars[instr.Var])))
+		}
+
+	case op == ir.OpCall:
+		s.call(instr)
+
+	case op == ir.OpPrint:
+		s.print(instr)
+
+	case op == ir.OpJump:
+		if instr.Targets[0] != s.next {
+			s.g.Emit(fmt.Sprintf("b %s", s.blockLabel(instr.Targets[0])))
+		}
+
+	case op == ir.OpBranch:
+		s.branch(instr)
+
+	case op == ir.OpReturn:
+		s.ret(instr)
+
+	default:
+		panic(fmt.Sprintf("arm64: instrucción %s sin selección", instr))
+	}
+}
+
+// loadFloats carga los operandos float en d0 y d1.
+func (s *selector) loadFloats(args []ir.Value) {
+	for i, arg := range args {
+		s.load(fmt.Sprintf("x%d", i), arg)
+		s.g.Emit(fmt.Sprintf("fmov d%d, x%d", i, i))
+	}
+}
+
+// call pasa los argumentos donde los ubica AssignArguments: primero baja sp
+// y copia los que van en el stack, después carga los de registros, que la
+// copia pisaría.
+func (s *selector) call(instr *ir.Instr) {
+	floats := make([]bool, len(instr.Args))
+	for i, arg := range instr.Args {
+		floats[i] = arg.Type() == ir.Float
+	}
+	locations, stackSize := AssignArguments(floats)
+
+	if stackSize > 0 {
+		s.addImmediate("sp", "sp", -stackSize)
+		s.shift = stackSize
+		for i, arg := range instr.Args {
+			if locations[i].Register == "" {
+				s.load("x0", arg)
+				s.g.Emit(fmt.Sprintf("str x0, [sp, #%d]", locations[i].Offset))
+			}
+		}
+	}
+	for i, arg := range instr.Args {
+		switch register := locations[i].Register; {
+		case register == "":
+		case arg.Type() == ir.Float:
+			s.load("x9", arg)
+			s.g.Emit(fmt.Sprintf("fmov %s, x9", register))
+		default:
+			s.load(register, arg)
+		}
+	}
+
+	s.g.CallFunction(functionLabel(instr.Func))
+	if stackSize > 0 {
+		s.addImmediate("sp", "sp", stackSize)
+		s.shift = 0
+	}
+	if instr.Dst != nil {
+		if instr.Type == ir.Float {
+			s.g.Emit("fmov x0, d0")
+		}
+		s.define(instr.Dst)
+	}
+}
+
+// floatParams indica cuáles de params son float, para AssignArguments.
+func floatParams(params []*ir.Var) []bool {
+	floats := make([]bool, len(params))
+	for i, param := range params {
+		floats[i] = param.T == ir.Float
+	}
+	return floats
+}
+
+// callRuntime llama a una función de la librería y la marca como usada.
+func (s *selector) callRuntime(name string) {
+	s.stdlib.MarkUsed(name)
+	s.g.CallFunction(name)
+}
+
+// toString convierte el valor a string con la rutina del runtime que
+// corresponde a su tipo.
+func (s *selector) toString(instr *ir.Instr) {
+	s.load("x0", instr.Args[0])
+	switch instr.Type {
+	case ir.String:
+	case ir.Bool:
+		s.callRuntime("str_from_bool")
+	case ir.Float:
+		s.g.Emit("fmov d0, x0")
+		s.g.Emit(fmt.Sprintf("mov x1, #%d", instr.Aux))
+		s.callRuntime("str_from_float")
+	default:
+		s.callRuntime("str_from_int")
+	}
+	s.define(instr.Dst)
+}
+
+// print llama a la rutina de la librería que corresponde al tipo. Un string
+// constante de un solo carácter se imprime con print_char.
+func (s *selector) print(instr *ir.Instr) {
+	val := instr.Args[0]
+	routine := "print_integer"
+	switch instr.Type {
+	case ir.Bool:
+		routine = "print_bool"
+	case ir.String:
+		routine = "print_string"
+		if c, ok := val.(ir.Const); ok && len(c.Str) == 1 {
+			routine = "print_char"
+			val = ir.IntConst(int64(c.Str[0]))
+		}
+	}
+
+	s.load("x0", val)
+	if instr.Type == ir.Float {
+		routine = "print_float"
+		s.g.Emit("fmov d0, x0")
+		s.g.Emit(fmt.Sprintf("mov x1, #%d", instr.Aux))
+	}
+	s.callRuntime(routine)
+}
+
+// branch salta al bloque que corresponde; si uno de los dos es el siguiente
+// se llega a él sin saltar.
+func (s *selector) branch(instr *ir.Instr) {
+	then, otherwise := instr.Targets[0], instr.Targets[1]
+	s.load("x0", instr.Args[0])
+	switch {
+	case then == s.next:
+		s.g.Emit(fmt.Sprintf("cbz x0, %s", s.blockLabel(otherwise)))
+	case otherwise == s.next:
+		s.g.Emit(fmt.Sprintf("cbnz x0, %s", s.blockLabel(then)))
+	default:
+		s.g.Emit(fmt.Sprintf("cbz x0, %s", s.blockLabel(otherwise)))
+		s.g.Emit(fmt.Sprintf("b %s", s.blockLabel(then)))
+	}
+}
+
+// ret deja el resultado en x0 y deshace el marco. En el programa principal
+// termina el proceso con código 0.
+func (s *selector) ret(instr *ir.Instr) {
+	if s.fn.Name == ir.MainName {
+		s.g.Comment("Terminar programa con código de salida 0")
+		s.g.Emit("mov x0, #0")
+		s.g.Emit("mov x8, #93")
+		s.g.Emit("svc #0")
+		return
+	}
+	if len(instr.Args) > 0 {
+		s.load("x0", instr.Args[0])
+		if s.fn.Result == ir.Float {
+			s.g.Emit("fmov d0, x0")
+		}
+	}
+	s.g.Emit("mov sp, x29")
+	s.g.Emit("ldp x29, x30, [sp], #16")
+	s.g.Emit("ret")
+}
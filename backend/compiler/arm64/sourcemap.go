@@ -0,0 +1,135 @@
+package arm64
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// === POSICIONES EN EL PROGRAMA VLANG ===
+//
+// Cada línea emitida guarda la posición de la sentencia VLang de la que
+// sale. GetCode las escribe como directivas DWARF:
+//
+//	.file 1 "<ruta del programa>"
+//	.loc 1 <línea> <columna>
+//
+// así el ensamblador arma la tabla de líneas que usan gdb y addr2line, y
+// SourceMap reconstruye desde el texto final, después del peephole, qué
+// bloque de ensamblador generó cada sentencia.
+
+// DefaultSourceFile es el nombre del programa en la directiva .file cuando
+// no se conoce su ruta, como en la entrada estándar.
+const DefaultSourceFile = "programa.vch"
+
+// Position es una posición del programa VLang. Line empieza en 1 y Column en
+// 0, como los tokens de ANTLR; Line 0 indica código que no sale de ninguna
+// sentencia, como el inicio del programa o la librería.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// line es una línea del código generado y la posición de la que sale.
+type line struct {
+	text string
+	pos  Position
+}
+
+// SetPosition fija la posición que se registra con lo que se emita desde
+// ahora.
+func (g *ARM64Generator) SetPosition(pos Position) {
+	g.position = pos
+}
+
+// Position devuelve la posición que se está registrando.
+func (g *ARM64Generator) Position() Position {
+	return g.position
+}
+
+// hasPositions indica si alguna línea salió de una sentencia.
+func (g *ARM64Generator) hasPositions() bool {
+	for _, l := range g.instructions {
+		if l.pos.Line > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// locDirective escribe la directiva .loc de pos. Las columnas de DWARF
+// empiezan en 1.
+func locDirective(pos Position) string {
+	if pos.Line == 0 {
+		return ".loc 1 0 0"
+	}
+	return fmt.Sprintf(".loc 1 %d %d", pos.Line, pos.Column+1)
+}
+
+// SourceMapping relaciona las líneas AsmStart a AsmEnd del ensamblador,
+// contadas desde 1, con la sentencia VLang que las generó.
+type SourceMapping struct {
+	Position
+	AsmStart int `json:"asmStart"`
+	AsmEnd   int `json:"asmEnd"`
+}
+
+// SourceMap lee las directivas .loc de assembly y devuelve el bloque de
+// líneas que sale de cada sentencia, en el orden del código. Un bloque
+// termina en el siguiente .loc, sin las líneas en blanco del final.
+func SourceMap(assembly string) []SourceMapping {
+	mappings := []SourceMapping{}
+	lines := strings.Split(assembly, "\n")
+
+	var open *SourceMapping
+	closeBlock := func(next int) {
+		if open == nil {
+			return
+		}
+		end := next - 1
+		for end >= open.AsmStart && strings.TrimSpace(lines[end-1]) == "" {
+			end--
+		}
+		if end >= open.AsmStart {
+			open.AsmEnd = end
+			mappings = append(mappings, *open)
+		}
+		open = nil
+	}
+
+	for i, text := range lines {
+		pos, ok := parseLoc(text)
+		if !ok {
+			continue
+		}
+		// la línea i es la i+1 contando desde 1
+		closeBlock(i + 1)
+		if pos.Line > 0 {
+			open = &SourceMapping{Position: pos, AsmStart: i + 2}
+		}
+	}
+	closeBlock(len(lines) + 1)
+	return mappings
+}
+
+// parseLoc lee una directiva ".loc 1 línea columna".
+func parseLoc(text string) (Position, bool) {
+	fields := strings.Fields(text)
+	if len(fields) < 3 || fields[0] != ".loc" {
+		return Position{}, false
+	}
+	lineNumber, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return Position{}, false
+	}
+	column := 0
+	if len(fields) > 3 {
+		if column, err = strconv.Atoi(fields[3]); err != nil {
+			return Position{}, false
+		}
+	}
+	if column > 0 {
+		column--
+	}
+	return Position{Line: lineNumber, Column: column}, true
+}
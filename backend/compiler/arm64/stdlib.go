@@ -1,5 +1,7 @@
 package arm64
 
+import "strings"
+
 // StandardLibrary contiene todas las funciones de la librería estándar ARM64
 type StandardLibrary struct {
 	usedFunctions map[string]bool // Funciones que se han usado
@@ -12,9 +14,43 @@ func NewStandardLibrary() *StandardLibrary {
 	}
 }
 
-// MarkUsed marca una función como usada
+// dependencies son las funciones de la librería que llama cada una.
+var dependencies = map[string][]string{
+	"print_integer": {"print_char"},
+	"print_float":   {"print_integer", "print_char"},
+	"print_bool":    {"print_string"},
+
+	"alloc":           {"runtime_error"},
+	"vec_new":         {"alloc"},
+	"vec_addr":        {"runtime_error"},
+	"vec_append":      {"alloc"},
+	"vec_copy":        {"vec_new", "vec_append"},
+	"vec_repeat":      {"vec_new", "vec_append", "vec_copy"},
+	"vec_remove":      {"vec_addr"},
+	"vec_remove_last": {"runtime_error"},
+	"print_vector":    {"print_integer", "print_float", "print_bool", "print_string", "print_char"},
+	"vec_index_of":    {"str_compare"},
+
+	"str_concat":      {"str_len", "str_copy", "alloc"},
+	"str_put_integer": {"str_put_char"},
+	"str_from_int":    {"alloc", "str_put_integer"},
+	"format_float":    {"str_put_char", "str_put_integer"},
+	"str_from_float":  {"alloc", "format_float"},
+	"str_to_double":   {"runtime_error"},
+	"str_parse_float": {"str_to_double"},
+	"str_atoi":        {"str_to_double"},
+	"str_join":        {"str_len", "str_copy", "alloc"},
+}
+
+// MarkUsed marca una función como usada, junto con las que ella llama
 func (sl *StandardLibrary) MarkUsed(functionName string) {
+	if sl.usedFunctions[functionName] {
+		return
+	}
 	sl.usedFunctions[functionName] = true
+	for _, dependency := range dependencies[functionName] {
+		sl.MarkUsed(dependency)
+	}
 }
 
 // IsUsed verifica si una función ha sido usada
@@ -22,37 +58,37 @@ func (sl *StandardLibrary) IsUsed(functionName string) bool {
 	return sl.usedFunctions[functionName]
 }
 
-// GetAllFunctions retorna el código de todas las funciones usadas
-func (sl *StandardLibrary) GetAllFunctions() string {
-	var code string
-
-	// Agregar funciones según las que se hayan usado
-	if sl.IsUsed("print_integer") {
-		code += sl.GetPrintInteger() + "\n"
-	}
-
-	if sl.IsUsed("print_char") {
-		code += sl.GetPrintChar() + "\n"
-	}
-
-	if sl.IsUsed("print_string") {
-		code += sl.GetPrintString() + "\n"
-	}
-
-	if sl.IsUsed("print_float") {
-		code += sl.GetPrintFloat() + "\n"
-	}
+// printFunctions son las funciones de impresión, en el orden en que se
+// emiten.
+var printFunctions = []struct {
+	name string
+	code func(sl *StandardLibrary) string
+}{
+	{"print_integer", (*StandardLibrary).GetPrintInteger},
+	{"print_char", (*StandardLibrary).GetPrintChar},
+	{"print_string", (*StandardLibrary).GetPrintString},
+	{"print_float", (*StandardLibrary).GetPrintFloat},
+	{"print_bool", (*StandardLibrary).GetPrintBool},
+}
 
-	if sl.IsUsed("print_bool") {
-		code += sl.GetPrintBool() + "\n"
+// GetPrintFunctions retorna el código de las funciones de impresión usadas,
+// sin sus datos (ver GetStandardData)
+func (sl *StandardLibrary) GetPrintFunctions() string {
+	var code string
+	for _, function := range printFunctions {
+		if sl.IsUsed(function.name) {
+			code += function.code(sl) + "\n"
+		}
 	}
-
-	// Agregar datos de la librería estándar al final
-	code += sl.GetStandardData()
-
 	return code
 }
 
+// GetAllFunctions retorna el código de todas las funciones de impresión
+// usadas seguido de los datos de la librería estándar
+func (sl *StandardLibrary) GetAllFunctions() string {
+	return sl.GetPrintFunctions() + sl.GetStandardData()
+}
+
 // === FUNCIONES INDIVIDUALES ===
 
 // GetPrintInteger retorna la función para imprimir enteros
@@ -188,31 +224,374 @@ print_string_done:
     ret`
 }
 
-// GetPrintFloat retorna la función para imprimir flotantes (simplificada)
+// GetPrintFloat retorna la función para imprimir flotantes. Imprime como
+// strconv.FormatFloat(f, 'f', decimales, 64): con una cantidad fija de
+// decimales o, si es negativa, con los mínimos para que el texto se lea de
+// vuelta como el mismo double. Usa print_integer y print_char.
 func (sl *StandardLibrary) GetPrintFloat() string {
-	return `
+	return floatFormatter("print_float", "print_char", "print_integer")
+}
+
+// floatFormatter retorna print_float con el nombre name, escribiendo cada
+// carácter con putChar y la parte entera con putInteger. Las etiquetas
+// internas toman el prefijo de name.
+func floatFormatter(name, putChar, putInteger string) string {
+	return strings.NewReplacer(
+		"print_float", name,
+		"bl print_char", "bl "+putChar,
+		"bl print_integer", "bl "+putInteger,
+	).Replace(printFloatCode)
+}
+
+// printFloatCode es el código de print_float.
+const printFloatCode = `
 print_float:
-    // Función simplificada para imprimir flotantes
+    // Función para imprimir flotantes
     // Input: d0 = número flotante
-    // Por simplicidad, convertimos a entero y agregamos ".0"
-    
+    //        x1 = decimales (negativo = precisión completa)
+    // Límites: se usan como mucho 18 decimales y, con precisión
+    // completa, desde 2^63 se imprime el entero exacto y no los dígitos
+    // mínimos seguidos de ceros
     stp x29, x30, [sp, #-16]!    // Guardar registros
-    
-    // Convertir flotante a entero (truncar)
-    fcvtzs x0, d0                // x0 = (int)d0
-    bl print_integer             // Imprimir parte entera
-    
-    // Imprimir punto decimal
-    mov x0, #46                  // ASCII '.'
+    stp x19, x20, [sp, #-16]!
+    stp x21, x22, [sp, #-16]!
+
+    mov x21, x1                   // x21 = decimales
+
+    // NaN es el único valor distinto de sí mismo
+    fcmp d0, d0
+    b.vs print_float_nan
+
+    fmov x20, d0                  // x20 = bits del valor, para el signo
+    fabs d0, d0
+    fmov x19, d0                  // x19 = valor absoluto
+
+    // Infinito: inf - inf es NaN
+    fsub d1, d0, d0
+    fcmp d1, d1
+    b.vs print_float_inf
+
+    // El signo sale del bit 63, así -0.0 también imprime '-'
+    tbz x20, #63, print_float_round
+    mov x0, #45                   // ASCII '-'
     bl print_char
-    
-    // Imprimir "0" (simplificación)
-    mov x0, #48                  // ASCII '0'
+
+print_float_round:
+    fmov d0, x19
+    tbz x21, #63, print_float_fixed
+
+    // Desde 2^63 todo double es entero y no entra en x20
+    mov x0, #1
+    lsl x0, x0, #63
+    ucvtf d1, x0
+    fcmp d0, d1
+    b.ge print_float_large
+
+    // Precisión completa: probar con 0, 1, 2... decimales hasta que el
+    // valor redondeado, dividido por 10^decimales, sea el mismo double
+    mov x21, #0
+    mov x22, #1                   // x22 = 10^decimales
+
+print_float_shortest:
+    bl print_float_scaled
+    ucvtf d1, x22
+    scvtf d3, x20
+    fdiv d3, d3, d1
+    fcmp d3, d0
+    b.eq print_float_digits
+
+    // Desde 2^53 la división ya no es exacta: se compara la distancia
+    // entre el candidato y el producto exacto (d2 + d7) con medio espacio
+    // hasta el double vecino más cercano, escalado por 10^decimales
+    mov x0, #1
+    lsl x0, x0, #53
+    cmp x20, x0
+    b.lt print_float_next
+    fmov x0, d0
+    add x1, x0, #1
+    fmov d4, x1
+    fsub d4, d4, d0               // d4 = espacio hasta el siguiente
+    sub x1, x0, #1
+    fmov d5, x1
+    fsub d5, d0, d5               // d5 = espacio hasta el anterior
+    fmin d5, d4, d5
+    fmul d5, d5, d1
+    fmov d4, #0.5
+    fmul d5, d5, d4               // d5 = medio espacio escalado
+    scvtf d3, x20
+    fcvtzs x0, d3
+    sub x0, x20, x0               // x0 = lo que scvtf perdió del candidato
+    fsub d3, d3, d2
+    fsub d3, d3, d7
+    scvtf d4, x0
+    fadd d3, d3, d4
+    fabs d3, d3                   // d3 = |candidato - producto exacto|
+    fcmp d3, d5
+    b.lt print_float_digits
+
+print_float_next:
+
+    // Con 17 dígitos significativos cualquier double se recupera
+    mov x0, #10000
+    mul x0, x0, x0
+    mul x0, x0, x0                // x0 = 10^16
+    cmp x20, x0
+    b.ge print_float_digits
+    cmp x21, #19
+    b.ge print_float_trim
+
+    add x21, x21, #1
+    mov x0, #10
+    mul x22, x22, x0
+    b print_float_shortest
+
+print_float_trim:
+    // Sin más decimales disponibles, quitar los ceros del final
+    cbz x21, print_float_digits
+    mov x0, #10
+    udiv x1, x20, x0
+    msub x2, x1, x0, x20
+    cbnz x2, print_float_digits
+    mov x20, x1
+    udiv x22, x22, x0
+    sub x21, x21, #1
+    b print_float_trim
+
+print_float_large:
+    mov x21, #0
+    b print_float_fixed_large
+
+print_float_fixed:
+    // x22 = 10^decimales
+    mov x22, #1
+    mov x0, x21
+print_float_power:
+    cbz x0, print_float_split
+    mov x1, #10
+    mul x22, x22, x1
+    sub x0, x0, #1
+    b print_float_power
+
+print_float_split:
+    mov x0, #1
+    lsl x0, x0, #63
+    ucvtf d1, x0
+    fcmp d0, d1
+    b.ge print_float_fixed_large
+    // Sin decimales se redondea el valor entero, con los empates al par
+    cbz x21, print_float_scale
+
+    // La parte entera se imprime aparte, así solo la parte fraccionaria,
+    // exacta, se escala por 10^decimales
+    fcvtms x19, d0                // x19 = parte entera
+    scvtf d1, x19
+    fsub d0, d0, d1
+    bl print_float_scaled
+    cmp x20, x22
+    b.lt print_float_integer
+    // El redondeo llegó a la unidad
+    mov x20, #0
+    add x19, x19, #1
+print_float_integer:
+    mov x0, x19
+    bl print_integer
+    b print_float_point
+
+print_float_fixed_large:
+    // Desde 2^63 el valor es entero: los decimales son todos cero
+    mov x20, #0
+    bl print_float_whole
+    b print_float_point
+
+print_float_scale:
+    bl print_float_scaled
+
+print_float_digits:
+    // Parte entera
+    udiv x0, x20, x22
+    msub x20, x0, x22, x20        // x20 = decimales como entero
+    bl print_integer
+
+print_float_point:
+    cbz x21, print_float_done
+
+    mov x0, #46                   // ASCII '.'
     bl print_char
-    
-    ldp x29, x30, [sp], #16      // Restaurar registros
+
+print_float_fraction:
+    // Un dígito por vuelta, del más significativo al menos
+    mov x0, #10
+    udiv x22, x22, x0             // x22 = peso del dígito
+    udiv x0, x20, x22
+    msub x20, x0, x22, x20
+    add x0, x0, #48               // Convertir a ASCII
+    bl print_char
+    sub x21, x21, #1
+    cbnz x21, print_float_fraction
+    b print_float_done
+
+print_float_whole:
+    // Imprime d0, un entero desde 2^63, que no entra en un registro.
+    // El valor es m * 2^e, así que se arma en la pila en bloques de 9
+    // dígitos decimales y se duplica e veces, de a 29 bits por pasada
+    // para que cada bloque desplazado entre en 64 bits.
+    stp x29, x30, [sp, #-16]!
+    stp x19, x20, [sp, #-16]!
+    stp x21, x22, [sp, #-16]!
+    sub sp, sp, #288              // 36 bloques: 2^1024 tiene 309 dígitos
+
+    fmov x0, d0
+    lsr x1, x0, #52
+    sub x19, x1, #1075            // x19 = e, el signo ya es 0
+    and x0, x0, #0xfffffffffffff
+    orr x0, x0, #0x10000000000000 // x0 = m, con el bit implícito
+    mov x20, #0xCA00
+    movk x20, #0x3B9A, lsl #16    // x20 = 10^9
+    udiv x1, x0, x20
+    msub x2, x1, x20, x0
+    stp x2, x1, [sp]
+    mov x21, #2                   // x21 = cantidad de bloques
+
+print_float_whole_double:
+    cbz x19, print_float_whole_print
+    mov x22, #29
+    cmp x19, x22
+    csel x22, x19, x22, lt        // x22 = bits de esta pasada
+    sub x19, x19, x22
+    mov x1, #0                    // x1 = acarreo
+    mov x2, #0                    // x2 = bloque actual
+print_float_whole_limb:
+    ldr x3, [sp, x2, lsl #3]
+    lsl x3, x3, x22
+    add x3, x3, x1
+    udiv x1, x3, x20
+    msub x3, x1, x20, x3
+    str x3, [sp, x2, lsl #3]
+    add x2, x2, #1
+    cmp x2, x21
+    b.lt print_float_whole_limb
+    cbz x1, print_float_whole_double
+    str x1, [sp, x21, lsl #3]
+    add x21, x21, #1
+    b print_float_whole_double
+
+print_float_whole_print:
+    // El bloque más alto va sin ceros a la izquierda, el resto con 9 dígitos
+    sub x21, x21, #1
+    ldr x0, [sp, x21, lsl #3]
+    bl print_integer
+print_float_whole_block:
+    cbz x21, print_float_whole_free
+    sub x21, x21, #1
+    ldr x19, [sp, x21, lsl #3]
+    mov x22, #0xE100
+    movk x22, #0x05F5, lsl #16    // x22 = 10^8
+print_float_whole_digit:
+    udiv x0, x19, x22
+    msub x19, x0, x22, x19
+    add x0, x0, #48               // Convertir a ASCII
+    bl print_char
+    mov x0, #10
+    udiv x22, x22, x0
+    cbnz x22, print_float_whole_digit
+    b print_float_whole_block
+
+print_float_whole_free:
+    add sp, sp, #288
+    ldp x21, x22, [sp], #16
+    ldp x19, x20, [sp], #16
+    ldp x29, x30, [sp], #16
+    ret
+
+print_float_scaled:
+    // x20 = d0 * x22 redondeado al entero más cercano, con los empates
+    // al par, como strconv. El producto se calcula exacto como d2 + d7
+    // partiendo los factores en mitades de 26 bits (Dekker), así no
+    // hace falta fma. No usa la pila ni llama a otras funciones.
+    ucvtf d1, x22                 // d1 = 10^decimales, exacto
+    fmul d2, d0, d1               // d2 = producto redondeado
+    mov x0, #1
+    lsl x0, x0, #27
+    add x0, x0, #1
+    scvtf d7, x0                  // d7 = 2^27 + 1
+    fmul d3, d7, d0
+    fsub d4, d3, d0
+    fsub d3, d3, d4               // d3 = mitad alta de d0
+    fsub d4, d0, d3               // d4 = mitad baja de d0
+    fmul d5, d7, d1
+    fsub d6, d5, d1
+    fsub d5, d5, d6               // d5 = mitad alta de d1
+    fsub d6, d1, d5               // d6 = mitad baja de d1
+    fmul d7, d3, d5
+    fsub d7, d7, d2
+    fmul d5, d4, d5
+    fmul d3, d3, d6
+    fadd d7, d7, d3
+    fadd d7, d7, d5
+    fmul d4, d4, d6
+    fadd d7, d7, d4               // d7 = error del producto
+
+    fcvtms x20, d2                // x20 = parte entera del producto
+    scvtf d3, x20
+    fsub d3, d2, d3               // d3 = parte fraccionaria, exacta
+    fcmp d3, #0.0
+    b.eq print_float_scaled_error
+    // El error es menor que medio ulp del producto, así que solo
+    // cuenta cuando la parte fraccionaria es justo la mitad
+    fmov d4, #0.5
+    fcmp d3, d4
+    b.gt print_float_scaled_up
+    b.lt print_float_scaled_done
+    fcmp d7, #0.0
+    b.gt print_float_scaled_up
+    b.lt print_float_scaled_done
+    tbz x20, #0, print_float_scaled_done
+    b print_float_scaled_up
+
+print_float_scaled_error:
+    // Producto entero (desde 2^52 lo es siempre): se suma el error
+    // redondeado, que puede valer varias unidades
+    fcvtms x0, d7
+    add x20, x20, x0
+    scvtf d3, x0
+    fsub d3, d7, d3               // d3 = parte fraccionaria del error
+    fmov d4, #0.5
+    fcmp d3, d4
+    b.gt print_float_scaled_up
+    b.lt print_float_scaled_done
+    tbz x20, #0, print_float_scaled_done
+print_float_scaled_up:
+    add x20, x20, #1
+print_float_scaled_done:
+    ret
+
+print_float_nan:
+    mov x0, #78                   // 'N'
+    bl print_char
+    mov x0, #97                   // 'a'
+    bl print_char
+    mov x0, #78                   // 'N'
+    bl print_char
+    b print_float_done
+
+print_float_inf:
+    mov x0, #43                   // '+'
+    tbz x20, #63, print_float_inf_sign
+    mov x0, #45                   // '-'
+print_float_inf_sign:
+    bl print_char
+    mov x0, #73                   // 'I'
+    bl print_char
+    mov x0, #110                  // 'n'
+    bl print_char
+    mov x0, #102                  // 'f'
+    bl print_char
+
+print_float_done:
+    ldp x21, x22, [sp], #16       // Restaurar registros
+    ldp x19, x20, [sp], #16
+    ldp x29, x30, [sp], #16
     ret`
-}
 
 // GetPrintBool retorna la función para imprimir booleanos
 func (sl *StandardLibrary) GetPrintBool() string {
@@ -0,0 +1,564 @@
+package arm64
+
+// Strings del runtime. Un string es la dirección de sus bytes terminados en
+// null: los literales están en .data y los que se arman al ejecutar (una
+// concatenación, un número convertido, un join) en el heap, y nunca se
+// modifican.
+//
+// Los números se escriben en un buffer del heap a través de str_cursor, la
+// dirección del próximo byte a escribir: str_put_char y str_put_integer
+// hacen lo que print_char y print_integer, y format_float es print_float
+// escribiendo con ellas.
+
+// GetStrLen retorna la función que calcula el largo de un string
+func (sl *StandardLibrary) GetStrLen() string {
+	return `
+str_len:
+    // Largo de un string en bytes
+    // Input: x0 = string
+    // Output: x0 = largo
+    // Solo modifica x0, x9 y x10
+    mov x9, x0
+str_len_loop:
+    ldrb w10, [x9], #1
+    cbnz w10, str_len_loop
+    sub x0, x9, x0
+    sub x0, x0, #1
+    ret`
+}
+
+// GetStrCopy retorna la función que copia los bytes de un string, sin el
+// null del final
+func (sl *StandardLibrary) GetStrCopy() string {
+	return `
+str_copy:
+    // Copia un string sin su null
+    // Input: x0 = destino, x1 = string
+    // Output: x0 = destino después del último byte copiado
+    // Solo modifica x0, x1 y x9
+    ldrb w9, [x1], #1
+    cbz w9, str_copy_done
+    strb w9, [x0], #1
+    b str_copy
+str_copy_done:
+    ret`
+}
+
+// GetStrConcat retorna la función que concatena dos strings
+func (sl *StandardLibrary) GetStrConcat() string {
+	return `
+str_concat:
+    // Concatena dos strings en uno nuevo
+    // Input: x0 = a, x1 = b
+    // Output: x0 = a + b
+    stp x29, x30, [sp, #-16]!    // Guardar registros
+    stp x19, x20, [sp, #-16]!
+    stp x21, x22, [sp, #-16]!
+
+    mov x19, x0                  // x19 = a
+    mov x20, x1                  // x20 = b
+    bl str_len
+    mov x21, x0
+    mov x0, x20
+    bl str_len
+    add x0, x21, x0
+    add x0, x0, #1               // el bloque viene en cero: el null ya está
+    bl alloc
+    mov x22, x0                  // x22 = resultado
+
+    mov x1, x19
+    bl str_copy
+    mov x1, x20
+    bl str_copy
+    mov x0, x22
+
+    ldp x21, x22, [sp], #16      // Restaurar registros
+    ldp x19, x20, [sp], #16
+    ldp x29, x30, [sp], #16
+    ret`
+}
+
+// GetStrCompare retorna la función que compara dos strings byte a byte,
+// como la comparación de strings de Go
+func (sl *StandardLibrary) GetStrCompare() string {
+	return `
+str_compare:
+    // Compara dos strings
+    // Input: x0 = a, x1 = b
+    // Output: x0 = -1 si a < b, 0 si son iguales, 1 si a > b
+    // Solo modifica x0, x1, x9 y x10
+    ldrb w9, [x0], #1
+    ldrb w10, [x1], #1
+    cmp w9, w10
+    b.ne str_compare_differ
+    cbnz w9, str_compare
+    mov x0, #0
+    ret
+str_compare_differ:
+    mov x0, #1
+    csneg x0, x0, x0, hi         // sin signo: los bytes van de 0 a 255
+    ret`
+}
+
+// GetStrPutChar retorna la función que escribe un carácter en str_cursor
+func (sl *StandardLibrary) GetStrPutChar() string {
+	return `
+str_put_char:
+    // Escribe un carácter en el buffer
+    // Input: x0 = carácter ASCII
+    // Solo modifica x9 y x10
+    adr x9, str_cursor
+    ldr x10, [x9]
+    strb w0, [x10], #1
+    str x10, [x9]
+    ret`
+}
+
+// GetStrPutInteger retorna la función que escribe un entero en str_cursor
+func (sl *StandardLibrary) GetStrPutInteger() string {
+	return `
+str_put_integer:
+    // Escribe un entero en el buffer
+    // Input: x0 = número
+    stp x29, x30, [sp, #-16]!    // Guardar registros
+    stp x19, x20, [sp, #-16]!
+    stp x21, x22, [sp, #-16]!
+    sub sp, sp, #32              // dígitos, del menos significativo al más
+
+    mov x19, x0                  // x19 = número
+    mov x20, #0                  // x20 = cantidad de dígitos
+    mov x21, sp                  // x21 = dígitos
+    tbz x19, #63, str_put_integer_digits
+    mov x0, #45                  // ASCII '-'
+    bl str_put_char
+    neg x19, x19                 // sin signo, así también anda el mínimo
+
+str_put_integer_digits:
+    mov x9, #10
+    udiv x10, x19, x9
+    msub x11, x10, x9, x19       // x11 = último dígito
+    add x11, x11, #48            // Convertir a ASCII
+    strb w11, [x21, x20]
+    add x20, x20, #1
+    mov x19, x10
+    cbnz x19, str_put_integer_digits
+
+str_put_integer_write:
+    sub x20, x20, #1
+    ldrb w0, [x21, x20]
+    bl str_put_char
+    cbnz x20, str_put_integer_write
+
+    add sp, sp, #32
+    ldp x21, x22, [sp], #16      // Restaurar registros
+    ldp x19, x20, [sp], #16
+    ldp x29, x30, [sp], #16
+    ret`
+}
+
+// GetStrFromInt retorna la función que convierte un entero en string
+func (sl *StandardLibrary) GetStrFromInt() string {
+	return `
+str_from_int:
+    // Convierte un entero en string
+    // Input: x0 = número
+    // Output: x0 = string
+    stp x29, x30, [sp, #-16]!    // Guardar registros
+    stp x19, x20, [sp, #-16]!
+
+    mov x19, x0
+    mov x0, #24                  // signo, 19 dígitos y el null
+    bl alloc
+    mov x20, x0
+    adr x9, str_cursor
+    str x0, [x9]
+    mov x0, x19
+    bl str_put_integer
+    mov x0, x20
+
+    ldp x19, x20, [sp], #16      // Restaurar registros
+    ldp x29, x30, [sp], #16
+    ret`
+}
+
+// GetFormatFloat retorna print_float escribiendo en str_cursor
+func (sl *StandardLibrary) GetFormatFloat() string {
+	return floatFormatter("format_float", "str_put_char", "str_put_integer")
+}
+
+// GetStrFromFloat retorna la función que convierte un flotante en string,
+// con los mismos decimales que print_float
+func (sl *StandardLibrary) GetStrFromFloat() string {
+	return `
+str_from_float:
+    // Convierte un flotante en string
+    // Input: d0 = número, x1 = decimales (negativo = precisión completa)
+    // Output: x0 = string
+    stp x29, x30, [sp, #-16]!    // Guardar registros
+    stp x19, x20, [sp, #-16]!
+    stp x21, x22, [sp, #-16]!
+
+    fmov x19, d0                 // x19 = bits del número
+    mov x20, x1                  // x20 = decimales
+    mov x0, #336                 // signo, 309 dígitos, punto, 18 decimales y el null
+    bl alloc
+    mov x21, x0
+    adr x9, str_cursor
+    str x0, [x9]
+    fmov d0, x19
+    mov x1, x20
+    bl format_float
+    mov x0, x21
+
+    ldp x21, x22, [sp], #16      // Restaurar registros
+    ldp x19, x20, [sp], #16
+    ldp x29, x30, [sp], #16
+    ret`
+}
+
+// GetStrFromBool retorna la función que convierte un booleano en string
+func (sl *StandardLibrary) GetStrFromBool() string {
+	return `
+str_from_bool:
+    // Convierte un booleano en string
+    // Input: x0 = valor (0 = false)
+    // Output: x0 = "true" o "false"
+    cbz x0, str_from_bool_false
+    adr x0, str_true
+    ret
+str_from_bool_false:
+    adr x0, str_false
+    ret`
+}
+
+// GetStrToDouble retorna la función que lee un número decimal, con signo,
+// decimales y exponente opcionales. Con hasta 15 dígitos significativos y
+// un exponente de hasta 22 el resultado es el double más cercano, como en
+// strconv.ParseFloat; con más puede diferir en el último bit.
+func (sl *StandardLibrary) GetStrToDouble() string {
+	return `
+str_to_double:
+    // Convierte un string en double
+    // Input: x0 = string, x1 = mensaje de error si no es un número
+    // Output: d0 = número
+    stp x29, x30, [sp, #-16]!    // Guardar registros
+    stp x19, x20, [sp, #-16]!
+    stp x21, x22, [sp, #-16]!
+    stp x23, x24, [sp, #-16]!
+
+    mov x19, x0                  // x19 = próximo byte
+    mov x20, #0                  // x20 = dígitos significativos como entero
+    mov x21, #0                  // x21 = exponente decimal
+    mov x22, #0                  // x22 = 1 si es negativo
+    mov x23, #0                  // x23 = dígitos leídos
+    mov x24, x1                  // x24 = mensaje de error
+
+    ldrb w9, [x19]
+    cmp w9, #45                  // '-'
+    b.ne str_to_double_plus
+    mov x22, #1
+    add x19, x19, #1
+    b str_to_double_int
+str_to_double_plus:
+    cmp w9, #43                  // '+'
+    b.ne str_to_double_int
+    add x19, x19, #1
+
+str_to_double_int:
+    // Parte entera; desde el dígito 20 solo cuenta para el exponente
+    ldrb w9, [x19]
+    sub w9, w9, #48
+    cmp w9, #9
+    b.hi str_to_double_point
+    add x19, x19, #1
+    add x23, x23, #1
+    bl str_to_double_digit
+    cbnz x0, str_to_double_int
+    add x21, x21, #1
+    b str_to_double_int
+
+str_to_double_point:
+    ldrb w9, [x19]
+    cmp w9, #46                  // '.'
+    b.ne str_to_double_exponent
+    add x19, x19, #1
+
+str_to_double_fraction:
+    ldrb w9, [x19]
+    sub w9, w9, #48
+    cmp w9, #9
+    b.hi str_to_double_exponent
+    add x19, x19, #1
+    add x23, x23, #1
+    bl str_to_double_digit
+    cbz x0, str_to_double_fraction
+    sub x21, x21, #1
+    b str_to_double_fraction
+
+str_to_double_exponent:
+    cbz x23, str_to_double_error // sin dígitos
+    ldrb w9, [x19]
+    orr w9, w9, #32              // minúscula
+    cmp w9, #101                 // 'e'
+    b.ne str_to_double_end
+    add x19, x19, #1
+    mov x11, #0                  // x11 = 1 si el exponente es negativo
+    ldrb w9, [x19]
+    cmp w9, #45                  // '-'
+    b.ne str_to_double_exponent_plus
+    mov x11, #1
+    add x19, x19, #1
+    b str_to_double_exponent_first
+str_to_double_exponent_plus:
+    cmp w9, #43                  // '+'
+    b.ne str_to_double_exponent_first
+    add x19, x19, #1
+str_to_double_exponent_first:
+    ldrb w9, [x19]
+    sub w9, w9, #48
+    cmp w9, #9
+    b.hi str_to_double_error     // la e necesita dígitos
+    mov x12, #0                  // x12 = exponente
+str_to_double_exponent_digits:
+    ldrb w9, [x19]
+    sub w9, w9, #48
+    cmp w9, #9
+    b.hi str_to_double_exponent_sign
+    add x19, x19, #1
+    mov x10, #10000
+    cmp x12, x10
+    b.hs str_to_double_exponent_digits // más allá todo es 0 o infinito
+    mov x10, #10
+    madd x12, x12, x10, x9
+    b str_to_double_exponent_digits
+str_to_double_exponent_sign:
+    cbz x11, str_to_double_exponent_add
+    neg x12, x12
+str_to_double_exponent_add:
+    add x21, x21, x12
+
+str_to_double_end:
+    ldrb w9, [x19]
+    cbnz w9, str_to_double_error // sobran caracteres
+
+    // d0 = dígitos * 10^exponente, de a 10^22 (la mayor potencia exacta)
+    ucvtf d0, x20
+    mov x9, x21
+    cmp x9, #0
+    csneg x9, x9, x9, ge         // x9 = |exponente|
+    mov x10, #800
+    cmp x9, x10
+    csel x9, x9, x10, lo         // más allá el resultado ya no cambia
+    fmov d2, #10.0
+str_to_double_scale:
+    cbz x9, str_to_double_sign
+    mov x10, #22
+    cmp x9, x10
+    csel x10, x9, x10, lo        // x10 = dígitos de este paso
+    sub x9, x9, x10
+    fmov d1, #1.0
+str_to_double_power:
+    fmul d1, d1, d2
+    sub x10, x10, #1
+    cbnz x10, str_to_double_power
+    tbnz x21, #63, str_to_double_divide
+    fmul d0, d0, d1
+    b str_to_double_scale
+str_to_double_divide:
+    fdiv d0, d0, d1
+    b str_to_double_scale
+
+str_to_double_sign:
+    cbz x22, str_to_double_done
+    fneg d0, d0
+
+str_to_double_done:
+    ldp x23, x24, [sp], #16      // Restaurar registros
+    ldp x21, x22, [sp], #16
+    ldp x19, x20, [sp], #16
+    ldp x29, x30, [sp], #16
+    ret
+
+str_to_double_error:
+    mov x0, x24
+    b runtime_error
+
+str_to_double_digit:
+    // Agrega el dígito w9 a x20 si todavía entra (x20 < 10^18)
+    // Output: x0 = 1 si lo agregó
+    mov x10, #10000
+    mov x11, #10000
+    mul x10, x10, x11
+    mul x10, x10, x10            // x10 = 10^16
+    mov x11, #100
+    mul x10, x10, x11            // x10 = 10^18
+    mov x0, #0
+    cmp x20, x10
+    b.hs str_to_double_digit_done
+    mov x11, #10
+    madd x20, x20, x11, x9
+    mov x0, #1
+str_to_double_digit_done:
+    ret`
+}
+
+// GetStrParseFloat retorna parseFloat: el double que representa un string
+func (sl *StandardLibrary) GetStrParseFloat() string {
+	return `
+str_parse_float:
+    // Input: x0 = string
+    // Output: x0 = bits del double
+    stp x29, x30, [sp, #-16]!
+    adr x1, err_parse_float
+    bl str_to_double
+    fmov x0, d0
+    ldp x29, x30, [sp], #16
+    ret`
+}
+
+// GetStrAtoi retorna atoi: como el intérprete, lee el string como un
+// double y lo trunca
+func (sl *StandardLibrary) GetStrAtoi() string {
+	return `
+str_atoi:
+    // Input: x0 = string
+    // Output: x0 = entero
+    stp x29, x30, [sp, #-16]!
+    adr x1, err_parse_int
+    bl str_to_double
+    fcvtzs x0, d0
+    ldp x29, x30, [sp], #16
+    ret`
+}
+
+// GetStrJoin retorna join: los strings de un vector unidos por un
+// separador
+func (sl *StandardLibrary) GetStrJoin() string {
+	return `
+str_join:
+    // Une los strings de un vector
+    // Input: x0 = vector de strings, x1 = separador
+    // Output: x0 = string
+    stp x29, x30, [sp, #-16]!    // Guardar registros
+    stp x19, x20, [sp, #-16]!
+    stp x21, x22, [sp, #-16]!
+    stp x23, x24, [sp, #-16]!
+
+    mov x19, x0                  // x19 = vector
+    mov x20, x1                  // x20 = separador
+    mov x0, x20
+    bl str_len
+    mov x23, x0                  // x23 = largo del separador
+
+    // x21 = largo total: los elementos y un separador entre cada par
+    mov x21, #1                  // el null
+    ldr x22, [x19]
+    cbz x22, str_join_alloc
+    sub x22, x22, #1
+    madd x21, x22, x23, x21
+    mov x22, #0                  // x22 = índice
+str_join_measure:
+    ldr x9, [x19]
+    cmp x22, x9
+    b.hs str_join_alloc
+    ldr x9, [x19, #16]
+    ldr x0, [x9, x22, lsl #3]
+    bl str_len
+    add x21, x21, x0
+    add x22, x22, #1
+    b str_join_measure
+
+str_join_alloc:
+    mov x0, x21
+    bl alloc
+    mov x24, x0                  // x24 = resultado
+    mov x21, x0                  // x21 = próximo byte a escribir
+    mov x22, #0
+str_join_copy:
+    ldr x9, [x19]
+    cmp x22, x9
+    b.hs str_join_done
+    cbz x22, str_join_item
+    mov x0, x21
+    mov x1, x20
+    bl str_copy
+    mov x21, x0
+str_join_item:
+    ldr x9, [x19, #16]
+    ldr x1, [x9, x22, lsl #3]
+    mov x0, x21
+    bl str_copy
+    mov x21, x0
+    add x22, x22, #1
+    b str_join_copy
+
+str_join_done:
+    mov x0, x24
+    ldp x23, x24, [sp], #16      // Restaurar registros
+    ldp x21, x22, [sp], #16
+    ldp x19, x20, [sp], #16
+    ldp x29, x30, [sp], #16
+    ret`
+}
+
+// GetVecIndexOf retorna indexOf: la primera posición de un valor en un
+// vector, o -1. Los strings se comparan por contenido y los flotantes
+// como números.
+func (sl *StandardLibrary) GetVecIndexOf() string {
+	return `
+vec_index_of:
+    // Busca un valor en un vector
+    // Input: x0 = vector, x1 = valor, x2 = tipo de los elementos (ver ElemInt...)
+    // Output: x0 = posición o -1
+    stp x29, x30, [sp, #-16]!    // Guardar registros
+    stp x19, x20, [sp, #-16]!
+    stp x21, x22, [sp, #-16]!
+
+    mov x19, x0                  // x19 = vector
+    mov x20, x1                  // x20 = valor
+    mov x21, x2                  // x21 = tipo
+    mov x22, #0                  // x22 = índice
+
+vec_index_of_loop:
+    ldr x9, [x19]
+    cmp x22, x9
+    b.hs vec_index_of_missing
+    ldr x9, [x19, #16]
+    ldr x0, [x9, x22, lsl #3]
+    cmp x21, #3
+    b.eq vec_index_of_string
+    cmp x21, #1
+    b.eq vec_index_of_float
+    cmp x0, x20
+    b.eq vec_index_of_found
+    b vec_index_of_next
+
+vec_index_of_float:
+    fmov d0, x0
+    fmov d1, x20
+    fcmp d0, d1
+    b.eq vec_index_of_found
+    b vec_index_of_next
+
+vec_index_of_string:
+    mov x1, x20
+    bl str_compare
+    cbz x0, vec_index_of_found
+
+vec_index_of_next:
+    add x22, x22, #1
+    b vec_index_of_loop
+
+vec_index_of_found:
+    mov x0, x22
+    b vec_index_of_done
+
+vec_index_of_missing:
+    mov x0, #-1
+
+vec_index_of_done:
+    ldp x21, x22, [sp], #16      // Restaurar registros
+    ldp x19, x20, [sp], #16
+    ldp x29, x30, [sp], #16
+    ret`
+}
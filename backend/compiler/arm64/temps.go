@@ -0,0 +1,112 @@
+package arm64
+
+import "fmt"
+
+// Temporales de las expresiones. Un valor intermedio (el operando izquierdo
+// mientras se evalúa el derecho, los argumentos ya evaluados de una llamada)
+// vive en un registro callee-saved, así sobrevive a las llamadas que haya en
+// el resto de la expresión. Los registros se entregan en orden de pila:
+// x19 para el primer temporal vivo, x20 para el segundo, etc. Cuando se
+// acaban, el valor se guarda en el stack.
+
+// Temp es un valor intermedio guardado con SaveTemp.
+type Temp struct {
+	Register string // registro que lo contiene; vacío si está en el stack
+}
+
+// tempRegisters es la cantidad de registros callee-saved para temporales:
+// x19-x27, porque x28 es la base de las variables (VB).
+const tempRegisters = 9
+
+// SaveTemp guarda el valor de register en un temporal nuevo.
+func (g *ARM64Generator) SaveTemp(register string) Temp {
+	depth := g.tempDepth
+	g.tempDepth++
+	if depth >= tempRegisters {
+		g.Push(register)
+		return Temp{}
+	}
+
+	temp := Temp{Register: GetCalleeSavedRegister(depth)}
+	g.usedTemps = max(g.usedTemps, depth+1)
+	g.Comment(fmt.Sprintf("Guardar temporal en %s", temp.Register))
+	g.Emit(fmt.Sprintf("mov %s, %s", temp.Register, register))
+	return temp
+}
+
+// RestoreTemp libera temp, que debe ser el último temporal guardado, y
+// devuelve el registro con su valor: el del temporal o scratch, donde se
+// carga si estaba en el stack. El registro solo es válido hasta el próximo
+// SaveTemp.
+func (g *ARM64Generator) RestoreTemp(temp Temp, scratch string) string {
+	g.tempDepth--
+	if temp.Register != "" {
+		return temp.Register
+	}
+	g.Pop(scratch)
+	return scratch
+}
+
+// StackTemps devuelve cuántos temporales vivos están en el stack, cada uno
+// en 16 bytes. Sirve para direccionar desde sp algo reservado antes de
+// guardarlos.
+func (g *ARM64Generator) StackTemps() int {
+	return max(0, g.tempDepth-tempRegisters)
+}
+
+// UsedTempRegisters devuelve los registros callee-saved que se usaron como
+// temporales desde BeginFunction, que la función debe preservar.
+func (g *ARM64Generator) UsedTempRegisters() []string {
+	registers := make([]string, g.usedTemps)
+	for i := range registers {
+		registers[i] = GetCalleeSavedRegister(i)
+	}
+	return registers
+}
+
+// Mark devuelve la posición actual del código, para insertar después con
+// SaveRegistersAt.
+func (g *ARM64Generator) Mark() int {
+	return len(g.instructions)
+}
+
+// SaveRegistersAt inserta en mark el código que guarda registers de a
+// pares, manteniendo sp alineado a 16 bytes. Sirve para el prólogo de una
+// función, que recién se conoce al terminar de traducir su cuerpo.
+func (g *ARM64Generator) SaveRegistersAt(mark int, registers []string) {
+	var code []string
+	for i := 0; i < len(registers); i += 2 {
+		code = append(code, fmt.Sprintf("    stp %s, %s, [sp, #-16]!", registers[i], pairOf(registers, i)))
+	}
+	g.insertAt(mark, code)
+}
+
+// insertAt inserta code en la posición mark del código ya emitido. Las
+// líneas nuevas toman la posición de la que las sigue.
+func (g *ARM64Generator) insertAt(mark int, code []string) {
+	pos := g.position
+	if mark < len(g.instructions) {
+		pos = g.instructions[mark].pos
+	}
+	inserted := make([]line, len(code))
+	for i, text := range code {
+		inserted[i] = line{text, pos}
+	}
+	g.instructions = append(g.instructions[:mark], append(inserted, g.instructions[mark:]...)...)
+}
+
+// RestoreRegisters recupera los registros guardados con SaveRegistersAt.
+func (g *ARM64Generator) RestoreRegisters(registers []string) {
+	for i := (len(registers)+1)/2*2 - 2; i >= 0; i -= 2 {
+		g.Emit(fmt.Sprintf("ldp %s, %s, [sp], #16", registers[i], pairOf(registers, i)))
+	}
+}
+
+// pairOf devuelve el compañero de registers[i] en un stp; con una cantidad
+// impar, el último va con xzr.
+func pairOf(registers []string, i int) string {
+	if i+1 < len(registers) {
+		return registers[i+1]
+	}
+	return XZR
+}
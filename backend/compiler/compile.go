@@ -0,0 +1,56 @@
+package compiler
+
+import (
+	"log/slog"
+
+	"github.com/antlr4-go/antlr/v4"
+	"main.go/ast"
+	"main.go/compiler/arm64"
+	"main.go/compiler/ir"
+)
+
+// Compile traduce un programa a ARM64. Lo baja al IR y selecciona las
+// instrucciones desde ahí; si el programa usa algo que el IR todavía no
+// representa (ver ir.UnsupportedError), lo traduce el ARM64Translator
+// directo desde el árbol y fallback dice por qué. Quien muestra el
+// resultado debe avisarlo: el traductor directo no pasa por las
+// optimizaciones del IR.
+//
+// source es la ruta del programa para la directiva .file; vacía usa
+// arm64.DefaultSourceFile.
+//
+// level es el nivel de optimización (ir.O0 o ir.O1): con O1 se optimiza el
+// IR y se pasa el peephole sobre el ensamblador, también sobre el del
+// traductor directo.
+func Compile(tree antlr.ParseTree, source string, level int, log *slog.Logger) (assembly string, errors []string, fallback *ir.UnsupportedError) {
+	module, err := ir.Lower(ast.Lower(tree))
+	if err != nil {
+		// lo que el IR no soporta lo traduce el traductor directo
+		fallback = err.(*ir.UnsupportedError)
+		log.Debug("IR no disponible, se usa el traductor directo", "reason", err)
+		translator := NewARM64Translator()
+		translator.SourceFile = source
+		translator.Log = log
+		assembly, errors = translator.TranslateProgram(tree)
+	} else {
+		ir.Optimize(module, level)
+		log.Debug("IR generado", "functions", len(module.Functions), "globals", len(module.Globals), "level", level)
+		assembly = arm64.Select(module, source)
+	}
+
+	if level >= ir.O1 && len(errors) == 0 {
+		assembly = arm64.Peephole(assembly)
+	}
+	return assembly, errors, fallback
+}
+
+// EmitIR devuelve el IR del programa en texto, ya optimizado según level, o
+// un *ir.UnsupportedError si el programa no se puede representar.
+func EmitIR(tree antlr.ParseTree, level int) (string, error) {
+	module, err := ir.Lower(ast.Lower(tree))
+	if err != nil {
+		return "", err
+	}
+	ir.Optimize(module, level)
+	return module.String(), nil
+}
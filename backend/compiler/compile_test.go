@@ -0,0 +1,154 @@
+package compiler_test
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/compiler"
+	"main.go/compiler/arm64"
+	"main.go/compiler/ir"
+	interpeter "main.go/grammar"
+)
+
+// compile traduce code y devuelve el ensamblador y, si no pasó por el IR,
+// por qué.
+func compile(t *testing.T, code string) (string, *ir.UnsupportedError) {
+	t.Helper()
+	return compileFile(t, "", code)
+}
+
+// compileFile es compile con source como ruta del programa.
+func compileFile(t *testing.T, source, code string) (string, *ir.UnsupportedError) {
+	t.Helper()
+	lexer := interpeter.NewVLangLexer(antlr.NewInputStream(code))
+	parser := interpeter.NewVLangGrammar(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	assembly, errors, fallback := compiler.Compile(parser.Program(), source, ir.O0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if len(errors) > 0 {
+		t.Fatalf("errores de traducción: %v", errors)
+	}
+	return assembly, fallback
+}
+
+// Programas que el IR representa: cada función tiene su propio marco, así
+// que la recursión y las variables que se ocultan funcionan.
+func TestCompileIR(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{
+			"recursión",
+			"fn fib(n int) int {\n    if n < 2 {\n        return n\n    }\n    return fib((n - 1)) + fib((n - 2))\n}\nprintln(fib(15))\n",
+		},
+		{
+			"globales desde funciones",
+			"mut cuenta = 0\nfn sumar(n int) {\n    cuenta += n\n}\nsumar(4)\nsumar(6)\nprintln(\"cuenta:\", cuenta)\n",
+		},
+		{
+			"variables ocultas",
+			"mut x = 1\nif x > 0 {\n    mut x = 2\n    println(x)\n}\nprintln(x)\n",
+		},
+		{
+			"cortocircuito",
+			"mut llamadas = 0\nfn marcar(b bool) bool {\n    llamadas += 1\n    return b\n}\n" +
+				"mut r = marcar(false) && marcar(true)\nmut s = marcar(true) || marcar(false)\nprintln(r, s, llamadas)\n",
+		},
+		{
+			"break, continue y switch",
+			"mut i = 0\nmut suma = 0\nfor i < 10 {\n    i += 1\n    switch i % 3 {\n    case 0:\n        continue\n    case 1:\n        break\n    }\n    if i > 7 {\n        break\n    }\n    suma += i\n}\nprintln(suma)\n",
+		},
+		{
+			"flotantes",
+			"fn area(r float) float {\n    return 3.14159 * r * r\n}\nmut a = area(2)\nprintln(a, a > 12.5, -a)\nmut x = 0.1\nprintln(\"x vale $x\")\n",
+		},
+		{
+			"cadenas con escapes",
+			"println(\"comillas \\\"dobles\\\" y barra \\\\\")\nprintln(\"tab\\tfin\")\nprint(\"a\", true, \"b\")\nprintln()\n",
+		},
+		{
+			"enteros grandes",
+			"mut n = 1234567890123\nprintln(n, -n, n % 1000, 70000 * 3)\n",
+		},
+		{
+			"strings",
+			"mut s = \"hola\"\nmut t = s + \" \" + \"mundo\"\nt += \"!\"\nprintln(t, s == \"hola\", s != \"chau\", s < \"hz\", \"bb\" >= t)\n" +
+				"switch t {\ncase \"hola\":\n    println(1)\ncase \"hola mundo!\":\n    println(2)\n}\n",
+		},
+		{
+			"interpolación como valor",
+			"fn d(x int) string {\n    return \"x vale $x\"\n}\nmut r = d(3)\nmut f = 2.5\nmut b = true\nmut s = \"${f} y $b: $r\"\nprintln(s)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, fallback := compile(t, tt.code); fallback != nil {
+				t.Fatalf("el programa no pasó por el IR: %v", fallback)
+			}
+			compareWith(t, tt.code, viaIR, optimized)
+		})
+	}
+}
+
+// Lo que el IR no representa lo traduce el traductor directo.
+func TestCompileFallsBackToTranslator(t *testing.T) {
+	code := "mut v []int = {1, 2}\nprintln(v[0] + v[1])\n"
+	assembly, fallback := compile(t, code)
+	if fallback == nil || fallback.Construct != "tipo []int" {
+		t.Fatalf("se esperaba que el IR no soportara el vector, se obtuvo %v", fallback)
+	}
+	if !strings.Contains(assembly, "x28") {
+		t.Fatalf("se esperaba el código del traductor directo:\n%s", assembly)
+	}
+	compare(t, code)
+}
+
+// Cada sentencia queda en el mapa de fuentes con el bloque de ensamblador
+// que sale de ella, tanto por el IR como por el traductor directo, y .file
+// nombra la ruta del programa o, sin ella, el nombre por defecto.
+func TestSourceMap(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		code   string
+		file   string
+	}{
+		{"IR", "ejemplos/suma.vch", "mut x = 41\nx += 1\nprintln(x)\n", "ejemplos/suma.vch"},
+		{"IR sin ruta", "", "mut x = 41\nx += 1\nprintln(x)\n", arm64.DefaultSourceFile},
+		{"traductor directo", "ejemplos/vector.vch", "mut v []int = {1, 2}\nmut b = v[0] < v[1]\nprintln(b)\n", "ejemplos/vector.vch"},
+		{"traductor directo sin ruta", "", "mut v []int = {1, 2}\nmut b = v[0] < v[1]\nprintln(b)\n", arm64.DefaultSourceFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assembly, _ := compileFile(t, tt.source, tt.code)
+			lines := strings.Split(assembly, "\n")
+
+			seen := make(map[int]bool)
+			last := 0
+			for _, m := range arm64.SourceMap(assembly) {
+				if m.AsmStart <= last || m.AsmEnd < m.AsmStart || m.AsmEnd > len(lines) {
+					t.Fatalf("bloque fuera de orden o de rango: %+v", m)
+				}
+				last = m.AsmEnd
+				seen[m.Line] = true
+
+				block := strings.Join(lines[m.AsmStart-1:m.AsmEnd], "\n")
+				if m.Line == 3 && !strings.Contains(block, "bl print_") {
+					t.Errorf("el bloque de println no llama a print_:\n%s", block)
+				}
+			}
+			for line := 1; line <= 3; line++ {
+				if !seen[line] {
+					t.Errorf("la línea %d no está en el mapa de fuentes:\n%s", line, assembly)
+				}
+			}
+			if want := fmt.Sprintf(".file 1 %q\n", tt.file); !strings.HasPrefix(assembly, want) {
+				t.Errorf("se esperaba la directiva %q", strings.TrimSpace(want))
+			}
+		})
+	}
+}
@@ -0,0 +1,955 @@
+// Package emulator ejecuta el ensamblador ARM64 que genera el traductor sin
+// ensamblarlo: interpreta el texto instrucción por instrucción. Sirve para
+// comparar la salida del código compilado con la del intérprete en máquinas
+// sin aarch64-linux-gnu-as ni qemu-aarch64.
+//
+// Solo cubre el subconjunto de AArch64 que usan el traductor y su librería
+// estándar: aritmética entera y de punto flotante, comparaciones y saltos,
+// cargas y guardados con los modos de direccionamiento habituales, y las
+// llamadas al sistema write, exit y brk de Linux. El heap que entrega brk
+// empieza vacío en heapBase y crece hasta HeapSize bytes.
+package emulator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Límites por defecto de una ejecución.
+const (
+	DefaultMaxSteps  = 10_000_000
+	DefaultStackSize = 1 << 20
+	DefaultHeapSize  = 64 << 20
+)
+
+// Direcciones base de cada región de memoria.
+const (
+	textBase  = 0x400000
+	dataBase  = 0x10000000
+	heapBase  = 0x20000000
+	stackTop  = 0x7ff00000
+	stackSlop = 4096 // espacio sobre el sp inicial, como argc y argv en Linux
+)
+
+// Error es un fallo de la ejecución, con la línea del ensamblador donde
+// ocurrió.
+type Error struct {
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf("línea %d: %s", e.Line, e.Msg)
+}
+
+// Result es lo que produjo una ejecución.
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Steps    int
+}
+
+type instruction struct {
+	op   string
+	args []string
+	line int
+}
+
+// Program es un ensamblador ya analizado, listo para ejecutarse.
+type Program struct {
+	text   []instruction
+	labels map[string]int // etiqueta de código -> índice de instrucción
+	data   []byte
+	dataAt map[string]int // etiqueta de datos -> desplazamiento en data
+
+	labelOffset int // inicio de los últimos datos emitidos, durante Parse
+}
+
+// Machine es el estado de una ejecución.
+type Machine struct {
+	MaxSteps  int
+	StackSize int
+	HeapSize  int
+
+	program *Program
+	x       [31]uint64
+	d       [32]float64
+	sp      uint64
+	pc      int
+	n, z    bool
+	c, v    bool
+	data    []byte
+	stack   []byte
+	heap    []byte // desde heapBase hasta el break actual
+	stdout  strings.Builder
+	stderr  strings.Builder
+	exited  bool
+	exit    int
+	current instruction
+}
+
+// Run analiza y ejecuta source con los límites por defecto.
+func Run(source string) (*Result, error) {
+	program, err := Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return NewMachine(program).Run()
+}
+
+// NewMachine prepara una ejecución de program.
+func NewMachine(program *Program) *Machine {
+	return &Machine{MaxSteps: DefaultMaxSteps, StackSize: DefaultStackSize, HeapSize: DefaultHeapSize, program: program}
+}
+
+// Run ejecuta el programa desde _start hasta la llamada exit. Si falla,
+// devuelve también lo que el programa alcanzó a escribir.
+func (m *Machine) Run() (result *Result, err error) {
+	start, ok := m.program.labels["_start"]
+	if !ok {
+		return nil, &Error{Msg: "no existe la etiqueta _start"}
+	}
+	m.pc = start
+	m.data = append([]byte(nil), m.program.data...)
+	m.stack = make([]byte, m.StackSize+stackSlop)
+	m.sp = stackTop - stackSlop
+	m.heap = nil
+
+	result = &Result{}
+	defer func() {
+		result.Stdout = m.stdout.String()
+		result.Stderr = m.stderr.String()
+		result.ExitCode = m.exit
+		if r := recover(); r != nil {
+			e, ok := r.(*Error)
+			if !ok {
+				panic(r)
+			}
+			err = e
+		}
+	}()
+
+	for !m.exited {
+		if result.Steps >= m.MaxSteps {
+			return result, &Error{Line: m.current.line, Msg: fmt.Sprintf("se superó el límite de %d instrucciones", m.MaxSteps)}
+		}
+		if m.pc < 0 || m.pc >= len(m.program.text) {
+			return result, &Error{Line: m.current.line, Msg: fmt.Sprintf("el programa saltó fuera del código (instrucción %d)", m.pc)}
+		}
+		m.current = m.program.text[m.pc]
+		m.pc++
+		result.Steps++
+		m.step(m.current)
+	}
+	return result, nil
+}
+
+// fail detiene la ejecución con un error en la instrucción actual.
+func (m *Machine) fail(format string, args ...any) {
+	panic(&Error{Line: m.current.line, Msg: fmt.Sprintf(format, args...)})
+}
+
+// === REGISTROS ===
+
+// register describe un operando de registro: x0-x30, w0-w30, sp, xzr, wzr,
+// d0-d31 o s0-s31.
+type register struct {
+	kind  byte // 'x', 'w', 'd', 's'
+	index int  // 31 es sp o el registro cero, según zero
+	zero  bool
+}
+
+func parseRegister(name string) (register, bool) {
+	switch name {
+	case "sp":
+		return register{kind: 'x', index: 31}, true
+	case "wsp":
+		return register{kind: 'w', index: 31}, true
+	case "xzr":
+		return register{kind: 'x', index: 31, zero: true}, true
+	case "wzr":
+		return register{kind: 'w', index: 31, zero: true}, true
+	case "fp":
+		return register{kind: 'x', index: 29}, true
+	case "lr":
+		return register{kind: 'x', index: 30}, true
+	}
+	if len(name) < 2 || !strings.ContainsRune("xwds", rune(name[0])) {
+		return register{}, false
+	}
+	index, err := strconv.Atoi(name[1:])
+	if err != nil || index < 0 || index > 31 || (index == 31 && name[0] != 'd' && name[0] != 's') {
+		return register{}, false
+	}
+	return register{kind: name[0], index: index}, true
+}
+
+func (m *Machine) reg(name string) register {
+	r, ok := parseRegister(name)
+	if !ok {
+		m.fail("registro inválido %q", name)
+	}
+	return r
+}
+
+func (m *Machine) get(r register) uint64 {
+	var v uint64
+	switch {
+	case r.zero:
+		return 0
+	case r.index == 31:
+		v = m.sp
+	default:
+		v = m.x[r.index]
+	}
+	if r.kind == 'w' {
+		v &= 0xffffffff
+	}
+	return v
+}
+
+func (m *Machine) set(r register, v uint64) {
+	if r.kind == 'w' {
+		v &= 0xffffffff
+	}
+	switch {
+	case r.zero:
+	case r.index == 31:
+		m.sp = v
+	default:
+		m.x[r.index] = v
+	}
+}
+
+// signed lee un registro como entero con signo de su ancho.
+func (m *Machine) signed(r register) int64 {
+	if r.kind == 'w' {
+		return int64(int32(m.get(r)))
+	}
+	return int64(m.get(r))
+}
+
+func (m *Machine) getFloat(r register) float64 {
+	if r.kind == 's' {
+		return float64(float32(m.d[r.index]))
+	}
+	return m.d[r.index]
+}
+
+func (m *Machine) setFloat(r register, v float64) {
+	if r.kind == 's' {
+		v = float64(float32(v))
+	}
+	m.d[r.index] = v
+}
+
+func isFloatRegister(r register) bool {
+	return r.kind == 'd' || r.kind == 's'
+}
+
+// === OPERANDOS ===
+
+// immediate interpreta "#123", "#-16", "#0x1f" o "123".
+func (m *Machine) immediate(arg string) int64 {
+	s := strings.TrimPrefix(arg, "#")
+	v, err := strconv.ParseInt(s, 0, 64)
+	if err != nil {
+		u, uerr := strconv.ParseUint(s, 0, 64)
+		if uerr != nil {
+			m.fail("inmediato inválido %q", arg)
+		}
+		v = int64(u)
+	}
+	return v
+}
+
+func isImmediate(arg string) bool {
+	return strings.HasPrefix(arg, "#")
+}
+
+// operand evalúa el segundo operando de una instrucción aritmética: un
+// registro con un desplazamiento opcional ("x2, lsl #3") o un inmediato.
+func (m *Machine) operand(args []string, width byte) uint64 {
+	if len(args) == 0 {
+		m.fail("falta un operando")
+	}
+	var v uint64
+	if isImmediate(args[0]) {
+		v = uint64(m.immediate(args[0]))
+	} else {
+		v = m.get(m.reg(args[0]))
+	}
+	if len(args) > 1 {
+		fields := strings.Fields(args[1])
+		if len(fields) != 2 {
+			m.fail("desplazamiento inválido %q", args[1])
+		}
+		amount := uint64(m.immediate(fields[1]))
+		switch fields[0] {
+		case "lsl":
+			v <<= amount
+		case "lsr":
+			v >>= amount
+		case "asr":
+			if width == 'w' {
+				v = uint64(int32(v) >> amount)
+			} else {
+				v = uint64(int64(v) >> amount)
+			}
+		default:
+			m.fail("desplazamiento desconocido %q", fields[0])
+		}
+	}
+	if width == 'w' {
+		v &= 0xffffffff
+	}
+	return v
+}
+
+// === MEMORIA ===
+
+func (m *Machine) memory(addr uint64, size int) []byte {
+	switch {
+	case addr >= dataBase && addr+uint64(size) <= dataBase+uint64(len(m.data)):
+		offset := addr - dataBase
+		return m.data[offset : offset+uint64(size)]
+	case addr >= stackTop-uint64(m.StackSize) && addr+uint64(size) <= stackTop:
+		offset := addr - (stackTop - uint64(m.StackSize))
+		return m.stack[offset : offset+uint64(size)]
+	case addr >= heapBase && addr+uint64(size) <= heapBase+uint64(len(m.heap)):
+		offset := addr - heapBase
+		return m.heap[offset : offset+uint64(size)]
+	}
+	m.fail("acceso a memoria inválido en 0x%x", addr)
+	return nil
+}
+
+func (m *Machine) load(addr uint64, size int) uint64 {
+	var v uint64
+	for i, b := range m.memory(addr, size) {
+		v |= uint64(b) << (8 * i)
+	}
+	return v
+}
+
+func (m *Machine) store(addr uint64, size int, v uint64) {
+	mem := m.memory(addr, size)
+	for i := range mem {
+		mem[i] = byte(v >> (8 * i))
+	}
+}
+
+// address resuelve un operando de memoria. Acepta "[xn]", "[xn, #imm]",
+// "[xn, xm]", "[xn, #imm]!" (pre-índice) y "[xn]" seguido de "#imm"
+// (post-índice). Devuelve la dirección a usar y la función que actualiza la
+// base, si corresponde.
+func (m *Machine) address(args []string) (uint64, func()) {
+	if len(args) == 0 || !strings.HasPrefix(args[0], "[") {
+		m.fail("operando de memoria inválido %v", args)
+	}
+	mem := args[0]
+	writeback := strings.HasSuffix(mem, "!")
+	mem = strings.TrimSuffix(mem, "!")
+	if !strings.HasSuffix(mem, "]") {
+		m.fail("operando de memoria inválido %q", args[0])
+	}
+	parts := splitOperands(mem[1 : len(mem)-1])
+	base := m.reg(parts[0])
+	addr := m.get(base)
+
+	var offset uint64
+	if len(parts) > 1 {
+		offset = m.operand(parts[1:], 'x')
+	}
+
+	switch {
+	case len(args) > 1:
+		// post-índice: se usa la base y después se le suma el inmediato
+		post := uint64(m.immediate(args[1]))
+		return addr, func() { m.set(base, addr+post) }
+	case writeback:
+		return addr + offset, func() { m.set(base, addr+offset) }
+	}
+	return addr + offset, nil
+}
+
+// === EJECUCIÓN ===
+
+var conditions = map[string]func(m *Machine) bool{
+	"eq": func(m *Machine) bool { return m.z },
+	"ne": func(m *Machine) bool { return !m.z },
+	"cs": func(m *Machine) bool { return m.c },
+	"hs": func(m *Machine) bool { return m.c },
+	"cc": func(m *Machine) bool { return !m.c },
+	"lo": func(m *Machine) bool { return !m.c },
+	"mi": func(m *Machine) bool { return m.n },
+	"pl": func(m *Machine) bool { return !m.n },
+	"vs": func(m *Machine) bool { return m.v },
+	"vc": func(m *Machine) bool { return !m.v },
+	"hi": func(m *Machine) bool { return m.c && !m.z },
+	"ls": func(m *Machine) bool { return !m.c || m.z },
+	"ge": func(m *Machine) bool { return m.n == m.v },
+	"lt": func(m *Machine) bool { return m.n != m.v },
+	"gt": func(m *Machine) bool { return !m.z && m.n == m.v },
+	"le": func(m *Machine) bool { return m.z || m.n != m.v },
+	"al": func(m *Machine) bool { return true },
+}
+
+func (m *Machine) condition(name string) bool {
+	cond, ok := conditions[name]
+	if !ok {
+		m.fail("condición desconocida %q", name)
+	}
+	return cond(m)
+}
+
+// setFlags calcula NZCV de a - b (o de a + b con add) con el ancho indicado.
+func (m *Machine) setFlags(a, b uint64, add bool, width byte) {
+	bits := uint(64)
+	if width == 'w' {
+		bits = 32
+		a &= 0xffffffff
+		b &= 0xffffffff
+	}
+	mask := uint64(math.MaxUint64) >> (64 - bits)
+	sign := uint64(1) << (bits - 1)
+
+	var result uint64
+	if add {
+		result = (a + b) & mask
+		m.c = result < a
+		m.v = (a&sign) == (b&sign) && (result&sign) != (a&sign)
+	} else {
+		result = (a - b) & mask
+		m.c = a >= b
+		m.v = (a&sign) != (b&sign) && (result&sign) != (a&sign)
+	}
+	m.n = result&sign != 0
+	m.z = result == 0
+}
+
+func (m *Machine) jump(label string) {
+	target, ok := m.program.labels[label]
+	if !ok {
+		m.fail("etiqueta desconocida %q", label)
+	}
+	m.pc = target
+}
+
+func (m *Machine) returnAddress() uint64 {
+	return textBase + uint64(m.pc)*4
+}
+
+func (m *Machine) need(args []string, n int) {
+	if len(args) < n {
+		m.fail("%s necesita %d operandos", m.current.op, n)
+	}
+}
+
+func (m *Machine) step(ins instruction) {
+	op, args := ins.op, ins.args
+
+	// b.cond y la forma sin punto (beq, bne...)
+	if cond, ok := strings.CutPrefix(op, "b."); ok {
+		m.need(args, 1)
+		if m.condition(cond) {
+			m.jump(args[0])
+		}
+		return
+	}
+	if len(op) == 3 && op[0] == 'b' && conditions[op[1:]] != nil {
+		m.need(args, 1)
+		if m.condition(op[1:]) {
+			m.jump(args[0])
+		}
+		return
+	}
+
+	switch op {
+	case "nop":
+
+	// --- movimientos ---
+	case "mov":
+		m.need(args, 2)
+		dst := m.reg(args[0])
+		if isFloatRegister(dst) {
+			m.setFloat(dst, m.getFloat(m.reg(args[1])))
+			return
+		}
+		m.set(dst, m.operand(args[1:], dst.kind))
+	case "movz":
+		m.need(args, 2)
+		dst := m.reg(args[0])
+		m.set(dst, m.operand(args[1:], dst.kind))
+	case "movk":
+		m.need(args, 2)
+		dst := m.reg(args[0])
+		shift := uint64(0)
+		if len(args) > 2 {
+			fields := strings.Fields(args[2])
+			if len(fields) == 2 {
+				shift = uint64(m.immediate(fields[1]))
+			}
+		}
+		imm := uint64(m.immediate(args[1])) & 0xffff
+		m.set(dst, m.get(dst)&^(0xffff<<shift)|imm<<shift)
+	case "mvn":
+		m.need(args, 2)
+		dst := m.reg(args[0])
+		m.set(dst, ^m.operand(args[1:], dst.kind))
+
+	// --- aritmética entera ---
+	case "add", "adds", "sub", "subs":
+		m.need(args, 3)
+		dst := m.reg(args[0])
+		a := m.get(m.reg(args[1]))
+		b := m.operand(args[2:], dst.kind)
+		add := strings.HasPrefix(op, "add")
+		if strings.HasSuffix(op, "s") {
+			m.setFlags(a, b, add, dst.kind)
+		}
+		if add {
+			m.set(dst, a+b)
+		} else {
+			m.set(dst, a-b)
+		}
+	case "neg", "negs":
+		m.need(args, 2)
+		dst := m.reg(args[0])
+		b := m.operand(args[1:], dst.kind)
+		if op == "negs" {
+			m.setFlags(0, b, false, dst.kind)
+		}
+		m.set(dst, -b)
+	case "mul":
+		m.need(args, 3)
+		dst := m.reg(args[0])
+		m.set(dst, m.get(m.reg(args[1]))*m.get(m.reg(args[2])))
+	case "madd", "msub":
+		m.need(args, 4)
+		dst := m.reg(args[0])
+		product := m.get(m.reg(args[1])) * m.get(m.reg(args[2]))
+		acc := m.get(m.reg(args[3]))
+		if op == "madd" {
+			m.set(dst, acc+product)
+		} else {
+			m.set(dst, acc-product)
+		}
+	case "sdiv":
+		m.need(args, 3)
+		dst := m.reg(args[0])
+		a, b := m.signed(m.reg(args[1])), m.signed(m.reg(args[2]))
+		switch {
+		case b == 0:
+			m.set(dst, 0) // AArch64 no lanza excepción al dividir entre cero
+		case dst.kind == 'w' && a == math.MinInt32 && b == -1:
+			m.set(dst, uint64(a))
+		case a == math.MinInt64 && b == -1:
+			m.set(dst, uint64(a))
+		default:
+			m.set(dst, uint64(a/b))
+		}
+	case "udiv":
+		m.need(args, 3)
+		dst := m.reg(args[0])
+		a, b := m.get(m.reg(args[1])), m.get(m.reg(args[2]))
+		if b == 0 {
+			m.set(dst, 0)
+		} else {
+			m.set(dst, a/b)
+		}
+
+	// --- lógica y desplazamientos ---
+	case "and", "ands", "orr", "eor", "bic":
+		m.need(args, 3)
+		dst := m.reg(args[0])
+		a := m.get(m.reg(args[1]))
+		b := m.operand(args[2:], dst.kind)
+		var r uint64
+		switch op {
+		case "and", "ands":
+			r = a & b
+		case "orr":
+			r = a | b
+		case "eor":
+			r = a ^ b
+		case "bic":
+			r = a &^ b
+		}
+		if op == "ands" {
+			m.setFlags(r, 0, false, dst.kind)
+			m.c, m.v = false, false
+		}
+		m.set(dst, r)
+	case "lsl", "lsr", "asr":
+		m.need(args, 3)
+		dst := m.reg(args[0])
+		a := m.get(m.reg(args[1]))
+		bits := uint64(64)
+		if dst.kind == 'w' {
+			bits = 32
+		}
+		amount := m.operand(args[2:], dst.kind) % bits
+		switch op {
+		case "lsl":
+			m.set(dst, a<<amount)
+		case "lsr":
+			m.set(dst, a>>amount)
+		case "asr":
+			m.set(dst, uint64(m.signed(m.reg(args[1]))>>amount))
+		}
+
+	// --- comparaciones ---
+	case "cmp", "cmn", "tst":
+		m.need(args, 2)
+		first := m.reg(args[0])
+		a := m.get(first)
+		b := m.operand(args[1:], first.kind)
+		switch op {
+		case "cmp":
+			m.setFlags(a, b, false, first.kind)
+		case "cmn":
+			m.setFlags(a, b, true, first.kind)
+		case "tst":
+			m.setFlags(a&b, 0, false, first.kind)
+			m.c, m.v = false, false
+		}
+	case "cset":
+		m.need(args, 2)
+		if m.condition(args[1]) {
+			m.set(m.reg(args[0]), 1)
+		} else {
+			m.set(m.reg(args[0]), 0)
+		}
+	case "csel", "csinc", "csneg":
+		m.need(args, 4)
+		dst := m.reg(args[0])
+		if m.condition(args[3]) {
+			m.set(dst, m.get(m.reg(args[1])))
+			return
+		}
+		v := m.get(m.reg(args[2]))
+		switch op {
+		case "csinc":
+			v++
+		case "csneg":
+			v = -v
+		}
+		m.set(dst, v)
+
+	// --- saltos ---
+	case "b":
+		m.need(args, 1)
+		m.jump(args[0])
+	case "bl":
+		m.need(args, 1)
+		m.x[30] = m.returnAddress()
+		m.jump(args[0])
+	case "blr", "br":
+		m.need(args, 1)
+		target := m.get(m.reg(args[0]))
+		if op == "blr" {
+			m.x[30] = m.returnAddress()
+		}
+		m.jumpAddress(target)
+	case "ret":
+		target := m.x[30]
+		if len(args) > 0 {
+			target = m.get(m.reg(args[0]))
+		}
+		m.jumpAddress(target)
+	case "cbz", "cbnz":
+		m.need(args, 2)
+		zero := m.get(m.reg(args[0])) == 0
+		if zero == (op == "cbz") {
+			m.jump(args[1])
+		}
+	case "tbz", "tbnz":
+		m.need(args, 3)
+		bit := m.get(m.reg(args[0])) >> uint64(m.immediate(args[1])) & 1
+		if (bit == 0) == (op == "tbz") {
+			m.jump(args[2])
+		}
+
+	// --- memoria ---
+	case "ldr", "ldrb", "ldrh", "ldrsw", "str", "strb", "strh":
+		m.need(args, 2)
+		m.loadStore(op, args)
+	case "ldp", "stp":
+		m.need(args, 3)
+		first, second := m.reg(args[0]), m.reg(args[1])
+		size := 8
+		if first.kind == 'w' || first.kind == 's' {
+			size = 4
+		}
+		addr, update := m.address(args[2:])
+		if op == "stp" {
+			m.storeRegister(first, addr, size)
+			m.storeRegister(second, addr+uint64(size), size)
+		} else {
+			m.loadRegister(first, addr, size, false)
+			m.loadRegister(second, addr+uint64(size), size, false)
+		}
+		if update != nil {
+			update()
+		}
+	case "adr", "adrp":
+		m.need(args, 2)
+		m.set(m.reg(args[0]), m.labelAddress(args[1]))
+
+	// --- punto flotante ---
+	case "fmov":
+		m.need(args, 2)
+		dst := m.reg(args[0])
+		switch {
+		case isImmediate(args[1]):
+			v, err := strconv.ParseFloat(strings.TrimPrefix(args[1], "#"), 64)
+			if err != nil {
+				m.fail("inmediato flotante inválido %q", args[1])
+			}
+			m.setFloat(dst, v)
+		case isFloatRegister(dst):
+			src := m.reg(args[1])
+			if isFloatRegister(src) {
+				m.setFloat(dst, m.getFloat(src))
+			} else if dst.kind == 's' {
+				m.setFloat(dst, float64(math.Float32frombits(uint32(m.get(src)))))
+			} else {
+				m.setFloat(dst, math.Float64frombits(m.get(src)))
+			}
+		default:
+			src := m.reg(args[1])
+			if src.kind == 's' {
+				m.set(dst, uint64(math.Float32bits(float32(m.getFloat(src)))))
+			} else {
+				m.set(dst, math.Float64bits(m.getFloat(src)))
+			}
+		}
+	case "fadd", "fsub", "fmul", "fdiv", "fmax", "fmin":
+		m.need(args, 3)
+		dst := m.reg(args[0])
+		a, b := m.getFloat(m.reg(args[1])), m.getFloat(m.reg(args[2]))
+		var r float64
+		switch op {
+		case "fadd":
+			r = a + b
+		case "fsub":
+			r = a - b
+		case "fmul":
+			r = a * b
+		case "fdiv":
+			r = a / b
+		case "fmax":
+			r = math.Max(a, b)
+		case "fmin":
+			r = math.Min(a, b)
+		}
+		m.setFloat(dst, r)
+	case "fneg", "fabs", "fsqrt":
+		m.need(args, 2)
+		dst := m.reg(args[0])
+		a := m.getFloat(m.reg(args[1]))
+		switch op {
+		case "fneg":
+			m.setFloat(dst, -a)
+		case "fabs":
+			m.setFloat(dst, math.Abs(a))
+		case "fsqrt":
+			m.setFloat(dst, math.Sqrt(a))
+		}
+	case "fcmp":
+		m.need(args, 2)
+		a := m.getFloat(m.reg(args[0]))
+		var b float64
+		if isImmediate(args[1]) {
+			b = float64(m.immediate(strings.Replace(args[1], ".0", "", 1)))
+		} else {
+			b = m.getFloat(m.reg(args[1]))
+		}
+		switch {
+		case math.IsNaN(a) || math.IsNaN(b):
+			m.n, m.z, m.c, m.v = false, false, true, true
+		case a == b:
+			m.n, m.z, m.c, m.v = false, true, true, false
+		case a < b:
+			m.n, m.z, m.c, m.v = true, false, false, false
+		default:
+			m.n, m.z, m.c, m.v = false, false, true, false
+		}
+	case "scvtf", "ucvtf":
+		m.need(args, 2)
+		src := m.reg(args[1])
+		if op == "scvtf" {
+			m.setFloat(m.reg(args[0]), float64(m.signed(src)))
+		} else {
+			m.setFloat(m.reg(args[0]), float64(m.get(src)))
+		}
+	case "fcvtzs", "fcvtzu", "fcvtms", "fcvtas":
+		m.need(args, 2)
+		dst := m.reg(args[0])
+		f := m.getFloat(m.reg(args[1]))
+		switch op {
+		case "fcvtms":
+			f = math.Floor(f)
+		case "fcvtas":
+			f = math.Round(f)
+		}
+		m.set(dst, uint64(saturate(f, dst.kind, op == "fcvtzu")))
+	case "fcsel":
+		m.need(args, 4)
+		if m.condition(args[3]) {
+			m.setFloat(m.reg(args[0]), m.getFloat(m.reg(args[1])))
+		} else {
+			m.setFloat(m.reg(args[0]), m.getFloat(m.reg(args[2])))
+		}
+
+	// --- sistema ---
+	case "svc":
+		m.syscall()
+
+	default:
+		m.fail("instrucción no soportada %q", op)
+	}
+}
+
+// saturate convierte f a entero como fcvtzs: trunca hacia cero, satura en
+// los extremos y convierte NaN en cero.
+func saturate(f float64, width byte, unsigned bool) int64 {
+	if math.IsNaN(f) {
+		return 0
+	}
+	f = math.Trunc(f)
+	lo, hi := float64(math.MinInt64), float64(math.MaxInt64)
+	if width == 'w' {
+		lo, hi = math.MinInt32, math.MaxInt32
+	}
+	if unsigned {
+		lo = 0
+	}
+	switch {
+	case f <= lo:
+		return int64(lo)
+	case f >= hi:
+		if width == 'w' {
+			return math.MaxInt32
+		}
+		return math.MaxInt64
+	}
+	return int64(f)
+}
+
+func (m *Machine) jumpAddress(addr uint64) {
+	if addr < textBase || (addr-textBase)%4 != 0 {
+		m.fail("salto a una dirección inválida 0x%x", addr)
+	}
+	m.pc = int((addr - textBase) / 4)
+}
+
+func (m *Machine) labelAddress(label string) uint64 {
+	label = strings.TrimPrefix(label, ":lo12:")
+	if offset, ok := m.program.dataAt[label]; ok {
+		return dataBase + uint64(offset)
+	}
+	if index, ok := m.program.labels[label]; ok {
+		return textBase + uint64(index)*4
+	}
+	m.fail("etiqueta desconocida %q", label)
+	return 0
+}
+
+func (m *Machine) loadStore(op string, args []string) {
+	r := m.reg(args[0])
+
+	// ldr x0, =etiqueta carga la dirección de la etiqueta
+	if op == "ldr" && strings.HasPrefix(args[1], "=") {
+		m.set(r, m.labelAddress(args[1][1:]))
+		return
+	}
+
+	size := 8
+	switch {
+	case strings.HasSuffix(op, "b"):
+		size = 1
+	case strings.HasSuffix(op, "h"):
+		size = 2
+	case op == "ldrsw" || r.kind == 'w' || r.kind == 's':
+		size = 4
+	}
+
+	addr, update := m.address(args[1:])
+	if strings.HasPrefix(op, "str") {
+		m.storeRegister(r, addr, size)
+	} else {
+		m.loadRegister(r, addr, size, op == "ldrsw")
+	}
+	if update != nil {
+		update()
+	}
+}
+
+func (m *Machine) storeRegister(r register, addr uint64, size int) {
+	if isFloatRegister(r) {
+		if size == 4 {
+			m.store(addr, 4, uint64(math.Float32bits(float32(m.getFloat(r)))))
+		} else {
+			m.store(addr, 8, math.Float64bits(m.getFloat(r)))
+		}
+		return
+	}
+	m.store(addr, size, m.get(r))
+}
+
+func (m *Machine) loadRegister(r register, addr uint64, size int, signExtend bool) {
+	v := m.load(addr, size)
+	if isFloatRegister(r) {
+		if size == 4 {
+			m.setFloat(r, float64(math.Float32frombits(uint32(v))))
+		} else {
+			m.setFloat(r, math.Float64frombits(v))
+		}
+		return
+	}
+	if signExtend {
+		v = uint64(int64(int32(v)))
+	}
+	m.set(r, v)
+}
+
+// syscall atiende las llamadas al sistema de Linux en AArch64: el número va
+// en x8 y los argumentos en x0-x5.
+func (m *Machine) syscall() {
+	switch m.x[8] {
+	case 64: // write(fd, buf, count)
+		fd, buf, count := m.x[0], m.x[1], m.x[2]
+		bytes := m.memory(buf, int(count))
+		switch fd {
+		case 1:
+			m.stdout.Write(bytes)
+		case 2:
+			m.stderr.Write(bytes)
+		default:
+			m.fail("write a un descriptor desconocido %d", fd)
+		}
+		m.x[0] = count
+	case 214: // brk(addr): con una dirección fuera del heap solo consulta
+		if addr := m.x[0]; addr >= heapBase && addr <= heapBase+uint64(m.HeapSize) {
+			size := int(addr - heapBase)
+			if size > len(m.heap) {
+				m.heap = append(m.heap, make([]byte, size-len(m.heap))...)
+			}
+			m.heap = m.heap[:size]
+		}
+		m.x[0] = heapBase + uint64(len(m.heap))
+	case 93, 94: // exit, exit_group
+		m.exited = true
+		m.exit = int(int32(m.x[0]))
+	default:
+		m.fail("llamada al sistema no soportada %d", m.x[8])
+	}
+}
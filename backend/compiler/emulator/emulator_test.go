@@ -0,0 +1,252 @@
+package emulator
+
+import (
+	"strings"
+	"testing"
+)
+
+// program arma un ensamblador con la sección de datos y el código de _start.
+func program(data, text string) string {
+	return ".data\n" + data + "\n.text\n.global _start\n_start:\n" + text + "\n    mov x0, #0\n    mov x8, #93\n    svc #0\n"
+}
+
+// printDigit imprime el dígito que queda en x0.
+const printDigit = `
+print_digit:
+    add x0, x0, #48
+    sub sp, sp, #16
+    strb w0, [sp]
+    mov x0, #1
+    mov x1, sp
+    mov x2, #1
+    mov x8, #64
+    svc #0
+    add sp, sp, #16
+    ret
+`
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{
+			name: "write de una cadena",
+			code: program(`msg: .asciz "hola\n"`, `
+    adr x1, msg
+    mov x0, #1
+    mov x2, #5
+    mov x8, #64
+    svc #0`),
+			want: "hola\n",
+		},
+		{
+			name: "aritmética y llamadas",
+			code: program("", `
+    mov x1, #7
+    mov x2, #3
+    sdiv x3, x1, x2
+    msub x0, x3, x2, x1     // 7 % 3
+    bl print_digit
+    mov x0, #2
+    mov x1, #3
+    mul x0, x0, x1
+    bl print_digit
+    b fin
+`+printDigit+`
+fin:`),
+			want: "16",
+		},
+		{
+			name: "comparaciones con signo",
+			code: program("", `
+    mov x19, #-5
+    cmp x19, #2
+    cset x0, lt
+    bl print_digit
+    cmp x19, #2
+    cset x0, hi              // sin signo -5 es enorme
+    bl print_digit
+    cmp x19, #-5
+    b.ne fin
+    mov x0, #9
+    bl print_digit
+    b fin
+`+printDigit+`
+fin:`),
+			want: "119",
+		},
+		{
+			name: "pila con pre y post índice",
+			code: program("", `
+    mov x0, #4
+    mov x1, #2
+    stp x0, x1, [sp, #-16]!
+    str x1, [sp, #-8]!
+    ldr x0, [sp], #8
+    bl print_digit
+    ldp x0, x1, [sp], #16
+    bl print_digit
+    b fin
+`+printDigit+`
+fin:`),
+			want: "24",
+		},
+		{
+			name: "registros w y bytes",
+			code: program(`buf: .space 8`, `
+    adr x20, buf
+    mov x21, #3
+    mov w0, #0x105
+    strb w0, [x20, x21]     // guarda solo el byte bajo
+    ldrb w0, [x20, x21]
+    bl print_digit
+    b fin
+`+printDigit+`
+fin:`),
+			want: "5",
+		},
+		{
+			name: "punto flotante",
+			code: program(`d: .double 2.75`, `
+    adr x0, d
+    ldr d0, [x0]
+    mov x1, #2
+    scvtf d1, x1
+    fmul d0, d0, d1          // 5.5
+    fcvtzs x0, d0
+    bl print_digit
+    fcmp d0, d1
+    cset x0, gt
+    bl print_digit
+    b fin
+`+printDigit+`
+fin:`),
+			want: "51",
+		},
+		{
+			name: "tbnz y cbz",
+			code: program("", `
+    mov x1, #-1
+    tbnz x1, #63, negativo
+    mov x0, #0
+    bl print_digit
+negativo:
+    mov x0, #0
+    cbz x0, fin_ok
+    bl print_digit
+fin_ok:
+    mov x0, #7
+    bl print_digit
+    b fin
+`+printDigit+`
+fin:`),
+			want: "7",
+		},
+		{
+			name: "división entre cero no falla",
+			code: program("", `
+    mov x1, #5
+    mov x2, #0
+    sdiv x0, x1, x2
+    bl print_digit
+    b fin
+`+printDigit+`
+fin:`),
+			want: "0",
+		},
+		{
+			name: "brk agranda el heap",
+			code: program("", `
+    mov x0, #0
+    mov x8, #214
+    svc #0                   // break actual
+    mov x19, x0
+    add x0, x19, #32
+    mov x8, #214
+    svc #0
+    sub x0, x0, x19          // 32 bytes nuevos
+    mov x1, #5
+    str x1, [x19, #24]
+    ldr x0, [x19, #24]
+    bl print_digit
+    ldr x0, [x19]            // la memoria nueva está en cero
+    bl print_digit
+    b fin
+`+printDigit+`
+fin:`),
+			want: "50",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Run(tt.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Stdout != tt.want {
+				t.Errorf("stdout = %q, se esperaba %q", result.Stdout, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunExitCode(t *testing.T) {
+	result, err := Run(".text\n_start:\n    mov x0, #3\n    mov x8, #93\n    svc #0\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 3 {
+		t.Errorf("código de salida = %d, se esperaba 3", result.ExitCode)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"sin _start", ".text\nmain:\n    ret\n", "no existe la etiqueta _start"},
+		{"instrucción desconocida", ".text\n_start:\n    mov x0, #1\n    frobnicate x0\n", "línea 4: instrucción no soportada"},
+		{"ciclo infinito", ".text\n_start:\nloop:\n    b loop\n", "se superó el límite"},
+		{"memoria inválida", ".text\n_start:\n    mov x1, #16\n    ldr x0, [x1]\n", "acceso a memoria inválido en 0x10"},
+		{"heap sin reservar", ".text\n_start:\n    mov x0, #0\n    mov x8, #214\n    svc #0\n    ldr x1, [x0]\n", "acceso a memoria inválido en 0x20000000"},
+		{"salir del código", ".text\n_start:\n    mov x0, #1\n", "saltó fuera del código"},
+		{"etiqueta repetida", ".text\n_start:\n_start:\n    ret\n", "definida dos veces"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := Parse(tt.code)
+			if err == nil {
+				machine := NewMachine(program)
+				machine.MaxSteps = 1000
+				_, err = machine.Run()
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, se esperaba que contuviera %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunKeepsPartialOutput(t *testing.T) {
+	code := program(`msg: .asciz "a"`, `
+    adr x1, msg
+    mov x0, #1
+    mov x2, #1
+    mov x8, #64
+    svc #0
+    mov x1, #0
+    ldr x0, [x1]`)
+	result, err := Run(code)
+	if err == nil {
+		t.Fatal("se esperaba un error de memoria")
+	}
+	if result == nil || result.Stdout != "a" {
+		t.Errorf("se esperaba la salida parcial %q, se obtuvo %+v", "a", result)
+	}
+}
@@ -0,0 +1,293 @@
+package emulator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Parse analiza el texto del ensamblador. Las etiquetas seguidas de
+// directivas de datos (.asciz, .quad, ...) son direcciones de datos; las
+// seguidas de instrucciones son destinos de salto, sin importar la sección.
+func Parse(source string) (*Program, error) {
+	p := &Program{labels: map[string]int{}, dataAt: map[string]int{}}
+	var pending []string // etiquetas que esperan su primera instrucción o dato
+
+	for number, raw := range strings.Split(source, "\n") {
+		line := strings.TrimSpace(stripComment(raw))
+		number++
+
+		// una línea puede tener varias etiquetas antes de la instrucción
+		for {
+			colon := labelEnd(line)
+			if colon < 0 {
+				break
+			}
+			pending = append(pending, line[:colon])
+			line = strings.TrimSpace(line[colon+1:])
+		}
+		if line == "" {
+			continue
+		}
+
+		op, rest := strings.ToLower(line), ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			op, rest = strings.ToLower(line[:i]), strings.TrimSpace(line[i+1:])
+		}
+
+		if strings.HasPrefix(op, ".") {
+			isData, err := p.directive(op, rest)
+			if err != nil {
+				return nil, &Error{Line: number, Msg: err.Error()}
+			}
+			if isData {
+				for _, label := range pending {
+					if err := p.define(label); err != nil {
+						return nil, &Error{Line: number, Msg: err.Error()}
+					}
+					p.dataAt[label] = p.labelOffset
+				}
+				pending = nil
+			}
+			continue
+		}
+
+		for _, label := range pending {
+			if err := p.define(label); err != nil {
+				return nil, &Error{Line: number, Msg: err.Error()}
+			}
+			p.labels[label] = len(p.text)
+		}
+		pending = nil
+		p.text = append(p.text, instruction{op: op, args: splitOperands(rest), line: number})
+	}
+
+	// etiquetas al final del código: un salto a ellas sale del programa
+	for _, label := range pending {
+		if err := p.define(label); err != nil {
+			return nil, &Error{Msg: err.Error()}
+		}
+		p.labels[label] = len(p.text)
+	}
+	return p, nil
+}
+
+func (p *Program) define(label string) error {
+	_, inText := p.labels[label]
+	_, inData := p.dataAt[label]
+	if inText || inData {
+		return fmt.Errorf("la etiqueta %q está definida dos veces", label)
+	}
+	return nil
+}
+
+// labelEnd devuelve la posición de los dos puntos de una etiqueta al inicio
+// de la línea, o -1 si la línea no empieza con una.
+func labelEnd(line string) int {
+	for i, r := range line {
+		switch {
+		case r == ':':
+			if i == 0 {
+				return -1
+			}
+			return i
+		case r == '_' || r == '.' || r == '$' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// stripComment quita los comentarios "//" y las líneas que empiezan con "#",
+// respetando las cadenas entre comillas.
+func stripComment(line string) string {
+	if strings.HasPrefix(strings.TrimSpace(line), "#") {
+		return ""
+	}
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && quoted:
+			i++
+		case line[i] == '"':
+			quoted = !quoted
+		case !quoted && strings.HasPrefix(line[i:], "//"):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitOperands separa los operandos por comas, sin partir lo que está entre
+// corchetes ni entre comillas.
+func splitOperands(s string) []string {
+	var args []string
+	depth, quoted, start := 0, false, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" || len(args) > 0 {
+		args = append(args, rest)
+	}
+	return args
+}
+
+// === DIRECTIVAS ===
+
+// directive procesa una directiva y devuelve si emitió datos. labelOffset
+// queda en el inicio de los datos emitidos, ya alineados, que es donde
+// apuntan las etiquetas que los preceden.
+func (p *Program) directive(op, rest string) (bool, error) {
+	args := splitOperands(rest)
+	switch op {
+	case ".text", ".data", ".bss", ".section", ".global", ".globl", ".type", ".size",
+		".file", ".loc", ".cfi_startproc", ".cfi_endproc", ".cfi_def_cfa", ".cfi_offset",
+		".cfi_def_cfa_offset", ".ident", ".arch", ".extern":
+		return false, nil
+
+	case ".align", ".p2align", ".balign":
+		if len(args) == 0 {
+			return false, fmt.Errorf("%s necesita un argumento", op)
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return false, fmt.Errorf("alineación inválida %q", args[0])
+		}
+		if op != ".balign" {
+			n = 1 << n
+		}
+		for n > 0 && len(p.data)%n != 0 {
+			p.data = append(p.data, 0)
+		}
+		return false, nil
+
+	case ".ascii", ".asciz", ".string":
+		p.labelOffset = len(p.data)
+		for _, arg := range args {
+			s, err := unquote(arg)
+			if err != nil {
+				return false, err
+			}
+			p.data = append(p.data, s...)
+			if op != ".ascii" {
+				p.data = append(p.data, 0)
+			}
+		}
+		return true, nil
+
+	case ".byte", ".hword", ".short", ".word", ".int", ".long", ".quad", ".xword", ".dword":
+		size := map[string]int{".byte": 1, ".hword": 2, ".short": 2, ".word": 4, ".int": 4, ".long": 4}[op]
+		if size == 0 {
+			size = 8
+		}
+		p.alignTo(size)
+		for _, arg := range args {
+			v, err := strconv.ParseInt(arg, 0, 64)
+			if err != nil {
+				return false, fmt.Errorf("valor inválido %q en %s", arg, op)
+			}
+			var buf [8]byte
+			binary.LittleEndian.PutUint64(buf[:], uint64(v))
+			p.data = append(p.data, buf[:size]...)
+		}
+		return true, nil
+
+	case ".double", ".float", ".single":
+		size := 8
+		if op != ".double" {
+			size = 4
+		}
+		p.alignTo(size)
+		for _, arg := range args {
+			f, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return false, fmt.Errorf("valor inválido %q en %s", arg, op)
+			}
+			var buf [8]byte
+			if size == 8 {
+				binary.LittleEndian.PutUint64(buf[:], math.Float64bits(f))
+			} else {
+				binary.LittleEndian.PutUint32(buf[:], math.Float32bits(float32(f)))
+			}
+			p.data = append(p.data, buf[:size]...)
+		}
+		return true, nil
+
+	case ".space", ".skip", ".zero":
+		if len(args) == 0 {
+			return false, fmt.Errorf("%s necesita un tamaño", op)
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return false, fmt.Errorf("tamaño inválido %q", args[0])
+		}
+		p.labelOffset = len(p.data)
+		p.data = append(p.data, make([]byte, n)...)
+		return true, nil
+	}
+	return false, fmt.Errorf("directiva no soportada %q", op)
+}
+
+// alignTo rellena los datos hasta un múltiplo de size, como hace el
+// ensamblador con los valores numéricos.
+func (p *Program) alignTo(size int) {
+	for len(p.data)%size != 0 {
+		p.data = append(p.data, 0)
+	}
+	p.labelOffset = len(p.data)
+}
+
+// unquote interpreta una cadena de GNU as con sus secuencias de escape.
+func unquote(arg string) ([]byte, error) {
+	if len(arg) < 2 || arg[0] != '"' || arg[len(arg)-1] != '"' {
+		return nil, fmt.Errorf("cadena inválida %s", arg)
+	}
+	s := arg[1 : len(arg)-1]
+	var out []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			out = append(out, s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			out = append(out, '\n')
+		case 't':
+			out = append(out, '\t')
+		case 'r':
+			out = append(out, '\r')
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			v := 0
+			for j := 0; j < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7'; j++ {
+				v = v*8 + int(s[i]-'0')
+				i++
+			}
+			i--
+			out = append(out, byte(v))
+		default:
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
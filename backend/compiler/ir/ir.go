@@ -0,0 +1,331 @@
+// Package ir es la representación intermedia del backend: código de tres
+// direcciones en bloques básicos, con registros virtuales e instrucciones
+// tipadas. Lower la construye desde el árbol tipado y cada target (por ahora
+// compiler/arm64) emite su ensamblador desde aquí, así las optimizaciones se
+// escriben una sola vez.
+//
+// El IR cubre solo una parte del lenguaje: escalares int, float, bool y
+// string, funciones globales, if, switch, los for de condición y de tres
+// partes, break, continue, return y print. No representa vectores ni
+// matrices, structs, métodos, for in, nil, funciones nativas, funciones
+// anidadas ni la asignación a un elemento o atributo. Esos programas, que son
+// la mayoría de los que usan colecciones, los traduce el ARM64Translator
+// directo desde el parse tree, sin las optimizaciones del IR, y Lower
+// devuelve un *UnsupportedError que dice qué construcción lo impidió.
+//
+// Las variables del programa no son registros: viven en un Var y se leen y
+// escriben con load y store. Los registros virtuales guardan resultados
+// intermedios y se definen una sola vez, salvo el resultado de && y ||, que
+// se define en cada camino de la evaluación en cortocircuito.
+package ir
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"main.go/ast"
+)
+
+// Type es el tipo de un valor en el IR.
+type Type int
+
+const (
+	Void   Type = iota
+	Int         // entero de 64 bits
+	Float       // double IEEE-754
+	Bool        // 0 o 1
+	String      // puntero a una cadena terminada en 0; add concatena
+)
+
+func (t Type) String() string {
+	switch t {
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case Bool:
+		return "bool"
+	case String:
+		return "string"
+	}
+	return "void"
+}
+
+// Value es un operando: un registro virtual o una constante.
+type Value interface {
+	Type() Type
+	String() string
+}
+
+// Reg es un registro virtual. Cada función numera los suyos desde 0.
+type Reg struct {
+	ID int
+	T  Type
+}
+
+func (r *Reg) Type() Type {
+	return r.T
+}
+
+func (r *Reg) String() string {
+	return fmt.Sprintf("%%%d", r.ID)
+}
+
+// Const es un valor conocido en tiempo de compilación. Los bool usan Int.
+type Const struct {
+	T     Type
+	Int   int64
+	Float float64
+	Str   string
+}
+
+// IntConst, FloatConst, BoolConst y StringConst crean constantes.
+func IntConst(v int64) Const { return Const{T: Int, Int: v} }
+
+func FloatConst(v float64) Const { return Const{T: Float, Float: v} }
+
+func StringConst(s string) Const { return Const{T: String, Str: s} }
+
+func BoolConst(b bool) Const {
+	if b {
+		return Const{T: Bool, Int: 1}
+	}
+	return Const{T: Bool}
+}
+
+func (c Const) Type() Type {
+	return c.T
+}
+
+func (c Const) String() string {
+	switch c.T {
+	case Float:
+		s := strconv.FormatFloat(c.Float, 'g', -1, 64)
+		if !math.IsInf(c.Float, 0) && !math.IsNaN(c.Float) && !strings.ContainsAny(s, ".eE") {
+			s += ".0"
+		}
+		return s
+	case Bool:
+		return strconv.FormatBool(c.Int != 0)
+	case String:
+		return strconv.Quote(c.Str)
+	}
+	return strconv.FormatInt(c.Int, 10)
+}
+
+// Var es una variable del programa: un parámetro o local de una función, o
+// una global del programa principal, que también ven las funciones.
+type Var struct {
+	Name   string // único dentro de la función (o del módulo, si es global)
+	T      Type
+	Global bool
+}
+
+func (v *Var) String() string {
+	if v.Global {
+		return "@" + v.Name
+	}
+	return v.Name
+}
+
+// Op es la operación de una instrucción.
+type Op int
+
+const (
+	OpCopy       Op = iota // dst = a
+	OpAdd                  // dst = a + b
+	OpSub                  // dst = a - b
+	OpMul                  // dst = a * b
+	OpDiv                  // dst = a / b
+	OpMod                  // dst = a % b
+	OpNeg                  // dst = -a
+	OpNot                  // dst = !a
+	OpEq                   // dst = a == b
+	OpNe                   // dst = a != b
+	OpLt                   // dst = a < b
+	OpLe                   // dst = a <= b
+	OpGt                   // dst = a > b
+	OpGe                   // dst = a >= b
+	OpIntToFloat           // dst = float(a)
+	OpToString             // dst = a como string; los float con Aux decimales
+	OpLoad                 // dst = Var
+	OpStore                // Var = a
+	OpCall                 // dst = Func(args...); sin dst si no retorna valor
+	OpPrint                // imprime a; los float con Aux decimales (-1: los mínimos)
+
+	// terminadores: cierran un bloque
+	OpJump   // salta a Targets[0]
+	OpBranch // si a salta a Targets[0], si no a Targets[1]
+	OpReturn // retorna a, o nada si no tiene argumentos
+)
+
+var opNames = [...]string{
+	OpCopy: "copy", OpAdd: "add", OpSub: "sub", OpMul: "mul", OpDiv: "div", OpMod: "mod",
+	OpNeg: "neg", OpNot: "not", OpEq: "eq", OpNe: "ne", OpLt: "lt", OpLe: "le", OpGt: "gt", OpGe: "ge",
+	OpIntToFloat: "itof", OpToString: "str", OpLoad: "load", OpStore: "store", OpCall: "call", OpPrint: "print",
+	OpJump: "jmp", OpBranch: "br", OpReturn: "ret",
+}
+
+func (op Op) String() string {
+	if int(op) < len(opNames) {
+		return opNames[op]
+	}
+	return fmt.Sprintf("op(%d)", int(op))
+}
+
+// IsTerminator indica si op cierra un bloque.
+func (op Op) IsTerminator() bool {
+	return op == OpJump || op == OpBranch || op == OpReturn
+}
+
+// IsComparison indica si op compara dos valores y produce un bool.
+func (op Op) IsComparison() bool {
+	return op >= OpEq && op <= OpGe
+}
+
+// Instr es una instrucción de tres direcciones. Type es el tipo en que se
+// opera: el de los operandos en las comparaciones y en str, y el del
+// resultado en el resto.
+type Instr struct {
+	Op      Op
+	Type    Type
+	Dst     *Reg // nil si no produce valor
+	Args    []Value
+	Var     *Var     // OpLoad y OpStore
+	Func    string   // OpCall
+	Aux     int      // OpPrint y OpToString: decimales de los float
+	Targets []*Block // OpJump y OpBranch
+	Pos     ast.Pos  // inicio de la sentencia de la que sale
+}
+
+// Block es un bloque básico: instrucciones que se ejecutan en secuencia y
+// terminan en un salto o un retorno.
+type Block struct {
+	Name   string
+	Instrs []*Instr
+}
+
+// Terminator devuelve la última instrucción si cierra el bloque, o nil.
+func (b *Block) Terminator() *Instr {
+	if len(b.Instrs) == 0 {
+		return nil
+	}
+	if last := b.Instrs[len(b.Instrs)-1]; last.Op.IsTerminator() {
+		return last
+	}
+	return nil
+}
+
+// Succs devuelve los bloques a los que puede saltar b.
+func (b *Block) Succs() []*Block {
+	if term := b.Terminator(); term != nil {
+		return term.Targets
+	}
+	return nil
+}
+
+// Function es una función del módulo. Blocks[0] es la entrada.
+type Function struct {
+	Name   string
+	Params []*Var // también están en Locals
+	Result Type
+	Locals []*Var
+	Blocks []*Block
+	Pos    ast.Pos // inicio de la declaración; cero en Main
+
+	regs   int
+	blocks int
+	names  map[string]int
+}
+
+// NewFunction crea una función con su bloque de entrada.
+func NewFunction(name string, result Type) *Function {
+	f := &Function{Name: name, Result: result, names: make(map[string]int)}
+	f.NewBlock("entry")
+	return f
+}
+
+// NewReg crea un registro virtual nuevo de tipo t.
+func (f *Function) NewReg(t Type) *Reg {
+	r := &Reg{ID: f.regs, T: t}
+	f.regs++
+	return r
+}
+
+// NumRegs es la cantidad de registros virtuales creados.
+func (f *Function) NumRegs() int {
+	return f.regs
+}
+
+// NewBlock agrega un bloque vacío. El nombre es hint seguido de un número,
+// salvo el de entrada.
+func (f *Function) NewBlock(hint string) *Block {
+	name := hint
+	if f.blocks > 0 {
+		name = fmt.Sprintf("%s.%d", hint, f.blocks)
+	}
+	f.blocks++
+	b := &Block{Name: name}
+	f.Blocks = append(f.Blocks, b)
+	return b
+}
+
+// NewLocal declara una variable local. Si el nombre ya se usó en la función
+// (una variable que oculta a otra) se le agrega un sufijo.
+func (f *Function) NewLocal(name string, t Type) *Var {
+	unique := name
+	if n := f.names[name]; n > 0 {
+		unique = fmt.Sprintf("%s.%d", name, n)
+	}
+	f.names[name]++
+	v := &Var{Name: unique, T: t}
+	f.Locals = append(f.Locals, v)
+	return v
+}
+
+// NewParam declara un parámetro.
+func (f *Function) NewParam(name string, t Type) *Var {
+	v := f.NewLocal(name, t)
+	f.Params = append(f.Params, v)
+	return v
+}
+
+// Module es un programa completo. Main contiene las sentencias del nivel
+// superior y es donde empieza la ejecución.
+type Module struct {
+	Globals   []*Var
+	Functions []*Function
+	Main      *Function
+}
+
+// MainName es el nombre de la función con el programa principal.
+const MainName = "_start"
+
+// NewModule crea un módulo vacío.
+func NewModule() *Module {
+	return &Module{Main: NewFunction(MainName, Void)}
+}
+
+// NewGlobal declara una variable global.
+func (m *Module) NewGlobal(name string, t Type) *Var {
+	v := &Var{Name: name, T: t, Global: true}
+	m.Globals = append(m.Globals, v)
+	return v
+}
+
+// Function busca una función de usuario por nombre.
+func (m *Module) Function(name string) *Function {
+	for _, f := range m.Functions {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// AllFunctions devuelve el programa principal seguido de las funciones.
+func (m *Module) AllFunctions() []*Function {
+	return append([]*Function{m.Main}, m.Functions...)
+}
@@ -0,0 +1,700 @@
+package ir
+
+import (
+	"fmt"
+
+	"main.go/ast"
+	"main.go/value"
+)
+
+// UnsupportedError indica que el programa usa una construcción que el IR
+// todavía no representa (ver el alcance en la documentación del paquete).
+// Quien llama debe usar el traductor directo.
+type UnsupportedError struct {
+	Construct string
+	Line      int
+	Column    int
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("construcción no soportada por el IR: %s (línea %d, columna %d)", e.Construct, e.Line, e.Column)
+}
+
+func unsupported(node ast.Node, construct string) {
+	pos := ast.Pos{}
+	if node != nil {
+		pos = node.GetSpan().Start
+	}
+	panic(&UnsupportedError{Construct: construct, Line: pos.Line, Column: pos.Column})
+}
+
+// target es adonde saltan break y continue. En un switch continueTo es nil:
+// continue sigue al ciclo que lo contiene.
+type target struct {
+	breakTo    *Block
+	continueTo *Block
+}
+
+type lowerer struct {
+	module  *Module
+	fn      *Function
+	block   *Block
+	vars    map[ast.Node]*Var // declaración (*VarDecl o *Param) -> variable
+	funcs   map[string]*ast.FuncDecl
+	targets []target
+	pos     ast.Pos // sentencia que se está bajando (ver Instr.Pos)
+}
+
+// Lower traduce un programa ya chequeado a IR. Las sentencias del nivel
+// superior forman Module.Main y sus variables son globales, porque las
+// funciones también las ven. Retorna un *UnsupportedError si el programa usa
+// algo que el IR no representa.
+func Lower(program *ast.Program) (module *Module, err error) {
+	l := &lowerer{
+		module: NewModule(),
+		vars:   make(map[ast.Node]*Var),
+		funcs:  make(map[string]*ast.FuncDecl),
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			unsupportedErr, ok := r.(*UnsupportedError)
+			if !ok {
+				panic(r)
+			}
+			module = nil
+			err = unsupportedErr
+		}
+	}()
+
+	for _, stmt := range program.Stmts {
+		switch decl := stmt.(type) {
+		case *ast.FuncDecl:
+			if decl.Name.Name == MainName {
+				unsupported(decl, "función llamada "+MainName)
+			}
+			l.funcs[decl.Name.Name] = decl
+		case *ast.StructDecl:
+			unsupported(decl, "struct")
+		}
+	}
+
+	// programa principal
+	l.fn, l.block = l.module.Main, l.module.Main.Blocks[0]
+	for _, stmt := range program.Stmts {
+		switch s := stmt.(type) {
+		case *ast.FuncDecl:
+		case *ast.VarDecl:
+			// las declaraciones del nivel superior son globales
+			l.pos = s.GetSpan().Start
+			l.varDecl(s, l.module.NewGlobal(s.Name.Name, l.typeOf(s, s.Name.Type())))
+		default:
+			l.stmt(s)
+		}
+	}
+	l.pos = ast.Pos{}
+	l.finish()
+
+	// funciones, en el orden en que se declararon
+	for _, stmt := range program.Stmts {
+		if decl, ok := stmt.(*ast.FuncDecl); ok {
+			l.function(decl)
+		}
+	}
+
+	return l.module, nil
+}
+
+// typeOf convierte un tipo del árbol tipado. node es donde se reporta si el
+// tipo no existe en el IR.
+func (l *lowerer) typeOf(node ast.Node, t string) Type {
+	switch t {
+	case value.IVOR_INT:
+		return Int
+	case value.IVOR_FLOAT:
+		return Float
+	case value.IVOR_BOOL:
+		return Bool
+	case value.IVOR_STRING, value.IVOR_CHARACTER:
+		return String
+	case value.IVOR_NIL:
+		return Void
+	}
+	if t == "" {
+		unsupported(node, "expresión de tipo desconocido")
+	}
+	unsupported(node, "tipo "+t)
+	return Void
+}
+
+func (l *lowerer) function(decl *ast.FuncDecl) {
+	result := Void
+	if decl.Result != nil {
+		result = l.typeOf(decl.Result, decl.Result.String())
+	}
+
+	l.fn = NewFunction(decl.Name.Name, result)
+	l.fn.Pos = decl.GetSpan().Start
+	l.pos = l.fn.Pos
+	l.block = l.fn.Blocks[0]
+	l.module.Functions = append(l.module.Functions, l.fn)
+
+	for _, param := range decl.Params {
+		l.vars[param] = l.fn.NewParam(param.Name.Name, l.typeOf(param, param.Type.String()))
+	}
+	l.stmts(decl.Body)
+	l.finish()
+}
+
+// finish cierra el último bloque de la función con un retorno implícito.
+func (l *lowerer) finish() {
+	if l.block.Terminator() != nil {
+		return
+	}
+	if l.fn.Result == Void {
+		l.emit(&Instr{Op: OpReturn})
+		return
+	}
+	l.emit(&Instr{Op: OpReturn, Type: l.fn.Result, Args: []Value{zero(l.fn.Result)}})
+}
+
+// zero es el valor por defecto de una variable de tipo t.
+func zero(t Type) Value {
+	switch t {
+	case Float:
+		return FloatConst(0)
+	case Bool:
+		return BoolConst(false)
+	case String:
+		return StringConst("")
+	}
+	return IntConst(0)
+}
+
+// === EMISIÓN ===
+
+// emit agrega instr al bloque actual. Si el bloque ya terminó (código después
+// de un return o un break), abre uno nuevo al que no salta nadie.
+func (l *lowerer) emit(instr *Instr) *Instr {
+	if l.block.Terminator() != nil {
+		l.block = l.fn.NewBlock("unreachable")
+	}
+	instr.Pos = l.pos
+	l.block.Instrs = append(l.block.Instrs, instr)
+	return instr
+}
+
+// value emite una instrucción que produce un valor de tipo result.
+func (l *lowerer) value(op Op, t, result Type, args ...Value) *Reg {
+	dst := l.fn.NewReg(result)
+	l.emit(&Instr{Op: op, Type: t, Dst: dst, Args: args})
+	return dst
+}
+
+// start hace de b el bloque actual y lo mueve al final de la función, así
+// los bloques quedan en el orden en que se llenan y no en el que se crearon.
+func (l *lowerer) start(b *Block) {
+	blocks := l.fn.Blocks
+	for i, block := range blocks {
+		if block == b {
+			l.fn.Blocks = append(append(blocks[:i:i], blocks[i+1:]...), b)
+			break
+		}
+	}
+	l.block = b
+}
+
+func (l *lowerer) jump(to *Block) {
+	l.emit(&Instr{Op: OpJump, Targets: []*Block{to}})
+}
+
+func (l *lowerer) branch(cond Value, then, otherwise *Block) {
+	l.emit(&Instr{Op: OpBranch, Type: Bool, Args: []Value{cond}, Targets: []*Block{then, otherwise}})
+}
+
+func (l *lowerer) load(v *Var) *Reg {
+	dst := l.fn.NewReg(v.T)
+	l.emit(&Instr{Op: OpLoad, Type: v.T, Dst: dst, Var: v})
+	return dst
+}
+
+func (l *lowerer) store(v *Var, val Value) {
+	l.emit(&Instr{Op: OpStore, Type: v.T, Var: v, Args: []Value{val}})
+}
+
+// print imprime val; decimals solo se usa si es float.
+func (l *lowerer) print(val Value, decimals int) {
+	instr := &Instr{Op: OpPrint, Type: val.Type(), Args: []Value{val}}
+	if val.Type() == Float {
+		instr.Aux = decimals
+	}
+	l.emit(instr)
+}
+
+// convert adapta val al tipo de destino t: el único cambio implícito entre
+// tipos del IR es de int a float.
+func (l *lowerer) convert(val Value, t Type) Value {
+	if t != Float || val.Type() != Int {
+		return val
+	}
+	if c, ok := val.(Const); ok {
+		return FloatConst(float64(c.Int))
+	}
+	return l.value(OpIntToFloat, Float, Float, val)
+}
+
+// === SENTENCIAS ===
+
+func (l *lowerer) stmts(stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		l.stmt(stmt)
+	}
+}
+
+func (l *lowerer) stmt(stmt ast.Stmt) {
+	// lo que se emita sale de esta sentencia hasta que termine
+	defer func(pos ast.Pos) { l.pos = pos }(l.pos)
+	l.pos = stmt.GetSpan().Start
+
+	switch s := stmt.(type) {
+	case *ast.VarDecl:
+		l.varDecl(s, l.fn.NewLocal(s.Name.Name, l.typeOf(s, s.Name.Type())))
+
+	case *ast.AssignStmt:
+		l.assign(s)
+
+	case *ast.BlockStmt:
+		l.stmts(s.Stmts)
+
+	case *ast.ExprStmt:
+		l.exprStmt(s.X)
+
+	case *ast.ReturnStmt:
+		if s.Value == nil || l.fn.Result == Void {
+			if s.Value != nil {
+				l.expr(s.Value)
+			}
+			l.emit(&Instr{Op: OpReturn})
+			return
+		}
+		val := l.convert(l.expr(s.Value), l.fn.Result)
+		l.emit(&Instr{Op: OpReturn, Type: l.fn.Result, Args: []Value{val}})
+
+	case *ast.BreakStmt:
+		if len(l.targets) == 0 {
+			unsupported(s, "break fuera de un ciclo")
+		}
+		l.jump(l.targets[len(l.targets)-1].breakTo)
+
+	case *ast.ContinueStmt:
+		for i := len(l.targets) - 1; i >= 0; i-- {
+			if l.targets[i].continueTo != nil {
+				l.jump(l.targets[i].continueTo)
+				return
+			}
+		}
+		unsupported(s, "continue fuera de un ciclo")
+
+	case *ast.IfStmt:
+		l.ifStmt(s)
+
+	case *ast.SwitchStmt:
+		l.switchStmt(s)
+
+	case *ast.WhileStmt:
+		l.loop(s.Cond, s.Body, nil)
+
+	case *ast.ForCondStmt:
+		l.loop(s.Cond, s.Body, nil)
+
+	case *ast.ForClauseStmt:
+		if s.Init != nil {
+			l.assign(s.Init)
+		}
+		l.loop(s.Cond, s.Body, s.Post)
+
+	case *ast.ForRange:
+		unsupported(s, "for in")
+
+	case *ast.FuncDecl:
+		unsupported(s, "función anidada")
+
+	case *ast.StructDecl:
+		unsupported(s, "struct")
+
+	default:
+		unsupported(stmt, fmt.Sprintf("sentencia %T", stmt))
+	}
+}
+
+func (l *lowerer) varDecl(decl *ast.VarDecl, v *Var) {
+	l.vars[decl] = v
+	if decl.Value == nil {
+		l.store(v, zero(v.T))
+		return
+	}
+	l.store(v, l.convert(l.expr(decl.Value), v.T))
+}
+
+// variable devuelve la variable a la que se refiere id.
+func (l *lowerer) variable(id *ast.Ident) *Var {
+	v, ok := l.vars[id.Decl]
+	if !ok {
+		unsupported(id, "variable "+id.Name)
+	}
+	return v
+}
+
+func (l *lowerer) assign(s *ast.AssignStmt) {
+	id, ok := s.Target.(*ast.Ident)
+	if !ok {
+		unsupported(s.Target, "asignación a un elemento o atributo")
+	}
+	v := l.variable(id)
+
+	val := l.expr(s.Value)
+	switch s.Op {
+	case "+=", "-=":
+		if v.T != Int && v.T != Float && (v.T != String || s.Op != "+=") {
+			unsupported(s, s.Op+" sobre "+v.T.String())
+		}
+		op := OpAdd
+		if s.Op == "-=" {
+			op = OpSub
+		}
+		val = l.value(op, v.T, v.T, l.load(v), l.convert(val, v.T))
+	case "=":
+		val = l.convert(val, v.T)
+	default:
+		unsupported(s, "operador de asignación "+s.Op)
+	}
+	l.store(v, val)
+}
+
+func (l *lowerer) exprStmt(x ast.Expr) {
+	call, ok := x.(*ast.CallExpr)
+	if !ok {
+		l.expr(x)
+		return
+	}
+	if id, ok := call.Fun.(*ast.Ident); ok && (id.Name == "print" || id.Name == "println") && id.Decl == nil {
+		l.printCall(call, id.Name == "println")
+		return
+	}
+	l.call(call)
+}
+
+func (l *lowerer) ifStmt(s *ast.IfStmt) {
+	end := l.fn.NewBlock("if.end")
+	for _, branch := range s.Branches {
+		then, next := l.fn.NewBlock("if.then"), l.fn.NewBlock("if.else")
+		l.branch(l.expr(branch.Cond), then, next)
+
+		l.start(then)
+		l.stmts(branch.Body)
+		l.jump(end)
+		l.start(next)
+	}
+	if s.Else != nil {
+		l.stmts(s.Else.Stmts)
+	}
+	l.jump(end)
+	l.start(end)
+}
+
+// switchStmt compara el valor con cada case en orden y ejecuta solo el
+// primero que coincide, como el intérprete. Los strings se comparan por
+// contenido. Un case de otro tipo nunca coincide, pero su valor igual se
+// evalúa.
+func (l *lowerer) switchStmt(s *ast.SwitchStmt) {
+	tag := l.expr(s.Tag)
+
+	end := l.fn.NewBlock("switch.end")
+	l.targets = append(l.targets, target{breakTo: end})
+
+	for _, clause := range s.Cases {
+		val := l.expr(clause.Value)
+		if val.Type() != tag.Type() {
+			continue
+		}
+		body, next := l.fn.NewBlock("switch.case"), l.fn.NewBlock("switch.next")
+		l.branch(l.value(OpEq, tag.Type(), Bool, tag, val), body, next)
+
+		l.start(body)
+		l.stmts(clause.Body)
+		l.jump(end)
+		l.start(next)
+	}
+	if s.Default != nil {
+		l.stmts(s.Default.Body)
+	}
+	l.jump(end)
+
+	l.targets = l.targets[:len(l.targets)-1]
+	l.start(end)
+}
+
+// loop arma un ciclo que evalúa cond antes de cada vuelta. post, si existe,
+// se evalúa al final de cada vuelta y es adonde salta continue.
+func (l *lowerer) loop(cond ast.Expr, body []ast.Stmt, post ast.Expr) {
+	condBlock := l.fn.NewBlock("loop.cond")
+	bodyBlock := l.fn.NewBlock("loop.body")
+	continueTo := condBlock
+	var postBlock *Block
+	if post != nil {
+		postBlock = l.fn.NewBlock("loop.post")
+		continueTo = postBlock
+	}
+	end := l.fn.NewBlock("loop.end")
+
+	l.jump(condBlock)
+	l.start(condBlock)
+	l.branch(l.expr(cond), bodyBlock, end)
+
+	l.targets = append(l.targets, target{breakTo: end, continueTo: continueTo})
+	l.start(bodyBlock)
+	l.stmts(body)
+	l.jump(continueTo)
+	l.targets = l.targets[:len(l.targets)-1]
+
+	if postBlock != nil {
+		l.start(postBlock)
+		l.expr(post)
+		l.jump(condBlock)
+	}
+	l.start(end)
+}
+
+// === EXPRESIONES ===
+
+func (l *lowerer) expr(expr ast.Expr) Value {
+	switch e := expr.(type) {
+	case *ast.IntLit:
+		return IntConst(int64(e.Value))
+
+	case *ast.FloatLit:
+		return FloatConst(e.Value)
+
+	case *ast.BoolLit:
+		return BoolConst(e.Value)
+
+	case *ast.StringLit:
+		if e.Interpolated() {
+			return l.concat(l.interpolation(e))
+		}
+		return StringConst(e.Value)
+
+	case *ast.Ident:
+		return l.load(l.variable(e))
+
+	case *ast.ParenExpr:
+		return l.expr(e.X)
+
+	case *ast.UnaryExpr:
+		t := l.typeOf(e, e.Type())
+		x := l.expr(e.X)
+		switch {
+		case e.Op == "-" && (t == Int || t == Float):
+			return l.value(OpNeg, t, t, x)
+		case e.Op == "!" && t == Bool:
+			return l.value(OpNot, Bool, Bool, x)
+		}
+		unsupported(e, "operador unario "+e.Op+" sobre "+t.String())
+
+	case *ast.BinaryExpr:
+		return l.binary(e)
+
+	case *ast.IncDecExpr:
+		v := l.variable(e.X)
+		if v.T != Int {
+			unsupported(e, e.Op+" sobre "+v.T.String())
+		}
+		old := l.load(v)
+		op := OpAdd
+		if e.Op == "--" {
+			op = OpSub
+		}
+		l.store(v, l.value(op, Int, Int, old, IntConst(1)))
+		return old
+
+	case *ast.CallExpr:
+		if id, ok := e.Fun.(*ast.Ident); ok && (id.Name == "print" || id.Name == "println") && id.Decl == nil {
+			unsupported(e, "print usado como valor")
+		}
+		dst := l.call(e)
+		if dst == nil {
+			unsupported(e, "llamada sin valor usada como valor")
+		}
+		return dst
+
+	case *ast.NilLit:
+		unsupported(e, "nil")
+	}
+
+	unsupported(expr, fmt.Sprintf("expresión %T", expr))
+	return nil
+}
+
+var binaryOps = map[string]Op{
+	"+": OpAdd, "-": OpSub, "*": OpMul, "/": OpDiv, "%": OpMod,
+	"==": OpEq, "!=": OpNe, "<": OpLt, "<=": OpLe, ">": OpGt, ">=": OpGe,
+}
+
+func (l *lowerer) binary(e *ast.BinaryExpr) Value {
+	if e.Op == "&&" || e.Op == "||" {
+		return l.logical(e)
+	}
+
+	op, ok := binaryOps[e.Op]
+	if !ok {
+		unsupported(e, "operador "+e.Op)
+	}
+
+	left, right := l.expr(e.Left), l.expr(e.Right)
+
+	// tipo en que se opera: si uno de los dos es float, el otro se convierte
+	t := left.Type()
+	if left.Type() == Float || right.Type() == Float {
+		t = Float
+	}
+	switch {
+	case t == String && op != OpAdd && !op.IsComparison():
+		unsupported(e, "operador "+e.Op+" sobre strings")
+	case (left.Type() == String) != (right.Type() == String):
+		unsupported(e, "operador "+e.Op+" entre "+left.Type().String()+" y "+right.Type().String())
+	case t == Float && op == OpMod:
+		unsupported(e, "% sobre float")
+	}
+
+	result := l.typeOf(e, e.Type())
+	switch {
+	case op.IsComparison() && result != Bool, !op.IsComparison() && result != t:
+		unsupported(e, "operador "+e.Op+" entre "+left.Type().String()+" y "+right.Type().String())
+	}
+
+	return l.value(op, t, result, l.convert(left, t), l.convert(right, t))
+}
+
+// logical evalúa && y || en cortocircuito: el operando derecho solo se
+// evalúa si hace falta, igual que en el intérprete.
+func (l *lowerer) logical(e *ast.BinaryExpr) Value {
+	left := l.expr(e.Left)
+	dst := l.fn.NewReg(Bool)
+	l.emit(&Instr{Op: OpCopy, Type: Bool, Dst: dst, Args: []Value{left}})
+
+	right, end := l.fn.NewBlock("logic.rhs"), l.fn.NewBlock("logic.end")
+	if e.Op == "&&" {
+		l.branch(left, right, end)
+	} else {
+		l.branch(left, end, right)
+	}
+
+	l.start(right)
+	l.emit(&Instr{Op: OpCopy, Type: Bool, Dst: dst, Args: []Value{l.expr(e.Right)}})
+	l.jump(end)
+
+	l.start(end)
+	return dst
+}
+
+// call traduce una llamada a una función de usuario y devuelve el registro
+// con el resultado, o nil si la función no retorna valor. Los argumentos se
+// pasan por posición: la etiqueta de un argumento no cambia su orden.
+func (l *lowerer) call(e *ast.CallExpr) *Reg {
+	id, ok := e.Fun.(*ast.Ident)
+	if !ok {
+		unsupported(e, "método")
+	}
+	decl, ok := id.Decl.(*ast.FuncDecl)
+	if !ok || l.funcs[id.Name] != decl {
+		unsupported(e, "función nativa "+id.Name)
+	}
+	if len(e.Args) != len(decl.Params) {
+		unsupported(e, "cantidad de argumentos")
+	}
+
+	args := make([]Value, len(e.Args))
+	for i, arg := range e.Args {
+		param := decl.Params[i]
+		args[i] = l.convert(l.expr(arg.Value), l.typeOf(param, param.Type.String()))
+	}
+
+	instr := &Instr{Op: OpCall, Func: id.Name, Args: args}
+	if decl.Result != nil {
+		instr.Type = l.typeOf(decl.Result, decl.Result.String())
+		instr.Dst = l.fn.NewReg(instr.Type)
+	}
+	l.emit(instr)
+	return instr.Dst
+}
+
+// printCall imprime los argumentos separados por un espacio, como print y
+// println del intérprete, que evalúa todos los argumentos antes de imprimir
+// el primero.
+func (l *lowerer) printCall(call *ast.CallExpr, newline bool) {
+	var parts []printPart
+	for i, arg := range call.Args {
+		if i > 0 {
+			parts = append(parts, printPart{StringConst(" "), 0})
+		}
+		if s, ok := arg.Value.(*ast.StringLit); ok && s.Interpolated() {
+			parts = append(parts, l.interpolation(s)...)
+			continue
+		}
+		parts = append(parts, printPart{l.expr(arg.Value), printDecimals})
+	}
+	if newline {
+		parts = append(parts, printPart{StringConst("\n"), 0})
+	}
+	for _, part := range parts {
+		l.print(part.val, part.decimals)
+	}
+}
+
+// printPart es un valor que imprime printCall.
+type printPart struct {
+	val      Value
+	decimals int
+}
+
+// Decimales de los float: print usa 4 y la interpolación los mínimos que
+// identifican al número.
+const (
+	printDecimals         = 4
+	interpolationDecimals = -1
+)
+
+// concat arma un string con el texto de cada parte, para la interpolación
+// usada como valor.
+func (l *lowerer) concat(parts []printPart) Value {
+	var result Value = StringConst("")
+	for i, part := range parts {
+		text := part.val
+		if text.Type() != String {
+			dst := l.fn.NewReg(String)
+			l.emit(&Instr{Op: OpToString, Type: text.Type(), Dst: dst, Args: []Value{text}, Aux: part.decimals})
+			text = dst
+		}
+		if i == 0 {
+			result = text
+			continue
+		}
+		result = l.value(OpAdd, String, String, result, text)
+	}
+	return result
+}
+
+// interpolation separa un string con $variables en las partes a imprimir.
+func (l *lowerer) interpolation(s *ast.StringLit) []printPart {
+	var parts []printPart
+	for _, part := range s.Parts {
+		if part.Var == nil {
+			if part.Text != "" {
+				parts = append(parts, printPart{StringConst(part.Text), 0})
+			}
+			continue
+		}
+		parts = append(parts, printPart{l.load(l.variable(part.Var)), interpolationDecimals})
+	}
+	return parts
+}
@@ -0,0 +1,179 @@
+package ir_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/ast"
+	"main.go/compiler/ir"
+	interpeter "main.go/grammar"
+)
+
+func lower(code string) (*ir.Module, error) {
+	lexer := interpeter.NewVLangLexer(antlr.NewInputStream(code))
+	parser := interpeter.NewVLangGrammar(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	return ir.Lower(ast.Lower(parser.Program()))
+}
+
+func TestLower(t *testing.T) {
+	code := `mut total = 0
+fn doble(n int) int {
+    return n * 2
+}
+mut i = 0
+for i < 3 {
+    total += doble(i)
+    i += 1
+}
+if total > 4 && total != 7 {
+    println("total:", total)
+} else {
+    print(1.5)
+}
+`
+	want := `global @total int
+global @i int
+
+func _start() {
+entry:
+    store int @total, 0
+    store int @i, 0
+    jmp loop.cond.1
+loop.cond.1:
+    %0 = load int @i
+    %1 = lt int %0, 3
+    br %1, loop.body.2, loop.end.3
+loop.body.2:
+    %2 = load int @i
+    %3 = call int doble(%2)
+    %4 = load int @total
+    %5 = add int %4, %3
+    store int @total, %5
+    %6 = load int @i
+    %7 = add int %6, 1
+    store int @i, %7
+    jmp loop.cond.1
+loop.end.3:
+    %8 = load int @total
+    %9 = gt int %8, 4
+    %10 = copy bool %9
+    br %9, logic.rhs.7, logic.end.8
+logic.rhs.7:
+    %11 = load int @total
+    %12 = ne int %11, 7
+    %10 = copy bool %12
+    jmp logic.end.8
+logic.end.8:
+    br %10, if.then.5, if.else.6
+if.then.5:
+    %13 = load int @total
+    print string "total:"
+    print string " "
+    print int %13
+    print string "\n"
+    jmp if.end.4
+if.else.6:
+    print float 1.5, 4
+    jmp if.end.4
+if.end.4:
+    ret
+}
+
+func doble(n int) int {
+entry:
+    %0 = load int n
+    %1 = mul int %0, 2
+    ret %1
+}
+`
+	module, err := lower(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := module.String(); got != want {
+		t.Errorf("IR distinto\n--- esperado\n%s\n--- obtenido\n%s", want, got)
+	}
+}
+
+func TestLowerConversions(t *testing.T) {
+	code := `fn mitad(x float) float {
+    return x / 2
+}
+mut f = mitad(3)
+mut g float = 1
+`
+	module, err := lower(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := module.String()
+	for _, want := range []string{
+		"call float mitad(3.0)", // el argumento entero se convierte al compilar
+		"div float %0, 2.0",     // también el operando de la división
+		"store float @g, 1.0",   // y el valor inicial
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("falta %q en\n%s", want, got)
+		}
+	}
+}
+
+func TestLowerStrings(t *testing.T) {
+	code := `mut s = "hola"
+s += " mundo"
+mut f = 2.5
+mut r = "$s: ${f}"
+mut igual = s == "hola mundo"
+switch s {
+case "hola":
+    println(1)
+}
+`
+	module, err := lower(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := module.String()
+	for _, want := range []string{
+		"add string %0, \" mundo\"",    // += concatena
+		"str float %3, -1",             // la interpolación convierte el float
+		"eq string %7, \"hola mundo\"", // se compara el contenido
+		"eq string %9, \"hola\"",       // también en el switch
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("falta %q en\n%s", want, got)
+		}
+	}
+}
+
+func TestLowerUnsupported(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      string
+		construct string
+	}{
+		{"vector", "mut v []int = {1, 2}\n", "tipo []int"},
+		{"struct", "struct Punto {\n    int x\n}\n", "struct"},
+		{"resta de strings", "println(\"ab\" - \"cd\")\n", "operador - sobre strings"},
+		{"nativa", "mut n = atoi(\"4\")\n", "función nativa atoi"},
+		{"for in", "for i, c in \"ab\" {\n    println(c)\n}\n", "for in"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module, err := lower(tt.code)
+			var unsupported *ir.UnsupportedError
+			if !errors.As(err, &unsupported) {
+				t.Fatalf("se esperaba un UnsupportedError, se obtuvo %v\n%s", err, module)
+			}
+			if unsupported.Construct != tt.construct {
+				t.Errorf("construcción %q, se esperaba %q", unsupported.Construct, tt.construct)
+			}
+			if unsupported.Line == 0 {
+				t.Errorf("el error no tiene posición: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,500 @@
+package ir
+
+// Niveles de optimización de Optimize.
+const (
+	O0 = 0 // el IR queda como lo arma Lower
+	O1 = 1 // plegado de constantes, código muerto y funciones sin usar
+)
+
+// Optimize aplica los pases del nivel dado y repite hasta que ninguno cambia
+// nada: plegar una constante puede decidir un branch, y eliminar el camino
+// muerto puede dejar otra constante a la vista.
+func Optimize(m *Module, level int) {
+	if level < O1 {
+		return
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, f := range m.AllFunctions() {
+			changed = FoldConstants(f) || changed
+			changed = RemoveDeadBranches(f) || changed
+			changed = RemoveDeadCode(f) || changed
+		}
+		changed = RemoveDeadGlobals(m) || changed
+		changed = RemoveUnusedFunctions(m) || changed
+	}
+	for _, f := range m.AllFunctions() {
+		f.Compact()
+	}
+}
+
+// === PLEGADO Y PROPAGACIÓN DE CONSTANTES ===
+
+// FoldConstants calcula en tiempo de compilación las operaciones con
+// operandos constantes y propaga los valores conocidos: el de un copy, y el
+// de una variable que se lee en el mismo bloque en que se escribió. Solo se
+// propagan registros que se definen una vez, porque los demás (el resultado
+// de && y ||) dependen del camino.
+func FoldConstants(f *Function) bool {
+	defs := definitions(f)
+	single := func(v Value) bool {
+		r, ok := v.(*Reg)
+		return !ok || defs[r.ID] == 1
+	}
+
+	changed := false
+	replace := make(map[int]Value)
+	for _, b := range f.Blocks {
+		known := make(map[*Var]Value) // valor de cada variable en este punto del bloque
+		instrs := b.Instrs[:0]
+		for _, instr := range b.Instrs {
+			for i, arg := range instr.Args {
+				if r, ok := arg.(*Reg); ok && replace[r.ID] != nil {
+					instr.Args[i] = replace[r.ID]
+					changed = true
+				}
+			}
+
+			if instr.Dst != nil && defs[instr.Dst.ID] == 1 {
+				if val := fold(instr, known); val != nil && single(val) {
+					replace[instr.Dst.ID] = val
+					changed = true
+					continue
+				}
+			}
+
+			switch instr.Op {
+			case OpLoad:
+				if defs[instr.Dst.ID] == 1 {
+					known[instr.Var] = instr.Dst
+				}
+			case OpStore:
+				delete(known, instr.Var)
+				if single(instr.Args[0]) {
+					known[instr.Var] = instr.Args[0]
+				}
+			case OpCall:
+				// la función llamada puede escribir cualquier global
+				for v := range known {
+					if v.Global {
+						delete(known, v)
+					}
+				}
+			}
+			instrs = append(instrs, instr)
+		}
+		b.Instrs = instrs
+	}
+	return changed
+}
+
+// definitions cuenta cuántas instrucciones definen cada registro.
+func definitions(f *Function) map[int]int {
+	defs := make(map[int]int)
+	for _, b := range f.Blocks {
+		for _, instr := range b.Instrs {
+			if instr.Dst != nil {
+				defs[instr.Dst.ID]++
+			}
+		}
+	}
+	return defs
+}
+
+// fold devuelve el valor que produce instr si se conoce al compilar, o nil.
+func fold(instr *Instr, known map[*Var]Value) Value {
+	switch instr.Op {
+	case OpCopy:
+		return instr.Args[0]
+	case OpLoad:
+		return known[instr.Var]
+	case OpCall, OpToString:
+		return nil
+	}
+
+	args := make([]Const, len(instr.Args))
+	for i, arg := range instr.Args {
+		c, ok := arg.(Const)
+		if !ok {
+			return nil
+		}
+		args[i] = c
+	}
+
+	switch {
+	case instr.Op == OpNeg && instr.Type == Float:
+		return FloatConst(-args[0].Float)
+	case instr.Op == OpNeg:
+		return IntConst(-args[0].Int)
+	case instr.Op == OpNot:
+		return BoolConst(args[0].Int == 0)
+	case instr.Op == OpIntToFloat:
+		return FloatConst(float64(args[0].Int))
+	case instr.Op.IsComparison():
+		return compare(instr.Op, instr.Type, args[0], args[1])
+	case instr.Op == OpAdd && instr.Type == String:
+		return StringConst(args[0].Str + args[1].Str)
+	case instr.Type == Float:
+		return arithmeticFloat(instr.Op, args[0].Float, args[1].Float)
+	case instr.Type == Int:
+		return arithmeticInt(instr.Op, args[0].Int, args[1].Int)
+	}
+	return nil
+}
+
+// arithmeticInt opera como el programa compilado: con desborde en 64 bits.
+// Una división entre cero no se pliega, para que falle igual que sin
+// optimizar.
+func arithmeticInt(op Op, a, b int64) Value {
+	switch op {
+	case OpAdd:
+		return IntConst(a + b)
+	case OpSub:
+		return IntConst(a - b)
+	case OpMul:
+		return IntConst(a * b)
+	case OpDiv:
+		if b != 0 {
+			return IntConst(a / b)
+		}
+	case OpMod:
+		if b != 0 {
+			return IntConst(a % b)
+		}
+	}
+	return nil
+}
+
+func arithmeticFloat(op Op, a, b float64) Value {
+	switch op {
+	case OpAdd:
+		return FloatConst(a + b)
+	case OpSub:
+		return FloatConst(a - b)
+	case OpMul:
+		return FloatConst(a * b)
+	case OpDiv:
+		if b != 0 {
+			return FloatConst(a / b)
+		}
+	}
+	return nil
+}
+
+func compare(op Op, t Type, a, b Const) Value {
+	if t == Float {
+		x, y := a.Float, b.Float
+		return BoolConst(op == OpEq && x == y || op == OpNe && x != y || op == OpLt && x < y ||
+			op == OpLe && x <= y || op == OpGt && x > y || op == OpGe && x >= y)
+	}
+	if t == String {
+		return nil
+	}
+	x, y := a.Int, b.Int
+	return BoolConst(op == OpEq && x == y || op == OpNe && x != y || op == OpLt && x < y ||
+		op == OpLe && x <= y || op == OpGt && x > y || op == OpGe && x >= y)
+}
+
+// === BRANCHES MUERTOS ===
+
+// RemoveDeadBranches convierte en saltos los branch con condición conocida,
+// quita los bloques a los que ya no se llega y une cada bloque con su único
+// sucesor cuando nadie más salta a él.
+func RemoveDeadBranches(f *Function) bool {
+	changed := false
+	for _, b := range f.Blocks {
+		term := b.Terminator()
+		if term == nil || term.Op != OpBranch {
+			continue
+		}
+		target := term.Targets[0]
+		if cond, ok := term.Args[0].(Const); ok {
+			if cond.Int == 0 {
+				target = term.Targets[1]
+			}
+		} else if term.Targets[0] != term.Targets[1] {
+			continue
+		}
+		*term = Instr{Op: OpJump, Targets: []*Block{target}, Pos: term.Pos}
+		changed = true
+	}
+
+	// bloques a los que se llega desde la entrada
+	reachable := map[*Block]bool{f.Blocks[0]: true}
+	work := []*Block{f.Blocks[0]}
+	for len(work) > 0 {
+		b := work[len(work)-1]
+		work = work[:len(work)-1]
+		for _, succ := range b.Succs() {
+			if !reachable[succ] {
+				reachable[succ] = true
+				work = append(work, succ)
+			}
+		}
+	}
+	blocks := f.Blocks[:0]
+	for _, b := range f.Blocks {
+		if reachable[b] {
+			blocks = append(blocks, b)
+		} else {
+			changed = true
+		}
+	}
+	f.Blocks = blocks
+
+	return mergeBlocks(f) || changed
+}
+
+// mergeBlocks une b con el bloque al que salta si b es su único predecesor.
+func mergeBlocks(f *Function) bool {
+	preds := make(map[*Block]int)
+	for _, b := range f.Blocks {
+		for _, succ := range b.Succs() {
+			preds[succ]++
+		}
+	}
+
+	changed := false
+	removed := make(map[*Block]bool)
+	for _, b := range f.Blocks {
+		if removed[b] {
+			continue
+		}
+		for {
+			term := b.Terminator()
+			if term == nil || term.Op != OpJump {
+				break
+			}
+			next := term.Targets[0]
+			if next == b || next == f.Blocks[0] || preds[next] != 1 {
+				break
+			}
+			b.Instrs = append(b.Instrs[:len(b.Instrs)-1], next.Instrs...)
+			removed[next] = true
+			changed = true
+		}
+	}
+
+	blocks := f.Blocks[:0]
+	for _, b := range f.Blocks {
+		if !removed[b] {
+			blocks = append(blocks, b)
+		}
+	}
+	f.Blocks = blocks
+	return changed
+}
+
+// === CÓDIGO MUERTO ===
+
+// RemoveDeadCode quita las instrucciones cuyo resultado nadie usa, los store
+// a variables locales que nunca se leen y los store que otro pisa en el mismo
+// bloque antes de que se lean. Las variables que quedan sin uso dejan de
+// ocupar lugar en el marco.
+func RemoveDeadCode(f *Function) bool {
+	// quitar una instrucción puede dejar sin uso a las que la alimentan
+	changed := false
+	for removeDeadInstrs(f) {
+		changed = true
+	}
+
+	// variables sin uso: los parámetros se quedan porque los escribe el
+	// prólogo
+	params := make(map[*Var]bool)
+	for _, param := range f.Params {
+		params[param] = true
+	}
+	stored := make(map[*Var]bool)
+	for _, b := range f.Blocks {
+		for _, instr := range b.Instrs {
+			if instr.Var != nil {
+				stored[instr.Var] = true
+			}
+		}
+	}
+	locals := f.Locals[:0]
+	for _, local := range f.Locals {
+		if params[local] || stored[local] {
+			locals = append(locals, local)
+		}
+	}
+	f.Locals = locals
+
+	return changed
+}
+
+// removeDeadInstrs hace una pasada de RemoveDeadCode sobre las
+// instrucciones.
+func removeDeadInstrs(f *Function) bool {
+	uses := make(map[int]int)
+	loaded := make(map[*Var]bool)
+	for _, b := range f.Blocks {
+		for _, instr := range b.Instrs {
+			for _, arg := range instr.Args {
+				if r, ok := arg.(*Reg); ok {
+					uses[r.ID]++
+				}
+			}
+			if instr.Op == OpLoad {
+				loaded[instr.Var] = true
+			}
+		}
+	}
+
+	changed := false
+	for _, b := range f.Blocks {
+		overwritten := overwrittenStores(b)
+		instrs := b.Instrs[:0]
+		for _, instr := range b.Instrs {
+			dead := overwritten[instr] ||
+				instr.Op == OpStore && !instr.Var.Global && !loaded[instr.Var] ||
+				instr.Dst != nil && instr.Op != OpCall && uses[instr.Dst.ID] == 0
+			if dead {
+				changed = true
+				continue
+			}
+			instrs = append(instrs, instr)
+		}
+		b.Instrs = instrs
+	}
+
+	return changed
+}
+
+// overwrittenStores devuelve los store de b que otro store a la misma
+// variable pisa antes de que alguien la lea. Una llamada puede leer las
+// globales, así que corta la búsqueda para ellas.
+func overwrittenStores(b *Block) map[*Instr]bool {
+	dead := make(map[*Instr]bool)
+	pending := make(map[*Var]*Instr) // último store todavía no leído
+	for _, instr := range b.Instrs {
+		switch instr.Op {
+		case OpStore:
+			if prev := pending[instr.Var]; prev != nil {
+				dead[prev] = true
+			}
+			pending[instr.Var] = instr
+		case OpLoad:
+			delete(pending, instr.Var)
+		case OpCall:
+			for v := range pending {
+				if v.Global {
+					delete(pending, v)
+				}
+			}
+		}
+	}
+	return dead
+}
+
+// RemoveDeadGlobals quita las globales que ninguna función lee, junto con
+// sus store.
+func RemoveDeadGlobals(m *Module) bool {
+	loaded := make(map[*Var]bool)
+	for _, f := range m.AllFunctions() {
+		for _, b := range f.Blocks {
+			for _, instr := range b.Instrs {
+				if instr.Op == OpLoad {
+					loaded[instr.Var] = true
+				}
+			}
+		}
+	}
+
+	changed := false
+	globals := m.Globals[:0]
+	for _, global := range m.Globals {
+		if loaded[global] {
+			globals = append(globals, global)
+		} else {
+			changed = true
+		}
+	}
+	m.Globals = globals
+
+	for _, f := range m.AllFunctions() {
+		for _, b := range f.Blocks {
+			instrs := b.Instrs[:0]
+			for _, instr := range b.Instrs {
+				if instr.Op == OpStore && instr.Var.Global && !loaded[instr.Var] {
+					continue
+				}
+				instrs = append(instrs, instr)
+			}
+			b.Instrs = instrs
+		}
+	}
+	return changed
+}
+
+// === FUNCIONES SIN USAR ===
+
+// RemoveUnusedFunctions quita las funciones a las que no se llega desde el
+// programa principal.
+func RemoveUnusedFunctions(m *Module) bool {
+	used := map[string]bool{m.Main.Name: true}
+	work := []*Function{m.Main}
+	for len(work) > 0 {
+		f := work[len(work)-1]
+		work = work[:len(work)-1]
+		for _, b := range f.Blocks {
+			for _, instr := range b.Instrs {
+				if instr.Op == OpCall && !used[instr.Func] {
+					used[instr.Func] = true
+					if callee := m.Function(instr.Func); callee != nil {
+						work = append(work, callee)
+					}
+				}
+			}
+		}
+	}
+
+	functions := m.Functions[:0]
+	for _, f := range m.Functions {
+		if used[f.Name] {
+			functions = append(functions, f)
+		}
+	}
+	changed := len(functions) != len(m.Functions)
+	m.Functions = functions
+	return changed
+}
+
+// === COMPACTACIÓN ===
+
+// Compact renumera los registros virtuales en el orden en que se definen,
+// para que los que quitaron los pases no ocupen lugar en el marco.
+func (f *Function) Compact() {
+	ids := make(map[int]int)
+	for _, b := range f.Blocks {
+		for _, instr := range b.Instrs {
+			if instr.Dst != nil {
+				if _, ok := ids[instr.Dst.ID]; !ok {
+					ids[instr.Dst.ID] = len(ids)
+				}
+			}
+		}
+	}
+
+	renumbered := make(map[*Reg]bool)
+	renumber := func(r *Reg) {
+		if !renumbered[r] {
+			renumbered[r] = true
+			r.ID = ids[r.ID]
+		}
+	}
+	for _, b := range f.Blocks {
+		for _, instr := range b.Instrs {
+			if instr.Dst != nil {
+				renumber(instr.Dst)
+			}
+			for _, arg := range instr.Args {
+				if r, ok := arg.(*Reg); ok {
+					renumber(r)
+				}
+			}
+		}
+	}
+	f.regs = len(ids)
+}
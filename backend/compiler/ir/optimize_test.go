@@ -0,0 +1,442 @@
+package ir_test
+
+import (
+	"testing"
+
+	"main.go/compiler/ir"
+)
+
+// optimizeTest baja code, comprueba que el IR de partida es before, le
+// aplica pass y compara el resultado con after.
+type optimizeTest struct {
+	name   string
+	code   string
+	pass   func(m *ir.Module)
+	before string
+	after  string
+}
+
+func (tt optimizeTest) run(t *testing.T) {
+	t.Helper()
+	module, err := lower(tt.code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := module.String(); got != tt.before {
+		t.Fatalf("IR de partida distinto\n--- esperado\n%s\n--- obtenido\n%s", tt.before, got)
+	}
+	tt.pass(module)
+	if got := module.String(); got != tt.after {
+		t.Errorf("IR optimizado distinto\n--- esperado\n%s\n--- obtenido\n%s", tt.after, got)
+	}
+}
+
+func TestFoldConstants(t *testing.T) {
+	tests := []optimizeTest{
+		{
+			name: "aritmética y propagación",
+			code: "mut x = 2 * 3 + 4\nmut y = x - 1\nprintln(y, -y, !(y < 3))\n",
+			pass: func(m *ir.Module) { ir.FoldConstants(m.Main) },
+			before: `global @x int
+global @y int
+
+func _start() {
+entry:
+    %0 = mul int 2, 3
+    %1 = add int %0, 4
+    store int @x, %1
+    %2 = load int @x
+    %3 = sub int %2, 1
+    store int @y, %3
+    %4 = load int @y
+    %5 = load int @y
+    %6 = neg int %5
+    %7 = load int @y
+    %8 = lt int %7, 3
+    %9 = not bool %8
+    print int %4
+    print string " "
+    print int %6
+    print string " "
+    print bool %9
+    print string "\n"
+    ret
+}
+`,
+			after: `global @x int
+global @y int
+
+func _start() {
+entry:
+    store int @x, 10
+    store int @y, 9
+    print int 9
+    print string " "
+    print int -9
+    print string " "
+    print bool true
+    print string "\n"
+    ret
+}
+`,
+		},
+		{
+			// las divisiones entre cero tienen que fallar al ejecutarse
+			name: "división entre cero",
+			code: "mut f = 1.5 * 2\nprintln(f / 0.0, 7 / 0)\n",
+			pass: func(m *ir.Module) { ir.FoldConstants(m.Main) },
+			before: `global @f float
+
+func _start() {
+entry:
+    %0 = mul float 1.5, 2.0
+    store float @f, %0
+    %1 = load float @f
+    %2 = div float %1, 0.0
+    %3 = div int 7, 0
+    print float %2, 4
+    print string " "
+    print int %3
+    print string "\n"
+    ret
+}
+`,
+			after: `global @f float
+
+func _start() {
+entry:
+    store float @f, 3.0
+    %2 = div float 3.0, 0.0
+    %3 = div int 7, 0
+    print float %2, 4
+    print string " "
+    print int %3
+    print string "\n"
+    ret
+}
+`,
+		},
+		{
+			// la llamada puede cambiar la global
+			name: "global después de una llamada",
+			code: "mut a = 1\nfn g() {\n    a = 5\n}\ng()\nprintln(a)\n",
+			pass: func(m *ir.Module) { ir.FoldConstants(m.Main) },
+			before: `global @a int
+
+func _start() {
+entry:
+    store int @a, 1
+    call void g()
+    %0 = load int @a
+    print int %0
+    print string "\n"
+    ret
+}
+
+func g() {
+entry:
+    store int @a, 5
+    ret
+}
+`,
+			after: `global @a int
+
+func _start() {
+entry:
+    store int @a, 1
+    call void g()
+    %0 = load int @a
+    print int %0
+    print string "\n"
+    ret
+}
+
+func g() {
+entry:
+    store int @a, 5
+    ret
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, tt.run)
+	}
+}
+
+func TestRemoveDeadBranches(t *testing.T) {
+	tests := []optimizeTest{
+		{
+			name: "condición conocida",
+			code: "if 2 > 3 {\n    println(\"sí\")\n} else {\n    println(\"no\")\n}\n",
+			pass: func(m *ir.Module) {
+				ir.FoldConstants(m.Main)
+				ir.RemoveDeadBranches(m.Main)
+			},
+			before: `func _start() {
+entry:
+    %0 = gt int 2, 3
+    br %0, if.then.2, if.else.3
+if.then.2:
+    print string "sí"
+    print string "\n"
+    jmp if.end.1
+if.else.3:
+    print string "no"
+    print string "\n"
+    jmp if.end.1
+if.end.1:
+    ret
+}
+`,
+			after: `func _start() {
+entry:
+    print string "no"
+    print string "\n"
+    ret
+}
+`,
+		},
+		{
+			name: "código después de un return",
+			code: "fn f() int {\n    return 1\n    println(\"nunca\")\n}\n",
+			pass: func(m *ir.Module) { ir.RemoveDeadBranches(m.Functions[0]) },
+			before: `func _start() {
+entry:
+    ret
+}
+
+func f() int {
+entry:
+    ret 1
+unreachable.1:
+    print string "nunca"
+    print string "\n"
+    ret 0
+}
+`,
+			after: `func _start() {
+entry:
+    ret
+}
+
+func f() int {
+entry:
+    ret 1
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, tt.run)
+	}
+}
+
+func TestRemoveDeadCode(t *testing.T) {
+	optimizeTest{
+		name: "store sin lectura y pisado",
+		code: "fn f(n int) int {\n    mut basura = n * 2\n    mut r = n\n    r = n + 1\n    return r\n}\n",
+		pass: func(m *ir.Module) { ir.RemoveDeadCode(m.Functions[0]) },
+		before: `func _start() {
+entry:
+    ret
+}
+
+func f(n int) int {
+entry:
+    %0 = load int n
+    %1 = mul int %0, 2
+    store int basura, %1
+    %2 = load int n
+    store int r, %2
+    %3 = load int n
+    %4 = add int %3, 1
+    store int r, %4
+    %5 = load int r
+    ret %5
+}
+`,
+		after: `func _start() {
+entry:
+    ret
+}
+
+func f(n int) int {
+entry:
+    %3 = load int n
+    %4 = add int %3, 1
+    store int r, %4
+    %5 = load int r
+    ret %5
+}
+`,
+	}.run(t)
+}
+
+func TestRemoveDeadGlobals(t *testing.T) {
+	optimizeTest{
+		name: "global que nadie lee",
+		code: "mut usada = 1\nmut escrita = 2\nescrita = 3\nprintln(usada)\n",
+		pass: func(m *ir.Module) { ir.RemoveDeadGlobals(m) },
+		before: `global @usada int
+global @escrita int
+
+func _start() {
+entry:
+    store int @usada, 1
+    store int @escrita, 2
+    store int @escrita, 3
+    %0 = load int @usada
+    print int %0
+    print string "\n"
+    ret
+}
+`,
+		after: `global @usada int
+
+func _start() {
+entry:
+    store int @usada, 1
+    %0 = load int @usada
+    print int %0
+    print string "\n"
+    ret
+}
+`,
+	}.run(t)
+}
+
+func TestRemoveUnusedFunctions(t *testing.T) {
+	optimizeTest{
+		name: "función a la que no se llega",
+		code: "fn a() {\n    b()\n}\nfn b() {\n}\nfn c() {\n}\na()\n",
+		pass: func(m *ir.Module) { ir.RemoveUnusedFunctions(m) },
+		before: `func _start() {
+entry:
+    call void a()
+    ret
+}
+
+func a() {
+entry:
+    call void b()
+    ret
+}
+
+func b() {
+entry:
+    ret
+}
+
+func c() {
+entry:
+    ret
+}
+`,
+		after: `func _start() {
+entry:
+    call void a()
+    ret
+}
+
+func a() {
+entry:
+    call void b()
+    ret
+}
+
+func b() {
+entry:
+    ret
+}
+`,
+	}.run(t)
+}
+
+func TestOptimize(t *testing.T) {
+	code := "mut i = 0\nmut suma = 0\nfor i < 3 {\n    suma += i * 2\n    i += 1\n}\nif 1 < 2 {\n    println(suma)\n}\n"
+	before := `global @i int
+global @suma int
+
+func _start() {
+entry:
+    store int @i, 0
+    store int @suma, 0
+    jmp loop.cond.1
+loop.cond.1:
+    %0 = load int @i
+    %1 = lt int %0, 3
+    br %1, loop.body.2, loop.end.3
+loop.body.2:
+    %2 = load int @i
+    %3 = mul int %2, 2
+    %4 = load int @suma
+    %5 = add int %4, %3
+    store int @suma, %5
+    %6 = load int @i
+    %7 = add int %6, 1
+    store int @i, %7
+    jmp loop.cond.1
+loop.end.3:
+    %8 = lt int 1, 2
+    br %8, if.then.5, if.else.6
+if.then.5:
+    %9 = load int @suma
+    print int %9
+    print string "\n"
+    jmp if.end.4
+if.else.6:
+    jmp if.end.4
+if.end.4:
+    ret
+}
+`
+	tests := []optimizeTest{
+		{
+			name:   "O0 no cambia nada",
+			code:   code,
+			pass:   func(m *ir.Module) { ir.Optimize(m, ir.O0) },
+			before: before,
+			after:  before,
+		},
+		{
+			name:   "O1",
+			code:   code,
+			pass:   func(m *ir.Module) { ir.Optimize(m, ir.O1) },
+			before: before,
+			// el if se decide al compilar, la segunda lectura de i usa la
+			// primera y los registros se renumeran
+			after: `global @i int
+global @suma int
+
+func _start() {
+entry:
+    store int @i, 0
+    store int @suma, 0
+    jmp loop.cond.1
+loop.cond.1:
+    %0 = load int @i
+    %1 = lt int %0, 3
+    br %1, loop.body.2, loop.end.3
+loop.body.2:
+    %2 = load int @i
+    %3 = mul int %2, 2
+    %4 = load int @suma
+    %5 = add int %4, %3
+    store int @suma, %5
+    %6 = add int %2, 1
+    store int @i, %6
+    jmp loop.cond.1
+loop.end.3:
+    %7 = load int @suma
+    print int %7
+    print string "\n"
+    ret
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, tt.run)
+	}
+}
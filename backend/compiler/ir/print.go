@@ -0,0 +1,92 @@
+package ir
+
+import (
+	"fmt"
+	"strings"
+)
+
+// String devuelve el módulo en texto, el formato que muestra
+// /api/compile?emit=ir:
+//
+//	global @n int
+//
+//	func doble(x int) int {
+//	entry:
+//	    %0 = load int x
+//	    %1 = mul int %0, 2
+//	    ret %1
+//	}
+func (m *Module) String() string {
+	var sb strings.Builder
+	for _, global := range m.Globals {
+		fmt.Fprintf(&sb, "global %s %s\n", global, global.T)
+	}
+	for _, f := range m.AllFunctions() {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(f.String())
+	}
+	return sb.String()
+}
+
+func (f *Function) String() string {
+	var sb strings.Builder
+	params := make([]string, len(f.Params))
+	for i, param := range f.Params {
+		params[i] = fmt.Sprintf("%s %s", param, param.T)
+	}
+	fmt.Fprintf(&sb, "func %s(%s)", f.Name, strings.Join(params, ", "))
+	if f.Result != Void {
+		fmt.Fprintf(&sb, " %s", f.Result)
+	}
+	sb.WriteString(" {\n")
+	for _, b := range f.Blocks {
+		fmt.Fprintf(&sb, "%s:\n", b.Name)
+		for _, instr := range b.Instrs {
+			fmt.Fprintf(&sb, "    %s\n", instr)
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func (i *Instr) String() string {
+	args := make([]string, len(i.Args))
+	for n, arg := range i.Args {
+		args[n] = arg.String()
+	}
+
+	var text string
+	switch i.Op {
+	case OpLoad:
+		text = fmt.Sprintf("load %s %s", i.Type, i.Var)
+	case OpStore:
+		text = fmt.Sprintf("store %s %s, %s", i.Type, i.Var, args[0])
+	case OpCall:
+		text = fmt.Sprintf("call %s %s(%s)", i.Type, i.Func, strings.Join(args, ", "))
+	case OpPrint:
+		text = fmt.Sprintf("print %s %s", i.Type, args[0])
+		if i.Type == Float {
+			text += fmt.Sprintf(", %d", i.Aux)
+		}
+	case OpToString:
+		text = fmt.Sprintf("str %s %s", i.Type, args[0])
+		if i.Type == Float {
+			text += fmt.Sprintf(", %d", i.Aux)
+		}
+	case OpJump:
+		text = fmt.Sprintf("jmp %s", i.Targets[0].Name)
+	case OpBranch:
+		text = fmt.Sprintf("br %s, %s, %s", args[0], i.Targets[0].Name, i.Targets[1].Name)
+	case OpReturn:
+		text = strings.TrimSpace("ret " + strings.Join(args, ", "))
+	default:
+		text = fmt.Sprintf("%s %s %s", i.Op, i.Type, strings.Join(args, ", "))
+	}
+
+	if i.Dst != nil {
+		return fmt.Sprintf("%s = %s", i.Dst, text)
+	}
+	return text
+}
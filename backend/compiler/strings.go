@@ -0,0 +1,211 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/antlr4-go/antlr/v4"
+	"main.go/ast"
+	"main.go/compiler/arm64"
+	compiler "main.go/grammar"
+)
+
+// === STRINGS ===
+//
+// Un string es la dirección de sus bytes terminados en null. Los literales
+// se registran en la primera pasada; los que se arman al ejecutar (una
+// concatenación, una interpolación, un join) salen del heap con las
+// funciones de compiler/arm64/strings.go.
+
+// translateStringBinaryExpression traduce a + b y las comparaciones entre
+// strings, que comparan el contenido byte a byte.
+func (t *ARM64Translator) translateStringBinaryExpression(ctx *compiler.BinaryExprContext, operator string) {
+	conditions := map[string]string{"==": "eq", "!=": "ne", "<": "lt", ">": "gt", "<=": "le", ">=": "ge"}
+	condition, isComparison := conditions[operator]
+	if operator != "+" && !isComparison {
+		t.addError(fmt.Sprintf("Operador no implementado sobre strings: %s", operator))
+		t.generator.LoadImmediate(arm64.X0, 0)
+		return
+	}
+
+	t.translateExpression(ctx.GetLeft())
+	temp := t.generator.SaveTemp(arm64.X0)
+	t.translateExpression(ctx.GetRight())
+	t.generator.Emit("mov x1, x0")
+	t.restoreTempTo(temp, arm64.X0)
+
+	if operator == "+" {
+		t.callRuntime("str_concat")
+		return
+	}
+	t.callRuntime("str_compare")
+	t.generator.Emit("cmp x0, #0")
+	t.generator.Emit(fmt.Sprintf("cset x0, %s", condition))
+}
+
+// translateStringAddAssign traduce s += x sobre una variable string.
+func (t *ARM64Translator) translateStringAddAssign(varName string, expr antlr.ParseTree) {
+	t.translateExpression(expr)
+	t.generator.Emit("mov x1, x0")
+	t.generator.LoadVariable(arm64.X0, varName)
+	t.callRuntime("str_concat")
+	t.generator.StoreVariable(arm64.X0, varName)
+}
+
+// toString reemplaza el valor de tipo typ que está en x0 por su texto. Los
+// flotantes se escriben con decimals decimales, como en print_float.
+func (t *ARM64Translator) toString(typ string, decimals int) {
+	switch {
+	case isStringType(typ):
+	case typ == "bool":
+		t.callRuntime("str_from_bool")
+	case typ == "float":
+		t.generator.Emit("fmov d0, x0")
+		t.generator.LoadImmediate(arm64.X1, decimals)
+		t.callRuntime("str_from_float")
+	default:
+		t.callRuntime("str_from_int")
+	}
+}
+
+// processStringInterpolation arma en el heap el string "texto $variable
+// ..." y lo deja en x0. Las partes vienen del literal tipado: el texto se
+// registra al emitirlo y las variables se convierten a texto según su tipo.
+func (t *ARM64Translator) processStringInterpolation(literal *ast.StringLit) {
+	t.generator.Comment("=== INTERPOLACIÓN DE STRING ===")
+
+	// Cada parte se convierte a string y se concatena con las anteriores
+	count := 0
+	var temp arm64.Temp
+	for _, part := range literal.Parts {
+		if part.Var == nil && part.Text == "" {
+			continue
+		}
+		if count > 0 {
+			temp = t.generator.SaveTemp(arm64.X0)
+		}
+
+		if part.Var != nil {
+			varName := part.Var.Name
+			t.generator.Comment(fmt.Sprintf("Interpolando variable: %s", varName))
+			if t.generator.VariableExists(varName) {
+				t.generator.LoadVariable(arm64.X0, varName)
+				t.toString(part.Var.Type(), interpolationDecimals)
+			} else {
+				t.addError(fmt.Sprintf("Variable '%s' no encontrada en interpolación", varName))
+				t.generator.LoadImmediate(arm64.X0, 0)
+			}
+		} else {
+			t.generator.Comment(fmt.Sprintf("Interpolando texto: %q", part.Text))
+			label := t.generator.AddStringLiteral(arm64.EscapeString(part.Text))
+			t.generator.Emit(fmt.Sprintf("adr x0, %s", label))
+		}
+
+		if count > 0 {
+			t.generator.Emit("mov x1, x0")
+			t.restoreTempTo(temp, arm64.X0)
+			t.callRuntime("str_concat")
+		}
+		count++
+	}
+
+	if count == 0 {
+		// Un string vacío: un byte del heap, que viene en cero
+		t.generator.LoadImmediate(arm64.X0, 1)
+		t.callRuntime("alloc")
+	}
+}
+
+// translateAtoi traduce atoi(x): un string se lee como número y se
+// trunca, como en el intérprete; un float solo se trunca.
+func (t *ARM64Translator) translateAtoi(ctx *compiler.FuncCallContext) {
+	args := funcArgs(ctx)
+	argType := ""
+	if len(args) == 1 {
+		argType = t.getArgumentType(args[0])
+	}
+
+	switch {
+	case isStringType(argType):
+		t.translateArgument(args[0], argType)
+		t.callRuntime("str_atoi")
+	case argType == "float":
+		t.translateArgument(args[0], argType)
+		t.generator.Emit("fmov d0, x0")
+		t.generator.Emit("fcvtzs x0, d0")
+	default:
+		t.addError("Función no implementada: atoi")
+		t.generator.LoadImmediate(arm64.X0, 0)
+	}
+}
+
+// translateParseFloat traduce parseFloat(s).
+func (t *ARM64Translator) translateParseFloat(ctx *compiler.FuncCallContext) {
+	args := funcArgs(ctx)
+	if len(args) != 1 || !isStringType(t.getArgumentType(args[0])) {
+		t.addError("Función no implementada: parseFloat")
+		t.generator.LoadImmediate(arm64.X0, 0)
+		return
+	}
+	t.translateArgument(args[0], "string")
+	t.callRuntime("str_parse_float")
+}
+
+// translateJoin traduce join(v, separador) sobre un vector de strings.
+func (t *ARM64Translator) translateJoin(ctx *compiler.FuncCallContext) {
+	args := funcArgs(ctx)
+	if len(args) != 2 || vectorLevels(t.getArgumentType(args[0])) != 1 || !isStringType(ast.ElemType(t.getArgumentType(args[0]))) {
+		t.addError("Función no implementada: join")
+		t.generator.LoadImmediate(arm64.X0, 0)
+		return
+	}
+
+	t.translateArgument(args[0], "")
+	temp := t.generator.SaveTemp(arm64.X0)
+	t.translateArgument(args[1], "string")
+	t.generator.Emit("mov x1, x0")
+	t.restoreTempTo(temp, arm64.X0)
+	t.callRuntime("str_join")
+}
+
+// translateIndexOf traduce indexOf(v, x): la primera posición de x en v, o
+// -1 si no está.
+func (t *ARM64Translator) translateIndexOf(ctx *compiler.FuncCallContext) {
+	args := funcArgs(ctx)
+	vectorType := ""
+	if len(args) == 2 {
+		vectorType = t.getArgumentType(args[0])
+	}
+	if vectorLevels(vectorType) == 0 {
+		t.addError("Función no implementada: indexOf")
+		t.generator.LoadImmediate(arm64.X0, 0)
+		return
+	}
+
+	t.translateArgument(args[0], "")
+	temp := t.generator.SaveTemp(arm64.X0)
+	t.translateArgument(args[1], ast.ElemType(vectorType))
+	t.generator.Emit("mov x1, x0")
+	t.restoreTempTo(temp, arm64.X0)
+	t.generator.LoadImmediate(arm64.X2, printKind(vectorType))
+	t.callRuntime("vec_index_of")
+}
+
+// translateTypeOf deja en x0 el nombre del tipo del argumento. El tipo se
+// conoce al compilar, así que es un literal; el argumento se evalúa igual
+// por si tiene efectos.
+func (t *ARM64Translator) translateTypeOf(ctx *compiler.FuncCallContext) {
+	args := funcArgs(ctx)
+	typeName := ""
+	if len(args) == 1 {
+		typeName = t.getArgumentType(args[0])
+	}
+	if typeName == "" {
+		t.addError("Función no implementada: TypeOf")
+		t.generator.LoadImmediate(arm64.X0, 0)
+		return
+	}
+
+	t.translateArgument(args[0], "")
+	label := t.generator.AddStringLiteral(arm64.EscapeString(typeName))
+	t.generator.Emit(fmt.Sprintf("adr x0, %s", label))
+}
@@ -0,0 +1,356 @@
+package compiler
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/antlr4-go/antlr/v4"
+	"main.go/ast"
+	"main.go/compiler/arm64"
+	compiler "main.go/grammar"
+)
+
+// === STRUCTS ===
+//
+// Una instancia de un struct es la dirección de un bloque del heap:
+//
+//	[p + 0]   máscara de los atributos inicializados, un bit por atributo
+//	[p + 8]   atributos, en el orden en que se declararon
+//
+// Los atributos de tipo string, vector o struct guardan una dirección. Uno
+// que no se inicializó vale 0 y no se imprime, como en formatStruct, hasta
+// que se le asigna un valor. Como en el intérprete, las
+// declaraciones y asignaciones comparten la instancia, y una función que
+// recibe un struct recibe su dirección: lo que modifique lo ve quien la
+// llamó.
+
+// structField es un atributo de un struct y su posición en la instancia.
+type structField struct {
+	Name   string
+	Type   string
+	Offset int
+	Bit    int // bit de la máscara de atributos inicializados
+}
+
+// structLayout es la disposición en memoria de un struct.
+type structLayout struct {
+	Name   string
+	Fields []structField
+	Size   int
+}
+
+// maxStructFields es la cantidad de atributos que entra en la máscara.
+const maxStructFields = 63
+
+// newStructLayout ubica los atributos de un struct después de la máscara:
+// cada uno alineado a su tamaño, y el total alineado al mayor de ellos.
+func newStructLayout(ctx *compiler.StructDeclContext) *structLayout {
+	layout := &structLayout{Name: ctx.ID().GetText()}
+	offset, align := 8, 8
+	for _, prop := range ctx.AllStruct_prop() {
+		attr, ok := prop.(*compiler.StructAttrContext)
+		if !ok {
+			continue
+		}
+		fieldType := attr.Type_().GetText()
+		size := VlangTypeToSize(fieldType)
+		offset = alignTo(offset, size)
+		align = max(align, size)
+		layout.Fields = append(layout.Fields, structField{
+			Name:   attr.ID().GetText(),
+			Type:   fieldType,
+			Offset: offset,
+			Bit:    len(layout.Fields),
+		})
+		offset += size
+	}
+	layout.Size = alignTo(offset, align)
+	return layout
+}
+
+// alignTo redondea offset hacia arriba al múltiplo de align.
+func alignTo(offset, align int) int {
+	return (offset + align - 1) / align * align
+}
+
+// field busca un atributo por nombre.
+func (l *structLayout) field(name string) (structField, bool) {
+	for _, field := range l.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return structField{}, false
+}
+
+// selectorOf devuelve el acceso a.b del árbol tipado para un id_pattern o
+// para la llamada a un método, o nil si ctx no es uno.
+func (t *ARM64Translator) selectorOf(ctx antlr.ParserRuleContext) *ast.SelectorExpr {
+	switch node := t.program.NodeOf(ctx).(type) {
+	case *ast.SelectorExpr:
+		return node
+	case *ast.CallExpr:
+		selector, _ := node.Fun.(*ast.SelectorExpr)
+		return selector
+	}
+	return nil
+}
+
+// loadSelector deja en x0 el valor de una cadena de accesos como
+// ana.casa.y o v.count y devuelve su tipo. Devuelve false si algún nombre
+// no es una variable, un atributo o una propiedad de vector.
+func (t *ARM64Translator) loadSelector(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if !t.generator.VariableExists(e.Name) {
+			return "", false
+		}
+		t.generator.LoadVariable(arm64.X0, e.Name)
+		return e.Type(), true
+
+	case *ast.SelectorExpr:
+		containerType, ok := t.loadSelector(e.X)
+		if !ok {
+			return "", false
+		}
+		if vectorLevels(containerType) > 0 {
+			propertyType := t.vectorProperty(e.Sel.Name)
+			return propertyType, propertyType != ""
+		}
+		layout, exists := t.structs[containerType]
+		if !exists {
+			return "", false
+		}
+		field, exists := layout.field(e.Sel.Name)
+		if !exists {
+			return "", false
+		}
+		t.generator.Emit(fmt.Sprintf("ldr x0, [x0, #%d]", field.Offset))
+		return field.Type, true
+	}
+	return "", false
+}
+
+// translateSelector traduce un id_pattern con puntos: el atributo de un
+// struct o una propiedad de un vector.
+func (t *ARM64Translator) translateSelector(idPattern compiler.IId_patternContext) {
+	name := idPattern.GetText()
+	selector := t.selectorOf(idPattern)
+	if selector == nil {
+		t.addError(fmt.Sprintf("Variable '%s' no está declarada", name))
+		t.generator.LoadImmediate(arm64.X0, 0)
+		return
+	}
+	if _, ok := t.loadSelector(selector); !ok {
+		t.addError(fmt.Sprintf("Variable '%s' no está declarada", name))
+		t.generator.LoadImmediate(arm64.X0, 0)
+	}
+}
+
+// translateStructInstantiation traduce Punto{x: 1, y: 2}: reserva la
+// instancia y guarda los atributos que se dan. La instancia queda en x0.
+func (t *ARM64Translator) translateStructInstantiation(ctx *compiler.StructInstantiationExprContext) {
+	name := ctx.ID().GetText()
+	layout, exists := t.structs[name]
+	if !exists {
+		t.addError(fmt.Sprintf("Struct '%s' no está declarado", name))
+		t.generator.LoadImmediate(arm64.X0, 0)
+		return
+	}
+
+	var params []*compiler.Struct_paramContext
+	if list, ok := ctx.Struct_param_list().(*compiler.Struct_param_listContext); ok {
+		for _, param := range list.AllStruct_param() {
+			params = append(params, param.(*compiler.Struct_paramContext))
+		}
+	}
+
+	var fields []structField
+	mask := 0
+	for _, param := range params {
+		field, exists := layout.field(param.ID().GetText())
+		if !exists {
+			t.addError(fmt.Sprintf("Atributo '%s' no existe en el struct %s", param.ID().GetText(), name))
+			t.generator.LoadImmediate(arm64.X0, 0)
+			return
+		}
+		fields = append(fields, field)
+		mask |= 1 << field.Bit
+	}
+
+	t.generator.Comment(fmt.Sprintf("=== INSTANCIA DE %s (%d bytes) ===", name, layout.Size))
+	t.generator.LoadImmediate(arm64.X0, layout.Size)
+	t.callRuntime("alloc")
+	t.generator.LoadImmediate(arm64.X1, mask)
+	t.generator.Emit("str x1, [x0]")
+
+	for i, paramCtx := range params {
+		field := fields[i]
+		temp := t.generator.SaveTemp(arm64.X0)
+		t.translateValue(paramCtx.Expression(), field.Type)
+		t.generator.Emit("mov x1, x0")
+		t.restoreTempTo(temp, arm64.X0)
+		t.generator.Emit(fmt.Sprintf("str x1, [x0, #%d]", field.Offset))
+	}
+}
+
+// translateFieldAssign traduce p.x = v, p.x += v y p.x -= v, también sobre
+// atributos anidados como ana.casa.y.
+func (t *ARM64Translator) translateFieldAssign(idPattern compiler.IId_patternContext, operator string, expr antlr.ParseTree) {
+	name := idPattern.GetText()
+	selector := t.selectorOf(idPattern)
+	if selector == nil {
+		t.addError(fmt.Sprintf("Variable '%s' no está declarada", name))
+		return
+	}
+
+	// Primero el valor, después la instancia que tiene el atributo
+	fieldType := t.exprType(idPattern)
+	t.translateValue(expr, fieldType)
+	if levels := vectorLevels(fieldType); levels > 0 && operator == "=" && isVariable(expr) {
+		t.copyVector(levels)
+	}
+	temp := t.generator.SaveTemp(arm64.X0)
+	containerType, ok := t.loadSelector(selector.X)
+	value := t.generator.RestoreTemp(temp, arm64.X1)
+
+	var field structField
+	if layout, exists := t.structs[containerType]; ok && exists {
+		field, ok = layout.field(selector.Sel.Name)
+	} else {
+		ok = false
+	}
+	if !ok {
+		t.addError(fmt.Sprintf("Variable '%s' no está declarada", name))
+		return
+	}
+	t.storeWithOperator(fmt.Sprintf("[x0, #%d]", field.Offset), operator, fieldType, value)
+
+	// Desde ahora el atributo está inicializado
+	t.generator.Emit("ldr x2, [x0]")
+	t.generator.Emit(fmt.Sprintf("orr x2, x2, #%d", 1<<field.Bit))
+	t.generator.Emit("str x2, [x0]")
+}
+
+// callPrintStruct imprime la instancia del struct name que está en x0.
+func (t *ARM64Translator) callPrintStruct(name string) {
+	t.structPrinters[name] = true
+	t.generator.CallFunction("print_struct_" + name)
+}
+
+// generateStructPrinters emite print_struct_<Nombre> para cada struct que
+// se imprime, y para los que aparecen en sus atributos. Imprimen como
+// formatStruct: Punto{x: 1, y: 2}. Devuelve los strings que usan, que van
+// en una sección .data aparte.
+func (t *ARM64Translator) generateStructPrinters() []string {
+	var data []string
+	generated := make(map[string]bool)
+	for {
+		var pending []string
+		for name := range t.structPrinters {
+			if !generated[name] {
+				pending = append(pending, name)
+			}
+		}
+		if len(pending) == 0 {
+			break
+		}
+		sort.Strings(pending)
+
+		for _, name := range pending {
+			generated[name] = true
+			data = append(data, t.generateStructPrinter(t.structs[name])...)
+		}
+	}
+	if len(data) > 0 {
+		data = append(data, `struct_nil_str: .asciz "nil"`, `struct_sep_str: .asciz ", "`)
+	}
+	return data
+}
+
+// generateStructPrinter emite la función que imprime un struct y devuelve
+// sus strings. Solo imprime los atributos inicializados, como
+// formatStruct, y una instancia nula se imprime como nil.
+func (t *ARM64Translator) generateStructPrinter(layout *structLayout) []string {
+	label := "print_struct_" + layout.Name
+	data := []string{fmt.Sprintf(`%s_open: .asciz "%s{"`, label, layout.Name)}
+
+	t.generator.EmitRaw("")
+	t.generator.EmitRaw(label + ":")
+	t.generator.Comment(fmt.Sprintf("Imprime un %s; Input: x0 = instancia", layout.Name))
+	t.generator.Emit("stp x29, x30, [sp, #-16]!")
+	t.generator.Emit("stp x19, x20, [sp, #-16]!")
+	t.generator.Emit("stp x21, x22, [sp, #-16]!")
+	t.generator.Emit("mov x19, x0")
+	t.generator.Emit(fmt.Sprintf("cbnz x19, %s_fields", label))
+	t.generator.Emit("adr x0, struct_nil_str")
+	t.callRuntime("print_string")
+	t.generator.Emit(fmt.Sprintf("b %s_end", label))
+
+	t.generator.EmitRaw(label + "_fields:")
+	t.generator.Emit(fmt.Sprintf("adr x0, %s_open", label))
+	t.callRuntime("print_string")
+	t.generator.Emit("mov x20, #0") // x20 = ya se imprimió un atributo
+	for i, field := range layout.Fields {
+		fieldLabel := fmt.Sprintf("%s_%d", label, i)
+		data = append(data, fmt.Sprintf(`%s: .asciz "%s: "`, fieldLabel, field.Name))
+
+		t.generator.Emit("ldr x9, [x19]")
+		t.generator.Emit(fmt.Sprintf("tbz x9, #%d, %s_skip", field.Bit, fieldLabel))
+		t.generator.Emit(fmt.Sprintf("cbz x20, %s_name", fieldLabel))
+		t.generator.Emit("adr x0, struct_sep_str")
+		t.callRuntime("print_string")
+		t.generator.EmitRaw(fieldLabel + "_name:")
+		t.generator.Emit("mov x20, #1")
+		t.generator.Emit("adr x0, " + fieldLabel)
+		t.callRuntime("print_string")
+		t.generator.Emit(fmt.Sprintf("ldr x0, [x19, #%d]", field.Offset))
+		t.printField(field)
+		t.generator.EmitRaw(fieldLabel + "_skip:")
+	}
+	t.generator.Emit("mov x0, #125") // ASCII }
+	t.callRuntime("print_char")
+
+	t.generator.EmitRaw(label + "_end:")
+	t.generator.Emit("ldp x21, x22, [sp], #16")
+	t.generator.Emit("ldp x19, x20, [sp], #16")
+	t.generator.Emit("ldp x29, x30, [sp], #16")
+	t.generator.Emit("ret")
+	return data
+}
+
+// printField imprime el atributo que está en x0. Los strings van entre
+// comillas y los rune entre comillas simples.
+func (t *ARM64Translator) printField(field structField) {
+	if _, isStruct := t.structs[field.Type]; isStruct {
+		t.structPrinters[field.Type] = true
+		t.generator.Emit("bl print_struct_" + field.Type)
+		return
+	}
+
+	switch {
+	case field.Type == "float":
+		t.generator.Emit("fmov d0, x0")
+		t.generator.Emit(fmt.Sprintf("mov x1, #%d", printDecimals))
+		t.callRuntime("print_float")
+	case field.Type == "bool":
+		t.callRuntime("print_bool")
+	case vectorLevels(field.Type) > 0:
+		t.generator.Emit(fmt.Sprintf("mov x1, #%d", printKind(field.Type)))
+		t.callRuntime("print_vector")
+	case isStringType(field.Type):
+		quote := 34 // ASCII "
+		if field.Type == "rune" {
+			quote = 39 // ASCII '
+		}
+		t.generator.Emit("mov x21, x0")
+		t.generator.Emit(fmt.Sprintf("mov x0, #%d", quote))
+		t.callRuntime("print_char")
+		t.generator.Emit("mov x0, x21")
+		t.callRuntime("print_string")
+		t.generator.Emit(fmt.Sprintf("mov x0, #%d", quote))
+		t.callRuntime("print_char")
+	default:
+		t.callRuntime("print_integer")
+	}
+}
@@ -3,12 +3,15 @@ package compiler
 
 import (
 	"fmt"
+	"log/slog"
 	"strconv"
 	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
+	"main.go/ast"
 	"main.go/compiler/arm64"
 	compiler "main.go/grammar"
+	"main.go/logging"
 )
 
 // ARM64Translator es el traductor principal de VlangCherry a ARM64
@@ -18,11 +21,17 @@ type ARM64Translator struct {
 
 	userFunctions   map[string]*compiler.FuncDeclContext
 	currentFunction string
+	returnLabel     string // epílogo de la función actual
 
-	breakLabels    []string          // Etiquetas para manejar break en loops
-	continueLabels []string          // Etiquetas para manejar continue en loops
-	stringRegistry map[string]string // texto -> etiqueta Para evitar procesar strings dos veces
-	variableTypes  map[string]string // nombre -> tipo Para rastrear tipos de variables
+	breakLabels    []string                 // Etiquetas para manejar break en loops
+	continueLabels []string                 // Etiquetas para manejar continue en loops
+	program        *ast.Program             // árbol tipado, fuente de los tipos de cada expresión
+	runtime        *arm64.StandardLibrary   // funciones del runtime que usa el programa
+	structs        map[string]*structLayout // nombre -> disposición de cada struct declarado
+	structPrinters map[string]bool          // structs que se imprimen
+
+	SourceFile string       // ruta del programa en la directiva .file; vacía usa arm64.DefaultSourceFile
+	Log        *slog.Logger // logger del subsistema compiler
 }
 
 // NewARM64Translator crea un nuevo traductor
@@ -33,8 +42,10 @@ func NewARM64Translator() *ARM64Translator {
 		userFunctions:  make(map[string]*compiler.FuncDeclContext),
 		breakLabels:    make([]string, 0),
 		continueLabels: make([]string, 0),
-		stringRegistry: make(map[string]string),
-		variableTypes:  make(map[string]string),
+		runtime:        arm64.NewStandardLibrary(),
+		structs:        make(map[string]*structLayout),
+		structPrinters: make(map[string]bool),
+		Log:            logging.New(logging.Compiler),
 	}
 }
 
@@ -44,10 +55,15 @@ func NewARM64Translator() *ARM64Translator {
 func (t *ARM64Translator) TranslateProgram(tree antlr.ParseTree) (string, []string) {
 	// Limpiar estado anterior
 	t.generator.Reset()
+	t.generator.SourceFile = t.SourceFile
 	t.errors = make([]string, 0)
-	t.variableTypes = make(map[string]string)
+	t.program = ast.Lower(tree)
+	t.runtime = arm64.NewStandardLibrary()
+	t.structs = make(map[string]*structLayout)
+	t.structPrinters = make(map[string]bool)
+	t.generator.Log = t.Log
 
-	fmt.Printf("🔍 === PRIMERA PASADA: ANÁLISIS DEL PROGRAMA ===\n")
+	t.Log.Debug("primera pasada: análisis del programa")
 
 	// Primera pasada: analizar declaraciones de variables Y strings
 	t.analyzeVariablesAndStrings(tree)
@@ -55,7 +71,7 @@ func (t *ARM64Translator) TranslateProgram(tree antlr.ParseTree) (string, []stri
 	// Generar header del programa
 	t.generator.GenerateHeader()
 
-	fmt.Printf("🔍 === SEGUNDA PASADA: GENERACIÓN DE CÓDIGO ===\n")
+	t.Log.Debug("segunda pasada: generación de código")
 
 	// Traducir el contenido del programa / Segunda pasada
 	t.translateNode(tree)
@@ -65,11 +81,34 @@ func (t *ARM64Translator) TranslateProgram(tree antlr.ParseTree) (string, []stri
 
 	// Generar código para funciones de usuario
 	t.generateUserFunctions()
+	t.generator.ReserveVariables()
 
 	// Agregar funciones de librería estándar
 	t.generator.EmitRaw("")
 	t.generator.EmitRaw("// === LIBRERÍA ESTÁNDAR ===")
-	t.generateStandardLibrary()
+	// Las funciones que imprimen structs van primero porque marcan las de
+	// impresión que usan
+	structData := t.generateStructPrinters()
+	t.generator.EmitRaw(t.runtime.GetPrintFunctions())
+	if runtime := t.runtime.GetRuntimeFunctions(); runtime != "" {
+		t.generator.EmitRaw("")
+		t.generator.EmitRaw("// === RUNTIME ===")
+		t.generator.EmitRaw(runtime)
+	}
+
+	// Strings y constantes flotantes registrados durante la traducción
+	t.generator.GenerateStringData()
+	t.generator.GenerateFloatData()
+	t.generator.EmitRaw(t.runtime.GetStandardData())
+
+	// Strings de las funciones que imprimen structs
+	if len(structData) > 0 {
+		t.generator.EmitRaw("")
+		t.generator.EmitRaw(".data")
+		for _, stringDef := range structData {
+			t.generator.EmitRaw(stringDef)
+		}
+	}
 
 	return t.generator.GetCode(), t.errors
 }
@@ -81,7 +120,7 @@ func (t *ARM64Translator) analyzeVariablesAndStrings(node antlr.ParseTree) {
 	switch ctx := node.(type) {
 	case *compiler.ProgramContext:
 		for _, stmt := range ctx.AllStmt() {
-			fmt.Printf("🔍 Analizando statement: %T\n", stmt)
+			t.Log.Debug("analizando statement", "node", logging.NodeType(stmt))
 			t.analyzeVariablesAndStrings(stmt)
 		}
 
@@ -110,6 +149,17 @@ func (t *ARM64Translator) analyzeVariablesAndStrings(node antlr.ParseTree) {
 		if ctx.Func_call() != nil {
 			t.analyzeVariablesAndStrings(ctx.Func_call())
 		}
+		if ctx.Strct_dcl() != nil {
+			t.analyzeVariablesAndStrings(ctx.Strct_dcl())
+		}
+
+	case *compiler.StructDeclContext:
+		layout := newStructLayout(ctx)
+		if len(layout.Fields) > maxStructFields {
+			t.addError(fmt.Sprintf("Struct '%s' con más de %d atributos no soportado", layout.Name, maxStructFields))
+		}
+		t.structs[layout.Name] = layout
+		t.Log.Debug("struct declarado", "name", layout.Name, "size", layout.Size)
 
 	case *compiler.Assign_stmtContext:
 		// Procesar asignaciones para buscar strings
@@ -121,122 +171,62 @@ func (t *ARM64Translator) analyzeVariablesAndStrings(node antlr.ParseTree) {
 			}
 		}
 
-	case *compiler.ArgAddAssigDeclContext:
-		// Analizar expresión en asignación suma
-		if ctx.Expression() != nil {
-			t.analyzeStringsInExpression(ctx.Expression())
-		}
-
 	case *compiler.ValueDeclContext:
 		varName := ctx.ID().GetText()
-		if !t.generator.VariableExists(varName) {
+		if !t.generator.DeclaredInScope(varName) {
 			t.generator.DeclareVariable(varName)
 		}
-		// NUEVO: Inferir tipo de la variable
-		if ctx.Expression() != nil {
-			varType := t.inferExpressionType(ctx.Expression())
-			t.variableTypes[varName] = varType
-			fmt.Printf("🔍 Variable '%s' inferida como tipo: %s\n", varName, varType)
-		}
-		// Analizar strings en la expresión de inicialización
-		if ctx.Expression() != nil {
-			t.analyzeStringsInExpression(ctx.Expression())
-		}
+		t.Log.Debug("variable inferida", "name", varName, "type", t.exprType(ctx.Expression()))
 
 	case *compiler.MutVarDeclContext:
 		varName := ctx.ID().GetText()
-		if !t.generator.VariableExists(varName) {
+		if !t.generator.DeclaredInScope(varName) {
 			t.generator.DeclareVariable(varName)
 		}
-		// NUEVO: Inferir tipo de la variable
-		if ctx.Expression() != nil {
-			varType := t.inferExpressionType(ctx.Expression())
-			t.variableTypes[varName] = varType
-			fmt.Printf("🔍 Variable '%s' inferida como tipo: %s\n", varName, varType)
-		}
-		// Analizar strings en la expresión de inicialización
-		if ctx.Expression() != nil {
-			t.analyzeStringsInExpression(ctx.Expression())
-		}
+		t.Log.Debug("variable inferida", "name", varName, "type", t.exprType(ctx.Expression()))
 
 	case *compiler.VarAssDeclContext:
 		varName := ctx.ID().GetText()
-		if !t.generator.VariableExists(varName) {
+		if !t.generator.DeclaredInScope(varName) {
 			t.generator.DeclareVariable(varName)
 		}
-		// NUEVO: Inferir tipo de la variable
-		if ctx.Expression() != nil {
-			varType := t.inferExpressionType(ctx.Expression())
-			t.variableTypes[varName] = varType
-			fmt.Printf("🔍 Variable '%s' inferida como tipo: %s\n", varName, varType)
-		}
-		// Analizar strings en la expresión de inicialización
-		if ctx.Expression() != nil {
-			t.analyzeStringsInExpression(ctx.Expression())
-		}
+		t.Log.Debug("variable inferida", "name", varName, "type", t.exprType(ctx.Expression()))
+
+	case *compiler.ValDeclVecContext:
+		t.declareVariable(ctx.ID().GetText())
+
+	case *compiler.VarVectDeclContext:
+		t.declareVariable(ctx.ID().GetText())
+
+	case *compiler.VarMatrixDeclContext:
+		t.declareVariable(ctx.ID().GetText())
 
 	case *compiler.FuncDeclContext:
 		funcName := ctx.ID().GetText()
 
-		fmt.Printf("🔍 Analizando función: %s\n", funcName)
+		t.Log.Debug("analizando función", "name", funcName)
 
-		// Registrar función de usuario
-		if funcName != "main" {
-			t.userFunctions[funcName] = ctx
-		}
-
-		// Contar parámetros
-		paramCount := 0
-		if ctx.Param_list() != nil {
-			params := ctx.Param_list().(*compiler.ParamListContext).AllFunc_param()
-			paramCount = len(params)
-			for i, param := range params {
-				if paramCtx := param.(*compiler.FuncParamContext); paramCtx.ID() != nil {
-					paramName := paramCtx.ID().GetText()
-					fmt.Printf("📝 Parámetro: %s\n", paramName)
-					t.generator.DeclareVariable(paramName)
-				} else {
-					fmt.Printf("📝 Parámetro %d: sin nombre\n", i)
-				}
+		// main se traduce dentro de _start y sus variables son del programa
+		// principal
+		if funcName == "main" {
+			for _, stmt := range ctx.AllStmt() {
+				t.analyzeVariablesAndStrings(stmt)
 			}
+			break
 		}
 
-		// Analizar el cuerpo de la función
-		varCount := 0
+		// Registrar función de usuario. Sus variables van en su marco, que
+		// se arma al generarla; acá solo se declaran
+		t.userFunctions[funcName] = ctx
+		t.generator.BeginFunction()
 		for _, stmt := range ctx.AllStmt() {
-			initialVarCount := len(t.generator.GetVariables())
 			t.analyzeVariablesAndStrings(stmt)
-			varCount += len(t.generator.GetVariables()) - initialVarCount
-		}
-
-		stackSize := (paramCount + varCount) * 8
-		fmt.Printf("📊 Parámetros: %d, Variables locales: %d, Stack: %d bytes\n", paramCount, varCount, stackSize)
-
-	// NUEVO: Análisis específico de llamadas a funciones
-	case *compiler.FuncCallContext:
-		fmt.Printf("🔍 Analizando llamada a función: %s\n", ctx.Id_pattern().GetText())
-
-		// Analizar argumentos en busca de strings
-		if ctx.Arg_list() != nil {
-			args := ctx.Arg_list().(*compiler.ArgListContext).AllFunc_arg()
-			for i, arg := range args {
-				fmt.Printf("🔍   Analizando argumento %d: %s\n", i, arg.GetText())
-				if argCtx := arg.(*compiler.FuncArgContext); argCtx != nil {
-					if argCtx.Expression() != nil {
-						t.analyzeStringsInExpression(argCtx.Expression())
-					}
-				}
-			}
 		}
+		t.generator.EndFunction()
 
-	// Otros casos que pueden contener strings
 	case *compiler.IfStmtContext:
 		for _, ifChain := range ctx.AllIf_chain() {
 			if ifChainCtx, ok := ifChain.(*compiler.IfChainContext); ok {
-				// Analizar condición
-				if ifChainCtx.Expression() != nil {
-					t.analyzeStringsInExpression(ifChainCtx.Expression())
-				}
 				// Analizar cuerpo
 				for _, stmt := range ifChainCtx.AllStmt() {
 					t.analyzeVariablesAndStrings(stmt)
@@ -251,19 +241,30 @@ func (t *ARM64Translator) analyzeVariablesAndStrings(node antlr.ParseTree) {
 		}
 
 	case *compiler.ForStmtCondContext:
-		// Analizar condición
-		if ctx.Expression() != nil {
-			t.analyzeStringsInExpression(ctx.Expression())
-		}
 		// Analizar cuerpo
 		for _, stmt := range ctx.AllStmt() {
 			t.analyzeVariablesAndStrings(stmt)
 		}
 
+	case *compiler.ForAssCondContext:
+		t.analyzeVariablesAndStrings(ctx.Assign_stmt())
+		for _, stmt := range ctx.AllStmt() {
+			t.analyzeVariablesAndStrings(stmt)
+		}
+
+	case *compiler.ForStmtContext:
+		// El índice, el valor y las variables ocultas del recorrido
+		vectorVar, indexVar := forRangeVariables(ctx)
+		for _, varName := range []string{ctx.ID(0).GetText(), ctx.ID(1).GetText(), vectorVar, indexVar} {
+			t.declareVariable(varName)
+		}
+		for _, stmt := range ctx.AllStmt() {
+			t.analyzeVariablesAndStrings(stmt)
+		}
+
 	case *compiler.SwitchStmtContext:
 		for _, rawCase := range ctx.AllSwitch_case() {
 			if caseCtx, ok := rawCase.(*compiler.SwitchCaseContext); ok {
-				t.analyzeStringsInExpression(caseCtx.Expression())
 				for _, stmt := range caseCtx.AllStmt() {
 					t.analyzeVariablesAndStrings(stmt)
 				}
@@ -280,172 +281,30 @@ func (t *ARM64Translator) analyzeVariablesAndStrings(node antlr.ParseTree) {
 	}
 }
 
-// NUEVA FUNCIÓN: Inferir tipo de expresión
-func (t *ARM64Translator) inferExpressionType(expr antlr.ParseTree) string {
-	if expr == nil {
-		return "unknown"
-	}
-
-	switch ctx := expr.(type) {
-	case *compiler.LiteralExprContext:
-		return t.inferExpressionType(ctx.Literal())
-	case *compiler.LiteralContext:
-		return t.inferLiteralType(ctx)
-	case *compiler.StringLiteralContext:
-		return "string"
-	case *compiler.IntLiteralContext:
-		return "int"
-	case *compiler.FloatLiteralContext:
-		return "float"
-	case *compiler.BoolLiteralContext:
-		return "bool"
-	case *compiler.IdPatternExprContext:
-		varName := ctx.Id_pattern().GetText()
-		if varType, exists := t.variableTypes[varName]; exists {
-			return varType
-		}
-		return "unknown"
-	case *compiler.BinaryExprContext:
-		// Para operaciones binarias, usar el tipo del operando izquierdo
-		return t.inferExpressionType(ctx.GetLeft())
-	default:
-		return "unknown"
+// declareVariable declara varName si todavía no existe en el ámbito actual.
+func (t *ARM64Translator) declareVariable(varName string) {
+	if !t.generator.DeclaredInScope(varName) {
+		t.generator.DeclareVariable(varName)
 	}
 }
 
-// Inferir tipo de literal
-func (t *ARM64Translator) inferLiteralType(ctx *compiler.LiteralContext) string {
-	text := ctx.GetText()
-
-	// Verificar string
-	if strings.HasPrefix(text, "\"") && strings.HasSuffix(text, "\"") {
-		return "string"
-	}
-
-	// Verificar float
-	if strings.Contains(text, ".") {
-		return "float"
-	}
-
-	// Verificar bool
-	if text == "true" || text == "false" {
-		return "bool"
+// exprType devuelve el tipo estático que el árbol tipado asignó a la
+// expresión, o "unknown" si no se pudo determinar.
+func (t *ARM64Translator) exprType(expr antlr.ParseTree) string {
+	ctx, ok := expr.(antlr.ParserRuleContext)
+	if !ok {
+		return "unknown"
 	}
-
-	// Por defecto, int
-	if _, err := strconv.Atoi(text); err == nil {
-		return "int"
+	if exprType := t.program.TypeOf(ctx); exprType != "" {
+		return exprType
 	}
-
 	return "unknown"
 }
 
-// === ANÁLISIS MEJORADO DE STRINGS ===
-
-// analyzeStringsInExpression busca strings en expresiones de forma más completa
-func (t *ARM64Translator) analyzeStringsInExpression(expr antlr.ParseTree) {
-	if expr == nil {
-		return
-	}
-
-	fmt.Printf("🔍 Analizando expresión para strings: %s (tipo: %T)\n", expr.GetText(), expr)
-
-	switch ctx := expr.(type) {
-	case *compiler.StringLiteralContext:
-		// STRING LITERAL DIRECTO
-		t.preProcessStringLiteral(ctx)
-
-	case *compiler.LiteralExprContext:
-		// Expresión literal que puede contener un string
-		t.analyzeStringsInExpression(ctx.Literal())
-
-	case *compiler.LiteralContext:
-		// Buscar string literals en el literal
-		for i := 0; i < ctx.GetChildCount(); i++ {
-			child := ctx.GetChild(i)
-			if stringCtx, ok := child.(*compiler.StringLiteralContext); ok {
-				t.preProcessStringLiteral(stringCtx)
-			}
-		}
-
-	case *compiler.BinaryExprContext:
-		// Analizar ambos lados de la expresión binaria
-		t.analyzeStringsInExpression(ctx.GetLeft())
-		t.analyzeStringsInExpression(ctx.GetRight())
-
-	case *compiler.ParensExprContext:
-		// Analizar expresión entre paréntesis
-		t.analyzeStringsInExpression(ctx.Expression())
-
-	case *compiler.FuncCallExprContext:
-		// Analizar llamada a función
-		t.analyzeStringsInExpression(ctx.Func_call())
-
-	case *compiler.FuncCallContext:
-		// Analizar argumentos de la función
-		if ctx.Arg_list() != nil {
-			args := ctx.Arg_list().(*compiler.ArgListContext).AllFunc_arg()
-			for _, arg := range args {
-				if argCtx := arg.(*compiler.FuncArgContext); argCtx != nil {
-					if argCtx.Expression() != nil {
-						t.analyzeStringsInExpression(argCtx.Expression())
-					}
-				}
-			}
-		}
-
-	default:
-		// Para otros tipos, analizar recursivamente todos los hijos
-		if ctx, ok := expr.(antlr.ParserRuleContext); ok {
-			for i := 0; i < ctx.GetChildCount(); i++ {
-				if child := ctx.GetChild(i); child != nil {
-					if parseTreeChild, ok := child.(antlr.ParseTree); ok {
-						t.analyzeStringsInExpression(parseTreeChild)
-					}
-				}
-			}
-		}
-	}
-}
-
-// preProcessStringLiteral procesa strings en la primera pasada (MEJORADO)
-func (t *ARM64Translator) preProcessStringLiteral(ctx *compiler.StringLiteralContext) {
-	text := ctx.GetText()
-	if len(text) >= 2 {
-		text = text[1 : len(text)-1] // Quitar comillas
-	}
-
-	// Procesar secuencias de escape
-	text = strings.ReplaceAll(text, "\\n", "\n")
-	text = strings.ReplaceAll(text, "\\t", "\t")
-	text = strings.ReplaceAll(text, "\\\"", "\"")
-	text = strings.ReplaceAll(text, "\\\\", "\\")
-
-	// VERIFICAR SI TIENE INTERPOLACIÓN
-	if strings.Contains(text, "$") {
-		// Procesar partes no variables del string interpolado
-		parts := t.parseInterpolatedString(text)
-		for _, part := range parts {
-			if !part.IsVariable && part.Content != "" {
-				// Solo registrar las partes de texto literal
-				if _, exists := t.stringRegistry[part.Content]; !exists {
-					stringLabel := t.generator.AddStringLiteral(part.Content)
-					t.stringRegistry[part.Content] = stringLabel
-					fmt.Printf("✅ STRING INTERPOLADO REGISTRADO: \"%s\" -> %s\n", part.Content, stringLabel)
-				}
-			}
-		}
-	} else {
-		// String normal - procesar como antes
-		if existingLabel, exists := t.stringRegistry[text]; exists {
-			fmt.Printf("🔄 String \"%s\" ya procesado como %s\n", text, existingLabel)
-			return
-		}
-
-		stringLabel := t.generator.AddStringLiteral(text)
-		t.stringRegistry[text] = stringLabel
-		fmt.Printf("✅ STRING REGISTRADO: \"%s\" -> %s\n", text, stringLabel)
-	}
+// isStringType indica si un tipo se representa como la dirección de un
+// string; los literales de un carácter son rune.
+func isStringType(exprType string) bool {
+	return exprType == "string" || exprType == "rune"
 }
 
 // === RESTO DE MÉTODOS (mantenidos igual pero con corrección en print) ===
@@ -454,38 +313,53 @@ func (t *ARM64Translator) generateUserFunctions() {
 	t.generator.EmitRaw("")
 	t.generator.EmitRaw("// === FUNCIONES DE USUARIO ===")
 
+	defer t.generator.SetPosition(arm64.Position{})
 	for funcName, funcDecl := range t.userFunctions {
+		t.generator.SetPosition(position(funcDecl))
 		t.generator.EmitRaw("")
 		t.generator.Comment(fmt.Sprintf("Función: %s", funcName))
 		t.generator.EmitRaw(fmt.Sprintf("func_%s:", funcName))
 
-		// Prólogo de función
-		t.generator.Emit("stp x29, x30, [sp, #-16]!")
-		t.generator.Emit("mov x29, sp")
-
-		// Mapear parámetros de registros a variables locales
-		if funcDecl.Param_list() != nil {
-			params := funcDecl.Param_list().(*compiler.ParamListContext).AllFunc_param()
+		// Cada llamada tiene su marco con los parámetros y las variables
+		// locales, así la recursión no pisa las de la llamada anterior
+		t.generator.BeginFunction()
+		paramNames, paramTypes := functionParams(funcDecl)
+		for _, paramName := range paramNames {
+			t.generator.DeclareVariable(paramName)
+		}
+		for _, stmt := range funcDecl.AllStmt() {
+			t.analyzeVariablesAndStrings(stmt)
+		}
+		t.Log.Debug("frame de función", "name", funcName, "params", len(paramNames), "frame", t.generator.FrameSize())
 
-			for i, param := range params {
-				if paramCtx := param.(*compiler.FuncParamContext); paramCtx.ID() != nil {
-					paramName := paramCtx.ID().GetText()
-					// Declarar parámetro como variable local
-					t.generator.DeclareVariable(paramName)
+		// Prólogo de función. Los registros de temporales que use el cuerpo
+		// se guardan en mark cuando se conocen, al final
+		t.generator.EmitPrologue()
+		mark := t.generator.Mark()
 
-					// Usar un registro temporal para no sobrescribir
-					sourceReg := fmt.Sprintf("x%d", i)
-					tempReg := fmt.Sprintf("x%d", i+10) // Usar x10, x11, etc. como temporales
+		// Copiar los parámetros de x0-x7, d0-d7 y el stack a sus variables
+		locations, _ := arm64.AssignArguments(floatTypes(paramTypes))
+		for i, paramName := range paramNames {
+			if register := locations[i].Register; register != "" {
+				t.generator.StoreVariable(register, paramName)
+				continue
+			}
+			t.generator.Emit(fmt.Sprintf("ldr x9, %s", t.generator.StackArgument(locations[i].Offset)))
+			t.generator.StoreVariable(arm64.X9, paramName)
+		}
 
-					t.generator.Emit(fmt.Sprintf("mov %s, %s", tempReg, sourceReg))
-					t.generator.Emit(fmt.Sprintf("mov x0, %s", tempReg))
-					t.generator.StoreVariable(arm64.X0, paramName)
-				}
+		// Los vectores se pasan por valor: la función trabaja con una copia
+		for i, paramName := range paramNames {
+			if levels := vectorLevels(paramTypes[i]); levels > 0 {
+				t.generator.LoadVariable(arm64.X0, paramName)
+				t.copyVector(levels)
+				t.generator.StoreVariable(arm64.X0, paramName)
 			}
 		}
 
-		// Traducir cuerpo de la función
+		// Traducir cuerpo de la función; los return saltan al epílogo
 		t.currentFunction = funcName
+		t.returnLabel = t.generator.GetLabel()
 		hasReturnStatement := false
 
 		for _, stmt := range funcDecl.AllStmt() {
@@ -496,17 +370,55 @@ func (t *ARM64Translator) generateUserFunctions() {
 			t.translateNode(stmt)
 		}
 
-		// Epílogo de función (solo si no hay return explícito)
+		// Valor de retorno por defecto (solo si no hay return explícito)
 		if !hasReturnStatement {
-			t.generator.Emit("mov x0, #0") // Valor de retorno por defecto
-			t.generator.Emit("ldp x29, x30, [sp], #16")
-			t.generator.Emit("ret")
+			t.generator.Emit("mov x0, #0")
+		}
+
+		// Epílogo de función: un float vuelve en d0
+		saved := t.generator.UsedTempRegisters()
+		t.generator.SetLabel(t.returnLabel)
+		if t.returnType() == "float" {
+			t.generator.Emit("fmov d0, x0")
 		}
+		t.generator.RestoreRegisters(saved)
+		t.generator.EmitEpilogue()
+		t.generator.SaveRegistersAt(mark, saved)
 
+		t.generator.EndFunction()
 		t.currentFunction = ""
 	}
 }
 
+// position devuelve dónde empieza ctx en el programa, para el generador.
+func position(ctx antlr.ParserRuleContext) arm64.Position {
+	return arm64.Position{Line: ctx.GetStart().GetLine(), Column: ctx.GetStart().GetColumn()}
+}
+
+// functionParams devuelve los nombres y los tipos de los parámetros de una
+// función de usuario.
+func functionParams(funcDecl *compiler.FuncDeclContext) (names, types []string) {
+	if funcDecl.Param_list() == nil {
+		return nil, nil
+	}
+	for _, param := range funcDecl.Param_list().(*compiler.ParamListContext).AllFunc_param() {
+		if paramCtx := param.(*compiler.FuncParamContext); paramCtx.ID() != nil {
+			names = append(names, paramCtx.ID().GetText())
+			types = append(types, paramCtx.Type_().GetText())
+		}
+	}
+	return names, types
+}
+
+// floatTypes indica cuáles de types son float, para AssignArguments.
+func floatTypes(types []string) []bool {
+	floats := make([]bool, len(types))
+	for i, typ := range types {
+		floats[i] = typ == "float"
+	}
+	return floats
+}
+
 // Verificar si un statement contiene return
 func (t *ARM64Translator) hasReturnStatement(stmt antlr.ParseTree) bool {
 	switch ctx := stmt.(type) {
@@ -530,16 +442,14 @@ func (t *ARM64Translator) translateReturnStatement(ctx *compiler.ReturnStmtConte
 
 	// Si hay expresión de retorno, evaluarla
 	if ctx.Expression() != nil {
-		t.translateExpression(ctx.Expression())
+		t.translateValue(ctx.Expression(), t.returnType())
 		// El resultado queda en x0, que es correcto para el valor de retorno
 	} else {
 		// Return sin valor
 		t.generator.LoadImmediate(arm64.X0, 0)
 	}
 
-	// Epílogo de función
-	t.generator.Emit("ldp x29, x30, [sp], #16")
-	t.generator.Emit("ret")
+	t.translateReturnJump()
 }
 
 // === TRADUCCIÓN DE NODOS (mantenida igual) ===
@@ -591,6 +501,19 @@ func (t *ARM64Translator) translateNode(node antlr.ParseTree) {
 		t.translateBreakStatement(ctx)
 	case *compiler.ContinueStmtContext:
 		t.translateContinueStatement(ctx)
+	case *compiler.ValDeclVecContext:
+		t.translateValDeclVec(ctx)
+	case *compiler.VarVectDeclContext:
+		t.translateVarVectDecl(ctx)
+	case *compiler.VarMatrixDeclContext:
+		t.translateVarMatrixDecl(ctx)
+	case *compiler.VectorAssignContext:
+		t.translateVectorAssign(ctx)
+	case *compiler.ForStmtContext:
+		t.translateForRange(ctx)
+	case *compiler.StructDeclContext:
+		// La disposición se calculó en la primera pasada
+		t.generator.Comment(fmt.Sprintf("=== STRUCT %s ===", ctx.ID().GetText()))
 
 	default:
 		// Para nodos no implementados, simplemente continuar
@@ -633,7 +556,13 @@ func (t *ARM64Translator) translateAssignStatement(ctx *compiler.Assign_stmtCont
 
 func (t *ARM64Translator) translateArgAddAssignment(ctx *compiler.ArgAddAssigDeclContext) {
 	varName := ctx.Id_pattern().GetText()
-	t.generator.Comment(fmt.Sprintf("=== ASIGNACIÓN SUMA: %s += ... ===", varName))
+	operator := ctx.GetOp().GetText()
+	t.generator.Comment(fmt.Sprintf("=== ASIGNACIÓN COMPUESTA: %s %s ... ===", varName, operator))
+
+	if strings.Contains(varName, ".") {
+		t.translateFieldAssign(ctx.Id_pattern(), operator, ctx.Expression())
+		return
+	}
 
 	// Verificar que la variable existe
 	if !t.generator.VariableExists(varName) {
@@ -641,19 +570,65 @@ func (t *ARM64Translator) translateArgAddAssignment(ctx *compiler.ArgAddAssigDec
 		return
 	}
 
-	// Cargar valor actual de la variable en x1
-	t.generator.LoadVariable(arm64.X1, varName)
+	if isStringType(t.exprType(ctx.Id_pattern())) && operator == "+=" {
+		t.translateStringAddAssign(varName, ctx.Expression())
+		return
+	}
+
+	if t.exprType(ctx.Id_pattern()) == "float" {
+		// Evaluar la expresión como double y operar en d0
+		t.translateFloatOperand(ctx.Expression())
+		t.generator.Emit("fmov d1, x0")
+		t.generator.LoadVariable(arm64.X0, varName)
+		t.generator.Emit("fmov d0, x0")
+		if operator == "-=" {
+			t.generator.Emit("fsub d0, d0, d1")
+		} else {
+			t.generator.Emit("fadd d0, d0, d1")
+		}
+		t.generator.Emit("fmov x0, d0")
+		t.generator.StoreVariable(arm64.X0, varName)
+		return
+	}
 
 	// Evaluar la expresión del lado derecho (resultado en x0)
 	t.translateExpression(ctx.Expression())
 
-	// Sumar: x0 = x1 + x0
-	t.generator.Add(arm64.X0, arm64.X1, arm64.X0)
+	// Cargar valor actual de la variable en x1
+	t.generator.LoadVariable(arm64.X1, varName)
+
+	// Operar: x0 = x1 + x0 o x0 = x1 - x0
+	if operator == "-=" {
+		t.generator.Sub(arm64.X0, arm64.X1, arm64.X0)
+	} else {
+		t.generator.Add(arm64.X0, arm64.X1, arm64.X0)
+	}
 
 	// Guardar el resultado de vuelta en la variable
 	t.generator.StoreVariable(arm64.X0, varName)
 }
 
+// translateReturnJump sale de la función actual por su epílogo, que
+// restaura los registros guardados en el prólogo.
+func (t *ARM64Translator) translateReturnJump() {
+	if t.currentFunction != "" {
+		t.generator.Jump(t.returnLabel)
+		return
+	}
+	t.generator.Emit("ldp x29, x30, [sp], #16")
+	t.generator.Emit("ret")
+}
+
+// returnType es el tipo de retorno declarado de la función que se está
+// traduciendo, o "" fuera de una función o si no declara uno.
+func (t *ARM64Translator) returnType() string {
+	funcDecl, exists := t.userFunctions[t.currentFunction]
+	if !exists || funcDecl.Type_() == nil {
+		return ""
+	}
+	return funcDecl.Type_().GetText()
+}
+
 // Manejar return desde transfer_stmt
 func (t *ARM64Translator) translateReturnStatementFromTransfer(ctx *compiler.Transfer_stmtContext) {
 	t.generator.Comment("=== RETURN STATEMENT ===")
@@ -666,7 +641,7 @@ func (t *ARM64Translator) translateReturnStatementFromTransfer(ctx *compiler.Tra
 		child := ctx.GetChild(i)
 		if expressionCtx, ok := child.(*compiler.ExpressionContext); ok {
 			hasExpression = true
-			t.translateExpression(expressionCtx)
+			t.translateValue(expressionCtx, t.returnType())
 			break
 		}
 	}
@@ -676,9 +651,7 @@ func (t *ARM64Translator) translateReturnStatementFromTransfer(ctx *compiler.Tra
 		t.generator.LoadImmediate(arm64.X0, 0)
 	}
 
-	// Epílogo de función
-	t.generator.Emit("ldp x29, x30, [sp], #16")
-	t.generator.Emit("ret")
+	t.translateReturnJump()
 }
 
 // Modificar translateBreakStatementFromTransfer
@@ -711,6 +684,10 @@ func (t *ARM64Translator) translateProgram(ctx *compiler.ProgramContext) {
 
 // translateStatement traduce una declaración general
 func (t *ARM64Translator) translateStatement(ctx *compiler.StmtContext) {
+	// Lo que se emita hasta que termine sale de esta sentencia
+	defer t.generator.SetPosition(t.generator.Position())
+	t.generator.SetPosition(position(ctx))
+
 	if ctx.Decl_stmt() != nil {
 		t.translateNode(ctx.Decl_stmt())
 	} else if ctx.Assign_stmt() != nil {
@@ -727,6 +704,8 @@ func (t *ARM64Translator) translateStatement(ctx *compiler.StmtContext) {
 		t.translateNode(ctx.Func_dcl())
 	} else if ctx.Transfer_stmt() != nil {
 		t.translateNode(ctx.Transfer_stmt())
+	} else if ctx.Strct_dcl() != nil {
+		t.translateNode(ctx.Strct_dcl())
 	}
 }
 
@@ -777,7 +756,7 @@ func (t *ARM64Translator) translateMutVarDecl(ctx *compiler.MutVarDeclContext) {
 	t.generator.Comment(fmt.Sprintf("=== DECLARACIÓN: mut %s ===", varName))
 
 	// Evaluar la expresión del lado derecho
-	t.translateExpression(ctx.Expression())
+	t.translateValue(ctx.Expression(), ctx.Type_().GetText())
 
 	// Guardar el resultado en la variable
 	t.generator.StoreVariable(arm64.X0, varName)
@@ -789,7 +768,7 @@ func (t *ARM64Translator) translateVarAssDecl(ctx *compiler.VarAssDeclContext) {
 	t.generator.Comment(fmt.Sprintf("=== DECLARACIÓN: %s ===", varName))
 
 	// Evaluar la expresión del lado derecho
-	t.translateExpression(ctx.Expression())
+	t.translateValue(ctx.Expression(), ctx.Type_().GetText())
 
 	// Guardar el resultado en la variable
 	t.generator.StoreVariable(arm64.X0, varName)
@@ -802,6 +781,11 @@ func (t *ARM64Translator) translateAssignment(ctx *compiler.AssignmentDeclContex
 	varName := ctx.Id_pattern().GetText()
 	t.generator.Comment(fmt.Sprintf("=== ASIGNACIÓN: %s = ... ===", varName))
 
+	if strings.Contains(varName, ".") {
+		t.translateFieldAssign(ctx.Id_pattern(), "=", ctx.Expression())
+		return
+	}
+
 	// Verificar que la variable existe
 	if !t.generator.VariableExists(varName) {
 		t.addError(fmt.Sprintf("Variable '%s' no está declarada", varName))
@@ -809,7 +793,13 @@ func (t *ARM64Translator) translateAssignment(ctx *compiler.AssignmentDeclContex
 	}
 
 	// Evaluar la expresión del lado derecho
-	t.translateExpression(ctx.Expression())
+	targetType := t.exprType(ctx.Id_pattern())
+	t.translateValue(ctx.Expression(), targetType)
+
+	// Asignar un vector lo copia; declararlo con otro no
+	if levels := vectorLevels(targetType); levels > 0 && isVariable(ctx.Expression()) {
+		t.copyVector(levels)
+	}
 
 	// Guardar el resultado en la variable
 	t.generator.StoreVariable(arm64.X0, varName)
@@ -819,7 +809,7 @@ func (t *ARM64Translator) translateAssignment(ctx *compiler.AssignmentDeclContex
 
 // translateExpression traduce cualquier expresión y deja el resultado en X0
 func (t *ARM64Translator) translateExpression(expr antlr.ParseTree) {
-	fmt.Printf("🔢 Traduciendo expresión: %T = %s\n", expr, expr.GetText())
+	t.Log.Debug("traduciendo expresión", "node", logging.NodeType(expr), "text", logging.Text(expr))
 
 	switch ctx := expr.(type) {
 	case *compiler.IntLiteralContext:
@@ -851,6 +841,14 @@ func (t *ARM64Translator) translateExpression(expr antlr.ParseTree) {
 		t.translateIncrement(ctx)
 	case *compiler.DecrementoContext:
 		t.translateDecrement(ctx)
+	case *compiler.VectorExprContext:
+		t.translateVectorLiteral(ctx.Vect_expr(), ast.ElemType(t.exprType(ctx)))
+	case *compiler.VectorItemExprContext:
+		t.translateVectorItem(ctx.Vect_item())
+	case *compiler.RepeatingExprContext:
+		t.translateRepeating(ctx.Repeating(), t.exprType(ctx))
+	case *compiler.StructInstantiationExprContext:
+		t.translateStructInstantiation(ctx)
 
 	default:
 		t.addError(fmt.Sprintf("Expresión no implementada: %T", ctx))
@@ -909,6 +907,12 @@ func (t *ARM64Translator) translateUnaryMinus(operandExpr antlr.ParseTree) {
 
 	// Negar el valor: x0 = -x0
 	t.generator.Comment("Negar valor numérico")
+	if t.exprType(operandExpr) == "float" {
+		t.generator.Emit("fmov d0, x0")
+		t.generator.Emit("fneg d0, d0")
+		t.generator.Emit("fmov x0, d0")
+		return
+	}
 	t.generator.Emit("neg x0, x0")
 }
 
@@ -941,7 +945,6 @@ func (t *ARM64Translator) translateLiteral(ctx *compiler.LiteralContext) {
 
 	// Fallback: analizar por texto si no se encontró un tipo específico
 	text := ctx.GetText()
-	fmt.Printf("🔍 Procesando literal por texto: %s\n", text)
 
 	if value, err := strconv.Atoi(text); err == nil {
 		t.generator.LoadImmediate(arm64.X0, value)
@@ -959,44 +962,35 @@ func (t *ARM64Translator) translateFloatLiteral(ctx *compiler.FloatLiteralContex
 		value = 0.0
 	}
 
-	// NUEVO: Escalar por 100 para mantener 2 decimales
-	scaledValue := int(value * 100)
-	t.generator.Comment(fmt.Sprintf("Flotante %s escalado como %d (x100)", valueStr, scaledValue))
-	t.generator.LoadImmediate(arm64.X0, scaledValue)
+	// Los flotantes viajan en x0 como los bits del double; las operaciones
+	// los pasan a registros d
+	label := t.generator.AddFloatLiteral(value)
+	t.generator.Comment(fmt.Sprintf("Flotante %s desde %s", valueStr, label))
+	t.generator.Emit(fmt.Sprintf("adr x0, %s", label))
+	t.generator.Emit("ldr d0, [x0]")
+	t.generator.Emit("fmov x0, d0")
 }
 
+// translateStringLiteral deja en x0 la dirección del string. Los literales
+// se registran al traducirlos, así que no dependen de que la primera pasada
+// haya recorrido la rama en la que aparecen.
 func (t *ARM64Translator) translateStringLiteral(ctx *compiler.StringLiteralContext) {
-	text := ctx.GetText()
-	if len(text) >= 2 {
-		text = text[1 : len(text)-1] // Quitar comillas
+	literal, ok := t.program.NodeOf(ctx).(*ast.StringLit)
+	if !ok {
+		t.addError(fmt.Sprintf("String %s sin nodo tipado", ctx.GetText()))
+		t.generator.LoadImmediate(arm64.X0, 0)
+		return
 	}
 
-	// Procesar secuencias de escape
-	text = strings.ReplaceAll(text, "\\n", "\n")
-	text = strings.ReplaceAll(text, "\\t", "\t")
-	text = strings.ReplaceAll(text, "\\\"", "\"")
-	text = strings.ReplaceAll(text, "\\\\", "\\")
-
-	// VERIFICAR SI TIENE INTERPOLACIÓN
-	if strings.Contains(text, "$") {
-		// IMPORTANTE: No usar x0 para el resultado final en interpolación
-		// porque processStringInterpolation hace múltiples prints
-		t.processStringInterpolation(text)
-
-		// DESPUÉS DE INTERPOLACIÓN, x0 queda en estado indefinido
-		// Para funciones que esperan un valor en x0, cargar 0
-		t.generator.Comment("Interpolación completada")
-
-	} else {
-		// String normal sin interpolación
-		if existingLabel, exists := t.stringRegistry[text]; exists {
-			t.generator.Comment(fmt.Sprintf("Usar string \"%s\" con etiqueta %s", text, existingLabel))
-			t.generator.Emit(fmt.Sprintf("adr x0, %s", existingLabel))
-		} else {
-			t.addError(fmt.Sprintf("String \"%s\" no fue procesado en primera pasada", text))
-			t.generator.LoadImmediate(arm64.X0, 0)
-		}
+	if literal.Interpolated() {
+		// El string se arma en el heap y queda en x0
+		t.processStringInterpolation(literal)
+		return
 	}
+
+	label := t.generator.AddStringLiteral(arm64.EscapeString(literal.Value))
+	t.generator.Comment(fmt.Sprintf("Usar string %q con etiqueta %s", literal.Value, label))
+	t.generator.Emit(fmt.Sprintf("adr x0, %s", label))
 }
 
 func (t *ARM64Translator) translateBoolLiteral(ctx *compiler.BoolLiteralContext) {
@@ -1022,7 +1016,17 @@ func (t *ARM64Translator) translateIntLiteral(ctx *compiler.IntLiteralContext) {
 
 // translateVariable traduce el acceso a una variable
 func (t *ARM64Translator) translateVariable(ctx *compiler.IdPatternExprContext) {
-	varName := ctx.Id_pattern().GetText()
+	t.translateIdPattern(ctx.Id_pattern())
+}
+
+// translateIdPattern deja en x0 el valor de una variable, del atributo de
+// un struct, como p.x, o de una propiedad de un vector, como v.count.
+func (t *ARM64Translator) translateIdPattern(idPattern compiler.IId_patternContext) {
+	varName := idPattern.GetText()
+	if strings.Contains(varName, ".") {
+		t.translateSelector(idPattern)
+		return
+	}
 
 	if !t.generator.VariableExists(varName) {
 		t.addError(fmt.Sprintf("Variable '%s' no está declarada", varName))
@@ -1048,88 +1052,130 @@ func (t *ARM64Translator) translateBinaryExpression(ctx *compiler.BinaryExprCont
 		return
 	}
 
-	// Determinar si estamos comparando flotantes
-	leftIsFloat := t.isFloatExpression(ctx.GetLeft())
-	rightIsFloat := t.isFloatExpression(ctx.GetRight())
+	// Entre strings se concatena o se compara el contenido
+	if isStringType(t.exprType(ctx.GetLeft())) && isStringType(t.exprType(ctx.GetRight())) {
+		t.translateStringBinaryExpression(ctx, operator)
+		return
+	}
+
+	// Si algún operando es flotante, la operación es entre doubles
+	if t.exprType(ctx.GetLeft()) == "float" || t.exprType(ctx.GetRight()) == "float" {
+		t.translateFloatBinaryExpression(ctx, operator)
+		return
+	}
 
-	// Evaluar operando izquierdo y guardarlo en x1
+	// Evaluar operando izquierdo y guardarlo en un temporal, que sobrevive
+	// a la evaluación del derecho aunque tenga llamadas u otras operaciones
 	t.translateExpression(ctx.GetLeft())
-	t.generator.Comment("Mover operando izquierdo a x1")
-	t.generator.Emit("mov x1, x0")
+	temp := t.generator.SaveTemp(arm64.X0)
 
 	// Evaluar operando derecho (queda en X0)
 	t.translateExpression(ctx.GetRight())
-
-	// Si uno de los operandos es flotante, escalar el entero
-	if leftIsFloat && !rightIsFloat {
-		// Escalar operando derecho
-		t.generator.Comment("Escalar operando derecho para comparación con flotante")
-		t.generator.Emit("mov x2, #100")
-		t.generator.Mul(arm64.X0, arm64.X0, "x2")
-	} else if !leftIsFloat && rightIsFloat {
-		// Escalar operando izquierdo
-		t.generator.Comment("Escalar operando izquierdo para comparación con flotante")
-		t.generator.Emit("mov x2, #100")
-		t.generator.Mul(arm64.X1, arm64.X1, "x2")
-	}
+	left := t.generator.RestoreTemp(temp, arm64.X1)
 
 	// Realizar la operación correspondiente
 	switch operator {
 	case "+":
-		t.generator.Add(arm64.X0, arm64.X1, arm64.X0)
+		t.generator.Add(arm64.X0, left, arm64.X0)
 	case "-":
-		t.generator.Sub(arm64.X0, arm64.X1, arm64.X0)
+		t.generator.Sub(arm64.X0, left, arm64.X0)
 	case "*":
-		t.generator.Mul(arm64.X0, arm64.X1, arm64.X0)
+		t.generator.Mul(arm64.X0, left, arm64.X0)
 	case "/":
-		t.generator.Div(arm64.X0, arm64.X1, arm64.X0)
+		t.generator.Div(arm64.X0, left, arm64.X0)
 	case "%":
-		t.generator.Mod(arm64.X0, arm64.X1, arm64.X0)
+		t.generator.Mod(arm64.X0, left, arm64.X0)
 	case "==":
-		t.translateComparison(arm64.X1, arm64.X0, "eq")
+		t.translateComparison(left, arm64.X0, "eq")
 	case "!=":
-		t.translateComparison(arm64.X1, arm64.X0, "ne")
+		t.translateComparison(left, arm64.X0, "ne")
 	case "<":
-		t.translateComparison(arm64.X1, arm64.X0, "lt")
+		t.translateComparison(left, arm64.X0, "lt")
 	case ">":
-		t.translateComparison(arm64.X1, arm64.X0, "gt")
+		t.translateComparison(left, arm64.X0, "gt")
 	case "<=":
-		t.translateComparison(arm64.X1, arm64.X0, "le")
+		t.translateComparison(left, arm64.X0, "le")
 	case ">=":
-		t.translateComparison(arm64.X1, arm64.X0, "ge")
+		t.translateComparison(left, arm64.X0, "ge")
 	default:
 		t.addError(fmt.Sprintf("Operador no implementado: %s", operator))
 	}
 }
 
-func (t *ARM64Translator) isFloatExpression(expr antlr.ParseTree) bool {
-	if expr == nil {
-		return false
-	}
+// translateFloatBinaryExpression traduce una operación con al menos un
+// operando flotante: el otro se convierte con scvtf, la operación se hace
+// en d0 y d1, y el resultado queda en x0 como los bits del double (o 1/0
+// si es una comparación).
+func (t *ARM64Translator) translateFloatBinaryExpression(ctx *compiler.BinaryExprContext, operator string) {
+	// Evaluar operando izquierdo y guardarlo en un temporal
+	t.translateFloatOperand(ctx.GetLeft())
+	temp := t.generator.SaveTemp(arm64.X0)
 
-	switch ctx := expr.(type) {
-	case *compiler.FloatLiteralContext:
-		return true
-	case *compiler.LiteralExprContext:
-		return t.isFloatExpression(ctx.Literal())
-	case *compiler.LiteralContext:
-		// Verificar si contiene FloatLiteralContext
-		for i := 0; i < ctx.GetChildCount(); i++ {
-			if _, ok := ctx.GetChild(i).(*compiler.FloatLiteralContext); ok {
-				return true
-			}
-		}
-		return false
-	case *compiler.IdPatternExprContext:
-		// Verificar el tipo de la variable
-		varName := ctx.Id_pattern().GetText()
-		if varType, exists := t.variableTypes[varName]; exists {
-			return varType == "float"
-		}
-		return false
+	// Evaluar operando derecho y pasar ambos a registros d
+	t.translateFloatOperand(ctx.GetRight())
+	left := t.generator.RestoreTemp(temp, arm64.X1)
+	t.generator.Emit("fmov d1, x0")
+	t.generator.Emit(fmt.Sprintf("fmov d0, %s", left))
+
+	switch operator {
+	case "+":
+		t.generator.Emit("fadd d0, d0, d1")
+	case "-":
+		t.generator.Emit("fsub d0, d0, d1")
+	case "*":
+		t.generator.Emit("fmul d0, d0, d1")
+	case "/":
+		t.generator.Emit("fdiv d0, d0, d1")
+	case "==":
+		t.translateFloatComparison("eq")
+		return
+	case "!=":
+		t.translateFloatComparison("ne")
+		return
+	case "<":
+		// Después de fcmp, lt y le también son verdaderos con NaN
+		t.translateFloatComparison("mi")
+		return
+	case ">":
+		t.translateFloatComparison("gt")
+		return
+	case "<=":
+		t.translateFloatComparison("ls")
+		return
+	case ">=":
+		t.translateFloatComparison("ge")
+		return
 	default:
-		return false
+		t.addError(fmt.Sprintf("Operador no implementado para flotantes: %s", operator))
+	}
+	t.generator.Emit("fmov x0, d0")
+}
+
+func (t *ARM64Translator) translateFloatComparison(condition string) {
+	t.generator.Emit("fcmp d0, d1")
+	t.generator.Comment("Convertir resultado de comparación a 1/0")
+	t.generator.Emit(fmt.Sprintf("cset %s, %s", arm64.X0, condition))
+}
+
+// translateFloatOperand evalúa expr y deja en x0 los bits de su valor como
+// double; un entero se convierte con scvtf.
+func (t *ARM64Translator) translateFloatOperand(expr antlr.ParseTree) {
+	t.translateExpression(expr)
+	if t.exprType(expr) != "float" {
+		t.generator.Comment("Convertir entero a flotante")
+		t.generator.Emit("scvtf d0, x0")
+		t.generator.Emit("fmov x0, d0")
+	}
+}
+
+// translateValue evalúa expr para guardarla donde se espera un valor de
+// tipo targetType: un entero que va a un float se convierte.
+func (t *ARM64Translator) translateValue(expr antlr.ParseTree, targetType string) {
+	if targetType == "float" {
+		t.translateFloatOperand(expr)
+		return
 	}
+	t.translateExpression(expr)
 }
 
 func (t *ARM64Translator) translateLogicalAnd(ctx *compiler.BinaryExprContext) {
@@ -1229,6 +1275,8 @@ func (t *ARM64Translator) translateFunctionCall(ctx *compiler.FuncCallContext) {
 		// AGREGAR: Verificar si es función de usuario
 		if funcDecl, exists := t.userFunctions[funcName]; exists {
 			t.translateUserFunctionCall(ctx, funcDecl)
+		} else if strings.Contains(funcName, ".") {
+			t.translateVectorMethod(ctx)
 		} else {
 			// Manejar funciones nativas simuladas
 			t.translateNativeFunction(ctx)
@@ -1249,7 +1297,7 @@ func (t *ARM64Translator) translatePrintBoolFunction(ctx *compiler.FuncCallConte
 					// Evaluar la expresión
 					t.translateExpression(argCtx.Expression())
 					// Llamar a print_bool
-					t.generator.CallFunction("print_bool")
+					t.callRuntime("print_bool")
 				}
 			}
 		}
@@ -1261,96 +1309,98 @@ func (t *ARM64Translator) translateUserFunctionCall(callCtx *compiler.FuncCallCo
 	funcName := callCtx.Id_pattern().GetText()
 	t.generator.Comment(fmt.Sprintf("=== LLAMADA A FUNCIÓN DE USUARIO: %s ===", funcName))
 
-	// Obtener información de parámetros de la función
-	var paramNames []string
-	if funcDecl.Param_list() != nil {
-		params := funcDecl.Param_list().(*compiler.ParamListContext).AllFunc_param()
-		for _, param := range params {
-			if paramCtx := param.(*compiler.FuncParamContext); paramCtx.ID() != nil {
-				paramNames = append(paramNames, paramCtx.ID().GetText())
-			}
-		}
-	}
-
-	// Preparar argumentos - CARGAR EN ORDEN INVERSO
+	_, paramTypes := functionParams(funcDecl)
+	var args []compiler.IFunc_argContext
 	if callCtx.Arg_list() != nil {
-		args := callCtx.Arg_list().(*compiler.ArgListContext).AllFunc_arg()
-
-		// Debug: mostrar argumentos
-		fmt.Printf("🔍 Argumentos para %s: %d\n", funcName, len(args))
-		for i, arg := range args {
-			fmt.Printf("🔍 Arg %d: %s\n", i, arg.GetText())
-		}
-
-		// CARGAR ARGUMENTOS EN ORDEN INVERSO PARA NO SOBRESCRIBIR
-		for i := len(args) - 1; i >= 0; i-- {
-			arg := args[i]
-			if argCtx := arg.(*compiler.FuncArgContext); argCtx != nil {
-
-				targetReg := fmt.Sprintf("x%d", i)
-				t.generator.Comment(fmt.Sprintf("Cargando argumento %d (%s) en %s", i, argCtx.GetText(), targetReg))
-
-				// NUEVO: Determinar el tipo del argumento que se está pasando
-				var argType string
-
-				// Evaluar el argumento
-				if argCtx.Expression() != nil {
-					// Inferir tipo de la expresión ANTES de evaluarla
-					argType = t.inferExpressionType(argCtx.Expression())
-					t.translateExpression(argCtx.Expression())
-				} else if argCtx.Id_pattern() != nil {
-					// Es una variable
-					varName := argCtx.Id_pattern().GetText()
-					if t.generator.VariableExists(varName) {
-						// Obtener tipo de la variable
-						if varType, exists := t.variableTypes[varName]; exists {
-							argType = varType
-						} else {
-							argType = "unknown"
-						}
-						t.generator.LoadVariable(arm64.X0, varName)
-					} else {
-						t.addError(fmt.Sprintf("Variable '%s' no encontrada", varName))
-						t.generator.LoadImmediate(arm64.X0, 0)
-						argType = "int"
-					}
+		args = callCtx.Arg_list().(*compiler.ArgListContext).AllFunc_arg()
+		t.Log.Debug("argumentos de llamada", "func", funcName, "count", len(args))
+	}
+
+	// Ubicar los argumentos según AAPCS64; los que no entran en registros
+	// van en un área del stack que se reserva antes de evaluarlos
+	floats := make([]bool, len(args))
+	copy(floats, floatTypes(paramTypes))
+	locations, stackSize := arm64.AssignArguments(floats)
+	if stackSize > 0 {
+		t.generator.Comment(fmt.Sprintf("Reservar %d bytes para argumentos en el stack", stackSize))
+		t.generator.Emit(fmt.Sprintf("sub sp, sp, #%d", stackSize))
+	}
+	stackTemps := t.generator.StackTemps()
+
+	// Preparar argumentos: cada uno se evalúa en orden y queda en un
+	// temporal, porque evaluar los siguientes puede pisar x0-x7
+	var temps []arm64.Temp
+	for i, arg := range args {
+		if argCtx := arg.(*compiler.FuncArgContext); argCtx != nil {
+			t.generator.Comment(fmt.Sprintf("Evaluando argumento %d (%s)", i, argCtx.GetText()))
+
+			// Evaluar el argumento
+			if argCtx.Expression() != nil {
+				paramType := ""
+				if i < len(paramTypes) {
+					paramType = paramTypes[i]
+				}
+				t.translateValue(argCtx.Expression(), paramType)
+			} else if argCtx.Id_pattern() != nil {
+				// Es una variable
+				varName := argCtx.Id_pattern().GetText()
+				if t.generator.VariableExists(varName) || strings.Contains(varName, ".") {
+					t.translateIdPattern(argCtx.Id_pattern())
 				} else {
-					// Fallback: intentar como texto
-					argText := argCtx.GetText()
-					if t.generator.VariableExists(argText) {
-						if varType, exists := t.variableTypes[argText]; exists {
-							argType = varType
-						} else {
-							argType = "unknown"
-						}
-						t.generator.LoadVariable(arm64.X0, argText)
-					} else if value, err := strconv.Atoi(argText); err == nil {
-						t.generator.LoadImmediate(arm64.X0, value)
-						argType = "int"
-					} else {
-						t.addError(fmt.Sprintf("No se puede procesar argumento: %s", argText))
-						t.generator.LoadImmediate(arm64.X0, 0)
-						argType = "int"
-					}
+					t.addError(fmt.Sprintf("Variable '%s' no encontrada", varName))
+					t.generator.LoadImmediate(arm64.X0, 0)
 				}
-
-				// NUEVO: Asignar tipo al parámetro correspondiente
-				if i < len(paramNames) {
-					paramName := paramNames[i]
-					t.variableTypes[paramName] = argType
-					fmt.Printf("📝 Parámetro '%s' asignado tipo: %s\n", paramName, argType)
+			} else {
+				// Fallback: intentar como texto
+				argText := argCtx.GetText()
+				if t.generator.VariableExists(argText) {
+					t.generator.LoadVariable(arm64.X0, argText)
+				} else if value, err := strconv.Atoi(argText); err == nil {
+					t.generator.LoadImmediate(arm64.X0, value)
+				} else {
+					t.addError(fmt.Sprintf("No se puede procesar argumento: %s", argText))
+					t.generator.LoadImmediate(arm64.X0, 0)
 				}
+			}
 
-				// Mover al registro correcto (solo si no es x0)
-				if i != 0 {
-					t.generator.Emit(fmt.Sprintf("mov %s, x0", targetReg))
-				}
+			if locations[i].Register == "" {
+				// los temporales apilados desde la reserva corren el área
+				offset := locations[i].Offset + 16*(t.generator.StackTemps()-stackTemps)
+				t.generator.Emit(fmt.Sprintf("str x0, [sp, #%d]", offset))
+				continue
 			}
+			temps = append(temps, t.generator.SaveTemp(arm64.X0))
 		}
 	}
 
-	// Llamar a la función
+	// Pasar los temporales a sus registros, del último al primero. Los
+	// float se pasan con sus bits a d0-d7
+	var registers []string
+	for _, location := range locations {
+		if location.Register != "" {
+			registers = append(registers, location.Register)
+		}
+	}
+	for i := len(temps) - 1; i >= 0; i-- {
+		targetReg := registers[i]
+		if strings.HasPrefix(targetReg, "d") {
+			source := t.generator.RestoreTemp(temps[i], arm64.X9)
+			t.generator.Emit(fmt.Sprintf("fmov %s, %s", targetReg, source))
+			continue
+		}
+		if source := t.generator.RestoreTemp(temps[i], targetReg); source != targetReg {
+			t.generator.Emit(fmt.Sprintf("mov %s, %s", targetReg, source))
+		}
+	}
+
+	// Llamar a la función; un float vuelve en d0
 	t.generator.CallFunction(fmt.Sprintf("func_%s", funcName))
+	if stackSize > 0 {
+		t.generator.Emit(fmt.Sprintf("add sp, sp, #%d", stackSize))
+	}
+	if funcDecl.Type_() != nil && funcDecl.Type_().GetText() == "float" {
+		t.generator.Emit("fmov x0, d0")
+	}
 }
 
 func (t *ARM64Translator) translateNativeFunction(ctx *compiler.FuncCallContext) {
@@ -1358,225 +1408,146 @@ func (t *ARM64Translator) translateNativeFunction(ctx *compiler.FuncCallContext)
 
 	switch funcName {
 	case "atoi":
-		// Simular atoi - por simplicidad retornar valor fijo
-		if ctx.Arg_list() != nil {
-			args := ctx.Arg_list().(*compiler.ArgListContext).AllFunc_arg()
-			if len(args) > 0 {
-				// Por simplicidad, si el string es "123", retornar 123
-				t.generator.LoadImmediate(arm64.X0, 123)
-			}
-		}
-	case "parse_float":
-		// Simular parse_float
-		t.generator.LoadImmediate(arm64.X0, 123) // Simplificado
+		t.translateAtoi(ctx)
+	case "parseFloat":
+		t.translateParseFloat(ctx)
+	case "join":
+		t.translateJoin(ctx)
+	case "indexOf":
+		t.translateIndexOf(ctx)
 	case "TypeOf", "Type":
-		// Simular TypeOf - retornar código que representa tipo
-		t.generator.LoadImmediate(arm64.X0, 1) // 1=int, 2=float, etc.
+		t.translateTypeOf(ctx)
+	case "len":
+		t.translateLen(ctx)
+	case "append":
+		t.translateAppend(ctx)
 	default:
 		t.addError(fmt.Sprintf("Función no implementada: %s", funcName))
 		t.generator.LoadImmediate(arm64.X0, 0)
 	}
 }
 
-// 🔥 CORREGIR FUNCIÓN PRINT - EL PROBLEMA PRINCIPAL ESTÁ AQUÍ
+// translatePrintFunction traduce print y println: cada argumento se
+// imprime con la función de la librería que corresponde a su tipo, separado
+// del anterior por un espacio. Como en el intérprete, todos los argumentos
+// se evalúan antes de imprimir el primero, así que si son varios se guardan
+// en el stack.
 func (t *ARM64Translator) translatePrintFunction(ctx *compiler.FuncCallContext, withNewline bool) {
 	t.generator.Comment("=== FUNCIÓN PRINT ===")
 
-	if ctx.Arg_list() != nil {
-		args := ctx.Arg_list().(*compiler.ArgListContext).AllFunc_arg()
-
+	args := funcArgs(ctx)
+	if len(args) == 1 {
+		t.translatePrintArgument(args[0])
+		t.printValue(t.getArgumentType(args[0]), args[0].GetText())
+	} else if len(args) > 1 {
+		for _, arg := range args {
+			t.translatePrintArgument(arg)
+			t.generator.Push(arm64.X0)
+		}
 		for i, arg := range args {
 			if i > 0 {
-				// Imprimir espacio entre argumentos
 				t.generator.Comment("Imprimir espacio")
 				t.generator.LoadImmediate(arm64.X0, 32) // ASCII espacio
-				t.generator.CallFunction("print_char")
-			}
-
-			if argCtx := arg.(*compiler.FuncArgContext); argCtx != nil {
-				if argCtx.Expression() != nil {
-					// NUEVA LÓGICA: Detectar tipo de expresión MEJORADA
-					exprText := argCtx.Expression().GetText()
-
-					// VERIFICAR EXPLÍCITAMENTE SI ES BOOLEANO
-					if t.isBooleanExpression(argCtx.Expression()) {
-						// Es un booleano - usar print_bool
-						t.generator.Comment(fmt.Sprintf("Imprimiendo booleano: %s", exprText))
-						t.translateExpression(argCtx.Expression())
-						t.generator.CallFunction("print_bool")
-					} else if strings.HasPrefix(exprText, "\"") && strings.HasSuffix(exprText, "\"") {
-						// Es un string literal directo
-						t.generator.Comment(fmt.Sprintf("Imprimiendo string literal: %s", exprText))
-						t.translateExpression(argCtx.Expression())
-						t.generator.CallFunction("print_string")
-					} else {
-						// Verificar tipo de variable si es una variable
-						argType := t.getArgumentType(argCtx)
-						if argType == "string" {
-							t.generator.Comment(fmt.Sprintf("Imprimiendo string: %s", exprText))
-							t.translateExpression(argCtx.Expression())
-							t.generator.CallFunction("print_string")
-						} else if argType == "bool" {
-							t.generator.Comment(fmt.Sprintf("Imprimiendo variable booleana: %s", exprText))
-							t.translateExpression(argCtx.Expression())
-							t.generator.CallFunction("print_bool")
-						} else {
-							// Es una expresión numérica
-							t.generator.Comment(fmt.Sprintf("Imprimiendo valor numérico: %s", exprText))
-							t.translateExpression(argCtx.Expression())
-							t.generator.CallFunction("print_integer")
-						}
-					}
-				} else if argCtx.Id_pattern() != nil {
-					// Variable directa - determinar tipo
-					varName := argCtx.Id_pattern().GetText()
-					if t.generator.VariableExists(varName) {
-						t.generator.LoadVariable(arm64.X0, varName)
-
-						// Determinar qué función usar según el tipo
-						if varType, exists := t.variableTypes[varName]; exists {
-							switch varType {
-							case "bool":
-								t.generator.Comment(fmt.Sprintf("Imprimiendo variable booleana: %s", varName))
-								t.generator.CallFunction("print_bool")
-							case "string":
-								t.generator.Comment(fmt.Sprintf("Imprimiendo variable string: %s", varName))
-								t.generator.CallFunction("print_string")
-							default:
-								t.generator.Comment(fmt.Sprintf("Imprimiendo variable numérica: %s", varName))
-								t.generator.CallFunction("print_integer")
-							}
-						} else {
-							t.generator.Comment(fmt.Sprintf("Imprimiendo variable (tipo desconocido): %s", varName))
-							t.generator.CallFunction("print_integer")
-						}
-					} else {
-						t.addError(fmt.Sprintf("Variable '%s' no encontrada", varName))
-					}
-				}
+				t.callRuntime("print_char")
 			}
+			t.generator.Emit(fmt.Sprintf("ldr x0, [sp, #%d]", 16*(len(args)-1-i)))
+			t.printValue(t.getArgumentType(arg), arg.GetText())
 		}
+		t.generator.Emit(fmt.Sprintf("add sp, sp, #%d", 16*len(args)))
 	}
 
 	if withNewline {
 		t.generator.Comment("Imprimir salto de línea")
 		t.generator.LoadImmediate(arm64.X0, 10) // ASCII newline
-		t.generator.CallFunction("print_char")
+		t.callRuntime("print_char")
 	}
 }
 
-func (t *ARM64Translator) isBooleanExpression(expr antlr.ParseTree) bool {
-	if expr == nil {
-		return false
+// translatePrintArgument deja en x0 el valor de un argumento de print.
+func (t *ARM64Translator) translatePrintArgument(arg *compiler.FuncArgContext) {
+	if arg.Expression() != nil {
+		t.translateExpression(arg.Expression())
+		return
 	}
-
-	switch ctx := expr.(type) {
-	case *compiler.BoolLiteralContext:
-		return true
-	case *compiler.LiteralExprContext:
-		return t.isBooleanExpression(ctx.Literal())
-	case *compiler.LiteralContext:
-		// Verificar si contiene BoolLiteralContext
-		for i := 0; i < ctx.GetChildCount(); i++ {
-			if _, ok := ctx.GetChild(i).(*compiler.BoolLiteralContext); ok {
-				return true
-			}
-		}
-		// AGREGAR: También verificar por texto específico
-		text := ctx.GetText()
-		if text == "true" || text == "false" {
-			return true
-		}
-		return false
-	case *compiler.BinaryExprContext:
-		// Operaciones de comparación devuelven booleanos
-		operator := ctx.GetOp().GetText()
-		switch operator {
-		case "==", "!=", "<", ">", "<=", ">=", "&&", "||":
-			return true
-		}
-		return false
-	case *compiler.UnaryExprContext:
-		// El operador ! devuelve booleano
-		for i := 0; i < ctx.GetChildCount(); i++ {
-			if terminal, ok := ctx.GetChild(i).(antlr.TerminalNode); ok {
-				if terminal.GetText() == "!" {
-					return true
-				}
-			}
-		}
-		return false
-	case *compiler.IdPatternExprContext:
-		// Verificar el tipo de la variable
-		varName := ctx.Id_pattern().GetText()
-		if varType, exists := t.variableTypes[varName]; exists {
-			return varType == "bool"
-		}
-		return false
+	varName := arg.Id_pattern().GetText()
+	if !t.generator.VariableExists(varName) && !strings.Contains(varName, ".") {
+		t.addError(fmt.Sprintf("Variable '%s' no encontrada", varName))
+		return
+	}
+	t.translateIdPattern(arg.Id_pattern())
+}
+
+// printValue imprime el valor de tipo typ que está en x0. text es el
+// argumento tal como está en el programa, para el comentario.
+func (t *ARM64Translator) printValue(typ, text string) {
+	t.generator.Comment(fmt.Sprintf("Imprimiendo %s", text))
+	switch {
+	case typ == "bool":
+		t.callRuntime("print_bool")
+	case isStringType(typ):
+		t.callRuntime("print_string")
+	case typ == "float":
+		t.callPrintFloat(printDecimals)
+	case vectorLevels(typ) > 0:
+		t.callPrintVector(typ)
+	case t.structs[typ] != nil:
+		t.callPrintStruct(typ)
 	default:
-		// CASO ESPECIAL: Si el texto completo es "true" o "false"
-		text := expr.GetText()
-		return text == "true" || text == "false"
+		t.callRuntime("print_integer")
 	}
 }
 
+// Decimales con los que se imprimen los flotantes: print y println usan
+// cuatro, como el intérprete, y la interpolación la precisión completa.
+const (
+	printDecimals         = 4
+	interpolationDecimals = -1
+)
+
+// callPrintFloat imprime el double cuyos bits están en x0.
+func (t *ARM64Translator) callPrintFloat(decimals int) {
+	t.generator.Emit("fmov d0, x0")
+	t.generator.LoadImmediate(arm64.X1, decimals)
+	t.callRuntime("print_float")
+}
+
 // Determinar tipo de argumento
 func (t *ARM64Translator) getArgumentType(argCtx *compiler.FuncArgContext) string {
 	if argCtx.Expression() != nil {
-		exprText := argCtx.Expression().GetText()
-
-		// VERIFICAR EXPLÍCITAMENTE BOOLEANOS
-		if exprText == "true" || exprText == "false" {
-			return "bool"
-		}
-
-		// VERIFICAR STRINGS
-		if strings.HasPrefix(exprText, "\"") && strings.HasSuffix(exprText, "\"") {
-			return "string"
-		}
-
-		// USAR INFERENCIA DE TIPO
-		return t.inferExpressionType(argCtx.Expression())
-	} else if argCtx.Id_pattern() != nil {
-		varName := argCtx.Id_pattern().GetText()
-		if varType, exists := t.variableTypes[varName]; exists {
-			return varType
-		}
+		return t.exprType(argCtx.Expression())
 	}
-	return "unknown"
+	return t.exprType(argCtx.Id_pattern())
 }
 
 // === CONTROL DE FLUJO (simplificado) ===
 
-// translateIfStatement traduce declaraciones if-else
+// translateIfStatement traduce declaraciones if, else if y else. Cada
+// condición falsa salta a la siguiente, y la última al else si lo hay.
 func (t *ARM64Translator) translateIfStatement(ctx *compiler.IfStmtContext) {
 	t.generator.Comment("=== IF STATEMENT ===")
 
-	elseLabel := t.generator.GetLabel()
 	endLabel := t.generator.GetLabel()
 
-	// Evaluar la condición del primer if_chain
-	if len(ctx.AllIf_chain()) > 0 {
-		ifChain := ctx.AllIf_chain()[0]
-		if ifChainCtx, ok := ifChain.(*compiler.IfChainContext); ok {
-			// Evaluar condición
-			t.translateExpression(ifChainCtx.Expression())
+	for _, ifChain := range ctx.AllIf_chain() {
+		ifChainCtx, ok := ifChain.(*compiler.IfChainContext)
+		if !ok {
+			continue
+		}
+		nextLabel := t.generator.GetLabel()
 
-			// Saltar a else si la condición es falsa (0)
-			t.generator.JumpIfZero(arm64.X0, elseLabel)
+		// Evaluar condición y saltar a la siguiente si es falsa (0)
+		t.translateExpression(ifChainCtx.Expression())
+		t.generator.JumpIfZero(arm64.X0, nextLabel)
 
-			// Ejecutar cuerpo del if
-			for _, stmt := range ifChainCtx.AllStmt() {
-				t.translateNode(stmt)
-			}
-
-			// Saltar al final para evitar ejecutar el else
-			t.generator.Jump(endLabel)
+		// Ejecutar cuerpo de la rama
+		for _, stmt := range ifChainCtx.AllStmt() {
+			t.translateNode(stmt)
 		}
-	}
 
-	// Etiqueta else
-	t.generator.SetLabel(elseLabel)
+		// Saltar al final para evitar ejecutar las demás ramas
+		t.generator.Jump(endLabel)
+		t.generator.SetLabel(nextLabel)
+	}
 
 	// Si hay else, ejecutarlo
 	if ctx.Else_stmt() != nil {
@@ -1594,10 +1565,12 @@ func (t *ARM64Translator) translateIfStatement(ctx *compiler.IfStmtContext) {
 func (t *ARM64Translator) translateSwitchStatement(ctx *compiler.SwitchStmtContext) {
 	t.generator.Comment("=== SWITCH STATEMENT ===")
 
-	// Evaluar la expresión del switch una vez y guardarla
+	// Evaluar la expresión del switch una vez y guardarla en un temporal
+	// mientras se evalúan los casos. Entre sentencias no hay temporales
+	// vivos, así que siempre toca un registro y los saltos a los casos no
+	// dejan nada en el stack.
 	t.translateExpression(ctx.Expression())
-	t.generator.Comment("Guardar valor del switch en x19")
-	t.generator.Emit("mov x19, x0")
+	temp := t.generator.SaveTemp(arm64.X0)
 
 	// Generar etiquetas
 	defaultLabel := t.generator.GetLabel()
@@ -1623,11 +1596,19 @@ func (t *ARM64Translator) translateSwitchStatement(ctx *compiler.SwitchStmtConte
 			// Evaluar la expresión del caso
 			t.translateExpression(caseCtx.Expression())
 
-			// Comparar con el valor del switch
-			t.generator.Compare("x19", "x0")
+			// Comparar con el valor del switch; los strings por contenido
+			if isStringType(t.exprType(ctx.Expression())) {
+				t.generator.Emit("mov x1, x0")
+				t.generator.Emit(fmt.Sprintf("mov x0, %s", temp.Register))
+				t.callRuntime("str_compare")
+				t.generator.Emit(fmt.Sprintf("cbz x0, %s", caseLabels[i]))
+				continue
+			}
+			t.generator.Compare(temp.Register, arm64.X0)
 			t.generator.Emit(fmt.Sprintf("beq %s", caseLabels[i]))
 		}
 	}
+	t.generator.RestoreTemp(temp, arm64.X1)
 
 	// Si ningún caso coincide, ir al default (o al final si no hay default)
 	if ctx.Default_case() != nil {
@@ -1764,8 +1745,8 @@ func (t *ARM64Translator) translateIncrement(ctx *compiler.IncrementoContext) {
 
 	t.generator.LoadVariable(arm64.X0, varName)
 	t.generator.Comment(fmt.Sprintf("Incrementar '%s'", varName))
-	t.generator.Emit("add x0, x0, #1")
-	t.generator.StoreVariable(arm64.X0, varName)
+	t.stepVariable(ctx, "add")
+	t.generator.StoreVariable(arm64.X1, varName)
 }
 
 func (t *ARM64Translator) translateDecrement(ctx *compiler.DecrementoContext) {
@@ -1773,8 +1754,22 @@ func (t *ARM64Translator) translateDecrement(ctx *compiler.DecrementoContext) {
 
 	t.generator.LoadVariable(arm64.X0, varName)
 	t.generator.Comment(fmt.Sprintf("Decrementar '%s'", varName))
-	t.generator.Emit("sub x0, x0, #1")
-	t.generator.StoreVariable(arm64.X0, varName)
+	t.stepVariable(ctx, "sub")
+	t.generator.StoreVariable(arm64.X1, varName)
+}
+
+// stepVariable deja en x1 el valor de x0 más o menos 1 según el tipo de la
+// expresión x++ o x--; op es "add" o "sub". x0 conserva el valor anterior,
+// que es el resultado de la expresión como en el intérprete.
+func (t *ARM64Translator) stepVariable(expr antlr.ParseTree, op string) {
+	if t.exprType(expr) == "float" {
+		t.generator.Emit("fmov d0, x0")
+		t.generator.Emit("fmov d1, #1.0")
+		t.generator.Emit(fmt.Sprintf("f%s d1, d0, d1", op))
+		t.generator.Emit("fmov x1, d1")
+		return
+	}
+	t.generator.Emit(fmt.Sprintf("%s x1, x0, #1", op))
 }
 
 // ====================================
@@ -1801,286 +1796,6 @@ func (t *ARM64Translator) translateContinueStatement(ctx *compiler.ContinueStmtC
 	t.generator.Jump(label)
 }
 
-// ====================================
-// Proceso de interpolacion
-// ====================================
-func (t *ARM64Translator) processStringInterpolation(text string) {
-	t.generator.Comment("=== INTERPOLACIÓN DE STRING ===")
-
-	// Dividir el string en partes: texto y variables
-	parts := t.parseInterpolatedString(text)
-
-	for _, part := range parts { // ← Quitar la variable i
-		if part.IsVariable {
-			// Es una variable - cargar y imprimir según su tipo
-			varName := part.Content
-			if t.generator.VariableExists(varName) {
-				t.generator.Comment(fmt.Sprintf("Interpolando variable: %s", varName))
-				t.generator.LoadVariable(arm64.X0, varName)
-
-				// Determinar tipo y llamar función apropiada
-				if varType, exists := t.variableTypes[varName]; exists {
-					switch varType {
-					case "bool":
-						t.generator.CallFunction("print_bool")
-					case "string":
-						t.generator.CallFunction("print_string")
-					default:
-						t.generator.CallFunction("print_integer")
-					}
-				} else {
-					t.generator.CallFunction("print_integer")
-				}
-			} else {
-				t.addError(fmt.Sprintf("Variable '%s' no encontrada en interpolación", varName))
-			}
-		} else {
-			// Es texto literal - crear string y imprimir
-			if part.Content != "" {
-				t.generator.Comment(fmt.Sprintf("Interpolando texto: \"%s\"", part.Content))
-
-				// VERIFICAR si ya existe en el registro
-				if existingLabel, exists := t.stringRegistry[part.Content]; exists {
-					t.generator.Emit(fmt.Sprintf("adr x0, %s", existingLabel))
-					t.generator.CallFunction("print_string")
-				} else {
-					// Si no existe, reportar error
-					t.addError(fmt.Sprintf("Parte interpolada \"%s\" no fue registrada en primera pasada", part.Content))
-				}
-			}
-		}
-	}
-}
-
-// Estructura para las partes del string:
-type InterpolationPart struct {
-	Content    string
-	IsVariable bool
-}
-
-func (t *ARM64Translator) parseInterpolatedString(text string) []InterpolationPart {
-	var parts []InterpolationPart
-	var currentPart strings.Builder
-
-	i := 0
-	for i < len(text) {
-		if text[i] == '$' && i+1 < len(text) {
-			// Guardar texto anterior si existe
-			if currentPart.Len() > 0 {
-				parts = append(parts, InterpolationPart{
-					Content:    currentPart.String(),
-					IsVariable: false,
-				})
-				currentPart.Reset()
-			}
-
-			// Saltar el '$'
-			i++
-
-			// Extraer nombre de variable
-			varStart := i
-			for i < len(text) && (isLetter(text[i]) || isDigit(text[i]) || text[i] == '_') {
-				i++
-			}
-
-			if i > varStart {
-				varName := text[varStart:i]
-				parts = append(parts, InterpolationPart{
-					Content:    varName,
-					IsVariable: true,
-				})
-			}
-			// i ya está en la posición correcta
-		} else {
-			currentPart.WriteByte(text[i])
-			i++
-		}
-	}
-
-	// Agregar última parte si existe
-	if currentPart.Len() > 0 {
-		parts = append(parts, InterpolationPart{
-			Content:    currentPart.String(),
-			IsVariable: false,
-		})
-	}
-
-	return parts
-}
-
-func isLetter(c byte) bool {
-	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
-}
-
-func isDigit(c byte) bool {
-	return c >= '0' && c <= '9'
-}
-
-// === LIBRERÍA ESTÁNDAR ===
-
-// generateStandardLibrary genera las funciones básicas necesarias
-// === FUNCIÓN generateStandardLibrary COMPLETA ===
-// REEMPLAZAR COMPLETAMENTE tu función generateStandardLibrary actual
-
-func (t *ARM64Translator) generateStandardLibrary() {
-	// FUNCIÓN print_integer
-	t.generator.EmitRaw(`
-print_integer:
-    // Función simplificada para imprimir enteros
-    // Input: x0 = número a imprimir
-    stp x29, x30, [sp, #-16]!    // Guardar registros
-    stp x19, x20, [sp, #-16]!
-
-    mov x19, x0                   // Guardar número original
-
-    // Manejar caso especial: cero
-    cmp x19, #0
-    bne convert_digits
-
-    // Imprimir '0'
-    mov x0, #48                   // ASCII '0'
-    bl print_char
-    b print_done
-
-convert_digits:
-    // Buffer para dígitos (en el stack)
-    sub sp, sp, #32
-    mov x20, sp                   // x20 = puntero al buffer
-    mov x21, #0                   // x21 = contador de dígitos
-
-    // Manejar números negativos
-    cmp x19, #0
-    bge positive
-    mov x0, #45                   // ASCII '-'
-    bl print_char
-    neg x19, x19                  // Hacer positivo
-
-positive:
-    // Convertir dígitos
-digit_loop:
-    mov x22, #10
-    udiv x23, x19, x22           // x23 = x19 / 10
-    msub x24, x23, x22, x19      // x24 = x19 % 10
-
-    add x24, x24, #48            // Convertir a ASCII
-    strb w24, [x20, x21]         // Guardar dígito
-    add x21, x21, #1             // Incrementar contador
-
-    mov x19, x23                 // x19 = quotient
-    cbnz x19, digit_loop         // Continuar si no es cero
-
-    // Imprimir dígitos en orden inverso
-print_digits:
-    sub x21, x21, #1
-    ldrb w0, [x20, x21]
-    bl print_char
-    cbnz x21, print_digits
-
-    add sp, sp, #32              // Limpiar buffer
-
-print_done:
-    ldp x19, x20, [sp], #16      // Restaurar registros
-    ldp x29, x30, [sp], #16
-    ret`)
-
-	// FUNCIÓN print_char
-	t.generator.EmitRaw(`
-print_char:
-    // Imprimir un carácter
-    // Input: x0 = carácter ASCII
-    stp x29, x30, [sp, #-16]!
-
-    // Crear buffer temporal en el stack
-    sub sp, sp, #16
-    strb w0, [sp]                // Guardar carácter
-
-    // Syscall write
-    mov x0, #1                   // stdout
-    mov x1, sp                   // buffer
-    mov x2, #1                   // length
-    mov x8, #64                  // write syscall
-    svc #0
-
-    add sp, sp, #16              // Limpiar buffer
-    ldp x29, x30, [sp], #16
-    ret`)
-
-	// FUNCIÓN print_string
-	t.generator.EmitRaw(`
-print_string:
-    // Función para imprimir strings
-    // Input: x0 = dirección del string (terminado en null)
-    stp x29, x30, [sp, #-16]!    // Guardar registros
-    stp x19, x20, [sp, #-16]!
-
-    mov x19, x0                   // x19 = dirección del string
-
-    // Encontrar la longitud del string
-    mov x20, #0                   // x20 = contador de longitud
-
-strlen_loop:
-    ldrb w1, [x19, x20]          // Cargar byte del string
-    cbz w1, strlen_done          // Si es 0 (null terminator), terminar
-    add x20, x20, #1             // Incrementar contador
-    b strlen_loop
-
-strlen_done:
-    // Verificar si el string está vacío
-    cbz x20, print_string_done
-
-    // Syscall write(1, string, length)
-    mov x0, #1                   // File descriptor: stdout
-    mov x1, x19                  // Buffer: dirección del string
-    mov x2, x20                  // Length: longitud calculada
-    mov x8, #64                  // Syscall number: write
-    svc #0                       // Llamada al sistema
-
-print_string_done:
-    ldp x19, x20, [sp], #16      // Restaurar registros
-    ldp x29, x30, [sp], #16
-    ret`)
-
-	// FUNCIÓN print_bool
-	t.generator.EmitRaw(`
-print_bool:
-    // Función para imprimir valores booleanos
-    // Input: x0 = valor booleano (0=false, cualquier otra cosa=true)
-    stp x29, x30, [sp, #-16]!    // Guardar registros
-
-    // Verificar si es true o false
-    cmp x0, #0
-    beq print_false_simple
-
-print_true_simple:
-    // Imprimir "true" manualmente
-    mov x0, #116  // 't'
-    bl print_char
-    mov x0, #114  // 'r'
-    bl print_char
-    mov x0, #117  // 'u'
-    bl print_char
-    mov x0, #101  // 'e'
-    bl print_char
-    b print_bool_simple_done
-
-print_false_simple:
-    // Imprimir "false" manualmente
-    mov x0, #102  // 'f'
-    bl print_char
-    mov x0, #97   // 'a'
-    bl print_char
-    mov x0, #108  // 'l'
-    bl print_char
-    mov x0, #115  // 's'
-    bl print_char
-    mov x0, #101  // 'e'
-    bl print_char
-
-print_bool_simple_done:
-    ldp x29, x30, [sp], #16      // Restaurar registros
-    ret`)
-}
-
 // === UTILIDADES ===
 
 // addError agrega un error a la lista
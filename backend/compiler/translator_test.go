@@ -0,0 +1,446 @@
+package compiler_test
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/compiler"
+	"main.go/compiler/emulator"
+	"main.go/compiler/ir"
+	"main.go/difftest"
+	interpeter "main.go/grammar"
+)
+
+// Traductores que se comparan con el intérprete.
+var (
+	viaIR = translator{"IR", func(tree antlr.ParseTree) (string, []string) {
+		assembly, errors, _ := compiler.Compile(tree, "", ir.O0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		return assembly, errors
+	}}
+	optimized = translator{"IR -O1", func(tree antlr.ParseTree) (string, []string) {
+		assembly, errors, _ := compiler.Compile(tree, "", ir.O1, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		return assembly, errors
+	}}
+	direct = translator{"directo", func(tree antlr.ParseTree) (string, []string) {
+		return compiler.NewARM64Translator().TranslateProgram(tree)
+	}}
+)
+
+type translator struct {
+	name      string
+	translate difftest.Translator
+}
+
+// compare traduce code por el IR, sin optimizar y optimizado, y con el
+// traductor directo, lo ejecuta en el emulador y exige que imprima lo mismo
+// que el intérprete.
+func compare(t *testing.T, code string) {
+	t.Helper()
+	compareWith(t, code, viaIR, optimized, direct)
+}
+
+func compareWith(t *testing.T, code string, translators ...translator) {
+	t.Helper()
+	for _, tr := range translators {
+		harness := difftest.NewHarness(difftest.Emulate)
+		harness.Translate = tr.translate
+		result := harness.Compare(code)
+		if result.Status != difftest.Match {
+			t.Fatalf("%s (%s): %s\n%s\nintérprete:\n%s\nbinario:\n%s", result.Status, tr.name, result.Reason, code, result.Interpreted, result.Compiled)
+		}
+	}
+}
+
+func translate(t *testing.T, code string) string {
+	t.Helper()
+	lexer := interpeter.NewVLangLexer(antlr.NewInputStream(code))
+	parser := interpeter.NewVLangGrammar(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	assembly, errors := compiler.NewARM64Translator().TranslateProgram(parser.Program())
+	if len(errors) > 0 {
+		t.Fatalf("errores de traducción: %v", errors)
+	}
+	return assembly
+}
+
+// nested arma una expresión anidada por la derecha con depth operadores:
+// 1 + (b * (3 - (a + ...))), alternando literales y variables.
+func nested(depth int, leaf func(i int) string) string {
+	operators := []string{"+", "*", "-", "+"}
+	expr := leaf(depth)
+	for i := depth - 1; i >= 0; i-- {
+		expr = fmt.Sprintf("%s %s (%s)", leaf(i), operators[i%len(operators)], expr)
+	}
+	return expr
+}
+
+func TestNestedExpressions(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"operando derecho anidado", "mut a = 7\nmut b = 3\nmut c = 2\nmut r = a - (b * c)\nprintln(r)\n"},
+		{"ambos lados anidados", "mut a = 7\nmut b = 3\nmut r = (a * (b + 1)) - ((a - b) * (b % 2 + a / 2))\nprintln(r)\n"},
+		{"comparaciones y lógicos", "mut a = 4\nmut b = 9\nmut r = (a + b * 2 > (b - a) * 3) && !(a == (b - 5))\nprintln(r)\nmut s = ((a < b) == (b > a)) || (a * (b - 1) <= 0)\nprintln(s)\n"},
+		{"flotantes mezclados con enteros", "mut a = 3\nmut x = 2.5\nmut r = (a * x - (a / (x + 0.5))) * (1 + (a - 1) / 4.0)\nprintln(r)\nmut c = x * (a + (x * (a - (x / 2))))\nprintln(c)\n"},
+		{"comparación de flotantes anidada", "mut a = 3\nmut x = 0.1\nmut r = (x + (x + x)) < (a * x) - (x - (x * a))\nprintln(r)\n"},
+		{"menos unario", "mut a = 5\nmut x = 1.5\nmut r = -a * (-(a - 8) + -(a * 2))\nprintln(r)\nmut f = -x * (-(x - 4.0) + a)\nprintln(f)\n"},
+		{
+			"llamadas dentro de la expresión",
+			"fn doble(n int) int {\n    return n * 2\n}\nfn suma(p int, q int) int {\n    return p + q\n}\n" +
+				"mut a = 5\nmut b = 3\nmut r = doble(a) + (doble(b) * (a - doble((b - 2))))\nprintln(r)\n" +
+				"mut s = suma((doble(a)), (suma(4, b) - doble((a + b))))\nprintln(s)\n",
+		},
+		{
+			"temporales dentro de funciones",
+			"fn calcular(p int, q int) int {\n    mut t = (p + (q * (p - (q + 1)))) * (p - q)\n    return t + (p * (q + t))\n}\n" +
+				"mut r = 10 + (calcular(4, 3) * (2 - calcular(2, 5)))\nprintln(r)\n",
+		},
+		{
+			"switch con expresiones anidadas",
+			"mut a = 3\nmut b = 4\nswitch a * (b - 1) {\ncase b + (a * 2) - 1:\n    println(1)\ncase (a + b) * 2 - (b - a) * 5:\n    println(2)\ndefault:\n    println(3)\n}\n",
+		},
+		{"incremento posfijo", "mut i = 0\nfor i++ < 4 {\n    println(i)\n}\nmut j = 3\nfor j-- > 0 {\n    println(j)\n}"},
+		{
+			"argumentos de println antes de imprimir",
+			"fn f(n int) int {\n    println(\"dentro\", n)\n    return n + 1\n}\nprintln(1, f(2), \"x\", f(3))\nprint(f(4), 5)\nprintln()\n",
+		},
+		{"asignación compuesta", "mut a = 10\nmut b = 3\na -= b * (a - (b + 4))\nprintln(a)\nmut x = 1.5\nx += b * (x - 0.5)\nprintln(x)\n"},
+		{"anidamiento profundo", "mut a = 3\nmut b = 2\nmut r = " + nested(30, func(i int) string {
+			return []string{"a", "2", "b", "1"}[i%4]
+		}) + "\nprintln(r)\n"},
+		{"anidamiento profundo con flotantes", "mut a = 3\nmut x = 0.5\nmut r = " + nested(24, func(i int) string {
+			return []string{"x", "a", "1.25", "2"}[i%4]
+		}) + "\nprintln(r)\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compare(t, tt.code)
+		})
+	}
+}
+
+// Con más temporales vivos que registros, los que sobran van al stack y
+// las variables se siguen leyendo bien aunque sp se haya movido.
+func TestTemporariesSpillToStack(t *testing.T) {
+	code := "mut a = 1\nmut b = 2\nmut r = " + nested(16, func(i int) string {
+		return []string{"a", "b"}[i%2]
+	}) + "\nprintln(r)\n"
+
+	assembly := translate(t, code)
+	if !strings.Contains(assembly, "str x0, [sp, #-16]!") {
+		t.Fatalf("se esperaba que los temporales pasaran al stack:\n%s", assembly)
+	}
+	if pushes, pops := strings.Count(assembly, "str x0, [sp, #-16]!"), strings.Count(assembly, "ldr x1, [sp], #16"); pushes != pops {
+		t.Errorf("se apilaron %d temporales y se recuperaron %d", pushes, pops)
+	}
+	compare(t, code)
+}
+
+// Una función que usa registros de temporales los guarda y los restaura,
+// así no pisa los temporales vivos de quien la llama.
+func TestFunctionPreservesTemporaries(t *testing.T) {
+	code := "fn f(n int) int {\n    return n * (n + (n - 1))\n}\nmut r = 1 + (2 * (f(3) + f((f(2) - 4))))\nprintln(r)\n"
+	assembly := translate(t, code)
+	body := assembly[strings.Index(assembly, "func_f:"):]
+	if !strings.Contains(body, "stp x19, x20, [sp, #-16]!") || !strings.Contains(body, "ldp x19, x20, [sp], #16") {
+		t.Fatalf("func_f no preserva x19 y x20:\n%s", body)
+	}
+	compare(t, code)
+}
+
+// Cada llamada tiene su marco: las locales y los parámetros de una función
+// recursiva no se pisan, y los argumentos siguen AAPCS64.
+func TestUserFunctionFrames(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{
+			"recursión con locales",
+			"fn fib(n int) int {\n    if n < 2 {\n        return n\n    }\n    mut a = fib((n - 1))\n    mut b = fib((n - 2))\n    return a + b\n}\nprintln(fib(12))\n",
+		},
+		{
+			"local con el nombre de una global",
+			"mut x = 5\nfn f(n int) int {\n    mut x = n * 10\n    return x + 1\n}\nprintln(f(3), x)\n",
+		},
+		{
+			"parámetros float en registros d",
+			"fn mezcla(a int, x float, b int, y float) float {\n    return a * x + b * y\n}\nprintln(mezcla(2, 1.5, 3, 0.25))\n",
+		},
+		{
+			"más de ocho argumentos",
+			"fn suma(a int, b int, c int, d int, e int, f int, g int, h int, i int, j int) int {\n    return a + b * 2 + c * 3 + d + e + f + g + h + i * 100 + j * 1000\n}\n" +
+				"println(suma(1, 2, 3, 4, 5, 6, 7, 8, 9, 10))\nprintln(1 + (suma(1, 1, 1, 1, 1, 1, 1, 1, (suma(0, 0, 0, 0, 0, 0, 0, 0, 1, 0)), 2)))\n",
+		},
+		{
+			"más de ocho float",
+			"fn prom(a float, b float, c float, d float, e float, f float, g float, h float, i float, n int) float {\n    return (a + b + c + d + e + f + g + h + i) / n\n}\n" +
+				"println(prom(1, 2, 3, 4, 5, 6, 7, 8, 9.5, 9))\n",
+		},
+		{
+			"recursión con argumentos en el stack",
+			"fn cuenta(a int, b int, c int, d int, e int, f int, g int, h int, n int, acc int) int {\n    if n == 0 {\n        return acc + a + h\n    }\n    return cuenta(a, b, c, d, e, f, g, h, (n - 1), (acc + n))\n}\nprintln(cuenta(1, 0, 0, 0, 0, 0, 0, 2, 10, 0))\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compare(t, tt.code)
+		})
+	}
+}
+
+// Los vectores y las matrices viven en el heap: las declaraciones comparten
+// el vector, las asignaciones y los parámetros lo copian, y el for sobre un
+// vector ve lo que el cuerpo le agrega.
+func TestVectors(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{
+			"índices y asignación compuesta",
+			"mut v []int = {5, 3, 8}\nprintln(v[0], v[2])\nv[1] = 10\nv[0] += 1\nv[2] -= 2\nprintln(v)\n" +
+				"mut f []float = {1.5, 2.25}\nf[1] -= 0.5\nprintln(f, f[0] * 2)\n",
+		},
+		{
+			"elementos de cada tipo",
+			"mut s []string = {\"hola\", \"mundo\"}\ns[1] = \"chau\"\nmut b []bool = {true, false}\nmut e []int\nprintln(s, b, b[1], e)\n",
+		},
+		{
+			"matrices",
+			"m = [][]int { {1, 2, 3}, {4, 5, 6} }\nm[0][0] = 9\nm[1][2] += m[0][1]\nprintln(m, m[1][2])\nmut r = m[0]\nr[0] = 99\nprintln(m, r)\n",
+		},
+		{
+			"declarar comparte y asignar copia",
+			"mut v []int = {1, 2, 3}\nmut w = v\nw[0] = 50\nmut z []int\nz = v\nz[1] = 70\nprintln(v, w, z)\n" +
+				"mut a = append(v, 9)\na[0] = -1\nprintln(v, a)\n",
+		},
+		{
+			"parámetros por valor",
+			"fn suma(v []int) int {\n    mut s = 0\n    for i, x in v {\n        s += x * i\n    }\n    v[0] = 100\n    return s\n}\n" +
+				"mut v []int = {4, 5, 6}\nmut total = suma(v)\nprintln(total, v)\n",
+		},
+		{
+			"métodos y propiedades",
+			"mut v []int\nprintln(v.isEmpty, v.count)\nv.append(4)\nv.append(5)\nv.append(6)\nv.remove(at 0)\nprintln(v, v.count)\nv.removeLast()\nmut n = len(v)\nprintln(v, n, v.isEmpty)\n",
+		},
+		{
+			"crecer más allá de la capacidad",
+			"mut g []int\nmut k = 0\nfor k = 0; k < 100; k++ {\n    g.append(k * k)\n}\nmut n = len(g)\nprintln(n, g[0], g[57], g[99])\n",
+		},
+		{
+			"for sobre un vector que crece",
+			"mut n []int = {1, 2}\nfor i, x in n {\n    if i < 3 {\n        n.append(x + 10)\n    }\n}\nprintln(n)\n" +
+				"mut c = 0\nfor i, x in n {\n    if x > 11 {\n        break\n    }\n    if i == 1 {\n        continue\n    }\n    c += x\n}\nprintln(c)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compare(t, tt.code)
+		})
+	}
+}
+
+// Un índice fuera de rango o quitar de un vector vacío termina el programa
+// con un mensaje en stderr.
+func TestVectorRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"índice mayor que el largo", "mut v []int = {1, 2}\nprintln(v[0])\nprintln(v[2])\n", "índice fuera de rango"},
+		{"índice negativo", "mut v []int = {1, 2}\nmut i = -1\nv[i] = 3\n", "índice fuera de rango"},
+		{"fila inexistente", "m = [][]int { {1} }\nprintln(m[1][0])\n", "índice fuera de rango"},
+		{"remove fuera de rango", "mut v []int = {1}\nv.remove(at 3)\n", "índice fuera de rango"},
+		{"removeLast de un vector vacío", "mut v []int\nv.removeLast()\n", "el vector está vacío"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := emulator.Run(translate(t, tt.code))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ExitCode != 1 || !strings.Contains(result.Stderr, tt.want) {
+				t.Errorf("salida %d, stderr %q; se esperaba salida 1 y %q", result.ExitCode, result.Stderr, tt.want)
+			}
+		})
+	}
+}
+
+// TestStructs compara la salida de programas con structs con la esperada.
+// El intérprete todavía no lee atributos dentro de println ni pasa structs
+// a funciones, así que no se compara contra él.
+func TestStructs(t *testing.T) {
+	const decls = `
+struct Punto {
+    int x
+    int y
+}
+
+struct Persona {
+    string nombre
+    Punto casa
+    float peso
+    bool activo
+    rune inicial
+    []int notas
+}
+`
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"lectura de atributos", `mut p = Punto{x: 1, y: 2}
+println(p.x, p.y)
+mut s = p.x + p.y * 10
+println(s)`, "1 2\n21\n"},
+		{"escritura de atributos", `mut p = Punto{x: 1, y: 2}
+p.x = 10
+p.y += 5
+p.x -= 3
+println(p.x, p.y)`, "7 7\n"},
+		{"atributos anidados", `mut ana = Persona{nombre: "Ana", casa: Punto{x: 3, y: 4}, peso: 60}
+ana.casa.y = 40
+ana.peso += 0.5
+mut y = ana.casa.y
+println(y, ana.peso, ana.nombre)`, "40 60.5000 Ana\n"},
+		{"impresión", `mut p = Punto{x: 1, y: 2}
+println(p)
+mut ana = Persona{nombre: "Ana", casa: p, peso: 60.5, activo: true, inicial: "A", notas: {7, 9}}
+println(ana)`, "Punto{x: 1, y: 2}\nPersona{nombre: \"Ana\", casa: Punto{x: 1, y: 2}, peso: 60.5000, activo: true, inicial: 'A', notas: [ 7 9 ]}\n"},
+		{"atributos sin valor", `mut ana = Persona{nombre: "Ana"}
+println(ana)
+ana.activo = true
+println(ana)`, "Persona{nombre: \"Ana\"}\nPersona{nombre: \"Ana\", activo: true}\n"},
+		{"las declaraciones comparten la instancia", `mut p = Punto{x: 1, y: 2}
+mut q = p
+q.x = 5
+println(p.x)`, "5\n"},
+		{"parámetros por referencia", `fn mover(p Punto, dx int) int {
+    p.x += dx
+    return p.x + p.y
+}
+mut p = Punto{x: 1, y: 2}
+mut s = mover(p, 4)
+println(s, p)`, "7 Punto{x: 5, y: 2}\n"},
+		{"vectores en atributos", `mut ana = Persona{notas: {1}}
+ana.notas.append(2)
+println(ana.notas.count, ana.notas[1])`, "2 2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := emulator.Run(translate(t, decls+tt.code+"\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Stdout != tt.want {
+				t.Errorf("salida %q; se esperaba %q", result.Stdout, tt.want)
+			}
+		})
+	}
+}
+
+// TestFloats cubre la impresión de flotantes fuera del rango de un entero
+// de 64 bits y el redondeo que llega a la parte entera.
+func TestFloats(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"grandes", "mut a = 100000000000000000000.0\nprintln(a, -a)\nmut b = 1234567890123456.75\nprintln(b)\n"},
+		{"enormes", "mut x = 1.0\nmut i = 0\nfor i = 0; i < 30; i++ {\n    x = x * 12345678901.0\n}\nprintln(x)\n"},
+		{"redondeo hasta la unidad", "mut a = 0.99999\nmut b = 2.99996\nmut c = -7.99999\nprintln(a, b, c)\n"},
+		{"interpolación grande", "mut a = 100000000000000000000.0\nmut b = 9007199254740993.0\nprintln(\"$a $b\")\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compare(t, tt.code)
+		})
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{
+			"concatenación",
+			"mut a = \"hola\"\nmut b = a + \" \" + \"mundo\"\nprintln(b)\nb += \"!\"\nprintln(b, a)\nmut c = a + \"y\"\nprintln(c)\n",
+		},
+		{
+			"comparaciones",
+			"mut a = \"hola\"\nmut b = \"ho\" + \"la\"\nmut e = a == b\nmut d = a != b\nmut m = a < \"hz\"\nmut g = \"bb\" >= \"ab\"\nprintln(e, d, m, g)\n" +
+				"if a == b {\n    println(\"iguales\")\n}\n",
+		},
+		{
+			"switch sobre strings",
+			"mut s = \"ho\" + \"la\"\nswitch s {\ncase \"chau\":\n    println(1)\ncase \"hola\":\n    println(2)\ndefault:\n    println(3)\n}\n",
+		},
+		{
+			"interpolación como valor",
+			"mut n = 42\nmut f = 2.5\nmut b = true\nmut s = \"mundo\"\nmut r = \"n=$n f=$f b=$b s=$s\"\nprintln(r)\nmut x = r + \".\"\nprintln(x)\n",
+		},
+		{
+			"interpolación con llaves",
+			"mut n = 7\nmut s = \"v=${n}\"\nprintln(s)\nprintln(\"${n}$n\")\n",
+		},
+		{
+			"interpolación en el retorno de una función",
+			"fn d(x int) string {\n    return \"x vale $x\"\n}\nmut r = d(3)\nprintln(r)\n",
+		},
+		{
+			"atoi y parseFloat",
+			"mut i = atoi(\"42\")\nmut k = atoi(\"-3.9\")\nmut l = atoi(3.9)\nmut f = parseFloat(\"3.25\")\nmut g = parseFloat(\"-1.5e2\")\nmut h = parseFloat(\"0.1\")\nprintln(i, k, l, f, g, h)\n",
+		},
+		{
+			"join e indexOf",
+			"mut v []string = {\"ab\", \"bcd\", \"cd\"}\nmut s = join(v, \", \")\nprintln(s)\nmut i = indexOf(v, \"b\" + \"cd\")\nmut j = indexOf(v, \"x\")\nprintln(i, j)\n" +
+				"mut w []int = {4, 5, 6}\nmut k = indexOf(w, 6)\nmut f []float = {1.5, 2.5}\nmut m = indexOf(f, 2.5)\nprintln(k, m)\n",
+		},
+		{
+			"TypeOf",
+			"mut a = 1\nmut f = 2.5\nmut s = \"arm\"\nmut b = true\nmut v []int = {1}\nprintln(TypeOf(a), TypeOf(f), TypeOf(s), TypeOf(b), TypeOf(v))\nmut t = TypeOf(a + 1)\nprintln(\"dos\", t)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compare(t, tt.code)
+		})
+	}
+}
+
+// TestStringRuntime cubre lo que el intérprete no compila: len de un
+// string y los errores de conversión.
+func TestStringRuntime(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		stdout   string
+		exitCode int
+		stderr   string
+	}{
+		{"len de un string", "mut s = \"hola\" + \" mundo\"\nmut n = len(s)\nprintln(n)\n", "10\n", 0, ""},
+		{"join de un vector vacío", "mut v []string\nmut s = join(v, \", \")\nmut n = len(s)\nprintln(n)\n", "0\n", 0, ""},
+		{"atoi de un texto", "mut i = atoi(\"x1\")\nprintln(i)\n", "", 1, "no se pudo convertir el valor a int"},
+		{"parseFloat sin dígitos", "mut f = parseFloat(\"-.e3\")\nprintln(f)\n", "", 1, "no se pudo convertir el valor a float"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := emulator.Run(translate(t, tt.code))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Stdout != tt.stdout || result.ExitCode != tt.exitCode || !strings.Contains(result.Stderr, tt.stderr) {
+				t.Errorf("salida %d, stdout %q, stderr %q; se esperaba %d, %q y %q", result.ExitCode, result.Stdout, result.Stderr, tt.exitCode, tt.stdout, tt.stderr)
+			}
+		})
+	}
+}
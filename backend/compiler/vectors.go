@@ -0,0 +1,469 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"main.go/ast"
+	"main.go/compiler/arm64"
+	compiler "main.go/grammar"
+)
+
+// === VECTORES Y MATRICES ===
+//
+// Un vector es la dirección de su cabecera en el heap (ver
+// compiler/arm64/runtime.go) y una matriz es un vector de filas. Las
+// declaraciones comparten el vector con la variable de la que salen; las
+// asignaciones, los parámetros y la fila que devuelve m[i] son copias, como
+// en el intérprete.
+
+// vectorLevels devuelve cuántos niveles de vector tiene un tipo: 1 para
+// []int, 2 para una matriz ([[]]int en el árbol tipado, [][]int en la
+// gramática) y 0 si no es un vector.
+func vectorLevels(typ string) int {
+	switch {
+	case strings.HasPrefix(typ, "[[]]"), strings.HasPrefix(typ, "[][]"):
+		return 2
+	case strings.HasPrefix(typ, "[]"):
+		return 1
+	}
+	return 0
+}
+
+// printKind es el tipo de elemento que espera print_vector para typ.
+func printKind(typ string) int {
+	kind := arm64.ElemInt
+	switch scalar := strings.TrimLeft(typ, "[]"); {
+	case scalar == "float":
+		kind = arm64.ElemFloat
+	case scalar == "bool":
+		kind = arm64.ElemBool
+	case isStringType(scalar):
+		kind = arm64.ElemString
+	}
+	if vectorLevels(typ) == 2 {
+		kind += arm64.ElemRows
+	}
+	return kind
+}
+
+// isVariable indica si expr es solo el nombre de una variable, cuyo vector
+// hay que copiar para no compartirlo.
+func isVariable(expr antlr.ParseTree) bool {
+	switch ctx := expr.(type) {
+	case *compiler.IdPatternExprContext:
+		return !strings.Contains(ctx.GetText(), ".")
+	case *compiler.ParensExprContext:
+		return isVariable(ctx.Expression())
+	}
+	return false
+}
+
+// forRangeVariables devuelve los nombres de las variables ocultas de un
+// for sobre un vector: el vector que se recorre y la posición actual. Salen
+// de la posición del for, así no chocan con las de otro ni con las del
+// usuario.
+func forRangeVariables(ctx *compiler.ForStmtContext) (vectorVar, indexVar string) {
+	start := ctx.GetStart()
+	prefix := fmt.Sprintf("for.%d.%d", start.GetLine(), start.GetColumn())
+	return prefix + ".vector", prefix + ".index"
+}
+
+// callRuntime llama a una función del runtime y la marca para emitirla.
+func (t *ARM64Translator) callRuntime(funcName string) {
+	t.runtime.MarkUsed(funcName)
+	t.generator.CallFunction(funcName)
+}
+
+// restoreTempTo recupera temp en register.
+func (t *ARM64Translator) restoreTempTo(temp arm64.Temp, register string) {
+	if source := t.generator.RestoreTemp(temp, register); source != register {
+		t.generator.Emit(fmt.Sprintf("mov %s, %s", register, source))
+	}
+}
+
+// copyVector reemplaza el vector de x0 por una copia de levels niveles.
+func (t *ARM64Translator) copyVector(levels int) {
+	t.generator.LoadImmediate(arm64.X1, levels)
+	t.callRuntime("vec_copy")
+}
+
+// callPrintVector imprime el vector de tipo typ que está en x0.
+func (t *ARM64Translator) callPrintVector(typ string) {
+	t.generator.LoadImmediate(arm64.X1, printKind(typ))
+	t.callRuntime("print_vector")
+}
+
+// funcArgs devuelve los argumentos de una llamada.
+func funcArgs(ctx *compiler.FuncCallContext) []*compiler.FuncArgContext {
+	if ctx.Arg_list() == nil {
+		return nil
+	}
+	var args []*compiler.FuncArgContext
+	for _, arg := range ctx.Arg_list().(*compiler.ArgListContext).AllFunc_arg() {
+		if argCtx, ok := arg.(*compiler.FuncArgContext); ok {
+			args = append(args, argCtx)
+		}
+	}
+	return args
+}
+
+// translateArgument deja en x0 el valor de un argumento para un parámetro
+// de tipo targetType.
+func (t *ARM64Translator) translateArgument(argCtx *compiler.FuncArgContext, targetType string) {
+	if argCtx.Expression() != nil {
+		t.translateValue(argCtx.Expression(), targetType)
+		return
+	}
+	t.translateIdPattern(argCtx.Id_pattern())
+}
+
+// vectorProperty deja en x0 la propiedad count o isEmpty del vector que
+// está en x0 y devuelve su tipo, o "" si no es una propiedad de vector.
+func (t *ARM64Translator) vectorProperty(property string) string {
+	switch property {
+	case "count":
+		t.generator.Emit("ldr x0, [x0]")
+		return "int"
+	case "isEmpty":
+		t.generator.Emit("ldr x0, [x0]")
+		t.generator.Emit("cmp x0, #0")
+		t.generator.Emit("cset x0, eq")
+		return "bool"
+	}
+	return ""
+}
+
+// translateVectorMethod traduce v.append(x), v.remove(at i) y
+// v.removeLast(), que modifican el vector. El vector puede ser un atributo
+// de un struct, como en p.puntos.append(x).
+func (t *ARM64Translator) translateVectorMethod(ctx *compiler.FuncCallContext) {
+	name := ctx.Id_pattern().GetText()
+	t.generator.Comment(fmt.Sprintf("=== MÉTODO DE VECTOR: %s ===", name))
+
+	// El vector queda en x0
+	method, receiverType := "", ""
+	if selector := t.selectorOf(ctx); selector != nil {
+		method = selector.Sel.Name
+		receiverType, _ = t.loadSelector(selector.X)
+	}
+	if vectorLevels(receiverType) == 0 {
+		method = ""
+	}
+	args := funcArgs(ctx)
+
+	switch {
+	case method == "append" && len(args) == 1:
+		t.appendElement(func() { t.translateArgument(args[0], ast.ElemType(receiverType)) })
+	case method == "remove" && len(args) == 1:
+		temp := t.generator.SaveTemp(arm64.X0)
+		t.translateArgument(args[0], "int")
+		t.generator.Emit("mov x1, x0")
+		t.restoreTempTo(temp, arm64.X0)
+		t.callRuntime("vec_remove")
+	case method == "removeLast" && len(args) == 0:
+		t.callRuntime("vec_remove_last")
+	default:
+		t.addError(fmt.Sprintf("Función no implementada: %s", name))
+		t.generator.LoadImmediate(arm64.X0, 0)
+	}
+}
+
+// translateLen traduce len(v), el largo de un vector, y len(s), el largo
+// de un string en bytes.
+func (t *ARM64Translator) translateLen(ctx *compiler.FuncCallContext) {
+	args := funcArgs(ctx)
+	argType := ""
+	if len(args) == 1 {
+		argType = t.getArgumentType(args[0])
+	}
+
+	switch {
+	case vectorLevels(argType) > 0:
+		t.translateArgument(args[0], "")
+		t.generator.Emit("ldr x0, [x0]")
+	case isStringType(argType):
+		t.translateArgument(args[0], argType)
+		t.callRuntime("str_len")
+	default:
+		t.addError("Función no implementada: len")
+		t.generator.LoadImmediate(arm64.X0, 0)
+	}
+}
+
+// translateAppend traduce append(v, x): un vector nuevo con los elementos
+// de v y x al final.
+func (t *ARM64Translator) translateAppend(ctx *compiler.FuncCallContext) {
+	args := funcArgs(ctx)
+	vectorType := ""
+	if len(args) == 2 {
+		vectorType = t.getArgumentType(args[0])
+	}
+	levels := vectorLevels(vectorType)
+	if levels == 0 {
+		t.addError("Función no implementada: append")
+		t.generator.LoadImmediate(arm64.X0, 0)
+		return
+	}
+
+	t.translateArgument(args[0], vectorType)
+	t.copyVector(levels)
+	t.appendElement(func() { t.translateArgument(args[1], ast.ElemType(vectorType)) })
+}
+
+// appendElement agrega al vector de x0 el valor que element deja en x0. El
+// vector queda en x0.
+func (t *ARM64Translator) appendElement(element func()) {
+	temp := t.generator.SaveTemp(arm64.X0)
+	element()
+	t.generator.Emit("mov x1, x0")
+	t.restoreTempTo(temp, arm64.X0)
+	t.callRuntime("vec_append")
+}
+
+// translateVectorLiteral crea el vector {a, b, ...} con elementos de tipo
+// elemType.
+func (t *ARM64Translator) translateVectorLiteral(ctx compiler.IVect_exprContext, elemType string) {
+	listCtx, ok := ctx.(*compiler.VectorItemLisContext)
+	if !ok {
+		t.addError(fmt.Sprintf("Vector no implementado: %T", ctx))
+		t.generator.LoadImmediate(arm64.X0, 0)
+		return
+	}
+
+	items := listCtx.AllExpression()
+	t.generator.Comment(fmt.Sprintf("=== VECTOR DE %d ELEMENTOS ===", len(items)))
+	t.generator.LoadImmediate(arm64.X0, len(items))
+	t.callRuntime("vec_new")
+	for _, item := range items {
+		t.appendElement(func() { t.translateValue(item, elemType) })
+	}
+}
+
+// translateMatrixLiteral crea la matriz { {a, b}, {c, d} } con filas de
+// tipo rowType.
+func (t *ARM64Translator) translateMatrixLiteral(ctx compiler.IMatrix_exprContext, rowType string) {
+	listCtx, ok := ctx.(*compiler.MatrixItemListContext)
+	if !ok {
+		t.addError(fmt.Sprintf("Matriz no implementada: %T", ctx))
+		t.generator.LoadImmediate(arm64.X0, 0)
+		return
+	}
+
+	rows := listCtx.AllVect_expr()
+	t.generator.Comment(fmt.Sprintf("=== MATRIZ DE %d FILAS ===", len(rows)))
+	t.generator.LoadImmediate(arm64.X0, len(rows))
+	t.callRuntime("vec_new")
+	for _, row := range rows {
+		t.appendElement(func() { t.translateVectorLiteral(row, ast.ElemType(rowType)) })
+	}
+}
+
+// translateRepeating traduce []T(count: n, value: x): un vector de tipo
+// typ con n veces x. En una matriz cada fila es una copia de x.
+func (t *ARM64Translator) translateRepeating(ctx compiler.IRepeatingContext, typ string) {
+	repeatingCtx, ok := ctx.(*compiler.RepeatingDeclContext)
+	var count, value antlr.ParseTree
+	if ok {
+		for i, label := range repeatingCtx.AllID() {
+			switch label.GetText() {
+			case "count":
+				count = repeatingCtx.Expression(i)
+			case "value":
+				value = repeatingCtx.Expression(i)
+			}
+		}
+	}
+	if count == nil || value == nil {
+		t.addError(fmt.Sprintf("Repetición no implementada: %s", ctx.GetText()))
+		t.generator.LoadImmediate(arm64.X0, 0)
+		return
+	}
+
+	t.generator.Comment(fmt.Sprintf("=== REPETICIÓN: %s ===", ctx.GetText()))
+	t.translateExpression(count)
+	temp := t.generator.SaveTemp(arm64.X0)
+	elemType := ast.ElemType(typ)
+	t.translateValue(value, elemType)
+	t.generator.Emit("mov x1, x0")
+	t.restoreTempTo(temp, arm64.X0)
+	t.generator.LoadImmediate(arm64.X2, vectorLevels(elemType))
+	t.callRuntime("vec_repeat")
+}
+
+// translateElementAddress deja en x0 la dirección del elemento v[i][j]...
+// Cada índice se controla contra el largo de su vector.
+func (t *ARM64Translator) translateElementAddress(ctx *compiler.VectorItemContext) {
+	t.translateIdPattern(ctx.Id_pattern())
+	for i, index := range ctx.AllExpression() {
+		if i > 0 {
+			// la fila elegida por el índice anterior
+			t.generator.Emit("ldr x0, [x0]")
+		}
+		temp := t.generator.SaveTemp(arm64.X0)
+		t.translateExpression(index)
+		t.generator.Emit("mov x1, x0")
+		t.restoreTempTo(temp, arm64.X0)
+		t.callRuntime("vec_addr")
+	}
+}
+
+// translateVectorItem lee v[i] o m[i][j]. Una fila de una matriz se lee
+// como una copia.
+func (t *ARM64Translator) translateVectorItem(ctx compiler.IVect_itemContext) {
+	itemCtx, ok := ctx.(*compiler.VectorItemContext)
+	if !ok {
+		t.addError(fmt.Sprintf("Acceso a vector no implementado: %T", ctx))
+		t.generator.LoadImmediate(arm64.X0, 0)
+		return
+	}
+
+	t.generator.Comment(fmt.Sprintf("=== ACCESO: %s ===", itemCtx.GetText()))
+	t.translateElementAddress(itemCtx)
+	t.generator.Emit("ldr x0, [x0]")
+	if levels := vectorLevels(t.exprType(itemCtx)); levels > 0 {
+		t.copyVector(levels)
+	}
+}
+
+// translateVectorAssign traduce v[i] = x, v[i] += x y v[i] -= x.
+func (t *ARM64Translator) translateVectorAssign(ctx *compiler.VectorAssignContext) {
+	itemCtx, ok := ctx.Vect_item().(*compiler.VectorItemContext)
+	if !ok {
+		t.addError(fmt.Sprintf("Asignación a vector no implementada: %s", ctx.GetText()))
+		return
+	}
+	operator := ctx.GetOp().GetText()
+	t.generator.Comment(fmt.Sprintf("=== ASIGNACIÓN: %s %s ... ===", itemCtx.GetText(), operator))
+
+	// Primero el valor, después la dirección del elemento
+	elemType := t.exprType(itemCtx)
+	t.translateValue(ctx.Expression(), elemType)
+	temp := t.generator.SaveTemp(arm64.X0)
+	t.translateElementAddress(itemCtx)
+	value := t.generator.RestoreTemp(temp, arm64.X1)
+
+	t.storeWithOperator("[x0]", operator, elemType, value)
+}
+
+// storeWithOperator guarda value en memory con =, += o -=; memory es una
+// dirección relativa a x0, como [x0] o [x0, #8].
+func (t *ARM64Translator) storeWithOperator(memory, operator, valueType, value string) {
+	switch {
+	case operator == "=":
+		t.generator.Emit(fmt.Sprintf("str %s, %s", value, memory))
+	case valueType == "float":
+		t.generator.Emit(fmt.Sprintf("ldr d0, %s", memory))
+		t.generator.Emit(fmt.Sprintf("fmov d1, %s", value))
+		if operator == "-=" {
+			t.generator.Emit("fsub d0, d0, d1")
+		} else {
+			t.generator.Emit("fadd d0, d0, d1")
+		}
+		t.generator.Emit(fmt.Sprintf("str d0, %s", memory))
+	default:
+		t.generator.Emit(fmt.Sprintf("ldr x2, %s", memory))
+		if operator == "-=" {
+			t.generator.Sub(arm64.X2, arm64.X2, value)
+		} else {
+			t.generator.Add(arm64.X2, arm64.X2, value)
+		}
+		t.generator.Emit(fmt.Sprintf("str x2, %s", memory))
+	}
+}
+
+// translateValDeclVec traduce mut v []int: un vector vacío.
+func (t *ARM64Translator) translateValDeclVec(ctx *compiler.ValDeclVecContext) {
+	varName := ctx.ID().GetText()
+	varType := ctx.Type_().GetText()
+	t.generator.Comment(fmt.Sprintf("=== DECLARACIÓN: mut %s %s ===", varName, varType))
+
+	switch {
+	case vectorLevels(varType) > 0:
+		t.generator.LoadImmediate(arm64.X0, 0)
+		t.callRuntime("vec_new")
+	case isStringType(varType):
+		t.addError(fmt.Sprintf("Declaración sin valor no implementada: %s %s", varName, varType))
+		return
+	default:
+		// 0 es también el 0.0 de un float y el false de un bool
+		t.generator.LoadImmediate(arm64.X0, 0)
+	}
+	t.generator.StoreVariable(arm64.X0, varName)
+}
+
+// translateVarVectDecl traduce v = []int {1, 2, 3}
+func (t *ARM64Translator) translateVarVectDecl(ctx *compiler.VarVectDeclContext) {
+	varName := ctx.ID().GetText()
+	vectorType := ctx.Vector_type().GetText()
+	t.generator.Comment(fmt.Sprintf("=== DECLARACIÓN: %s = %s ===", varName, vectorType))
+
+	t.translateVectorLiteral(ctx.Vect_expr(), ast.ElemType(vectorType))
+	t.generator.StoreVariable(arm64.X0, varName)
+}
+
+// translateVarMatrixDecl traduce m = [][]int { {1, 2}, {3, 4} }
+func (t *ARM64Translator) translateVarMatrixDecl(ctx *compiler.VarMatrixDeclContext) {
+	varName := ctx.ID().GetText()
+	matrixType := ctx.Matrix_type().GetText()
+	t.generator.Comment(fmt.Sprintf("=== DECLARACIÓN: %s = %s ===", varName, matrixType))
+
+	t.translateMatrixLiteral(ctx.Matrix_expr(), strings.TrimPrefix(matrixType, "[]"))
+	t.generator.StoreVariable(arm64.X0, varName)
+}
+
+// translateForRange traduce for i, x in v { ... }. Como en el intérprete, el
+// largo se vuelve a leer en cada vuelta, así el ciclo ve los elementos que
+// agrega el cuerpo.
+func (t *ARM64Translator) translateForRange(ctx *compiler.ForStmtContext) {
+	indexName, valueName := ctx.ID(0).GetText(), ctx.ID(1).GetText()
+	t.generator.Comment(fmt.Sprintf("=== FOR %s, %s IN %s ===", indexName, valueName, ctx.Expression().GetText()))
+
+	if vectorLevels(t.exprType(ctx.Expression())) == 0 {
+		t.addError(fmt.Sprintf("For sobre %s no implementado", t.exprType(ctx.Expression())))
+		return
+	}
+
+	vectorVar, indexVar := forRangeVariables(ctx)
+	t.translateExpression(ctx.Expression())
+	t.generator.StoreVariable(arm64.X0, vectorVar)
+	t.generator.LoadImmediate(arm64.X0, 0)
+	t.generator.StoreVariable(arm64.X0, indexVar)
+
+	startLabel := t.generator.GetLabel()
+	continueLabel := t.generator.GetLabel()
+	endLabel := t.generator.GetLabel()
+	t.breakLabels = append(t.breakLabels, endLabel)
+	t.continueLabels = append(t.continueLabels, continueLabel)
+
+	// Salir cuando la posición llega al largo
+	t.generator.SetLabel(startLabel)
+	t.generator.LoadVariable(arm64.X1, indexVar)
+	t.generator.LoadVariable(arm64.X0, vectorVar)
+	t.generator.Emit("ldr x9, [x0]")
+	t.generator.Compare(arm64.X1, arm64.X9)
+	t.generator.Emit(fmt.Sprintf("bge %s", endLabel))
+
+	// Índice y valor de esta vuelta
+	t.callRuntime("vec_addr")
+	t.generator.Emit("ldr x0, [x0]")
+	t.generator.StoreVariable(arm64.X0, valueName)
+	t.generator.LoadVariable(arm64.X0, indexVar)
+	t.generator.StoreVariable(arm64.X0, indexName)
+
+	for _, stmt := range ctx.AllStmt() {
+		t.translateNode(stmt)
+	}
+
+	t.generator.SetLabel(continueLabel)
+	t.generator.LoadVariable(arm64.X0, indexVar)
+	t.generator.Emit("add x0, x0, #1")
+	t.generator.StoreVariable(arm64.X0, indexVar)
+	t.generator.Jump(startLabel)
+
+	t.generator.SetLabel(endLabel)
+
+	t.breakLabels = t.breakLabels[:len(t.breakLabels)-1]
+	t.continueLabels = t.continueLabels[:len(t.continueLabels)-1]
+}
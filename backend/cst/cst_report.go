@@ -9,8 +9,13 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+
+	"main.go/logging"
 )
 
+// log es el logger de las peticiones al servicio de ANTLR Lab
+var log = logging.New(logging.HTTP)
+
 type CSTResponse struct {
 	SVGTree string `json:"svgtree"`
 }
@@ -18,14 +23,14 @@ type CSTResponse struct {
 func ReadFile(filename string) string {
 	file, err := os.Open(filename)
 	if err != nil {
-		fmt.Printf("Error leyendo archivo %s: %v\n", filename, err)
+		log.Warn("error leyendo archivo", "file", filename, "error", err)
 		return ""
 	}
 	defer file.Close()
 
 	content, err := io.ReadAll(file)
 	if err != nil {
-		fmt.Printf("Error leyendo contenido: %v\n", err)
+		log.Warn("error leyendo contenido", "file", filename, "error", err)
 		return ""
 	}
 	return string(content)
@@ -42,18 +47,16 @@ func CstReport(input string) string {
 	parserFile := filepath.Join(grammarPath, "VLangGrammar.g4")
 	lexerFile := filepath.Join(grammarPath, "VLangLexer.g4")
 
-	fmt.Printf("🔍 Buscando gramáticas en:\n")
-	fmt.Printf("   Parser: %s\n", parserFile)
-	fmt.Printf("   Lexer: %s\n", lexerFile)
+	log.Debug("buscando gramáticas", "parser", parserFile, "lexer", lexerFile)
 
 	// Verificar que los archivos existen
 	if _, err := os.Stat(parserFile); os.IsNotExist(err) {
-		fmt.Printf("❌ Archivo de gramática parser no encontrado: %s\n", parserFile)
+		log.Warn("gramática del parser no encontrada", "file", parserFile)
 		return generateFallbackAST(input)
 	}
 
 	if _, err := os.Stat(lexerFile); os.IsNotExist(err) {
-		fmt.Printf("❌ Archivo de gramática lexer no encontrado: %s\n", lexerFile)
+		log.Warn("gramática del lexer no encontrada", "file", lexerFile)
 		return generateFallbackAST(input)
 	}
 
@@ -62,26 +65,26 @@ func CstReport(input string) string {
 	lexerContent := ReadFile(lexerFile)
 
 	if parserContent == "" || lexerContent == "" {
-		fmt.Println("❌ Error leyendo archivos de gramática")
+		log.Warn("error leyendo archivos de gramática")
 		return generateFallbackAST(input)
 	}
 
 	// Preparar contenido para el servicio
 	parserJSON, err := json.Marshal(parserContent)
 	if err != nil {
-		fmt.Printf("Error marshaling parser: %v\n", err)
+		log.Warn("error serializando la gramática del parser", "error", err)
 		return generateFallbackAST(input)
 	}
 
 	lexerJSON, err := json.Marshal(lexerContent)
 	if err != nil {
-		fmt.Printf("Error marshaling lexer: %v\n", err)
+		log.Warn("error serializando la gramática del lexer", "error", err)
 		return generateFallbackAST(input)
 	}
 
 	inputJSON, err := json.Marshal(input)
 	if err != nil {
-		fmt.Printf("Error marshaling input: %v\n", err)
+		log.Warn("error serializando la entrada", "error", err)
 		return generateFallbackAST(input)
 	}
 
@@ -93,13 +96,13 @@ func CstReport(input string) string {
 		"start": "program"
 	}`, parserJSON, inputJSON, lexerJSON))
 
-	fmt.Printf("📤 Enviando request a ANTLR Lab...\n")
+	log.Debug("enviando request a ANTLR Lab")
 
 	// Hacer request con timeout
 	client := &http.Client{}
 	req, err := http.NewRequest("POST", "http://lab.antlr.org/parse/", bytes.NewBuffer(payload))
 	if err != nil {
-		fmt.Printf("Error creando request: %v\n", err)
+		log.Warn("error creando request", "error", err)
 		return generateFallbackAST(input)
 	}
 
@@ -108,44 +111,43 @@ func CstReport(input string) string {
 
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Printf("Error enviando request: %v\n", err)
+		log.Warn("error enviando request", "error", err)
 		return generateFallbackAST(input)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Printf("Error leyendo respuesta: %v\n", err)
+		log.Warn("error leyendo respuesta", "error", err)
 		return generateFallbackAST(input)
 	}
 
 	if resp.StatusCode != 200 {
-		fmt.Printf("❌ Error HTTP %d: %s\n", resp.StatusCode, string(body))
+		log.Warn("respuesta HTTP inesperada", "status", resp.StatusCode, "body", string(body))
 		return generateFallbackAST(input)
 	}
 
 	// Parsear respuesta JSON
 	var data map[string]interface{}
 	if err := json.Unmarshal(body, &data); err != nil {
-		fmt.Printf("Error unmarshaling JSON: %v\n", err)
+		log.Warn("error decodificando JSON", "error", err)
 		return generateFallbackAST(input)
 	}
 
 	// Extraer SVG
 	if result, ok := data["result"].(map[string]interface{}); ok {
 		if svgTree, ok := result["svgtree"].(string); ok {
-			fmt.Printf("✅ AST generado exitosamente\n")
 			return svgTree
 		}
 	}
 
-	fmt.Printf("❌ No se pudo extraer svgtree de la respuesta\n")
+	log.Warn("la respuesta no contiene svgtree")
 	return generateFallbackAST(input)
 }
 
 // Generar AST de respaldo usando información básica
 func generateFallbackAST(input string) string {
-	fmt.Printf("🔧 Generando AST de respaldo...\n")
+	log.Debug("generando AST de respaldo")
 
 	// Crear un SVG básico pero más informativo
 	inputPreview := input
@@ -0,0 +1,419 @@
+package dap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"main.go/analysis"
+	"main.go/ast"
+	"main.go/logging"
+	"main.go/repl"
+)
+
+const threadID = 1
+
+var log = logging.New(logging.DAP)
+
+// Session es una sesión de depuración: un cliente, un programa.
+type Session struct {
+	transport Transport
+
+	mu  sync.Mutex // protege seq y el orden de escritura
+	seq int
+
+	path        string
+	program     *ast.Program
+	visitor     *repl.ReplVisitor
+	debugger    *repl.Debugger
+	breakpoints []int
+	noDebug     bool
+	printed     int // bytes de la consola ya enviados como eventos output
+	errors      int // errores de la tabla antes de ejecutar
+	running     bool
+
+	// sourceOnly rechaza launch con 'program': una sesión que llega por la
+	// red no puede leer archivos del servidor
+	sourceOnly bool
+}
+
+func NewSession(transport Transport) *Session {
+	return &Session{transport: transport}
+}
+
+// ServeStdio atiende una sesión sobre la entrada y la salida estándar.
+func ServeStdio() error {
+	return NewSession(NewStreamTransport(os.Stdin, os.Stdout)).Serve()
+}
+
+// Serve atiende requests hasta que el cliente pide disconnect o cierra la
+// conexión.
+func (s *Session) Serve() error {
+	defer s.terminate()
+
+	for {
+		data, err := s.transport.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(data, &req); err != nil {
+			log.Info("mensaje DAP inválido", "error", err)
+			continue
+		}
+		if req.Type != "request" {
+			continue
+		}
+
+		log.Debug("request DAP", "command", req.Command, "seq", req.Seq)
+		if !s.handle(&req) {
+			return nil
+		}
+	}
+}
+
+// handle responde un request. Devuelve false cuando la sesión termina.
+func (s *Session) handle(req *request) bool {
+	switch req.Command {
+	case "initialize":
+		s.respond(req, capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsTerminateRequest:         true,
+		})
+		s.sendEvent("initialized", nil)
+
+	case "launch":
+		var args launchArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			s.fail(req, "argumentos de launch inválidos: "+err.Error())
+			break
+		}
+		if err := s.launch(args); err != nil {
+			s.fail(req, err.Error())
+			break
+		}
+		s.respond(req, nil)
+
+	case "setBreakpoints":
+		var args setBreakpointsArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			s.fail(req, "argumentos de setBreakpoints inválidos: "+err.Error())
+			break
+		}
+		s.respond(req, map[string]any{"breakpoints": s.setBreakpoints(args)})
+
+	case "setExceptionBreakpoints":
+		s.respond(req, map[string]any{"breakpoints": []breakpoint{}})
+
+	case "configurationDone":
+		if s.program == nil {
+			s.fail(req, "no hay un programa lanzado")
+			break
+		}
+		s.respond(req, nil)
+		s.run()
+
+	case "threads":
+		s.respond(req, map[string]any{"threads": []thread{{ID: threadID, Name: "main"}}})
+
+	case "stackTrace":
+		frames := s.stackTrace()
+		s.respond(req, map[string]any{"stackFrames": frames, "totalFrames": len(frames)})
+
+	case "scopes":
+		var args scopesArguments
+		json.Unmarshal(req.Arguments, &args)
+		s.respond(req, map[string]any{"scopes": []scope{
+			{Name: "Locals", VariablesReference: args.FrameID*2 + 1},
+			{Name: "Globals", VariablesReference: args.FrameID*2 + 2},
+		}})
+
+	case "variables":
+		var args variablesArguments
+		json.Unmarshal(req.Arguments, &args)
+		s.respond(req, map[string]any{"variables": s.variables(args.VariablesReference)})
+
+	case "continue":
+		s.resume(req, (*repl.Debugger).Continue, map[string]any{"allThreadsContinued": true})
+	case "next":
+		s.resume(req, (*repl.Debugger).StepOver, nil)
+	case "stepIn":
+		s.resume(req, (*repl.Debugger).StepIn, nil)
+	case "stepOut":
+		s.resume(req, (*repl.Debugger).StepOut, nil)
+
+	case "pause":
+		if s.debugger != nil {
+			s.debugger.Pause()
+		}
+		s.respond(req, nil)
+
+	case "terminate":
+		s.respond(req, nil)
+		s.terminate()
+
+	case "disconnect":
+		s.terminate()
+		s.respond(req, nil)
+		return false
+
+	default:
+		s.fail(req, fmt.Sprintf("comando no soportado: %s", req.Command))
+	}
+	return true
+}
+
+// === PROGRAMA ===
+
+// launch analiza el programa y prepara el visitor. El programa no empieza a
+// correr hasta configurationDone, para que el cliente alcance a poner sus
+// breakpoints.
+func (s *Session) launch(args launchArguments) error {
+	if s.program != nil {
+		return fmt.Errorf("la sesión ya tiene un programa lanzado")
+	}
+
+	if s.sourceOnly && args.Program != "" {
+		return fmt.Errorf("esta sesión no puede abrir archivos del servidor: launch necesita 'source'")
+	}
+
+	code := args.Source
+	s.path = args.Program
+	if code == "" {
+		if args.Program == "" {
+			return fmt.Errorf("launch necesita 'program' o 'source'")
+		}
+		data, err := os.ReadFile(args.Program)
+		if err != nil {
+			return err
+		}
+		code = string(data)
+	}
+
+	analyzed := analysis.Parse(code)
+	if !analyzed.Check() {
+		return errorsToError(analyzed.ErrorTable.Errors)
+	}
+
+	s.program = analyzed.Program
+	s.visitor = repl.NewVisitor(analyzed.DclVisitor)
+	// sin Debugger (noDebug) terminate no puede detener el programa; los
+	// límites acotan lo que corre la goroutine
+	s.visitor.Limits = repl.SandboxLimits
+	s.errors = len(analyzed.ErrorTable.Errors)
+	s.noDebug = args.NoDebug
+
+	if !s.noDebug {
+		s.debugger = repl.NewDebugger(s.visitor.ScopeTrace)
+		s.debugger.OnStop = s.stopped
+		s.debugger.SetBreakpoints(s.breakpoints)
+		if args.StopOnEntry {
+			s.debugger.StopOnEntry()
+		}
+		s.visitor.Tracer = s.debugger
+	}
+	return nil
+}
+
+func errorsToError(list []repl.Error) error {
+	lines := make([]string, 0, len(list))
+	for _, err := range list {
+		lines = append(lines, fmt.Sprintf("%s %d:%d: %s", err.GetDisplayName(), err.Line, err.Column, err.Msg))
+	}
+	return fmt.Errorf("%s", strings.Join(lines, "\n"))
+}
+
+// run ejecuta el programa en su propia goroutine. Al terminar, normalmente
+// o por terminate, envía la salida pendiente, los errores de ejecución y los
+// eventos exited y terminated.
+func (s *Session) run() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				s.sendEvent("output", outputEvent{Category: "stderr", Output: fmt.Sprintf("error interno del intérprete: %v\n", r)})
+			}
+
+			s.flushOutput()
+			exitCode := 0
+			for _, err := range s.visitor.ErrorTable.Errors[s.errors:] {
+				exitCode = 1
+				s.sendEvent("output", outputEvent{
+					Category: "stderr",
+					Output:   fmt.Sprintf("%s: %s\n", err.GetDisplayName(), err.Msg),
+					Line:     err.Line,
+					Column:   err.Column + 1,
+				})
+			}
+			s.sendEvent("exited", exitedEvent{ExitCode: exitCode})
+			s.sendEvent("terminated", nil)
+		}()
+
+		s.visitor.Visit(s.program)
+	}()
+}
+
+// terminate detiene el programa si está corriendo.
+func (s *Session) terminate() {
+	if s.debugger != nil {
+		s.debugger.Terminate()
+	}
+}
+
+// stopped se llama desde la goroutine del programa cuando el Debugger lo
+// detiene.
+func (s *Session) stopped(reason repl.StopReason) {
+	s.flushOutput()
+	s.sendEvent("stopped", stoppedEvent{Reason: string(reason), ThreadID: threadID, AllThreadsStopped: true})
+}
+
+// flushOutput envía lo que el programa imprimió desde el último envío. Solo
+// se llama desde la goroutine del programa.
+func (s *Session) flushOutput() {
+	output := s.visitor.Console.GetOutput()
+	if len(output) <= s.printed {
+		return
+	}
+	s.sendEvent("output", outputEvent{Category: "stdout", Output: output[s.printed:]})
+	s.printed = len(output)
+}
+
+// === BREAKPOINTS E INSPECCIÓN ===
+
+func (s *Session) setBreakpoints(args setBreakpointsArguments) []breakpoint {
+	lines := args.Lines
+	if len(args.Breakpoints) > 0 {
+		lines = make([]int, 0, len(args.Breakpoints))
+		for _, bp := range args.Breakpoints {
+			lines = append(lines, bp.Line)
+		}
+	}
+
+	s.breakpoints = lines
+	if s.debugger != nil {
+		s.debugger.SetBreakpoints(lines)
+	}
+
+	result := make([]breakpoint, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, breakpoint{Verified: true, Line: line})
+	}
+	return result
+}
+
+func (s *Session) stackTrace() []stackFrame {
+	frames := make([]stackFrame, 0)
+	if s.debugger == nil {
+		return frames
+	}
+
+	src := source{Name: "program.vch"}
+	if s.path != "" {
+		src = source{Name: filepath.Base(s.path), Path: s.path}
+	}
+
+	for i, frame := range s.debugger.Frames() {
+		frames = append(frames, stackFrame{
+			ID:     i,
+			Name:   frame.Name,
+			Source: src,
+			Line:   frame.Line,
+			Column: frame.Column + 1,
+		})
+	}
+	return frames
+}
+
+// variables resuelve una referencia de scopes: las impares son las locales
+// del marco (ref-1)/2 y las pares las globales.
+func (s *Session) variables(ref int) []variable {
+	result := make([]variable, 0)
+	if s.debugger == nil || ref <= 0 {
+		return result
+	}
+
+	var values []repl.TraceValue
+	if ref%2 == 0 {
+		values = s.debugger.Globals()
+	} else {
+		values = s.debugger.Locals((ref - 1) / 2)
+	}
+
+	for _, val := range values {
+		result = append(result, variable{Name: val.Name, Value: val.Value, Type: val.Type})
+	}
+	return result
+}
+
+// === ÓRDENES DE EJECUCIÓN ===
+
+// resume responde antes de reanudar para que la respuesta llegue antes que
+// el siguiente evento stopped.
+func (s *Session) resume(req *request, command func(*repl.Debugger) bool, body any) {
+	if s.debugger == nil || !s.debugger.Paused() {
+		s.fail(req, "el programa no está detenido")
+		return
+	}
+	s.respond(req, body)
+	command(s.debugger)
+}
+
+// === ENVÍO ===
+
+func (s *Session) send(msg any) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Error("no se pudo codificar el mensaje DAP", "error", err)
+		return
+	}
+	if err := s.transport.Write(data); err != nil {
+		log.Debug("no se pudo enviar el mensaje DAP", "error", err)
+	}
+}
+
+func (s *Session) nextSeq() message {
+	s.seq++
+	return message{Seq: s.seq}
+}
+
+func (s *Session) respond(req *request, body any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := s.nextSeq()
+	msg.Type = "response"
+	s.send(response{message: msg, RequestSeq: req.Seq, Success: true, Command: req.Command, Body: body})
+}
+
+func (s *Session) fail(req *request, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := s.nextSeq()
+	msg.Type = "response"
+	s.send(response{message: msg, RequestSeq: req.Seq, Success: false, Command: req.Command, Message: text})
+}
+
+func (s *Session) sendEvent(name string, body any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := s.nextSeq()
+	msg.Type = "event"
+	s.send(event{message: msg, Event: name, Body: body})
+}
@@ -0,0 +1,414 @@
+package dap_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"main.go/dap"
+)
+
+// message es cualquier mensaje que envía el servidor.
+type message struct {
+	Seq        int             `json:"seq"`
+	Type       string          `json:"type"`
+	Command    string          `json:"command"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// client habla DAP con una sesión por un par de pipes, con el mismo
+// encuadre Content-Length que usa un cliente real por stdio.
+type client struct {
+	t        *testing.T
+	conn     *dap.StreamTransport // lado del cliente: escribe requests, lee respuestas
+	messages chan message
+	pending  []message // eventos recibidos mientras se esperaba otra cosa
+	seq      int
+	done     chan error
+}
+
+func newClient(t *testing.T) *client {
+	t.Helper()
+	toServer, fromClient := io.Pipe()
+	toClient, fromServer := io.Pipe()
+
+	c := &client{
+		t:        t,
+		conn:     dap.NewStreamTransport(toClient, fromClient),
+		messages: make(chan message, 64),
+		done:     make(chan error, 1),
+	}
+	go func() {
+		c.done <- dap.NewSession(dap.NewStreamTransport(toServer, fromServer)).Serve()
+		fromServer.Close()
+	}()
+	go func() {
+		defer close(c.messages)
+		for {
+			data, err := c.conn.Read()
+			if err != nil {
+				return
+			}
+			var msg message
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Errorf("mensaje inválido del servidor: %s", data)
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	t.Cleanup(func() { fromClient.Close() })
+	return c
+}
+
+// next devuelve el siguiente mensaje del servidor.
+func (c *client) next() message {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("el servidor cerró la conexión")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("el servidor no respondió a tiempo")
+	}
+	return message{}
+}
+
+// request envía command y devuelve su respuesta; los eventos que lleguen
+// antes quedan pendientes para event.
+func (c *client) request(command string, arguments any) message {
+	c.t.Helper()
+	c.seq++
+	data, err := json.Marshal(map[string]any{"seq": c.seq, "type": "request", "command": command, "arguments": arguments})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err := c.conn.Write(data); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.next()
+		if msg.Type == "response" && msg.RequestSeq == c.seq {
+			if msg.Command != command {
+				c.t.Fatalf("respuesta de %s para el request %s", msg.Command, command)
+			}
+			return msg
+		}
+		c.pending = append(c.pending, msg)
+	}
+}
+
+// event espera el evento name y devuelve su cuerpo en body. Descarta los
+// eventos anteriores que no sean output.
+func (c *client) event(name string, body any) {
+	c.t.Helper()
+	for {
+		var msg message
+		if len(c.pending) > 0 {
+			msg, c.pending = c.pending[0], c.pending[1:]
+		} else {
+			msg = c.next()
+		}
+		if msg.Type != "event" {
+			c.t.Fatalf("se esperaba el evento %s, llegó %+v", name, msg)
+		}
+		if msg.Event == name {
+			if body != nil {
+				if err := json.Unmarshal(msg.Body, body); err != nil {
+					c.t.Fatal(err)
+				}
+			}
+			return
+		}
+		if msg.Event == "stopped" || msg.Event == "terminated" {
+			c.t.Fatalf("se esperaba el evento %s, llegó %s: %s", name, msg.Event, msg.Body)
+		}
+	}
+}
+
+// ok envía command, exige una respuesta exitosa y decodifica su cuerpo.
+func (c *client) ok(command string, arguments any, body any) {
+	c.t.Helper()
+	msg := c.request(command, arguments)
+	if !msg.Success {
+		c.t.Fatalf("%s falló: %s", command, msg.Message)
+	}
+	if body != nil {
+		if err := json.Unmarshal(msg.Body, body); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+}
+
+type frame struct {
+	Name string `json:"name"`
+	Line int    `json:"line"`
+}
+
+// stopped espera un evento stopped con reason y devuelve el marco actual.
+func (c *client) stopped(reason string) frame {
+	c.t.Helper()
+	var event struct {
+		Reason string `json:"reason"`
+	}
+	c.event("stopped", &event)
+	if event.Reason != reason {
+		c.t.Errorf("detenido por %q, se esperaba %q", event.Reason, reason)
+	}
+	var trace struct {
+		StackFrames []frame `json:"stackFrames"`
+	}
+	c.ok("stackTrace", map[string]any{"threadId": 1}, &trace)
+	if len(trace.StackFrames) == 0 {
+		c.t.Fatal("la pila está vacía")
+	}
+	return trace.StackFrames[0]
+}
+
+// variables devuelve las variables de una referencia como nombre=valor.
+func (c *client) variables(ref int) map[string]string {
+	c.t.Helper()
+	var body struct {
+		Variables []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"variables"`
+	}
+	c.ok("variables", map[string]any{"variablesReference": ref}, &body)
+	result := map[string]string{}
+	for _, v := range body.Variables {
+		result[v.Name] = v.Value
+	}
+	return result
+}
+
+const program = `fn doble(n int) int {
+    mut r = n * 2
+    return r
+}
+mut x = 1
+x = doble(x)
+println(x)
+`
+
+// Una sesión completa: breakpoint, paso a paso por una llamada, variables
+// locales y globales, y la salida y el código al terminar.
+func TestDebugSession(t *testing.T) {
+	c := newClient(t)
+	c.ok("initialize", map[string]any{"adapterID": "vlang"}, nil)
+	c.event("initialized", nil)
+	c.ok("launch", map[string]any{"source": program}, nil)
+
+	var set struct {
+		Breakpoints []struct {
+			Verified bool `json:"verified"`
+			Line     int  `json:"line"`
+		} `json:"breakpoints"`
+	}
+	c.ok("setBreakpoints", map[string]any{"source": map[string]any{"name": "programa.vch"}, "breakpoints": []map[string]int{{"line": 6}}}, &set)
+	if len(set.Breakpoints) != 1 || !set.Breakpoints[0].Verified || set.Breakpoints[0].Line != 6 {
+		t.Fatalf("breakpoints: %+v", set.Breakpoints)
+	}
+	c.ok("configurationDone", nil, nil)
+
+	if f := c.stopped("breakpoint"); f.Line != 6 {
+		t.Fatalf("detenido en la línea %d, se esperaba 6", f.Line)
+	}
+	var scopes struct {
+		Scopes []struct {
+			Name               string `json:"name"`
+			VariablesReference int    `json:"variablesReference"`
+		} `json:"scopes"`
+	}
+	c.ok("scopes", map[string]any{"frameId": 0}, &scopes)
+	if len(scopes.Scopes) != 2 {
+		t.Fatalf("scopes: %+v", scopes.Scopes)
+	}
+	globals := scopes.Scopes[1].VariablesReference
+	if x := c.variables(globals)["x"]; x != "1" {
+		t.Errorf("antes de la llamada x = %q, se esperaba 1", x)
+	}
+
+	c.ok("stepIn", map[string]any{"threadId": 1}, nil)
+	if f := c.stopped("step"); f.Name != "doble" || f.Line != 2 {
+		t.Fatalf("stepIn se detuvo en %+v, se esperaba doble en la línea 2", f)
+	}
+	if n := c.variables(1)["n"]; n != "1" {
+		t.Errorf("n = %q, se esperaba 1", n)
+	}
+
+	c.ok("next", map[string]any{"threadId": 1}, nil)
+	if f := c.stopped("step"); f.Name != "doble" || f.Line != 3 {
+		t.Fatalf("next se detuvo en %+v, se esperaba doble en la línea 3", f)
+	}
+	if r := c.variables(1)["r"]; r != "2" {
+		t.Errorf("r = %q, se esperaba 2", r)
+	}
+
+	c.ok("stepOut", map[string]any{"threadId": 1}, nil)
+	if f := c.stopped("step"); f.Name == "doble" || f.Line != 7 {
+		t.Fatalf("stepOut se detuvo en %+v, se esperaba la línea 7 fuera de doble", f)
+	}
+	if x := c.variables(globals)["x"]; x != "2" {
+		t.Errorf("después de la llamada x = %q, se esperaba 2", x)
+	}
+
+	c.ok("continue", map[string]any{"threadId": 1}, nil)
+	var output struct {
+		Category string `json:"category"`
+		Output   string `json:"output"`
+	}
+	c.event("output", &output)
+	if output.Category != "stdout" || !strings.HasPrefix(output.Output, "2\n") {
+		t.Errorf("salida %+v, se esperaba 2 en stdout", output)
+	}
+	var exited struct {
+		ExitCode int `json:"exitCode"`
+	}
+	c.event("exited", &exited)
+	if exited.ExitCode != 0 {
+		t.Errorf("código de salida %d, se esperaba 0", exited.ExitCode)
+	}
+	c.event("terminated", nil)
+
+	c.ok("disconnect", nil, nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("Serve terminó con error: %v", err)
+	}
+}
+
+// launch rechaza un programa con errores y las órdenes de ejecución fallan
+// si el programa no está detenido.
+func TestDebugErrors(t *testing.T) {
+	c := newClient(t)
+	c.ok("initialize", nil, nil)
+
+	if msg := c.request("launch", map[string]any{"source": "mut = 5\n"}); msg.Success || !strings.Contains(msg.Message, "Sintáctico") {
+		t.Errorf("launch con error de sintaxis: %+v", msg)
+	}
+	if msg := c.request("configurationDone", nil); msg.Success {
+		t.Error("configurationDone sin programa debería fallar")
+	}
+	if msg := c.request("next", map[string]any{"threadId": 1}); msg.Success {
+		t.Error("next sin programa detenido debería fallar")
+	}
+	if msg := c.request("evaluate", nil); msg.Success {
+		t.Error("un comando no soportado debería fallar")
+	}
+}
+
+// Con noDebug no hay Debugger que detenga el programa: los límites del
+// sandbox lo terminan con un error de ejecución.
+func TestDebugLimits(t *testing.T) {
+	c := newClient(t)
+	c.ok("initialize", nil, nil)
+	c.ok("launch", map[string]any{"source": "mut s = \"ab\"\nfor true {\n    s += s\n}\n", "noDebug": true}, nil)
+	c.ok("configurationDone", nil, nil)
+
+	var output struct {
+		Category string `json:"category"`
+		Output   string `json:"output"`
+	}
+	c.event("output", &output)
+	if output.Category != "stderr" || !strings.Contains(output.Output, "largo máximo") {
+		t.Errorf("salida %+v, se esperaba el error del límite en stderr", output)
+	}
+	var exited struct {
+		ExitCode int `json:"exitCode"`
+	}
+	c.event("exited", &exited)
+	if exited.ExitCode != 1 {
+		t.Errorf("código de salida %d, se esperaba 1", exited.ExitCode)
+	}
+}
+
+// dial abre /api/debug en server con el Origin indicado ("" para no
+// mandarlo).
+func dial(t *testing.T, server *httptest.Server, origin string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	header := http.Header{}
+	if origin != "" {
+		header.Set("Origin", origin)
+	}
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), header)
+	if conn != nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conn, resp, err
+}
+
+// Solo las páginas del mismo servidor o de la máquina local abren una sesión
+// por WebSocket, y la sesión no puede leer archivos del servidor.
+func TestWebSocket(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(dap.ServeWebSocket))
+	defer server.Close()
+
+	origins := []struct {
+		origin string
+		ok     bool
+	}{
+		{"", true},
+		{server.URL, true},
+		{"http://localhost:5173", true},
+		{"http://127.0.0.1:3000", true},
+		{"https://evil.example", false},
+		{"null", false},
+	}
+	for _, tt := range origins {
+		_, resp, err := dial(t, server, tt.origin)
+		if (err == nil) != tt.ok {
+			t.Errorf("Origin %q: error %v, se esperaba aceptado = %v", tt.origin, err, tt.ok)
+		}
+		if !tt.ok && resp != nil && resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Origin %q: estado %d, se esperaba 403", tt.origin, resp.StatusCode)
+		}
+	}
+
+	conn, _, err := dial(t, server, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq := 0
+	request := func(command string, arguments any) message {
+		seq++
+		if err := conn.WriteJSON(map[string]any{"seq": seq, "type": "request", "command": command, "arguments": arguments}); err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			var msg message
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatal(err)
+			}
+			if msg.Type == "response" && msg.RequestSeq == seq {
+				return msg
+			}
+		}
+	}
+
+	secret := filepath.Join(t.TempDir(), "secreto.vch")
+	if err := os.WriteFile(secret, []byte("xyzzy plugh\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	request("initialize", nil)
+	if msg := request("launch", map[string]any{"program": secret}); msg.Success || strings.Contains(msg.Message, "xyzzy") {
+		t.Errorf("launch con 'program' por WebSocket: %+v", msg)
+	}
+	if msg := request("launch", map[string]any{"source": program}); !msg.Success {
+		t.Errorf("launch con 'source' por WebSocket falló: %s", msg.Message)
+	}
+}
@@ -0,0 +1,131 @@
+package dap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// Transport lee y escribe mensajes DAP completos (el JSON de un request,
+// una respuesta o un evento).
+type Transport interface {
+	Read() ([]byte, error)
+	Write(message []byte) error
+}
+
+// StreamTransport usa el encuadre del protocolo sobre un stream: cada
+// mensaje va precedido por el encabezado Content-Length y una línea vacía.
+// Es el que usan los clientes DAP al lanzar el adaptador por stdio.
+type StreamTransport struct {
+	reader *textproto.Reader
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func NewStreamTransport(r io.Reader, w io.Writer) *StreamTransport {
+	return &StreamTransport{
+		reader: textproto.NewReader(bufio.NewReader(r)),
+		writer: w,
+	}
+}
+
+func (t *StreamTransport) Read() ([]byte, error) {
+	header, err := t.reader.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("encabezado Content-Length inválido: %q", header.Get("Content-Length"))
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(t.reader.R, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (t *StreamTransport) Write(message []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, err := fmt.Fprintf(t.writer, "Content-Length: %d\r\n\r\n", len(message)); err != nil {
+		return err
+	}
+	_, err := t.writer.Write(message)
+	return err
+}
+
+// WebSocketTransport manda un mensaje DAP por cada mensaje de texto del
+// WebSocket, sin encabezados.
+type WebSocketTransport struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func NewWebSocketTransport(conn *websocket.Conn) *WebSocketTransport {
+	return &WebSocketTransport{conn: conn}
+}
+
+func (t *WebSocketTransport) Read() ([]byte, error) {
+	_, message, err := t.conn.ReadMessage()
+	return message, err
+}
+
+func (t *WebSocketTransport) Write(message []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.conn.WriteMessage(websocket.TextMessage, message)
+}
+
+var upgrader = websocket.Upgrader{CheckOrigin: checkOrigin}
+
+// checkOrigin acepta el WebSocket si el navegador no manda Origin (clientes
+// que no son navegadores), si la página es del mismo servidor o si se sirve
+// desde la máquina local, como el IDE en desarrollo. Cualquier otra página
+// queda afuera.
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// ServeWebSocket atiende una sesión de depuración sobre un WebSocket. La
+// sesión solo acepta el código en 'source'.
+func ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Info("no se pudo abrir el WebSocket", "error", err, "origin", r.Header.Get("Origin"))
+		return
+	}
+	defer conn.Close()
+
+	session := NewSession(NewWebSocketTransport(conn))
+	session.sourceOnly = true
+	if err := session.Serve(); err != nil {
+		log.Info("sesión de depuración terminada", "error", err)
+	}
+}
@@ -0,0 +1,452 @@
+package difftest
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Tipos que maneja el generador.
+const (
+	typeInt    = "int"
+	typeBool   = "bool"
+	typeString = "string"
+)
+
+var generatedTypes = []string{typeInt, typeBool, typeString}
+
+// Generator produce programas aleatorios bien tipados, recorriendo la
+// gramática desde las sentencias hasta los literales. Los programas evitan
+// lo que el intérprete rechaza en tiempo de ejecución (divisiones entre cero,
+// ciclos infinitos) y las ambigüedades de la gramática: un argumento que no
+// es un identificador o un literal va entre paréntesis, porque "f(n - 1)" se
+// lee como el argumento con nombre n.
+type Generator struct {
+	MaxStatements int  // sentencias del programa principal
+	MaxFunctions  int  // funciones declaradas antes del programa principal
+	MaxDepth      int  // anidamiento de bloques y de expresiones
+	Natives       bool // usa atoi y TypeOf
+
+	rand      *rand.Rand
+	b         strings.Builder
+	indent    int
+	names     int
+	scope     *genScope
+	functions []genFunction
+	loops     int      // ciclos abiertos alrededor de la sentencia actual
+	hoisted   []string // contadores de ciclos anidados, ver loop
+}
+
+type genVariable struct {
+	name    string
+	typ     string
+	counter bool // contador de un ciclo, no se reasigna
+}
+
+type genScope struct {
+	variables []genVariable
+	parent    *genScope
+}
+
+type genFunction struct {
+	name   string
+	params int
+}
+
+// NewGenerator crea un generador con la semilla dada; la misma semilla
+// produce siempre los mismos programas.
+func NewGenerator(seed int64) *Generator {
+	return &Generator{
+		MaxStatements: 12,
+		MaxFunctions:  2,
+		MaxDepth:      3,
+		Natives:       true,
+		rand:          rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Program genera un programa nuevo.
+func (g *Generator) Program() string {
+	g.b.Reset()
+	g.indent = 0
+	g.names = 0
+	g.functions = nil
+	g.loops = 0
+	g.hoisted = nil
+
+	for range g.rand.Intn(g.MaxFunctions + 1) {
+		g.function()
+	}
+	g.scope = &genScope{}
+	for range 1 + g.rand.Intn(g.MaxStatements) {
+		g.statement(g.MaxDepth)
+	}
+	return g.b.String()
+}
+
+// === SALIDA ===
+
+func (g *Generator) line(format string, args ...any) {
+	g.b.WriteString(strings.Repeat("    ", g.indent))
+	fmt.Fprintf(&g.b, format, args...)
+	g.b.WriteString("\n")
+}
+
+func (g *Generator) fresh(prefix string) string {
+	g.names++
+	return fmt.Sprintf("%s%d", prefix, g.names)
+}
+
+// === ÁMBITOS ===
+
+func (g *Generator) push() {
+	g.scope = &genScope{parent: g.scope}
+}
+
+func (g *Generator) pop() {
+	g.scope = g.scope.parent
+}
+
+func (g *Generator) declare(name, typ string, counter bool) {
+	g.scope.variables = append(g.scope.variables, genVariable{name: name, typ: typ, counter: counter})
+}
+
+// visible devuelve las variables de tipo typ visibles desde el ámbito actual;
+// con assignable solo las que se pueden reasignar.
+func (g *Generator) visible(typ string, assignable bool) []genVariable {
+	var vars []genVariable
+	for s := g.scope; s != nil; s = s.parent {
+		for _, v := range s.variables {
+			if (typ == "" || v.typ == typ) && !(assignable && v.counter) {
+				vars = append(vars, v)
+			}
+		}
+	}
+	return vars
+}
+
+func (g *Generator) pick(vars []genVariable) genVariable {
+	return vars[g.rand.Intn(len(vars))]
+}
+
+// === DECLARACIONES ===
+
+// function declara una función que recibe enteros y retorna un entero. Solo
+// ve sus parámetros y llama a las funciones declaradas antes que ella, así
+// que no hay recursión.
+func (g *Generator) function() {
+	name := g.fresh("f")
+	params := 1 + g.rand.Intn(3)
+	g.scope = &genScope{}
+
+	var list []string
+	for range params {
+		param := g.fresh("p")
+		list = append(list, param+" int")
+		g.declare(param, typeInt, false)
+	}
+	g.line("fn %s(%s) int {", name, strings.Join(list, ", "))
+	g.indent++
+	for range g.rand.Intn(4) {
+		g.statement(g.MaxDepth - 1)
+	}
+	g.line("return %s", g.expr(typeInt, g.MaxDepth))
+	g.indent--
+	g.line("}")
+	g.line("")
+
+	g.functions = append(g.functions, genFunction{name: name, params: params})
+}
+
+// === SENTENCIAS ===
+
+func (g *Generator) statement(depth int) {
+	choices := []func(int) bool{g.declaration, g.declaration, g.assignment, g.print, g.print}
+	if depth > 0 {
+		choices = append(choices, g.ifStatement, g.switchStatement, g.whileLoop, g.forLoop)
+	}
+	if g.loops > 0 {
+		choices = append(choices, g.transfer)
+	}
+	// una alternativa que no aplica (asignar sin variables) se reemplaza por
+	// una declaración
+	if !choices[g.rand.Intn(len(choices))](depth) {
+		g.declaration(depth)
+	}
+}
+
+// declaration declara una variable nueva. Dentro de un ciclo no se declara
+// nada: el intérprete no abre un ámbito nuevo en cada iteración y la segunda
+// vuelta fallaría con "la variable ya existe".
+func (g *Generator) declaration(depth int) bool {
+	if g.loops > 0 {
+		return g.assignment(depth) || g.print(depth)
+	}
+	typ := generatedTypes[g.rand.Intn(len(generatedTypes))]
+	name := g.fresh("v")
+	g.line("mut %s = %s", name, g.expr(typ, depth))
+	g.declare(name, typ, false)
+	return true
+}
+
+func (g *Generator) assignment(depth int) bool {
+	vars := g.visible("", true)
+	if len(vars) == 0 {
+		return false
+	}
+	v := g.pick(vars)
+	if v.typ == typeInt && g.rand.Intn(3) == 0 {
+		op := []string{"+=", "-="}[g.rand.Intn(2)]
+		g.line("%s %s %s", v.name, op, g.expr(typeInt, depth))
+		return true
+	}
+	g.line("%s = %s", v.name, g.expr(v.typ, depth))
+	return true
+}
+
+func (g *Generator) print(depth int) bool {
+	var args []string
+	for range 1 + g.rand.Intn(3) {
+		typ := generatedTypes[g.rand.Intn(len(generatedTypes))]
+		args = append(args, g.argument(typ, depth))
+	}
+	g.line("println(%s)", strings.Join(args, ", "))
+	return true
+}
+
+func (g *Generator) block(depth int) {
+	g.indent++
+	g.push()
+	for range 1 + g.rand.Intn(3) {
+		g.statement(depth - 1)
+	}
+	g.pop()
+	g.indent--
+}
+
+func (g *Generator) ifStatement(depth int) bool {
+	g.line("if %s {", g.expr(typeBool, depth))
+	g.block(depth)
+	for range g.rand.Intn(2) {
+		g.line("} else if %s {", g.expr(typeBool, depth))
+		g.block(depth)
+	}
+	if g.rand.Intn(2) == 0 {
+		g.line("} else {")
+		g.block(depth)
+	}
+	g.line("}")
+	return true
+}
+
+func (g *Generator) switchStatement(depth int) bool {
+	g.line("switch %s {", g.expr(typeInt, depth))
+	used := map[int]bool{}
+	for range 1 + g.rand.Intn(3) {
+		value := g.rand.Intn(5)
+		if used[value] {
+			continue
+		}
+		used[value] = true
+		g.line("case %d:", value)
+		g.block(depth)
+	}
+	if g.rand.Intn(2) == 0 {
+		g.line("default:")
+		g.block(depth)
+	}
+	g.line("}")
+	return true
+}
+
+// whileLoop genera "for i++ < n { ... }". El incremento va en la condición
+// para que el minimizador, que borra líneas, no pueda dejar un ciclo
+// infinito.
+func (g *Generator) whileLoop(depth int) bool {
+	counter := g.fresh("i")
+	g.loop(counter, func() {
+		g.line("for %s++ < %d {", counter, 1+g.rand.Intn(4))
+		g.block(depth)
+		g.line("}")
+	})
+	return true
+}
+
+// forLoop genera "for j = 0; j < n; j++ { ... }"; la variable se declara
+// aparte porque la gramática no declara en la inicialización.
+func (g *Generator) forLoop(depth int) bool {
+	counter := g.fresh("j")
+	g.loop(counter, func() {
+		g.line("for %s = 0; %s < %d; %s++ {", counter, counter, 1+g.rand.Intn(4), counter)
+		g.block(depth)
+		g.line("}")
+	})
+	return true
+}
+
+// loop declara el contador de un ciclo y genera el ciclo con emit. Como
+// dentro de un ciclo no se puede declarar, los contadores de los ciclos
+// anidados se declaran antes del ciclo más externo y se reinician en su
+// lugar.
+func (g *Generator) loop(counter string, emit func()) {
+	g.declare(counter, typeInt, true)
+	g.loops++
+	defer func() { g.loops-- }()
+	if g.loops > 1 {
+		g.hoisted = append(g.hoisted, counter)
+		g.line("%s = 0", counter)
+		emit()
+		return
+	}
+
+	start := g.b.Len()
+	g.line("mut %s = 0", counter)
+	emit()
+	if len(g.hoisted) == 0 {
+		return
+	}
+	code := g.b.String()
+	g.b.Reset()
+	g.b.WriteString(code[:start])
+	for _, name := range g.hoisted {
+		g.line("mut %s = 0", name)
+	}
+	g.b.WriteString(code[start:])
+	g.hoisted = nil
+}
+
+// transfer genera un break o un continue condicional dentro de un ciclo.
+func (g *Generator) transfer(depth int) bool {
+	keyword := "break"
+	if g.rand.Intn(2) == 0 {
+		keyword = "continue"
+	}
+	g.line("if %s {", g.expr(typeBool, depth))
+	g.indent++
+	g.line("%s", keyword)
+	g.indent--
+	g.line("}")
+	return true
+}
+
+// === EXPRESIONES ===
+
+// argument genera una expresión para usar como argumento de una llamada.
+func (g *Generator) argument(typ string, depth int) string {
+	expr := g.expr(typ, depth)
+	if isAtom(expr) {
+		return expr
+	}
+	return "(" + expr + ")"
+}
+
+// isAtom indica si expr es un identificador o un literal sin operadores.
+func isAtom(expr string) bool {
+	if strings.HasPrefix(expr, `"`) && strings.Count(expr, `"`) == 2 && strings.HasSuffix(expr, `"`) {
+		return true
+	}
+	for _, r := range expr {
+		if !(r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return expr != ""
+}
+
+func (g *Generator) expr(typ string, depth int) string {
+	switch typ {
+	case typeInt:
+		return g.intExpr(depth)
+	case typeBool:
+		return g.boolExpr(depth)
+	}
+	return g.stringExpr(depth)
+}
+
+func (g *Generator) variableOr(typ string, literal func() string) string {
+	if vars := g.visible(typ, false); len(vars) > 0 && g.rand.Intn(2) == 0 {
+		return g.pick(vars).name
+	}
+	return literal()
+}
+
+func (g *Generator) intExpr(depth int) string {
+	atom := func() string {
+		return g.variableOr(typeInt, func() string { return fmt.Sprint(g.rand.Intn(100)) })
+	}
+	if depth <= 0 {
+		return atom()
+	}
+
+	switch g.rand.Intn(8) {
+	case 0, 1:
+		return atom()
+	case 2, 3:
+		op := []string{"+", "-", "*"}[g.rand.Intn(3)]
+		return fmt.Sprintf("%s %s %s", g.intExpr(depth-1), op, g.intOperand(depth-1))
+	case 4:
+		// el divisor es un literal distinto de cero
+		op := []string{"/", "%"}[g.rand.Intn(2)]
+		return fmt.Sprintf("%s %s %d", g.intOperand(depth-1), op, 1+g.rand.Intn(9))
+	case 5:
+		return fmt.Sprintf("-%s", g.intOperand(depth-1))
+	case 6:
+		if len(g.functions) > 0 {
+			f := g.functions[g.rand.Intn(len(g.functions))]
+			var args []string
+			for range f.params {
+				args = append(args, g.argument(typeInt, depth-1))
+			}
+			return fmt.Sprintf("%s(%s)", f.name, strings.Join(args, ", "))
+		}
+	case 7:
+		if g.Natives {
+			// con un solo dígito la cadena se leería como un carácter
+			return fmt.Sprintf(`atoi("%d")`, 10+g.rand.Intn(990))
+		}
+	}
+	return atom()
+}
+
+// intOperand genera un operando entre paréntesis si no es atómico, para que
+// la precedencia no dependa de cómo se anidaron las llamadas.
+func (g *Generator) intOperand(depth int) string {
+	expr := g.intExpr(depth)
+	if isAtom(expr) {
+		return expr
+	}
+	return "(" + expr + ")"
+}
+
+func (g *Generator) boolExpr(depth int) string {
+	atom := func() string {
+		return g.variableOr(typeBool, func() string { return []string{"true", "false"}[g.rand.Intn(2)] })
+	}
+	if depth <= 0 {
+		return atom()
+	}
+
+	switch g.rand.Intn(6) {
+	case 0:
+		return atom()
+	case 1, 2:
+		op := []string{"<", "<=", ">", ">=", "==", "!="}[g.rand.Intn(6)]
+		return fmt.Sprintf("%s %s %s", g.intOperand(depth-1), op, g.intOperand(depth-1))
+	case 3, 4:
+		op := []string{"&&", "||"}[g.rand.Intn(2)]
+		return fmt.Sprintf("(%s) %s (%s)", g.boolExpr(depth-1), op, g.boolExpr(depth-1))
+	}
+	return fmt.Sprintf("!(%s)", g.boolExpr(depth-1))
+}
+
+var words = []string{"hola", "mundo", "vlang", "arm", "uno", "dos", "si", "no"}
+
+func (g *Generator) stringExpr(depth int) string {
+	if g.Natives && depth > 0 && g.rand.Intn(5) == 0 {
+		if vars := g.visible("", false); len(vars) > 0 {
+			return fmt.Sprintf("TypeOf(%s)", g.pick(vars).name)
+		}
+	}
+	// las cadenas de un solo carácter se leerían como caracteres
+	return g.variableOr(typeString, func() string { return `"` + words[g.rand.Intn(len(words))] + `"` })
+}
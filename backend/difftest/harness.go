@@ -0,0 +1,222 @@
+// Package difftest compara el intérprete con el traductor ARM64: ejecuta un
+// mismo programa en el ReplVisitor y, compilado con compiler.Compile, en
+// qemu o en el emulador de compiler/emulator, y compara lo que imprimen.
+//
+// Además de programas escritos a mano, Generator produce programas aleatorios
+// bien tipados y Minimize reduce los que divergen hasta unas pocas líneas.
+package difftest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/analysis"
+	"main.go/ast"
+	"main.go/compiler"
+	"main.go/compiler/emulator"
+	"main.go/compiler/ir"
+	"main.go/logging"
+	"main.go/repl"
+)
+
+// Estados de una comparación.
+const (
+	Match    = "match"    // las dos salidas coinciden
+	Diverged = "diverged" // las salidas difieren o el binario falló
+	Skipped  = "skipped"  // el programa no sirve para comparar
+)
+
+// DefaultStatementLimit es la cantidad de sentencias que el intérprete
+// ejecuta antes de abandonar el programa. El límite corta la ejecución desde
+// el Tracer con un pánico que atraviesa los ciclos y las funciones.
+const DefaultStatementLimit = 100_000
+
+// Executor ensambla y ejecuta el código ARM64 y devuelve lo que imprimió. Si
+// el binario falla, devuelve también la salida que alcanzó a producir.
+type Executor func(assembly string) (string, error)
+
+// ExitError es el error de un Executor cuando el binario terminó con un
+// código de salida distinto de cero, como tras un error de ejecución.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("el programa terminó con código %d", e.Code)
+}
+
+// Emulate es el Executor que usa el emulador en lugar de qemu.
+func Emulate(assembly string) (string, error) {
+	result, err := emulator.Run(assembly)
+	if result == nil {
+		return "", err
+	}
+	if err == nil && result.ExitCode != 0 {
+		err = &ExitError{Code: result.ExitCode}
+	}
+	return result.Stdout, err
+}
+
+// Result es el resultado de comparar un programa.
+type Result struct {
+	Status      string
+	Reason      string // por qué se omitió o en qué difieren las salidas
+	Interpreted string // salida del intérprete
+	Compiled    string // salida del binario ARM64
+}
+
+// Translator traduce un programa a ARM64 y devuelve los errores de
+// traducción, con la forma de compiler.Compile.
+type Translator func(tree antlr.ParseTree) (string, []string)
+
+// Harness compara programas con un Executor dado.
+type Harness struct {
+	Execute        Executor
+	Translate      Translator // por defecto CompileAt(ir.O0)
+	StatementLimit int
+}
+
+// NewHarness crea un Harness que ejecuta el código ARM64 con execute.
+func NewHarness(execute Executor) *Harness {
+	return &Harness{Execute: execute, Translate: CompileAt(ir.O0), StatementLimit: DefaultStatementLimit}
+}
+
+// CompileAt es el Translator que usa compiler.Compile con el nivel de
+// optimización dado. Compara el binario sea cual sea el traductor que lo
+// generó.
+func CompileAt(level int) Translator {
+	return func(tree antlr.ParseTree) (string, []string) {
+		assembly, errors, _ := compiler.Compile(tree, "", level, logging.New(logging.Compiler))
+		return assembly, errors
+	}
+}
+
+// Compare ejecuta code en el intérprete y compilado, y compara las salidas.
+// Los programas con errores de compilación, con errores de ejecución en el
+// intérprete o que el traductor no soporta se omiten: solo interesa lo que
+// ambos aceptan y aun así imprimen distinto.
+func (h *Harness) Compare(code string) *Result {
+	analyzed := analysis.Analyze(code)
+	tree := analyzed.Tree
+	if err := firstError(analyzed.ErrorTable); err != "" {
+		return &Result{Status: Skipped, Reason: "el programa no compila: " + err}
+	}
+
+	interpreted, err := h.interpret(analyzed.Program)
+	if err != "" {
+		return &Result{Status: Skipped, Reason: "el intérprete falló: " + err, Interpreted: interpreted}
+	}
+
+	assembly, translationErrors := h.Translate(tree)
+	if len(translationErrors) > 0 {
+		return &Result{Status: Skipped, Reason: "el traductor no soporta el programa: " + translationErrors[0], Interpreted: interpreted}
+	}
+
+	result := &Result{Status: Match, Interpreted: interpreted}
+	compiled, execErr := h.Execute(assembly)
+	result.Compiled = compiled
+	if execErr != nil {
+		result.Status = Diverged
+		result.Reason = "el binario falló: " + execErr.Error()
+	} else if diff := firstDifference(normalize(interpreted), normalize(compiled)); diff != "" {
+		result.Status = Diverged
+		result.Reason = diff
+	}
+	return result
+}
+
+// errStatementLimit detiene al intérprete desde el Tracer.
+var errStatementLimit = errors.New("límite de sentencias")
+
+// statementLimiter es un Tracer que solo cuenta sentencias.
+type statementLimiter struct {
+	remaining int
+	exceeded  bool
+}
+
+func (l *statementLimiter) OnStatement(repl.TraceEvent) {
+	l.remaining--
+	if l.remaining < 0 {
+		l.exceeded = true
+		panic(errStatementLimit)
+	}
+}
+func (l *statementLimiter) OnCall(repl.TraceEvent)      {}
+func (l *statementLimiter) OnReturn(repl.TraceEvent)    {}
+func (l *statementLimiter) OnAssign(repl.TraceEvent)    {}
+func (l *statementLimiter) OnScopePush(repl.TraceEvent) {}
+func (l *statementLimiter) OnScopePop(repl.TraceEvent)  {}
+
+// interpret ejecuta el programa ya validado y devuelve su salida y el primer
+// error de ejecución, si lo hubo.
+func (h *Harness) interpret(program *ast.Program) (output, errMsg string) {
+	errorTable := repl.NewErrorTable()
+	dclVisitor := repl.NewDclVisitor(errorTable)
+	dclVisitor.VisitProgram(program)
+	visitor := repl.NewVisitor(dclVisitor)
+	visitor.StructNames = dclVisitor.StructNames
+	limiter := &statementLimiter{remaining: h.StatementLimit}
+	visitor.Tracer = limiter
+
+	defer func() {
+		output = visitor.Console.GetOutput()
+		r := recover()
+		switch {
+		case limiter.exceeded:
+			errMsg = fmt.Sprintf("se superó el límite de %d sentencias", h.StatementLimit)
+		case r != nil:
+			errMsg = fmt.Sprintf("pánico: %v", r)
+		default:
+			errMsg = firstError(errorTable)
+		}
+	}()
+	visitor.VisitProgram(program)
+	return
+}
+
+func firstError(errorTable *repl.ErrorTable) string {
+	for _, err := range errorTable.Errors {
+		if err.Severity != "warning" {
+			return fmt.Sprintf("%d:%d: %s", err.Line, err.Column, err.Msg)
+		}
+	}
+	return ""
+}
+
+// normalize deja solo las líneas no vacías: la consola del intérprete
+// duplica los saltos de línea de println, así que las líneas vacías no se
+// pueden comparar.
+func normalize(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// firstDifference describe la primera línea en que difieren las salidas, o
+// devuelve "" si son iguales.
+func firstDifference(interpreted, compiled []string) string {
+	for i := 0; i < len(interpreted) || i < len(compiled); i++ {
+		var want, got string
+		if i < len(interpreted) {
+			want = fmt.Sprintf("%q", interpreted[i])
+		} else {
+			want = "fin de la salida"
+		}
+		if i < len(compiled) {
+			got = fmt.Sprintf("%q", compiled[i])
+		} else {
+			got = "fin de la salida"
+		}
+		if want != got {
+			return fmt.Sprintf("línea %d: el intérprete imprimió %s y el binario %s", i+1, want, got)
+		}
+	}
+	return ""
+}
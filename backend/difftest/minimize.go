@@ -0,0 +1,120 @@
+package difftest
+
+import "strings"
+
+// Minimize reduce un programa que diverge borrando líneas mientras la
+// divergencia se mantenga (delta debugging). Los candidatos que dejan de
+// compilar o que el traductor no soporta se descartan solos, porque Compare
+// los omite en lugar de reportarlos como divergentes.
+func (h *Harness) Minimize(code string) string {
+	return minimize(code, func(candidate string) bool {
+		return h.Compare(candidate).Status == Diverged
+	})
+}
+
+// minimize alterna dos pasadas hasta que ninguna reduce más: ddmin sobre las
+// líneas, y otra que borra o desenvuelve cada bloque entre llaves, porque la
+// cabecera y la llave que lo cierra no son líneas contiguas.
+func minimize(code string, interesting func(string) bool) string {
+	lines := strings.Split(strings.TrimRight(code, "\n"), "\n")
+	test := func(candidate []string) bool { return interesting(join(candidate)) }
+	for {
+		before := len(lines)
+		lines = ddmin(lines, test)
+		lines = reduceBlocks(lines, test)
+		if len(lines) == before {
+			return join(lines)
+		}
+	}
+}
+
+func join(lines []string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// without devuelve lines sin las posiciones indicadas.
+func without(lines []string, skip ...int) []string {
+	var out []string
+	for i, line := range lines {
+		removed := false
+		for _, s := range skip {
+			removed = removed || s == i
+		}
+		if !removed {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// ddmin prueba a quitar bloques de líneas cada vez más chicos y se queda con
+// el primer resultado que sigue siendo interesante.
+func ddmin(lines []string, test func([]string) bool) []string {
+	chunks := 2
+	for len(lines) >= 2 {
+		size := (len(lines) + chunks - 1) / chunks
+		reduced := false
+		for start := 0; start < len(lines); start += size {
+			end := min(start+size, len(lines))
+			candidate := append(append([]string{}, lines[:start]...), lines[end:]...)
+			if test(candidate) {
+				lines = candidate
+				chunks = max(chunks-1, 2)
+				reduced = true
+				break
+			}
+		}
+		if reduced {
+			continue
+		}
+		if chunks >= len(lines) {
+			break
+		}
+		chunks = min(chunks*2, len(lines))
+	}
+	return lines
+}
+
+// reduceBlocks intenta, para cada línea que abre un bloque, borrar el bloque
+// entero o solo la cabecera y la llave de cierre, dejando el cuerpo. Los
+// bloques con "} else" no se tocan: su cierre también abre otro bloque.
+func reduceBlocks(lines []string, test func([]string) bool) []string {
+	for i := 0; i < len(lines); i++ {
+		open := strings.TrimSpace(lines[i])
+		if !strings.HasSuffix(open, "{") || strings.HasPrefix(open, "}") {
+			continue
+		}
+		end := closingLine(lines, i)
+		if end < 0 || strings.TrimSpace(lines[end]) != "}" {
+			continue
+		}
+
+		block := make([]int, 0, end-i+1)
+		for j := i; j <= end; j++ {
+			block = append(block, j)
+		}
+		if candidate := without(lines, block...); test(candidate) {
+			lines = candidate
+			i--
+			continue
+		}
+		if candidate := without(lines, i, end); test(candidate) {
+			lines = candidate
+			i--
+		}
+	}
+	return lines
+}
+
+// closingLine devuelve la línea con la llave que cierra el bloque abierto en
+// la línea start, o -1 si no se cierra.
+func closingLine(lines []string, start int) int {
+	depth := 0
+	for i := start; i < len(lines); i++ {
+		depth += strings.Count(lines[i], "{") - strings.Count(lines[i], "}")
+		if depth <= 0 {
+			return i
+		}
+	}
+	return -1
+}
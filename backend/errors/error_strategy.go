@@ -31,7 +31,11 @@ Funcion para traducir mensajes de error de ANTLR a un formato más amigable.
 En este caso se traduce al español.
 */
 func (es *CustomErrorStrategy) ReportInputMisMatch(recognizer antlr.Parser, e *antlr.InputMisMatchException) {
+	// al principio de la entrada no hay token anterior
 	t1 := recognizer.GetTokenStream().LT(-1)
+	if t1 == nil {
+		t1 = e.GetOffendingToken()
+	}
 	msg := "Se recibió " + t1.GetText() + ", se esperaba " + es.GetExpectedTokens(recognizer).String()
 	recognizer.NotifyErrorListeners(msg, e.GetOffendingToken(), e)
 }
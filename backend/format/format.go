@@ -0,0 +1,324 @@
+// Package format implementa el formateador de VLang. Recorre el flujo de
+// tokens, incluidos los comentarios del canal oculto, y decide los saltos de
+// línea, la indentación y los espacios con la información del parse tree.
+//
+// Estilo canónico:
+//   - una sentencia por línea, indentación de 4 espacios
+//   - la llave que abre un bloque queda en la línea de su sentencia y la que
+//     lo cierra en su propia línea; "} else {" va en una sola línea
+//   - los case y default de un switch van un nivel adentro del switch y sus
+//     sentencias otro nivel más
+//   - espacios alrededor de los operadores binarios y las asignaciones y
+//     después de las comas; ninguno en llamadas, índices, tipos y literales
+//     de vector o de struct
+//   - se conserva como máximo una línea en blanco entre sentencias
+//   - los comentarios se conservan; los que estaban al final de una línea se
+//     quedan al final de la línea
+package format
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/analysis"
+	compiler "main.go/grammar"
+	"main.go/repl"
+)
+
+const indentUnit = "    "
+
+// Error se devuelve cuando el código tiene errores léxicos o sintácticos;
+// un programa que no se puede analizar no se formatea.
+type Error struct {
+	Errors []repl.Error
+}
+
+func (e *Error) Error() string {
+	lines := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		lines = append(lines, fmt.Sprintf("%s %d:%d: %s", err.GetDisplayName(), err.Line, err.Column, err.Msg))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Source devuelve code en el estilo canónico. Formatear un resultado de
+// Source lo deja igual.
+func Source(code string) (string, error) {
+	analyzed := analysis.Parse(code)
+	if analyzed.ErrorTable.HasErrors() {
+		return "", &Error{Errors: analyzed.ErrorTable.Errors}
+	}
+
+	analyzed.Stream.Fill()
+	p := newPrinter()
+	p.mark(analyzed.Tree)
+	return p.print(analyzed.Stream.GetAllTokens()), nil
+}
+
+// === MARCAS DEL PARSE TREE ===
+
+// printer guarda, por índice de token, lo que el parse tree dice de cada
+// token y después los imprime en orden.
+type printer struct {
+	newlineAfter map[int]bool // fin de sentencia o de atributo de struct
+	blockOpen    map[int]int  // "{" de un bloque -> índice de su "}"
+	blockClose   map[int]bool
+	caseColon    map[int]bool // ":" de un case o default
+	caseEnd      map[int]int  // último token de un case -> cuántos case terminan ahí
+	unary        map[int]bool // operador unario
+	typeStart    map[int]bool // primer token de un tipo vector o matriz
+	typeInner    map[int]bool // resto de los tokens del tipo
+
+	out        strings.Builder
+	indent     int
+	atLineHead bool
+	prev       antlr.Token // último token impreso, incluidos comentarios
+	pending    bool        // el siguiente token va en una línea nueva
+}
+
+func newPrinter() *printer {
+	return &printer{
+		newlineAfter: make(map[int]bool),
+		blockOpen:    make(map[int]int),
+		blockClose:   make(map[int]bool),
+		caseColon:    make(map[int]bool),
+		caseEnd:      make(map[int]int),
+		unary:        make(map[int]bool),
+		typeStart:    make(map[int]bool),
+		typeInner:    make(map[int]bool),
+		atLineHead:   true,
+	}
+}
+
+// braces marca las llaves hijas directas de ctx como llaves de bloque.
+func (p *printer) braces(ctx antlr.ParserRuleContext) {
+	open := -1
+	for _, child := range ctx.GetChildren() {
+		terminal, ok := child.(antlr.TerminalNode)
+		if !ok {
+			continue
+		}
+		token := terminal.GetSymbol()
+		switch token.GetTokenType() {
+		case compiler.VLangGrammarLBRACE:
+			open = token.GetTokenIndex()
+		case compiler.VLangGrammarRBRACE:
+			if open >= 0 {
+				p.blockOpen[open] = token.GetTokenIndex()
+			}
+			p.blockClose[token.GetTokenIndex()] = true
+		}
+	}
+}
+
+func (p *printer) mark(tree antlr.Tree) {
+	ctx, ok := tree.(antlr.ParserRuleContext)
+	if !ok {
+		return
+	}
+
+	switch c := ctx.(type) {
+	case *compiler.StmtContext, *compiler.StructAttrContext:
+		p.newlineAfter[c.GetStop().GetTokenIndex()] = true
+
+	case *compiler.IfChainContext, *compiler.ElseStmtContext, *compiler.WhileStmtContext,
+		*compiler.ForStmtCondContext, *compiler.ForAssCondContext, *compiler.ForStmtContext,
+		*compiler.FuncDeclContext, *compiler.BlockIndContext, *compiler.SwitchStmtContext,
+		*compiler.StructDeclContext:
+		p.braces(c)
+
+	case *compiler.SwitchCaseContext:
+		p.caseColon[c.COLON().GetSymbol().GetTokenIndex()] = true
+		p.caseEnd[c.GetStop().GetTokenIndex()]++
+
+	case *compiler.DefaultCaseContext:
+		p.caseColon[c.COLON().GetSymbol().GetTokenIndex()] = true
+		p.caseEnd[c.GetStop().GetTokenIndex()]++
+
+	case *compiler.UnaryExprContext:
+		p.unary[c.GetStart().GetTokenIndex()] = true
+
+	case *compiler.Vector_typeContext, *compiler.Matrix_typeContext:
+		p.typeStart[c.GetStart().GetTokenIndex()] = true
+		for i := c.GetStart().GetTokenIndex() + 1; i <= c.GetStop().GetTokenIndex(); i++ {
+			p.typeInner[i] = true
+		}
+	}
+
+	for _, child := range ctx.GetChildren() {
+		p.mark(child)
+	}
+}
+
+// === IMPRESIÓN ===
+
+func isComment(token antlr.Token) bool {
+	return token.GetChannel() == antlr.TokenHiddenChannel
+}
+
+// endLine es la última línea que ocupa el token en el código original.
+func endLine(token antlr.Token) int {
+	return token.GetLine() + strings.Count(token.GetText(), "\n")
+}
+
+func (p *printer) print(tokens []antlr.Token) string {
+	for _, token := range tokens {
+		if token.GetTokenType() == antlr.TokenEOF {
+			continue
+		}
+		if isComment(token) {
+			p.comment(token)
+		} else {
+			p.token(token)
+		}
+	}
+
+	result := strings.TrimRight(p.out.String(), " \n")
+	if result == "" {
+		return ""
+	}
+	return result + "\n"
+}
+
+// newline termina la línea actual y agrega una línea en blanco si el código
+// original la tenía antes de next.
+func (p *printer) newline(next antlr.Token) {
+	p.out.WriteString("\n")
+	blank := p.prev != nil && next.GetLine()-endLine(p.prev) > 1
+	if blank && !p.opensBody(p.prev) && !p.blockClose[next.GetTokenIndex()] {
+		p.out.WriteString("\n")
+	}
+	p.atLineHead = true
+	p.pending = false
+}
+
+// opensBody indica si token abre un bloque o el cuerpo de un case; después
+// de esos tokens no se dejan líneas en blanco.
+func (p *printer) opensBody(token antlr.Token) bool {
+	_, open := p.blockOpen[token.GetTokenIndex()]
+	return open || p.caseColon[token.GetTokenIndex()]
+}
+
+func (p *printer) write(text string) {
+	if p.atLineHead {
+		p.out.WriteString(strings.Repeat(indentUnit, p.indent))
+		p.atLineHead = false
+	}
+	p.out.WriteString(text)
+}
+
+func (p *printer) token(token antlr.Token) {
+	index := token.GetTokenIndex()
+	kind := token.GetTokenType()
+
+	emptyBlock := false
+	if p.blockClose[index] {
+		p.indent--
+		emptyBlock = p.prev != nil && p.blockOpen[p.prev.GetTokenIndex()] == index
+		p.pending = !emptyBlock
+	}
+	if kind == compiler.VLangGrammarCASE_KW || kind == compiler.VLangGrammarDEFAULT_KW {
+		p.pending = true
+	}
+	// "} else" va en la misma línea
+	if kind == compiler.VLangGrammarELSE_KW && p.prev != nil && p.blockClose[p.prev.GetTokenIndex()] {
+		p.pending = false
+	}
+
+	switch {
+	case p.pending && p.prev != nil:
+		p.newline(token)
+	case p.prev != nil && !p.atLineHead && !emptyBlock && p.space(p.prev, token):
+		p.write(" ")
+	}
+	p.write(token.GetText())
+	p.prev = token
+
+	if _, ok := p.blockOpen[index]; ok {
+		p.indent++
+		p.pending = true
+	}
+	if p.caseColon[index] {
+		p.indent++
+		p.pending = true
+	}
+	if p.newlineAfter[index] || p.blockClose[index] {
+		p.pending = true
+	}
+	p.indent -= p.caseEnd[index]
+}
+
+// comment imprime un comentario. Los que estaban en la misma línea que el
+// token anterior se quedan ahí; los demás van en su propia línea con la
+// indentación actual.
+func (p *printer) comment(token antlr.Token) {
+	trailing := p.prev != nil && token.GetLine() == endLine(p.prev)
+
+	if trailing {
+		p.write(" ")
+	} else if p.prev != nil {
+		p.newline(token)
+	}
+	p.write(token.GetText())
+	p.prev = token
+
+	// después de un comentario de línea siempre hay un salto; un comentario
+	// de bloque en medio de una línea deja que la línea siga
+	if token.GetTokenType() == compiler.VLangLexerLINE_COMMENT || !trailing {
+		p.pending = true
+	}
+}
+
+// space decide si va un espacio entre dos tokens de la misma línea.
+func (p *printer) space(prev, next antlr.Token) bool {
+	if isComment(prev) {
+		return true
+	}
+
+	prevKind, nextKind := prev.GetTokenType(), next.GetTokenType()
+	_, prevOpensBlock := p.blockOpen[prev.GetTokenIndex()]
+	_, nextOpensBlock := p.blockOpen[next.GetTokenIndex()]
+
+	switch {
+	case p.typeInner[next.GetTokenIndex()]:
+		return false
+	case nextOpensBlock:
+		return true
+	case p.unary[prev.GetTokenIndex()]:
+		return false
+	case p.typeStart[next.GetTokenIndex()]:
+		return prevKind != compiler.VLangGrammarLPAREN && prevKind != compiler.VLangGrammarLBRACE
+	}
+
+	switch nextKind {
+	case compiler.VLangGrammarRPAREN, compiler.VLangGrammarRBRACK, compiler.VLangGrammarRBRACE,
+		compiler.VLangGrammarCOMMA, compiler.VLangGrammarDOT, compiler.VLangGrammarSEMI,
+		compiler.VLangGrammarCOLON, compiler.VLangGrammarINC, compiler.VLangGrammarDEC:
+		return false
+	case compiler.VLangGrammarLPAREN:
+		// llamadas a función y vectores repetidos: []int(len: 3, ...)
+		if prevKind == compiler.VLangGrammarID {
+			return false
+		}
+	case compiler.VLangGrammarLBRACK:
+		// acceso por índice
+		if prevKind == compiler.VLangGrammarID || prevKind == compiler.VLangGrammarRBRACK || prevKind == compiler.VLangGrammarRPAREN {
+			return false
+		}
+	case compiler.VLangGrammarLBRACE:
+		// literales de vector, matriz y struct: []int{1, 2}, Punto{x: 1}
+		if prevKind == compiler.VLangGrammarID {
+			return false
+		}
+	}
+
+	switch prevKind {
+	case compiler.VLangGrammarLPAREN, compiler.VLangGrammarLBRACK, compiler.VLangGrammarDOT:
+		return false
+	case compiler.VLangGrammarLBRACE:
+		return prevOpensBlock
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	compiler "main.go/grammar"
+)
+
+// corpus cubre todas las sentencias de la gramática, escritas sin ningún
+// estilo en particular y con comentarios en distintas posiciones.
+var corpus = map[string]string{
+	"declaraciones": `mut a int=5
+mut b=3.5
+mut v []int
+x int = 2
+nums=[]int{1,2,3}
+m=[][]int{ {1,2},{3,4} }
+r=[]int(len:3,init:0)`,
+
+	"expresiones": `println(a+2*3,a/2,a%3,-a,!true)
+println((a>3&&b<1.0)||c!=d, -(a+1))
+a+=1
+a-=2
+v[0]=v[1]+v[2]
+m[0][1]+=1
+println(a++, a--)`,
+
+	"funciones": `fn suma(x int,y int)int{return x+y}
+fn vacia(){}
+fn fact(n int) int {
+  if n<=1 {return 1}
+  return n*fact(n)
+}
+println(suma(1,2), fact(5))`,
+
+	"control": `if a>100{println("big")}else if a>10{println("mid")}else{println("small")}
+while i<3{i+=1
+if i==2{break}
+continue}
+for i<5 { i+=1 }
+for i=0;i<3;i++{println(i)}
+for idx,val in v {println(idx,val)}
+{ mut local = 1 }`,
+
+	"switch": `switch a {
+case 1: println("uno")
+case 2:
+println("dos")
+println("x")
+default:println("otro")}`,
+
+	"structs": `struct Punto{int x
+[]int ys
+Otro o}
+p = Punto{x:1,ys:{1}}
+p.x = 2
+println(p.x,p.ys.count)
+v.append(4)
+v.remove(at 0)`,
+
+	"comentarios": `// cabecera
+
+/* bloque
+   de varias lineas */
+mut a = 1 // al final
+mut b /* en medio */ = 2
+if a > 0 { // despues de la llave
+    // dentro del bloque
+    println(a)
+
+
+    // despues de dos lineas en blanco
+}
+// final`,
+}
+
+// tokens devuelve el texto de cada token del código, incluidos los
+// comentarios del canal oculto.
+func tokens(t *testing.T, code string) []string {
+	t.Helper()
+	lexer := compiler.NewVLangLexer(antlr.NewInputStream(code))
+	var list []string
+	for _, token := range lexer.GetAllTokens() {
+		list = append(list, token.GetText())
+	}
+	return list
+}
+
+func TestRoundTrip(t *testing.T) {
+	for name, code := range corpus {
+		t.Run(name, func(t *testing.T) {
+			formatted, err := Source(code)
+			if err != nil {
+				t.Fatalf("el corpus no se pudo formatear: %v", err)
+			}
+
+			// el formateador solo cambia espacios y saltos de línea
+			before, after := tokens(t, code), tokens(t, formatted)
+			if strings.Join(before, "\x00") != strings.Join(after, "\x00") {
+				t.Fatalf("los tokens cambiaron:\nantes:   %q\ndespués: %q", before, after)
+			}
+
+			again, err := Source(formatted)
+			if err != nil {
+				t.Fatalf("la salida formateada no se pudo volver a analizar: %v\n%s", err, formatted)
+			}
+			if again != formatted {
+				t.Fatalf("el formato no es idempotente:\nprimera pasada:\n%s\nsegunda pasada:\n%s", formatted, again)
+			}
+		})
+	}
+}
+
+func TestCanonicalStyle(t *testing.T) {
+	cases := []struct {
+		name, code, want string
+	}{
+		{
+			name: "bloques",
+			code: "fn doble(n int)int{\nmut r=n*2 // doble\nif r>10{return r}else{r= -r}\n\n\nreturn r}",
+			want: `fn doble(n int) int {
+    mut r = n * 2 // doble
+    if r > 10 {
+        return r
+    } else {
+        r = -r
+    }
+
+    return r
+}
+`,
+		},
+		{
+			name: "switch",
+			code: "switch v[0] {\ncase 1: println(\"uno\")\ncase 2:\ndefault: println(\"otro\") }",
+			want: `switch v[0] {
+    case 1:
+        println("uno")
+    case 2:
+    default:
+        println("otro")
+}
+`,
+		},
+		{
+			name: "literales y tipos",
+			code: "mut v []int={1,2}\nm = [][]int{{1,2},{3,4}}\np = Punto{x:1,y: 2}\nfor i = 0; i < 3; i++ { }",
+			want: `mut v []int = {1, 2}
+m = [][]int{{1, 2}, {3, 4}}
+p = Punto{x: 1, y: 2}
+for i = 0; i < 3; i++ {}
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Source(tc.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Fatalf("salida inesperada:\n%s\nse esperaba:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	_, err := Source("mut = 5")
+	formatErr, ok := err.(*Error)
+	if !ok || len(formatErr.Errors) == 0 {
+		t.Fatalf("se esperaba un *Error con errores de sintaxis, se obtuvo %v", err)
+	}
+}
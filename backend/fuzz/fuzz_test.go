@@ -0,0 +1,152 @@
+package fuzz
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"main.go/analysis"
+	"main.go/ast"
+	"main.go/repl"
+)
+
+// Límites de ejecución de los objetivos: bajos para que cada entrada
+// termine rápido aunque tenga un ciclo infinito o una recursión sin fin.
+var limits = repl.Limits{MaxSteps: 20_000, MaxCallDepth: 200, MaxStringLength: 1 << 16}
+
+// addGoldenSeeds agrega al corpus los programas de testdata/golden.
+func addGoldenSeeds(f *testing.F) {
+	paths, err := filepath.Glob(filepath.Join("..", "testdata", "golden", "*.vch"))
+	if err != nil || len(paths) == 0 {
+		f.Fatalf("no se encontraron los programas golden: %v", err)
+	}
+	for _, path := range paths {
+		code, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(code))
+	}
+}
+
+// interpret corre el programa como lo hace el servidor: análisis y, si no
+// hubo errores, la ejecución con límites. Devuelve el visitor para
+// inspeccionar su estado.
+func interpret(code string) (*repl.ReplVisitor, *repl.ErrorTable) {
+	a := analysis.Parse(code)
+	if !a.Check() {
+		return nil, a.ErrorTable
+	}
+
+	visitor := repl.NewVisitor(a.DclVisitor)
+	visitor.StructNames = a.DclVisitor.StructNames
+	visitor.Limits = limits
+	visitor.VisitProgram(a.Program)
+	return visitor, a.ErrorTable
+}
+
+func FuzzParse(f *testing.F) {
+	addGoldenSeeds(f)
+	f.Add("mut x = ")
+	f.Add("fn f( {")
+	f.Add("if { } else")
+	f.Add("\"sin cerrar")
+	f.Add("/* comentario sin cerrar")
+	f.Add("struct {}")
+
+	f.Fuzz(func(t *testing.T, code string) {
+		if a := analysis.Parse(code); a.Tree == nil {
+			t.Fatal("el parser no devolvió un árbol")
+		}
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	data := []byte("semilla del generador")
+	if Generate(data) != Generate(data) {
+		t.Fatal("la misma entrada generó programas distintos")
+	}
+	for n := range 300 {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i*31 + n*7)
+		}
+		code := Generate(data)
+		if a := analysis.Parse(code); a.ErrorTable.HasErrors() {
+			t.Fatalf("entrada de %d bytes: %s\n%s", n, a.ErrorTable.Errors[0].Msg, code)
+		}
+	}
+}
+
+func FuzzInterpret(f *testing.F) {
+	addGoldenSeeds(f)
+	f.Add("fn f(n int) int {\n    return f(n)\n}\nprintln(f(1))\n")
+	f.Add("mut i = 0\nfor true {\n}\n")
+	f.Add("mut v = []int {1}\nprintln(v[5])\n")
+
+	f.Fuzz(func(t *testing.T, code string) {
+		interpret(code)
+	})
+}
+
+func FuzzGenerated(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte("generador"))
+	f.Add([]byte{2, 0, 1, 9, 3, 4, 1, 7, 5, 0, 6})
+	f.Add([]byte{0, 1, 3, 0, 2, 5, 8, 13, 21, 34, 55, 89, 144, 233})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		code := Generate(data)
+		visitor, errorTable := interpret(code)
+		// lo que produce el generador siempre pasa el parser
+		for _, err := range errorTable.Errors {
+			if err.Type == repl.LexicalError || err.Type == repl.SyntaxError {
+				t.Fatalf("el programa generado no es válido: %s\n%s", err.Msg, code)
+			}
+		}
+		if visitor != nil && visitor.ScopeTrace.CurrentScope != visitor.ScopeTrace.GlobalScope {
+			t.Fatalf("la ejecución no volvió al scope global\n%s", code)
+		}
+	})
+}
+
+// interpolationScope es un programa que deja variables de cada tipo para
+// que las interpolaciones tengan algo que reemplazar.
+const interpolationScope = `struct Punto {
+    int x
+    int y
+}
+mut a = 5
+mut f = 2.5
+mut s = "hola"
+mut r = "x"
+mut b = true
+mut n = nil
+v = []int {1, 2}
+m = [][]int { {1, 2}, {3, 4} }
+mut e []int
+mut p = Punto{x: 1, y: 2}
+`
+
+func FuzzInterpolateString(f *testing.F) {
+	for _, seed := range []string{"", "$", "${", "${}", "$a", "${a}", "$$a", "${s}${f}$b", "$v y $m", "$p.x", "$noExiste", "${a", "$e$n$r", "ñ$a\\n"} {
+		f.Add(seed)
+	}
+
+	visitor, errorTable := interpret(interpolationScope)
+	if visitor == nil || errorTable.HasErrors() {
+		f.Fatalf("el programa de variables tiene errores: %+v", errorTable.Errors)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		errors := len(errorTable.Errors)
+		result := visitor.InterpolateString(input, ast.Pos{})
+		if !strings.Contains(input, "$") && result != input {
+			t.Errorf("una cadena sin $ cambió: %q -> %q", input, result)
+		}
+		if !repl.HasInterpolation(input) && len(errorTable.Errors) != errors {
+			t.Errorf("una cadena sin interpolación reportó errores: %q", input)
+		}
+	})
+}
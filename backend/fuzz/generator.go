@@ -0,0 +1,314 @@
+// Package fuzz tiene los objetivos de fuzzing nativo de Go para el lexer, el
+// parser, los visitors del intérprete y la interpolación de cadenas.
+//
+// Los bytes aleatorios casi nunca pasan del parser, así que Generate usa la
+// entrada del fuzzer como una secuencia de decisiones sobre la gramática y
+// produce programas sintácticamente válidos que llegan al análisis
+// semántico y a la ejecución. Los programas no tienen por qué estar bien
+// tipados: los errores semánticos también son código que hay que probar.
+//
+// Para correr un objetivo:
+//
+//	go test ./fuzz -run='^$' -fuzz=FuzzGenerated -fuzztime=1m
+package fuzz
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Nombres que usan los programas generados. Son pocos a propósito: así las
+// sentencias se refieren a variables, funciones y structs ya declarados.
+var (
+	variables = []string{"a", "b", "c", "i", "s", "v", "m", "p"}
+	functions = []string{"f", "g"}
+	builtins  = []string{"print", "println", "atoi", "parseFloat", "TypeOf", "indexOf", "join", "len", "append"}
+	types     = []string{"int", "float", "string", "bool", "rune", "[]int", "[][]int", "[]string", "Punto"}
+	fields    = []string{"x", "y", "len", "nombre"}
+	operators = []string{"+", "-", "*", "/", "%", "<", "<=", ">", ">=", "==", "!=", "&&", "||"}
+	literals  = []string{`""`, `"hola"`, `"$a"`, `"${s} y $b"`, `"$noExiste"`, `"\n\t\""`, `"x"`, `"12"`, `"3.5"`}
+)
+
+// maxDepth acota el anidamiento de sentencias y expresiones.
+const maxDepth = 4
+
+// generator lee las decisiones de data; cuando se acaban, todas valen cero
+// y el programa termina con las producciones más cortas.
+type generator struct {
+	data   []byte
+	pos    int
+	out    strings.Builder
+	indent int
+}
+
+// Generate convierte data en un programa de VLang sintácticamente válido.
+// La misma entrada produce siempre el mismo programa.
+func Generate(data []byte) string {
+	g := &generator{data: data}
+	for g.pos < len(g.data) {
+		g.topLevel()
+	}
+	return g.out.String()
+}
+
+func (g *generator) next() byte {
+	if g.pos >= len(g.data) {
+		return 0
+	}
+	b := g.data[g.pos]
+	g.pos++
+	return b
+}
+
+// choose devuelve un número en [0, n).
+func (g *generator) choose(n int) int {
+	return int(g.next()) % n
+}
+
+func pick[T any](g *generator, options []T) T {
+	return options[g.choose(len(options))]
+}
+
+func (g *generator) line(format string, args ...any) {
+	g.out.WriteString(strings.Repeat("    ", g.indent))
+	fmt.Fprintf(&g.out, format, args...)
+	g.out.WriteString("\n")
+}
+
+// block escribe header, las sentencias del cuerpo y la llave de cierre.
+func (g *generator) block(header string, depth int) {
+	g.line("%s", strings.TrimSpace(header+" {"))
+	g.indent++
+	g.body(depth)
+	g.indent--
+	g.line("}")
+}
+
+func (g *generator) body(depth int) {
+	for range g.choose(4) {
+		g.stmt(depth + 1)
+	}
+}
+
+// === SENTENCIAS ===
+
+func (g *generator) topLevel() {
+	switch g.choose(6) {
+	case 0:
+		g.funcDecl()
+	case 1:
+		g.structDecl()
+	default:
+		g.stmt(0)
+	}
+}
+
+func (g *generator) funcDecl() {
+	name := pick(g, functions)
+	var params []string
+	for i := range g.choose(3) {
+		params = append(params, fmt.Sprintf("%s %s", variables[i], pick(g, types)))
+	}
+	header := fmt.Sprintf("fn %s(%s)", name, strings.Join(params, ", "))
+	if g.choose(3) > 0 {
+		header += " " + pick(g, types)
+	}
+	g.block(header, 1)
+}
+
+func (g *generator) structDecl() {
+	g.line("struct Punto {")
+	g.indent++
+	for i := range 1 + g.choose(3) {
+		g.line("%s %s", pick(g, types), fields[i])
+	}
+	g.indent--
+	g.line("}")
+}
+
+func (g *generator) stmt(depth int) {
+	if depth >= maxDepth {
+		g.line("println(%s)", g.expr(maxDepth))
+		return
+	}
+
+	switch g.choose(16) {
+	case 0:
+		g.line("mut %s %s = %s", pick(g, variables), pick(g, types), g.expr(depth))
+	case 1:
+		g.line("mut %s = %s", pick(g, variables), g.expr(depth))
+	case 2:
+		g.line("mut %s %s", pick(g, variables), pick(g, types))
+	case 3:
+		g.line("%s = []%s {%s}", pick(g, variables), pick(g, []string{"int", "string", "float"}), g.exprList(depth))
+	case 4:
+		g.line("%s = [][]int { {%s}, {%s} }", pick(g, variables), g.exprList(depth), g.exprList(depth))
+	case 5:
+		g.line("%s %s %s", g.target(), pick(g, []string{"=", "+=", "-="}), g.expr(depth))
+	case 6:
+		g.line("%s %s %s", g.item(depth), pick(g, []string{"=", "+=", "-="}), g.expr(depth))
+	case 7:
+		g.line("%s", g.call(depth))
+	case 8:
+		g.ifStmt(depth)
+	case 9:
+		g.switchStmt(depth)
+	case 10:
+		g.block(fmt.Sprintf("for %s", g.expr(depth)), depth)
+	case 11:
+		v := pick(g, variables)
+		g.block(fmt.Sprintf("for %s = %s; %s; %s", v, g.expr(depth), g.expr(depth), g.expr(depth)), depth)
+	case 12:
+		g.block(fmt.Sprintf("for %s, %s in %s", pick(g, variables), pick(g, variables), g.expr(depth)), depth)
+	case 13:
+		if transfer := pick(g, []string{"break", "continue", "return", "return "}); transfer == "return " {
+			g.line("return %s", g.expr(depth))
+		} else {
+			g.line("%s", transfer)
+		}
+	case 14:
+		g.block("", depth)
+	default:
+		g.line("%s.%s", g.item(depth), g.call(depth))
+	}
+}
+
+func (g *generator) ifStmt(depth int) {
+	g.line("if %s {", g.expr(depth))
+	g.indent++
+	g.body(depth)
+	g.indent--
+	for range g.choose(3) {
+		g.line("} else if %s {", g.expr(depth))
+		g.indent++
+		g.body(depth)
+		g.indent--
+	}
+	if g.choose(2) == 0 {
+		g.line("} else {")
+		g.indent++
+		g.body(depth)
+		g.indent--
+	}
+	g.line("}")
+}
+
+func (g *generator) switchStmt(depth int) {
+	g.line("switch %s {", g.expr(depth))
+	g.indent++
+	for range g.choose(4) {
+		g.line("case %s:", g.expr(depth))
+		g.indent++
+		g.body(depth)
+		g.indent--
+	}
+	if g.choose(2) == 0 {
+		g.line("default:")
+		g.indent++
+		g.body(depth)
+		g.indent--
+	}
+	g.indent--
+	g.line("}")
+}
+
+// === EXPRESIONES ===
+
+// target es un nombre con o sin acceso a atributos: a, p.x, p.x.y.
+func (g *generator) target() string {
+	name := pick(g, variables)
+	for range g.choose(3) {
+		name += "." + pick(g, fields)
+	}
+	return name
+}
+
+// item es un acceso a un vector o a una matriz: v[0], m[i][1].
+func (g *generator) item(depth int) string {
+	name := pick(g, variables)
+	for range 1 + g.choose(2) {
+		name += "[" + g.expr(depth+1) + "]"
+	}
+	return name
+}
+
+func (g *generator) call(depth int) string {
+	var name string
+	switch g.choose(3) {
+	case 0:
+		name = pick(g, functions)
+	case 1:
+		name = g.target()
+	default:
+		name = pick(g, builtins)
+	}
+	var args []string
+	for range g.choose(4) {
+		// un argumento que empieza con un nombre seguido de una expresión
+		// es un argumento con nombre; los demás van entre paréntesis
+		if g.choose(4) == 0 {
+			args = append(args, pick(g, variables)+" "+g.atom(depth))
+		} else {
+			args = append(args, "("+g.expr(depth+1)+")")
+		}
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+}
+
+func (g *generator) exprList(depth int) string {
+	var items []string
+	for range g.choose(4) {
+		items = append(items, g.expr(depth+1))
+	}
+	return strings.Join(items, ", ")
+}
+
+func (g *generator) expr(depth int) string {
+	if depth >= maxDepth {
+		return g.atom(depth)
+	}
+
+	switch g.choose(12) {
+	case 0, 1:
+		return fmt.Sprintf("%s %s %s", g.expr(depth+1), pick(g, operators), g.expr(depth+1))
+	case 2:
+		return pick(g, []string{"-", "!"}) + g.atom(depth)
+	case 3:
+		return "(" + g.expr(depth+1) + ")"
+	case 4:
+		return g.call(depth)
+	case 5:
+		return g.item(depth)
+	case 6:
+		return g.item(depth) + "." + pick(g, fields)
+	case 7:
+		return g.item(depth) + "." + g.call(depth)
+	case 8:
+		return "{" + g.exprList(depth) + "}"
+	case 9:
+		return fmt.Sprintf("[]%s(count: %s, value: %s)", pick(g, []string{"int", "string"}), g.expr(depth+1), g.expr(depth+1))
+	case 10:
+		return fmt.Sprintf("Punto{x: %s, y: %s}", g.expr(depth+1), g.expr(depth+1))
+	default:
+		return g.atom(depth)
+	}
+}
+
+func (g *generator) atom(depth int) string {
+	switch g.choose(8) {
+	case 0:
+		return fmt.Sprint(g.choose(256))
+	case 1:
+		return fmt.Sprintf("%d.%d", g.choose(100), g.choose(100))
+	case 2:
+		return pick(g, literals)
+	case 3:
+		return pick(g, []string{"true", "false", "nil"})
+	case 4:
+		return pick(g, variables) + pick(g, []string{"++", "--"})
+	case 5:
+		return g.target()
+	default:
+		return pick(g, variables)
+	}
+}
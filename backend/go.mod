@@ -5,9 +5,8 @@ go 1.24.3
 require (
 	github.com/antlr4-go/antlr/v4 v4.13.1
 	github.com/gorilla/mux v1.8.1
+	github.com/gorilla/websocket v1.5.3
 	github.com/rs/cors v1.11.1
 )
 
-require (
-	golang.org/x/exp v0.0.0-20250606033433-dcc06ee1d476 // indirect
-)
+require golang.org/x/exp v0.0.0-20250606033433-dcc06ee1d476 // indirect
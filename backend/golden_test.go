@@ -0,0 +1,345 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/compiler/ir"
+	"main.go/difftest"
+	interpeter "main.go/grammar"
+	"main.go/repl"
+)
+
+// Suite de conformidad: cada programa de testdata/golden/*.vch tiene junto a
+// él lo que se espera de él:
+//
+//	nombre.out        salida estándar del intérprete
+//	nombre.err        errores del intérprete, uno por línea (si los hay)
+//	nombre.arm64.err  errores del traductor ARM64 (si los hay)
+//	nombre.arm64.out  salida del binario ARM64, solo si difiere de nombre.out;
+//	                  si el binario termina con un código distinto de cero
+//	                  termina en "[el binario ARM64 terminó con código N]"
+//
+// Con -update los archivos se regeneran a partir del comportamiento actual:
+//
+//	go test -run Golden -update
+//
+// Los programas ARM64 se ejecutan en qemu si están instalados
+// aarch64-linux-gnu-as, aarch64-linux-gnu-ld y qemu-aarch64, y si no en el
+// emulador de compiler/emulator.
+var update = flag.Bool("update", false, "regenera los archivos esperados de testdata/golden")
+
+const goldenDir = "testdata/golden"
+
+// goldenPrograms devuelve las rutas de los programas de la suite.
+func goldenPrograms(t *testing.T) []string {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(goldenDir, "*.vch"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("no hay programas en %s", goldenDir)
+	}
+	return paths
+}
+
+// golden compara got con el archivo esperado, o lo escribe con -update. Un
+// archivo que no existe equivale a uno vacío, y con -update un resultado
+// vacío borra el archivo.
+func golden(t *testing.T, path, got string) {
+	t.Helper()
+	if *update {
+		if got == "" {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+			return
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s no coincide\n--- esperado\n%s\n--- obtenido\n%s", path, want, got)
+	}
+}
+
+// errorLines escribe los errores de la tabla como en la línea de comandos,
+// sin el nombre del archivo.
+func errorLines(errorTable *repl.ErrorTable) string {
+	var b strings.Builder
+	for _, err := range errorTable.Errors {
+		fmt.Fprintf(&b, "%d:%d: %s: %s\n", err.Line, err.Column, err.GetDisplayName(), err.Msg)
+	}
+	return b.String()
+}
+
+// interpret ejecuta el programa como "vlang run" y devuelve la salida y los
+// errores. Un pánico del intérprete se reporta como un error más, para que
+// el resto de la suite siga corriendo.
+func interpret(t *testing.T, path string) (output, errs string) {
+	t.Helper()
+	c, err := parseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Check() {
+		return "", errorLines(c.ErrorTable)
+	}
+
+	visitor := repl.NewVisitor(c.DclVisitor)
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errs = fmt.Sprintf("pánico: %v\n", r)
+			}
+		}()
+		visitor.VisitProgram(c.Program)
+	}()
+	return visitor.Console.GetOutput(), errorLines(c.ErrorTable) + errs
+}
+
+func TestGoldenInterpreter(t *testing.T) {
+	for _, path := range goldenPrograms(t) {
+		base := strings.TrimSuffix(path, ".vch")
+		t.Run(filepath.Base(base), func(t *testing.T) {
+			output, errs := interpret(t, path)
+			golden(t, base+".out", output)
+			golden(t, base+".err", errs)
+		})
+	}
+}
+
+// TestGoldenVM corre cada programa como "vlang run --engine vm" y exige la
+// misma salida y los mismos errores que el intérprete. Los programas que la
+// VM no compila se saltean con el motivo; -update no los toca porque los
+// archivos son del intérprete.
+func TestGoldenVM(t *testing.T) {
+	for _, path := range goldenPrograms(t) {
+		base := strings.TrimSuffix(path, ".vch")
+		t.Run(filepath.Base(base), func(t *testing.T) {
+			if *update {
+				t.Skip("los archivos esperados los escribe TestGoldenInterpreter")
+			}
+			c, err := parseFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !c.Check() {
+				t.Skip("el programa tiene errores de compilación")
+			}
+
+			console, fallback := c.run(engineVM)
+			if fallback != nil {
+				t.Skip(fallback)
+			}
+			golden(t, base+".out", console.GetOutput())
+			golden(t, base+".err", errorLines(c.ErrorTable))
+		})
+	}
+}
+
+func TestGoldenARM64(t *testing.T) {
+	execute := arm64Executor(false)
+	for _, path := range goldenPrograms(t) {
+		base := strings.TrimSuffix(path, ".vch")
+		t.Run(filepath.Base(base), func(t *testing.T) {
+			c, err := parseFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !c.Check() {
+				t.Skip("el programa tiene errores de compilación")
+			}
+
+			assembly, translationErrors, _ := translateToARM64(c.Tree, c.path, ir.O0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			// el traductor recorre mapas, así que el orden de sus errores varía
+			// de una corrida a otra
+			sort.Strings(translationErrors)
+			var errs strings.Builder
+			for _, msg := range translationErrors {
+				fmt.Fprintln(&errs, msg)
+			}
+			golden(t, base+".arm64.err", errs.String())
+			if len(translationErrors) > 0 {
+				return
+			}
+
+			// el mensaje de error depende de si corrió en qemu o en el
+			// emulador, así que solo se registra el código de salida o que
+			// el binario falló
+			output, err := execute(assembly)
+			var exit *difftest.ExitError
+			if errors.As(err, &exit) {
+				output += fmt.Sprintf("\n[el binario ARM64 terminó con código %d]\n", exit.Code)
+			} else if err != nil {
+				output += "\n[el binario ARM64 falló]\n"
+			}
+
+			// la salida ARM64 solo necesita su propio archivo si difiere de la
+			// del intérprete
+			expected, _ := os.ReadFile(base + ".out")
+			if *update && output == string(expected) {
+				output = ""
+			} else if !*update {
+				if _, err := os.Stat(base + ".arm64.out"); os.IsNotExist(err) {
+					if output != string(expected) {
+						t.Errorf("la salida ARM64 no coincide con %s.out\n--- esperado\n%s\n--- obtenido\n%s", base, expected, output)
+					}
+					return
+				}
+			}
+			golden(t, base+".arm64.out", output)
+		})
+	}
+}
+
+// assembler devuelve el comando que ensambla un archivo .s para AArch64 en
+// output, o nil si no hay ningún ensamblador instalado.
+func assembler(source, output string) *exec.Cmd {
+	if _, err := exec.LookPath("aarch64-linux-gnu-as"); err == nil {
+		return exec.Command("aarch64-linux-gnu-as", "-o", output, source)
+	}
+	if _, err := exec.LookPath("llvm-mc"); err == nil {
+		return exec.Command("llvm-mc", "-triple=aarch64-linux-gnu", "-filetype=obj", "-o", output, source)
+	}
+	return nil
+}
+
+// TestGoldenAssemble pasa el código que genera el traductor, sin tocarlo,
+// por un ensamblador real: lo que se muestra en el IDE es lo que se ejecuta.
+func TestGoldenAssemble(t *testing.T) {
+	if assembler("", "") == nil {
+		t.Skip("no hay aarch64-linux-gnu-as ni llvm-mc")
+	}
+	dir := t.TempDir()
+
+	for _, path := range goldenPrograms(t) {
+		base := filepath.Base(strings.TrimSuffix(path, ".vch"))
+		for _, level := range []int{ir.O0, ir.O1} {
+			t.Run(fmt.Sprintf("%s/O%d", base, level), func(t *testing.T) {
+				c, err := parseFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !c.Check() {
+					t.Skip("el programa tiene errores de compilación")
+				}
+				assembly, translationErrors, _ := translateToARM64(c.Tree, c.path, level, slog.New(slog.NewTextHandler(io.Discard, nil)))
+				if len(translationErrors) > 0 {
+					t.Skip("el programa tiene errores de traducción")
+				}
+
+				source := filepath.Join(dir, fmt.Sprintf("%s_O%d.s", base, level))
+				if err := os.WriteFile(source, []byte(assembly+"\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if output, err := assembler(source, source+".o").CombinedOutput(); err != nil {
+					t.Errorf("el ensamblador rechazó el código: %v\n%s", err, output)
+				}
+			})
+		}
+	}
+}
+
+// unreachable son las alternativas de la gramática que ningún programa
+// puede producir.
+var unreachable = map[string]string{
+	"NumericRange":              "ninguna regla usa range",
+	"InterpolatedStringLiteral": "StringLiteral siempre gana para STRING_LITERAL",
+	"InterpolatedString":        "solo se llega desde InterpolatedStringLiteral",
+}
+
+// grammarAlternatives devuelve el nombre de cada alternativa etiquetada y de
+// cada regla sin etiquetas, a partir de los métodos del visitor generado.
+func grammarAlternatives() []string {
+	visitor := reflect.TypeOf((*interpeter.VLangGrammarVisitor)(nil)).Elem()
+	var names []string
+	for i := 0; i < visitor.NumMethod(); i++ {
+		name, ok := strings.CutPrefix(visitor.Method(i).Name, "Visit")
+		if !ok || name == "" || name == "Children" || name == "Terminal" || name == "ErrorNode" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TestGoldenCoverage exige que los programas de la suite usen cada
+// alternativa de la gramática y cada token del lexer. Las alternativas solo
+// cuentan en programas sin errores léxicos ni sintácticos, porque la
+// recuperación de errores construye nodos incompletos.
+func TestGoldenCoverage(t *testing.T) {
+	seenNodes := make(map[string]bool)
+	seenTokens := make(map[int]bool)
+
+	for _, path := range goldenPrograms(t) {
+		c, err := parseFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c.Stream.Fill()
+		for _, token := range c.Stream.GetAllTokens() {
+			seenTokens[token.GetTokenType()] = true
+		}
+
+		if c.ErrorTable.HasErrors() {
+			continue
+		}
+		var walk func(tree antlr.Tree)
+		walk = func(tree antlr.Tree) {
+			if _, ok := tree.(antlr.ParserRuleContext); ok {
+				seenNodes[strings.TrimSuffix(reflect.TypeOf(tree).Elem().Name(), "Context")] = true
+			}
+			for _, child := range tree.GetChildren() {
+				walk(child)
+			}
+		}
+		walk(c.Tree)
+	}
+
+	for _, name := range grammarAlternatives() {
+		_, excluded := unreachable[name]
+		// las etiquetas en minúscula de la gramática generan contextos con
+		// la inicial en mayúscula
+		covered := seenNodes[name] || seenNodes[strings.ToUpper(name[:1])+name[1:]]
+		if covered && excluded {
+			t.Errorf("la alternativa %s figura como inalcanzable pero algún programa la usa", name)
+		}
+		if !covered && !excluded {
+			t.Errorf("ningún programa de %s usa la alternativa %s", goldenDir, name)
+		}
+	}
+
+	lexer := interpeter.NewVLangLexer(antlr.NewInputStream(""))
+	for tokenType, name := range lexer.SymbolicNames {
+		// WS se descarta en el lexer y nunca llega al flujo de tokens
+		if name == "" || name == "WS" {
+			continue
+		}
+		if !seenTokens[tokenType] {
+			t.Errorf("ningún programa de %s usa el token %s", goldenDir, name)
+		}
+	}
+}
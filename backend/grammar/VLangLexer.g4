@@ -87,5 +87,5 @@ fragment ESC_SEQ: '\\' [btnfr"'\\]
 
 // Commentarios
 WS : [ \t\r\n]+ -> skip ;
-LINE_COMMENT  : '//' ~[\r\n]* -> skip ;
-BLOCK_COMMENT : '/*' .*? '*/' -> skip ;
\ No newline at end of file
+LINE_COMMENT  : '//' ~[\r\n]* -> channel(HIDDEN) ;
+BLOCK_COMMENT : '/*' .*? '*/' -> channel(HIDDEN) ;
\ No newline at end of file
@@ -221,13 +221,13 @@ func vlanglexerLexerInit() {
 		0, 0, 332, 333, 5, 47, 0, 0, 333, 337, 1, 0, 0, 0, 334, 336, 8, 5, 0, 0,
 		335, 334, 1, 0, 0, 0, 336, 339, 1, 0, 0, 0, 337, 335, 1, 0, 0, 0, 337,
 		338, 1, 0, 0, 0, 338, 340, 1, 0, 0, 0, 339, 337, 1, 0, 0, 0, 340, 341,
-		6, 55, 0, 0, 341, 112, 1, 0, 0, 0, 342, 343, 5, 47, 0, 0, 343, 344, 5,
+		6, 55, 1, 0, 341, 112, 1, 0, 0, 0, 342, 343, 5, 47, 0, 0, 343, 344, 5,
 		42, 0, 0, 344, 348, 1, 0, 0, 0, 345, 347, 9, 0, 0, 0, 346, 345, 1, 0, 0,
 		0, 347, 350, 1, 0, 0, 0, 348, 349, 1, 0, 0, 0, 348, 346, 1, 0, 0, 0, 349,
 		351, 1, 0, 0, 0, 350, 348, 1, 0, 0, 0, 351, 352, 5, 42, 0, 0, 352, 353,
-		5, 47, 0, 0, 353, 354, 1, 0, 0, 0, 354, 355, 6, 56, 0, 0, 355, 114, 1,
+		5, 47, 0, 0, 353, 354, 1, 0, 0, 0, 354, 355, 6, 56, 1, 0, 355, 114, 1,
 		0, 0, 0, 13, 0, 271, 276, 282, 287, 289, 303, 311, 316, 318, 327, 337,
-		348, 1, 6, 0, 0,
+		348, 2, 6, 0, 0, 0, 1, 0,
 	}
 	deserializer := antlr.NewATNDeserializer(nil)
 	staticData.atn = deserializer.Deserialize(staticData.serializedATN)
@@ -0,0 +1,253 @@
+// Package lint implementa el linter de VLang: un conjunto de reglas que
+// recorren el árbol tipado y reportan advertencias sobre código que es
+// válido pero probablemente incorrecto. Las advertencias van a la tabla de
+// errores con severidad "warning" y no impiden la ejecución.
+//
+// Cada regla tiene un identificador que sirve para activarla o desactivarla
+// en la configuración y para suprimirla con un comentario:
+//
+//	mut x = 1 // vlang:ignore unused-variable
+//
+//	// vlang:ignore float-equality, empty-block
+//	if a == 0.5 {}
+//
+// Un comentario al final de una línea suprime las advertencias de esa línea;
+// uno en su propia línea suprime las de la línea siguiente. Sin reglas
+// después de "vlang:ignore" se suprimen todas.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/ast"
+	compiler "main.go/grammar"
+	"main.go/repl"
+)
+
+// Rule es una regla del linter.
+type Rule struct {
+	ID          string
+	Description string
+	check       func(l *Linter, program *ast.Program)
+}
+
+// Rules son todas las reglas disponibles, en el orden en que se ejecutan.
+var Rules = []*Rule{
+	{ID: "unused-variable", Description: "variable declarada que nunca se lee", check: checkUnusedVariables},
+	{ID: "unused-function", Description: "función declarada que nunca se llama", check: checkUnusedFunctions},
+	{ID: "shadowed-name", Description: "declaración que oculta a otra de un scope exterior", check: checkShadowedNames},
+	{ID: "unreachable-code", Description: "sentencias después de return, break o continue", check: checkUnreachableCode},
+	{ID: "assign-in-condition", Description: "condición que modifica una variable con ++ o --", check: checkAssignInCondition},
+	{ID: "empty-block", Description: "bloque vacío sin comentarios", check: checkEmptyBlocks},
+	{ID: "float-equality", Description: "comparación de valores float con == o !=", check: checkFloatEquality},
+}
+
+// RuleByID devuelve la regla con ese identificador, o nil si no existe.
+func RuleByID(id string) *Rule {
+	for _, rule := range Rules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	return nil
+}
+
+// === CONFIGURACIÓN ===
+
+// Config activa o desactiva reglas por identificador. Las reglas que no
+// aparecen en Rules quedan activas.
+//
+//	{"rules": {"shadowed-name": false, "empty-block": false}}
+type Config struct {
+	Rules map[string]bool `json:"rules"`
+}
+
+// Enabled indica si la regla id está activa.
+func (c Config) Enabled(id string) bool {
+	enabled, ok := c.Rules[id]
+	return !ok || enabled
+}
+
+// Validate devuelve un error si la configuración nombra reglas que no existen.
+func (c Config) Validate() error {
+	for id := range c.Rules {
+		if RuleByID(id) == nil {
+			return fmt.Errorf("regla de lint desconocida: %q", id)
+		}
+	}
+	return nil
+}
+
+// LoadConfig lee una configuración en JSON desde path.
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, config.Validate()
+}
+
+// === LINTER ===
+
+// Warning es una advertencia de una regla. Line empieza en 1 y Column en 0,
+// igual que en la tabla de errores.
+type Warning struct {
+	Rule   string `json:"rule"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Msg    string `json:"message"`
+}
+
+// Linter ejecuta las reglas activas y agrega sus advertencias a la tabla de
+// errores.
+type Linter struct {
+	ErrorTable *repl.ErrorTable
+	Config     Config
+
+	rule     *Rule
+	warnings []Warning
+	comments []ast.Pos // inicio de cada comentario del código
+}
+
+func NewLinter(errorTable *repl.ErrorTable, config Config) *Linter {
+	return &Linter{ErrorTable: errorTable, Config: config}
+}
+
+// Lint revisa el programa y devuelve las advertencias ordenadas por
+// posición. tokens es el flujo completo del lexer, incluido el canal oculto,
+// de donde salen los comentarios vlang:ignore y los que justifican un
+// bloque vacío.
+func (l *Linter) Lint(program *ast.Program, tokens []antlr.Token) []Warning {
+	l.warnings = nil
+	if program == nil {
+		return nil
+	}
+
+	l.comments = commentPositions(tokens)
+	for _, rule := range Rules {
+		if !l.Config.Enabled(rule.ID) {
+			continue
+		}
+		l.rule = rule
+		rule.check(l, program)
+	}
+
+	ignored := suppressions(tokens)
+	warnings := make([]Warning, 0, len(l.warnings))
+	for _, warning := range l.warnings {
+		if ignored.covers(warning) {
+			continue
+		}
+		warnings = append(warnings, warning)
+	}
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		if warnings[i].Line != warnings[j].Line {
+			return warnings[i].Line < warnings[j].Line
+		}
+		return warnings[i].Column < warnings[j].Column
+	})
+
+	if l.ErrorTable != nil {
+		for _, warning := range warnings {
+			l.ErrorTable.NewWarning(warning.Line, warning.Column, warning.Msg, warning.Rule)
+		}
+	}
+	return warnings
+}
+
+func (l *Linter) warn(pos ast.Pos, format string, args ...any) {
+	l.warnings = append(l.warnings, Warning{
+		Rule:   l.rule.ID,
+		Line:   pos.Line,
+		Column: pos.Column,
+		Msg:    fmt.Sprintf(format, args...),
+	})
+}
+
+// === COMENTARIOS ===
+
+var ignorePattern = regexp.MustCompile(`^//\s*vlang:ignore\b(.*)$`)
+
+// ignoreAll marca las líneas con un vlang:ignore sin reglas.
+const ignoreAll = "*"
+
+// suppression guarda, por línea, las reglas suprimidas.
+type suppression map[int]map[string]bool
+
+func (s suppression) covers(warning Warning) bool {
+	rules := s[warning.Line]
+	return rules[ignoreAll] || rules[warning.Rule]
+}
+
+// suppressions busca los comentarios vlang:ignore del flujo de tokens.
+func suppressions(tokens []antlr.Token) suppression {
+	result := make(suppression)
+	lastCodeLine := 0
+
+	for _, token := range tokens {
+		if token.GetTokenType() == antlr.TokenEOF {
+			continue
+		}
+		if token.GetChannel() != antlr.TokenHiddenChannel {
+			lastCodeLine = token.GetLine() + strings.Count(token.GetText(), "\n")
+			continue
+		}
+		if token.GetTokenType() != compiler.VLangLexerLINE_COMMENT {
+			continue
+		}
+
+		match := ignorePattern.FindStringSubmatch(strings.TrimSpace(token.GetText()))
+		if match == nil {
+			continue
+		}
+
+		line := token.GetLine()
+		if lastCodeLine != line {
+			line++ // comentario en su propia línea: aplica a la siguiente
+		}
+		if result[line] == nil {
+			result[line] = make(map[string]bool)
+		}
+		ids := strings.FieldsFunc(match[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		if len(ids) == 0 {
+			ids = []string{ignoreAll}
+		}
+		for _, id := range ids {
+			result[line][id] = true
+		}
+	}
+	return result
+}
+
+// commentPositions devuelve la posición de inicio de cada comentario.
+func commentPositions(tokens []antlr.Token) []ast.Pos {
+	var list []ast.Pos
+	for _, token := range tokens {
+		if token.GetChannel() == antlr.TokenHiddenChannel {
+			list = append(list, ast.Pos{Line: token.GetLine(), Column: token.GetColumn()})
+		}
+	}
+	return list
+}
+
+// hasComment indica si algún comentario cae dentro de span.
+func (l *Linter) hasComment(span ast.Span) bool {
+	for _, pos := range l.comments {
+		if span.Contains(pos.Line, pos.Column) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package lint_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"main.go/analysis"
+	"main.go/lint"
+)
+
+// check analiza code y devuelve las advertencias del linter con config.
+func check(t *testing.T, code string, config lint.Config) []lint.Warning {
+	t.Helper()
+	analyzed := analysis.Analyze(code)
+	for _, err := range analyzed.ErrorTable.Errors {
+		t.Fatalf("%d:%d: %s", err.Line, err.Column, err.Msg)
+	}
+	analyzed.Stream.Fill()
+	return lint.NewLinter(nil, config).Lint(analyzed.Program, analyzed.Stream.GetAllTokens())
+}
+
+// withComment agrega comment al final de la línea line (desde 1) o, si own
+// es true, en una línea propia antes de ella.
+func withComment(code string, line int, comment string, own bool) string {
+	lines := strings.Split(code, "\n")
+	if own {
+		indent := lines[line-1][:len(lines[line-1])-len(strings.TrimLeft(lines[line-1], " "))]
+		lines = append(lines[:line-1], append([]string{indent + comment}, lines[line-1:]...)...)
+	} else {
+		lines[line-1] += " " + comment
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Cada regla reporta su advertencia, y un vlang:ignore con su id, o sin
+// reglas, la suprime tanto al final de la línea como en la línea anterior.
+// Un vlang:ignore de otra regla no la suprime.
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule   string
+		code   string
+		line   int
+		column int
+	}{
+		{"unused-variable", "fn f() {\n    mut x = 1\n}\nf()\n", 2, 8},
+		{"unused-function", "fn f() {\n    println(1)\n}\n", 1, 3},
+		{"shadowed-name", "mut x = 1\nfn f() {\n    mut x = 2\n    println(x)\n}\nf()\nprintln(x)\n", 3, 8},
+		{"unreachable-code", "fn f() int {\n    return 1\n    println(2)\n}\nmut r = f()\nprintln(r)\n", 3, 4},
+		{"assign-in-condition", "mut i = 0\nif i++ > 0 {\n    println(i)\n}\n", 2, 3},
+		{"empty-block", "mut i = 0\nif i > 0 {}\n", 2, 0},
+		{"float-equality", "mut a = 0.5\nif a == 0.5 {\n    println(a)\n}\n", 2, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			warnings := check(t, tt.code, lint.Config{})
+			want := lint.Warning{Rule: tt.rule, Line: tt.line, Column: tt.column}
+			if len(warnings) != 1 || warnings[0].Rule != want.Rule || warnings[0].Line != want.Line || warnings[0].Column != want.Column {
+				t.Fatalf("advertencias %+v, se esperaba solo %+v", warnings, want)
+			}
+			if warnings[0].Msg == "" {
+				t.Error("la advertencia no tiene mensaje")
+			}
+
+			disabled := lint.Config{Rules: map[string]bool{tt.rule: false}}
+			if warnings := check(t, tt.code, disabled); len(warnings) != 0 {
+				t.Errorf("con la regla desactivada: %+v", warnings)
+			}
+
+			other := "assign-in-condition"
+			if tt.rule == other {
+				other = "float-equality"
+			}
+			suppressions := []struct {
+				comment    string
+				suppressed bool
+			}{
+				{"// vlang:ignore " + tt.rule, true},
+				{"// vlang:ignore " + other + ", " + tt.rule, true},
+				{"// vlang:ignore", true},
+				{"// vlang:ignore " + other, false},
+				{"// comentario", false},
+			}
+			for _, s := range suppressions {
+				for _, own := range []bool{false, true} {
+					code := withComment(tt.code, tt.line, s.comment, own)
+					got := len(check(t, code, lint.Config{})) == 0
+					if got != s.suppressed {
+						t.Errorf("%q (en su propia línea: %v): suprimida %v, se esperaba %v\n%s", s.comment, own, got, s.suppressed, code)
+					}
+				}
+			}
+		})
+	}
+}
+
+// Un comentario en un bloque vacío lo justifica.
+func TestEmptyBlockWithComment(t *testing.T) {
+	if warnings := check(t, "mut i = 0\nif i > 0 {\n    // nada que hacer\n}\n", lint.Config{}); len(warnings) != 0 {
+		t.Errorf("advertencias %+v", warnings)
+	}
+}
+
+// Las advertencias salen ordenadas por posición y también quedan en la tabla
+// de errores como warnings con su regla.
+func TestWarningsOrderAndErrorTable(t *testing.T) {
+	code := "mut a = 0.5\nfn f() {\n    mut x = 1\n}\nif a == 0.5 {}\n"
+	analyzed := analysis.Analyze(code)
+	analyzed.Stream.Fill()
+	warnings := lint.NewLinter(analyzed.ErrorTable, lint.Config{}).Lint(analyzed.Program, analyzed.Stream.GetAllTokens())
+
+	var got []string
+	for _, w := range warnings {
+		got = append(got, fmt.Sprintf("%d:%d %s", w.Line, w.Column, w.Rule))
+	}
+	want := "2:3 unused-function, 3:8 unused-variable, 5:0 empty-block, 5:5 float-equality"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("advertencias %s, se esperaba %s", strings.Join(got, ", "), want)
+	}
+
+	if len(analyzed.ErrorTable.Errors) != len(warnings) {
+		t.Fatalf("la tabla tiene %d entradas, se esperaban %d", len(analyzed.ErrorTable.Errors), len(warnings))
+	}
+	for i, err := range analyzed.ErrorTable.Errors {
+		if err.Severity != "warning" || err.Rule != warnings[i].Rule {
+			t.Errorf("entrada %d: %+v", i, err)
+		}
+	}
+}
+
+// Validate rechaza reglas que no existen.
+func TestConfigValidate(t *testing.T) {
+	if err := (lint.Config{Rules: map[string]bool{"unused-variable": false}}).Validate(); err != nil {
+		t.Errorf("configuración válida rechazada: %v", err)
+	}
+	if err := (lint.Config{Rules: map[string]bool{"tabs": true}}).Validate(); err == nil {
+		t.Error("se esperaba un error por la regla desconocida")
+	}
+}
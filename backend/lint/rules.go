@@ -0,0 +1,329 @@
+package lint
+
+import (
+	"strings"
+
+	"main.go/ast"
+	"main.go/testrunner"
+	"main.go/value"
+)
+
+// === VARIABLES Y FUNCIONES SIN USO ===
+
+// checkUnusedVariables reporta las variables que nunca se leen. Asignarles
+// un valor con "=" no cuenta como uso; "+=", "-=" y "++" sí, porque leen el
+// valor anterior.
+func checkUnusedVariables(l *Linter, program *ast.Program) {
+	var decls []*ast.VarDecl
+	writes := make(map[*ast.Ident]bool)
+	reads := make(map[*ast.VarDecl]bool)
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.VarDecl:
+			decls = append(decls, n)
+		case *ast.AssignStmt:
+			// la sentencia se visita antes que su destino
+			if target, ok := n.Target.(*ast.Ident); ok && n.Op == "=" {
+				writes[target] = true
+			}
+		case *ast.Ident:
+			if decl, ok := n.Decl.(*ast.VarDecl); ok && n != decl.Name && !writes[n] {
+				reads[decl] = true
+			}
+		}
+		return true
+	})
+
+	for _, decl := range decls {
+		if !reads[decl] {
+			l.warn(decl.Name.Start, "La variable '%s' se declara pero nunca se usa", decl.Name.Name)
+		}
+	}
+}
+
+// checkUnusedFunctions reporta las funciones que nadie llama. Las llamadas
+// recursivas desde la misma función no cuentan, y las pruebas las llama
+// "vlang test".
+func checkUnusedFunctions(l *Linter, program *ast.Program) {
+	var funcs []*ast.FuncDecl
+	used := make(map[*ast.FuncDecl]bool)
+
+	var visit func(node ast.Node, current *ast.FuncDecl)
+	visit = func(node ast.Node, current *ast.FuncDecl) {
+		switch n := node.(type) {
+		case *ast.FuncDecl:
+			funcs = append(funcs, n)
+			current = n
+		case *ast.Ident:
+			if decl, ok := n.Decl.(*ast.FuncDecl); ok && decl != current {
+				used[decl] = true
+			}
+		}
+		for _, child := range ast.Children(node) {
+			visit(child, current)
+		}
+	}
+	visit(program, nil)
+
+	for _, decl := range funcs {
+		if !used[decl] && !strings.HasPrefix(decl.Name.Name, testrunner.Prefix) {
+			l.warn(decl.Name.Start, "La función '%s' se declara pero nunca se llama", decl.Name.Name)
+		}
+	}
+}
+
+// === NOMBRES OCULTOS ===
+
+// shadowScope replica los niveles del BaseScopeTrace que abre el intérprete:
+// uno por bloque, las funciones colgando del scope global y los for con un
+// scope propio para sus variables además del de su cuerpo.
+type shadowScope struct {
+	parent *shadowScope
+	names  map[string]ast.Pos
+}
+
+type shadowWalker struct {
+	l      *Linter
+	global *shadowScope
+	scope  *shadowScope
+}
+
+// checkShadowedNames reporta las declaraciones que ocultan a una variable o
+// parámetro de un scope exterior. Las redeclaraciones en el mismo scope son
+// errores del chequeo estático, no advertencias.
+func checkShadowedNames(l *Linter, program *ast.Program) {
+	global := &shadowScope{names: make(map[string]ast.Pos)}
+	w := &shadowWalker{l: l, global: global, scope: global}
+
+	// igual que en el intérprete, las funciones ven todas las variables
+	// globales sin importar dónde se declaren
+	for _, stmt := range program.Stmts {
+		if _, ok := stmt.(*ast.FuncDecl); !ok {
+			w.stmt(stmt)
+		}
+	}
+	for _, stmt := range program.Stmts {
+		if decl, ok := stmt.(*ast.FuncDecl); ok {
+			w.funcDecl(decl)
+		}
+	}
+}
+
+func (w *shadowWalker) push() {
+	w.scope = &shadowScope{parent: w.scope, names: make(map[string]ast.Pos)}
+}
+
+func (w *shadowWalker) pop() {
+	w.scope = w.scope.parent
+}
+
+func (w *shadowWalker) declare(id *ast.Ident) {
+	if id == nil {
+		return
+	}
+	for scope := w.scope.parent; scope != nil; scope = scope.parent {
+		if pos, ok := scope.names[id.Name]; ok {
+			w.l.warn(id.Start, "'%s' oculta la declaración de la línea %d", id.Name, pos.Line)
+			break
+		}
+	}
+	w.scope.names[id.Name] = id.Start
+}
+
+func (w *shadowWalker) body(stmts []ast.Stmt) {
+	w.push()
+	for _, stmt := range stmts {
+		w.stmt(stmt)
+	}
+	w.pop()
+}
+
+func (w *shadowWalker) stmt(stmt ast.Stmt) {
+	switch s := stmt.(type) {
+	case *ast.VarDecl:
+		w.declare(s.Name)
+	case *ast.BlockStmt:
+		w.body(s.Stmts)
+	case *ast.IfStmt:
+		for _, branch := range s.Branches {
+			w.body(branch.Body)
+		}
+		if s.Else != nil {
+			w.body(s.Else.Stmts)
+		}
+	case *ast.SwitchStmt:
+		for _, clause := range s.Cases {
+			w.body(clause.Body)
+		}
+		if s.Default != nil {
+			w.body(s.Default.Body)
+		}
+	case *ast.WhileStmt:
+		w.body(s.Body)
+	case *ast.ForCondStmt:
+		w.body(s.Body)
+	case *ast.ForClauseStmt:
+		w.push()
+		w.body(s.Body)
+		w.pop()
+	case *ast.ForRange:
+		w.push()
+		w.declare(s.Index)
+		w.declare(s.Value)
+		w.body(s.Body)
+		w.pop()
+	case *ast.FuncDecl:
+		w.funcDecl(s)
+	}
+}
+
+func (w *shadowWalker) funcDecl(decl *ast.FuncDecl) {
+	saved := w.scope
+	w.scope = &shadowScope{parent: w.global, names: make(map[string]ast.Pos)}
+	for _, param := range decl.Params {
+		w.declare(param.Name)
+	}
+	for _, stmt := range decl.Body {
+		w.stmt(stmt)
+	}
+	w.scope = saved
+}
+
+// === BLOQUES ===
+
+// block es una lista de sentencias del programa junto con el nodo que la
+// contiene.
+type block struct {
+	kind  string
+	node  ast.Node
+	stmts []ast.Stmt
+}
+
+// blocks devuelve todos los cuerpos de sentencias del programa, incluido el
+// nivel global.
+func blocks(program *ast.Program) []block {
+	list := []block{{kind: "programa", node: program, stmts: program.Stmts}}
+	elses := make(map[*ast.BlockStmt]bool)
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.IfStmt:
+			if n.Else != nil {
+				elses[n.Else] = true
+				list = append(list, block{kind: "else", node: n.Else, stmts: n.Else.Stmts})
+			}
+		case *ast.IfBranch:
+			list = append(list, block{kind: "if", node: n, stmts: n.Body})
+		case *ast.BlockStmt:
+			if !elses[n] {
+				list = append(list, block{kind: "bloque", node: n, stmts: n.Stmts})
+			}
+		case *ast.CaseClause:
+			list = append(list, block{kind: "case", node: n, stmts: n.Body})
+		case *ast.WhileStmt:
+			list = append(list, block{kind: "while", node: n, stmts: n.Body})
+		case *ast.ForCondStmt:
+			list = append(list, block{kind: "for", node: n, stmts: n.Body})
+		case *ast.ForClauseStmt:
+			list = append(list, block{kind: "for", node: n, stmts: n.Body})
+		case *ast.ForRange:
+			list = append(list, block{kind: "for", node: n, stmts: n.Body})
+		case *ast.FuncDecl:
+			list = append(list, block{kind: "función '" + n.Name.Name + "'", node: n, stmts: n.Body})
+		}
+		return true
+	})
+	return list
+}
+
+// transfer devuelve la palabra clave de una sentencia que siempre sale del
+// bloque, o "" si la ejecución puede seguir a la sentencia siguiente.
+func transfer(stmt ast.Stmt) string {
+	switch stmt.(type) {
+	case *ast.ReturnStmt:
+		return "return"
+	case *ast.BreakStmt:
+		return "break"
+	case *ast.ContinueStmt:
+		return "continue"
+	}
+	return ""
+}
+
+// checkUnreachableCode reporta la primera sentencia después de un return,
+// break o continue del mismo bloque.
+func checkUnreachableCode(l *Linter, program *ast.Program) {
+	for _, b := range blocks(program) {
+		for i, stmt := range b.stmts {
+			if keyword := transfer(stmt); keyword != "" && i+1 < len(b.stmts) {
+				l.warn(b.stmts[i+1].GetSpan().Start, "Código inalcanzable después de %s", keyword)
+				break
+			}
+		}
+	}
+}
+
+// checkEmptyBlocks reporta los bloques sin sentencias. Un case vacío es la
+// forma de no hacer nada para ese valor, y un bloque con un comentario
+// adentro se dejó vacío a propósito.
+func checkEmptyBlocks(l *Linter, program *ast.Program) {
+	for _, b := range blocks(program) {
+		if len(b.stmts) > 0 || b.kind == "programa" || b.kind == "case" {
+			continue
+		}
+		span := b.node.GetSpan()
+		if !l.hasComment(span) {
+			l.warn(span.Start, "Bloque vacío en %s", b.kind)
+		}
+	}
+}
+
+// === CONDICIONES Y COMPARACIONES ===
+
+// checkAssignInCondition reporta los ++ y -- dentro de la condición de un
+// if, while o for. La gramática no tiene asignaciones como expresión, así
+// que son la única forma de modificar una variable al evaluar la condición.
+func checkAssignInCondition(l *Linter, program *ast.Program) {
+	var conditions []ast.Expr
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.IfBranch:
+			conditions = append(conditions, n.Cond)
+		case *ast.WhileStmt:
+			conditions = append(conditions, n.Cond)
+		case *ast.ForCondStmt:
+			conditions = append(conditions, n.Cond)
+		case *ast.ForClauseStmt:
+			conditions = append(conditions, n.Cond)
+		}
+		return true
+	})
+
+	for _, cond := range conditions {
+		if cond == nil {
+			continue
+		}
+		ast.Inspect(cond, func(node ast.Node) bool {
+			if n, ok := node.(*ast.IncDecExpr); ok {
+				l.warn(n.Start, "La condición modifica '%s' con %s", n.X.Name, n.Op)
+			}
+			return true
+		})
+	}
+}
+
+// checkFloatEquality reporta == y != con un operando float; los errores de
+// redondeo hacen que dos cálculos iguales en papel casi nunca coincidan.
+func checkFloatEquality(l *Linter, program *ast.Program) {
+	isFloat := func(expr ast.Expr) bool {
+		return expr != nil && expr.Type() == value.IVOR_FLOAT
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if n, ok := node.(*ast.BinaryExpr); ok && (n.Op == "==" || n.Op == "!=") && (isFloat(n.Left) || isFloat(n.Right)) {
+			l.warn(n.OpPos, "Comparar valores float con '%s' es impreciso; compare la diferencia contra una tolerancia", n.Op)
+		}
+		return true
+	})
+}
@@ -0,0 +1,117 @@
+// Package logging centraliza los loggers del servidor. Cada subsistema
+// (lexer, parser, repl, compiler, http, dap, lsp) tiene su propio logger que agrega el
+// atributo "subsystem" a cada registro.
+//
+// El nivel global por defecto es Info, así que los mensajes por nodo del
+// árbol, que se registran en Debug, no imprimen nada. Una petición puede
+// pedir tracing y en ese caso recibe loggers en nivel Debug sin afectar a
+// las demás peticiones.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Subsistemas con logger propio.
+const (
+	Lexer    = "lexer"
+	Parser   = "parser"
+	Repl     = "repl"
+	Compiler = "compiler"
+	HTTP     = "http"
+	DAP      = "dap"
+	LSP      = "lsp"
+)
+
+var (
+	mu     sync.RWMutex
+	output io.Writer = os.Stderr
+	level            = new(slog.LevelVar) // Info por defecto
+)
+
+// SetLevel cambia el nivel global de todos los loggers que no tienen el
+// tracing activado.
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// ParseLevel interpreta un nivel escrito como "debug", "info", "warn" o
+// "error".
+func ParseLevel(name string) (slog.Level, error) {
+	var l slog.Level
+	err := l.UnmarshalText([]byte(strings.TrimSpace(name)))
+	return l, err
+}
+
+// SetOutput cambia el destino de los registros (stderr por defecto).
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	output = w
+}
+
+// New devuelve el logger del subsistema con el nivel global.
+func New(subsystem string) *slog.Logger {
+	return newLogger(subsystem, level)
+}
+
+// Trace devuelve el logger del subsistema en nivel Debug, para las
+// peticiones que activan el tracing.
+func Trace(subsystem string) *slog.Logger {
+	return newLogger(subsystem, slog.LevelDebug)
+}
+
+// For devuelve Trace(subsystem) si trace es true y New(subsystem) si no.
+func For(subsystem string, trace bool) *slog.Logger {
+	if trace {
+		return Trace(subsystem)
+	}
+	return New(subsystem)
+}
+
+func newLogger(subsystem string, leveler slog.Leveler) *slog.Logger {
+	mu.RLock()
+	w := output
+	mu.RUnlock()
+
+	handler := slog.NewTextHandler(w, &slog.HandlerOptions{Level: leveler})
+	return slog.New(handler).With("subsystem", subsystem)
+}
+
+// texter es cualquier nodo del parse tree.
+type texter interface {
+	GetText() string
+}
+
+type lazyText struct {
+	node texter
+}
+
+func (t lazyText) LogValue() slog.Value {
+	return slog.StringValue(t.node.GetText())
+}
+
+// Text difiere el GetText de un nodo hasta que el registro realmente se
+// imprime. GetText recorre todo el subárbol, así que llamarlo en cada nodo
+// con el nivel Debug apagado es lo que volvía lenta la ejecución.
+func Text(node texter) slog.LogValuer {
+	return lazyText{node: node}
+}
+
+type lazyType struct {
+	node any
+}
+
+func (t lazyType) LogValue() slog.Value {
+	return slog.StringValue(fmt.Sprintf("%T", t.node))
+}
+
+// NodeType difiere el nombre del tipo Go de un nodo, igual que Text.
+func NodeType(node any) slog.LogValuer {
+	return lazyType{node: node}
+}
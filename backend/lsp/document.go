@@ -0,0 +1,510 @@
+package lsp
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/analysis"
+	"main.go/ast"
+	interpeter "main.go/grammar"
+	"main.go/lint"
+	"main.go/repl"
+)
+
+// document es un archivo abierto en el editor con el resultado de su último
+// análisis.
+type document struct {
+	uri         string
+	text        string
+	lines       []string
+	diagnostics []Diagnostic
+
+	// program y report son del último análisis sin errores de sintaxis, para
+	// que hover y el autocompletado sigan funcionando mientras se escribe
+	program *ast.Program
+	report  *repl.ReportTable
+}
+
+// analyze corre el lexer, el parser, el DclVisitor, el chequeo estático y el
+// linter sobre text. previous es el análisis anterior del mismo documento, o
+// nil.
+func analyze(uri, text string, previous *document, lintConfig lint.Config) (doc *document) {
+	doc = &document{uri: uri, text: text, lines: strings.Split(text, "\n")}
+	if previous != nil {
+		doc.program = previous.program
+		doc.report = previous.report
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("error analizando el documento", "uri", uri, "panic", r)
+		}
+	}()
+
+	analyzed := analysis.Parse(text)
+	analyzed.Log = log
+	analyzed.Check()
+	errorTable := analyzed.ErrorTable
+
+	// con errores de sintaxis no se corre el DclVisitor ni hay árbol tipado
+	if analyzed.Program != nil {
+		analyzed.Stream.Fill()
+		lint.NewLinter(errorTable, lintConfig).Lint(analyzed.Program, analyzed.Stream.GetAllTokens())
+
+		report := analyzed.DclVisitor.ScopeTrace.Report()
+		doc.program = analyzed.Program
+		doc.report = &report
+	}
+
+	doc.diagnostics = make([]Diagnostic, 0, len(errorTable.Errors))
+	for _, err := range errorTable.Errors {
+		diagnostic := Diagnostic{
+			Range:    doc.wordRange(err.Line-1, err.Column),
+			Severity: severityOf(err.Severity),
+			Source:   "vlang",
+			Message:  err.Msg,
+		}
+		if err.Type == repl.LintWarning {
+			diagnostic.Source = "vlang-lint"
+			diagnostic.Code = err.Rule
+		}
+		doc.diagnostics = append(doc.diagnostics, diagnostic)
+	}
+	return doc
+}
+
+func severityOf(severity string) int {
+	switch severity {
+	case "warning":
+		return severityWarning
+	case "info":
+		return severityInformation
+	}
+	return severityError
+}
+
+// === POSICIONES ===
+
+func isWordChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// wordRange devuelve el rango de la palabra que empieza en (line, column), o
+// de un solo carácter si ahí no hay una palabra.
+func (d *document) wordRange(line, column int) Range {
+	start := Position{Line: line, Character: column}
+	end := Position{Line: line, Character: column + 1}
+
+	if line >= 0 && line < len(d.lines) {
+		text := []rune(d.lines[line])
+		i := column
+		for i < len(text) && isWordChar(text[i]) {
+			i++
+		}
+		if i > column {
+			end.Character = i
+		}
+	}
+	return Range{Start: start, End: end}
+}
+
+func spanRange(span ast.Span) Range {
+	return Range{
+		Start: Position{Line: span.Start.Line - 1, Character: span.Start.Column},
+		End:   Position{Line: span.End.Line - 1, Character: span.End.Column},
+	}
+}
+
+// before indica si p está antes de la posición (line, column) del árbol.
+func before(p ast.Pos, line, column int) bool {
+	return p.Line < line || (p.Line == line && p.Column <= column)
+}
+
+// identAt devuelve el identificador más interno que contiene la posición.
+func (d *document) identAt(pos Position) *ast.Ident {
+	if d.program == nil {
+		return nil
+	}
+
+	line, column := pos.Line+1, pos.Character
+	var found *ast.Ident
+	ast.Inspect(d.program, func(node ast.Node) bool {
+		if !node.GetSpan().Contains(line, column) {
+			return false
+		}
+		if id, ok := node.(*ast.Ident); ok {
+			found = id
+		}
+		return true
+	})
+	return found
+}
+
+// === DECLARACIONES ===
+
+// declIdent devuelve el identificador que declara name en decl.
+func declIdent(decl ast.Node, name string) *ast.Ident {
+	switch d := decl.(type) {
+	case *ast.VarDecl:
+		return d.Name
+	case *ast.Param:
+		return d.Name
+	case *ast.FuncDecl:
+		return d.Name
+	case *ast.StructDecl:
+		return d.Name
+	case *ast.Field:
+		return d.Name
+	case *ast.ForRange:
+		if d.Index != nil && d.Index.Name == name {
+			return d.Index
+		}
+		return d.Value
+	}
+	return nil
+}
+
+func (d *document) structDecl(name string) *ast.StructDecl {
+	if d.program == nil {
+		return nil
+	}
+	for _, stmt := range d.program.Stmts {
+		if decl, ok := stmt.(*ast.StructDecl); ok && decl.Name.Name == name {
+			return decl
+		}
+	}
+	return nil
+}
+
+func (d *document) funcDecl(name string) *ast.FuncDecl {
+	if d.program == nil {
+		return nil
+	}
+	for _, stmt := range d.program.Stmts {
+		if decl, ok := stmt.(*ast.FuncDecl); ok && decl.Name.Name == name {
+			return decl
+		}
+	}
+	return nil
+}
+
+// globalSymbol busca una función o un struct declarado por el usuario en el
+// reporte del ScopeTrace.
+func (d *document) globalSymbol(name string) (repl.ReportSymbol, bool) {
+	if d.report == nil {
+		return repl.ReportSymbol{}, false
+	}
+	for _, list := range [][]repl.ReportSymbol{d.report.GlobalScope.Funcs, d.report.GlobalScope.Structs} {
+		for _, symbol := range list {
+			// las funciones embebidas aparecen en el reporte sin posición
+			if symbol.Name == name && symbol.Line > 0 {
+				return symbol, true
+			}
+		}
+	}
+	return repl.ReportSymbol{}, false
+}
+
+// visible devuelve las variables, parámetros, funciones y structs visibles
+// en la posición, indexados por nombre. Las declaraciones internas ocultan
+// a las externas.
+func (d *document) visible(pos Position) map[string]*ast.Ident {
+	names := make(map[string]*ast.Ident)
+	if d.program == nil {
+		return names
+	}
+
+	line, column := pos.Line+1, pos.Character
+	add := func(id *ast.Ident) {
+		if id != nil {
+			names[id.Name] = id
+		}
+	}
+
+	for _, stmt := range d.program.Stmts {
+		switch decl := stmt.(type) {
+		case *ast.FuncDecl:
+			add(decl.Name)
+		case *ast.StructDecl:
+			add(decl.Name)
+		}
+	}
+
+	var walk func(node ast.Node)
+	walk = func(node ast.Node) {
+		if forRange, ok := node.(*ast.ForRange); ok {
+			add(forRange.Index)
+			add(forRange.Value)
+		}
+		for _, child := range ast.Children(node) {
+			switch c := child.(type) {
+			case *ast.VarDecl:
+				if before(c.End, line, column) {
+					add(c.Name)
+				}
+			case *ast.Param:
+				add(c.Name)
+			}
+			if child.GetSpan().Contains(line, column) {
+				walk(child)
+			}
+		}
+	}
+	walk(d.program)
+
+	return names
+}
+
+// === HOVER ===
+
+func funcSignature(decl *ast.FuncDecl) string {
+	params := make([]string, 0, len(decl.Params))
+	for _, param := range decl.Params {
+		params = append(params, param.Name.Name+" "+param.Type.Syntax())
+	}
+	signature := fmt.Sprintf("fn %s(%s)", decl.Name.Name, strings.Join(params, ", "))
+	if decl.Result != nil {
+		signature += " " + decl.Result.Syntax()
+	}
+	return signature
+}
+
+func structSignature(decl *ast.StructDecl) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "struct %s {\n", decl.Name.Name)
+	for _, field := range decl.Fields {
+		fmt.Fprintf(&b, "    %s %s\n", field.Type.Syntax(), field.Name.Name)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func (d *document) hover(pos Position) *Hover {
+	id := d.identAt(pos)
+	if id == nil {
+		return nil
+	}
+
+	var text string
+	switch decl := id.Decl.(type) {
+	case *ast.FuncDecl:
+		text = funcSignature(decl)
+	case *ast.StructDecl:
+		text = structSignature(decl)
+	case *ast.VarDecl:
+		text = id.Name + " " + id.Type()
+		if decl.Mutable() {
+			text = "mut " + text
+		}
+	case nil:
+		if _, ok := repl.DefaultBuiltInFunctions[id.Name]; ok {
+			text = "fn " + id.Name + " (embebida)"
+		} else if decl := d.structDecl(id.Name); decl != nil {
+			text = structSignature(decl)
+		} else if id.Type() != "" {
+			text = id.Name + " " + id.Type()
+		}
+	default:
+		text = id.Name + " " + id.Type()
+	}
+
+	if text == "" {
+		return nil
+	}
+
+	r := spanRange(id.Span)
+	return &Hover{
+		Contents: markupContent{Kind: "markdown", Value: "```vlang\n" + text + "\n```"},
+		Range:    &r,
+	}
+}
+
+// === DEFINICIÓN ===
+
+func (d *document) definition(pos Position) *Location {
+	id := d.identAt(pos)
+	if id == nil {
+		return nil
+	}
+
+	if decl := declIdent(id.Decl, id.Name); decl != nil {
+		return &Location{URI: d.uri, Range: spanRange(decl.Span)}
+	}
+
+	// los usos que el árbol no resolvió (por ejemplo el nombre de un struct
+	// en una declaración tipada) se buscan en el reporte del ScopeTrace
+	if symbol, ok := d.globalSymbol(id.Name); ok {
+		return &Location{URI: d.uri, Range: d.wordRange(symbol.Line-1, symbol.Column)}
+	}
+	return nil
+}
+
+// === AUTOCOMPLETADO ===
+
+// vectorMembers son los métodos y propiedades que AddVectorBuiltins registra
+// en cada vector.
+var vectorMembers = []CompletionItem{
+	{Label: "append", Kind: completionMethod, Detail: "append(valor)"},
+	{Label: "remove", Kind: completionMethod, Detail: "remove(at indice)"},
+	{Label: "removeLast", Kind: completionMethod, Detail: "removeLast()"},
+	{Label: "count", Kind: completionProperty, Detail: "int"},
+	{Label: "isEmpty", Kind: completionProperty, Detail: "bool"},
+}
+
+var keywords = func() []string {
+	lexer := interpeter.NewVLangLexer(antlr.NewInputStream(""))
+	words := []string{"true", "false"}
+	for _, literal := range lexer.LiteralNames {
+		word := strings.Trim(literal, "'")
+		if word != "" && unicode.IsLetter([]rune(word)[0]) {
+			words = append(words, word)
+		}
+	}
+	return words
+}()
+
+// memberAccess reconoce "a.b.c." o "a.b.c.pre" justo antes del cursor.
+var memberAccess = regexp.MustCompile(`([A-Za-z_]\w*(?:\.[A-Za-z_]\w*)*)\.\w*$`)
+
+func (d *document) completion(pos Position) []CompletionItem {
+	prefix := ""
+	if pos.Line < len(d.lines) {
+		text := []rune(d.lines[pos.Line])
+		if pos.Character <= len(text) {
+			prefix = string(text[:pos.Character])
+		}
+	}
+
+	visible := d.visible(pos)
+	if match := memberAccess.FindStringSubmatch(prefix); match != nil {
+		return d.members(strings.Split(match[1], "."), visible)
+	}
+
+	items := make([]CompletionItem, 0)
+	for _, word := range keywords {
+		items = append(items, CompletionItem{Label: word, Kind: completionKeyword})
+	}
+
+	builtins := make([]string, 0, len(repl.DefaultBuiltInFunctions))
+	for name := range repl.DefaultBuiltInFunctions {
+		builtins = append(builtins, name)
+	}
+	sort.Strings(builtins)
+	for _, name := range builtins {
+		items = append(items, CompletionItem{Label: name, Kind: completionFunction, Detail: "embebida"})
+	}
+
+	names := make([]string, 0, len(visible))
+	for name := range visible {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		id := visible[name]
+		switch decl := id.Decl.(type) {
+		case *ast.FuncDecl:
+			items = append(items, CompletionItem{Label: name, Kind: completionFunction, Detail: funcSignature(decl)})
+		case *ast.StructDecl:
+			items = append(items, CompletionItem{Label: name, Kind: completionStruct, Detail: "struct"})
+		default:
+			items = append(items, CompletionItem{Label: name, Kind: completionVariable, Detail: id.Type()})
+		}
+	}
+	return items
+}
+
+// members completa los atributos de path[0].path[1]...; el tipo de cada paso
+// sale de la declaración de la variable y de los atributos de los structs.
+func (d *document) members(path []string, visible map[string]*ast.Ident) []CompletionItem {
+	id, ok := visible[path[0]]
+	if !ok {
+		return []CompletionItem{}
+	}
+
+	typ := id.Type()
+	for _, name := range path[1:] {
+		decl := d.structDecl(typ)
+		if decl == nil {
+			return []CompletionItem{}
+		}
+		typ = ""
+		for _, field := range decl.Fields {
+			if field.Name.Name == name {
+				typ = field.Type.String()
+			}
+		}
+	}
+
+	if ast.ElemType(typ) != "" {
+		return vectorMembers
+	}
+
+	items := make([]CompletionItem, 0)
+	if decl := d.structDecl(typ); decl != nil {
+		for _, field := range decl.Fields {
+			items = append(items, CompletionItem{Label: field.Name.Name, Kind: completionField, Detail: field.Type.Syntax()})
+		}
+	}
+	return items
+}
+
+// === SÍMBOLOS ===
+
+// symbols lista las funciones del reporte del ScopeTrace, con el rango
+// completo de su declaración tomado del árbol, y los structs. El DclVisitor
+// solo guarda los nombres de los structs, así que esos salen del árbol.
+func (d *document) symbols() []DocumentSymbol {
+	result := make([]DocumentSymbol, 0)
+	if d.report == nil {
+		return result
+	}
+
+	for _, symbol := range d.report.GlobalScope.Funcs {
+		decl := d.funcDecl(symbol.Name)
+		if symbol.Line == 0 || decl == nil {
+			continue
+		}
+		result = append(result, DocumentSymbol{
+			Name:           symbol.Name,
+			Detail:         funcSignature(decl),
+			Kind:           symbolFunction,
+			Range:          spanRange(decl.Span),
+			SelectionRange: spanRange(decl.Name.Span),
+		})
+	}
+
+	for _, stmt := range d.program.Stmts {
+		decl, ok := stmt.(*ast.StructDecl)
+		if !ok {
+			continue
+		}
+		fields := make([]DocumentSymbol, 0, len(decl.Fields))
+		for _, field := range decl.Fields {
+			fields = append(fields, DocumentSymbol{
+				Name:           field.Name.Name,
+				Detail:         field.Type.Syntax(),
+				Kind:           symbolField,
+				Range:          spanRange(field.Span),
+				SelectionRange: spanRange(field.Name.Span),
+			})
+		}
+		result = append(result, DocumentSymbol{
+			Name:           decl.Name.Name,
+			Kind:           symbolStruct,
+			Range:          spanRange(decl.Span),
+			SelectionRange: spanRange(decl.Name.Span),
+			Children:       fields,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].Range.Start, result[j].Range.Start
+		return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+	})
+	return result
+}
@@ -0,0 +1,129 @@
+package lsp
+
+import (
+	"testing"
+
+	"main.go/lint"
+)
+
+const source = `struct Punto {
+    int x
+}
+fn doble(n int) int {
+    return n * 2
+}
+mut p Punto = Punto{x: 1}
+mut total = doble(3)
+println(total)
+`
+
+// Los errores del análisis y las advertencias del linter se publican como
+// diagnósticos sobre la palabra en la que empiezan.
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   string
+		config lint.Config
+		want   []Diagnostic
+	}{
+		{
+			name: "sin errores",
+			code: source + "println(p.x)\n",
+			want: []Diagnostic{},
+		},
+		{
+			name: "error semántico y advertencia",
+			code: source + "println(y)\n",
+			want: []Diagnostic{
+				{Range: Range{Start: Position{9, 8}, End: Position{9, 9}}, Severity: severityError, Source: "vlang", Message: "Variable y no encontrada"},
+				{Range: Range{Start: Position{6, 4}, End: Position{6, 5}}, Severity: severityWarning, Source: "vlang-lint", Code: "unused-variable", Message: "La variable 'p' se declara pero nunca se usa"},
+			},
+		},
+		{
+			name:   "regla desactivada",
+			code:   source,
+			config: lint.Config{Rules: map[string]bool{"unused-variable": false}},
+			want:   []Diagnostic{},
+		},
+		{
+			name: "error de sintaxis",
+			code: "mut = 5\n",
+			want: []Diagnostic{
+				{Range: Range{Start: Position{0, 4}, End: Position{0, 5}}, Severity: severityError, Source: "vlang", Message: "no viable alternative at input 'mut='"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := analyze("file:///programa.vch", tt.code, nil, tt.config)
+			if len(doc.diagnostics) != len(tt.want) {
+				t.Fatalf("diagnósticos %+v, se esperaban %+v", doc.diagnostics, tt.want)
+			}
+			for i, got := range doc.diagnostics {
+				if got != tt.want[i] {
+					t.Errorf("diagnóstico %d: %+v, se esperaba %+v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// hover muestra la firma o el tipo del identificador bajo el cursor, y
+// definition lleva a su declaración.
+func TestHoverAndDefinition(t *testing.T) {
+	doc := analyze("file:///programa.vch", source, nil, lint.Config{})
+
+	tests := []struct {
+		name       string
+		pos        Position
+		hover      string // "" si no hay hover
+		definition *Range // nil si no hay definición
+	}{
+		{"variable", Position{7, 5}, "mut total int", &Range{Position{7, 4}, Position{7, 9}}},
+		{"uso de una variable", Position{8, 10}, "mut total int", &Range{Position{7, 4}, Position{7, 9}}},
+		{"llamada", Position{7, 13}, "fn doble(n int) int", &Range{Position{3, 3}, Position{3, 8}}},
+		{"parámetro", Position{4, 11}, "n int", &Range{Position{3, 9}, Position{3, 10}}},
+		{"embebida", Position{8, 2}, "fn println (embebida)", nil},
+		{"palabra clave", Position{4, 6}, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hover := doc.hover(tt.pos)
+			switch {
+			case tt.hover == "" && hover != nil:
+				t.Errorf("hover %q, no se esperaba ninguno", hover.Contents.Value)
+			case tt.hover != "" && hover == nil:
+				t.Errorf("sin hover, se esperaba %q", tt.hover)
+			case tt.hover != "" && hover.Contents.Value != "```vlang\n"+tt.hover+"\n```":
+				t.Errorf("hover %q, se esperaba %q", hover.Contents.Value, tt.hover)
+			}
+
+			location := doc.definition(tt.pos)
+			switch {
+			case tt.definition == nil && location != nil:
+				t.Errorf("definición %+v, no se esperaba ninguna", *location)
+			case tt.definition != nil && location == nil:
+				t.Errorf("sin definición, se esperaba %+v", *tt.definition)
+			case tt.definition != nil && (location.Range != *tt.definition || location.URI != doc.uri):
+				t.Errorf("definición %+v, se esperaba %+v", *location, *tt.definition)
+			}
+		})
+	}
+}
+
+// Mientras el documento tiene errores de sintaxis, hover y definition usan
+// el último análisis que sí tuvo árbol.
+func TestKeepsLastProgram(t *testing.T) {
+	valid := analyze("file:///programa.vch", source, nil, lint.Config{})
+	broken := analyze("file:///programa.vch", source+"mut = \n", valid, lint.Config{})
+
+	if len(broken.diagnostics) == 0 || broken.diagnostics[0].Severity != severityError {
+		t.Fatalf("se esperaba el error de sintaxis: %+v", broken.diagnostics)
+	}
+	if hover := broken.hover(Position{7, 5}); hover == nil {
+		t.Error("sin hover con el documento roto")
+	}
+	if location := broken.definition(Position{7, 13}); location == nil || location.Range.Start != (Position{3, 3}) {
+		t.Errorf("definición %+v con el documento roto", location)
+	}
+}
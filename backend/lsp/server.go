@@ -0,0 +1,209 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"main.go/dap"
+	"main.go/lint"
+	"main.go/logging"
+)
+
+var log = logging.New(logging.LSP)
+
+// Server atiende a un editor. Los requests se procesan en orden, uno a la
+// vez, así que los documentos no necesitan sincronización.
+type Server struct {
+	transport dap.Transport
+	documents map[string]*document
+	shutdown  bool
+	lint      lint.Config // reglas del linter elegidas por el cliente al inicializar
+}
+
+// NewServer usa el mismo encuadre con Content-Length que el adaptador de
+// depuración; el protocolo base de LSP y el de DAP coinciden en eso.
+func NewServer(transport dap.Transport) *Server {
+	return &Server{
+		transport: transport,
+		documents: make(map[string]*document),
+	}
+}
+
+// ServeStdio atiende al editor por la entrada y la salida estándar.
+func ServeStdio() error {
+	return NewServer(dap.NewStreamTransport(os.Stdin, os.Stdout)).Serve()
+}
+
+// Serve atiende mensajes hasta la notificación exit o el cierre de la
+// conexión.
+func (s *Server) Serve() error {
+	for {
+		data, err := s.transport.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var msg rpcMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			log.Info("mensaje LSP inválido", "error", err)
+			continue
+		}
+
+		log.Debug("mensaje LSP", "method", msg.Method)
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit sin shutdown previo")
+			}
+			return nil
+		}
+		s.handle(&msg)
+	}
+}
+
+func (s *Server) handle(msg *rpcMessage) {
+	switch msg.Method {
+	case "initialize":
+		var params initializeParams
+		if len(msg.Params) > 0 && !s.decode(msg, &params) {
+			break
+		}
+		if err := params.InitializationOptions.Lint.Validate(); err != nil {
+			s.replyError(msg, codeInvalidParams, err.Error())
+			break
+		}
+		s.lint = params.InitializationOptions.Lint
+		s.reply(msg, map[string]any{
+			"capabilities": serverCapabilities{
+				TextDocumentSync:       textDocumentSyncFull,
+				HoverProvider:          true,
+				DefinitionProvider:     true,
+				DocumentSymbolProvider: true,
+				CompletionProvider:     completionOptions{TriggerCharacters: []string{"."}},
+			},
+			"serverInfo": map[string]string{"name": "vlang-lsp"},
+		})
+
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		// notificaciones sin respuesta
+
+	case "shutdown":
+		s.shutdown = true
+		s.reply(msg, nil)
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if s.decode(msg, &params) {
+			s.update(params.TextDocument.URI, params.TextDocument.Text)
+		}
+
+	case "textDocument/didChange":
+		var params didChangeParams
+		if s.decode(msg, &params) && len(params.ContentChanges) > 0 {
+			// con sincronización completa el último cambio es el documento entero
+			text := params.ContentChanges[len(params.ContentChanges)-1].Text
+			s.update(params.TextDocument.URI, text)
+		}
+
+	case "textDocument/didClose":
+		var params didCloseParams
+		if s.decode(msg, &params) {
+			delete(s.documents, params.TextDocument.URI)
+			s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+		}
+
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if doc := s.positionRequest(msg, &params); doc != nil {
+			s.reply(msg, doc.hover(params.Position))
+		}
+
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if doc := s.positionRequest(msg, &params); doc != nil {
+			s.reply(msg, doc.definition(params.Position))
+		}
+
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if doc := s.positionRequest(msg, &params); doc != nil {
+			s.reply(msg, doc.completion(params.Position))
+		}
+
+	case "textDocument/documentSymbol":
+		var params documentSymbolParams
+		if !s.decode(msg, &params) {
+			break
+		}
+		if doc, ok := s.documents[params.TextDocument.URI]; ok {
+			s.reply(msg, doc.symbols())
+		} else {
+			s.reply(msg, []DocumentSymbol{})
+		}
+
+	default:
+		if msg.ID != nil {
+			s.replyError(msg, codeMethodNotFound, "método no soportado: "+msg.Method)
+		}
+	}
+}
+
+// update analiza la nueva versión del documento y publica sus diagnósticos.
+func (s *Server) update(uri, text string) {
+	doc := analyze(uri, text, s.documents[uri], s.lint)
+	s.documents[uri] = doc
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: doc.diagnostics})
+}
+
+// positionRequest decodifica los parámetros y devuelve el documento; si el
+// documento no está abierto responde null y devuelve nil.
+func (s *Server) positionRequest(msg *rpcMessage, params *textDocumentPositionParams) *document {
+	if !s.decode(msg, params) {
+		return nil
+	}
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		s.reply(msg, nil)
+		return nil
+	}
+	return doc
+}
+
+func (s *Server) decode(msg *rpcMessage, params any) bool {
+	if err := json.Unmarshal(msg.Params, params); err != nil {
+		if msg.ID != nil {
+			s.replyError(msg, codeInvalidParams, err.Error())
+		}
+		return false
+	}
+	return true
+}
+
+// === ENVÍO ===
+
+func (s *Server) send(msg any) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Error("no se pudo codificar el mensaje LSP", "error", err)
+		return
+	}
+	if err := s.transport.Write(data); err != nil {
+		log.Debug("no se pudo enviar el mensaje LSP", "error", err)
+	}
+}
+
+func (s *Server) reply(msg *rpcMessage, result any) {
+	s.send(rpcResponse{JSONRPC: "2.0", ID: msg.ID, Result: result})
+}
+
+func (s *Server) replyError(msg *rpcMessage, code int, text string) {
+	s.send(rpcErrorResponse{JSONRPC: "2.0", ID: msg.ID, Error: rpcError{Code: code, Message: text}})
+}
+
+func (s *Server) notify(method string, params any) {
+	s.send(rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
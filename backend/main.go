@@ -6,13 +6,12 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/antlr4-go/antlr/v4"
@@ -21,12 +20,19 @@ import (
 
 	// Importa el paquete de pruebas que contiene la lógica de ejecución
 
+	"main.go/analysis"
 	"main.go/ast"
 	compiler "main.go/compiler" // NUEVA: nuestro traductor ARM64
+	"main.go/compiler/arm64"
+	"main.go/compiler/ir"
 	"main.go/cst"
-	"main.go/errors"
-	interpeter "main.go/grammar"
+	"main.go/dap"
+	"main.go/format"
+	"main.go/lint"
+	"main.go/logging"
 	"main.go/repl"
+	"main.go/session"
+	"main.go/vm"
 )
 
 type executionResult struct {
@@ -46,88 +52,53 @@ type executionResult struct {
 	ARM64Code   string   `json:"arm64Code"`   // Código ARM64 generado
 	ARM64Errors []string `json:"arm64Errors"` // Errores de traducción
 	HasARM64    bool     `json:"hasArm64"`    // Si se generó código ARM64
-}
 
-// Agregar esta función para limpiar el HTML del código ARM64
-func stripHTMLFromAssembly(htmlCode string) string {
-	// Eliminar etiquetas HTML comunes
-	htmlCode = regexp.MustCompile(`<span[^>]*>`).ReplaceAllString(htmlCode, "")
-	htmlCode = regexp.MustCompile(`</span>`).ReplaceAllString(htmlCode, "")
-	htmlCode = regexp.MustCompile(`"asm-[^"]*">`).ReplaceAllString(htmlCode, "")
+	// Qué bloque de arm64Code sale de cada sentencia del programa
+	ARM64SourceMap []arm64.SourceMapping `json:"arm64SourceMap,omitempty"`
 
-	// Reemplazar entidades HTML comunes
-	htmlCode = strings.ReplaceAll(htmlCode, "&lt;", "<")
-	htmlCode = strings.ReplaceAll(htmlCode, "&gt;", ">")
-	htmlCode = strings.ReplaceAll(htmlCode, "&amp;", "&")
-	htmlCode = strings.ReplaceAll(htmlCode, "&quot;", "\"")
+	// Por qué arm64Code no pasó por el IR, si lo generó el traductor directo
+	ARM64Fallback string `json:"arm64Fallback,omitempty"`
 
-	return htmlCode
-}
+	Engine string `json:"engine"` // Motor que ejecutó el programa: "repl" o "vm"
 
-// Función para traducir a ARM64
-func translateToARM64(tree antlr.ParseTree) (string, []string, bool) {
-	fmt.Printf("🔹 Iniciando traducción a ARM64...\n")
+	// Por qué se ejecutó con el intérprete si se pidió la VM
+	EngineFallback string `json:"engineFallback,omitempty"`
+
+	// Traza de ejecución, solo si la petición la pidió con executionTrace
+	ExecutionTrace          []repl.TraceEvent `json:"executionTrace,omitempty"`
+	ExecutionTraceTruncated bool              `json:"executionTraceTruncated,omitempty"`
+}
 
-	// Crear el traductor
-	translator := compiler.NewARM64Translator()
+// httpLog es el logger del servidor fuera de una petición concreta
+var httpLog = logging.New(logging.HTTP)
 
-	// Traducir el programa
-	arm64Code, errors := translator.TranslateProgram(tree)
+// Motores de ejecución disponibles en /api/execute
+const (
+	engineREPL = "repl"
+	engineVM   = "vm"
+)
 
-	// Limpiar cualquier HTML del código generado
-	arm64Code = stripHTMLFromAssembly(arm64Code)
+// translateToARM64 traduce el programa a ARM64: por el IR si se puede, si no
+// con el traductor directo, y en ese caso fallback dice por qué. source es la
+// ruta del programa para la directiva .file, vacía si no se conoce.
+func translateToARM64(tree antlr.ParseTree, source string, level int, logger *slog.Logger) (string, []string, *ir.UnsupportedError) {
+	logger.Debug("iniciando traducción a ARM64")
 
-	// Asegurarse de que el código esté limpio antes de enviarlo al frontend
-	if strings.Contains(arm64Code, "<span") ||
-		strings.Contains(arm64Code, "</span") ||
-		strings.Contains(arm64Code, "asm-") {
-		fmt.Println("⚠️ Se detectaron etiquetas HTML en el código, aplicando limpieza adicional...")
-		arm64Code = deepCleanAssemblyCode(arm64Code)
+	arm64Code, errors, fallback := compiler.Compile(tree, source, level, logger)
+	if fallback != nil {
+		logger.Info("traducción ARM64 sin IR", "reason", fallback)
 	}
 
-	// Log para debugging
-	fmt.Println("🔎 Primeros 100 caracteres del código ARM64 limpio:")
-	if len(arm64Code) > 100 {
-		fmt.Println(arm64Code[:100] + "...")
-	} else {
-		fmt.Println(arm64Code)
-	}
+	logger.Debug("código ARM64 generado", "code", arm64Code)
 
 	if len(errors) > 0 {
-		fmt.Printf("❌ Errores en traducción ARM64: %d\n", len(errors))
+		logger.Info("traducción ARM64 con errores", "errors", len(errors))
 		for _, err := range errors {
-			fmt.Printf("   - %s\n", err)
+			logger.Debug("error de traducción ARM64", "error", err)
 		}
-	} else {
-		fmt.Printf("✅ Traducción ARM64 exitosa\n")
 	}
 
-	return arm64Code, errors, len(errors) == 0
-}
-
-// Función de limpieza profunda para asegurar que no hay rastros de HTML
-func deepCleanAssemblyCode(code string) string {
-	// Remover cualquier etiqueta HTML posiblemente mal formada
-	cleanCode := code
-
-	// Eliminar spans y atributos
-	cleanCode = regexp.MustCompile(`<span[^>]*>`).ReplaceAllString(cleanCode, "")
-	cleanCode = regexp.MustCompile(`</span>`).ReplaceAllString(cleanCode, "")
-
-	// Eliminar fragmentos de etiquetas incompletas como "asm-comment">
-	cleanCode = regexp.MustCompile(`"asm-[^"]*">`).ReplaceAllString(cleanCode, "")
-	cleanCode = regexp.MustCompile(`class="[^"]*"`).ReplaceAllString(cleanCode, "")
-
-	// Eliminar otros fragmentos HTML comunes
-	cleanCode = regexp.MustCompile(`</?[a-z]+[^>]*>`).ReplaceAllString(cleanCode, "")
-
-	// Reemplazar entidades HTML
-	cleanCode = strings.ReplaceAll(cleanCode, "&lt;", "<")
-	cleanCode = strings.ReplaceAll(cleanCode, "&gt;", ">")
-	cleanCode = strings.ReplaceAll(cleanCode, "&amp;", "&")
-	cleanCode = strings.ReplaceAll(cleanCode, "&quot;", "\"")
-
-	return cleanCode
+	return arm64Code, errors, fallback
 }
 
 func executeCode(w http.ResponseWriter, r *http.Request) {
@@ -136,31 +107,72 @@ func executeCode(w http.ResponseWriter, r *http.Request) {
 	// Leer y procesar el body
 	bodyBytes, err := io.ReadAll(r.Body)
 	if err != nil {
-		fmt.Printf("❌ Error leyendo body: %v\n", err)
+		httpLog.Error("error leyendo el body", "error", err)
 		http.Error(w, "Error reading request body", http.StatusBadRequest)
 		return
 	}
 
-	fmt.Printf("🔹 Body raw recibido: %s\n", string(bodyBytes))
-
 	if len(bodyBytes) == 0 {
-		fmt.Println("❌ Body está vacío")
+		httpLog.Info("body vacío")
 		http.Error(w, "Request body is empty", http.StatusBadRequest)
 		return
 	}
 
 	var requestData struct {
-		Code string `json:"code"`
+		Code   string `json:"code"`
+		Engine string `json:"engine"` // "repl" (por defecto) o "vm"
+		Trace  bool   `json:"trace"`  // registra en Debug cada nodo visitado, solo para esta petición
+
+		// devuelve la traza de ejecución; solo el intérprete emite eventos,
+		// así que fuerza el motor repl
+		ExecutionTrace bool `json:"executionTrace"`
+
+		// reglas del linter activadas o desactivadas; sin este campo corren todas
+		Lint lint.Config `json:"lint"`
+
+		// ruta del programa para la directiva .file del ensamblador
+		Path string `json:"path"`
 	}
 
 	if err := json.Unmarshal(bodyBytes, &requestData); err != nil {
-		fmt.Printf("❌ Error decodificando JSON: %v\n", err)
+		httpLog.Info("JSON inválido", "error", err)
 		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
 		return
 	}
 
+	// loggers de esta petición: con trace activo imprimen todo en Debug
+	reqLog := logging.For(logging.HTTP, requestData.Trace)
+	lexerLog := logging.For(logging.Lexer, requestData.Trace)
+	parserLog := logging.For(logging.Parser, requestData.Trace)
+	replLog := logging.For(logging.Repl, requestData.Trace)
+	compilerLog := logging.For(logging.Compiler, requestData.Trace)
+
+	reqLog.Debug("body recibido", "body", string(bodyBytes))
+
+	engine := requestData.Engine
+	if engine == "" {
+		engine = engineREPL
+	}
+
+	if engine != engineREPL && engine != engineVM {
+		http.Error(w, fmt.Sprintf("Unknown engine %q, expected %q or %q", engine, engineREPL, engineVM), http.StatusBadRequest)
+		return
+	}
+
+	// la traza de ejecución solo la registra el intérprete
+	var engineFallback string
+	if requestData.ExecutionTrace && engine == engineVM {
+		engine = engineREPL
+		engineFallback = "la traza de ejecución solo la registra el intérprete"
+	}
+
+	if err := requestData.Lint.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if requestData.Code == "" {
-		fmt.Println("❌ Campo 'code' está vacío")
+		reqLog.Info("campo 'code' vacío")
 		http.Error(w, "Code field is required and cannot be empty", http.StatusBadRequest)
 		return
 	}
@@ -170,7 +182,7 @@ func executeCode(w http.ResponseWriter, r *http.Request) {
 		codeString = codeString[1:]
 	}
 
-	fmt.Printf("✅ Código recibido exitosamente:\n%s\n", codeString)
+	reqLog.Info("petición de ejecución", "bytes", len(codeString), "engine", engine, "trace", requestData.Trace)
 
 	// =========== ANÁLISIS Y EJECUCIÓN ===========
 	startTime := time.Now()
@@ -180,86 +192,115 @@ func executeCode(w http.ResponseWriter, r *http.Request) {
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
-				fmt.Println("Error generando CST Report:", r)
+				reqLog.Error("error generando el reporte CST", "panic", r)
 				cstChannel <- ""
 			}
 		}()
 		cstChannel <- cst.CstReport(codeString)
 	}()
 
-	// 2. Análisis Léxico
-	lexicalErrorListener := errors.NewLexicalErrorListener()
-	lexer := interpeter.NewVLangLexer(antlr.NewInputStream(codeString))
-	lexer.RemoveErrorListeners()
-	lexer.AddErrorListener(lexicalErrorListener)
-
-	// 3. Análisis Sintáctico
-	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
-	parser := interpeter.NewVLangGrammar(stream)
-	parser.BuildParseTrees = true
-
-	syntaxErrorListener := errors.NewSyntaxErrorListener(lexicalErrorListener.ErrorTable)
-	parser.RemoveErrorListeners()
-	parser.SetErrorHandler(errors.NewCustomErrorStrategy())
-	parser.AddErrorListener(syntaxErrorListener)
-
-	// 4. Generar AST
-	tree := parser.Program()
+	// 2. Análisis léxico y sintáctico
+	analyzed := analysis.Parse(codeString)
+	analyzed.Log = replLog
+	tree := analyzed.Tree
 
 	// Verificar si hubo errores críticos
-	hasCompilationErrors := len(syntaxErrorListener.ErrorTable.Errors) > 0
+	hasCompilationErrors := analyzed.ErrorTable.HasErrors()
 
-	fmt.Printf("🔹 Errores de compilación: %d\n", len(syntaxErrorListener.ErrorTable.Errors))
-	if hasCompilationErrors {
-		for _, err := range syntaxErrorListener.ErrorTable.Errors {
-			fmt.Printf("   - %s (Línea %d, Col %d): %s\n", err.GetDisplayName(), err.Line, err.Column, err.Msg)
+	for _, err := range analyzed.ErrorTable.Errors {
+		errLog := parserLog
+		if err.Type == repl.LexicalError {
+			errLog = lexerLog
 		}
+		errLog.Debug(err.GetDisplayName(), "line", err.Line, "column", err.Column, "msg", err.Msg)
 	}
 
 	var replVisitor *repl.ReplVisitor
 	var output string = ""
 	var formattedOutput string = ""
 	var consoleMessages []repl.ConsoleMessage
+	var typedProgram *ast.Program
+	var traceRecorder *repl.TraceRecorder
 
-	// 5. Solo continuar con análisis semántico si no hay errores críticos
+	// 3. Solo continuar con análisis semántico si no hay errores críticos
 	if !hasCompilationErrors {
-		// Análisis Semántico y Ejecución
-		dclVisitor := repl.NewDclVisitor(syntaxErrorListener.ErrorTable)
-		dclVisitor.Visit(tree)
+		// Análisis Semántico y Ejecución. El chequeo estático revisa todo el
+		// programa antes de ejecutarlo, incluso las ramas que nunca se van a
+		// ejecutar
+		checked := analyzed.Check()
+		dclVisitor := analyzed.DclVisitor
+		typedProgram = analyzed.Program
+
+		// las advertencias del linter van a la misma tabla pero no impiden
+		// ejecutar el programa
+		analyzed.Stream.Fill()
+		lint.NewLinter(dclVisitor.ErrorTable, requestData.Lint).Lint(typedProgram, analyzed.Stream.GetAllTokens())
 
 		replVisitor = repl.NewVisitor(dclVisitor)
-		replVisitor.Visit(tree)
-		output = replVisitor.Console.GetOutput()
-		formattedOutput = replVisitor.Console.GetFormattedOutput()
-		consoleMessages = replVisitor.Console.GetMessages()
+		console := replVisitor.Console
+
+		if requestData.ExecutionTrace {
+			traceRecorder = repl.NewTraceRecorder(0)
+			replVisitor.Tracer = traceRecorder
+		}
+
+		var program *vm.Program
+		if !checked {
+			replLog.Debug("el chequeo de tipos encontró errores, no se ejecuta el programa")
+		} else if engine == engineVM {
+			var err error
+			program, err = vm.Compile(typedProgram, dclVisitor)
+
+			// lo que la VM no soporta se ejecuta con el intérprete
+			if err != nil {
+				replLog.Info("VM no disponible, se usa el intérprete", "reason", err)
+				engine = engineREPL
+				engineFallback = err.Error()
+			}
+		}
+
+		if checked && engine == engineVM {
+			machine := vm.NewMachine(program, dclVisitor.ErrorTable)
+			machine.Limits = repl.SandboxLimits
+			if err := machine.Run(); err != nil {
+				replLog.Debug("error de ejecución en la VM", "error", err)
+			}
+			machine.ExportGlobals(dclVisitor.ScopeTrace)
+			console = machine.Console
+		} else if checked {
+			replVisitor.Limits = repl.SandboxLimits
+			runInterpreter(replVisitor, typedProgram, reqLog)
+		}
+
+		output = console.GetOutput()
+		formattedOutput = console.GetFormattedOutput()
+		consoleMessages = console.GetMessages()
 	} else {
 		// Si hay errores de compilación, crear visitor básico para reportes
-		dclVisitor := repl.NewDclVisitor(syntaxErrorListener.ErrorTable)
+		dclVisitor := repl.NewDclVisitor(analyzed.ErrorTable)
 		replVisitor = repl.NewVisitor(dclVisitor)
 		output = ""
 	}
 
 	interpretationEndTime := time.Now()
 
-	// 6. Obtener CST Report
+	// 4. Obtener CST Report
 	cstReport := <-cstChannel
 
-	// 7. Generar AST nativo
+	// 5. Generar AST nativo
 	var finalAST string
 	if tree != nil && !hasCompilationErrors {
-		fmt.Println("🌳 Generando AST nativo...")
-
 		// Generar AST con timeout para evitar bloqueos
 		astChannel := make(chan string, 1)
 		go func() {
 			defer func() {
 				if r := recover(); r != nil {
-					fmt.Printf("❌ Error generando AST nativo: %v\n", r)
+					reqLog.Error("error generando el AST", "panic", r)
 					astChannel <- generateErrorAST("Error al generar AST")
 				}
 			}()
 
-			astNode := ast.GenerateNativeAST(tree)
+			astNode := ast.GenerateReportAST(typedProgram)
 			if astNode != nil {
 				astChannel <- ast.GenerateASTSVG(astNode)
 			} else {
@@ -270,13 +311,11 @@ func executeCode(w http.ResponseWriter, r *http.Request) {
 		// Esperar con timeout
 		select {
 		case finalAST = <-astChannel:
-			fmt.Println("✅ AST nativo generado exitosamente")
 		case <-time.After(5 * time.Second):
-			fmt.Println("⏱️ Timeout generando AST")
+			reqLog.Warn("timeout generando el AST")
 			finalAST = generateErrorAST("Timeout al generar AST")
 		}
 	} else {
-		fmt.Println("❌ No se pudo generar el árbol de análisis debido a errores")
 		finalAST = generateErrorAST("Error en análisis sintáctico")
 	}
 
@@ -290,30 +329,36 @@ func executeCode(w http.ResponseWriter, r *http.Request) {
 	// =========== GENERAR REPORTES ===========
 
 	// Determinar si la ejecución fue exitosa
-	success := !hasCompilationErrors && len(syntaxErrorListener.ErrorTable.Errors) == 0
+	success := !hasCompilationErrors && !analyzed.ErrorTable.HasErrors()
 
 	// Generar tabla de símbolos
 	scopeReport := replVisitor.ScopeTrace.Report()
 	symbols := extractSymbolsFromScope(scopeReport)
 
 	// Crear resumen de errores
-	errorSummary := syntaxErrorListener.ErrorTable.GetErrorsSummary()
+	errorSummary := analyzed.ErrorTable.GetErrorsSummary()
 
-	fmt.Printf("🔹 Resumen de errores: %+v\n", errorSummary)
-	fmt.Printf("🔹 Tiempo de interpretación: %v\n", interpretationEndTime.Sub(startTime))
-	fmt.Printf("🔹 Tiempo total: %v\n", reportEndTime.Sub(startTime))
-	fmt.Printf("🔹 Salida: %s\n", output)
+	reqLog.Info("ejecución terminada",
+		"errors", analyzed.ErrorTable.GetErrorCount(),
+		"warnings", analyzed.ErrorTable.GetWarningCount(),
+		"interpretation", interpretationEndTime.Sub(startTime),
+		"total", reportEndTime.Sub(startTime))
+	reqLog.Debug("salida del programa", "output", output)
 
 	// =========== TRADUCCIÓN A ARM64 ===========
 	var arm64Code string
 	var arm64Errors []string
 	var hasValidARM64 bool
+	var arm64Fallback string
 
 	// Solo intentar traducir a ARM64 si no hay errores de compilación
 	if !hasCompilationErrors {
-		fmt.Printf("🔹 Intentando generar código ARM64...\n")
-		arm64Code, arm64Errors, hasValidARM64 = translateToARM64(tree)
-		fmt.Print("Codigo Arm64 \n", arm64Code)
+		var fallback *ir.UnsupportedError
+		arm64Code, arm64Errors, fallback = translateToARM64(tree, requestData.Path, ir.O0, compilerLog)
+		hasValidARM64 = len(arm64Errors) == 0
+		if fallback != nil {
+			arm64Fallback = fallback.Error()
+		}
 	} else {
 		arm64Code = ""
 		arm64Errors = []string{"No se puede generar ARM64 debido a errores de compilación"}
@@ -323,7 +368,7 @@ func executeCode(w http.ResponseWriter, r *http.Request) {
 	// Crear resultado con información detallada
 	result := executionResult{
 		Success:         success,
-		Errors:          syntaxErrorListener.ErrorTable.Errors,
+		Errors:          analyzed.ErrorTable.Errors,
 		Output:          output,
 		FormattedOutput: formattedOutput,
 		ConsoleMessages: consoleMessages,
@@ -335,23 +380,43 @@ func executeCode(w http.ResponseWriter, r *http.Request) {
 		ExecutionTime:   interpretationEndTime.Sub(startTime).Milliseconds(),
 
 		// NUEVOS CAMPOS ARM64
-		ARM64Code:   arm64Code,
-		ARM64Errors: arm64Errors,
-		HasARM64:    hasValidARM64,
+		ARM64Code:      arm64Code,
+		ARM64Errors:    arm64Errors,
+		HasARM64:       hasValidARM64,
+		ARM64SourceMap: arm64.SourceMap(arm64Code),
+		ARM64Fallback:  arm64Fallback,
+
+		Engine:         engine,
+		EngineFallback: engineFallback,
+	}
+
+	if traceRecorder != nil {
+		result.ExecutionTrace = traceRecorder.Events
+		result.ExecutionTraceTruncated = traceRecorder.Truncated
 	}
 
 	// Enviar respuesta
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(result); err != nil {
-		fmt.Printf("❌ Error encoding response: %v\n", err)
+		reqLog.Error("error codificando la respuesta", "error", err)
 		http.Error(w, "Error encoding response", http.StatusInternalServerError)
-		return
 	}
-
-	fmt.Printf("✅ Respuesta enviada exitosamente\n")
 }
 
 // Función auxiliar para generar AST de error
+// runInterpreter ejecuta el programa y convierte cualquier pánico del
+// intérprete en un error de ejecución, para que un programa que encuentra
+// un error interno no tire el servidor.
+func runInterpreter(visitor *repl.ReplVisitor, program *ast.Program, logger *slog.Logger) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("error interno del intérprete", "panic", r)
+			visitor.ErrorTable.NewRuntimeError(0, 0, fmt.Sprintf("Error interno del intérprete: %v", r))
+		}
+	}()
+	visitor.VisitProgram(program)
+}
+
 func generateErrorAST(errorMsg string) string {
 	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="600" height="200" viewBox="0 0 600 200">
 		<rect width="600" height="200" fill="#1e1e1e"/>
@@ -427,6 +492,79 @@ func min(a, b int) int {
 	return b
 }
 
+// formatFiles implementa "fmt [-w] archivos...". Sin archivos formatea la
+// entrada estándar; con -w reescribe cada archivo en lugar de imprimirlo.
+func formatFiles(args []string) error {
+	write := len(args) > 0 && args[0] == "-w"
+	if write {
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		code, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		formatted, err := format.Source(string(code))
+		if err != nil {
+			return err
+		}
+		fmt.Print(formatted)
+		return nil
+	}
+
+	for _, path := range args {
+		code, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		formatted, err := format.Source(string(code))
+		if err != nil {
+			return fmt.Errorf("%s:\n%w", path, err)
+		}
+		if !write {
+			fmt.Print(formatted)
+			continue
+		}
+		if formatted != string(code) {
+			if err := os.WriteFile(path, []byte(formatted), 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// formatCode devuelve el código en el estilo canónico. Si el código tiene
+// errores léxicos o sintácticos responde success false con los errores.
+func formatCode(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var requestData struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	response := struct {
+		Success   bool         `json:"success"`
+		Formatted string       `json:"formatted"`
+		Errors    []repl.Error `json:"errors"`
+	}{Errors: []repl.Error{}}
+
+	formatted, err := format.Source(requestData.Code)
+	if formatErr, ok := err.(*format.Error); ok {
+		response.Errors = formatErr.Errors
+	} else {
+		response.Success = true
+		response.Formatted = formatted
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
 func healthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
@@ -435,10 +573,38 @@ func healthCheck(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Endpoint para obtener solo el código ARM64
-func getARM64Code(w http.ResponseWriter, r *http.Request) {
+// Salidas de /api/compile
+const (
+	emitASM = "asm" // ensamblador ARM64
+	emitIR  = "ir"  // representación intermedia
+)
+
+// compileCode compila el programa sin ejecutarlo. ?emit=ir devuelve el IR en
+// lugar del ensamblador, para ver qué recibe el selector de instrucciones, y
+// ?O=1 lo optimiza. Con el ensamblador va sourceMap: las líneas de
+// arm64Code que salen de cada sentencia (ver arm64.SourceMap).
+func compileCode(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	emit := r.URL.Query().Get("emit")
+	if emit == "" {
+		emit = emitASM
+	}
+	if emit != emitASM && emit != emitIR {
+		http.Error(w, fmt.Sprintf("Unknown emit %q, expected %q or %q", emit, emitASM, emitIR), http.StatusBadRequest)
+		return
+	}
+
+	level := ir.O0
+	if value := r.URL.Query().Get("O"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < ir.O0 || parsed > ir.O1 {
+			http.Error(w, fmt.Sprintf("Unknown optimization level %q, expected %d or %d", value, ir.O0, ir.O1), http.StatusBadRequest)
+			return
+		}
+		level = parsed
+	}
+
 	// Leer el código fuente del request
 	bodyBytes, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -448,6 +614,7 @@ func getARM64Code(w http.ResponseWriter, r *http.Request) {
 
 	var requestData struct {
 		Code string `json:"code"`
+		Path string `json:"path"` // ruta del programa para la directiva .file
 	}
 
 	if err := json.Unmarshal(bodyBytes, &requestData); err != nil {
@@ -455,22 +622,43 @@ func getARM64Code(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Análisis léxico y sintáctico
-	lexer := interpeter.NewVLangLexer(antlr.NewInputStream(requestData.Code))
-	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
-	parser := interpeter.NewVLangGrammar(stream)
-	tree := parser.Program()
-
-	// Traducir a ARM64
-	arm64Code, arm64Errors, success := translateToARM64(tree)
+	// Análisis léxico, sintáctico y semántico
+	c := parseSource("request", requestData.Code)
+	if !c.Check() {
+		response := map[string]interface{}{
+			"success":   false,
+			"emit":      emit,
+			"errors":    c.ErrorTable.Errors,
+			"timestamp": time.Now().Format(time.RFC3339),
+		}
+		json.NewEncoder(w).Encode(response)
+		return
+	}
 
-	// Respuesta
 	response := map[string]interface{}{
-		"success":   success,
-		"arm64Code": arm64Code,
-		"errors":    arm64Errors,
+		"success":   true,
+		"emit":      emit,
+		"level":     level,
 		"timestamp": time.Now().Format(time.RFC3339),
 	}
+	if emit == emitIR {
+		irCode, err := compiler.EmitIR(c.Tree, level)
+		if err != nil {
+			response["success"] = false
+			response["errors"] = []string{err.Error()}
+		}
+		response["ir"] = irCode
+	} else {
+		arm64Code, arm64Errors, fallback := translateToARM64(c.Tree, requestData.Path, level, logging.New(logging.Compiler))
+		response["success"] = len(arm64Errors) == 0
+		response["errors"] = arm64Errors
+		response["arm64Code"] = arm64Code
+		response["sourceMap"] = arm64.SourceMap(arm64Code)
+		if fallback != nil {
+			// el ensamblador salió del traductor directo, sin el IR
+			response["fallback"] = fallback.Error()
+		}
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
@@ -515,12 +703,19 @@ func executeARM64Code(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// Función para ejecutar código ARM64
-// Función para ejecutar código ARM64 con pre-procesamiento para corregir errores comunes
-func executeARM64Assembly(arm64Code string) (string, bool, string) {
-	// PRE-PROCESAR EL CÓDIGO PARA CORREGIR ERRORES CONOCIDOS
-	arm64Code = fixARM64Code(arm64Code)
+// arm64Toolchain indica si se pueden ensamblar y ejecutar programas ARM64.
+func arm64Toolchain() bool {
+	for _, tool := range []string{"aarch64-linux-gnu-as", "aarch64-linux-gnu-ld", "qemu-aarch64"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return false
+		}
+	}
+	return true
+}
 
+// executeARM64Assembly ensambla, enlaza y ejecuta en qemu el código ARM64
+// tal como lo generó el traductor
+func executeARM64Assembly(arm64Code string) (string, bool, string) {
 	// Crear archivo temporal
 	tmpDir := "/tmp"
 	timestamp := fmt.Sprintf("%d", time.Now().UnixNano())
@@ -528,7 +723,7 @@ func executeARM64Assembly(arm64Code string) (string, bool, string) {
 	objectFile := filepath.Join(tmpDir, fmt.Sprintf("temp_program_%s.o", timestamp))
 	executableFile := filepath.Join(tmpDir, fmt.Sprintf("temp_program_%s", timestamp))
 
-	// Escribir código ARM64 corregido al archivo
+	// Escribir el código ARM64 al archivo
 	err := ioutil.WriteFile(sourceFile, []byte(arm64Code), 0644)
 	if err != nil {
 		return "", false, fmt.Sprintf("Error creating source file: %v", err)
@@ -583,65 +778,57 @@ func executeARM64Assembly(arm64Code string) (string, bool, string) {
 	return string(execOutput), true, ""
 }
 
-// Función para corregir errores comunes en el código ARM64 generado
-func fixARM64Code(code string) string {
-	// 1. Corregir la llamada duplicada a print_string al final de interpolación
-	// Buscar el patrón problemático: "// Interpolación completada\n    // Llamar función print_string\n    bl print_string"
-	interpolationFix := regexp.MustCompile(`// Interpolación completada\s*\n\s*// Llamar función print_string\s*\n\s*bl print_string`)
-	code = interpolationFix.ReplaceAllString(code, "// Interpolación completada")
-
-	// 2. Corregir funciones print_integer que usan registros no preservados
-	// Buscar y reemplazar la función print_integer problemática
-	printIntegerRegex := regexp.MustCompile(`print_integer:\s*\n(.*?\n)*?\s*ret`)
-	code = printIntegerRegex.ReplaceAllString(code, `print_integer:
-    // Función mejorada para imprimir enteros
-    stp x29, x30, [sp, #-16]!    // Guardar frame pointer y link register
-    mov x29, sp                   // Setup frame pointer
-    
-    // Manejar caso especial: cero
-    cmp x0, #0
-    bne .L_not_zero
-    
-    // Imprimir '0'
-    mov x0, #48                   // ASCII '0'
-    bl print_char
-    b .L_print_int_done
-    
-.L_not_zero:
-    // Usar una implementación más simple y robusta
-    // Solo manejamos números positivos pequeños por ahora
-    cmp x0, #10
-    blt .L_single_digit
-    
-    // Para números >= 10, imprimir recursivamente
-    mov x1, x0
-    mov x2, #10
-    udiv x0, x1, x2               // x0 = x1 / 10
-    bl print_integer             // Llamada recursiva
-    
-    mov x2, #10
-    msub x0, x0, x2, x1          // x0 = x1 % 10
-    
-.L_single_digit:
-    add x0, x0, #48              // Convertir a ASCII
-    bl print_char
-    
-.L_print_int_done:
-    ldp x29, x30, [sp], #16      // Restaurar registros
-    ret`)
-
+// exitCode devuelve el código de salida del último binario ejecutado por
+// executeARM64Assembly a partir de su mensaje de error, o -1 si el fallo no
+// fue una salida con código.
+func exitCode(msg string) int {
+	var code int
+	if _, err := fmt.Sscanf(msg, "Execution error: exit status %d", &code); err != nil {
+		return -1
+	}
 	return code
 }
 
 func main() {
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	if name := os.Getenv("LOG_LEVEL"); name != "" {
+		level, err := logging.ParseLevel(name)
+		if err != nil {
+			httpLog.Warn("LOG_LEVEL inválido, se usa info", "value", name)
+		} else {
+			logging.SetLevel(level)
+		}
+	} else if command != "" && command != "serve" {
+		// en la línea de comandos stderr es para los errores del programa
+		logging.SetLevel(slog.LevelWarn)
+	}
+
+	os.Exit(runCLI(os.Args[1:]))
+}
+
+// serve levanta el servidor HTTP que usa el IDE.
+func serve(port int) error {
 	r := mux.NewRouter()
 
 	// API Routes
 	api := r.PathPrefix("/api").Subrouter()
 	api.HandleFunc("/status", healthCheck).Methods("GET")
 	api.HandleFunc("/execute", executeCode).Methods("POST")
+	api.HandleFunc("/debug", dap.ServeWebSocket).Methods("GET")
+	api.HandleFunc("/format", formatCode).Methods("POST")
+
+	// sesiones interactivas: el estado se conserva entre entradas
+	sessions := session.NewStore(30 * time.Minute)
+	api.HandleFunc("/repl", sessions.Create).Methods("POST")
+	api.HandleFunc("/repl/{id}", sessions.Eval).Methods("POST")
+	api.HandleFunc("/repl/{id}", sessions.Delete).Methods("DELETE")
 
 	// NUEVA RUTA PARA ARM64
+	api.HandleFunc("/compile", compileCode).Methods("POST")
 	api.HandleFunc("/execute-arm64", executeARM64Code).Methods("POST")
 
 	// CORS configuration
@@ -653,11 +840,9 @@ func main() {
 
 	handler := c.Handler(r)
 
-	port := ":8080"
-	fmt.Printf("🚀 Servidor Go iniciado en http://localhost%s\n", port)
-	fmt.Println("📋 API endpoints disponibles:")
-	fmt.Println("  - GET    /api/status")
-	fmt.Println("  - POST   /api/execute")
+	addr := fmt.Sprintf(":%d", port)
+	httpLog.Info("servidor iniciado", "addr", fmt.Sprintf("http://localhost%s", addr),
+		"endpoints", []string{"GET /api/status", "POST /api/execute", "GET /api/debug", "POST /api/format", "POST /api/repl", "POST /api/repl/{id}", "DELETE /api/repl/{id}", "POST /api/compile", "POST /api/execute-arm64"})
 
-	log.Fatal(http.ListenAndServe(port, handler))
+	return http.ListenAndServe(addr, handler)
 }
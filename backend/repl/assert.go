@@ -0,0 +1,127 @@
+package repl
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"main.go/value"
+)
+
+// AssertionFailed encabeza el mensaje de toda aserción que no se cumple; el
+// runner de pruebas lo usa para distinguir una prueba que falla de una que
+// termina con otro error.
+const AssertionFailed = "Aserción fallida"
+
+// AssertionStop es el pánico con el que el visitor abandona la ejecución
+// después de una aserción fallida si StopOnAssertion está activo. La
+// aserción ya quedó en la tabla de errores; quien ejecuta lo recupera.
+type AssertionStop struct{}
+
+// assertMessage arma el mensaje de una aserción fallida. Si la llamada trae
+// un argumento extra de tipo string, se agrega como descripción.
+func assertMessage(detail string, extra []*Argument) (value.IVOR, bool, string) {
+	msg := AssertionFailed + ": " + detail
+	if len(extra) == 1 && extra[0].Value.Type() == value.IVOR_STRING {
+		msg += " (" + extra[0].Value.Value().(string) + ")"
+	}
+	return value.DefaultNilValue, false, msg
+}
+
+// validMessage revisa el argumento opcional con la descripción de la aserción.
+func validMessage(name string, extra []*Argument) string {
+	if len(extra) > 1 {
+		return "La función " + name + " recibe como máximo un mensaje además de sus valores"
+	}
+	if len(extra) == 1 && extra[0].Value.Type() != value.IVOR_STRING {
+		return "El mensaje de " + name + " debe ser de tipo string"
+	}
+	return ""
+}
+
+// * assert(condicion [, mensaje])
+func Assert(context *ReplContext, args []*Argument) (value.IVOR, bool, string) {
+	if len(args) == 0 {
+		return value.DefaultNilValue, false, "La función assert requiere una condición"
+	}
+	if msg := validMessage("assert", args[1:]); msg != "" {
+		return value.DefaultNilValue, false, msg
+	}
+
+	cond := args[0].Value
+	if cond.Type() != value.IVOR_BOOL {
+		return value.DefaultNilValue, false, "La condición de assert debe ser de tipo bool, no " + cond.Type()
+	}
+	if !cond.Value().(bool) {
+		return assertMessage("la condición es falsa", args[1:])
+	}
+	return value.DefaultNilValue, true, ""
+}
+
+// * assertEq(obtenido, esperado [, mensaje])
+func AssertEq(context *ReplContext, args []*Argument) (value.IVOR, bool, string) {
+	if len(args) < 2 {
+		return value.DefaultNilValue, false, "La función assertEq requiere dos valores"
+	}
+	if msg := validMessage("assertEq", args[2:]); msg != "" {
+		return value.DefaultNilValue, false, msg
+	}
+
+	actual, expected := args[0].Value, args[1].Value
+	if actual.Type() != expected.Type() {
+		return assertMessage(fmt.Sprintf("se esperaba %s de tipo %s, se obtuvo %s de tipo %s",
+			quoteValue(expected), expected.Type(), quoteValue(actual), actual.Type()), args[2:])
+	}
+	if ValueToString(actual) != ValueToString(expected) {
+		return assertMessage(fmt.Sprintf("se esperaba %s, se obtuvo %s", quoteValue(expected), quoteValue(actual)), args[2:])
+	}
+	return value.DefaultNilValue, true, ""
+}
+
+// * assertNear(obtenido, esperado, tolerancia [, mensaje])
+func AssertNear(context *ReplContext, args []*Argument) (value.IVOR, bool, string) {
+	if len(args) < 3 {
+		return value.DefaultNilValue, false, "La función assertNear requiere dos valores y una tolerancia"
+	}
+	if msg := validMessage("assertNear", args[3:]); msg != "" {
+		return value.DefaultNilValue, false, msg
+	}
+
+	var numbers [3]float64
+	for i, arg := range args[:3] {
+		switch arg.Value.Type() {
+		case value.IVOR_INT:
+			numbers[i] = float64(arg.Value.Value().(int))
+		case value.IVOR_FLOAT:
+			numbers[i] = arg.Value.Value().(float64)
+		default:
+			return value.DefaultNilValue, false, "La función assertNear solo acepta valores de tipo int o float, no " + arg.Value.Type()
+		}
+	}
+
+	actual, expected, tolerance := numbers[0], numbers[1], numbers[2]
+	if tolerance < 0 {
+		return value.DefaultNilValue, false, "La tolerancia de assertNear no puede ser negativa"
+	}
+	if math.IsNaN(actual) || math.Abs(actual-expected) > tolerance {
+		return assertMessage(fmt.Sprintf("se esperaba %s ± %s, se obtuvo %s",
+			formatNumber(expected), formatNumber(tolerance), formatNumber(actual)), args[3:])
+	}
+	return value.DefaultNilValue, true, ""
+}
+
+// quoteValue muestra un valor en un mensaje de aserción; las cadenas van
+// entre comillas para que se vean los espacios.
+func quoteValue(val value.IVOR) string {
+	switch val.Type() {
+	case value.IVOR_STRING:
+		return strconv.Quote(ValueToString(val))
+	case value.IVOR_CHARACTER:
+		return "'" + ValueToString(val) + "'"
+	}
+	return ValueToString(val)
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -1,7 +1,6 @@
 package repl
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 
@@ -50,8 +49,6 @@ func PrintCore(context *ReplContext, args []*Argument, newLine bool) (value.IVOR
 			return value.DefaultNilValue, false, "La función print solo acepta tipos primitivos, vectores y matrices"
 		}
 
-		fmt.Printf("DEBUG: Argumento recibido - Nombre: %s, Tipo: %s, Valor Go: %T\n", arg.Name, arg.Value.Type(), arg.Value)
-
 		switch arg.Value.Type() {
 
 		case value.IVOR_BOOL:
@@ -483,4 +480,16 @@ var DefaultBuiltInFunctions = map[string]*BuiltInFunction{
 		Name: "append",
 		Exec: Append,
 	},
+	"assert": {
+		Name: "assert",
+		Exec: Assert,
+	},
+	"assertEq": {
+		Name: "assertEq",
+		Exec: AssertEq,
+	},
+	"assertNear": {
+		Name: "assertNear",
+		Exec: AssertNear,
+	},
 }
@@ -1,19 +1,19 @@
 package repl
 
 import (
-	"fmt"
-	"log"
+	"log/slog"
 
-	"github.com/antlr4-go/antlr/v4"
-	compiler "main.go/grammar"
+	"main.go/ast"
+	"main.go/logging"
 	"main.go/value"
 )
 
 type DclVisitor struct {
-	compiler.BaseVLangGrammarVisitor
 	ScopeTrace  *ScopeTrace
 	ErrorTable  *ErrorTable
 	StructNames []string
+	// Log es el logger del subsistema repl; el ReplVisitor lo hereda
+	Log *slog.Logger
 }
 
 func NewDclVisitor(errorTable *ErrorTable) *DclVisitor {
@@ -21,57 +21,37 @@ func NewDclVisitor(errorTable *ErrorTable) *DclVisitor {
 		ScopeTrace:  NewScopeTrace(),
 		ErrorTable:  errorTable,
 		StructNames: []string{},
+		Log:         logging.New(logging.Repl),
 	}
 }
 
-func (v *DclVisitor) Visit(tree antlr.ParseTree) interface{} {
-	fmt.Printf("🔹 DclVisitor.Visit llamado con: %T\n", tree)
-
-	switch val := tree.(type) {
-	case *antlr.ErrorNodeImpl:
-		fmt.Printf("❌ ERROR NODE ENCONTRADO: %s\n", val.GetText())
-		log.Fatal(val.GetText()) // ⚠️ AQUÍ SE PUEDE ESTAR DETENIENDO
-		return nil
-	default:
-		fmt.Printf("🔹 Aceptando tree con visitor\n")
-		return tree.Accept(v)
-	}
-}
-
-func (v *DclVisitor) VisitProgram(ctx *compiler.ProgramContext) interface{} {
-	fmt.Printf("🔹 DclVisitor.VisitProgram EJECUTADO\n")
-	fmt.Printf("🔹 Número de statements: %d\n", len(ctx.AllStmt()))
-
-	for _, stmt := range ctx.AllStmt() {
-		fmt.Printf("🔹 DclVisitor procesando stmt: %s\n", stmt.GetText())
-		v.Visit(stmt)
-	}
-	return nil
-}
-
-func (v *DclVisitor) VisitStmt(ctx *compiler.StmtContext) interface{} {
-
-	if ctx.Func_dcl() != nil {
-		v.Visit(ctx.Func_dcl())
-	} else if ctx.Strct_dcl() != nil {
-		v.Visit(ctx.Strct_dcl())
+// VisitProgram registra las funciones y los structs globales del programa,
+// para que se puedan usar antes de su declaración.
+func (v *DclVisitor) VisitProgram(program *ast.Program) {
+	v.Log.Debug("declaraciones globales", "stmts", len(program.Stmts))
+
+	for _, stmt := range program.Stmts {
+		switch stmt := stmt.(type) {
+		case *ast.FuncDecl:
+			v.VisitFuncDecl(stmt)
+		case *ast.StructDecl:
+			v.VisitStructDecl(stmt)
+		}
 	}
-
-	return nil
 }
 
-func (v *DclVisitor) VisitFuncDecl(ctx *compiler.FuncDeclContext) interface{} {
+func (v *DclVisitor) VisitFuncDecl(decl *ast.FuncDecl) {
 
 	if v.ScopeTrace.CurrentScope != v.ScopeTrace.GlobalScope {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "Las funciones solo pueden ser declaradas en el scope global")
+		v.ErrorTable.NewSemanticError(decl.Span.Start, "Las funciones solo pueden ser declaradas en el scope global")
 	}
 
-	funcName := ctx.ID().GetText()
+	funcName := decl.Name.Name
 
 	params := make([]*Param, 0)
 
-	if ctx.Param_list() != nil {
-		params = v.Visit(ctx.Param_list()).([]*Param)
+	for _, param := range decl.Params {
+		params = append(params, v.VisitParam(param))
 	}
 
 	if len(params) > 0 {
@@ -80,84 +60,47 @@ func (v *DclVisitor) VisitFuncDecl(ctx *compiler.FuncDeclContext) interface{} {
 
 		for _, param := range params {
 			if param.ParamType() != baseParamType {
-				v.ErrorTable.NewSemanticError(param.Token, "Todos los parametros de la funcion deben ser del mismo tipo")
-				return nil
+				v.ErrorTable.NewSemanticError(param.Pos, "Todos los parametros de la funcion deben ser del mismo tipo")
+				return
 			}
 		}
 	}
 
 	returnType := value.IVOR_NIL
-	var returnTypeToken antlr.Token = nil
 
-	if ctx.Type_() != nil {
-		returnType = ctx.Type_().GetText()
-		returnTypeToken = ctx.Type_().GetStart()
+	if decl.Result != nil {
+		returnType = decl.Result.Syntax()
 	}
 
-	body := ctx.AllStmt()
-
 	function := &Function{ // pointer ?
-		Name:            funcName,
-		Param:           params,
-		ReturnType:      returnType,
-		Body:            body,
-		DeclScope:       v.ScopeTrace.CurrentScope,
-		ReturnTypeToken: returnTypeToken,
-		Token:           ctx.GetStart(),
+		Name:       funcName,
+		Param:      params,
+		ReturnType: returnType,
+		Result:     decl.Result,
+		Body:       decl.Body,
+		DeclScope:  v.ScopeTrace.CurrentScope,
+		Pos:        decl.Span.Start,
 	}
 
 	ok, msg := v.ScopeTrace.AddFunction(funcName, function)
 
 	if !ok {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
+		v.ErrorTable.NewSemanticError(decl.Span.Start, msg)
 	}
-
-	return nil
 }
 
-func (v *DclVisitor) VisitParamList(ctx *compiler.ParamListContext) interface{} {
-
-	params := make([]*Param, 0)
-
-	for _, param := range ctx.AllFunc_param() {
-		params = append(params, v.Visit(param).(*Param))
-	}
-
-	return params
-}
-
-func (v *DclVisitor) VisitFuncParam(ctx *compiler.FuncParamContext) interface{} {
-
-	externName := ""
-	innerName := ""
-
-	// at least ID(0) is defined
-	// only 1 ID defined
-	if ctx.ID() == nil {
-		// innerName : type
-		// _ : type
-		innerName = "_"
-	} else {
-		// externName innerName : type
-		externName = "_"
-		innerName = ctx.ID().GetText()
-	}
-
-	passByReference := false
-
-	paramType := ctx.Type_().GetText()
+func (v *DclVisitor) VisitParam(param *ast.Param) *Param {
 
+	// externName innerName : type
 	return &Param{
-		ExternName:      externName,
-		InnerName:       innerName,
-		PassByReference: passByReference,
-		Type:            paramType,
-		Token:           ctx.GetStart(),
+		ExternName:      "_",
+		InnerName:       param.Name.Name,
+		PassByReference: false,
+		Type:            param.Type.Syntax(),
+		Pos:             param.Span.Start,
 	}
-
 }
 
-func (v *DclVisitor) VisitStructDecl(ctx *compiler.StructDeclContext) interface{} {
-	v.StructNames = append(v.StructNames, ctx.ID().GetText())
-	return nil
+func (v *DclVisitor) VisitStructDecl(decl *ast.StructDecl) {
+	v.StructNames = append(v.StructNames, decl.Name.Name)
 }
@@ -0,0 +1,295 @@
+package repl
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// StopReason indica por qué se detuvo el programa.
+type StopReason string
+
+const (
+	StopEntry      StopReason = "entry"
+	StopBreakpoint StopReason = "breakpoint"
+	StopStep       StopReason = "step"
+	StopPause      StopReason = "pause"
+)
+
+type stepMode int
+
+const (
+	modeContinue stepMode = iota
+	modeEntry
+	modeStepIn
+	modeStepOver
+	modeStepOut
+	modeTerminate
+)
+
+// DebugFrame es un marco de la pila de llamadas de VLang. Line y Column
+// son la posición de la sentencia que el marco está ejecutando.
+type DebugFrame struct {
+	Name   string
+	Line   int
+	Column int
+	scope  *BaseScopeTrace
+}
+
+// Debugger es un Tracer que detiene al ReplVisitor en los breakpoints y al
+// avanzar paso a paso. El programa corre en su propia goroutine: cuando se
+// detiene llama a OnStop y se bloquea hasta que otra goroutine llama a
+// Continue, StepIn, StepOver, StepOut o Terminate.
+type Debugger struct {
+	ScopeTrace *ScopeTrace
+	OnStop     func(reason StopReason)
+
+	mu             sync.Mutex
+	breakpoints    map[int]bool
+	mode           stepMode
+	stepDepth      int
+	pauseRequested bool
+	paused         bool
+	terminated     bool
+	frames         []*DebugFrame
+	resume         chan stepMode
+}
+
+func NewDebugger(scopeTrace *ScopeTrace) *Debugger {
+	return &Debugger{
+		ScopeTrace:  scopeTrace,
+		breakpoints: make(map[int]bool),
+		frames:      []*DebugFrame{{Name: "main", scope: scopeTrace.GlobalScope}},
+		resume:      make(chan stepMode),
+	}
+}
+
+// SetBreakpoints reemplaza los breakpoints de línea.
+func (d *Debugger) SetBreakpoints(lines []int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.breakpoints = make(map[int]bool)
+	for _, line := range lines {
+		d.breakpoints[line] = true
+	}
+}
+
+// StopOnEntry hace que el programa se detenga en su primera sentencia.
+func (d *Debugger) StopOnEntry() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.mode = modeEntry
+}
+
+// === TRACER ===
+
+func (d *Debugger) OnStatement(event TraceEvent) {
+	d.mu.Lock()
+	if d.terminated {
+		d.mu.Unlock()
+		runtime.Goexit()
+	}
+
+	top := d.frames[len(d.frames)-1]
+	// un breakpoint se respeta una vez por sentencia de la línea, no por
+	// cada sentencia anidada que empieza más a la derecha en la misma línea
+	nested := top.Line == event.Line && event.Column > top.Column
+	top.Line = event.Line
+	top.Column = event.Column
+	top.scope = d.ScopeTrace.CurrentScope
+
+	reason, stop := d.shouldStop(event.Line, nested)
+	if stop {
+		d.paused = true
+		d.pauseRequested = false
+	}
+	d.mu.Unlock()
+
+	if stop {
+		d.wait(reason)
+	}
+}
+
+func (d *Debugger) OnCall(event TraceEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.frames = append(d.frames, &DebugFrame{Name: event.Name, Line: event.Line, Column: event.Column})
+}
+
+func (d *Debugger) OnReturn(event TraceEvent) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.frames) > 1 {
+		d.frames = d.frames[:len(d.frames)-1]
+	}
+}
+
+func (d *Debugger) OnAssign(event TraceEvent)    {}
+func (d *Debugger) OnScopePush(event TraceEvent) {}
+func (d *Debugger) OnScopePop(event TraceEvent)  {}
+
+// shouldStop se llama con mu tomado.
+func (d *Debugger) shouldStop(line int, nested bool) (StopReason, bool) {
+	depth := len(d.frames)
+
+	switch {
+	case d.pauseRequested:
+		return StopPause, true
+	case d.mode == modeEntry:
+		return StopEntry, true
+	case d.mode == modeStepIn:
+		return StopStep, true
+	case d.mode == modeStepOver && depth <= d.stepDepth:
+		return StopStep, true
+	case d.mode == modeStepOut && depth < d.stepDepth:
+		return StopStep, true
+	case d.breakpoints[line] && !nested:
+		return StopBreakpoint, true
+	}
+	return "", false
+}
+
+// wait avisa que el programa se detuvo y bloquea la goroutine del programa
+// hasta recibir la siguiente orden.
+func (d *Debugger) wait(reason StopReason) {
+	if d.OnStop != nil {
+		d.OnStop(reason)
+	}
+
+	mode := <-d.resume
+
+	d.mu.Lock()
+	d.paused = false
+	if mode == modeTerminate {
+		d.mu.Unlock()
+		// Goexit ejecuta los defer del visitor sin que los recover de los
+		// ciclos y las funciones lo detengan
+		runtime.Goexit()
+	}
+	d.mode = mode
+	d.stepDepth = len(d.frames)
+	d.mu.Unlock()
+}
+
+// === ÓRDENES ===
+
+func (d *Debugger) command(mode stepMode) bool {
+	d.mu.Lock()
+	paused := d.paused
+	d.mu.Unlock()
+
+	if !paused {
+		return false
+	}
+	d.resume <- mode
+	return true
+}
+
+// Continue reanuda el programa hasta el siguiente breakpoint. Devuelve false
+// si el programa no estaba detenido.
+func (d *Debugger) Continue() bool { return d.command(modeContinue) }
+
+// StepIn avanza hasta la siguiente sentencia, entrando a las funciones.
+func (d *Debugger) StepIn() bool { return d.command(modeStepIn) }
+
+// StepOver avanza hasta la siguiente sentencia del marco actual o de uno
+// externo, sin detenerse dentro de las funciones que se llamen.
+func (d *Debugger) StepOver() bool { return d.command(modeStepOver) }
+
+// StepOut avanza hasta volver al marco que llamó al actual.
+func (d *Debugger) StepOut() bool { return d.command(modeStepOut) }
+
+// Pause detiene el programa en la siguiente sentencia.
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pauseRequested = true
+}
+
+// Terminate termina el programa. Si estaba detenido la goroutine del
+// programa sale en ese momento; si no, sale en la siguiente sentencia.
+func (d *Debugger) Terminate() {
+	d.mu.Lock()
+	d.terminated = true
+	paused := d.paused
+	d.mu.Unlock()
+
+	if paused {
+		d.resume <- modeTerminate
+	}
+}
+
+// === INSPECCIÓN ===
+
+// Paused indica si el programa está detenido. Frames y Variables solo
+// devuelven datos con el programa detenido.
+func (d *Debugger) Paused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.paused
+}
+
+// Frames devuelve la pila de llamadas desde el marco más interno.
+func (d *Debugger) Frames() []DebugFrame {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.paused {
+		return nil
+	}
+
+	frames := make([]DebugFrame, 0, len(d.frames))
+	for i := len(d.frames) - 1; i >= 0; i-- {
+		frames = append(frames, *d.frames[i])
+	}
+	return frames
+}
+
+// Locals devuelve las variables visibles desde el marco indicado (0 es el
+// más interno) sin contar las globales.
+func (d *Debugger) Locals(frame int) []TraceValue {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.paused || frame < 0 || frame >= len(d.frames) {
+		return nil
+	}
+
+	scope := d.frames[len(d.frames)-1-frame].scope
+	if scope == nil {
+		scope = d.ScopeTrace.CurrentScope
+	}
+	return scopeVariables(scope, d.ScopeTrace.GlobalScope)
+}
+
+// Globals devuelve las variables del scope global.
+func (d *Debugger) Globals() []TraceValue {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.paused {
+		return nil
+	}
+	return scopeVariables(d.ScopeTrace.GlobalScope, nil)
+}
+
+// scopeVariables junta las variables desde scope hasta stop (sin incluirlo),
+// ordenadas por nombre; las de scopes internos ocultan a las externas.
+func scopeVariables(scope *BaseScopeTrace, stop *BaseScopeTrace) []TraceValue {
+	seen := make(map[string]bool)
+	vars := make([]TraceValue, 0)
+
+	for ; scope != nil && scope != stop; scope = scope.Parent() {
+		for name, variable := range scope.variables {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			vars = append(vars, snapshot(name, variable.Value))
+		}
+	}
+
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	return vars
+}
@@ -1,6 +1,6 @@
 package repl
 
-import "github.com/antlr4-go/antlr/v4"
+import "main.go/ast"
 
 /*
 ErrorTable es una estructura que almacena errores encontrados durante el análisis de código.
@@ -11,6 +11,7 @@ const (
 	SyntaxError   = "syntax"
 	SemanticError = "semantic"
 	RuntimeError  = "runtime"
+	LintWarning   = "lint" // advertencias del linter; no impiden la ejecución
 )
 
 // Mapeo de tipos para mostrar en español
@@ -19,6 +20,7 @@ var ErrorTypeNames = map[string]string{
 	SyntaxError:   "Error Sintáctico",
 	SemanticError: "Error Semántico",
 	RuntimeError:  "Error en Tiempo de Ejecución",
+	LintWarning:   "Advertencia",
 }
 
 // Error representa un error encontrado durante el análisis
@@ -27,8 +29,9 @@ type Error struct {
 	Column   int    `json:"column"`
 	Msg      string `json:"message"` // Cambiar a "message" para el frontend
 	Type     string `json:"type"`
-	Severity string `json:"severity"` // Agregar severidad para el frontend
-	Source   string `json:"source"`   // Agregar fuente del error
+	Severity string `json:"severity"`       // Agregar severidad para el frontend
+	Source   string `json:"source"`         // Agregar fuente del error
+	Rule     string `json:"rule,omitempty"` // regla del linter que generó la advertencia
 }
 
 // GetDisplayName retorna el nombre en español del tipo de error
@@ -48,6 +51,8 @@ func (e *Error) GetSeverity() string {
 		return "error"
 	case RuntimeError:
 		return "error"
+	case LintWarning:
+		return "warning"
 	default:
 		return "error"
 	}
@@ -76,6 +81,8 @@ func getSeverityByType(errorType string) string {
 	switch errorType {
 	case LexicalError, SyntaxError, SemanticError, RuntimeError:
 		return "error"
+	case LintWarning:
+		return "warning"
 	default:
 		return "error"
 	}
@@ -92,8 +99,8 @@ func (et *ErrorTable) NewSyntaxError(line int, column int, msg string) {
 }
 
 // NewSemanticError crea un nuevo error semántico y lo agrega a la tabla de errores.
-func (et *ErrorTable) NewSemanticError(token antlr.Token, msg string) {
-	et.AddError(token.GetLine(), token.GetColumn(), msg, SemanticError)
+func (et *ErrorTable) NewSemanticError(pos ast.Pos, msg string) {
+	et.AddError(pos.Line, pos.Column, msg, SemanticError)
 }
 
 // NewRuntimeError crea un nuevo error de tiempo de ejecución y lo agrega a la tabla de errores.
@@ -101,14 +108,33 @@ func (et *ErrorTable) NewRuntimeError(line int, column int, msg string) {
 	et.AddError(line, column, msg, RuntimeError)
 }
 
-// HasErrors retorna true si hay errores en la tabla
+// NewWarning agrega una advertencia del linter. rule es el identificador de
+// la regla que la generó.
+func (et *ErrorTable) NewWarning(line int, column int, msg string, rule string) {
+	et.AddError(line, column, msg, LintWarning)
+	et.Errors[len(et.Errors)-1].Source = "lint"
+	et.Errors[len(et.Errors)-1].Rule = rule
+}
+
+// HasErrors retorna true si hay errores en la tabla; las advertencias no cuentan
 func (et *ErrorTable) HasErrors() bool {
-	return len(et.Errors) > 0
+	return et.GetErrorCount() > 0
 }
 
-// GetErrorCount retorna el número total de errores
+// GetErrorCount retorna el número de errores, sin contar las advertencias
 func (et *ErrorTable) GetErrorCount() int {
-	return len(et.Errors)
+	count := 0
+	for _, err := range et.Errors {
+		if err.Severity != "warning" {
+			count++
+		}
+	}
+	return count
+}
+
+// GetWarningCount retorna el número de advertencias
+func (et *ErrorTable) GetWarningCount() int {
+	return len(et.Errors) - et.GetErrorCount()
 }
 
 // GetErrorsByType retorna errores filtrados por tipo
@@ -3,22 +3,21 @@ package repl
 import (
 	"fmt"
 
-	"github.com/antlr4-go/antlr/v4"
-	compiler "main.go/grammar"
+	"main.go/ast"
 	"main.go/value"
 )
 
 type Function struct {
-	Name            string
-	Param           []*Param
-	ReturnType      string
-	ReturnTypeToken antlr.Token
-	Body            []compiler.IStmtContext
-	DeclScope       *BaseScopeTrace
-	ReturnValue     value.IVOR
-	IsMutating      bool
-	DefaultScope    *BaseScopeTrace
-	Token           antlr.Token
+	Name         string
+	Param        []*Param
+	ReturnType   string
+	Result       *ast.TypeRef // tipo de retorno escrito en la declaración; nil si no tiene
+	Body         []ast.Stmt
+	DeclScope    *BaseScopeTrace
+	ReturnValue  value.IVOR
+	IsMutating   bool
+	DefaultScope *BaseScopeTrace
+	Pos          ast.Pos
 }
 
 func (f *Function) Value() interface{} {
@@ -33,26 +32,29 @@ func (f *Function) Copy() value.IVOR {
 	return f
 }
 
-func (f *Function) Exec(visitor *ReplVisitor, args []*Argument, token antlr.Token) {
+func (f *Function) Exec(visitor *ReplVisitor, args []*Argument, pos ast.Pos) {
 
 	context := visitor.GetReplContext()
 
 	// validate args
-	argsOk, argsMap := f.ValidateArgs(context, args, token)
+	argsOk, argsMap := f.ValidateArgs(context, args, pos)
 
 	if !argsOk {
 		f.ReturnValue = value.DefaultNilValue
 		return
 	}
 
+	// el límite de profundidad se revisa antes de tocar el scope; el defer de abajo deshace el resto
+	visitor.enterCall(pos)
+
 	// create new scope
 	initialScope := context.ScopeTrace.CurrentScope // save current scope, scope at call time
 
 	if f.DefaultScope != nil {
 		context.ScopeTrace.CurrentScope = f.DefaultScope // set function default scope as current scope
 	} else {
-		context.ScopeTrace.CurrentScope = f.DeclScope            // set function declaration scope as current scope
-		context.ScopeTrace.PushScope("func: " + token.GetText()) // push a new function scope
+		context.ScopeTrace.CurrentScope = f.DeclScope // set function declaration scope as current scope
+		visitor.pushScope("func: "+f.Name, pos)       // push a new function scope
 	}
 
 	wasMutating := context.ScopeTrace.CurrentScope.IsMutating
@@ -70,25 +72,33 @@ func (f *Function) Exec(visitor *ReplVisitor, args []*Argument, token antlr.Toke
 	// ✅ CORRECCIÓN: handle return from callstack - PRIMERO recover, DESPUÉS cleanup
 	defer func() {
 		// 1. PRIMERO: Manejar panic/return
-		if item, ok := recover().(*CallStackItem); item != nil && ok {
+		r := recover()
+		item, ok := r.(*CallStackItem)
+		if item != nil {
 
 			if item != funcItem {
-				context.ErrorTable.NewSemanticError(token, "Return invalido")
+				context.ErrorTable.NewSemanticError(pos, "Return invalido")
 				f.ReturnValue = value.DefaultNilValue
 			} else {
 				// validate return type
-				f.ValidateReturn(context, item.ReturnValue, token) // return value from return statement
+				f.ValidateReturn(context, item.ReturnValue, pos) // return value from return statement
 			}
-		} else {
+		} else if r == nil {
 			// No hay return explícito, usar valor por defecto
-			f.ValidateReturn(context, value.DefaultNilValue, token)
+			f.ValidateReturn(context, value.DefaultNilValue, pos)
 		}
 
 		// 2. DESPUÉS: Limpiar call stack y restaurar scope
 		context.CallStack.Clean(funcItem)                        // clean callstack
-		context.ScopeTrace.PopScope()                            // pop function scope
+		visitor.popScope(pos)                                    // pop function scope
 		context.ScopeTrace.CurrentScope.IsMutating = wasMutating // restore mutating flag
 		context.ScopeTrace.CurrentScope = initialScope           // restore the call time scope
+		visitor.callDepth--
+
+		// 3. Los pánicos que no son un return (límites, errores internos) siguen hacia arriba
+		if r != nil && !ok {
+			panic(r)
+		}
 	}()
 
 	// push args to scope
@@ -98,8 +108,8 @@ func (f *Function) Exec(visitor *ReplVisitor, args []*Argument, token antlr.Toke
 		if arg.PassByReference {
 
 			if arg.VariableRef == nil {
-				context.ErrorTable.NewSemanticError(arg.Token, "No es posible pasar por referencia un valor que no este asociado a una variable")
-				f.ValidateReturn(context, value.DefaultNilValue, token)
+				context.ErrorTable.NewSemanticError(arg.Pos, "No es posible pasar por referencia un valor que no este asociado a una variable")
+				f.ValidateReturn(context, value.DefaultNilValue, pos)
 				return
 			}
 
@@ -109,24 +119,24 @@ func (f *Function) Exec(visitor *ReplVisitor, args []*Argument, token antlr.Toke
 			}
 
 			// add pointer to scope
-			context.ScopeTrace.CurrentScope.AddVariable(varName, value.IVOR_POINTER, pointer, false, false, arg.Token)
+			context.ScopeTrace.CurrentScope.AddVariable(varName, value.IVOR_POINTER, pointer, false, false, arg.Pos)
 			continue
 		}
 
-		context.ScopeTrace.CurrentScope.AddVariable(varName, arg.Value.Type(), arg.Value.Copy(), false, false, arg.Token)
+		context.ScopeTrace.CurrentScope.AddVariable(varName, arg.Value.Type(), arg.Value.Copy(), false, false, arg.Pos)
 	}
 
 	// evaluate body
 	for _, stmt := range f.Body {
-		visitor.Visit(stmt)
+		visitor.VisitStmt(stmt)
 	}
 }
 
-func (f *Function) ValidateArgs(context *ReplContext, args []*Argument, token antlr.Token) (bool, map[string]*Argument) {
+func (f *Function) ValidateArgs(context *ReplContext, args []*Argument, pos ast.Pos) (bool, map[string]*Argument) {
 
 	// validate arg count
 	if len(args) != len(f.Param) {
-		context.ErrorTable.NewSemanticError(token, "Numero de argumentos invalido")
+		context.ErrorTable.NewSemanticError(pos, "Numero de argumentos invalido")
 		return false, nil
 	}
 
@@ -158,7 +168,7 @@ func (f *Function) ValidateArgs(context *ReplContext, args []*Argument, token an
 
 		// validate arg exists
 		if argToValidate == nil {
-			context.ErrorTable.NewSemanticError(token, fmt.Sprintf("Argumento %s no especificado", param.InnerName))
+			context.ErrorTable.NewSemanticError(pos, fmt.Sprintf("Argumento %s no especificado", param.InnerName))
 			errorFound = true
 			continue
 		}
@@ -171,10 +181,9 @@ func (f *Function) ValidateArgs(context *ReplContext, args []*Argument, token an
 			if canConvert {
 				// Actualizar el argumento con el valor convertido
 				argToValidate.Value = convertedValue
-				fmt.Printf("🔄 DEBUG: Conversión implícita %s -> %s para parámetro %s\n",
-					argToValidate.Value.Type(), param.Type, param.InnerName)
+				context.Log.Debug("conversión implícita de argumento", "param", param.InnerName, "type", param.Type)
 			} else {
-				context.ErrorTable.NewSemanticError(token, fmt.Sprintf("Tipo de argumento %s invalido, esperado %s, recibido %s", param.InnerName, param.Type, argToValidate.Value.Type()))
+				context.ErrorTable.NewSemanticError(pos, fmt.Sprintf("Tipo de argumento %s invalido, esperado %s, recibido %s", param.InnerName, param.Type, argToValidate.Value.Type()))
 				errorFound = true
 				continue
 			}
@@ -182,7 +191,7 @@ func (f *Function) ValidateArgs(context *ReplContext, args []*Argument, token an
 
 		// validate pass by reference
 		if argToValidate.PassByReference != param.PassByReference {
-			context.ErrorTable.NewSemanticError(token, fmt.Sprintf("Argumento %s no es pasado por referencia", param.InnerName))
+			context.ErrorTable.NewSemanticError(pos, fmt.Sprintf("Argumento %s no es pasado por referencia", param.InnerName))
 			errorFound = true
 			continue
 		}
@@ -198,13 +207,13 @@ func (f *Function) ValidateArgs(context *ReplContext, args []*Argument, token an
 	return true, finalArgsMap
 }
 
-func (f *Function) ValidateReturn(context *ReplContext, val value.IVOR, token antlr.Token) {
+func (f *Function) ValidateReturn(context *ReplContext, val value.IVOR, pos ast.Pos) {
 
 	if val.Type() != f.ReturnType {
-		if f.ReturnTypeToken != nil {
-			context.ErrorTable.NewSemanticError(f.ReturnTypeToken, fmt.Sprintf("Tipo de retorno invalido, se esperaba %s, se obtuvo %s", f.ReturnType, val.Type()))
+		if f.Result != nil {
+			context.ErrorTable.NewSemanticError(f.Result.Span.Start, fmt.Sprintf("Tipo de retorno invalido, se esperaba %s, se obtuvo %s", f.ReturnType, val.Type()))
 		} else {
-			context.ErrorTable.NewSemanticError(token, fmt.Sprintf("Tipo de retorno invalido, se esperaba %s, se obtuvo %s", f.ReturnType, val.Type()))
+			context.ErrorTable.NewSemanticError(pos, fmt.Sprintf("Tipo de retorno invalido, se esperaba %s, se obtuvo %s", f.ReturnType, val.Type()))
 		}
 
 		f.ReturnValue = value.DefaultNilValue
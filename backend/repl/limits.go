@@ -0,0 +1,100 @@
+package repl
+
+import (
+	"fmt"
+
+	"main.go/ast"
+	"main.go/value"
+)
+
+// DefaultMaxCallDepth es la profundidad de llamadas que acepta un visitor
+// creado con NewVisitor. Una recursión sin caso base agota la pila de Go,
+// y eso no se puede recuperar: termina el proceso entero.
+const DefaultMaxCallDepth = 5000
+
+// Limits acota la ejecución del ReplVisitor. Un límite en cero no se
+// revisa.
+//   - MaxSteps: sentencias ejecutadas más iteraciones de los ciclos, para
+//     que un ciclo con el cuerpo vacío también cuente.
+//   - MaxCallDepth: llamadas a funciones de usuario anidadas.
+//   - MaxStringLength: bytes de una cadena construida con + o +=; con pocos
+//     pasos, s += s ya agota la memoria.
+type Limits struct {
+	MaxSteps        int
+	MaxCallDepth    int
+	MaxStringLength int
+}
+
+// SandboxLimits son los límites para ejecutar código que llega de afuera,
+// como el del servidor y las sesiones del REPL.
+var SandboxLimits = Limits{
+	MaxSteps:        10_000_000,
+	MaxCallDepth:    DefaultMaxCallDepth,
+	MaxStringLength: 16 << 20,
+}
+
+// LimitExceeded es el pánico con el que el visitor abandona la ejecución al
+// superar un límite. VisitProgram lo recupera y lo registra como error de
+// ejecución; los ciclos y las funciones lo dejan pasar.
+type LimitExceeded struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *LimitExceeded) Error() string {
+	return e.Msg
+}
+
+// step cuenta una sentencia o una iteración.
+func (v *ReplVisitor) step(pos ast.Pos) {
+	v.steps++
+	if v.Limits.MaxSteps > 0 && v.steps > v.Limits.MaxSteps {
+		panic(newLimitExceeded(pos, fmt.Sprintf("Se superó el límite de %d pasos de ejecución", v.Limits.MaxSteps)))
+	}
+}
+
+// checkString revisa el largo de una cadena recién construida.
+func (v *ReplVisitor) checkString(pos ast.Pos, result value.IVOR) {
+	str, ok := result.(*value.StringValue)
+	if ok && v.Limits.MaxStringLength > 0 && len(str.InternalValue) > v.Limits.MaxStringLength {
+		panic(newLimitExceeded(pos, fmt.Sprintf("Se superó el largo máximo de %d bytes para una cadena", v.Limits.MaxStringLength)))
+	}
+}
+
+// ResetSteps reinicia el contador de pasos, para que cada entrada de una
+// sesión tenga su propio límite.
+func (v *ReplVisitor) ResetSteps() {
+	v.steps = 0
+}
+
+// enterCall cuenta una llamada; quien llama debe decrementar callDepth al
+// salir de la función.
+func (v *ReplVisitor) enterCall(pos ast.Pos) {
+	if v.Limits.MaxCallDepth > 0 && v.callDepth >= v.Limits.MaxCallDepth {
+		panic(newLimitExceeded(pos, fmt.Sprintf("Se superó la profundidad máxima de %d llamadas", v.Limits.MaxCallDepth)))
+	}
+	v.callDepth++
+}
+
+func newLimitExceeded(pos ast.Pos, msg string) *LimitExceeded {
+	return &LimitExceeded{Line: pos.Line, Column: pos.Column, Msg: msg}
+}
+
+// recoverLimit registra en la tabla de errores el límite superado y deja el
+// visitor en el scope y la pila que tenía al empezar, para que una sesión
+// pueda seguir usándolo. Cualquier otro pánico sigue hacia arriba. Se llama
+// con defer.
+func (v *ReplVisitor) recoverLimit(scope *BaseScopeTrace, callStack int) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	limit, ok := r.(*LimitExceeded)
+	if !ok {
+		panic(r)
+	}
+	v.ErrorTable.NewRuntimeError(limit.Line, limit.Column, limit.Msg)
+	v.ScopeTrace.CurrentScope = scope
+	v.CallStack.Items = v.CallStack.Items[:callStack]
+}
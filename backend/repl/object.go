@@ -1,7 +1,7 @@
 package repl
 
 import (
-	"github.com/antlr4-go/antlr/v4"
+	"main.go/ast"
 	"main.go/value"
 )
 
@@ -10,7 +10,7 @@ type ObjectValue struct {
 	AuxObject     interface{}
 	ConcretType   string
 	v             *ReplVisitor
-	t             antlr.Token
+	pos           ast.Pos
 }
 
 func (o ObjectValue) Value() interface{} {
@@ -35,17 +35,17 @@ func (o *ObjectValue) Copy() value.IVOR {
 		})
 	}
 
-	return NewObjectValue(o.v, o.ConcretType, o.t, args, true)
+	return NewObjectValue(o.v, o.ConcretType, o.pos, args, true)
 }
 
-func NewObjectValue(v *ReplVisitor, targetStruct string, targetToken antlr.Token, args []*Argument, allowReinitialize bool) value.IVOR {
+func NewObjectValue(v *ReplVisitor, targetStruct string, targetPos ast.Pos, args []*Argument, allowReinitialize bool) value.IVOR {
 
 	// Check if struct exists
 
 	structTemplate, msg := v.ScopeTrace.GlobalScope.GetStruct(targetStruct)
 
 	if structTemplate == nil {
-		v.ErrorTable.NewSemanticError(targetToken, msg)
+		v.ErrorTable.NewSemanticError(targetPos, msg)
 	}
 
 	internalScope := NewStructScope()
@@ -70,7 +70,7 @@ func NewObjectValue(v *ReplVisitor, targetStruct string, targetToken antlr.Token
 
 		// repeat arg
 		if _, ok := argMap[arg.Name]; ok {
-			v.ErrorTable.NewSemanticError(arg.Token, "El argumento "+arg.Name+" ya fue definido")
+			v.ErrorTable.NewSemanticError(arg.Pos, "El argumento "+arg.Name+" ya fue definido")
 			return value.DefaultNilValue
 		}
 
@@ -86,7 +86,7 @@ func NewObjectValue(v *ReplVisitor, targetStruct string, targetToken antlr.Token
 
 		if !found {
 			if prop.Value == value.DefaultUnInitializedValue {
-				v.ErrorTable.NewSemanticError(targetToken, "El campo "+prop.Name+" no fue inicializado en el constructor")
+				v.ErrorTable.NewSemanticError(targetPos, "El campo "+prop.Name+" no fue inicializado en el constructor")
 				return value.DefaultNilValue
 			}
 
@@ -96,7 +96,7 @@ func NewObjectValue(v *ReplVisitor, targetStruct string, targetToken antlr.Token
 		// then the arg exists
 		if prop.IsConst {
 			if (prop.Value != value.DefaultUnInitializedValue) && !allowReinitialize {
-				v.ErrorTable.NewSemanticError(targetToken, "El campo "+prop.Name+" es inmutable y ya fue inicializado")
+				v.ErrorTable.NewSemanticError(targetPos, "El campo "+prop.Name+" es inmutable y ya fue inicializado")
 				return value.DefaultNilValue
 			}
 
@@ -132,7 +132,7 @@ func NewObjectValue(v *ReplVisitor, targetStruct string, targetToken antlr.Token
 		}
 
 		if !throwError {
-			v.ErrorTable.NewSemanticError(targetToken, msg)
+			v.ErrorTable.NewSemanticError(targetPos, msg)
 			return value.DefaultNilValue
 		}
 
@@ -142,7 +142,7 @@ func NewObjectValue(v *ReplVisitor, targetStruct string, targetToken antlr.Token
 	// validate unused args
 	for _, arg := range args {
 		if _, ok := usedArgs[arg.Name]; !ok {
-			v.ErrorTable.NewSemanticError(arg.Token, "El argumento "+arg.Name+" no es utilizado en el constructor")
+			v.ErrorTable.NewSemanticError(arg.Pos, "El argumento "+arg.Name+" no es utilizado en el constructor")
 		}
 	}
 
@@ -160,7 +160,7 @@ func NewObjectValue(v *ReplVisitor, targetStruct string, targetToken antlr.Token
 
 	instanceInternalScope := NewStructScope()
 
-	instanceInternalScope.AddVariable("self", value.IVOR_SELF, selfObject, true, false, nil)
+	instanceInternalScope.AddVariable("self", value.IVOR_SELF, selfObject, true, false, ast.Pos{})
 
 	// make functions use the instance scope
 
@@ -179,7 +179,7 @@ func NewObjectValue(v *ReplVisitor, targetStruct string, targetToken antlr.Token
 		InternalScope: internalScope,
 		ConcretType:   targetStruct,
 		v:             v,
-		t:             targetToken,
+		pos:           targetPos,
 	}
 }
 
@@ -1,14 +1,14 @@
 package repl
 
 import (
-	"github.com/antlr4-go/antlr/v4"
+	"main.go/ast"
 	"main.go/value"
 )
 
 type ObjectBuiltInFunction struct {
 	*Function
 	Object     *ObjectValue
-	CustomExec func(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, args map[string]*Argument, token antlr.Token)
+	CustomExec func(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, args map[string]*Argument, pos ast.Pos)
 }
 
 // implementing ivor
@@ -24,19 +24,19 @@ func (b ObjectBuiltInFunction) Copy() value.IVOR {
 	return b
 }
 
-func (f *ObjectBuiltInFunction) Exec(visitor *ReplVisitor, args []*Argument, token antlr.Token) {
+func (f *ObjectBuiltInFunction) Exec(visitor *ReplVisitor, args []*Argument, pos ast.Pos) {
 
 	context := visitor.GetReplContext()
 
 	// validate args
-	argsOk, argsMap := f.ValidateArgs(context, args, token)
+	argsOk, argsMap := f.ValidateArgs(context, args, pos)
 
 	if !argsOk {
 		f.ReturnValue = value.DefaultNilValue
 		return
 	}
 
-	f.CustomExec(f, visitor, argsMap, token)
+	f.CustomExec(f, visitor, argsMap, pos)
 
 }
 
@@ -54,11 +54,10 @@ var appendParams = []*Param{
 		InnerName:       "_",
 		Type:            value.IVOR_ANY,
 		PassByReference: false,
-		Token:           nil,
 	},
 }
 
-func appendCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, args map[string]*Argument, token antlr.Token) {
+func appendCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, args map[string]*Argument, pos ast.Pos) {
 
 	builtinRef.ReturnValue = value.DefaultNilValue
 
@@ -69,7 +68,7 @@ func appendCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, a
 	arg := args["_"]
 
 	if vector.ItemType != arg.Value.Type() {
-		visitor.ErrorTable.NewSemanticError(arg.Token, "No se puede agregar un valor de tipo "+arg.Value.Type()+" a un vector de tipo "+vector.ItemType)
+		visitor.ErrorTable.NewSemanticError(arg.Pos, "No se puede agregar un valor de tipo "+arg.Value.Type()+" a un vector de tipo "+vector.ItemType)
 		return
 	}
 	vector.InternalValue = append(vector.InternalValue, arg.Value)
@@ -83,11 +82,10 @@ var removeParams = []*Param{
 		InnerName:       "at",
 		Type:            value.IVOR_INT,
 		PassByReference: false,
-		Token:           nil,
 	},
 }
 
-func removeCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, args map[string]*Argument, token antlr.Token) {
+func removeCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, args map[string]*Argument, pos ast.Pos) {
 
 	builtinRef.ReturnValue = value.DefaultNilValue
 
@@ -98,13 +96,13 @@ func removeCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, a
 	arg := args["at"]
 
 	if arg.Value.Type() != value.IVOR_INT {
-		visitor.ErrorTable.NewSemanticError(arg.Token, "El argumento 'at' debe ser de tipo Int")
+		visitor.ErrorTable.NewSemanticError(arg.Pos, "El argumento 'at' debe ser de tipo Int")
 		return
 	}
 
 	// out of bounds
 	if arg.Value.Value().(int) >= vector.Size() || arg.Value.Value().(int) < 0 {
-		visitor.ErrorTable.NewSemanticError(arg.Token, "El indice esta fuera de rango")
+		visitor.ErrorTable.NewSemanticError(arg.Pos, "El indice esta fuera de rango")
 		return
 	}
 
@@ -120,7 +118,7 @@ func removeCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, a
 
 var removeLastParams = []*Param{}
 
-func removeLastCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, args map[string]*Argument, token antlr.Token) {
+func removeLastCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, args map[string]*Argument, pos ast.Pos) {
 
 	builtinRef.ReturnValue = value.DefaultNilValue
 
@@ -128,7 +126,7 @@ func removeLastCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisito
 	vector := builtinRef.Object.AuxObject.(*VectorValue)
 
 	if vector.Size() == 0 {
-		visitor.ErrorTable.NewSemanticError(token, "El vector esta vacio y no se puede remover el ultimo elemento")
+		visitor.ErrorTable.NewSemanticError(pos, "El vector esta vacio y no se puede remover el ultimo elemento")
 		return
 	}
 
@@ -172,16 +170,16 @@ func AddVectorBuiltins(vectorRef *VectorValue) {
 	})
 
 	// make isEmpty a property
-	vectorScope.AddVariable("isEmpty", value.IVOR_BOOL, vectorRef.IsEmpty, true, false, nil)
+	vectorScope.AddVariable("isEmpty", value.IVOR_BOOL, vectorRef.IsEmpty, true, false, ast.Pos{})
 
 	// make count a property
-	vectorScope.AddVariable("count", value.IVOR_INT, vectorRef.SizeValue, true, false, nil)
+	vectorScope.AddVariable("count", value.IVOR_INT, vectorRef.SizeValue, true, false, ast.Pos{})
 
 	vectorRef.ObjectValue = vectorInternalObject
 }
 
 /*
-func appendCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, args map[string]*Argument, token antlr.Token) {
+func appendCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, args map[string]*Argument, pos ast.Pos) {
 
 	builtinRef.ReturnValue = value.DefaultNilValue
 
@@ -192,7 +190,7 @@ func appendCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, a
 	arg := args["_"]
 
 	if vector.ItemType != arg.Value.Type() {
-		visitor.ErrorTable.NewSemanticError(arg.Token, "No se puede agregar un valor de tipo "+arg.Value.Type()+" a un vector de tipo "+vector.ItemType)
+		visitor.ErrorTable.NewSemanticError(arg.Pos, "No se puede agregar un valor de tipo "+arg.Value.Type()+" a un vector de tipo "+vector.ItemType)
 		return
 	}
 	vector.InternalValue = append(vector.InternalValue, arg.Value)
@@ -211,12 +209,11 @@ var removeParams = []*Param{
 		InnerName:       "at",
 		Type:            value.IVOR_INT,
 		PassByReference: false,
-		Token:           nil,
 	},
 }
 
 
-func removeCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, args map[string]*Argument, token antlr.Token) {
+func removeCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, args map[string]*Argument, pos ast.Pos) {
 
 	builtinRef.ReturnValue = value.DefaultNilValue
 
@@ -227,13 +224,13 @@ func removeCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, a
 	arg := args["at"]
 
 	if arg.Value.Type() != value.IVOR_INT {
-		visitor.ErrorTable.NewSemanticError(arg.Token, "El argumento 'at' debe ser de tipo Int")
+		visitor.ErrorTable.NewSemanticError(arg.Pos, "El argumento 'at' debe ser de tipo Int")
 		return
 	}
 
 	// out of bounds
 	if arg.Value.Value().(int) >= vector.Size() || arg.Value.Value().(int) < 0 {
-		visitor.ErrorTable.NewSemanticError(arg.Token, "El indice esta fuera de rango")
+		visitor.ErrorTable.NewSemanticError(arg.Pos, "El indice esta fuera de rango")
 		return
 	}
 
@@ -249,7 +246,7 @@ func removeCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, a
 
 var removeLastParams = []*Param{}
 
-func removeLastCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, args map[string]*Argument, token antlr.Token) {
+func removeLastCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisitor, args map[string]*Argument, pos ast.Pos) {
 
 	builtinRef.ReturnValue = value.DefaultNilValue
 
@@ -257,7 +254,7 @@ func removeLastCustomExec(builtinRef *ObjectBuiltInFunction, visitor *ReplVisito
 	vector := builtinRef.Object.AuxObject.(*VectorValue)
 
 	if vector.Size() == 0 {
-		visitor.ErrorTable.NewSemanticError(token, "El vector esta vacio y no se puede remover el ultimo elemento")
+		visitor.ErrorTable.NewSemanticError(pos, "El vector esta vacio y no se puede remover el ultimo elemento")
 		return
 	}
 
@@ -302,10 +299,10 @@ func AddVectorBuiltins(vectorRef *VectorValue) {
 	})
 
 	// make isEmpty a property
-	vectorScope.AddVariable("isEmpty", value.IVOR_BOOL, vectorRef.IsEmpty, true, false, nil)
+	vectorScope.AddVariable("isEmpty", value.IVOR_BOOL, vectorRef.IsEmpty, true, false, ast.Pos{})
 
 	// make count a property
-	vectorScope.AddVariable("count", value.IVOR_INT, vectorRef.SizeValue, true, false, nil)
+	vectorScope.AddVariable("count", value.IVOR_INT, vectorRef.SizeValue, true, false, ast.Pos{})
 
 	vectorRef.ObjectValue = vectorInternalObject
 }
@@ -1,6 +1,6 @@
 package repl
 
-import "github.com/antlr4-go/antlr/v4"
+import "main.go/ast"
 
 const (
 	ExternNameParam = iota
@@ -13,7 +13,7 @@ type Param struct {
 	InnerName       string
 	Type            string
 	PassByReference bool
-	Token           antlr.Token
+	Pos             ast.Pos
 }
 
 // 3 types of paramantlr
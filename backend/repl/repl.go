@@ -1,5 +1,7 @@
 package repl
 
+import "log/slog"
+
 // ReplContext es una estructura que contiene el contexto del REPL (Read-Eval-Print Loop).
 type ReplContext struct {
 	// The console is the output of the REPL
@@ -10,4 +12,6 @@ type ReplContext struct {
 	CallStack *CallStack
 	// Error table is the table of errors
 	ErrorTable *ErrorTable
+	// Log es el logger del subsistema repl
+	Log *slog.Logger
 }
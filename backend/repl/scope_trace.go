@@ -1,10 +1,9 @@
 package repl
 
 import (
-	"log"
 	"strings"
 
-	"github.com/antlr4-go/antlr/v4"
+	"main.go/ast"
 	"main.go/value"
 )
 
@@ -64,7 +63,7 @@ func (s *BaseScopeTrace) variableExists(variable *Variable) bool {
 	return false
 }
 
-func (s *BaseScopeTrace) AddVariable(name string, varType string, value value.IVOR, isConst bool, allowNil bool, token antlr.Token) (*Variable, string) {
+func (s *BaseScopeTrace) AddVariable(name string, varType string, value value.IVOR, isConst bool, allowNil bool, pos ast.Pos) (*Variable, string) {
 	// Crea una nueva variable con los parámetros dados
 	variable := &Variable{
 		Name:     name,
@@ -72,7 +71,7 @@ func (s *BaseScopeTrace) AddVariable(name string, varType string, value value.IV
 		Value:    value,
 		IsConst:  isConst,
 		AllowNil: allowNil,
-		Token:    token,
+		Pos:      pos,
 	}
 
 	// Verifica si la variable ya existe en el ámbito
@@ -137,11 +136,6 @@ func (s *BaseScopeTrace) searchObjectVariable(name string, lastObj value.IVOR) *
 	// split name by dot
 	parts := strings.Split(name, ".")
 
-	if len(parts) == 0 {
-		log.Fatal("idk what u did, cant split by dot")
-		return nil
-	}
-
 	if len(parts) == 1 {
 		obj, ok := lastObj.(*ObjectValue)
 
@@ -149,7 +143,7 @@ func (s *BaseScopeTrace) searchObjectVariable(name string, lastObj value.IVOR) *
 			return obj.InternalScope.GetVariable(name)
 		}
 
-		log.Fatal("idk what u did, cant convert to object")
+		// el valor anterior no tiene propiedades
 		return nil
 	}
 
@@ -178,15 +172,16 @@ func (s *BaseScopeTrace) searchObjectVariable(name string, lastObj value.IVOR) *
 	}
 
 	obj, ok := lastObj.(*ObjectValue)
+	if !ok {
+		return nil
+	}
 
-	if ok {
-		lastObj = obj.InternalScope.GetVariable(parts[0]).Value
-
-		return s.searchObjectVariable(strings.Join(parts[1:], "."), lastObj)
-	} else {
-		log.Fatal("idk what u did, cant convert to object")
+	property := obj.InternalScope.GetVariable(parts[0])
+	if property == nil {
 		return nil
 	}
+
+	return s.searchObjectVariable(strings.Join(parts[1:], "."), property.Value)
 }
 
 func (s *BaseScopeTrace) AddFunction(name string, function value.IVOR) (bool, string) {
@@ -229,11 +224,6 @@ func (s *BaseScopeTrace) searchObjectFunction(name string, lastObj value.IVOR) (
 	// split name by dot
 	parts := strings.Split(name, ".")
 
-	if len(parts) == 0 {
-		log.Fatal("idk what u did, cant split by dot")
-		return nil, ""
-	}
-
 	if len(parts) == 1 {
 		obj, ok := lastObj.(*ObjectValue)
 
@@ -241,8 +231,7 @@ func (s *BaseScopeTrace) searchObjectFunction(name string, lastObj value.IVOR) (
 			return obj.InternalScope.GetFunction(name)
 		}
 
-		log.Fatal("idk what u did, cant convert to object")
-		return nil, ""
+		return nil, "El valor de tipo " + lastObj.Type() + " no tiene funciones"
 	}
 
 	// then parts should be 2 or more
@@ -271,15 +260,16 @@ func (s *BaseScopeTrace) searchObjectFunction(name string, lastObj value.IVOR) (
 	}
 
 	obj, ok := lastObj.(*ObjectValue)
+	if !ok {
+		return nil, "El valor de tipo " + lastObj.Type() + " no tiene propiedades"
+	}
 
-	if ok {
-		lastObj = obj.InternalScope.GetVariable(parts[0]).Value
-
-		return s.searchObjectFunction(strings.Join(parts[1:], "."), lastObj)
-	} else {
-		log.Fatal("idk what u did, cant convert to object")
-		return nil, ""
+	property := obj.InternalScope.GetVariable(parts[0])
+	if property == nil {
+		return nil, "No se puede acceder a la propiedad " + parts[0]
 	}
+
+	return s.searchObjectFunction(strings.Join(parts[1:], "."), property.Value)
 }
 
 /*
@@ -371,6 +361,12 @@ func (s *BaseScopeTrace) GetStruct(name string) (*Struct, string) {
 	return nil, "La estructura " + name + " no existe"
 }
 
+// Variables devuelve las variables declaradas directamente en este ámbito,
+// ordenadas por nombre.
+func (s *BaseScopeTrace) Variables() []TraceValue {
+	return scopeVariables(s, s.parent)
+}
+
 // Reset reinicializa el ámbito actual, eliminando todas las variables y funciones definidas en él.
 func (s *BaseScopeTrace) Reset() {
 	s.variables = make(map[string]*Variable)
@@ -462,8 +458,8 @@ func (s *ScopeTrace) Reset() {
 }
 
 // AddVariable agrega una nueva variable al ámbito local actual de la traza de ejecución del REPL.
-func (s *ScopeTrace) AddVariable(name string, varType string, value value.IVOR, isConst bool, allowNil bool, token antlr.Token) (*Variable, string) {
-	return s.CurrentScope.AddVariable(name, varType, value, isConst, allowNil, token)
+func (s *ScopeTrace) AddVariable(name string, varType string, value value.IVOR, isConst bool, allowNil bool, pos ast.Pos) (*Variable, string) {
+	return s.CurrentScope.AddVariable(name, varType, value, isConst, allowNil, pos)
 }
 
 // GetVariable busca una variable por su nombre en el ámbito local actual de la traza de ejecución del REPL.
@@ -562,20 +558,11 @@ func (s *BaseScopeTrace) Report() ReportScope {
 	}
 
 	for _, v := range s.variables {
-		token := v.Token
-		line := 0
-		column := 0
-
-		if token != nil {
-			line = token.GetLine()
-			column = token.GetColumn()
-		}
-
 		reportScope.Vars = append(reportScope.Vars, ReportSymbol{
 			Name:   v.Name,
 			Type:   v.Type,
-			Line:   line,
-			Column: column,
+			Line:   v.Pos.Line,
+			Column: v.Pos.Column,
 		})
 	}
 
@@ -589,24 +576,12 @@ func (s *BaseScopeTrace) Report() ReportScope {
 				Column: 0,
 			})
 		case *Function:
-			line := 0
-			column := 0
-
-			if function.Token != nil {
-				line = function.Token.GetLine()
-				column = function.Token.GetColumn()
-			}
-
 			reportScope.Funcs = append(reportScope.Funcs, ReportSymbol{
 				Name:   function.Name,
 				Type:   function.ReturnType,
-				Line:   line,
-				Column: column,
+				Line:   function.Pos.Line,
+				Column: function.Pos.Column,
 			})
-		case *ObjectBuiltInFunction:
-			break
-		default:
-			log.Fatal("Function type not found")
 		}
 	}
 
@@ -614,8 +589,8 @@ func (s *BaseScopeTrace) Report() ReportScope {
 		reportScope.Structs = append(reportScope.Structs, ReportSymbol{
 			Name:   v.Name,
 			Type:   v.Name,
-			Line:   v.Token.GetLine(),
-			Column: v.Token.GetColumn(),
+			Line:   v.Pos.Line,
+			Column: v.Pos.Column,
 		})
 	}
 
@@ -6,13 +6,13 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/antlr4-go/antlr/v4"
+	"main.go/ast"
 	"main.go/value"
 )
 
 // InterpolateString procesa una cadena con interpolación de variables
 // Busca patrones como $variable y los reemplaza con sus valores
-func (v *ReplVisitor) InterpolateString(input string, token antlr.Token) string {
+func (v *ReplVisitor) InterpolateString(input string, pos ast.Pos) string {
 	// Regex para encontrar patrones $variable o ${variable}
 	// Soporta tanto $n como ${variable_name}
 	patterns := []*regexp.Regexp{
@@ -37,7 +37,7 @@ func (v *ReplVisitor) InterpolateString(input string, token antlr.Token) string
 			// Buscar la variable en el scope
 			variable := v.ScopeTrace.GetVariable(varName)
 			if variable == nil {
-				v.ErrorTable.NewSemanticError(token, fmt.Sprintf("Variable '%s' no encontrada en interpolación de string", varName))
+				v.ErrorTable.NewSemanticError(pos, fmt.Sprintf("Variable '%s' no encontrada en interpolación de string", varName))
 				return match // Retornar el patrón original si hay error
 			}
 
@@ -51,6 +51,13 @@ func (v *ReplVisitor) InterpolateString(input string, token antlr.Token) string
 
 // ValueToString convierte un valor IVOR a su representación de string
 func (v *ReplVisitor) ValueToString(val value.IVOR) string {
+	return ValueToString(val)
+}
+
+// ValueToString convierte un valor IVOR a su representación de string dentro
+// de una interpolación. No depende del estado del visitor, por lo que también
+// la usa la máquina virtual.
+func ValueToString(val value.IVOR) string {
 	if val == nil {
 		return "nil"
 	}
@@ -71,10 +78,10 @@ func (v *ReplVisitor) ValueToString(val value.IVOR) string {
 	default:
 		// Para vectores, matrices u otros tipos complejos
 		if IsVectorType(val.Type()) {
-			return v.formatVectorForInterpolation(val.(*VectorValue))
+			return formatVectorForInterpolation(val.(*VectorValue))
 		}
 		if IsMatrixType(val.Type()) {
-			return v.formatMatrixForInterpolation(val.(*MatrixValue))
+			return formatMatrixForInterpolation(val.(*MatrixValue))
 		}
 		// Para otros tipos, usar el tipo como representación
 		return fmt.Sprintf("[%s]", val.Type())
@@ -82,21 +89,21 @@ func (v *ReplVisitor) ValueToString(val value.IVOR) string {
 }
 
 // formatVectorForInterpolation formatea un vector para interpolación
-func (v *ReplVisitor) formatVectorForInterpolation(vector *VectorValue) string {
+func formatVectorForInterpolation(vector *VectorValue) string {
 	if len(vector.InternalValue) == 0 {
 		return "[]"
 	}
 
 	var elements []string
 	for _, item := range vector.InternalValue {
-		elements = append(elements, v.ValueToString(item))
+		elements = append(elements, ValueToString(item))
 	}
 
 	return "[" + strings.Join(elements, ", ") + "]"
 }
 
 // formatMatrixForInterpolation formatea una matriz para interpolación
-func (v *ReplVisitor) formatMatrixForInterpolation(matrix *MatrixValue) string {
+func formatMatrixForInterpolation(matrix *MatrixValue) string {
 	if len(matrix.Items) == 0 {
 		return "[[]]"
 	}
@@ -105,7 +112,7 @@ func (v *ReplVisitor) formatMatrixForInterpolation(matrix *MatrixValue) string {
 	for _, row := range matrix.Items {
 		var elements []string
 		for _, item := range row {
-			elements = append(elements, v.ValueToString(item))
+			elements = append(elements, ValueToString(item))
 		}
 		rows = append(rows, "["+strings.Join(elements, ", ")+"]")
 	}
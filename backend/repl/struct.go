@@ -1,12 +1,11 @@
 package repl
 
 import (
-	"github.com/antlr4-go/antlr/v4"
-	compiler "main.go/grammar"
+	"main.go/ast"
 )
 
 type Struct struct {
 	Name   string
-	Fields []compiler.IStruct_propContext
-	Token  antlr.Token
+	Fields []*ast.Field
+	Pos    ast.Pos
 }
@@ -0,0 +1,187 @@
+package repl
+
+import (
+	"main.go/ast"
+	"main.go/value"
+)
+
+// TraceKind identifica el tipo de evento de la traza de ejecución.
+type TraceKind string
+
+const (
+	TraceStatement TraceKind = "statement"
+	TraceCall      TraceKind = "call"
+	TraceReturn    TraceKind = "return"
+	TraceAssign    TraceKind = "assign"
+	TraceScopePush TraceKind = "scopePush"
+	TraceScopePop  TraceKind = "scopePop"
+)
+
+// TraceValue es una copia de un valor en el momento del evento, ya
+// convertida a texto para que no cambie si el programa modifica el valor
+// después.
+type TraceValue struct {
+	Name  string `json:"name,omitempty"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// TraceEvent es un evento de la ejecución.
+//   - statement: Locals tiene las variables visibles antes de ejecutarlo.
+//   - call: Name es la función y Args los argumentos recibidos.
+//   - return: Name es la función y Value el valor retornado.
+//   - assign: Name es la variable y Value su nuevo valor.
+//   - scopePush/scopePop: Scope es el ámbito que se abre o se cierra.
+type TraceEvent struct {
+	Kind   TraceKind    `json:"kind"`
+	Line   int          `json:"line"`
+	Column int          `json:"column"`
+	Scope  string       `json:"scope"`
+	Name   string       `json:"name,omitempty"`
+	Value  *TraceValue  `json:"value,omitempty"`
+	Args   []TraceValue `json:"args,omitempty"`
+	Locals []TraceValue `json:"locals,omitempty"`
+}
+
+// Tracer recibe los eventos de la ejecución del ReplVisitor. Con Tracer en
+// nil el visitor no construye ningún evento.
+type Tracer interface {
+	OnStatement(event TraceEvent)
+	OnCall(event TraceEvent)
+	OnReturn(event TraceEvent)
+	OnAssign(event TraceEvent)
+	OnScopePush(event TraceEvent)
+	OnScopePop(event TraceEvent)
+}
+
+// TraceRecorder es un Tracer que guarda los eventos en orden. Deja de
+// guardar al llegar a Limit para que un ciclo largo no agote la memoria.
+type TraceRecorder struct {
+	Events    []TraceEvent
+	Limit     int
+	Truncated bool
+}
+
+// DefaultTraceLimit es la cantidad de eventos que guarda un TraceRecorder
+// creado con límite 0.
+const DefaultTraceLimit = 10000
+
+func NewTraceRecorder(limit int) *TraceRecorder {
+	if limit <= 0 {
+		limit = DefaultTraceLimit
+	}
+	return &TraceRecorder{
+		Events: make([]TraceEvent, 0),
+		Limit:  limit,
+	}
+}
+
+func (r *TraceRecorder) record(event TraceEvent) {
+	if len(r.Events) >= r.Limit {
+		r.Truncated = true
+		return
+	}
+	r.Events = append(r.Events, event)
+}
+
+func (r *TraceRecorder) OnStatement(event TraceEvent) { r.record(event) }
+func (r *TraceRecorder) OnCall(event TraceEvent)      { r.record(event) }
+func (r *TraceRecorder) OnReturn(event TraceEvent)    { r.record(event) }
+func (r *TraceRecorder) OnAssign(event TraceEvent)    { r.record(event) }
+func (r *TraceRecorder) OnScopePush(event TraceEvent) { r.record(event) }
+func (r *TraceRecorder) OnScopePop(event TraceEvent)  { r.record(event) }
+
+// === EMISIÓN DESDE EL VISITOR ===
+
+func snapshot(name string, val value.IVOR) TraceValue {
+	if val == nil {
+		val = value.DefaultNilValue
+	}
+	return TraceValue{Name: name, Type: val.Type(), Value: ValueToString(val)}
+}
+
+func (v *ReplVisitor) newTraceEvent(kind TraceKind, pos ast.Pos) TraceEvent {
+	return TraceEvent{Kind: kind, Line: pos.Line, Column: pos.Column, Scope: v.ScopeTrace.CurrentScope.Name()}
+}
+
+// visibleVariables devuelve las variables visibles desde el scope actual,
+// ordenadas por nombre; las de scopes internos ocultan a las externas.
+func (v *ReplVisitor) visibleVariables() []TraceValue {
+	return scopeVariables(v.ScopeTrace.CurrentScope, nil)
+}
+
+func (v *ReplVisitor) traceStatement(pos ast.Pos) {
+	if v.Tracer == nil {
+		return
+	}
+	event := v.newTraceEvent(TraceStatement, pos)
+	event.Locals = v.visibleVariables()
+	v.Tracer.OnStatement(event)
+}
+
+func (v *ReplVisitor) traceCall(pos ast.Pos, name string, args []*Argument) {
+	if v.Tracer == nil {
+		return
+	}
+	event := v.newTraceEvent(TraceCall, pos)
+	event.Name = name
+	for _, arg := range args {
+		event.Args = append(event.Args, snapshot(arg.Name, arg.Value))
+	}
+	v.Tracer.OnCall(event)
+}
+
+func (v *ReplVisitor) traceReturn(pos ast.Pos, name string, result value.IVOR) {
+	if v.Tracer == nil {
+		return
+	}
+	event := v.newTraceEvent(TraceReturn, pos)
+	event.Name = name
+	returned := snapshot("", result)
+	event.Value = &returned
+	v.Tracer.OnReturn(event)
+}
+
+func (v *ReplVisitor) traceAssign(pos ast.Pos, name string, val value.IVOR) {
+	if v.Tracer == nil {
+		return
+	}
+	event := v.newTraceEvent(TraceAssign, pos)
+	event.Name = name
+	assigned := snapshot("", val)
+	event.Value = &assigned
+	v.Tracer.OnAssign(event)
+}
+
+// traceItemAssign registra la asignación a una posición de un vector o una
+// matriz; el nombre es el acceso completo, por ejemplo v[0].
+func (v *ReplVisitor) traceItemAssign(stmt *ast.AssignStmt, val value.IVOR) {
+	if v.Tracer == nil {
+		return
+	}
+	v.traceAssign(stmt.Span.Start, ast.Text(stmt.Target), val)
+}
+
+// pushScope abre un scope y avisa al Tracer. Todos los scopes que abre el
+// visitor pasan por aquí.
+func (v *ReplVisitor) pushScope(name string, pos ast.Pos) *BaseScopeTrace {
+	scope := v.ScopeTrace.PushScope(name)
+	if v.Tracer != nil {
+		v.Tracer.OnScopePush(v.newTraceEvent(TraceScopePush, pos))
+	}
+	return scope
+}
+
+// popScope avisa al Tracer y cierra el scope actual.
+func (v *ReplVisitor) popScope(pos ast.Pos) {
+	if v.Tracer != nil {
+		v.Tracer.OnScopePop(v.newTraceEvent(TraceScopePop, pos))
+	}
+	v.ScopeTrace.PopScope()
+}
+
+// closing devuelve la posición del último carácter de span, la "}" o ")"
+// con la que se cierra la construcción.
+func closing(span ast.Span) ast.Pos {
+	return ast.Pos{Line: span.End.Line, Column: span.End.Column - 1, Offset: span.End.Offset - 1}
+}
@@ -0,0 +1,123 @@
+package repl_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/ast"
+	interpeter "main.go/grammar"
+	"main.go/repl"
+)
+
+// trace ejecuta code con un TraceRecorder de a lo sumo limit eventos.
+func trace(t *testing.T, code string, limit int) *repl.TraceRecorder {
+	t.Helper()
+	lexer := interpeter.NewVLangLexer(antlr.NewInputStream(code))
+	parser := interpeter.NewVLangGrammar(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	program := ast.Lower(parser.Program())
+
+	dclVisitor := repl.NewDclVisitor(repl.NewErrorTable())
+	dclVisitor.VisitProgram(program)
+	visitor := repl.NewVisitor(dclVisitor)
+	recorder := repl.NewTraceRecorder(limit)
+	visitor.Tracer = recorder
+	visitor.VisitProgram(program)
+
+	for _, err := range visitor.ErrorTable.Errors {
+		t.Errorf("%d:%d: %s", err.Line, err.Column, err.Msg)
+	}
+	return recorder
+}
+
+// describe resume un evento en una línea: tipo, posición, scope y los datos
+// que correspondan a su tipo.
+func describe(event repl.TraceEvent) string {
+	s := fmt.Sprintf("%s %d:%d %s", event.Kind, event.Line, event.Column, event.Scope)
+	if event.Name != "" {
+		s += " " + event.Name
+	}
+	if event.Value != nil {
+		s += fmt.Sprintf(" = %s %s", event.Value.Type, event.Value.Value)
+	}
+	for _, arg := range event.Args {
+		s += fmt.Sprintf(" (%s %s)", arg.Type, arg.Value)
+	}
+	for _, local := range event.Locals {
+		s += fmt.Sprintf(" [%s=%s]", local.Name, local.Value)
+	}
+	return s
+}
+
+// Los eventos llegan en el orden en que se ejecuta el programa: la llamada
+// abre su scope antes del cuerpo y lo cierra antes del retorno, y cada
+// sentencia ve las variables visibles antes de ejecutarse.
+func TestTraceOrder(t *testing.T) {
+	recorder := trace(t, "fn doble(n int) int {\n    return n * 2\n}\nmut x = 1\nx = doble(x)\nif x > 1 {\n    mut y = x\n}\n", 0)
+	want := []string{
+		"statement 1:0 global",
+		"statement 4:0 global",
+		"assign 4:0 global x = int 1",
+		"statement 5:0 global [x=1]",
+		"call 5:4 global doble (int 1)",
+		"scopePush 5:4 func: doble",
+		"statement 2:4 func: doble [n=1] [x=1]",
+		"scopePop 5:4 func: doble",
+		"return 5:11 global doble = int 2",
+		"assign 5:0 global x = int 2",
+		"statement 6:0 global [x=2]",
+		"scopePush 6:0 if",
+		"statement 7:4 if [x=2]",
+		"assign 7:4 if y = int 2",
+		"scopePop 8:0 if",
+	}
+
+	if len(recorder.Events) != len(want) {
+		for _, event := range recorder.Events {
+			t.Log(describe(event))
+		}
+		t.Fatalf("%d eventos, se esperaban %d", len(recorder.Events), len(want))
+	}
+	for i, event := range recorder.Events {
+		if got := describe(event); got != want[i] {
+			t.Errorf("evento %d: %q, se esperaba %q", i, got, want[i])
+		}
+	}
+	if recorder.Truncated {
+		t.Error("la traza no debería estar truncada")
+	}
+}
+
+// Al llegar al límite el recorder deja de guardar y marca la traza como
+// truncada, pero el programa termina de ejecutarse.
+func TestTraceLimit(t *testing.T) {
+	recorder := trace(t, "mut i = 0\nfor i < 100 {\n    i += 1\n}\n", 5)
+	if len(recorder.Events) != 5 || !recorder.Truncated {
+		t.Errorf("%d eventos, truncada %v; se esperaban 5 y truncada", len(recorder.Events), recorder.Truncated)
+	}
+	if recorder.Events[0].Kind != repl.TraceStatement {
+		t.Errorf("el primer evento debería ser la primera sentencia: %s", describe(recorder.Events[0]))
+	}
+}
+
+// Cada scopePush tiene su scopePop aunque break, continue o return
+// interrumpan el if, el else o el bloque que lo abrió.
+func TestTraceScopesBalance(t *testing.T) {
+	recorder := trace(t, "fn f(x int) int {\n    if x > 0 {\n        return 1\n    }\n    return 0\n}\nmut i = 0\nfor i < 3 {\n    i += 1\n    if i == 2 {\n        break\n    } else {\n        {\n            continue\n        }\n    }\n}\nmut r = f(1)\n", 0)
+	depth := 0
+	for _, event := range recorder.Events {
+		switch event.Kind {
+		case repl.TraceScopePush:
+			depth++
+		case repl.TraceScopePop:
+			depth--
+		}
+		if depth < 0 {
+			t.Fatalf("scopePop sin scopePush: %s", describe(event))
+		}
+	}
+	if depth != 0 {
+		t.Errorf("quedaron %d scopes sin cerrar", depth)
+	}
+}
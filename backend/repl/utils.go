@@ -19,22 +19,13 @@ func StringToVector(s *value.StringValue) *VectorValue {
 
 }
 
-func IsVectorType(_type string) bool {
-
-	// Vector starts with only one [ and ends with only one ]
-	// el verctorPattern ahora valida la espresion []tipo
-	vectorPattern := "^\\[\\](int|float|bool|string)"
-
-	// Matrix starts with AT LEAST two [[ and ends with at least two ]]
-	//matrixPattern := "^\\[\\[.*\\]\\](\\[.*\\]\\])*$"
+// Vector starts with only one [ and ends with only one ]
+// el vectorPattern valida la expresion []tipo; se compila una sola vez
+// porque IsVectorType se usa en cada asignación y declaración
+var vectorPattern = regexp.MustCompile("^\\[\\](int|float|bool|string)")
 
-	// match vector pattern but not matrix pattern
-
-	match, _ := regexp.MatchString(vectorPattern, _type)
-	//match2, _ := regexp.MatchString(matrixPattern, _type)
-
-	//return match && !match2
-	return match
+func IsVectorType(_type string) bool {
+	return vectorPattern.MatchString(_type)
 }
 
 func RemoveBrackets(s string) string {
@@ -1,19 +1,19 @@
 package repl
 
 import (
-	"github.com/antlr4-go/antlr/v4"
+	"main.go/ast"
 	"main.go/value"
 )
 
 // Variable representa una variable en el entorno REPL.
 type Variable struct {
-	Name     string      // Nombre de la variable
-	Value    value.IVOR  // Valor de la variable
-	Type     string      // Tipo de la variable
-	IsConst  bool        // Indica si la variable es constante
-	AllowNil bool        // Indica si la variable permite valores nulos
-	Token    antlr.Token // Token asociado a la variable
-	isProp   bool        // Indica si la variable es una propiedad
+	Name     string     // Nombre de la variable
+	Value    value.IVOR // Valor de la variable
+	Type     string     // Tipo de la variable
+	IsConst  bool       // Indica si la variable es constante
+	AllowNil bool       // Indica si la variable permite valores nulos
+	Pos      ast.Pos    // Token asociado a la variable
+	isProp   bool       // Indica si la variable es una propiedad
 }
 
 func (v *Variable) TypeValidation() (bool, string) {
@@ -1,26 +1,35 @@
 package repl
 
 import (
-	"fmt"
-	"log"
+	"log/slog"
 	"strconv"
 	"strings"
 
-	"github.com/antlr4-go/antlr/v4"
-	compiler "main.go/grammar"
+	"main.go/ast"
+	"main.go/logging"
 	"main.go/value"
 )
 
 /*
 ReplVisitor es una estructura que implementa el visitor para el REPL (Read-Eval-Print Loop).
+Recorre el AST tipado que genera ast.Lower.
 */
 type ReplVisitor struct {
-	compiler.BaseVLangGrammarVisitor
 	ScopeTrace  *ScopeTrace
 	CallStack   *CallStack
 	Console     *Console
 	ErrorTable  *ErrorTable
 	StructNames []string
+	Log         *slog.Logger
+	Tracer      Tracer // recibe los eventos de ejecución; nil desactiva la traza
+	Limits      Limits // acota pasos y profundidad de llamadas
+
+	// StopOnAssertion abandona la ejecución con un *AssertionStop después
+	// de la primera aserción fallida, como necesita el runner de pruebas
+	StopOnAssertion bool
+
+	steps     int
+	callDepth int
 }
 
 func NewVisitor(dclVisitor *DclVisitor) *ReplVisitor {
@@ -30,6 +39,8 @@ func NewVisitor(dclVisitor *DclVisitor) *ReplVisitor {
 		StructNames: dclVisitor.StructNames,
 		CallStack:   NewCallStack(),
 		Console:     NewConsole(),
+		Log:         dclVisitor.Log,
+		Limits:      Limits{MaxCallDepth: DefaultMaxCallDepth},
 	}
 }
 
@@ -39,6 +50,7 @@ func (v *ReplVisitor) GetReplContext() *ReplContext {
 		ScopeTrace: v.ScopeTrace,
 		CallStack:  v.CallStack,
 		ErrorTable: v.ErrorTable,
+		Log:        v.Log,
 	}
 }
 
@@ -46,61 +58,145 @@ func (v *ReplVisitor) ValidType(_type string) bool {
 	return v.ScopeTrace.GlobalScope.ValidType(_type)
 }
 
-func (v *ReplVisitor) Visit(tree antlr.ParseTree) interface{} {
-	fmt.Printf("-------------------------------------------\n")
-	fmt.Printf("🔹 ReplVisitor.Visit llamado con: %T\n", tree)
+// Visit ejecuta un programa, una sentencia o una expresión. Las
+// expresiones devuelven su valor; lo demás devuelve nil.
+func (v *ReplVisitor) Visit(node ast.Node) interface{} {
+	v.Log.Debug("visit", "node", logging.NodeType(node))
 
-	switch val := tree.(type) {
-	case *antlr.ErrorNodeImpl:
-		fmt.Printf("❌ ERROR NODE en ReplVisitor: %s\n", val.GetText())
-		log.Fatal(val.GetText())
-		return nil
-	case *compiler.FuncCallExprContext:
-		return v.VisitFuncCall(val.Func_call().(*compiler.FuncCallContext))
+	switch node := node.(type) {
+	case *ast.Program:
+		v.VisitProgram(node)
+	case ast.Stmt:
+		v.VisitStmt(node)
+	case ast.Expr:
+		return v.VisitExpr(node)
+	}
+	return nil
+}
+
+func (v *ReplVisitor) VisitProgram(program *ast.Program) {
+	v.Log.Debug("programa", "stmts", len(program.Stmts))
+	defer v.recoverLimit(v.ScopeTrace.CurrentScope, len(v.CallStack.Items))
+
+	for i, stmt := range program.Stmts {
+		v.Log.Debug("statement", "index", i, "line", stmt.GetSpan().Start.Line, "node", logging.NodeType(stmt))
+		v.VisitStmt(stmt)
+	}
+}
+
+func (v *ReplVisitor) VisitStmt(stmt ast.Stmt) {
+	start := stmt.GetSpan().Start
+	v.step(start)
+	v.traceStatement(start)
+
+	switch stmt := stmt.(type) {
+	case *ast.VarDecl:
+		v.VisitVarDecl(stmt)
+	case *ast.AssignStmt:
+		v.VisitAssignStmt(stmt)
+	case *ast.BlockStmt:
+		v.VisitBlockStmt(stmt)
+	case *ast.ReturnStmt:
+		v.VisitReturnStmt(stmt)
+	case *ast.BreakStmt:
+		v.VisitBreakStmt(stmt)
+	case *ast.ContinueStmt:
+		v.VisitContinueStmt(stmt)
+	case *ast.ExprStmt:
+		// los métodos de un elemento de vector (v[0].append(4)) no se ejecutan
+		if call, ok := stmt.X.(*ast.CallExpr); ok && isPath(call.Fun) {
+			v.VisitCallExpr(call)
+		} else {
+			v.unsupportedStmt(stmt)
+		}
+	case *ast.FuncDecl:
+		v.VisitFuncDecl(stmt)
+	case *ast.IfStmt:
+		v.VisitIfStmt(stmt)
+	case *ast.SwitchStmt:
+		v.VisitSwitchStmt(stmt)
+	case *ast.ForCondStmt:
+		v.VisitForCondStmt(stmt)
+	case *ast.ForClauseStmt:
+		v.VisitForClauseStmt(stmt)
+	case *ast.ForRange:
+		v.VisitForRange(stmt)
+	case *ast.StructDecl:
+		v.VisitStructDecl(stmt)
 	default:
-		fmt.Printf("🔹 ReplVisitor aceptando tree\n")
-		return tree.Accept(v)
+		v.unsupportedStmt(stmt)
 	}
 }
 
-func (v *ReplVisitor) VisitProgram(ctx *compiler.ProgramContext) interface{} {
-	fmt.Printf("🎯 ¡ENTRANDO A ReplVisitor.VisitProgram!\n")
-	fmt.Printf("🔹 Número de statements: %d\n", len(ctx.AllStmt()))
+func (v *ReplVisitor) unsupportedStmt(stmt ast.Stmt) {
+	v.ErrorTable.NewSemanticError(stmt.GetSpan().Start, "Sentencia no soportada por el intérprete: "+ast.Text(stmt))
+}
 
-	for i, stmt := range ctx.AllStmt() {
-		fmt.Printf("🔹 Procesando statement %d: %s\n", i, stmt.GetText())
-		v.Visit(stmt)
-	}
-	return nil
+func (v *ReplVisitor) VisitExpr(expr ast.Expr) value.IVOR {
+	switch expr := expr.(type) {
+	case *ast.IntLit:
+		return &value.IntValue{InternalValue: expr.Value}
+	case *ast.FloatLit:
+		return &value.FloatValue{InternalValue: expr.Value}
+	case *ast.StringLit:
+		return v.VisitStringLit(expr)
+	case *ast.BoolLit:
+		return &value.BoolValue{InternalValue: expr.Value}
+	case *ast.NilLit:
+		return value.DefaultNilValue
+	case *ast.ParenExpr:
+		return v.VisitExpr(expr.X)
+	case *ast.Ident:
+		return v.VisitIdPattern(expr)
+	case *ast.SelectorExpr:
+		if !isPath(expr) {
+			return v.unsupportedExpr(expr)
+		}
+		return v.VisitIdPattern(expr)
+	case *ast.IndexExpr:
+		return v.VisitIndexExpr(expr)
+	case *ast.CallExpr:
+		if !isPath(expr.Fun) {
+			return v.unsupportedExpr(expr)
+		}
+		return v.VisitCallExpr(expr)
+	case *ast.IncDecExpr:
+		return v.VisitIncDec(expr)
+	case *ast.UnaryExpr:
+		return v.VisitUnaryExpr(expr)
+	case *ast.BinaryExpr:
+		return v.VisitBinaryExpr(expr)
+	case *ast.VectorLit:
+		return v.VisitVectorLit(expr)
+	case *ast.MatrixLit:
+		return v.VisitMatrixLit(expr)
+	case *ast.StructLit:
+		return v.VisitStructLit(expr)
+	}
+
+	// Las propiedades y métodos de un elemento de vector y la forma de
+	// repetición están en la gramática pero el intérprete no las evalúa; se
+	// reportan en lugar de devolver un valor nulo.
+	return v.unsupportedExpr(expr)
+}
+
+func (v *ReplVisitor) unsupportedExpr(expr ast.Expr) value.IVOR {
+	v.ErrorTable.NewSemanticError(expr.GetSpan().Start, "Expresión no soportada por el intérprete: "+ast.Text(expr))
+	return value.DefaultNilValue
 }
 
-func (v *ReplVisitor) VisitStmt(ctx *compiler.StmtContext) interface{} {
-
-	if ctx.Decl_stmt() != nil {
-		v.Visit(ctx.Decl_stmt())
-	} else if ctx.Assign_stmt() != nil {
-		v.Visit(ctx.Assign_stmt())
-	} else if ctx.Block_ind() != nil {
-		v.Visit(ctx.Block_ind())
-	} else if ctx.Transfer_stmt() != nil {
-		v.Visit(ctx.Transfer_stmt())
-	} else if ctx.Func_call() != nil {
-		v.Visit(ctx.Func_call())
-	} else if ctx.Func_dcl() != nil {
-		v.Visit(ctx.Func_dcl())
-	} else if ctx.If_stmt() != nil {
-		v.Visit(ctx.If_stmt())
-	} else if ctx.Switch_stmt() != nil {
-		v.Visit(ctx.Switch_stmt())
-	} else if ctx.For_stmt() != nil {
-		v.Visit(ctx.For_stmt())
-	} else if ctx.Strct_dcl() != nil {
-		v.Visit(ctx.Strct_dcl())
-	} else {
-		log.Fatal("Statement not recognized: ", ctx.GetText())
-	}
+// patternName devuelve el nombre a.b.c de un acceso que ya se sabe que parte
+// de un identificador.
+func patternName(expr ast.Expr) string {
+	name, _ := ast.PathName(expr)
+	return name
+}
 
-	return nil
+// isPath indica si expr es un identificador o un acceso a.b.c; los accesos
+// sobre un elemento de vector (v[0].count) no lo son.
+func isPath(expr ast.Expr) bool {
+	_, ok := ast.PathName(expr)
+	return ok
 }
 
 // En el enunciado no hay constantes, solo variables mut
@@ -110,44 +206,62 @@ func isDeclConst(lexval string) bool {
 }
 */
 
+func (v *ReplVisitor) VisitVarDecl(decl *ast.VarDecl) {
+	switch decl.Kind {
+	case ast.DeclMutTyped:
+		v.VisitMutVarDecl(decl)
+	case ast.DeclMutInferred:
+		v.VisitValueDecl(decl)
+	case ast.DeclMutZero:
+		v.VisitValDeclVec(decl)
+	case ast.DeclVector:
+		v.VisitVarVectDecl(decl)
+	case ast.DeclMatrix:
+		v.VisitVarMatrixDecl(decl)
+	case ast.DeclTyped:
+		// x int = 5 sin mut no la ejecuta el intérprete (ni la VM); queda
+		// documentada en testdata/golden/no_soportado.vch
+	}
+}
+
 // Ejemplo: Mut variable_1 int = 10
-// Ejemplo: Mut variable_2 int
-func (v *ReplVisitor) VisitMutVarDecl(ctx *compiler.MutVarDeclContext) interface{} {
+func (v *ReplVisitor) VisitMutVarDecl(decl *ast.VarDecl) {
 
 	// Si hubiera constantes se validan aquí
 	// isConst := isDeclConst(ctx.Var_type().GetText())
 	isConst := false
 
-	// Obtenemos el context de la declaración MutVarDecl
-	varName := ctx.ID().GetText()
-	varType := v.Visit(ctx.Type_()).(string)
-	varValue := v.Visit(ctx.Expression()).(value.IVOR)
+	varName := decl.Name.Name
+	varType := v.VisitType(decl.TypeRef)
+	varValue := v.VisitExpr(decl.Value)
 
 	// copy object
 	if obj, ok := varValue.(*ObjectValue); ok {
 		varValue = obj.Copy()
 	}
 
-	variable, msg := v.ScopeTrace.AddVariable(varName, varType, varValue, isConst, false, ctx.GetStart())
+	variable, msg := v.ScopeTrace.AddVariable(varName, varType, varValue, isConst, false, decl.Span.Start)
 
 	// Variable already exists
 	if variable == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
+		v.ErrorTable.NewSemanticError(decl.Span.Start, msg)
+		return
 	}
 
-	return nil
+	v.traceAssign(decl.Span.Start, variable.Name, variable.Value)
 }
 
-func (v *ReplVisitor) VisitValueDecl(ctx *compiler.ValueDeclContext) interface{} {
+// Ejemplo: mut variable_1 = 10
+func (v *ReplVisitor) VisitValueDecl(decl *ast.VarDecl) {
 
 	isConst := false
-	varName := ctx.ID().GetText()
-	varValue := v.Visit(ctx.Expression()).(value.IVOR)
+	varName := decl.Name.Name
+	varValue := v.VisitExpr(decl.Value)
 	varType := varValue.Type()
 
 	if varType == "[]" {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "No se puede inferir el tipo de un vector vacio '"+varName+"'")
-		return nil
+		v.ErrorTable.NewSemanticError(decl.Span.Start, "No se puede inferir el tipo de un vector vacio '"+varName+"'")
+		return
 	}
 
 	// copy object
@@ -155,65 +269,35 @@ func (v *ReplVisitor) VisitValueDecl(ctx *compiler.ValueDeclContext) interface{}
 		varValue = obj.Copy()
 	}
 
-	variable, msg := v.ScopeTrace.AddVariable(varName, varType, varValue, isConst, false, ctx.GetStart())
+	variable, msg := v.ScopeTrace.AddVariable(varName, varType, varValue, isConst, false, decl.Span.Start)
 
 	// Variable already exists
 	if variable == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
+		v.ErrorTable.NewSemanticError(decl.Span.Start, msg)
+		return
 	}
-	return nil
-}
-
-// Ejemplo: variable_1 int = 10
-func (v *ReplVisitor) VisitVarDecl(ctx *compiler.VarAssDeclContext) interface{} {
-
-	// Si hubiera constantes se validan aquí
-	// isConst := isDeclConst(ctx.Var_type().GetText())
-	isConst := false
 
-	// Obtenemos el context de la declaración VarAssDecñ
-	exprName := ctx.ID().GetText()
-	exprType := v.Visit(ctx.Type_()).(string)
-
-	exprValue := v.Visit(ctx.Expression()).(value.IVOR)
-
-	// Validar tipo de expresión
-	if obj, ok := exprValue.(*ObjectValue); ok {
-		exprValue = obj.Copy()
-	}
-
-	variable, msg := v.ScopeTrace.AddVariable(exprName, exprType, exprValue, isConst, false, ctx.GetStart())
-
-	// Si la variable ya existe, se lanza un error
-	if variable == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
-	}
-
-	return nil
+	v.traceAssign(decl.Span.Start, variable.Name, variable.Value)
 }
 
 // Declaracion de vectores
-// Ejemplo: vector_1 []int = [1, 2, 3]
-// cibtexto VarVectDecl
-func (v *ReplVisitor) VisitVarVectDecl(ctx *compiler.VarVectDeclContext) interface{} {
-	fmt.Printf("🔹 Visitando VarVectDecl (nueva gramática): %s\n", ctx.GetText())
+// Ejemplo: vector_1 = []int{1, 2, 3}
+func (v *ReplVisitor) VisitVarVectDecl(decl *ast.VarDecl) {
+	v.Log.Debug("VarVectDecl", "line", decl.Span.Start.Line)
 
 	// No hay constantes en este contexto
 	isConst := false
 
-	// Obtener información del contexto con la nueva gramática
-	varName := ctx.ID().GetText()                        // nombre de variable
-	vectorType := v.Visit(ctx.Vector_type()).(string)    // tipo del vector (ej: "[]int")
-	vectorValue := v.Visit(ctx.Vect_expr()).(value.IVOR) // expresión del vector (ej: {1,2,3})
+	varName := decl.Name.Name              // nombre de variable
+	vectorType := decl.TypeRef.Syntax()    // tipo del vector (ej: "[]int")
+	vectorValue := v.VisitExpr(decl.Value) // expresión del vector (ej: {1,2,3})
 
-	fmt.Printf("   Variable: '%s'\n", varName)
-	fmt.Printf("   Tipo Vector: '%s'\n", vectorType)
-	fmt.Printf("   Valor Vector: %v (tipo: %s)\n", vectorValue, vectorValue.Type())
+	v.Log.Debug("declaración de vector", "name", varName, "type", vectorType, "value", vectorValue, "valueType", vectorValue.Type())
 
 	// Validar que el tipo declarado sea un vector válido
 	if !IsVectorType(vectorType) {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "El tipo '"+vectorType+"' no es un tipo de vector válido")
-		return nil
+		v.ErrorTable.NewSemanticError(decl.Span.Start, "El tipo '"+vectorType+"' no es un tipo de vector válido")
+		return
 	}
 
 	// Validar que el valor sea compatible con el tipo declarado
@@ -224,12 +308,12 @@ func (v *ReplVisitor) VisitVarVectDecl(ctx *compiler.VarVectDeclContext) interfa
 			valueItemType := RemoveBrackets(vectorValue.Type())
 
 			if declaredItemType != valueItemType {
-				v.ErrorTable.NewSemanticError(ctx.GetStart(), "No se puede asignar un vector de tipo '"+vectorValue.Type()+"' a una variable de tipo '"+vectorType+"'")
-				return nil
+				v.ErrorTable.NewSemanticError(decl.Span.Start, "No se puede asignar un vector de tipo '"+vectorValue.Type()+"' a una variable de tipo '"+vectorType+"'")
+				return
 			}
 		} else {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "No se puede asignar un valor de tipo '"+vectorValue.Type()+"' a una variable de tipo '"+vectorType+"'")
-			return nil
+			v.ErrorTable.NewSemanticError(decl.Span.Start, "No se puede asignar un valor de tipo '"+vectorValue.Type()+"' a una variable de tipo '"+vectorType+"'")
+			return
 		}
 	}
 
@@ -239,36 +323,34 @@ func (v *ReplVisitor) VisitVarVectDecl(ctx *compiler.VarVectDeclContext) interfa
 	}
 
 	// Agregar variable al scope
-	variable, msg := v.ScopeTrace.AddVariable(varName, vectorType, vectorValue, isConst, false, ctx.GetStart())
+	variable, msg := v.ScopeTrace.AddVariable(varName, vectorType, vectorValue, isConst, false, decl.Span.Start)
 
 	if variable == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
-		return nil
+		v.ErrorTable.NewSemanticError(decl.Span.Start, msg)
+		return
 	}
 
-	fmt.Printf("✅ Variable vector '%s' declarada exitosamente con tipo '%s'\n", varName, vectorType)
-	return nil
+	v.traceAssign(decl.Span.Start, variable.Name, variable.Value)
+	v.Log.Debug("vector declarado", "name", varName, "type", vectorType)
 }
 
-// Contexto decl_stmt
-// visitor MutSliceDecl // mut slice []int
 // Ejemplo: mut slice []int
-func (v *ReplVisitor) VisitValDeclVec(ctx *compiler.ValDeclVecContext) interface{} {
-	fmt.Printf("🔹 Visitando ValDeclVec: %s\n", ctx.GetText())
+func (v *ReplVisitor) VisitValDeclVec(decl *ast.VarDecl) {
+	v.Log.Debug("ValDeclVec", "line", decl.Span.Start.Line)
 
 	// En este contexto no hay constantes, solo variables mut
 	isConst := false
 
 	// Obtener el nombre de la variable
-	varName := ctx.ID().GetText()
+	varName := decl.Name.Name
 
 	// Obtener el tipo del vector (ej: "[]int")
-	varType := v.Visit(ctx.Type_()).(string)
+	varType := v.VisitType(decl.TypeRef)
 
 	// Validar que sea un tipo de vector válido
 	if !IsVectorType(varType) {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "El tipo '"+varType+"' no es un tipo de vector válido")
-		return nil
+		v.ErrorTable.NewSemanticError(decl.Span.Start, "El tipo '"+varType+"' no es un tipo de vector válido")
+		return
 	}
 
 	// Extraer el tipo de los elementos del vector (ej: "int" de "[]int")
@@ -276,52 +358,45 @@ func (v *ReplVisitor) VisitValDeclVec(ctx *compiler.ValDeclVecContext) interface
 
 	// Validar que el tipo del elemento sea válido
 	if !v.ValidType(itemType) {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "El tipo de elemento '"+itemType+"' no es válido para un vector")
-		return nil
+		v.ErrorTable.NewSemanticError(decl.Span.Start, "El tipo de elemento '"+itemType+"' no es válido para un vector")
+		return
 	}
 
 	// Crear un vector vacío del tipo especificado
 	emptyVector := NewVectorValue([]value.IVOR{}, varType, itemType)
 
 	// Agregar la variable al scope actual
-	variable, msg := v.ScopeTrace.AddVariable(varName, varType, emptyVector, isConst, false, ctx.GetStart())
+	variable, msg := v.ScopeTrace.AddVariable(varName, varType, emptyVector, isConst, false, decl.Span.Start)
 
 	// Si la variable ya existe o hay otro error, reportarlo
 	if variable == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
-		return nil
+		v.ErrorTable.NewSemanticError(decl.Span.Start, msg)
+		return
 	}
 
-	fmt.Printf("✅ Vector '%s' de tipo '%s' declarado exitosamente\n", varName, varType)
-	return nil
+	v.traceAssign(decl.Span.Start, variable.Name, variable.Value)
+	v.Log.Debug("vector declarado", "name", varName, "type", varType)
 }
 
-// Contextos matrices
-
-// Contextos VectorItemLis
 // Ejemplo: {1, 2, 3}
-func (v *ReplVisitor) VisitVectorItemLis(ctx *compiler.VectorItemLisContext) interface{} {
-	fmt.Printf("🔹 Visitando VectorItemLis: %s\n", ctx.GetText())
+func (v *ReplVisitor) VisitVectorLit(vector *ast.VectorLit) value.IVOR {
+	v.Log.Debug("VectorLit", "items", len(vector.Elems))
 	var vectorItems []value.IVOR
 
-	if len(ctx.AllExpression()) == 0 {
+	if len(vector.Elems) == 0 {
 		return NewVectorValue(vectorItems, "[]", value.IVOR_ANY)
 	}
 
-	for _, item := range ctx.AllExpression() {
-		vectorItems = append(vectorItems, v.Visit(item).(value.IVOR))
+	for _, item := range vector.Elems {
+		vectorItems = append(vectorItems, v.VisitExpr(item))
 	}
 
-	var itemType = value.IVOR_NIL
-
-	if ctx.Expression(0) != nil {
-		itemType = vectorItems[0].Type()
+	itemType := vectorItems[0].Type()
 
-		for _, item := range vectorItems {
-			if item.Type() != itemType {
-				v.ErrorTable.NewSemanticError(ctx.GetStart(), "Todos los items de la coleccion deben ser del mismo tipo")
-				return value.DefaultNilValue
-			}
+	for _, item := range vectorItems {
+		if item.Type() != itemType {
+			v.ErrorTable.NewSemanticError(vector.Lbrace, "Todos los items de la coleccion deben ser del mismo tipo")
+			return value.DefaultNilValue
 		}
 	}
 
@@ -331,17 +406,14 @@ func (v *ReplVisitor) VisitVectorItemLis(ctx *compiler.VectorItemLisContext) int
 		return NewVectorValue(vectorItems, _type, itemType)
 	}
 
-	v.ErrorTable.NewSemanticError(ctx.GetStart(), "Tipo "+_type+" no encontrado")
+	v.ErrorTable.NewSemanticError(vector.Lbrace, "Tipo "+_type+" no encontrado")
 	return value.DefaultNilValue
 }
 
-// Faltan Vectores aca Vector Item - vect_expr
+// VisitType valida el tipo escrito en una declaración y devuelve su texto.
+func (v *ReplVisitor) VisitType(ref *ast.TypeRef) string {
 
-// VisitType es el visitor para el tipo de dato en la declaración de variables.
-func (v *ReplVisitor) VisitType(ctx *compiler.TypeContext) interface{} {
-
-	// remove white spaces
-	_type := ctx.GetText()
+	_type := ref.Syntax()
 
 	if v.ValidType(_type) {
 		return _type
@@ -354,55 +426,56 @@ func (v *ReplVisitor) VisitType(ctx *compiler.TypeContext) interface{} {
 			return _type
 		}
 
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "El tipo "+internType+" no es valido para un vector")
+		v.ErrorTable.NewSemanticError(ref.Span.Start, "El tipo "+internType+" no es valido para un vector")
 		return value.IVOR_NIL
 	}
 
-	/*
-
-
-		if IsMatrixType(_type) {
-			// remove [[]]
-			internType := RemoveBrackets(_type)
-			if value.IsPrimitiveType(internType) {
-				return _type
-			}
-
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "Las matrices solo pueden contener tipos primitivos")
-			return value.IVOR_NIL
-		}
-	*/
-
-	v.ErrorTable.NewSemanticError(ctx.GetStart(), "Tipo "+ctx.GetText()+" no encontrado")
+	v.ErrorTable.NewSemanticError(ref.Span.Start, "Tipo "+_type+" no encontrado")
 	return value.IVOR_NIL
 }
 
-func (v *ReplVisitor) VisitVector_type(ctx *compiler.Vector_typeContext) interface{} {
-	return ctx.GetText()
+// splitIndex separa v[i][j] en el acceso base y sus índices, en orden.
+func splitIndex(item *ast.IndexExpr) (ast.Expr, []ast.Expr) {
+	var indexes []ast.Expr
+	var base ast.Expr = item
+
+	for {
+		index, ok := base.(*ast.IndexExpr)
+		if !ok {
+			break
+		}
+		indexes = append([]ast.Expr{index.Index}, indexes...)
+		base = index.X
+	}
+	return base, indexes
 }
 
-func (v *ReplVisitor) VisitVectorItem(ctx *compiler.VectorItemContext) interface{} {
+// VisitVectorItem resuelve v[i] o m[i][j]. Devuelve una referencia al
+// elemento, una copia de la fila de una matriz, o nil si hubo un error.
+func (v *ReplVisitor) VisitVectorItem(item *ast.IndexExpr) interface{} {
 
-	varName := ctx.Id_pattern().GetText()
+	base, indexExprs := splitIndex(item)
+	varName := patternName(base)
+	start := item.Span.Start
 
 	variable := v.ScopeTrace.GetVariable(varName)
 	if variable == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "Variable "+varName+" no encontrada")
+		v.ErrorTable.NewSemanticError(start, "Variable "+varName+" no encontrada")
 		return nil
 	}
 
 	// Validar que la variable sea vector o matriz
 	if !(IsVectorType(variable.Type)) && !(IsMatrixType(variable.Type)) {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "La variable "+varName+" no es un vector o matriz")
+		v.ErrorTable.NewSemanticError(start, "La variable "+varName+" no es un vector o matriz")
 		return nil
 	}
 
 	// Obtener todos los índices
 	var indexes []int
-	for _, expr := range ctx.AllExpression() {
-		val := v.Visit(expr).(value.IVOR)
+	for _, expr := range indexExprs {
+		val := v.VisitExpr(expr)
 		if val.Type() != value.IVOR_INT {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "Los índices deben ser enteros")
+			v.ErrorTable.NewSemanticError(start, "Los índices deben ser enteros")
 			return nil
 		}
 		indexes = append(indexes, val.Value().(int))
@@ -416,7 +489,7 @@ func (v *ReplVisitor) VisitVectorItem(ctx *compiler.VectorItemContext) interface
 		if vectorValue, ok := variable.Value.(*VectorValue); ok {
 
 			if !vectorValue.ValidIndex(index) {
-				v.ErrorTable.NewSemanticError(ctx.GetStart(), "Índice "+strconv.Itoa(index)+" fuera de rango")
+				v.ErrorTable.NewSemanticError(start, "Índice "+strconv.Itoa(index)+" fuera de rango")
 				return nil
 			}
 			return &VectorItemReference{
@@ -429,7 +502,7 @@ func (v *ReplVisitor) VisitVectorItem(ctx *compiler.VectorItemContext) interface
 		// Si es matriz y se accede a la fila completa
 		if matrixValue, ok := variable.Value.(*MatrixValue); ok {
 			if index < 0 || index >= len(matrixValue.Items) {
-				v.ErrorTable.NewSemanticError(ctx.GetStart(), "Fila "+strconv.Itoa(index)+" fuera de rango")
+				v.ErrorTable.NewSemanticError(start, "Fila "+strconv.Itoa(index)+" fuera de rango")
 				return nil
 			}
 			filaOriginal := matrixValue.Items[index]
@@ -454,7 +527,7 @@ func (v *ReplVisitor) VisitVectorItem(ctx *compiler.VectorItemContext) interface
 
 		}
 
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "Acceso inválido con un solo índice a variable "+varName)
+		v.ErrorTable.NewSemanticError(start, "Acceso inválido con un solo índice a variable "+varName)
 		return nil
 	}
 	// Verificar si es acceso a matriz (2D)
@@ -462,15 +535,15 @@ func (v *ReplVisitor) VisitVectorItem(ctx *compiler.VectorItemContext) interface
 		i, j := indexes[0], indexes[1]
 		matrixValue, ok := variable.Value.(*MatrixValue)
 		if !ok {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "La variable "+varName+" no es una matriz")
+			v.ErrorTable.NewSemanticError(start, "La variable "+varName+" no es una matriz")
 			return nil
 		}
 		if i < 0 || i >= len(matrixValue.Items) {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "Fila "+strconv.Itoa(i)+" fuera de rango")
+			v.ErrorTable.NewSemanticError(start, "Fila "+strconv.Itoa(i)+" fuera de rango")
 			return nil
 		}
 		if j < 0 || j >= len(matrixValue.Items[i]) {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "Columna "+strconv.Itoa(j)+" fuera de rango")
+			v.ErrorTable.NewSemanticError(start, "Columna "+strconv.Itoa(j)+" fuera de rango")
 			return nil
 		}
 		return &MatrixItemReference{
@@ -480,98 +553,101 @@ func (v *ReplVisitor) VisitVectorItem(ctx *compiler.VectorItemContext) interface
 		}
 	}
 
-	v.ErrorTable.NewSemanticError(ctx.GetStart(), "Número de índices inválido")
+	v.ErrorTable.NewSemanticError(start, "Número de índices inválido")
 	return nil
 }
 
-// Falta el visit repeating
-// Falta todo de Vectores
-func (v *ReplVisitor) VisitAssignmentDecl(ctx *compiler.AssignmentDeclContext) interface{} {
-	fmt.Printf("🔹 Visitando AssignmentDecl: %s\n", ctx.GetText())
+func (v *ReplVisitor) VisitAssignStmt(stmt *ast.AssignStmt) {
+	if target, ok := stmt.Target.(*ast.IndexExpr); ok {
+		v.VisitVectorAssign(stmt, target)
+		return
+	}
+
+	if stmt.Op == "=" {
+		v.VisitAssignment(stmt)
+	} else {
+		v.VisitCompoundAssign(stmt)
+	}
+}
 
-	varName := v.Visit(ctx.Id_pattern()).(string)
-	varValue := v.Visit(ctx.Expression()).(value.IVOR)
+// Ejemplo: x = 5 o p.x = 5
+func (v *ReplVisitor) VisitAssignment(stmt *ast.AssignStmt) {
+	start := stmt.Span.Start
 
-	fmt.Printf("   Variable: '%s'\n", varName)
-	fmt.Printf("   Valor: %v (tipo: %s)\n", varValue, varValue.Type())
+	varName := patternName(stmt.Target)
+	varValue := v.VisitExpr(stmt.Value)
+
+	v.Log.Debug("asignación", "name", varName, "value", varValue, "valueType", varValue.Type())
 
 	// Buscar la variable en el scope
 	if strings.Contains(varName, ".") {
 		parts := strings.Split(varName, ".")
 		if len(parts) != 2 {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "Asignación con acceso encadenado inválido: '"+varName+"'")
-			return nil
+			v.ErrorTable.NewSemanticError(start, "Asignación con acceso encadenado inválido: '"+varName+"'")
+			return
 		}
 		baseName := parts[0]
 		fieldName := parts[1]
 
 		baseVar := v.ScopeTrace.GetVariable(baseName)
 		if baseVar == nil {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "Variable '"+baseName+"' no encontrada")
-			return nil
+			v.ErrorTable.NewSemanticError(start, "Variable '"+baseName+"' no encontrada")
+			return
 		}
 
 		structVal, ok := baseVar.Value.(*value.StructValue)
 		if !ok {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "Variable '"+baseName+"' no es un struct")
-			return nil
+			v.ErrorTable.NewSemanticError(start, "Variable '"+baseName+"' no es un struct")
+			return
 		}
 
 		// Verifica si el campo existe
 		if _, exists := structVal.Instance.Fields[fieldName]; !exists {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "El campo '"+fieldName+"' no existe en el struct '"+baseName+"'")
-			return nil
+			v.ErrorTable.NewSemanticError(start, "El campo '"+fieldName+"' no existe en el struct '"+baseName+"'")
+			return
 		}
 
 		// Asignación
 		structVal.Instance.Fields[fieldName] = varValue
-		fmt.Printf("✅ Campo '%s' del struct '%s' actualizado a: %v\n", fieldName, baseName, varValue)
-		return nil
+		v.traceAssign(start, varName, varValue)
+		v.Log.Debug("campo actualizado", "struct", baseName, "field", fieldName, "value", varValue)
+		return
 	}
 
 	// Buscar la variable en el scope
 	variable := v.ScopeTrace.GetVariable(varName)
 
 	if variable == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "Variable '"+varName+"' no encontrada")
-		return nil
+		v.ErrorTable.NewSemanticError(start, "Variable '"+varName+"' no encontrada")
+		return
 	}
 
-	fmt.Printf("   Variable encontrada con tipo: '%s'\n", variable.Type)
-
 	// Validaciones específicas para vectores
 	if IsVectorType(variable.Type) {
-		fmt.Printf("   Procesando asignación de vector\n")
-
 		// Si el valor es un vector, validar compatibilidad
 		if IsVectorType(varValue.Type()) {
 			varItemType := RemoveBrackets(variable.Type)
 			valueItemType := RemoveBrackets(varValue.Type())
 
-			fmt.Printf("   Tipo de elemento variable: '%s'\n", varItemType)
-			fmt.Printf("   Tipo de elemento valor: '%s'\n", valueItemType)
-
 			if varItemType != valueItemType {
-				v.ErrorTable.NewSemanticError(ctx.GetStart(), "No se puede asignar un vector de tipo '"+varValue.Type()+"' a una variable de tipo '"+variable.Type+"'")
-				return nil
+				v.ErrorTable.NewSemanticError(start, "No se puede asignar un vector de tipo '"+varValue.Type()+"' a una variable de tipo '"+variable.Type+"'")
+				return
 			}
 		} else if varValue.Type() != "[]" {
 			// El valor no es un vector ni un vector vacío
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "No se puede asignar un valor de tipo '"+varValue.Type()+"' a una variable vector de tipo '"+variable.Type+"'")
-			return nil
+			v.ErrorTable.NewSemanticError(start, "No se puede asignar un valor de tipo '"+varValue.Type()+"' a una variable vector de tipo '"+variable.Type+"'")
+			return
 		}
 	}
 
 	// Manejar copia de objetos
 	if obj, ok := varValue.(*ObjectValue); ok {
 		varValue = obj.Copy()
-		fmt.Printf("   Objeto copiado\n")
 	}
 
 	// Manejar copia de vectores para evitar referencias compartidas
 	if IsVectorType(varValue.Type()) {
 		varValue = varValue.Copy()
-		fmt.Printf("   Vector copiado\n")
 	}
 
 	// Verificar contexto de mutación (para propiedades de struct)
@@ -584,176 +660,152 @@ func (v *ReplVisitor) VisitAssignmentDecl(ctx *compiler.AssignmentDeclContext) i
 	ok, msg := variable.AssignValue(varValue, canMutate)
 
 	if !ok {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
-		fmt.Printf("❌ Error en asignación: %s\n", msg)
-		return nil
+		v.ErrorTable.NewSemanticError(start, msg)
+		return
 	}
 
-	fmt.Printf("✅ Asignación completada exitosamente: '%s' = valor de tipo '%s'\n", varName, varValue.Type())
-	return nil
+	v.traceAssign(start, variable.Name, variable.Value)
+	v.Log.Debug("asignación completada", "name", varName, "type", varValue.Type())
 }
 
-func (v *ReplVisitor) VisitArgAddAssigDecl(ctx *compiler.ArgAddAssigDeclContext) interface{} {
-	varName := v.Visit(ctx.Id_pattern()).(string)
+// Ejemplo: x += 5
+func (v *ReplVisitor) VisitCompoundAssign(stmt *ast.AssignStmt) {
+	start := stmt.Span.Start
+	varName := patternName(stmt.Target)
 
 	variable := v.ScopeTrace.GetVariable(varName)
 
 	if variable == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "Variable "+varName+" no encontrada")
-	} else {
+		v.ErrorTable.NewSemanticError(start, "Variable "+varName+" no encontrada")
+		return
+	}
 
-		leftValue := variable.Value
-		rightValue := v.Visit(ctx.Expression()).(value.IVOR)
+	leftValue := variable.Value
+	rightValue := v.VisitExpr(stmt.Value)
 
-		op := string(ctx.GetOp().GetText()[0])
+	op := string(stmt.Op[0])
 
-		strat, ok := BinaryStrats[op]
+	strat, ok := value.BinaryStrats[op]
 
-		if !ok {
-			log.Fatal("Binary operator not found")
-		}
+	if !ok {
+		v.ErrorTable.NewSemanticError(start, "Operador "+op+" no soportado")
+		return
+	}
 
-		ok, msg, varValue := strat.Validate(leftValue, rightValue)
+	ok, msg, varValue := strat.Validate(leftValue, rightValue)
 
-		if !ok {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
-			return nil
-		}
+	if !ok {
+		v.ErrorTable.NewSemanticError(start, msg)
+		return
+	}
 
-		canMutate := true
+	v.checkString(start, varValue)
 
-		if v.ScopeTrace.CurrentScope.isStruct {
-			canMutate = v.ScopeTrace.IsMutatingEnvironment()
-		}
+	canMutate := true
+
+	if v.ScopeTrace.CurrentScope.isStruct {
+		canMutate = v.ScopeTrace.IsMutatingEnvironment()
+	}
 
-		ok, msg = variable.AssignValue(varValue, canMutate)
+	ok, msg = variable.AssignValue(varValue, canMutate)
 
-		if !ok {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
-		}
+	if !ok {
+		v.ErrorTable.NewSemanticError(start, msg)
+		return
 	}
 
-	return nil
+	v.traceAssign(start, variable.Name, variable.Value)
 }
 
-// Falta  VisitVectorAssign
-func (v *ReplVisitor) VisitVectorAssign(ctx *compiler.VectorAssignContext) interface{} {
+// Ejemplo: v[0] = 5 o m[0][1] += 2
+func (v *ReplVisitor) VisitVectorAssign(stmt *ast.AssignStmt, target *ast.IndexExpr) {
+	start := stmt.Span.Start
 
-	rightValue := v.Visit(ctx.Expression()).(value.IVOR)
+	rightValue := v.VisitExpr(stmt.Value)
 
-	switch itemRef := v.Visit(ctx.Vect_item()).(type) {
+	switch itemRef := v.VisitVectorItem(target).(type) {
 	case *VectorItemReference:
 
 		leftValue := itemRef.Value
 
 		// check type, todo: improve cast -> ¿? idk what i was thinking
 		if rightValue.Type() != itemRef.Vector.ItemType {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "No se puede asignar un valor de tipo "+rightValue.Type()+" a un vector de tipo "+itemRef.Vector.ItemType)
-			return nil
+			v.ErrorTable.NewSemanticError(start, "No se puede asignar un valor de tipo "+rightValue.Type()+" a un vector de tipo "+itemRef.Vector.ItemType)
+			return
 		}
-		op := string(ctx.GetOp().GetText()[0])
+		op := string(stmt.Op[0])
 
 		if op == "=" {
 			itemRef.Vector.InternalValue[itemRef.Index] = rightValue
-			return nil
+			v.traceItemAssign(stmt, rightValue)
+			return
 		}
 
-		strat, ok := BinaryStrats[op]
+		strat, ok := value.BinaryStrats[op]
 
 		if !ok {
-			log.Fatal("Binary operator not found")
+			v.ErrorTable.NewSemanticError(start, "Operador "+op+" no soportado")
+			return
 		}
 
 		ok, msg, varValue := strat.Validate(leftValue, rightValue)
 
 		if !ok {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
-			return nil
+			v.ErrorTable.NewSemanticError(start, msg)
+			return
 		}
 
+		v.checkString(start, varValue)
+
 		itemRef.Vector.InternalValue[itemRef.Index] = varValue
+		v.traceItemAssign(stmt, varValue)
 
-		return nil
 	case *MatrixItemReference:
 		leftValue := itemRef.Value
 
 		// check type, todo: improve cast -> ¿? idk what i was thinking
 		if rightValue.Type() != RemoveBrackets(itemRef.Matrix.Type()) {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "No se puede asignar un valor de tipo "+rightValue.Type()+" a una matriz de tipo "+RemoveBrackets(itemRef.Matrix.Type()))
-			return nil
+			v.ErrorTable.NewSemanticError(start, "No se puede asignar un valor de tipo "+rightValue.Type()+" a una matriz de tipo "+RemoveBrackets(itemRef.Matrix.Type()))
+			return
 		}
 
-		op := string(ctx.GetOp().GetText()[0])
+		op := string(stmt.Op[0])
 
 		if op == "=" {
 			itemRef.Matrix.Set(itemRef.Index, rightValue)
-			return nil
+			v.traceItemAssign(stmt, rightValue)
+			return
 		}
 
-		strat, ok := BinaryStrats[op]
+		strat, ok := value.BinaryStrats[op]
 
 		if !ok {
-			log.Fatal("Binary operator not found")
+			v.ErrorTable.NewSemanticError(start, "Operador "+op+" no soportado")
+			return
 		}
 
 		ok, msg, varValue := strat.Validate(leftValue, rightValue)
 
 		if !ok {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
-			return nil
+			v.ErrorTable.NewSemanticError(start, msg)
+			return
 		}
 
-		itemRef.Matrix.Set(itemRef.Index, varValue)
-		return nil
-	}
-
-	return nil
-}
-
-// Id parents
-func (v *ReplVisitor) VisitIdPattern(ctx *compiler.IdPatternContext) interface{} {
-	return ctx.GetText()
-}
-
-// literales
-//
-//	Literal Int
-func (v *ReplVisitor) VisitIntLiteral(ctx *compiler.IntLiteralContext) interface{} {
-
-	intVal, _ := strconv.Atoi(ctx.GetText())
+		v.checkString(start, varValue)
 
-	return &value.IntValue{
-		InternalValue: intVal,
+		itemRef.Matrix.Set(itemRef.Index, varValue)
+		v.traceItemAssign(stmt, varValue)
 	}
-
 }
 
-// literal Float
-func (v *ReplVisitor) VisitFloatLiteral(ctx *compiler.FloatLiteralContext) interface{} {
-
-	floatVal, _ := strconv.ParseFloat(ctx.GetText(), 64)
-
-	return &value.FloatValue{
-		InternalValue: floatVal,
-	}
-
-}
-
-// literal String
 // literal String
-func (v *ReplVisitor) VisitStringLiteral(ctx *compiler.StringLiteralContext) interface{} {
-	// Remover las comillas
-	stringVal := ctx.GetText()[1 : len(ctx.GetText())-1]
-
-	// Procesar secuencias de escape
-	stringVal = strings.ReplaceAll(stringVal, "\\\"", "\"")
-	stringVal = strings.ReplaceAll(stringVal, "\\\\", "\\")
-	stringVal = strings.ReplaceAll(stringVal, "\\n", "\n")
-	stringVal = strings.ReplaceAll(stringVal, "\\r", "\r")
+func (v *ReplVisitor) VisitStringLit(lit *ast.StringLit) value.IVOR {
+	// Las comillas y las secuencias de escape ya las procesó ast.Lower
+	stringVal := lit.Value
 
-	// ✨ NUEVA FUNCIONALIDAD: Procesar interpolación de strings
+	// Procesar interpolación de strings
 	if HasInterpolation(stringVal) {
-		stringVal = v.InterpolateString(stringVal, ctx.GetStart())
+		stringVal = v.InterpolateString(stringVal, lit.Span.Start)
 	}
 
 	// Character literal (un solo carácter)
@@ -769,67 +821,44 @@ func (v *ReplVisitor) VisitStringLiteral(ctx *compiler.StringLiteralContext) int
 	}
 }
 
-// literal Bool
-func (v *ReplVisitor) VisitBoolLiteral(ctx *compiler.BoolLiteralContext) interface{} {
+// VisitIncDec maneja el incremento y el decremento (ID++, ID--)
+// Comportamiento: post-incremento - retorna el valor actual, luego lo modifica
+func (v *ReplVisitor) VisitIncDec(expr *ast.IncDecExpr) value.IVOR {
+	start := expr.Span.Start
 
-	boolVal, _ := strconv.ParseBool(ctx.GetText())
-
-	return &value.BoolValue{
-		InternalValue: boolVal,
+	delta, verb := 1, "incrementar"
+	if expr.Op == "--" {
+		delta, verb = -1, "decrementar"
 	}
 
-}
-
-// literal Nil
-func (v *ReplVisitor) VisitNilLiteral(ctx *compiler.NilLiteralContext) interface{} {
-	return value.DefaultNilValue
-}
-
-// literal en Exp
-func (v *ReplVisitor) VisitLiteralExpr(ctx *compiler.LiteralExprContext) interface{} {
-	fmt.Print("El valor de LiteralExp es: " + ctx.GetText() + "\n")
-	return v.Visit(ctx.Literal())
-}
-
-// VisitIncredecr maneja las expresiones de incremento y decremento
-// Ejemplo: i++ o i-- dentro de una expresión
-func (v *ReplVisitor) VisitIncredecr(ctx *compiler.IncredecrContext) interface{} {
-	return v.Visit(ctx.Incredecre())
-}
-
-// VisitIncremento maneja el incremento (ID++)
-// Comportamiento: Post-incremento - retorna el valor actual, luego incrementa
-func (v *ReplVisitor) VisitIncremento(ctx *compiler.IncrementoContext) interface{} {
-	fmt.Printf("🔹 Visitando Incremento: %s\n", ctx.GetText())
-
 	// Obtener el nombre de la variable
-	varName := ctx.ID().GetText()
+	varName := expr.X.Name
 
 	// Buscar la variable en el scope
 	variable := v.ScopeTrace.GetVariable(varName)
 	if variable == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "Variable '"+varName+"' no encontrada")
+		v.ErrorTable.NewSemanticError(start, "Variable '"+varName+"' no encontrada")
 		return value.DefaultNilValue
 	}
 
 	// Verificar que la variable sea de tipo entero
 	if variable.Value.Type() != value.IVOR_INT {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "El operador ++ solo puede aplicarse a variables de tipo int")
+		v.ErrorTable.NewSemanticError(start, "El operador "+expr.Op+" solo puede aplicarse a variables de tipo int")
 		return value.DefaultNilValue
 	}
 
 	// Verificar que no sea constante
 	if variable.IsConst {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "No se puede incrementar una variable constante")
+		v.ErrorTable.NewSemanticError(start, "No se puede "+verb+" una variable constante")
 		return value.DefaultNilValue
 	}
 
 	// Obtener el valor actual (para retornarlo - post-incremento)
 	currentValue := variable.Value.(*value.IntValue).InternalValue
 
-	// Crear el nuevo valor incrementado
+	// Crear el nuevo valor
 	newValue := &value.IntValue{
-		InternalValue: currentValue + 1,
+		InternalValue: currentValue + delta,
 	}
 
 	// Verificar contexto de mutación (para propiedades de struct)
@@ -841,85 +870,31 @@ func (v *ReplVisitor) VisitIncremento(ctx *compiler.IncrementoContext) interface
 	// Asignar el nuevo valor a la variable
 	ok, msg := variable.AssignValue(newValue, canMutate)
 	if !ok {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
+		v.ErrorTable.NewSemanticError(start, msg)
 		return value.DefaultNilValue
 	}
 
-	// Retornar el valor original (comportamiento post-incremento)
-	return &value.IntValue{
-		InternalValue: currentValue,
-	}
-}
+	v.traceAssign(start, variable.Name, variable.Value)
 
-// VisitDecremento maneja el decremento (ID--)
-// Comportamiento: Post-decremento - retorna el valor actual, luego decrementa
-func (v *ReplVisitor) VisitDecremento(ctx *compiler.DecrementoContext) interface{} {
-	fmt.Printf("🔹 Visitando Decremento: %s\n", ctx.GetText())
-
-	// Obtener el nombre de la variable
-	varName := ctx.ID().GetText()
-
-	// Buscar la variable en el scope
-	variable := v.ScopeTrace.GetVariable(varName)
-	if variable == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "Variable '"+varName+"' no encontrada")
-		return value.DefaultNilValue
-	}
-
-	// Verificar que la variable sea de tipo entero
-	if variable.Value.Type() != value.IVOR_INT {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "El operador -- solo puede aplicarse a variables de tipo int")
-		return value.DefaultNilValue
-	}
-
-	// Verificar que no sea constante
-	if variable.IsConst {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "No se puede decrementar una variable constante")
-		return value.DefaultNilValue
-	}
-
-	// Obtener el valor actual (para retornarlo - post-decremento)
-	currentValue := variable.Value.(*value.IntValue).InternalValue
-
-	// Crear el nuevo valor decrementado
-	newValue := &value.IntValue{
-		InternalValue: currentValue - 1,
-	}
-
-	// Verificar contexto de mutación (para propiedades de struct)
-	canMutate := true
-	if v.ScopeTrace.CurrentScope.isStruct {
-		canMutate = v.ScopeTrace.IsMutatingEnvironment()
-	}
-
-	// Asignar el nuevo valor a la variable
-	ok, msg := variable.AssignValue(newValue, canMutate)
-	if !ok {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
-		return value.DefaultNilValue
-	}
-
-	// Retornar el valor original (comportamiento post-decremento)
+	// Retornar el valor original (comportamiento post-incremento)
 	return &value.IntValue{
 		InternalValue: currentValue,
 	}
 }
 
-func (v *ReplVisitor) VisitIdPatternExpr(ctx *compiler.IdPatternExprContext) interface{} {
-	idCtx := ctx.Id_pattern().(*compiler.IdPatternContext)
+// VisitIdPattern resuelve una variable o un acceso encadenado a.b.c
+func (v *ReplVisitor) VisitIdPattern(expr ast.Expr) value.IVOR {
+	start := expr.GetSpan().Start
 
 	// Extraer todos los IDs del acceso encadenado
-	ids := []string{idCtx.GetHead().GetText()}
-	for _, t := range idCtx.GetTail() {
-		ids = append(ids, t.GetText())
-	}
+	ids := strings.Split(patternName(expr), ".")
 
 	// Inicia la resolución
 	varName := ids[0]
 	variable := v.ScopeTrace.GetVariable(varName)
 
 	if variable == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "Variable '"+varName+"' no encontrada")
+		v.ErrorTable.NewSemanticError(start, "Variable '"+varName+"' no encontrada")
 		return value.DefaultNilValue
 	}
 
@@ -931,13 +906,13 @@ func (v *ReplVisitor) VisitIdPatternExpr(ctx *compiler.IdPatternExprContext) int
 
 		structVal, ok := valueRef.(*value.StructValue)
 		if !ok {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "No se puede acceder a '"+attr+"' porque '"+ids[i-1]+"' no es un struct")
+			v.ErrorTable.NewSemanticError(start, "No se puede acceder a '"+attr+"' porque '"+ids[i-1]+"' no es un struct")
 			return value.DefaultNilValue
 		}
 
 		val, ok := structVal.Instance.Fields[attr]
 		if !ok {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "El atributo '"+attr+"' no existe en el struct '"+structVal.Instance.StructName+"'")
+			v.ErrorTable.NewSemanticError(start, "El atributo '"+attr+"' no existe en el struct '"+structVal.Instance.StructName+"'")
 			return value.DefaultNilValue
 		}
 
@@ -948,16 +923,10 @@ func (v *ReplVisitor) VisitIdPatternExpr(ctx *compiler.IdPatternExprContext) int
 	return valueRef
 }
 
-// Expresiones con parentesis
-func (v *ReplVisitor) VisitParensExpr(ctx *compiler.ParensExprContext) interface{} {
-	fmt.Print("El valor de ParenExp es: " + ctx.GetText() + "\n")
-	return v.Visit(ctx.Expression())
-}
-
 // Expresiones con vectores
-func (v *ReplVisitor) VisitVectorItemExpr(ctx *compiler.VectorItemExprContext) interface{} {
+func (v *ReplVisitor) VisitIndexExpr(expr *ast.IndexExpr) value.IVOR {
 
-	switch itemRef := v.Visit(ctx.Vect_item()).(type) {
+	switch itemRef := v.VisitVectorItem(expr).(type) {
 	case *VectorItemReference:
 		return itemRef.Value
 	case *MatrixItemReference:
@@ -968,30 +937,21 @@ func (v *ReplVisitor) VisitVectorItemExpr(ctx *compiler.VectorItemExprContext) i
 	return value.DefaultNilValue
 }
 
-// Expresiones con vectores
-func (v *ReplVisitor) VisitVectorExpr(ctx *compiler.VectorExprContext) interface{} {
-	return v.Visit(ctx.Vect_expr())
-}
-
-// Funciones con expresiones
-func (v *ReplVisitor) VisitFuncCallExpr(ctx *compiler.FuncCallExprContext) interface{} {
-	return v.Visit(ctx.Func_call())
-}
+func (v *ReplVisitor) VisitUnaryExpr(expr *ast.UnaryExpr) value.IVOR {
 
-func (v *ReplVisitor) VisitUnaryExpr(ctx *compiler.UnaryExprContext) interface{} {
+	exp := v.VisitExpr(expr.X)
 
-	exp := v.Visit(ctx.Expression()).(value.IVOR)
-
-	strat, ok := UnaryStrats[ctx.GetOp().GetText()]
+	strat, ok := value.UnaryStrats[expr.Op]
 
 	if !ok {
-		log.Fatal("Unary operator not found")
+		v.ErrorTable.NewSemanticError(expr.OpPos, "Operador "+expr.Op+" no soportado")
+		return value.DefaultNilValue
 	}
 
 	ok, msg, result := strat.Validate(exp)
 
 	if !ok {
-		v.ErrorTable.NewSemanticError(ctx.GetOp(), msg)
+		v.ErrorTable.NewSemanticError(expr.OpPos, msg)
 		return value.DefaultNilValue
 	}
 
@@ -999,12 +959,12 @@ func (v *ReplVisitor) VisitUnaryExpr(ctx *compiler.UnaryExprContext) interface{}
 
 }
 
-func (v *ReplVisitor) VisitBinaryExpr(ctx *compiler.BinaryExprContext) interface{} {
+func (v *ReplVisitor) VisitBinaryExpr(expr *ast.BinaryExpr) value.IVOR {
 
-	op := ctx.GetOp().GetText()
-	left := v.Visit(ctx.GetLeft()).(value.IVOR)
+	op := expr.Op
+	left := v.VisitExpr(expr.Left)
 
-	earlyCheck, ok := EarlyReturnStrats[op]
+	earlyCheck, ok := value.EarlyReturnStrats[op]
 
 	if ok {
 		ok, _, result := earlyCheck.Validate(left)
@@ -1014,112 +974,104 @@ func (v *ReplVisitor) VisitBinaryExpr(ctx *compiler.BinaryExprContext) interface
 		}
 	}
 
-	//
-	right := v.Visit(ctx.GetRight()).(value.IVOR)
-
-	// Si right es un IVOR, lo convertimos a IVOR
+	right := v.VisitExpr(expr.Right)
 
-	strat, ok := BinaryStrats[op]
+	strat, ok := value.BinaryStrats[op]
 
 	if !ok {
-		log.Fatal("Binary operator not found")
+		v.ErrorTable.NewSemanticError(expr.OpPos, "Operador "+op+" no soportado")
+		return value.DefaultNilValue
 	}
 
 	ok, msg, result := strat.Validate(left, right)
 
 	if !ok {
-		v.ErrorTable.NewSemanticError(ctx.GetOp(), msg)
+		v.ErrorTable.NewSemanticError(expr.OpPos, msg)
 		return value.DefaultNilValue
 	}
 
+	v.checkString(expr.OpPos, result)
+
 	return result
 }
 
-func (v *ReplVisitor) VisitIfStmt(ctx *compiler.IfStmtContext) interface{} {
+func (v *ReplVisitor) VisitIfStmt(stmt *ast.IfStmt) {
 
 	runChain := true
 
-	for _, ifStmt := range ctx.AllIf_chain() {
+	for _, branch := range stmt.Branches {
 
-		runChain = !v.Visit(ifStmt).(bool)
+		runChain = !v.VisitIfBranch(branch)
 
 		if !runChain {
 			break
 		}
 	}
 
-	if runChain && ctx.Else_stmt() != nil {
-		v.Visit(ctx.Else_stmt())
+	if runChain && stmt.Else != nil {
+		v.VisitElse(stmt.Else)
 	}
-
-	return nil
 }
 
-func (v *ReplVisitor) VisitIfChain(ctx *compiler.IfChainContext) interface{} {
+// VisitIfBranch ejecuta la rama si su condición se cumple e indica si lo hizo.
+func (v *ReplVisitor) VisitIfBranch(branch *ast.IfBranch) bool {
 
-	condition := v.Visit(ctx.Expression()).(value.IVOR)
+	condition := v.VisitExpr(branch.Cond)
 
 	if condition.Type() != value.IVOR_BOOL {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "La condicion del if debe ser un booleano")
+		v.ErrorTable.NewSemanticError(branch.Span.Start, "La condicion del if debe ser un booleano")
 		return false
 
 	}
 
 	if condition.(*value.BoolValue).InternalValue {
 
-		// Push scope
-		v.ScopeTrace.PushScope("if")
+		// Push scope; el pop se difiere para que break/continue/return
+		// (que se propagan con panic) no dejen el scope abierto
+		v.pushScope("if", branch.Span.Start)
+		defer v.popScope(closing(branch.Span))
 
-		for _, stmt := range ctx.AllStmt() {
-			v.Visit(stmt)
+		for _, stmt := range branch.Body {
+			v.VisitStmt(stmt)
 		}
 
-		// Pop scope
-		v.ScopeTrace.PopScope()
-
 		return true
 	}
 
 	return false
 }
 
-func (v *ReplVisitor) VisitElseStmt(ctx *compiler.ElseStmtContext) interface{} {
+func (v *ReplVisitor) VisitElse(block *ast.BlockStmt) {
 
 	// Push scope
-	v.ScopeTrace.PushScope("else")
+	v.pushScope("else", block.Span.Start)
+	defer v.popScope(closing(block.Span))
 
-	for _, stmt := range ctx.AllStmt() {
-		v.Visit(stmt)
+	for _, stmt := range block.Stmts {
+		v.VisitStmt(stmt)
 	}
-
-	// Pop scope
-	v.ScopeTrace.PopScope()
-
-	return nil
 }
 
-func (v *ReplVisitor) VisitForStmtCond(ctx *compiler.ForStmtCondContext) interface{} {
-	condition := ctx.Expression()
+// Ejemplo: for i < 5 { ... }
+func (v *ReplVisitor) VisitForCondStmt(stmt *ast.ForCondStmt) {
+	start := stmt.Span.Start
 
 	forItem := &CallStackItem{ReturnValue: value.DefaultNilValue, Type: []string{BreakItem, ContinueItem}}
 	v.CallStack.Push(forItem)
-	v.ScopeTrace.PushScope("for_cond")
+	v.pushScope("for_cond", start)
 
 	defer func() {
-		v.ScopeTrace.PopScope()
+		v.popScope(closing(stmt.Span))
 		v.CallStack.Clean(forItem)
 	}()
 
 	for {
-		condValue, ok := v.Visit(condition).(value.IVOR)
-		if !ok {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "Error evaluando la condición del for")
-			return nil
-		}
+		v.step(start)
+		condValue := v.VisitExpr(stmt.Cond)
 
 		if condValue.Type() != value.IVOR_BOOL {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "La condición del for debe ser un booleano")
-			return nil
+			v.ErrorTable.NewSemanticError(start, "La condición del for debe ser un booleano")
+			return
 		}
 
 		boolVal := condValue.Value().(bool)
@@ -1134,7 +1086,12 @@ func (v *ReplVisitor) VisitForStmtCond(ctx *compiler.ForStmtCondContext) interfa
 		// Defer para capturar continue/break dentro del cuerpo del bucle
 		func() {
 			defer func() {
-				if item, ok := recover().(*CallStackItem); item != nil && ok {
+				r := recover()
+				item, ok := r.(*CallStackItem)
+				if r != nil && !ok {
+					panic(r) // no es una sentencia de transferencia
+				}
+				if item != nil {
 					// Si no es el for actual, propaga el panic hacia arriba
 					if item != forItem {
 						panic(item)
@@ -1157,8 +1114,8 @@ func (v *ReplVisitor) VisitForStmtCond(ctx *compiler.ForStmtCondContext) interfa
 			}()
 
 			// Ejecutar todas las statements del cuerpo del bucle
-			for _, stmt := range ctx.AllStmt() {
-				v.Visit(stmt)
+			for _, stmt := range stmt.Body {
+				v.VisitStmt(stmt)
 			}
 		}()
 
@@ -1170,21 +1127,17 @@ func (v *ReplVisitor) VisitForStmtCond(ctx *compiler.ForStmtCondContext) interfa
 			continue // Saltar a la siguiente iteración
 		}
 	}
-
-	return nil
 }
 
-func (v *ReplVisitor) VisitForAssCond(ctx *compiler.ForAssCondContext) interface{} {
-	// Obtener las tres partes del for: inicialización, condición e incremento
-	initAssign := ctx.Assign_stmt()    // i = 0
-	condition := ctx.Expression(0)     // i < 5  (primera expresión)
-	incrementExpr := ctx.Expression(1) // i++    (segunda expresión)
+// Ejemplo: for i = 0; i < 5; i++ { ... }
+func (v *ReplVisitor) VisitForClauseStmt(stmt *ast.ForClauseStmt) {
+	start := stmt.Span.Start
 
 	// Crear nuevo scope para el for
-	v.ScopeTrace.PushScope("for_assignment")
+	v.pushScope("for_assignment", start)
 
 	// Ejecutar la inicialización (i = 0)
-	v.Visit(initAssign)
+	v.VisitAssignStmt(stmt.Init)
 
 	// Crear item para manejo de break/continue
 	forItem := &CallStackItem{
@@ -1194,54 +1147,49 @@ func (v *ReplVisitor) VisitForAssCond(ctx *compiler.ForAssCondContext) interface
 	v.CallStack.Push(forItem)
 
 	defer func() {
-		v.ScopeTrace.PopScope()    // Limpiar scope
-		v.CallStack.Clean(forItem) // Limpiar call stack
+		v.popScope(closing(stmt.Span)) // Limpiar scope
+		v.CallStack.Clean(forItem)     // Limpiar call stack
 	}()
 
 	// Bucle principal
 	for {
-		// Evaluar condición (i < 5)
-		condValue := v.Visit(condition)
-		if condValue == nil {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "Error evaluando la condición del for")
-			break
-		}
+		v.step(start)
 
-		condIVOR, ok := condValue.(value.IVOR)
-		if !ok {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "La condición del for debe evaluar a un valor IVOR")
-			break
-		}
+		// Evaluar condición (i < 5)
+		condValue := v.VisitExpr(stmt.Cond)
 
 		// Verificar que la condición sea booleana
-		if condIVOR.Type() != value.IVOR_BOOL {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "La condición del for debe ser un booleano")
+		if condValue.Type() != value.IVOR_BOOL {
+			v.ErrorTable.NewSemanticError(start, "La condición del for debe ser un booleano")
 			break
 		}
 
 		// Obtener valor booleano
-		boolVal := condIVOR.Value().(bool)
+		boolVal := condValue.Value().(bool)
 		if !boolVal {
 			break // Condición falsa, salir del bucle
 		}
 
 		// Variables para controlar el flujo
 		shouldBreak := false
-		shouldContinue := false
 
 		// Ejecutar cuerpo del bucle con manejo de continue/break
 		func() {
 			defer func() {
-				if item, ok := recover().(*CallStackItem); item != nil && ok {
+				r := recover()
+				item, ok := r.(*CallStackItem)
+				if r != nil && !ok {
+					panic(r) // no es una sentencia de transferencia
+				}
+				if item != nil {
 					// Si no es nuestro forItem, propagar panic hacia arriba
 					if item != forItem {
 						panic(item)
 					}
 
-					// Si es continue, marcamos para continuar
+					// Si es continue, se sigue con el incremento
 					if item.IsAction(ContinueItem) {
 						item.ResetAction()
-						shouldContinue = true
 						return
 					}
 
@@ -1255,8 +1203,8 @@ func (v *ReplVisitor) VisitForAssCond(ctx *compiler.ForAssCondContext) interface
 			}()
 
 			// Ejecutar todas las statements del cuerpo del bucle
-			for _, stmt := range ctx.AllStmt() {
-				v.Visit(stmt)
+			for _, stmt := range stmt.Body {
+				v.VisitStmt(stmt)
 			}
 		}()
 
@@ -1264,197 +1212,172 @@ func (v *ReplVisitor) VisitForAssCond(ctx *compiler.ForAssCondContext) interface
 		if shouldBreak {
 			break
 		}
-		if shouldContinue {
-			// Ejecutar incremento antes de continuar a la siguiente iteración
-			v.Visit(incrementExpr)
-			continue
-		}
 
-		// Ejecutar incremento (i++) para ejecución normal
-		v.Visit(incrementExpr)
+		// Ejecutar incremento (i++), también después de un continue
+		v.VisitExpr(stmt.Post)
 	}
-
-	return nil
 }
 
-func (v *ReplVisitor) VisitReturnStmt(ctx *compiler.ReturnStmtContext) interface{} {
+func (v *ReplVisitor) VisitReturnStmt(stmt *ast.ReturnStmt) {
 
 	exits, item := v.CallStack.IsReturnEnv()
 
 	if !exits {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "La sentencia return debe estar dentro de una funcion")
-		return nil
+		v.ErrorTable.NewSemanticError(stmt.Span.Start, "La sentencia return debe estar dentro de una funcion")
+		return
 	}
 
 	item.ReturnValue = value.DefaultNilValue
 	item.Action = ReturnItem
 
-	if ctx.Expression() != nil {
-		item.ReturnValue = v.Visit(ctx.Expression()).(value.IVOR)
+	if stmt.Value != nil {
+		item.ReturnValue = v.VisitExpr(stmt.Value)
 	}
 
 	panic(item)
 }
 
-func (v *ReplVisitor) VisitBreakStmt(ctx *compiler.BreakStmtContext) interface{} {
+func (v *ReplVisitor) VisitBreakStmt(stmt *ast.BreakStmt) {
 
 	exits, item := v.CallStack.IsBreakEnv()
 
 	if !exits {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "La sentencia break debe estar dentro de un ciclo o un switch")
-		return nil
+		v.ErrorTable.NewSemanticError(stmt.Span.Start, "La sentencia break debe estar dentro de un ciclo o un switch")
+		return
 	}
 
 	item.Action = BreakItem
 	panic(item)
 }
 
-func (v *ReplVisitor) VisitContinueStmt(ctx *compiler.ContinueStmtContext) interface{} {
+func (v *ReplVisitor) VisitContinueStmt(stmt *ast.ContinueStmt) {
 
 	exits, item := v.CallStack.IsContinueEnv()
 
 	if !exits {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "La sentencia continue debe estar dentro de un ciclo")
-		return nil
+		v.ErrorTable.NewSemanticError(stmt.Span.Start, "La sentencia continue debe estar dentro de un ciclo")
+		return
 	}
 
 	item.Action = ContinueItem
 	panic(item)
 }
 
-func (v *ReplVisitor) VisitFuncCall(ctx *compiler.FuncCallContext) interface{} {
+func (v *ReplVisitor) VisitCallExpr(call *ast.CallExpr) value.IVOR {
+	start := call.Span.Start
 
-	canditateName := v.Visit(ctx.Id_pattern()).(string)
+	canditateName := patternName(call.Fun)
 	funcObj, msg1 := v.ScopeTrace.GetFunction(canditateName)
 	structObj, msg2 := v.ScopeTrace.GlobalScope.GetStruct(canditateName)
 
 	if funcObj == nil && structObj == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), msg1+msg2)
+		v.ErrorTable.NewSemanticError(start, msg1+msg2)
 		return value.DefaultNilValue
 	}
 
-	args := make([]*Argument, 0)
-	if ctx.Arg_list() != nil {
-		fmt.Printf("🔹 Visitando Arg_list: %s\n", ctx.Arg_list().GetText())
-		args = v.Visit(ctx.Arg_list()).([]*Argument)
+	args := make([]*Argument, 0, len(call.Args))
+	for _, arg := range call.Args {
+		args = append(args, v.VisitArg(arg))
 	}
 
 	// Aca van estrcuturas
 	if structObj != nil {
 		if IsArgValidForStruct(args) {
-			return NewObjectValue(v, canditateName, ctx.Id_pattern().GetStart(), args, false)
+			return NewObjectValue(v, canditateName, start, args, false)
 		} else {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "Si bien "+canditateName+" es un struct, no se puede llamar a su constructor con los argumentos especificados. Ni tampoco es una funcion.")
+			v.ErrorTable.NewSemanticError(start, "Si bien "+canditateName+" es un struct, no se puede llamar a su constructor con los argumentos especificados. Ni tampoco es una funcion.")
 			return value.DefaultNilValue
 		}
 	}
 
+	v.traceCall(start, canditateName, args)
+
+	var returnValue value.IVOR = value.DefaultNilValue
+
 	switch funcObj := funcObj.(type) {
 	case *BuiltInFunction:
-		returnValue, ok, msg := funcObj.Exec(v.GetReplContext(), args)
+		result, ok, msg := funcObj.Exec(v.GetReplContext(), args)
 
 		if !ok {
 
 			if msg != "" {
-				v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
+				v.ErrorTable.NewSemanticError(start, msg)
+			}
+			if v.StopOnAssertion && strings.HasPrefix(msg, AssertionFailed) {
+				panic(&AssertionStop{})
 			}
 
-			return value.DefaultNilValue
-
+		} else {
+			returnValue = result
 		}
 
-		return returnValue
-
 	case *Function:
-		funcObj.Exec(v, args, ctx.GetStart())
-		return funcObj.ReturnValue
+		funcObj.Exec(v, args, start)
+		returnValue = funcObj.ReturnValue
 
 	case *ObjectBuiltInFunction:
-		funcObj.Exec(v, args, ctx.GetStart())
-		return funcObj.ReturnValue
+		funcObj.Exec(v, args, start)
+		returnValue = funcObj.ReturnValue
 
 	default:
-		log.Fatal("Function type not found")
+		v.ErrorTable.NewSemanticError(start, canditateName+" no es una funcion")
 	}
 
-	return value.DefaultNilValue
+	v.traceReturn(closing(call.Span), canditateName, returnValue)
+	return returnValue
 }
 
-func (v *ReplVisitor) VisitArgList(ctx *compiler.ArgListContext) interface{} {
-
-	args := make([]*Argument, 0)
-
-	for _, arg := range ctx.AllFunc_arg() {
-		// Visualizar lo que tiene arg
-		fmt.Printf("🔹 Visitando FuncArg: %s\n", arg.GetText())
-		args = append(args, v.Visit(arg).(*Argument))
-	}
-
-	return args
-
-}
-
-func (v *ReplVisitor) VisitFuncArg(ctx *compiler.FuncArgContext) interface{} {
-	fmt.Printf("🔹 Visitando FuncArg: %s\n", ctx.GetText())
+func (v *ReplVisitor) VisitArg(arg *ast.Arg) *Argument {
 	argName := ""
 	passByReference := false
 
 	var argValue value.IVOR = value.DefaultNilValue
 	var argVariableRef *Variable = nil
 
-	if ctx.Id_pattern() != nil {
+	if isPath(arg.Value) {
 		// Because is a reference to a variable, the treatment is a bit different
-		argName = ctx.Id_pattern().GetText()
+		argName = patternName(arg.Value)
 		argVariableRef = v.ScopeTrace.GetVariable(argName)
 
 		if argVariableRef != nil {
 			argValue = argVariableRef.Value
 		} else {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "Variable "+argName+" no encontrada")
+			v.ErrorTable.NewSemanticError(arg.Span.Start, "Variable "+argName+" no encontrada")
 		}
 	} else {
-		val := v.Visit(ctx.Expression())
-		fmt.Printf("Tipo retornado por v.Visit(ctx.Expression()): %T\n", val)
-		ivor, ok := val.(value.IVOR)
-		if !ok {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "El argumento no es un valor válido")
-			argValue = value.DefaultNilValue
-		} else {
-			argValue = ivor
-		}
+		argValue = v.VisitExpr(arg.Value)
 	}
 
-	if ctx.ID() != nil {
-		argName = ctx.ID().GetText()
+	if arg.Label != "" {
+		argName = arg.Label
 	}
 
 	return &Argument{
 		Name:            argName,
 		Value:           argValue,
 		PassByReference: passByReference,
-		Token:           ctx.GetStart(),
+		Pos:             arg.Span.Start,
 		VariableRef:     argVariableRef,
 	}
 
 }
 
-func (v *ReplVisitor) VisitFuncDecl(ctx *compiler.FuncDeclContext) interface{} {
+func (v *ReplVisitor) VisitFuncDecl(decl *ast.FuncDecl) {
 
 	if v.ScopeTrace.CurrentScope == v.ScopeTrace.GlobalScope {
 		// aready declared by dcl_visitor
-		return nil
+		return
 	}
 
 	if v.ScopeTrace.CurrentScope != v.ScopeTrace.GlobalScope && !v.ScopeTrace.CurrentScope.isStruct {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "Las funciones solo pueden ser declaradas en el scope global o en un struct")
+		v.ErrorTable.NewSemanticError(decl.Span.Start, "Las funciones solo pueden ser declaradas en el scope global o en un struct")
 	}
 
-	funcName := ctx.ID().GetText()
-
-	params := make([]*Param, 0)
+	funcName := decl.Name.Name
 
-	if ctx.Param_list() != nil {
-		params = v.Visit(ctx.Param_list()).([]*Param)
+	params := make([]*Param, 0, len(decl.Params))
+	for _, param := range decl.Params {
+		params = append(params, v.VisitParam(param))
 	}
 
 	if len(params) > 0 {
@@ -1463,89 +1386,52 @@ func (v *ReplVisitor) VisitFuncDecl(ctx *compiler.FuncDeclContext) interface{} {
 
 		for _, param := range params {
 			if param.ParamType() != baseParamType {
-				v.ErrorTable.NewSemanticError(param.Token, "Todos los parametros de la funcion deben ser del mismo tipo")
-				return nil
+				v.ErrorTable.NewSemanticError(param.Pos, "Todos los parametros de la funcion deben ser del mismo tipo")
+				return
 			}
 		}
 	}
 
 	returnType := value.IVOR_NIL
-	var returnTypeToken antlr.Token = nil
 
-	if ctx.Type_() != nil {
-		returnType = v.Visit(ctx.Type_()).(string)
-		returnTypeToken = ctx.Type_().GetStart()
+	if decl.Result != nil {
+		returnType = v.VisitType(decl.Result)
 	}
 
-	body := ctx.AllStmt()
-
 	function := &Function{ // pointer ?
-		Name:            funcName,
-		Param:           params,
-		ReturnType:      returnType,
-		Body:            body,
-		DeclScope:       v.ScopeTrace.CurrentScope,
-		ReturnTypeToken: returnTypeToken,
-		Token:           ctx.GetStart(),
+		Name:       funcName,
+		Param:      params,
+		ReturnType: returnType,
+		Result:     decl.Result,
+		Body:       decl.Body,
+		DeclScope:  v.ScopeTrace.CurrentScope,
+		Pos:        decl.Span.Start,
 	}
 
 	ok, msg := v.ScopeTrace.AddFunction(funcName, function)
 
 	if !ok {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
-		return nil
+		v.ErrorTable.NewSemanticError(decl.Span.Start, msg)
 	}
-
-	return function
 }
 
-func (v *ReplVisitor) VisitParamList(ctx *compiler.ParamListContext) interface{} {
-
-	params := make([]*Param, 0)
-
-	for _, param := range ctx.AllFunc_param() {
-		params = append(params, v.Visit(param).(*Param))
-	}
-
-	return params
-}
-
-func (v *ReplVisitor) VisitFuncParam(ctx *compiler.FuncParamContext) interface{} {
-
-	externName := ""
-	innerName := ""
-
-	// at least ID(0) is defined
-	// only 1 ID defined
-	if ctx.ID() == nil {
-		// innerName : type
-		// _ : type
-		innerName = "_"
-	} else {
-		// externName innerName : type
-		externName = "_"
-		innerName = ctx.ID().GetText()
-	}
-
-	passByReference := false
-
-	paramType := v.Visit(ctx.Type_()).(string)
+func (v *ReplVisitor) VisitParam(param *ast.Param) *Param {
 
+	// externName innerName : type
 	return &Param{
-		ExternName:      externName,
-		InnerName:       innerName,
-		PassByReference: passByReference,
-		Type:            paramType,
-		Token:           ctx.GetStart(),
+		ExternName:      "_",
+		InnerName:       param.Name.Name,
+		PassByReference: false,
+		Type:            v.VisitType(param.Type),
+		Pos:             param.Span.Start,
 	}
-
 }
 
-func (v *ReplVisitor) VisitSwitchStmt(ctx *compiler.SwitchStmtContext) interface{} {
+func (v *ReplVisitor) VisitSwitchStmt(stmt *ast.SwitchStmt) {
 
-	mainValue := v.Visit(ctx.Expression()).(value.IVOR)
+	mainValue := v.VisitExpr(stmt.Tag)
 
-	v.ScopeTrace.PushScope("switch")
+	v.pushScope("switch", stmt.Span.Start)
 
 	// Push break switchItem to call stack [breakable]
 	switchItem := &CallStackItem{
@@ -1560,10 +1446,15 @@ func (v *ReplVisitor) VisitSwitchStmt(ctx *compiler.SwitchStmtContext) interface
 	// handle break statements from call stack
 	defer func() {
 
-		v.ScopeTrace.PopScope()       // pop switch scope
-		v.CallStack.Clean(switchItem) // clean item if it's still in call stack
+		v.popScope(closing(stmt.Span)) // pop switch scope
+		v.CallStack.Clean(switchItem)  // clean item if it's still in call stack
 
-		if item, ok := recover().(*CallStackItem); item != nil && ok {
+		r := recover()
+		item, ok := r.(*CallStackItem)
+		if r != nil && !ok {
+			panic(r) // no es una sentencia de transferencia
+		}
+		if item != nil {
 
 			// Not a switch item, propagate panic
 			if item != switchItem {
@@ -1577,9 +1468,9 @@ func (v *ReplVisitor) VisitSwitchStmt(ctx *compiler.SwitchStmtContext) interface
 	visited := false
 
 	// evaluate cases
-	for _, switchCase := range ctx.AllSwitch_case() {
+	for _, switchCase := range stmt.Cases {
 
-		caseValue := v.GetCaseValue(switchCase)
+		caseValue := v.VisitExpr(switchCase.Value)
 
 		// ? use binary strat
 		if caseValue.Type() != mainValue.Type() {
@@ -1588,7 +1479,7 @@ func (v *ReplVisitor) VisitSwitchStmt(ctx *compiler.SwitchStmtContext) interface
 		}
 
 		if caseValue.Value() == mainValue.Value() {
-			v.Visit(switchCase)
+			v.VisitCaseClause(switchCase)
 			visited = true
 			break // implicit break
 		}
@@ -1596,64 +1487,42 @@ func (v *ReplVisitor) VisitSwitchStmt(ctx *compiler.SwitchStmtContext) interface
 	}
 
 	// evaluate default
-	if ctx.Default_case() != nil && !visited {
-		v.Visit(ctx.Default_case())
+	if stmt.Default != nil && !visited {
+		v.VisitCaseClause(stmt.Default)
 	}
-
-	return nil
 }
 
-func (v *ReplVisitor) GetCaseValue(tree antlr.ParseTree) value.IVOR {
-
-	switch val := tree.(type) {
-	case *compiler.SwitchCaseContext:
-		return v.Visit(val.Expression()).(value.IVOR)
-	default:
-		return nil
-	}
-
-}
-
-func (v *ReplVisitor) VisitSwitchCase(ctx *compiler.SwitchCaseContext) interface{} {
+func (v *ReplVisitor) VisitCaseClause(clause *ast.CaseClause) {
 
 	// * all cases inside switch case will share the same scope
 
-	for _, stmt := range ctx.AllStmt() {
-		v.Visit(stmt)
-	}
-	return nil
-}
-
-func (v *ReplVisitor) VisitDefaultCase(ctx *compiler.DefaultCaseContext) interface{} {
-	for _, stmt := range ctx.AllStmt() {
-		v.Visit(stmt)
+	for _, stmt := range clause.Body {
+		v.VisitStmt(stmt)
 	}
-	return nil
 }
 
-func (v *ReplVisitor) VisitBlockInd(ctx *compiler.BlockIndContext) interface{} {
-	fmt.Printf("🔹 Visitando BlockInd (bloque independiente): %s\n", ctx.GetText())
-
+func (v *ReplVisitor) VisitBlockStmt(block *ast.BlockStmt) {
 	// Push scope para crear un nuevo ámbito local
-	v.ScopeTrace.PushScope("block")
+	v.pushScope("block", block.Span.Start)
+
+	// Pop scope para restaurar el ámbito anterior, incluso si una sentencia
+	// de transferencia interrumpe el bloque
+	defer v.popScope(closing(block.Span))
 
 	// Ejecutar todas las sentencias dentro del bloque
-	for _, stmt := range ctx.AllStmt() {
-		v.Visit(stmt)
+	for _, stmt := range block.Stmts {
+		v.VisitStmt(stmt)
 	}
-
-	// Pop scope para restaurar el ámbito anterior
-	v.ScopeTrace.PopScope()
-
-	return nil
 }
 
-func (v *ReplVisitor) VisitForStmt(ctx *compiler.ForStmtContext) interface{} {
+// Ejemplo: for i, x in v { ... }
+func (v *ReplVisitor) VisitForRange(stmt *ast.ForRange) {
+	start, stop := stmt.Span.Start, closing(stmt.Span)
 
-	indexName := ctx.ID(0).GetText()
-	valueName := ctx.ID(1).GetText()
+	indexName := stmt.Index.Name
+	valueName := stmt.Value.Name
 
-	iterableValue := v.Visit(ctx.Expression()).(value.IVOR)
+	iterableValue := v.VisitExpr(stmt.X)
 
 	var iterableItem *VectorValue
 
@@ -1662,24 +1531,24 @@ func (v *ReplVisitor) VisitForStmt(ctx *compiler.ForStmtContext) interface{} {
 	} else if iterableValue.Type() == value.IVOR_STRING {
 		iterableItem = StringToVector(iterableValue.(*value.StringValue))
 	} else {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "El valor del for debe ser un vector o una cadena")
-		return nil
+		v.ErrorTable.NewSemanticError(start, "El valor del for debe ser un vector o una cadena")
+		return
 	}
 
 	if iterableItem.Size() == 0 {
-		return nil
+		return
 	}
 
-	outerForScope := v.ScopeTrace.PushScope("outer_for")
+	outerForScope := v.pushScope("outer_for", start)
 
 	// Declarar índice y valor
-	indexVar, msg1 := outerForScope.AddVariable(indexName, value.IVOR_INT, &value.IntValue{InternalValue: 0}, true, false, ctx.ID(0).GetSymbol())
-	valueVar, msg2 := outerForScope.AddVariable(valueName, iterableItem.ItemType, iterableItem.Current(), true, false, ctx.ID(1).GetSymbol())
+	indexVar, msg1 := outerForScope.AddVariable(indexName, value.IVOR_INT, &value.IntValue{InternalValue: 0}, true, false, stmt.Index.Span.Start)
+	valueVar, msg2 := outerForScope.AddVariable(valueName, iterableItem.ItemType, iterableItem.Current(), true, false, stmt.Value.Span.Start)
 
 	if indexVar == nil || valueVar == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), msg1+" "+msg2)
-		log.Fatal("Error declarando variables del for")
-		return nil
+		v.ErrorTable.NewSemanticError(start, msg1+" "+msg2)
+		v.popScope(stop)
+		return
 	}
 
 	forItem := &CallStackItem{
@@ -1688,29 +1557,33 @@ func (v *ReplVisitor) VisitForStmt(ctx *compiler.ForStmtContext) interface{} {
 	}
 
 	v.CallStack.Push(forItem)
-	innerForScope := v.ScopeTrace.PushScope("inner_for")
+	innerForScope := v.pushScope("inner_for", start)
 
-	v.VisitInnerForWithIndex(ctx, outerForScope, innerForScope, forItem, iterableItem, indexVar, valueVar)
+	v.VisitInnerForWithIndex(stmt, outerForScope, innerForScope, forItem, iterableItem, indexVar, valueVar)
 
 	iterableItem.Reset()
-	v.ScopeTrace.PopScope()
-	v.ScopeTrace.PopScope()
+	v.popScope(stop)
+	v.popScope(stop)
 	v.CallStack.Clean(forItem)
-	return nil
 }
 
-func (v *ReplVisitor) VisitInnerForWithIndex(ctx *compiler.ForStmtContext, outerForScope *BaseScopeTrace, innerForScope *BaseScopeTrace, forItem *CallStackItem, iterableItem *VectorValue, indexVar *Variable, valueVar *Variable) {
+func (v *ReplVisitor) VisitInnerForWithIndex(stmt *ast.ForRange, outerForScope *BaseScopeTrace, innerForScope *BaseScopeTrace, forItem *CallStackItem, iterableItem *VectorValue, indexVar *Variable, valueVar *Variable) {
 
 	defer func() {
 		innerForScope.Reset()
-		if item, ok := recover().(*CallStackItem); item != nil && ok {
+		r := recover()
+		item, ok := r.(*CallStackItem)
+		if r != nil && !ok {
+			panic(r) // no es una sentencia de transferencia
+		}
+		if item != nil {
 			if item != forItem {
 				panic(item)
 			}
 			if item.IsAction(ContinueItem) {
 				item.ResetAction()
 				iterableItem.Next()
-				v.VisitInnerForWithIndex(ctx, outerForScope, innerForScope, forItem, iterableItem, indexVar, valueVar)
+				v.VisitInnerForWithIndex(stmt, outerForScope, innerForScope, forItem, iterableItem, indexVar, valueVar)
 			}
 			if item.IsAction(BreakItem) {
 				return
@@ -1719,11 +1592,12 @@ func (v *ReplVisitor) VisitInnerForWithIndex(ctx *compiler.ForStmtContext, outer
 	}()
 
 	for iterableItem.CurrentIndex < iterableItem.Size() {
+		v.step(stmt.Span.Start)
 		indexVar.Value = &value.IntValue{InternalValue: iterableItem.CurrentIndex}
 		valueVar.Value = iterableItem.Current()
 
-		for _, stmt := range ctx.AllStmt() {
-			v.Visit(stmt)
+		for _, stmt := range stmt.Body {
+			v.VisitStmt(stmt)
 		}
 
 		iterableItem.Next()
@@ -1732,78 +1606,53 @@ func (v *ReplVisitor) VisitInnerForWithIndex(ctx *compiler.ForStmtContext, outer
 }
 
 // Structs
-func (v *ReplVisitor) VisitStructDecl(ctx *compiler.StructDeclContext) interface{} {
+func (v *ReplVisitor) VisitStructDecl(decl *ast.StructDecl) {
 	if v.ScopeTrace.CurrentScope != v.ScopeTrace.GlobalScope {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "Los structs solo pueden ser declaradas en el scope global")
-		return nil
+		v.ErrorTable.NewSemanticError(decl.Span.Start, "Los structs solo pueden ser declaradas en el scope global")
+		return
 	}
 
-	structAdded, msg := v.ScopeTrace.GlobalScope.AddStruct(ctx.ID().GetText(), &Struct{
-		Name:   ctx.ID().GetText(),
-		Fields: ctx.AllStruct_prop(),
-		Token:  ctx.GetStart(),
+	structAdded, msg := v.ScopeTrace.GlobalScope.AddStruct(decl.Name.Name, &Struct{
+		Name:   decl.Name.Name,
+		Fields: decl.Fields,
+		Pos:    decl.Span.Start,
 	})
 
 	if !structAdded {
-		v.ErrorTable.NewSemanticError(ctx.ID().GetSymbol(), msg)
+		v.ErrorTable.NewSemanticError(decl.Name.Span.Start, msg)
 	}
-
-	return nil
 }
 
-func (v *ReplVisitor) VisitStructAttr(ctx *compiler.StructAttrContext) interface{} {
-	varName := ctx.ID().GetText()
-	explicitType := ""
-	finalType := ""
+// VisitStructField declara un atributo en el scope de la instancia que se
+// está construyendo.
+func (v *ReplVisitor) VisitStructField(field *ast.Field) {
+	varName := field.Name.Name
 	var varValue value.IVOR = value.DefaultUnInitializedValue
 
-	if ctx.Type_() != nil {
-		explicitType = v.Visit(ctx.Type_()).(string)
-		finalType = explicitType
-	} else {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "Los atributos de un struct deben tener tipo explícito")
-		return nil
+	if field.Type == nil {
+		v.ErrorTable.NewSemanticError(field.Span.Start, "Los atributos de un struct deben tener tipo explícito")
+		return
 	}
 
-	variable, msg := v.ScopeTrace.AddVariable(varName, finalType, varValue, true, true, ctx.ID().GetSymbol())
+	finalType := v.VisitType(field.Type)
+
+	variable, msg := v.ScopeTrace.AddVariable(varName, finalType, varValue, true, true, field.Name.Span.Start)
 
 	if variable == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
+		v.ErrorTable.NewSemanticError(field.Span.Start, msg)
 	}
-
-	return nil
-}
-
-type StructInstance struct {
-	StructName string
-	Fields     map[string]value.IVOR
 }
 
-func (v *ReplVisitor) VisitStructInstantiationExpr(ctx *compiler.StructInstantiationExprContext) interface{} {
-	idToken := ctx.ID()
-	if idToken == nil {
-		log.Println("Error: no se pudo obtener el ID del struct")
-		return nil
-	}
-	structName := idToken.GetText()
-	log.Printf("Nombre del struct a instanciar: %s\n", structName)
+func (v *ReplVisitor) VisitStructLit(lit *ast.StructLit) value.IVOR {
+	structName := lit.Name.Name
+	v.Log.Debug("instanciando struct", "name", structName)
 
-	params := ctx.Struct_param_list()
 	fieldsMap := make(map[string]value.IVOR)
 
-	if params != nil {
-		i := 0
-		for {
-			paramCtx := params.Struct_param(i)
-			if paramCtx == nil {
-				break
-			}
-			paramName := paramCtx.ID().GetText()
-			exprValue := v.Visit(paramCtx.Expression()).(value.IVOR)
-			log.Printf("Param: %s = %v\n", paramName, exprValue)
-			fieldsMap[paramName] = exprValue
-			i++
-		}
+	for _, field := range lit.Fields {
+		exprValue := v.VisitExpr(field.Value)
+		v.Log.Debug("atributo", "name", field.Name.Name, "value", exprValue)
+		fieldsMap[field.Name.Name] = exprValue
 	}
 
 	structValue := &value.StructValue{
@@ -1813,45 +1662,28 @@ func (v *ReplVisitor) VisitStructInstantiationExpr(ctx *compiler.StructInstantia
 		},
 	}
 
-	log.Println("Instancia creada correctamente:", structValue.ToString())
+	v.Log.Debug("instancia creada", "value", structValue.ToString())
 	return structValue
 }
 
-func (v *ReplVisitor) VisitStruct_param(ctx *compiler.Struct_paramContext) interface{} {
-	fieldName := ctx.ID().GetText()
-	val := v.Visit(ctx.Expression()).(value.IVOR)
-
-	return &StructFieldValue{
-		Name:  fieldName,
-		Value: val,
-	}
-}
-
-type StructFieldValue struct {
-	Name  string
-	Value value.IVOR
-}
-
 // Declaracion de matrices
-// Ejemplo: matrix [][]int = { {1,2,3}, {4,5,6}, {7,8,9} }
-func (v *ReplVisitor) VisitVarMatrixDecl(ctx *compiler.VarMatrixDeclContext) interface{} {
-	fmt.Printf("🔹 Visitando VarMatrixDecl: %s\n", ctx.GetText())
+// Ejemplo: matrix = [][]int{ {1,2,3}, {4,5,6}, {7,8,9} }
+func (v *ReplVisitor) VisitVarMatrixDecl(decl *ast.VarDecl) {
+	v.Log.Debug("VarMatrixDecl", "line", decl.Span.Start.Line)
 
 	isConst := false
 
 	// Obtener información
-	varName := ctx.ID().GetText()
-	matrixType := v.Visit(ctx.Matrix_type()).(string)
-	matrixValue := v.Visit(ctx.Matrix_expr()).(value.IVOR)
+	varName := decl.Name.Name
+	matrixType := decl.TypeRef.String()
+	matrixValue := v.VisitExpr(decl.Value)
 
-	fmt.Printf("   Variable: '%s'\n", varName)
-	fmt.Printf("   Tipo Matrix: '%s'\n", matrixType)
-	fmt.Printf("   Valor Matrix: %v (tipo: %s)\n", matrixValue, matrixValue.Type())
+	v.Log.Debug("declaración de matriz", "name", varName, "type", matrixType, "value", matrixValue, "valueType", matrixValue.Type())
 
 	// Validar tipo
 	if !IsMatrixType(matrixType) {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), "El tipo '"+matrixType+"' no es un tipo de matriz válido")
-		return nil
+		v.ErrorTable.NewSemanticError(decl.Span.Start, "El tipo '"+matrixType+"' no es un tipo de matriz válido")
+		return
 	}
 
 	// Validar tipo de datos dentro de la matriz
@@ -1861,12 +1693,12 @@ func (v *ReplVisitor) VisitVarMatrixDecl(ctx *compiler.VarMatrixDeclContext) int
 			valueItemType := RemoveMatrixBrackets(matrixValue.Type())
 
 			if declaredItemType != valueItemType {
-				v.ErrorTable.NewSemanticError(ctx.GetStart(), "No se puede asignar una matriz de tipo '"+matrixValue.Type()+"' a '"+matrixType+"'")
-				return nil
+				v.ErrorTable.NewSemanticError(decl.Span.Start, "No se puede asignar una matriz de tipo '"+matrixValue.Type()+"' a '"+matrixType+"'")
+				return
 			}
 		} else {
-			v.ErrorTable.NewSemanticError(ctx.GetStart(), "No se puede asignar un valor de tipo '"+matrixValue.Type()+"' a '"+matrixType+"'")
-			return nil
+			v.ErrorTable.NewSemanticError(decl.Span.Start, "No se puede asignar un valor de tipo '"+matrixValue.Type()+"' a '"+matrixType+"'")
+			return
 		}
 	}
 
@@ -1876,34 +1708,35 @@ func (v *ReplVisitor) VisitVarMatrixDecl(ctx *compiler.VarMatrixDeclContext) int
 	}
 
 	// Agregar al scope
-	variable, msg := v.ScopeTrace.AddVariable(varName, matrixType, matrixValue, isConst, false, ctx.GetStart())
+	variable, msg := v.ScopeTrace.AddVariable(varName, matrixType, matrixValue, isConst, false, decl.Span.Start)
 
 	if variable == nil {
-		v.ErrorTable.NewSemanticError(ctx.GetStart(), msg)
-		return nil
+		v.ErrorTable.NewSemanticError(decl.Span.Start, msg)
+		return
 	}
 
-	fmt.Printf("✅ Variable matriz '%s' declarada exitosamente con tipo '%s'\n", varName, matrixType)
-	return nil
+	v.traceAssign(decl.Span.Start, variable.Name, variable.Value)
+	v.Log.Debug("matriz declarada", "name", varName, "type", matrixType)
 }
 
 // Procesamiento de la expresion literal de la matriz
 // Ejemplo: { {1,2,3}, {4,5,6}, {7,8,9} }
-func (v *ReplVisitor) VisitMatrixItemList(ctx *compiler.MatrixItemListContext) interface{} {
-	fmt.Printf("🔹 Visitando MatrixItemList: %s\n", ctx.GetText())
-
+func (v *ReplVisitor) VisitMatrixLit(matrix *ast.MatrixLit) value.IVOR {
 	var matrixItems [][]value.IVOR
 	var innerType string = value.IVOR_NIL
 
-	for i, row := range ctx.AllVect_expr() {
-		rowValue := v.Visit(row).(*VectorValue)
+	for i, row := range matrix.Rows {
+		rowValue, ok := v.VisitVectorLit(row).(*VectorValue)
+		if !ok {
+			return value.DefaultNilValue // la fila ya reportó su error
+		}
 		matrixItems = append(matrixItems, rowValue.InternalValue)
 
 		if i == 0 {
 			innerType = rowValue.ItemType
 		} else {
 			if rowValue.ItemType != innerType {
-				v.ErrorTable.NewSemanticError(ctx.GetStart(), "Todos los elementos de la matriz deben ser del mismo tipo")
+				v.ErrorTable.NewSemanticError(matrix.Lbrace, "Todos los elementos de la matriz deben ser del mismo tipo")
 				return value.DefaultNilValue
 			}
 		}
@@ -1915,14 +1748,6 @@ func (v *ReplVisitor) VisitMatrixItemList(ctx *compiler.MatrixItemListContext) i
 		return NewMatrixValue(matrixItems, _type, innerType)
 	}
 
-	v.ErrorTable.NewSemanticError(ctx.GetStart(), "Tipo "+_type+" no encontrado")
+	v.ErrorTable.NewSemanticError(matrix.Lbrace, "Tipo "+_type+" no encontrado")
 	return value.DefaultNilValue
 }
-
-func (v *ReplVisitor) VisitMatrix_type(ctx *compiler.Matrix_typeContext) interface{} {
-	// Extraemos el tipo base
-	baseType := ctx.ID().GetText()
-
-	// Formamos el tipo completo de matriz
-	return "[[]]" + baseType
-}
@@ -0,0 +1,55 @@
+package repl_test
+
+import (
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/ast"
+	interpeter "main.go/grammar"
+	"main.go/repl"
+)
+
+// run ejecuta code en el intérprete y devuelve el visitor para revisar su
+// estado al terminar.
+func run(t *testing.T, code string) *repl.ReplVisitor {
+	t.Helper()
+	lexer := interpeter.NewVLangLexer(antlr.NewInputStream(code))
+	parser := interpeter.NewVLangGrammar(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	program := ast.Lower(parser.Program())
+
+	dclVisitor := repl.NewDclVisitor(repl.NewErrorTable())
+	dclVisitor.VisitProgram(program)
+	visitor := repl.NewVisitor(dclVisitor)
+	visitor.VisitProgram(program)
+
+	for _, err := range visitor.ErrorTable.Errors {
+		t.Errorf("%d:%d: %s", err.Line, err.Column, err.Msg)
+	}
+	return visitor
+}
+
+// break, continue y return se propagan con panic: los scopes de if, else y
+// los bloques que interrumpen tienen que cerrarse igual.
+func TestTransferClosesScopes(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"break en un if", "mut i = 0\nfor i < 3 {\n    if i == 1 {\n        break\n    }\n    i += 1\n}\nmut t = 1\n"},
+		{"continue en un else", "mut i = 0\nfor i < 3 {\n    i += 1\n    if i > 5 {\n        println(i)\n    } else {\n        continue\n    }\n}\nmut t = 1\n"},
+		{"break en un bloque", "mut i = 0\nfor i < 3 {\n    {\n        break\n    }\n}\nmut t = 1\n"},
+		{"return en un if", "fn f(x int) int {\n    if x > 0 {\n        return 1\n    }\n    return 0\n}\nmut r = f(1)\nmut t = 1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visitor := run(t, tt.code)
+			if visitor.ScopeTrace.CurrentScope != visitor.ScopeTrace.GlobalScope {
+				t.Errorf("quedó abierto el scope %q", visitor.ScopeTrace.CurrentScope.Name())
+			}
+			if visitor.ScopeTrace.GlobalScope.GetVariable("t") == nil {
+				t.Errorf("la variable declarada después no quedó en el scope global")
+			}
+		})
+	}
+}
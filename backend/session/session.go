@@ -0,0 +1,316 @@
+// Package session implementa una sesión interactiva del intérprete: cada
+// entrada se ejecuta sobre el mismo scope global, los mismos structs y la
+// misma consola que las anteriores, así que una función declarada en una
+// entrada se puede llamar en la siguiente.
+//
+// Una entrada puede ser:
+//   - sentencias, que pasan el chequeo estático con las declaraciones de
+//     las entradas anteriores y se ejecutan como un programa
+//   - una expresión suelta, que se evalúa y cuyo valor se devuelve
+//   - un meta-comando: ":reset" borra el estado, ":vars" lista las variables
+//     globales y ":type expr" muestra el tipo estático de expr sin evaluarla
+//
+// Mientras queden llaves, paréntesis o corchetes sin cerrar, las líneas se
+// acumulan y se ejecutan juntas; una línea vacía fuerza la ejecución.
+package session
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/analysis"
+	"main.go/ast"
+	"main.go/checker"
+	compiler "main.go/grammar"
+	"main.go/logging"
+	"main.go/repl"
+	"main.go/value"
+)
+
+// Result es la respuesta a una línea de entrada.
+type Result struct {
+	Output     string       `json:"output"`          // lo que imprimió el programa
+	Value      string       `json:"value,omitempty"` // valor de la expresión o respuesta del meta-comando
+	Type       string       `json:"type,omitempty"`  // tipo de Value
+	Errors     []repl.Error `json:"errors"`
+	Incomplete bool         `json:"incomplete"` // la entrada sigue abierta y espera más líneas
+}
+
+// Session guarda el estado del intérprete entre entradas.
+type Session struct {
+	Log *slog.Logger
+
+	visitor *repl.ReplVisitor
+	printed int      // parte de la consola ya devuelta
+	pending []string // líneas de una entrada incompleta
+
+	// declaraciones globales de las entradas ejecutadas, para inferir tipos
+	// y chequear cada entrada nueva sin volver a analizar las anteriores
+	env     *ast.Env
+	checker *checker.Checker
+}
+
+func NewSession() *Session {
+	s := &Session{Log: logging.New(logging.Repl)}
+	s.Reset()
+	return s
+}
+
+// Reset descarta las variables, funciones, structs y la salida acumulada.
+func (s *Session) Reset() {
+	dclVisitor := repl.NewDclVisitor(repl.NewErrorTable())
+	dclVisitor.Log = s.Log
+	s.visitor = repl.NewVisitor(dclVisitor)
+	s.visitor.Limits = repl.SandboxLimits // un ciclo infinito aborta la entrada, no la sesión
+	s.printed = 0
+	s.pending = nil
+	s.forget()
+}
+
+// forget descarta las declaraciones que conoce el análisis.
+func (s *Session) forget() {
+	s.env = ast.NewEnv()
+	s.checker = checker.NewChecker(repl.NewErrorTable())
+}
+
+// Pending indica si hay una entrada incompleta esperando más líneas.
+func (s *Session) Pending() bool {
+	return len(s.pending) > 0
+}
+
+// Input recibe una línea. Si completa una entrada la ejecuta; si no, la
+// guarda y devuelve Incomplete.
+func (s *Session) Input(line string) Result {
+	trimmed := strings.TrimSpace(line)
+	if !s.Pending() && strings.HasPrefix(trimmed, ":") {
+		return s.command(trimmed)
+	}
+
+	if trimmed == "" && !s.Pending() {
+		return Result{Errors: []repl.Error{}}
+	}
+
+	s.pending = append(s.pending, line)
+	code := strings.Join(s.pending, "\n")
+	if trimmed != "" && incomplete(code) {
+		return Result{Errors: []repl.Error{}, Incomplete: true}
+	}
+
+	s.pending = nil
+	return s.eval(code)
+}
+
+// InputLines procesa un texto de varias líneas, una por una, y junta los
+// resultados. Los meta-comandos solo se reconocen al inicio de una entrada, y
+// las líneas vacías dentro de un bloque abierto no fuerzan su ejecución.
+func (s *Session) InputLines(text string) Result {
+	total := Result{Errors: []repl.Error{}}
+	for _, line := range strings.Split(text, "\n") {
+		if s.Pending() && strings.TrimSpace(line) == "" {
+			continue
+		}
+		result := s.Input(line)
+		total.Output += result.Output
+		total.Errors = append(total.Errors, result.Errors...)
+		if result.Value != "" {
+			total.Value, total.Type = result.Value, result.Type
+		}
+		total.Incomplete = result.Incomplete
+	}
+	return total
+}
+
+// === EJECUCIÓN ===
+
+func (s *Session) eval(code string) (result Result) {
+	errorTable := repl.NewErrorTable()
+	s.visitor.ErrorTable = errorTable
+	s.visitor.CallStack = repl.NewCallStack()
+	s.visitor.ResetSteps()
+
+	defer func() {
+		r := recover()
+		if limit, ok := r.(*repl.LimitExceeded); ok {
+			// el estado se conserva: solo se abandona esta entrada
+			errorTable.NewRuntimeError(limit.Line, limit.Column, limit.Msg)
+			s.visitor.ScopeTrace.CurrentScope = s.visitor.ScopeTrace.GlobalScope
+		} else if r != nil {
+			s.Log.Error("error interno en la sesión", "panic", r)
+			errorTable.NewRuntimeError(0, 0, fmt.Sprintf("Error interno del intérprete: %v", r))
+			s.visitor.ScopeTrace.Reset()
+			s.forget()
+		}
+		result.Output = s.flush()
+		result.Errors = errorTable.Errors
+	}()
+
+	// una expresión suelta se evalúa y se muestra su valor
+	if expr := s.env.LowerExpr(parseExpression(code)); expr != nil {
+		if val := s.visitor.VisitExpr(expr); val != nil && val.Type() != value.IVOR_NIL {
+			result.Value = display(val)
+			result.Type = val.Type()
+		}
+		return result
+	}
+
+	tree := parseProgram(code, errorTable)
+	if errorTable.HasErrors() {
+		return result
+	}
+	program := s.env.Lower(tree)
+	s.checker.ErrorTable = errorTable
+	if !s.checker.Check(program) {
+		return result
+	}
+
+	// las funciones y structs nuevos se agregan al mismo scope global
+	dclVisitor := &repl.DclVisitor{
+		ScopeTrace:  s.visitor.ScopeTrace,
+		ErrorTable:  errorTable,
+		StructNames: s.visitor.StructNames,
+		Log:         s.Log,
+	}
+	dclVisitor.VisitProgram(program)
+	s.visitor.StructNames = dclVisitor.StructNames
+	if errorTable.HasErrors() {
+		return result
+	}
+
+	// lo que la entrada declare antes de un error de ejecución queda en el
+	// estado, así que el análisis lo conoce aunque falle
+	s.env.Declare(program)
+	s.checker.Declare(program)
+	s.visitor.VisitProgram(program)
+	return result
+}
+
+// flush devuelve lo que la consola imprimió desde la última entrada.
+func (s *Session) flush() string {
+	output := s.visitor.Console.GetOutput()
+	if s.printed > len(output) {
+		s.printed = 0
+	}
+	fresh := output[s.printed:]
+	s.printed = len(output)
+	return fresh
+}
+
+// display muestra un valor como en el REPL: igual que println, salvo las
+// cadenas y los caracteres, que van entre comillas.
+func display(val value.IVOR) string {
+	switch val.Type() {
+	case value.IVOR_STRING:
+		return strconv.Quote(repl.ValueToString(val))
+	case value.IVOR_CHARACTER:
+		return "'" + repl.ValueToString(val) + "'"
+	}
+	return repl.ValueToString(val)
+}
+
+// === META-COMANDOS ===
+
+func (s *Session) command(line string) Result {
+	result := Result{Errors: []repl.Error{}}
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case ":reset":
+		s.Reset()
+		result.Value = "estado reiniciado"
+
+	case ":vars":
+		var lines []string
+		for _, variable := range s.visitor.ScopeTrace.GlobalScope.Variables() {
+			lines = append(lines, fmt.Sprintf("%s %s = %s", variable.Name, variable.Type, variable.Value))
+		}
+		result.Value = strings.Join(lines, "\n")
+
+	case ":type":
+		if arg == "" {
+			result.Errors = append(result.Errors, commandError("Uso: :type expresion"))
+			break
+		}
+		typ, errs := s.staticType(arg)
+		result.Errors = append(result.Errors, errs...)
+		result.Value = typ
+
+	default:
+		result.Errors = append(result.Errors, commandError(fmt.Sprintf("Comando desconocido %s; los comandos son :reset, :vars y :type expresion", name)))
+	}
+	return result
+}
+
+func commandError(msg string) repl.Error {
+	return repl.Error{Line: 1, Msg: msg, Type: repl.SyntaxError, Severity: "error", Source: "session"}
+}
+
+// staticType infiere el tipo de expr con el árbol tipado, sin evaluarla. Las
+// declaraciones de las entradas anteriores salen de s.env.
+func (s *Session) staticType(expr string) (string, []repl.Error) {
+	if parseExpression(expr) == nil {
+		errorTable := repl.NewErrorTable()
+		parseProgram(expr, errorTable)
+		if !errorTable.HasErrors() {
+			errorTable.NewSyntaxError(1, 0, "Se esperaba una expresion")
+		}
+		return "", errorTable.Errors
+	}
+
+	const probe = "__tipo"
+	errorTable := repl.NewErrorTable()
+	tree := parseProgram("mut "+probe+" = ("+expr+")", errorTable)
+	if errorTable.HasErrors() {
+		return "", errorTable.Errors
+	}
+
+	program := s.env.Lower(tree)
+	if len(program.Stmts) > 0 {
+		if decl, ok := program.Stmts[len(program.Stmts)-1].(*ast.VarDecl); ok && decl.Name.Name == probe && decl.Value != nil {
+			if typ := decl.Value.Type(); typ != "" {
+				return typ, nil
+			}
+		}
+	}
+	return "desconocido", nil
+}
+
+// === ANÁLISIS ===
+
+func parseProgram(code string, errorTable *repl.ErrorTable) antlr.ParseTree {
+	parser, _ := analysis.NewParser(code, errorTable)
+	return parser.Program()
+}
+
+// parseExpression devuelve el árbol de code si es una sola expresión
+// completa, o nil si no lo es.
+func parseExpression(code string) antlr.ParseTree {
+	errorTable := repl.NewErrorTable()
+	parser, stream := analysis.NewParser(code, errorTable)
+	tree := parser.Expression()
+	if errorTable.HasErrors() || stream.LA(1) != antlr.TokenEOF {
+		return nil
+	}
+	return tree
+}
+
+// incomplete indica si code tiene llaves, paréntesis o corchetes sin cerrar.
+func incomplete(code string) bool {
+	lexer := compiler.NewVLangLexer(antlr.NewInputStream(code))
+	lexer.RemoveErrorListeners()
+
+	depth := 0
+	for _, token := range lexer.GetAllTokens() {
+		switch token.GetTokenType() {
+		case compiler.VLangLexerLBRACE, compiler.VLangLexerLPAREN, compiler.VLangLexerLBRACK:
+			depth++
+		case compiler.VLangLexerRBRACE, compiler.VLangLexerRPAREN, compiler.VLangLexerRBRACK:
+			depth--
+		}
+	}
+	return depth > 0
+}
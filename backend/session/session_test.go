@@ -0,0 +1,137 @@
+package session_test
+
+import (
+	"strings"
+	"testing"
+
+	"main.go/session"
+)
+
+// input manda cada línea a la sesión y devuelve el resultado de la última.
+func input(t *testing.T, s *session.Session, lines ...string) session.Result {
+	t.Helper()
+	var result session.Result
+	for _, line := range lines {
+		result = s.Input(line)
+	}
+	return result
+}
+
+// messages junta los mensajes de error de un resultado.
+func messages(result session.Result) string {
+	var msgs []string
+	for _, err := range result.Errors {
+		msgs = append(msgs, err.Msg)
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Las variables, funciones y structs de una entrada siguen disponibles en
+// las siguientes.
+func TestStateAcrossInputs(t *testing.T) {
+	s := session.NewSession()
+	input(t, s, "mut x = 41")
+	input(t, s, "x += 1")
+	if result := input(t, s, "x"); result.Value != "42" || result.Type != "int" {
+		t.Errorf("x = %q de tipo %q, se esperaba 42 de tipo int; errores: %s", result.Value, result.Type, messages(result))
+	}
+
+	result := input(t, s, "fn doble(n int) int {", "    return n * 2", "}")
+	if result.Incomplete || len(result.Errors) > 0 {
+		t.Fatalf("la función no se declaró: %s", messages(result))
+	}
+	input(t, s, "struct Punto {", "    int x", "}")
+	input(t, s, "mut p = Punto{x: doble(x)}")
+	input(t, s, "mut px = p.x")
+	if result := input(t, s, "println(px)"); result.Output != "84\n\n" || len(result.Errors) > 0 {
+		t.Errorf("salida %q, errores: %s", result.Output, messages(result))
+	}
+
+	input(t, s, ":reset")
+	if result := input(t, s, "x"); len(result.Errors) == 0 {
+		t.Errorf("x sigue declarada después de :reset")
+	}
+}
+
+// El chequeo estático revisa la entrada completa, también las ramas que no
+// se ejecutan, y reporta las líneas contadas desde la entrada.
+func TestInputIsChecked(t *testing.T) {
+	s := session.NewSession()
+	input(t, s, "mut a = 1")
+	input(t, s, "fn f(n int) int {", "    return n", "}")
+
+	result := input(t, s, "if a > 5 {", "    mut b = a + true", "}")
+	if !strings.Contains(messages(result), "No es posible realizar la operación '+'") {
+		t.Fatalf("no se reportó la suma de la rama que no se ejecuta: %s", messages(result))
+	}
+	if result.Errors[0].Line != 2 {
+		t.Errorf("error en la línea %d, se esperaba la 2 de la entrada", result.Errors[0].Line)
+	}
+
+	result = input(t, s, "fn g(n int) int {", "    if n > 0 {", "        return n", "    }", "}")
+	if !strings.Contains(messages(result), "no retorna un valor de tipo int en todos los caminos") {
+		t.Errorf("no se reportó la función sin return: %s", messages(result))
+	}
+
+	result = input(t, s, "mut r = f(\"x\")")
+	if !strings.Contains(messages(result), "Tipo de argumento n invalido") {
+		t.Errorf("el chequeo no conoce la función de una entrada anterior: %s", messages(result))
+	}
+}
+
+// Una entrada con errores se descarta sin perder lo que había antes, y la
+// sesión sigue aceptando entradas.
+func TestRecoversFromErrors(t *testing.T) {
+	s := session.NewSession()
+	input(t, s, "mut y = 5")
+
+	tests := []struct {
+		name  string
+		lines []string
+	}{
+		{"error de sintaxis", []string{"mut = 3"}},
+		{"error de tipos", []string{"mut z = y + true"}},
+		{"error de ejecución", []string{"mut v []int = {1}", "println(v[3])"}},
+		{"límite de llamadas", []string{"fn r(n int) int {", "    return r((n + 1))", "}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input(t, s, tt.lines...)
+			if tt.name == "límite de llamadas" {
+				if result := input(t, s, "r(0)"); len(result.Errors) == 0 {
+					t.Fatal("la recursión infinita no reportó un error")
+				}
+			}
+			if result := input(t, s, "y"); result.Value != "5" {
+				t.Errorf("y = %q, se esperaba 5; errores: %s", result.Value, messages(result))
+			}
+			if result := input(t, s, "mut w = y * 2", "w"); result.Value != "10" {
+				t.Errorf("w = %q, se esperaba 10; errores: %s", result.Value, messages(result))
+			}
+			input(t, s, ":reset")
+			input(t, s, "mut y = 5")
+		})
+	}
+}
+
+// Solo las entradas aceptadas dejan declaraciones para el chequeo de las
+// siguientes; una entrada rechazada no cuenta.
+func TestCheckKnowsAcceptedDeclarations(t *testing.T) {
+	s := session.NewSession()
+	input(t, s, "mut a = 1")
+
+	if result := input(t, s, "mut a = 2"); !strings.Contains(messages(result), "La variable 'a' ya existe") {
+		t.Errorf("no se reportó la redeclaración de una entrada anterior: %s", messages(result))
+	}
+
+	input(t, s, "mut b = a + true")
+	if result := input(t, s, "mut b = \"uno\"", "mut c = b + \"dos\""); len(result.Errors) > 0 {
+		t.Errorf("la entrada rechazada dejó declarada b: %s", messages(result))
+	}
+	if result := input(t, s, "mut d int = b"); !strings.Contains(messages(result), "tipo string a una variable de tipo int") {
+		t.Errorf("el chequeo no conoce el tipo de b: %s", messages(result))
+	}
+	if result := input(t, s, ":type c"); result.Value != "string" {
+		t.Errorf(":type c = %q, se esperaba string; errores: %s", result.Value, messages(result))
+	}
+}
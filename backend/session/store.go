@@ -0,0 +1,116 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Store guarda las sesiones abiertas por el IDE. Las que pasan más de TTL
+// sin recibir entradas se descartan.
+type Store struct {
+	TTL time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*entry
+}
+
+type entry struct {
+	mu       sync.Mutex // una entrada a la vez por sesión
+	session  *Session
+	lastUsed time.Time
+}
+
+func NewStore(ttl time.Duration) *Store {
+	return &Store{TTL: ttl, sessions: make(map[string]*entry)}
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// expire descarta las sesiones vencidas; se llama con mu tomado.
+func (st *Store) expire(now time.Time) {
+	for id, e := range st.sessions {
+		if now.Sub(e.lastUsed) > st.TTL {
+			delete(st.sessions, id)
+		}
+	}
+}
+
+func (st *Store) get(id string) *entry {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+	st.expire(now)
+	e, ok := st.sessions[id]
+	if !ok {
+		return nil
+	}
+	e.lastUsed = now
+	return e
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// Create abre una sesión nueva y responde {"id": ...}.
+func (st *Store) Create(w http.ResponseWriter, r *http.Request) {
+	st.mu.Lock()
+	now := time.Now()
+	st.expire(now)
+	id := newID()
+	st.sessions[id] = &entry{session: NewSession(), lastUsed: now}
+	st.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, map[string]string{"id": id})
+}
+
+// Eval recibe {"input": "..."} y responde con el Result de la sesión. input
+// puede tener varias líneas; se procesan en orden y la respuesta junta la
+// salida y los errores de todas.
+func (st *Store) Eval(w http.ResponseWriter, r *http.Request) {
+	e := st.get(mux.Vars(r)["id"])
+	if e == nil {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	var request struct {
+		Input string `json:"input"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	writeJSON(w, http.StatusOK, e.session.InputLines(request.Input))
+}
+
+// Delete cierra la sesión.
+func (st *Store) Delete(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	st.mu.Lock()
+	_, ok := st.sessions[id]
+	delete(st.sessions, id)
+	st.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
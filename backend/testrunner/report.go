@@ -0,0 +1,243 @@
+package testrunner
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formatos de reporte.
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatJUnit = "junit"
+)
+
+// Write escribe el reporte de las suites en el formato indicado.
+func Write(w io.Writer, format string, suites []*Suite) error {
+	switch format {
+	case FormatText:
+		return WriteText(w, suites)
+	case FormatJSON:
+		return WriteJSON(w, suites)
+	case FormatJUnit:
+		return WriteJUnit(w, suites)
+	}
+	return fmt.Errorf("formato de reporte desconocido %q, se esperaba %q, %q o %q", format, FormatText, FormatJSON, FormatJUnit)
+}
+
+// totals suma los resultados de todas las suites.
+type totals struct {
+	tests, passed, failed, errors int
+}
+
+func sum(suites []*Suite) totals {
+	var t totals
+	for _, s := range suites {
+		t.tests += len(s.Cases)
+		t.passed += s.Count(Passed)
+		t.failed += s.Count(Failed)
+		t.errors += s.Count(Errored)
+		if len(s.Errors) > 0 {
+			// el archivo que no compila cuenta como una prueba con error
+			t.tests++
+			t.errors++
+		}
+	}
+	return t
+}
+
+// === TEXTO ===
+
+var statusLabels = map[string]string{
+	Passed:  "ok   ",
+	Failed:  "FALLA",
+	Errored: "ERROR",
+}
+
+// WriteText escribe un reporte para leer en la terminal: una línea por
+// prueba y, debajo de las que no pasaron, sus errores con línea y columna.
+func WriteText(w io.Writer, suites []*Suite) error {
+	var b strings.Builder
+	for _, s := range suites {
+		fmt.Fprintf(&b, "%s\n", s.File)
+		for _, err := range s.Errors {
+			fmt.Fprintf(&b, "  %d:%d: %s: %s\n", err.Line, err.Column, err.GetDisplayName(), err.Msg)
+		}
+		if len(s.Errors) == 0 && len(s.Cases) == 0 {
+			fmt.Fprintf(&b, "  sin pruebas\n")
+		}
+		for _, c := range s.Cases {
+			fmt.Fprintf(&b, "  %s %s (línea %d, %.3fs)\n", statusLabels[c.Status], c.Name, c.Line, c.Duration.Seconds())
+			for _, f := range c.Failures {
+				fmt.Fprintf(&b, "        %d:%d: %s\n", f.Line, f.Column, f.Message)
+			}
+		}
+	}
+
+	t := sum(suites)
+	fmt.Fprintf(&b, "\n%d pruebas: %d pasaron, %d fallaron, %d con errores\n", t.tests, t.passed, t.failed, t.errors)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// === JSON ===
+
+type jsonCase struct {
+	*Case
+	Time float64 `json:"time"`
+}
+
+type jsonSuite struct {
+	*Suite
+	Cases []jsonCase `json:"cases"`
+	Time  float64    `json:"time"`
+}
+
+// WriteJSON escribe las suites y los totales como un objeto JSON. Los
+// tiempos van en segundos.
+func WriteJSON(w io.Writer, suites []*Suite) error {
+	t := sum(suites)
+	report := struct {
+		Tests  int         `json:"tests"`
+		Passed int         `json:"passed"`
+		Failed int         `json:"failed"`
+		Errors int         `json:"errors"`
+		Suites []jsonSuite `json:"suites"`
+	}{Tests: t.tests, Passed: t.passed, Failed: t.failed, Errors: t.errors, Suites: []jsonSuite{}}
+
+	for _, s := range suites {
+		js := jsonSuite{Suite: s, Cases: []jsonCase{}, Time: s.Duration.Seconds()}
+		for _, c := range s.Cases {
+			js.Cases = append(js.Cases, jsonCase{Case: c, Time: c.Duration.Seconds()})
+		}
+		report.Suites = append(report.Suites, js)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// === JUNIT ===
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Line      int           `xml:"line,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit escribe el reporte en el formato XML de JUnit que entienden los
+// servidores de integración continua y los correctores automáticos. Los
+// errores de compilación de un archivo aparecen como un caso con error
+// llamado "compilación".
+func WriteJUnit(w io.Writer, suites []*Suite) error {
+	t := sum(suites)
+	report := junitTestSuites{Tests: t.tests, Failures: t.failed, Errors: t.errors}
+
+	for _, s := range suites {
+		js := junitTestSuite{
+			Name:     s.File,
+			Tests:    len(s.Cases),
+			Failures: s.Count(Failed),
+			Errors:   s.Count(Errored),
+			Time:     fmt.Sprintf("%.3f", s.Duration.Seconds()),
+		}
+
+		if len(s.Errors) > 0 {
+			var lines []string
+			for _, err := range s.Errors {
+				lines = append(lines, fmt.Sprintf("%d:%d: %s: %s", err.Line, err.Column, err.GetDisplayName(), err.Msg))
+			}
+			js.Tests++
+			js.Errors++
+			js.Cases = append(js.Cases, junitTestCase{
+				Name:      "compilación",
+				Classname: s.File,
+				File:      s.File,
+				Line:      s.Errors[0].Line,
+				Time:      "0.000",
+				Error:     &junitProblem{Message: s.Errors[0].Msg, Type: s.Errors[0].Type, Text: strings.Join(lines, "\n")},
+			})
+		}
+
+		for _, c := range s.Cases {
+			tc := junitTestCase{
+				Name:      c.Name,
+				Classname: s.File,
+				File:      s.File,
+				Line:      c.Line,
+				Time:      fmt.Sprintf("%.3f", c.Duration.Seconds()),
+				SystemOut: c.Output,
+			}
+			if c.Status != Passed {
+				var lines []string
+				for _, f := range c.Failures {
+					lines = append(lines, fmt.Sprintf("%s:%d:%d: %s", s.File, f.Line, f.Column, f.Message))
+				}
+				problem := &junitProblem{Message: firstMessage(c), Text: strings.Join(lines, "\n")}
+				if c.Status == Failed {
+					problem.Type = "assertion"
+					tc.Failure = problem
+				} else {
+					problem.Type = "error"
+					tc.Error = problem
+				}
+			}
+			js.Cases = append(js.Cases, tc)
+		}
+		report.Suites = append(report.Suites, js)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// firstMessage es el mensaje que resume una prueba que no pasó: la primera
+// aserción fallida o, si terminó con error, el primer error del intérprete.
+func firstMessage(c *Case) string {
+	for _, f := range c.Failures {
+		if f.Assertion == (c.Status == Failed) {
+			return f.Message
+		}
+	}
+	return ""
+}
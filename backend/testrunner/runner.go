@@ -0,0 +1,176 @@
+// Package testrunner ejecuta las pruebas escritas en VLang. Una prueba es una
+// función global sin parámetros cuyo nombre empieza con "test_":
+//
+//	fn test_suma() {
+//	    assertEq(suma(2, 3), 5)
+//	}
+//
+// Cada prueba corre en un ReplVisitor propio: las declaraciones y sentencias
+// globales del archivo se vuelven a ejecutar antes de llamarla, así que lo que
+// una prueba cambia en una variable global no lo ve la siguiente.
+//
+// Una prueba pasa si termina sin errores, falla si una aserción (assert,
+// assertEq, assertNear) no se cumple y termina con error si el intérprete
+// reporta cualquier otro error. La primera aserción fallida detiene la
+// prueba: lo que sigue en su cuerpo no se ejecuta.
+package testrunner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"main.go/analysis"
+	"main.go/ast"
+	"main.go/repl"
+)
+
+// Prefix es el prefijo del nombre de las funciones de prueba.
+const Prefix = "test_"
+
+// Estados de una prueba.
+const (
+	Passed  = "pass"
+	Failed  = "fail"
+	Errored = "error"
+)
+
+// Failure es una aserción fallida o un error del intérprete durante la prueba.
+type Failure struct {
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	Message   string `json:"message"`
+	Assertion bool   `json:"assertion"` // false si es un error del intérprete
+}
+
+// Case es el resultado de una prueba.
+type Case struct {
+	Name     string        `json:"name"`
+	Line     int           `json:"line"`
+	Status   string        `json:"status"`
+	Failures []Failure     `json:"failures"`
+	Output   string        `json:"output"` // lo que imprimió la prueba
+	Duration time.Duration `json:"-"`
+}
+
+// Suite es el resultado de las pruebas de un archivo. Si el archivo tiene
+// errores léxicos, sintácticos o semánticos no se ejecuta ninguna prueba y
+// Errors los lista.
+type Suite struct {
+	File     string        `json:"file"`
+	Cases    []*Case       `json:"cases"`
+	Errors   []repl.Error  `json:"errors"`
+	Duration time.Duration `json:"-"`
+}
+
+// Count devuelve cuántas pruebas de la suite terminaron con status.
+func (s *Suite) Count(status string) int {
+	n := 0
+	for _, c := range s.Cases {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// OK indica si el archivo compiló y todas sus pruebas pasaron.
+func (s *Suite) OK() bool {
+	return len(s.Errors) == 0 && s.Count(Passed) == len(s.Cases)
+}
+
+// Discover devuelve las funciones de prueba del programa en orden de
+// aparición.
+func Discover(program *ast.Program) []*ast.FuncDecl {
+	var tests []*ast.FuncDecl
+	for _, stmt := range program.Stmts {
+		if decl, ok := stmt.(*ast.FuncDecl); ok && strings.HasPrefix(decl.Name.Name, Prefix) {
+			tests = append(tests, decl)
+		}
+	}
+	return tests
+}
+
+// Run analiza code y ejecuta sus pruebas. file solo se usa en el reporte.
+func Run(file, code string) *Suite {
+	start := time.Now()
+	suite := &Suite{File: file, Cases: []*Case{}, Errors: []repl.Error{}}
+	defer func() { suite.Duration = time.Since(start) }()
+
+	analyzed := analysis.Analyze(code)
+	if analyzed.ErrorTable.HasErrors() {
+		for _, err := range analyzed.ErrorTable.Errors {
+			if err.Severity != "warning" {
+				suite.Errors = append(suite.Errors, err)
+			}
+		}
+		return suite
+	}
+
+	for _, decl := range Discover(analyzed.Program) {
+		suite.Cases = append(suite.Cases, runCase(analyzed.Program, decl))
+	}
+	return suite
+}
+
+// runCase ejecuta una prueba en un intérprete nuevo.
+func runCase(program *ast.Program, decl *ast.FuncDecl) (c *Case) {
+	c = &Case{Name: decl.Name.Name, Line: decl.Name.Start.Line, Failures: []Failure{}}
+	if len(decl.Params) > 0 {
+		c.Status = Errored
+		c.Failures = append(c.Failures, Failure{
+			Line:    decl.Name.Start.Line,
+			Column:  decl.Name.Start.Column,
+			Message: fmt.Sprintf("La prueba %s no puede recibir parámetros", c.Name),
+		})
+		return c
+	}
+
+	start := time.Now()
+	errorTable := repl.NewErrorTable()
+	dclVisitor := repl.NewDclVisitor(errorTable)
+	visitor := repl.NewVisitor(dclVisitor)
+	visitor.StopOnAssertion = true
+	printed := 0
+
+	defer func() {
+		r := recover()
+		if limit, ok := r.(*repl.LimitExceeded); ok {
+			errorTable.NewRuntimeError(limit.Line, limit.Column, limit.Msg)
+		} else if _, ok := r.(*repl.AssertionStop); ok {
+			// la aserción fallida ya está en la tabla de errores
+		} else if r != nil {
+			errorTable.NewRuntimeError(c.Line, 0, fmt.Sprintf("Error interno del intérprete: %v", r))
+		}
+		c.Duration = time.Since(start)
+		c.Output = visitor.Console.GetOutput()[printed:]
+		c.Status = Passed
+		for _, err := range errorTable.Errors {
+			if err.Severity == "warning" {
+				continue
+			}
+			assertion := strings.HasPrefix(err.Msg, repl.AssertionFailed)
+			c.Failures = append(c.Failures, Failure{Line: err.Line, Column: err.Column, Message: err.Msg, Assertion: assertion})
+			if !assertion {
+				c.Status = Errored
+			} else if c.Status == Passed {
+				c.Status = Failed
+			}
+		}
+	}()
+
+	// las declaraciones y sentencias globales preparan el estado de la prueba
+	dclVisitor.VisitProgram(program)
+	visitor.StructNames = dclVisitor.StructNames
+	visitor.VisitProgram(program)
+	printed = len(visitor.Console.GetOutput())
+
+	fn, msg := visitor.ScopeTrace.GetFunction(c.Name)
+	function, ok := fn.(*repl.Function)
+	if !ok {
+		errorTable.NewRuntimeError(c.Line, 0, msg)
+		return c
+	}
+	function.Exec(visitor, []*repl.Argument{}, function.Pos)
+	return c
+}
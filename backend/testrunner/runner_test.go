@@ -0,0 +1,198 @@
+package testrunner_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"main.go/testrunner"
+)
+
+const source = `mut contador = 0
+
+fn suma(a int, b int) int {
+    return a + b
+}
+
+fn test_pasa() {
+    contador += 1
+    assertEq(suma(2, 3), 5)
+    assertEq(contador, 1)
+}
+
+fn test_falla() {
+    println("antes")
+    assertEq(suma(2, 2), 5)
+    println("despues")
+    assertEq(suma(1, 1), 3)
+}
+
+fn test_falla_en_ciclo() {
+    mut i = 0
+    for i = 0; i < 3; i++ {
+        assert(i < 1)
+    }
+    println("despues")
+}
+
+fn test_error() {
+    mut v []int = {1}
+    mut i = 3
+    println(v[i])
+}
+
+fn test_con_parametros(n int) {
+    assertEq(n, n)
+}
+
+fn auxiliar() {
+    assert(false)
+}
+`
+
+// caseByName devuelve el resultado de la prueba name.
+func caseByName(t *testing.T, suite *testrunner.Suite, name string) *testrunner.Case {
+	t.Helper()
+	for _, c := range suite.Cases {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("no se ejecutó la prueba %s", name)
+	return nil
+}
+
+// Cada prueba termina con su estado, y la primera aserción fallida detiene
+// el cuerpo de la prueba.
+func TestRun(t *testing.T) {
+	suite := testrunner.Run("pruebas.vch", source)
+	if len(suite.Errors) > 0 {
+		t.Fatalf("errores de análisis: %+v", suite.Errors)
+	}
+	if len(suite.Cases) != 5 {
+		t.Fatalf("se esperaban 5 pruebas, se obtuvieron %d", len(suite.Cases))
+	}
+
+	tests := []struct {
+		name      string
+		status    string
+		failures  int
+		assertion bool
+		output    string // fragmento esperado en la salida
+		notOutput string // fragmento que no debe aparecer en la salida
+	}{
+		{"test_pasa", testrunner.Passed, 0, false, "", ""},
+		{"test_falla", testrunner.Failed, 1, true, "antes", "despues"},
+		{"test_falla_en_ciclo", testrunner.Failed, 1, true, "", "despues"},
+		{"test_error", testrunner.Errored, 1, false, "", ""},
+		{"test_con_parametros", testrunner.Errored, 1, false, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := caseByName(t, suite, tt.name)
+			if c.Status != tt.status {
+				t.Errorf("estado %q, se esperaba %q; fallas: %+v", c.Status, tt.status, c.Failures)
+			}
+			if len(c.Failures) != tt.failures {
+				t.Fatalf("%d fallas, se esperaban %d: %+v", len(c.Failures), tt.failures, c.Failures)
+			}
+			if tt.failures > 0 && c.Failures[0].Assertion != tt.assertion {
+				t.Errorf("Assertion = %v, se esperaba %v: %+v", c.Failures[0].Assertion, tt.assertion, c.Failures[0])
+			}
+			if !strings.Contains(c.Output, tt.output) {
+				t.Errorf("la salida %q no contiene %q", c.Output, tt.output)
+			}
+			if tt.notOutput != "" && strings.Contains(c.Output, tt.notOutput) {
+				t.Errorf("la salida %q no debería contener %q", c.Output, tt.notOutput)
+			}
+		})
+	}
+
+	if c := caseByName(t, suite, "test_falla"); c.Failures[0].Line != 15 {
+		t.Errorf("la falla debería estar en la línea 15: %+v", c.Failures[0])
+	}
+	if suite.Count(testrunner.Passed) != 1 || suite.Count(testrunner.Failed) != 2 || suite.Count(testrunner.Errored) != 2 {
+		t.Errorf("conteos: %d pasaron, %d fallaron, %d con errores",
+			suite.Count(testrunner.Passed), suite.Count(testrunner.Failed), suite.Count(testrunner.Errored))
+	}
+	if suite.OK() {
+		t.Error("la suite no debería estar OK")
+	}
+}
+
+// Las variables globales se reinician antes de cada prueba.
+func TestGlobalsAreReset(t *testing.T) {
+	code := "mut n = 0\nfn test_uno() {\n    n += 1\n    assertEq(n, 1)\n}\nfn test_dos() {\n    n += 1\n    assertEq(n, 1)\n}\n"
+	suite := testrunner.Run("globales.vch", code)
+	if !suite.OK() {
+		t.Fatalf("las pruebas deberían pasar: %+v", suite.Cases)
+	}
+}
+
+// Un archivo con errores no ejecuta pruebas.
+func TestAnalysisErrors(t *testing.T) {
+	suite := testrunner.Run("roto.vch", "fn test_x() {\n    println(y)\n}\n")
+	if len(suite.Errors) == 0 || len(suite.Cases) != 0 || suite.OK() {
+		t.Errorf("errores %+v, pruebas %+v", suite.Errors, suite.Cases)
+	}
+}
+
+// El reporte de texto lista cada prueba con su estado y termina con el
+// resumen; el JSON y el JUnit llevan los mismos totales.
+func TestReport(t *testing.T) {
+	suites := []*testrunner.Suite{
+		testrunner.Run("pruebas.vch", source),
+		testrunner.Run("roto.vch", "mut = 5\n"),
+		testrunner.Run("vacio.vch", "mut x = 1\n"),
+	}
+
+	var text bytes.Buffer
+	if err := testrunner.Write(&text, testrunner.FormatText, suites); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"pruebas.vch\n",
+		"  ok    test_pasa (línea 7,",
+		"  FALLA test_falla (línea 13,",
+		"        15:4: Aserción fallida",
+		"  ERROR test_error (línea 28,",
+		"roto.vch\n",
+		"vacio.vch\n  sin pruebas\n",
+		"\n6 pruebas: 1 pasaron, 2 fallaron, 3 con errores\n",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("el reporte no contiene %q:\n%s", want, text.String())
+		}
+	}
+	if strings.Count(text.String(), "Aserción fallida") != 2 {
+		t.Errorf("se esperaban dos aserciones fallidas, una por prueba:\n%s", text.String())
+	}
+
+	var out bytes.Buffer
+	if err := testrunner.Write(&out, testrunner.FormatJSON, suites); err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Tests, Passed, Failed, Errors int
+		Suites                        []json.RawMessage
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("JSON inválido: %v\n%s", err, out.String())
+	}
+	if report.Tests != 6 || report.Passed != 1 || report.Failed != 2 || report.Errors != 3 || len(report.Suites) != 3 {
+		t.Errorf("totales del JSON: %+v", report)
+	}
+
+	out.Reset()
+	if err := testrunner.Write(&out, testrunner.FormatJUnit, suites); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `tests="6"`) || !strings.Contains(out.String(), `failures="2"`) {
+		t.Errorf("totales del JUnit:\n%s", out.String())
+	}
+
+	if err := testrunner.Write(&out, "tap", suites); err == nil {
+		t.Error("se esperaba un error por el formato desconocido")
+	}
+}
@@ -0,0 +1,781 @@
+package value
+
+type evalFunc func(IVOR, IVOR) (bool, string, IVOR) // toma dos valores IVOR y devuelve un booleano, un mensaje y un valor IVOR
+type conversionFunc func(IVOR) IVOR                 // toma un valor IVOR y devuelve un valor IVOR convertido
+
+type BinaryValidation struct {
+	LeftType        string         // permite valores de tipo izquierdo
+	RightType       string         // permite valores de tipo derecho
+	LeftConversion  conversionFunc // función de conversión para el valor izquierdo
+	RightConversion conversionFunc // función de conversión para el valor derecho
+	Eval            evalFunc       // función de evaluación que toma los dos valores y devuelve un booleano, un mensaje y un valor IVOR
+}
+
+type BinaryStrategy struct {
+	Name        string
+	Validations []BinaryValidation
+	Viceversa   bool // if true, the validation is also performed in the opposite order
+	DefaultEval evalFunc
+}
+
+func (s *BinaryStrategy) Validate(left, right IVOR) (bool, string, IVOR) {
+
+	// nil in any side is, by default return nil
+
+	if left.Type() == IVOR_NIL || right.Type() == IVOR_NIL {
+		return false, "No es posible realizar operaciones con valores nulos", DefaultNilValue
+	}
+
+	for _, valid := range s.Validations {
+
+		if valid.LeftType == left.Type() && valid.RightType == right.Type() {
+
+			if valid.LeftConversion != nil {
+				left = valid.LeftConversion(left)
+			}
+
+			if valid.RightConversion != nil {
+				right = valid.RightConversion(right)
+			}
+
+			if valid.Eval != nil {
+				return valid.Eval(left, right)
+			}
+
+			return s.DefaultEval(left, right)
+		}
+
+		if s.Viceversa && valid.LeftType == right.Type() && valid.RightType == left.Type() {
+
+			if valid.LeftConversion != nil {
+				right = valid.LeftConversion(right)
+			}
+
+			if valid.RightConversion != nil {
+				left = valid.RightConversion(left)
+			}
+
+			if valid.Eval != nil {
+				return valid.Eval(left, right)
+			}
+
+			return s.DefaultEval(left, right)
+		}
+
+	}
+
+	msg := "No es posible realizar la operación '" + s.Name + "' con los tipos '" + left.Type() + "' y '" + right.Type() + "'"
+
+	return false, msg, DefaultNilValue
+}
+
+// * arithmetic operators
+
+// int + int; float + float; float + int (viceversa); string + string
+var addStrategy = BinaryStrategy{
+	Name:        "+",
+	Viceversa:   true,
+	DefaultEval: nil,
+	Validations: []BinaryValidation{
+		{
+			LeftType:        IVOR_INT,
+			RightType:       IVOR_INT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &IntValue{
+					InternalValue: left.(*IntValue).InternalValue + right.(*IntValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_FLOAT,
+			RightType:       IVOR_FLOAT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &FloatValue{
+					InternalValue: left.(*FloatValue).InternalValue + right.(*FloatValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:       IVOR_FLOAT,
+			RightType:      IVOR_INT,
+			LeftConversion: nil,
+			RightConversion: func(v IVOR) IVOR {
+				return &FloatValue{
+					InternalValue: float64(v.(*IntValue).InternalValue),
+				}
+			},
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &FloatValue{
+					InternalValue: left.(*FloatValue).InternalValue + right.(*FloatValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_STRING,
+			RightType:       IVOR_STRING,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &StringValue{
+					InternalValue: left.(*StringValue).InternalValue + right.(*StringValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:  IVOR_CHARACTER,
+			RightType: IVOR_STRING,
+			LeftConversion: func(v IVOR) IVOR {
+				return &StringValue{
+					InternalValue: string(v.(*CharacterValue).InternalValue),
+				}
+			},
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &StringValue{
+					InternalValue: left.(*StringValue).InternalValue + right.(*StringValue).InternalValue,
+				}
+			},
+		},
+	},
+}
+
+// int - int; float - float; float - int (viceversa)
+var subStrategy = BinaryStrategy{
+	Name:        "-",
+	Viceversa:   true,
+	DefaultEval: nil,
+	Validations: []BinaryValidation{
+		{
+			LeftType:        IVOR_INT,
+			RightType:       IVOR_INT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &IntValue{
+					InternalValue: left.(*IntValue).InternalValue - right.(*IntValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_FLOAT,
+			RightType:       IVOR_FLOAT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &FloatValue{
+					InternalValue: left.(*FloatValue).InternalValue - right.(*FloatValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:       IVOR_FLOAT,
+			RightType:      IVOR_INT,
+			LeftConversion: nil,
+			RightConversion: func(v IVOR) IVOR {
+				return &FloatValue{
+					InternalValue: float64(v.(*IntValue).InternalValue),
+				}
+			},
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &FloatValue{
+					InternalValue: left.(*FloatValue).InternalValue - right.(*FloatValue).InternalValue,
+				}
+			},
+		},
+	},
+}
+
+// int * int; float * float; float * int (viceversa)
+var mulStrategy = BinaryStrategy{
+	Name:        "*",
+	Viceversa:   true,
+	DefaultEval: nil,
+	Validations: []BinaryValidation{
+		{
+			LeftType:        IVOR_INT,
+			RightType:       IVOR_INT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &IntValue{
+					InternalValue: left.(*IntValue).InternalValue * right.(*IntValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_FLOAT,
+			RightType:       IVOR_FLOAT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &FloatValue{
+					InternalValue: left.(*FloatValue).InternalValue * right.(*FloatValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:       IVOR_FLOAT,
+			RightType:      IVOR_INT,
+			LeftConversion: nil,
+			RightConversion: func(v IVOR) IVOR {
+				return &FloatValue{
+					InternalValue: float64(v.(*IntValue).InternalValue),
+				}
+			},
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &FloatValue{
+					InternalValue: left.(*FloatValue).InternalValue * right.(*FloatValue).InternalValue,
+				}
+			},
+		},
+	},
+}
+
+// int / int; float / float; float / int (viceversa) !division by zero
+var divStrategy = BinaryStrategy{
+	Name:        "/",
+	Viceversa:   true,
+	DefaultEval: nil,
+	Validations: []BinaryValidation{
+		{
+			LeftType:        IVOR_INT,
+			RightType:       IVOR_INT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+
+				if right.(*IntValue).InternalValue == 0 {
+					return false, "No se puede dividir entre cero", DefaultNilValue
+				}
+
+				return true, "", &IntValue{
+					InternalValue: left.(*IntValue).InternalValue / right.(*IntValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_FLOAT,
+			RightType:       IVOR_FLOAT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+
+				if right.(*FloatValue).InternalValue == 0 {
+					return false, "No se puede dividir entre cero", DefaultNilValue
+				}
+
+				return true, "", &FloatValue{
+					InternalValue: left.(*FloatValue).InternalValue / right.(*FloatValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:       IVOR_FLOAT,
+			RightType:      IVOR_INT,
+			LeftConversion: nil,
+			RightConversion: func(v IVOR) IVOR {
+				return &FloatValue{
+					InternalValue: float64(v.(*IntValue).InternalValue),
+				}
+			},
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+
+				if right.(*FloatValue).InternalValue == 0 {
+					return false, "No se puede dividir entre cero", DefaultNilValue
+				}
+
+				return true, "", &FloatValue{
+					InternalValue: left.(*FloatValue).InternalValue / right.(*FloatValue).InternalValue,
+				}
+			},
+		},
+	},
+}
+
+// int % int; !division by zero
+var modStrategy = BinaryStrategy{
+	Name:        "%",
+	Viceversa:   true,
+	DefaultEval: nil,
+	Validations: []BinaryValidation{
+		{
+			LeftType:        IVOR_INT,
+			RightType:       IVOR_INT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+
+				if right.(*IntValue).InternalValue == 0 {
+					return false, "No se puede dividir entre cero", DefaultNilValue
+				}
+
+				return true, "", &IntValue{
+					InternalValue: left.(*IntValue).InternalValue % right.(*IntValue).InternalValue,
+				}
+			},
+		},
+	},
+}
+
+// * comparison operators
+
+// int == int; float == float; bool == bool; string == string; char == char
+func sameTypeStrat(name string, eval evalFunc) BinaryStrategy {
+	return BinaryStrategy{
+		Name:        name,
+		Viceversa:   true,
+		DefaultEval: eval,
+		Validations: []BinaryValidation{
+			{
+				LeftType:        IVOR_INT,
+				RightType:       IVOR_INT,
+				LeftConversion:  nil,
+				RightConversion: nil,
+				Eval:            nil,
+			},
+			{
+				LeftType:        IVOR_FLOAT,
+				RightType:       IVOR_FLOAT,
+				LeftConversion:  nil,
+				RightConversion: nil,
+				Eval:            nil,
+			},
+			{
+				LeftType:        IVOR_BOOL,
+				RightType:       IVOR_BOOL,
+				LeftConversion:  nil,
+				RightConversion: nil,
+				Eval:            nil,
+			},
+			{
+				LeftType:        IVOR_STRING,
+				RightType:       IVOR_STRING,
+				LeftConversion:  nil,
+				RightConversion: nil,
+				Eval:            nil,
+			},
+			{
+				LeftType:        IVOR_CHARACTER,
+				RightType:       IVOR_CHARACTER,
+				LeftConversion:  nil,
+				RightConversion: nil,
+				Eval:            nil,
+			},
+		},
+	}
+}
+
+var eqStrategy = sameTypeStrat("==", func(left, right IVOR) (bool, string, IVOR) {
+	return true, "", &BoolValue{
+		InternalValue: left.Value() == right.Value(),
+	}
+})
+
+var notEqStrategy = sameTypeStrat("!=", func(left, right IVOR) (bool, string, IVOR) {
+	return true, "", &BoolValue{
+		InternalValue: left.Value() != right.Value(),
+	}
+})
+
+var lessThanStrategy = BinaryStrategy{
+	Name:        "<",
+	Viceversa:   true,
+	DefaultEval: nil,
+	Validations: []BinaryValidation{
+		{
+			LeftType:        IVOR_INT,
+			RightType:       IVOR_INT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*IntValue).InternalValue < right.(*IntValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_FLOAT,
+			RightType:       IVOR_FLOAT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*FloatValue).InternalValue < right.(*FloatValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_STRING,
+			RightType:       IVOR_STRING,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*StringValue).InternalValue < right.(*StringValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_CHARACTER,
+			RightType:       IVOR_CHARACTER,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*CharacterValue).InternalValue < right.(*CharacterValue).InternalValue,
+				}
+			},
+		},
+	},
+}
+
+var lessOrEqStrategy = BinaryStrategy{
+	Name:        "<=",
+	Viceversa:   true,
+	DefaultEval: nil,
+	Validations: []BinaryValidation{
+		{
+			LeftType:        IVOR_INT,
+			RightType:       IVOR_INT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*IntValue).InternalValue <= right.(*IntValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_FLOAT,
+			RightType:       IVOR_FLOAT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*FloatValue).InternalValue <= right.(*FloatValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_STRING,
+			RightType:       IVOR_STRING,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*StringValue).InternalValue <= right.(*StringValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_CHARACTER,
+			RightType:       IVOR_CHARACTER,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*CharacterValue).InternalValue <= right.(*CharacterValue).InternalValue,
+				}
+			},
+		},
+	},
+}
+
+var greaterThanStrategy = BinaryStrategy{
+	Name:        ">",
+	Viceversa:   true,
+	DefaultEval: nil,
+	Validations: []BinaryValidation{
+		{
+			LeftType:        IVOR_INT,
+			RightType:       IVOR_INT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*IntValue).InternalValue > right.(*IntValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_FLOAT,
+			RightType:       IVOR_FLOAT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*FloatValue).InternalValue > right.(*FloatValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_STRING,
+			RightType:       IVOR_STRING,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*StringValue).InternalValue > right.(*StringValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_CHARACTER,
+			RightType:       IVOR_CHARACTER,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*CharacterValue).InternalValue > right.(*CharacterValue).InternalValue,
+				}
+			},
+		},
+	},
+}
+
+var greaterOrEqStrategy = BinaryStrategy{
+	Name:        ">=",
+	Viceversa:   true,
+	DefaultEval: nil,
+	Validations: []BinaryValidation{
+		{
+			LeftType:        IVOR_INT,
+			RightType:       IVOR_INT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*IntValue).InternalValue >= right.(*IntValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_FLOAT,
+			RightType:       IVOR_FLOAT,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*FloatValue).InternalValue >= right.(*FloatValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_STRING,
+			RightType:       IVOR_STRING,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*StringValue).InternalValue >= right.(*StringValue).InternalValue,
+				}
+			},
+		},
+		{
+			LeftType:        IVOR_CHARACTER,
+			RightType:       IVOR_CHARACTER,
+			LeftConversion:  nil,
+			RightConversion: nil,
+			Eval: func(left, right IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: left.(*CharacterValue).InternalValue >= right.(*CharacterValue).InternalValue,
+				}
+			},
+		},
+	},
+}
+
+// * logical operators
+
+func genericBinaryLogicalStrat(name string, eval evalFunc) BinaryStrategy {
+
+	return BinaryStrategy{
+		Name:        name,
+		Viceversa:   true,
+		DefaultEval: eval,
+		Validations: []BinaryValidation{
+			{
+				LeftType:        IVOR_BOOL,
+				RightType:       IVOR_BOOL,
+				LeftConversion:  nil,
+				RightConversion: nil,
+				Eval:            nil,
+			},
+		},
+	}
+}
+
+var andStrategy = genericBinaryLogicalStrat("&&", func(left, right IVOR) (bool, string, IVOR) {
+	return true, "", &BoolValue{
+		InternalValue: left.(*BoolValue).InternalValue && right.(*BoolValue).InternalValue,
+	}
+})
+
+var orStrategy = genericBinaryLogicalStrat("||", func(left, right IVOR) (bool, string, IVOR) {
+	return true, "", &BoolValue{
+		InternalValue: left.(*BoolValue).InternalValue || right.(*BoolValue).InternalValue,
+	}
+})
+
+var BinaryStrats = map[string]BinaryStrategy{
+	"+":  addStrategy,
+	"-":  subStrategy,
+	"*":  mulStrategy,
+	"/":  divStrategy,
+	"%":  modStrategy,
+	"==": eqStrategy,
+	"!=": notEqStrategy,
+	"<":  lessThanStrategy,
+	"<=": lessOrEqStrategy,
+	">":  greaterThanStrategy,
+	">=": greaterOrEqStrategy,
+	"&&": andStrategy,
+	"||": orStrategy,
+}
+
+// UnaryStrats
+
+type UnaryValidation struct {
+	Type       string // allowed type
+	Conversion conversionFunc
+	Eval       evalFunc
+}
+
+type UnaryStrategy struct {
+	Name        string
+	Validations []UnaryValidation
+	DefaultEval evalFunc
+}
+
+func (s *UnaryStrategy) Validate(val IVOR) (bool, string, IVOR) {
+
+	if val.Type() == IVOR_NIL {
+		return false, "No es posible realizar operaciones con valores nulos", DefaultNilValue
+	}
+
+	for _, valid := range s.Validations {
+
+		if valid.Type == val.Type() {
+
+			if valid.Conversion != nil {
+				val = valid.Conversion(val)
+			}
+
+			if valid.Eval != nil {
+				return valid.Eval(val, nil)
+			}
+
+			return s.DefaultEval(val, nil)
+		}
+
+	}
+
+	msg := "No es posible realizar la operación '" + s.Name + "' con el tipo '" + val.Type() + "'"
+
+	return false, msg, DefaultNilValue
+}
+
+// * Not
+
+var notStrategy = UnaryStrategy{
+	Name:        "!",
+	DefaultEval: nil,
+	Validations: []UnaryValidation{
+		{
+			Type:       IVOR_BOOL,
+			Conversion: nil,
+			Eval: func(i1, i2 IVOR) (bool, string, IVOR) {
+				return true, "", &BoolValue{
+					InternalValue: !i1.(*BoolValue).InternalValue,
+				}
+			},
+		},
+	},
+}
+
+// * Minus
+
+var minusStrategy = UnaryStrategy{
+	Name:        "-",
+	DefaultEval: nil,
+	Validations: []UnaryValidation{
+		{
+			Type:       IVOR_INT,
+			Conversion: nil,
+			Eval: func(i1, i2 IVOR) (bool, string, IVOR) {
+				return true, "", &IntValue{
+					InternalValue: -i1.(*IntValue).InternalValue,
+				}
+			},
+		},
+		{
+			Type:       IVOR_FLOAT,
+			Conversion: nil,
+			Eval: func(i1, i2 IVOR) (bool, string, IVOR) {
+				return true, "", &FloatValue{
+					InternalValue: -i1.(*FloatValue).InternalValue,
+				}
+			},
+		},
+	},
+}
+
+var UnaryStrats = map[string]UnaryStrategy{
+	"!": notStrategy,
+	"-": minusStrategy,
+}
+
+// Early return strats
+
+// * And
+
+var andEarlyReturnStrategy = UnaryStrategy{
+	Name: "&&",
+	Validations: []UnaryValidation{
+		{
+			Type:       IVOR_BOOL,
+			Conversion: nil,
+			Eval: func(i1, i2 IVOR) (bool, string, IVOR) {
+
+				if !i1.(*BoolValue).InternalValue {
+					return true, "", &BoolValue{
+						InternalValue: false,
+					}
+				}
+
+				return false, "", nil
+			},
+		},
+	},
+}
+
+// * Or
+
+var orEarlyReturnStrategy = UnaryStrategy{
+	Name: "||",
+	Validations: []UnaryValidation{
+		{
+			Type:       IVOR_BOOL,
+			Conversion: nil,
+			Eval: func(i1, i2 IVOR) (bool, string, IVOR) {
+
+				if i1.(*BoolValue).InternalValue {
+					return true, "", &BoolValue{
+						InternalValue: true,
+					}
+				}
+
+				return false, "", nil
+			},
+		},
+	},
+}
+
+var EarlyReturnStrats = map[string]UnaryStrategy{
+	"&&": andEarlyReturnStrategy,
+	"||": orEarlyReturnStrategy,
+}
@@ -0,0 +1,983 @@
+package vm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"main.go/ast"
+	"main.go/repl"
+	"main.go/value"
+)
+
+// Patrones de interpolación, en el mismo orden en que los aplica el intérprete
+var interpolationPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_]*)\}`), // ${variable}
+	regexp.MustCompile(`\$([a-zA-Z_][a-zA-Z0-9_]*)`),     // $variable
+}
+
+// UnsupportedError indica que el programa usa una construcción que la VM no
+// compila (structs, while, repeating, ...). Quien llama debe ejecutar el
+// programa con el intérprete y avisar por qué.
+type UnsupportedError struct {
+	Construct string
+	Line      int
+	Column    int
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("construcción no soportada por la VM: %s (línea %d, columna %d)", e.Construct, e.Line, e.Column)
+}
+
+// scope es un ámbito de compilación. El ámbito global no reserva slots: sus
+// variables se resuelven por nombre a la tabla de globales.
+type scope struct {
+	names   map[string]int
+	global  bool
+	start   int
+	clearAt int
+}
+
+// label registra los saltos pendientes de un ciclo o un switch.
+type label struct {
+	isLoop    bool
+	breaks    []int
+	continues []int
+}
+
+// Compiler traduce el árbol tipado a bytecode.
+type Compiler struct {
+	program     *Program
+	globalScope *repl.BaseScopeTrace
+
+	chunk      *Chunk
+	scopes     []*scope
+	labels     []*label
+	inFunction bool
+
+	globalSlots   map[string]int
+	functionIndex map[*repl.Function]int
+	pending       []*FunctionProto
+	nameIndex     map[string]int
+	binaryIndex   map[string]int
+	unaryIndex    map[string]int
+	earlyIndex    map[string]int
+	builtinIndex  map[string]int
+}
+
+// Compile compila el programa usando las funciones registradas por el
+// DclVisitor. Retorna un *UnsupportedError si el programa usa algo que la VM
+// no implementa.
+func Compile(program *ast.Program, dclVisitor *repl.DclVisitor) (compiled *Program, err error) {
+
+	c := &Compiler{
+		program:       &Program{},
+		globalScope:   dclVisitor.ScopeTrace.GlobalScope,
+		globalSlots:   make(map[string]int),
+		functionIndex: make(map[*repl.Function]int),
+		nameIndex:     make(map[string]int),
+		binaryIndex:   make(map[string]int),
+		unaryIndex:    make(map[string]int),
+		earlyIndex:    make(map[string]int),
+		builtinIndex:  make(map[string]int),
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			unsupported, ok := r.(*UnsupportedError)
+			if !ok {
+				panic(r)
+			}
+			compiled = nil
+			err = unsupported
+		}
+	}()
+
+	// chunk principal
+	c.chunk = &Chunk{Name: "main"}
+	c.scopes = []*scope{{names: make(map[string]int), global: true}}
+
+	for _, stmt := range program.Stmts {
+		c.stmt(stmt)
+	}
+	c.emit(OpReturnNil, 0, 0, 0, program.Span.End)
+	c.program.Main = c.chunk
+
+	// funciones alcanzables desde el programa
+	for len(c.pending) > 0 {
+		proto := c.pending[0]
+		c.pending = c.pending[1:]
+		c.compileFunction(proto)
+	}
+
+	return c.program, nil
+}
+
+func unsupported(node ast.Node, construct string) {
+	start := node.GetSpan().Start
+	panic(&UnsupportedError{Construct: construct, Line: start.Line, Column: start.Column})
+}
+
+// * Emisión
+
+func (c *Compiler) emit(op Opcode, a, b, cc int, pos ast.Pos) int {
+	return c.chunk.emit(op, a, b, cc, pos)
+}
+
+func (c *Compiler) here() int {
+	return len(c.chunk.Code)
+}
+
+// patch apunta el operando de salto de la instrucción pos a la posición actual
+func (c *Compiler) patch(pos int) {
+	c.patchTo(pos, c.here())
+}
+
+func (c *Compiler) patchTo(pos int, target int) {
+	ins := &c.chunk.Code[pos]
+	switch ins.Op {
+	case OpJump, OpJumpIfFalse:
+		ins.A = target
+	case OpMethod:
+		ins.C = target
+	default:
+		ins.B = target
+	}
+}
+
+func (c *Compiler) name(s string) int {
+	if idx, ok := c.nameIndex[s]; ok {
+		return idx
+	}
+	c.program.Names = append(c.program.Names, s)
+	c.nameIndex[s] = len(c.program.Names) - 1
+	return len(c.program.Names) - 1
+}
+
+func (c *Compiler) constant(v value.IVOR) int {
+	c.program.Constants = append(c.program.Constants, v)
+	return len(c.program.Constants) - 1
+}
+
+func (c *Compiler) errorAt(pos ast.Pos, msg string) {
+	c.emit(OpError, c.name(msg), 0, 0, pos)
+}
+
+func (c *Compiler) binary(op string) int {
+	if idx, ok := c.binaryIndex[op]; ok {
+		return idx
+	}
+	strat, ok := value.BinaryStrats[op]
+	if !ok {
+		panic(&UnsupportedError{Construct: "operador " + op})
+	}
+	c.program.Binary = append(c.program.Binary, &strat)
+	c.binaryIndex[op] = len(c.program.Binary) - 1
+	return len(c.program.Binary) - 1
+}
+
+func (c *Compiler) unary(op string) int {
+	if idx, ok := c.unaryIndex[op]; ok {
+		return idx
+	}
+	strat, ok := value.UnaryStrats[op]
+	if !ok {
+		panic(&UnsupportedError{Construct: "operador " + op})
+	}
+	c.program.Unary = append(c.program.Unary, &strat)
+	c.unaryIndex[op] = len(c.program.Unary) - 1
+	return len(c.program.Unary) - 1
+}
+
+func (c *Compiler) early(op string) (int, bool) {
+	if idx, ok := c.earlyIndex[op]; ok {
+		return idx, true
+	}
+	strat, ok := value.EarlyReturnStrats[op]
+	if !ok {
+		return 0, false
+	}
+	c.program.Early = append(c.program.Early, &strat)
+	c.earlyIndex[op] = len(c.program.Early) - 1
+	return len(c.program.Early) - 1, true
+}
+
+// * Ámbitos y variables
+
+func (c *Compiler) pushScope(pos ast.Pos) {
+	s := &scope{
+		names: make(map[string]int),
+		start: len(c.chunk.Locals),
+	}
+	s.clearAt = c.emit(OpClear, s.start, s.start, 0, pos)
+	c.scopes = append(c.scopes, s)
+}
+
+func (c *Compiler) popScope() {
+	s := c.scopes[len(c.scopes)-1]
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.chunk.Code[s.clearAt].B = len(c.chunk.Locals)
+}
+
+func (c *Compiler) newSlot(name string) int {
+	c.chunk.Locals = append(c.chunk.Locals, name)
+	return len(c.chunk.Locals) - 1
+}
+
+// hidden reserva un slot interno (valor del switch, iterable del for)
+func (c *Compiler) hidden() int {
+	return c.newSlot("")
+}
+
+func (c *Compiler) global(name string) int {
+	if slot, ok := c.globalSlots[name]; ok {
+		return globalRef(slot)
+	}
+	c.program.Globals = append(c.program.Globals, name)
+	c.globalSlots[name] = len(c.program.Globals) - 1
+	return globalRef(len(c.program.Globals) - 1)
+}
+
+// declare retorna la referencia para declarar name en el ámbito actual. Una
+// redeclaración en el mismo ámbito reutiliza el slot para que la VM reporte
+// "ya existe" igual que el intérprete.
+func (c *Compiler) declare(name string) int {
+	s := c.scopes[len(c.scopes)-1]
+
+	if s.global {
+		return c.global(name)
+	}
+
+	if ref, ok := s.names[name]; ok {
+		return ref
+	}
+
+	ref := localRef(c.newSlot(name))
+	s.names[name] = ref
+	return ref
+}
+
+// resolve busca name desde el ámbito más interno; los nombres que no son
+// locales se resuelven a la tabla de globales, que se consulta en ejecución.
+func (c *Compiler) resolve(name string) int {
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		if ref, ok := c.scopes[i].names[name]; ok && !c.scopes[i].global {
+			return ref
+		}
+	}
+	return c.global(name)
+}
+
+// visibleLocals retorna las variables locales visibles, para resolver
+// interpolaciones cuyo nombre solo se conoce en ejecución
+func (c *Compiler) visibleLocals() map[string]int {
+	refs := make(map[string]int)
+	for _, s := range c.scopes {
+		if s.global {
+			continue
+		}
+		for name, ref := range s.names {
+			refs[name] = ref
+		}
+	}
+	return refs
+}
+
+func (c *Compiler) atGlobalScope() bool {
+	return !c.inFunction && len(c.scopes) == 1
+}
+
+// * Funciones
+
+func (c *Compiler) function(f *repl.Function) int {
+	if idx, ok := c.functionIndex[f]; ok {
+		return idx
+	}
+
+	proto := &FunctionProto{Decl: f, Chunk: &Chunk{Name: "func: " + f.Name}}
+	c.program.Functions = append(c.program.Functions, proto)
+	c.functionIndex[f] = len(c.program.Functions) - 1
+	c.pending = append(c.pending, proto)
+
+	return len(c.program.Functions) - 1
+}
+
+func (c *Compiler) compileFunction(proto *FunctionProto) {
+	c.chunk = proto.Chunk
+	c.scopes = []*scope{{names: make(map[string]int), global: true}}
+	c.labels = nil
+	c.inFunction = true
+
+	// el ámbito de la función contiene los parámetros en los primeros slots
+	s := &scope{names: make(map[string]int)}
+	c.scopes = append(c.scopes, s)
+
+	for _, param := range proto.Decl.Param {
+		if _, exists := s.names[param.InnerName]; exists {
+			panic(&UnsupportedError{Construct: "parámetros repetidos en " + proto.Decl.Name})
+		}
+		s.names[param.InnerName] = localRef(c.newSlot(param.InnerName))
+	}
+
+	for _, stmt := range proto.Decl.Body {
+		c.stmt(stmt)
+	}
+
+	c.emit(OpReturnNil, 0, 0, 0, proto.Decl.Pos)
+}
+
+// * Sentencias
+
+func (c *Compiler) stmt(stmt ast.Stmt) {
+	start := stmt.GetSpan().Start
+
+	c.emit(OpStep, 0, 0, 0, start)
+
+	switch stmt := stmt.(type) {
+	case *ast.VarDecl:
+		c.varDecl(stmt)
+	case *ast.AssignStmt:
+		c.assignStmt(stmt)
+	case *ast.BlockStmt:
+		c.pushScope(start)
+		for _, inner := range stmt.Stmts {
+			c.stmt(inner)
+		}
+		c.popScope()
+	case *ast.ReturnStmt, *ast.BreakStmt, *ast.ContinueStmt:
+		c.transferStmt(stmt)
+	case *ast.ExprStmt:
+		call, ok := stmt.X.(*ast.CallExpr)
+		if !ok {
+			unsupported(stmt, "sentencia "+ast.Text(stmt))
+		}
+		if _, ok := ast.PathName(call.Fun); !ok {
+			unsupported(stmt, "sentencia "+ast.Text(stmt))
+		}
+		c.funcCall(call)
+		c.emit(OpPop, 0, 0, 0, start)
+	case *ast.FuncDecl:
+		// ya registrada por el DclVisitor
+		if !c.atGlobalScope() {
+			unsupported(stmt, "funciones anidadas")
+		}
+	case *ast.IfStmt:
+		c.ifStmt(stmt)
+	case *ast.SwitchStmt:
+		c.switchStmt(stmt)
+	case *ast.ForCondStmt:
+		c.forCond(stmt)
+	case *ast.ForClauseStmt:
+		c.forClause(stmt)
+	case *ast.ForRange:
+		c.forRange(stmt)
+	case *ast.StructDecl:
+		unsupported(stmt, "struct")
+	case *ast.WhileStmt:
+		unsupported(stmt, "while")
+	default:
+		unsupported(stmt, "sentencia "+ast.Text(stmt))
+	}
+}
+
+// resolveType replica la validación de tipos de ReplVisitor.VisitType
+func resolveType(_type string) (string, string) {
+
+	if value.IsPrimitiveType(_type) {
+		return _type, ""
+	}
+
+	if repl.IsVectorType(_type) {
+		internType := repl.RemoveBrackets(_type)
+		if value.IsPrimitiveType(internType) {
+			return _type, ""
+		}
+		return value.IVOR_NIL, "El tipo " + internType + " no es valido para un vector"
+	}
+
+	return value.IVOR_NIL, "Tipo " + _type + " no encontrado"
+}
+
+func (c *Compiler) typeOf(ref *ast.TypeRef) string {
+	_type, msg := resolveType(ref.Syntax())
+	if msg != "" {
+		c.errorAt(ref.Span.Start, msg)
+	}
+	return _type
+}
+
+func (c *Compiler) varDecl(decl *ast.VarDecl) {
+	start := decl.Span.Start
+
+	switch decl.Kind {
+	case ast.DeclMutTyped:
+		varType := c.typeOf(decl.TypeRef)
+		c.expr(decl.Value)
+		c.emit(OpDeclare, c.declare(decl.Name.Name), c.name(varType), 0, start)
+
+	case ast.DeclMutInferred:
+		c.expr(decl.Value)
+		c.emit(OpDeclare, c.declare(decl.Name.Name), -1, 0, start)
+
+	case ast.DeclMutZero:
+		varType := c.typeOf(decl.TypeRef)
+
+		if !repl.IsVectorType(varType) {
+			c.errorAt(start, "El tipo '"+varType+"' no es un tipo de vector válido")
+			return
+		}
+
+		itemType := repl.RemoveBrackets(varType)
+		if !value.IsPrimitiveType(itemType) {
+			c.errorAt(start, "El tipo de elemento '"+itemType+"' no es válido para un vector")
+			return
+		}
+
+		c.emit(OpDeclareEmpty, c.declare(decl.Name.Name), c.name(varType), 0, start)
+
+	case ast.DeclTyped:
+		// el intérprete tampoco ejecuta esta forma y la ignora
+
+	case ast.DeclVector:
+		c.expr(decl.Value)
+		c.emit(OpDeclareVector, c.declare(decl.Name.Name), c.name(decl.TypeRef.Syntax()), 0, start)
+
+	case ast.DeclMatrix:
+		c.expr(decl.Value)
+		c.emit(OpDeclareMatrix, c.declare(decl.Name.Name), c.name(decl.TypeRef.String()), 0, start)
+
+	default:
+		unsupported(decl, "declaración")
+	}
+}
+
+func (c *Compiler) assignStmt(stmt *ast.AssignStmt) {
+	start := stmt.Span.Start
+
+	if target, ok := stmt.Target.(*ast.IndexExpr); ok {
+		op := -1
+		if stmt.Op != "=" {
+			op = c.binary(string(stmt.Op[0]))
+		}
+
+		c.expr(stmt.Value)
+
+		ref, n, fails := c.vectItem(target)
+		c.emit(OpIndexSet, ref, n, op, start)
+		end := c.emit(OpJump, 0, 0, 0, start)
+
+		for _, fail := range fails {
+			c.patch(fail)
+		}
+		c.emit(OpPop, 0, 0, 0, start)
+		c.patch(end)
+		return
+	}
+
+	varName, ok := ast.PathName(stmt.Target)
+	if !ok {
+		unsupported(stmt, "asignación")
+	}
+
+	if stmt.Op == "=" {
+		c.expr(stmt.Value)
+
+		if strings.Contains(varName, ".") {
+			parts := strings.Split(varName, ".")
+			if len(parts) != 2 {
+				c.emit(OpPop, 0, 0, 0, start)
+				c.errorAt(start, "Asignación con acceso encadenado inválido: '"+varName+"'")
+				return
+			}
+			c.emit(OpAssignField, c.resolve(parts[0]), c.name(parts[1]), 0, start)
+			return
+		}
+
+		c.emit(OpAssign, c.resolve(varName), 0, 0, start)
+		return
+	}
+
+	if strings.Contains(varName, ".") {
+		unsupported(stmt, "asignación compuesta a propiedades")
+	}
+
+	ref := c.resolve(varName)
+	load := c.emit(OpCompoundLoad, ref, 0, 0, start)
+	c.expr(stmt.Value)
+	c.emit(OpCompoundStore, ref, c.binary(string(stmt.Op[0])), 0, start)
+	c.patch(load)
+}
+
+// vectItem emite la resolución de v[i] o m[i][j] y retorna la referencia, el
+// número de índices y los saltos de error pendientes
+func (c *Compiler) vectItem(item *ast.IndexExpr) (int, int, []int) {
+	start := item.Span.Start
+
+	var indexes []ast.Expr
+	var base ast.Expr = item
+	for {
+		index, ok := base.(*ast.IndexExpr)
+		if !ok {
+			break
+		}
+		indexes = append([]ast.Expr{index.Index}, indexes...)
+		base = index.X
+	}
+
+	varName, ok := ast.PathName(base)
+	if !ok || strings.Contains(varName, ".") {
+		unsupported(item, "acceso indexado a propiedades")
+	}
+
+	ref := c.resolve(varName)
+	fails := []int{c.emit(OpIndexBegin, ref, 0, 0, start)}
+
+	for k, expr := range indexes {
+		c.expr(expr)
+		fails = append(fails, c.emit(OpIndexInt, 0, 0, k, start))
+	}
+
+	return ref, len(indexes), fails
+}
+
+func (c *Compiler) transferStmt(stmt ast.Stmt) {
+	start := stmt.GetSpan().Start
+
+	switch stmt := stmt.(type) {
+	case *ast.ReturnStmt:
+		if !c.inFunction {
+			c.errorAt(start, "La sentencia return debe estar dentro de una funcion")
+			return
+		}
+
+		if stmt.Value != nil {
+			c.expr(stmt.Value)
+			c.emit(OpReturn, 0, 0, 0, start)
+			return
+		}
+
+		c.emit(OpReturnNil, 0, 0, 0, start)
+
+	case *ast.BreakStmt:
+		if len(c.labels) == 0 {
+			c.errorAt(start, "La sentencia break debe estar dentro de un ciclo o un switch")
+			return
+		}
+
+		target := c.labels[len(c.labels)-1]
+		target.breaks = append(target.breaks, c.emit(OpJump, 0, 0, 0, start))
+
+	case *ast.ContinueStmt:
+		for i := len(c.labels) - 1; i >= 0; i-- {
+			if c.labels[i].isLoop {
+				c.labels[i].continues = append(c.labels[i].continues, c.emit(OpJump, 0, 0, 0, start))
+				return
+			}
+		}
+
+		c.errorAt(start, "La sentencia continue debe estar dentro de un ciclo")
+
+	default:
+		unsupported(stmt, "sentencia de transferencia")
+	}
+}
+
+func (c *Compiler) pushLabel(isLoop bool) *label {
+	l := &label{isLoop: isLoop}
+	c.labels = append(c.labels, l)
+	return l
+}
+
+// popLabel resuelve los break al final y los continue a continueAt
+func (c *Compiler) popLabel(l *label, continueAt int) {
+	c.labels = c.labels[:len(c.labels)-1]
+
+	for _, pos := range l.breaks {
+		c.patch(pos)
+	}
+	for _, pos := range l.continues {
+		c.patchTo(pos, continueAt)
+	}
+}
+
+func (c *Compiler) ifStmt(stmt *ast.IfStmt) {
+	ends := make([]int, 0)
+
+	for _, branch := range stmt.Branches {
+		start := branch.Span.Start
+
+		c.expr(branch.Cond)
+		next := c.emit(OpJumpIfFalse, 0, c.name("La condicion del if debe ser un booleano"), 0, start)
+
+		c.pushScope(start)
+		for _, inner := range branch.Body {
+			c.stmt(inner)
+		}
+		c.popScope()
+
+		ends = append(ends, c.emit(OpJump, 0, 0, 0, start))
+		c.patch(next)
+	}
+
+	if stmt.Else != nil {
+		c.pushScope(stmt.Else.Span.Start)
+		for _, inner := range stmt.Else.Stmts {
+			c.stmt(inner)
+		}
+		c.popScope()
+	}
+
+	for _, end := range ends {
+		c.patch(end)
+	}
+}
+
+func (c *Compiler) switchStmt(stmt *ast.SwitchStmt) {
+	start := stmt.Span.Start
+
+	c.expr(stmt.Tag)
+
+	mainSlot := c.hidden()
+	c.emit(OpStoreHidden, mainSlot, 0, 0, start)
+
+	c.pushScope(start)
+	l := c.pushLabel(false)
+
+	jumps := make([]int, len(stmt.Cases))
+
+	for i, switchCase := range stmt.Cases {
+		c.expr(switchCase.Value)
+		jumps[i] = c.emit(OpCaseJump, mainSlot, 0, 0, switchCase.Span.Start)
+	}
+
+	toDefault := c.emit(OpJump, 0, 0, 0, start)
+	ends := make([]int, 0)
+
+	// todos los casos comparten el scope del switch
+	for i, switchCase := range stmt.Cases {
+		c.patch(jumps[i])
+		for _, inner := range switchCase.Body {
+			c.stmt(inner)
+		}
+		ends = append(ends, c.emit(OpJump, 0, 0, 0, switchCase.Span.Start))
+	}
+
+	c.patch(toDefault)
+	if stmt.Default != nil {
+		for _, inner := range stmt.Default.Body {
+			c.stmt(inner)
+		}
+	}
+
+	for _, end := range ends {
+		c.patch(end)
+	}
+
+	c.popLabel(l, c.here())
+	c.popScope()
+}
+
+func (c *Compiler) forCond(stmt *ast.ForCondStmt) {
+	start := stmt.Span.Start
+
+	c.pushScope(start)
+	l := c.pushLabel(true)
+
+	loop := c.here()
+	c.emit(OpStep, 0, 0, 0, start)
+	c.expr(stmt.Cond)
+	exit := c.emit(OpJumpIfFalse, 0, c.name("La condición del for debe ser un booleano"), 0, start)
+
+	for _, inner := range stmt.Body {
+		c.stmt(inner)
+	}
+	c.emit(OpJump, loop, 0, 0, start)
+
+	c.patch(exit)
+	c.popLabel(l, loop)
+	c.popScope()
+}
+
+func (c *Compiler) forClause(stmt *ast.ForClauseStmt) {
+	start := stmt.Span.Start
+
+	c.pushScope(start)
+	c.assignStmt(stmt.Init)
+	l := c.pushLabel(true)
+
+	loop := c.here()
+	c.emit(OpStep, 0, 0, 0, start)
+	c.expr(stmt.Cond)
+	exit := c.emit(OpJumpIfFalse, 0, c.name("La condición del for debe ser un booleano"), 0, start)
+
+	for _, inner := range stmt.Body {
+		c.stmt(inner)
+	}
+
+	increment := c.here()
+	c.expr(stmt.Post)
+	c.emit(OpPop, 0, 0, 0, start)
+	c.emit(OpJump, loop, 0, 0, start)
+
+	c.patch(exit)
+	c.popLabel(l, increment)
+	c.popScope()
+}
+
+func (c *Compiler) forRange(stmt *ast.ForRange) {
+	start := stmt.Span.Start
+
+	indexName := stmt.Index.Name
+	valueName := stmt.Value.Name
+	if indexName == valueName {
+		unsupported(stmt, "for con índice y valor del mismo nombre")
+	}
+
+	c.expr(stmt.X)
+
+	iterSlot := c.hidden()
+	exit := c.emit(OpIterInit, iterSlot, 0, 0, start)
+
+	// outer_for: índice y valor
+	c.pushScope(start)
+	indexRef := c.declare(indexName)
+	valueRef := c.declare(valueName)
+	c.emit(OpIterBind, iterSlot, indexRef, 0, stmt.Index.Span.Start)
+	c.emit(OpIterBind, iterSlot, valueRef, 1, stmt.Value.Span.Start)
+
+	l := c.pushLabel(true)
+
+	loop := c.here()
+	end := c.emit(OpIterTest, iterSlot, 0, 0, start)
+	c.emit(OpStep, 0, 0, 0, start)
+	c.emit(OpIterSet, iterSlot, indexRef, valueRef, start)
+
+	// inner_for: se reinicia en cada iteración
+	c.pushScope(start)
+	for _, inner := range stmt.Body {
+		c.stmt(inner)
+	}
+	c.popScope()
+
+	advance := c.here()
+	c.emit(OpIterAdvance, iterSlot, 0, 0, start)
+	c.emit(OpJump, loop, 0, 0, start)
+
+	c.patch(end)
+	c.popLabel(l, advance)
+	c.emit(OpIterReset, iterSlot, 0, 0, start)
+	c.popScope()
+	c.patch(exit)
+}
+
+// * Llamadas
+
+func (c *Compiler) funcCall(call *ast.CallExpr) {
+	candidateName, _ := ast.PathName(call.Fun)
+	start := call.Span.Start
+
+	// métodos de vectores: v.append(x), v.remove(at: 0), v.removeLast()
+	if strings.Contains(candidateName, ".") {
+		parts := strings.Split(candidateName, ".")
+		if len(parts) != 2 {
+			unsupported(call, "acceso encadenado a métodos")
+		}
+
+		method := c.emit(OpMethod, c.resolve(parts[0]), c.name(parts[1]), 0, start)
+		argc := c.args(call)
+		c.emit(OpCallMethod, 0, argc, 0, start)
+		c.patch(method)
+		return
+	}
+
+	funcObj, msg1 := c.globalScope.GetFunction(candidateName)
+
+	switch funcObj := funcObj.(type) {
+	case *repl.BuiltInFunction:
+		idx, ok := c.builtinIndex[candidateName]
+		if !ok {
+			c.program.Builtins = append(c.program.Builtins, funcObj)
+			idx = len(c.program.Builtins) - 1
+			c.builtinIndex[candidateName] = idx
+		}
+		argc := c.args(call)
+		c.emit(OpCallNative, idx, argc, 0, start)
+
+	case *repl.Function:
+		idx := c.function(funcObj)
+		argc := c.args(call)
+		c.emit(OpCall, idx, argc, 0, start)
+
+	case nil:
+		// sin structs declarados, la búsqueda de estructura siempre falla
+		c.errorAt(start, msg1+"La estructura "+candidateName+" no existe")
+		c.emit(OpNil, 0, 0, 0, start)
+
+	default:
+		unsupported(call, "llamada a "+candidateName)
+	}
+}
+
+func (c *Compiler) args(call *ast.CallExpr) int {
+
+	for _, arg := range call.Args {
+		start := arg.Span.Start
+		argName := ""
+
+		// un acceso a.b se pasa como referencia a la variable
+		if name, ok := ast.PathName(arg.Value); ok {
+			argName = name
+
+			if strings.Contains(argName, ".") {
+				parts := strings.Split(argName, ".")
+				if len(parts) != 2 {
+					unsupported(arg.Value, "acceso encadenado a propiedades")
+				}
+				c.emit(OpLoadProp, c.resolve(parts[0]), c.name(parts[1]), 0, start)
+			} else {
+				c.emit(OpLoadArg, c.resolve(argName), 0, 0, start)
+			}
+		} else {
+			c.expr(arg.Value)
+		}
+
+		if arg.Label != "" {
+			argName = arg.Label
+		}
+
+		nameIdx := -1
+		if argName != "" {
+			nameIdx = c.name(argName)
+		}
+		c.emit(OpArg, nameIdx, 0, 0, start)
+	}
+
+	return len(call.Args)
+}
+
+// * Expresiones
+
+func (c *Compiler) expr(expr ast.Expr) {
+	start := expr.GetSpan().Start
+
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		c.expr(expr.X)
+
+	case *ast.CallExpr:
+		if _, ok := ast.PathName(expr.Fun); !ok {
+			unsupported(expr, "expresión "+ast.Text(expr))
+		}
+		c.funcCall(expr)
+
+	case *ast.Ident:
+		c.emit(OpLoad, c.resolve(expr.Name), 0, 0, start)
+
+	case *ast.SelectorExpr:
+		name, ok := ast.PathName(expr)
+		if !ok {
+			unsupported(expr, "expresión "+ast.Text(expr))
+		}
+
+		ids := strings.Split(name, ".")
+		c.program.Paths = append(c.program.Paths, ids)
+		c.emit(OpLoadPath, c.resolve(ids[0]), len(c.program.Paths)-1, 0, start)
+
+	case *ast.IndexExpr:
+		ref, n, fails := c.vectItem(expr)
+		c.emit(OpIndexGet, ref, n, 0, start)
+		end := c.emit(OpJump, 0, 0, 0, start)
+
+		for _, fail := range fails {
+			c.patch(fail)
+		}
+		c.emit(OpNil, 0, 0, 0, start)
+		c.patch(end)
+
+	case *ast.IntLit:
+		c.emit(OpConst, c.constant(&value.IntValue{InternalValue: expr.Value}), 0, 0, start)
+
+	case *ast.FloatLit:
+		c.emit(OpConst, c.constant(&value.FloatValue{InternalValue: expr.Value}), 0, 0, start)
+
+	case *ast.StringLit:
+		c.stringLit(expr)
+
+	case *ast.BoolLit:
+		c.emit(OpConst, c.constant(&value.BoolValue{InternalValue: expr.Value}), 0, 0, start)
+
+	case *ast.NilLit:
+		c.emit(OpNil, 0, 0, 0, start)
+
+	case *ast.VectorLit:
+		for _, item := range expr.Elems {
+			c.expr(item)
+		}
+		c.emit(OpMakeVector, len(expr.Elems), 0, 0, expr.Lbrace)
+
+	case *ast.MatrixLit:
+		for _, row := range expr.Rows {
+			c.expr(row)
+		}
+		c.emit(OpMakeMatrix, len(expr.Rows), 0, 0, expr.Lbrace)
+
+	case *ast.IncDecExpr:
+		delta := 1
+		if expr.Op == "--" {
+			delta = -1
+		}
+		c.emit(OpIncDec, c.resolve(expr.X.Name), delta, 0, start)
+
+	case *ast.UnaryExpr:
+		c.expr(expr.X)
+		c.emit(OpUnary, c.unary(expr.Op), 0, 0, expr.OpPos)
+
+	case *ast.BinaryExpr:
+		c.expr(expr.Left)
+
+		earlyIdx, hasEarly := c.early(expr.Op)
+		var early int
+		if hasEarly {
+			early = c.emit(OpEarly, earlyIdx, 0, 0, expr.OpPos)
+		}
+
+		c.expr(expr.Right)
+		c.emit(OpBinary, c.binary(expr.Op), 0, 0, expr.OpPos)
+
+		if hasEarly {
+			c.patch(early)
+		}
+
+	default:
+		unsupported(expr, "expresión "+ast.Text(expr))
+	}
+}
+
+func (c *Compiler) stringLit(lit *ast.StringLit) {
+	start := lit.Span.Start
+	stringVal := lit.Value
+
+	if repl.HasInterpolation(stringVal) {
+		interpolation := &Interpolation{Text: stringVal, Refs: c.visibleLocals()}
+
+		// los nombres que no son locales se buscan entre las globales
+		for _, pattern := range interpolationPatterns {
+			for _, match := range pattern.FindAllStringSubmatch(stringVal, -1) {
+				if _, ok := interpolation.Refs[match[1]]; !ok {
+					interpolation.Refs[match[1]] = c.global(match[1])
+				}
+			}
+		}
+
+		c.program.Interpolations = append(c.program.Interpolations, interpolation)
+		c.emit(OpInterpolate, len(c.program.Interpolations)-1, 0, 0, start)
+		return
+	}
+
+	if len(stringVal) == 1 {
+		c.emit(OpConst, c.constant(&value.CharacterValue{InternalValue: stringVal}), 0, 0, start)
+		return
+	}
+
+	c.emit(OpConst, c.constant(&value.StringValue{InternalValue: stringVal}), 0, 0, start)
+}
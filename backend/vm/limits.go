@@ -0,0 +1,41 @@
+package vm
+
+import (
+	"fmt"
+
+	"main.go/ast"
+	"main.go/repl"
+	"main.go/value"
+)
+
+// Los límites de la máquina son los de repl.Limits, con los mismos mensajes
+// que el intérprete. El compilador emite un OpStep donde el intérprete
+// cuenta un paso: al empezar cada sentencia y cada iteración de un ciclo.
+
+func limitExceeded(pos ast.Pos, msg string) *repl.LimitExceeded {
+	return &repl.LimitExceeded{Line: pos.Line, Column: pos.Column, Msg: msg}
+}
+
+// step cuenta una sentencia o una iteración.
+func (m *Machine) step(pos ast.Pos) {
+	m.steps++
+	if m.Limits.MaxSteps > 0 && m.steps > m.Limits.MaxSteps {
+		panic(limitExceeded(pos, fmt.Sprintf("Se superó el límite de %d pasos de ejecución", m.Limits.MaxSteps)))
+	}
+}
+
+// checkString revisa el largo de una cadena recién construida con + o +=.
+func (m *Machine) checkString(pos ast.Pos, result value.IVOR) {
+	str, ok := result.(*value.StringValue)
+	if ok && m.Limits.MaxStringLength > 0 && len(str.InternalValue) > m.Limits.MaxStringLength {
+		panic(limitExceeded(pos, fmt.Sprintf("Se superó el largo máximo de %d bytes para una cadena", m.Limits.MaxStringLength)))
+	}
+}
+
+// enterCall revisa la profundidad antes de apilar el marco de una llamada;
+// el marco del programa principal no cuenta.
+func (m *Machine) enterCall(pos ast.Pos) {
+	if m.Limits.MaxCallDepth > 0 && len(m.frames)-1 >= m.Limits.MaxCallDepth {
+		panic(limitExceeded(pos, fmt.Sprintf("Se superó la profundidad máxima de %d llamadas", m.Limits.MaxCallDepth)))
+	}
+}
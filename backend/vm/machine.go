@@ -0,0 +1,1055 @@
+package vm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"main.go/ast"
+	"main.go/repl"
+	"main.go/value"
+)
+
+// MaxFrames limita la profundidad de llamadas para que una recursión
+// infinita se reporte como error en lugar de agotar la memoria.
+const MaxFrames = 10000
+
+// frame es la activación de un chunk: el programa principal o una función.
+type frame struct {
+	chunk  *Chunk
+	proto  *FunctionProto
+	ip     int
+	locals []*repl.Variable
+	base   int
+	pos    ast.Pos
+}
+
+// Machine ejecuta un Program compilado. Los errores semánticos se reportan en
+// la misma tabla y con los mismos mensajes que el intérprete.
+type Machine struct {
+	Console    *repl.Console
+	ErrorTable *repl.ErrorTable
+	Limits     repl.Limits // acota pasos, profundidad de llamadas y largo de cadenas
+
+	program *Program
+	globals []*repl.Variable
+	stack   []value.IVOR
+	args    []*repl.Argument
+	frames  []*frame
+	lastRef *repl.Variable
+	steps   int
+
+	context *repl.ReplContext
+	shim    *repl.ReplVisitor
+}
+
+// NewMachine crea una máquina lista para ejecutar el programa
+func NewMachine(program *Program, errorTable *repl.ErrorTable) *Machine {
+
+	console := repl.NewConsole()
+
+	return &Machine{
+		Console:    console,
+		ErrorTable: errorTable,
+		program:    program,
+		globals:    make([]*repl.Variable, len(program.Globals)),
+		stack:      make([]value.IVOR, 0, 256),
+		context: &repl.ReplContext{
+			Console:    console,
+			CallStack:  repl.NewCallStack(),
+			ErrorTable: errorTable,
+		},
+		// los métodos de los vectores solo usan la consola y la tabla de errores
+		shim: &repl.ReplVisitor{
+			Console:    console,
+			ErrorTable: errorTable,
+			CallStack:  repl.NewCallStack(),
+		},
+	}
+}
+
+// Run ejecuta el programa principal. Un panic durante la ejecución se reporta
+// como error de ejecución en la posición de la instrucción actual.
+func (m *Machine) Run() (err error) {
+
+	m.frames = []*frame{{
+		chunk:  m.program.Main,
+		locals: make([]*repl.Variable, len(m.program.Main.Locals)),
+	}}
+
+	defer func() {
+		r := recover()
+		if limit, ok := r.(*repl.LimitExceeded); ok {
+			err = limit
+			m.ErrorTable.NewRuntimeError(limit.Line, limit.Column, limit.Msg)
+		} else if r != nil {
+			err = fmt.Errorf("%v", r)
+			line, column := m.position()
+			m.ErrorTable.NewRuntimeError(line, column, fmt.Sprintf("Error de ejecución: %v", r))
+		}
+	}()
+
+	m.execute()
+	return nil
+}
+
+// ExportGlobals agrega las variables globales al ámbito global para que la
+// tabla de símbolos refleje la ejecución.
+func (m *Machine) ExportGlobals(scopeTrace *repl.ScopeTrace) {
+	for _, variable := range m.globals {
+		if variable == nil {
+			continue
+		}
+		scopeTrace.GlobalScope.AddVariable(variable.Name, variable.Type, variable.Value, variable.IsConst, variable.AllowNil, variable.Pos)
+	}
+}
+
+func (m *Machine) position() (int, int) {
+	if len(m.frames) == 0 {
+		return 0, 0
+	}
+
+	fr := m.frames[len(m.frames)-1]
+	ip := fr.ip - 1
+	if ip < 0 || ip >= len(fr.chunk.Pos) {
+		return 0, 0
+	}
+
+	pos := fr.chunk.Pos[ip]
+	return pos.Line, pos.Column
+}
+
+// * Pila
+
+func (m *Machine) push(v value.IVOR) {
+	m.stack = append(m.stack, v)
+}
+
+func (m *Machine) pop() value.IVOR {
+	v := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+	return v
+}
+
+func (m *Machine) peek() value.IVOR {
+	return m.stack[len(m.stack)-1]
+}
+
+func (m *Machine) popN(n int) []value.IVOR {
+	items := make([]value.IVOR, n)
+	copy(items, m.stack[len(m.stack)-n:])
+	m.stack = m.stack[:len(m.stack)-n]
+	return items
+}
+
+func (m *Machine) popArgs(n int) []*repl.Argument {
+	args := make([]*repl.Argument, n)
+	copy(args, m.args[len(m.args)-n:])
+	m.args = m.args[:len(m.args)-n]
+	return args
+}
+
+// * Variables
+
+func (m *Machine) lookup(fr *frame, ref int) *repl.Variable {
+	if global, slot := isGlobalRef(ref); global {
+		return m.globals[slot]
+	}
+	return fr.locals[ref]
+}
+
+func (m *Machine) store(fr *frame, ref int, variable *repl.Variable) {
+	if global, slot := isGlobalRef(ref); global {
+		m.globals[slot] = variable
+		return
+	}
+	fr.locals[ref] = variable
+}
+
+func (m *Machine) refName(fr *frame, ref int) string {
+	if global, slot := isGlobalRef(ref); global {
+		return m.program.Globals[slot]
+	}
+	return fr.chunk.Locals[ref]
+}
+
+func (m *Machine) globalByName(name string) *repl.Variable {
+	for slot, global := range m.program.Globals {
+		if global == name {
+			return m.globals[slot]
+		}
+	}
+	return nil
+}
+
+// declare replica BaseScopeTrace.AddVariable sobre un slot: la variable se
+// guarda aunque la validación de tipo falle.
+func (m *Machine) declare(fr *frame, ref int, varType string, val value.IVOR, isConst bool, pos ast.Pos) (*repl.Variable, string) {
+
+	name := m.refName(fr, ref)
+
+	if m.lookup(fr, ref) != nil {
+		return nil, "La variable '" + name + "' ya existe en el ámbito actual"
+	}
+
+	variable := &repl.Variable{
+		Name:    name,
+		Type:    varType,
+		Value:   val,
+		IsConst: isConst,
+		Pos:     pos,
+	}
+
+	ok, msg := variable.TypeValidation()
+	m.store(fr, ref, variable)
+
+	if !ok {
+		return nil, msg
+	}
+
+	return variable, ""
+}
+
+func (m *Machine) semanticError(pos ast.Pos, msg string) {
+	m.ErrorTable.NewSemanticError(pos, msg)
+}
+
+// * Ciclo de ejecución
+
+func (m *Machine) execute() {
+
+	fr := m.frames[len(m.frames)-1]
+
+	for {
+		ins := fr.chunk.Code[fr.ip]
+		pos := fr.chunk.Pos[fr.ip]
+		fr.ip++
+
+		switch ins.Op {
+		case OpNil:
+			m.push(value.DefaultNilValue)
+
+		case OpConst:
+			m.push(m.program.Constants[ins.A])
+
+		case OpPop:
+			m.pop()
+
+		case OpError:
+			m.semanticError(pos, m.program.Names[ins.A])
+
+		case OpStep:
+			m.step(pos)
+
+		case OpLoad:
+			variable := m.lookup(fr, ins.A)
+			if variable == nil {
+				m.semanticError(pos, "Variable '"+m.refName(fr, ins.A)+"' no encontrada")
+				m.push(value.DefaultNilValue)
+				continue
+			}
+			m.push(variable.Value)
+
+		case OpLoadPath:
+			m.push(m.loadPath(fr, ins, pos))
+
+		case OpLoadArg:
+			m.lastRef = m.lookup(fr, ins.A)
+			if m.lastRef == nil {
+				m.semanticError(pos, "Variable "+m.refName(fr, ins.A)+" no encontrada")
+				m.push(value.DefaultNilValue)
+				continue
+			}
+			m.push(m.lastRef.Value)
+
+		case OpLoadProp:
+			m.lastRef = m.loadProp(fr, ins)
+			if m.lastRef == nil {
+				m.semanticError(pos, "Variable "+m.refName(fr, ins.A)+"."+m.program.Names[ins.B]+" no encontrada")
+				m.push(value.DefaultNilValue)
+				continue
+			}
+			m.push(m.lastRef.Value)
+
+		case OpDeclare:
+			m.declareValue(fr, ins, pos)
+
+		case OpDeclareVector:
+			m.declareVector(fr, ins, pos)
+
+		case OpDeclareEmpty:
+			varType := m.program.Names[ins.B]
+			emptyVector := repl.NewVectorValue([]value.IVOR{}, varType, repl.RemoveBrackets(varType))
+			if variable, msg := m.declare(fr, ins.A, varType, emptyVector, false, pos); variable == nil {
+				m.semanticError(pos, msg)
+			}
+
+		case OpDeclareMatrix:
+			m.declareMatrix(fr, ins, pos)
+
+		case OpClear:
+			for i := ins.A; i < ins.B; i++ {
+				fr.locals[i] = nil
+			}
+
+		case OpStoreHidden:
+			fr.locals[ins.A] = &repl.Variable{Value: m.pop()}
+
+		case OpAssign:
+			m.assign(fr, ins, pos)
+
+		case OpAssignField:
+			m.assignField(fr, ins, pos)
+
+		case OpCompoundLoad:
+			variable := m.lookup(fr, ins.A)
+			if variable == nil {
+				m.semanticError(pos, "Variable "+m.refName(fr, ins.A)+" no encontrada")
+				fr.ip = ins.B
+				continue
+			}
+			m.push(variable.Value)
+
+		case OpCompoundStore:
+			right := m.pop()
+			left := m.pop()
+
+			ok, msg, result := m.program.Binary[ins.B].Validate(left, right)
+			if !ok {
+				m.semanticError(pos, msg)
+				continue
+			}
+			m.checkString(pos, result)
+
+			if ok, msg := m.lookup(fr, ins.A).AssignValue(result, true); !ok {
+				m.semanticError(pos, msg)
+			}
+
+		case OpIncDec:
+			m.push(m.incDec(fr, ins, pos))
+
+		case OpIndexBegin:
+			variable := m.lookup(fr, ins.A)
+			if variable == nil {
+				m.semanticError(pos, "Variable "+m.refName(fr, ins.A)+" no encontrada")
+				fr.ip = ins.B
+				continue
+			}
+			if !repl.IsVectorType(variable.Type) && !repl.IsMatrixType(variable.Type) {
+				m.semanticError(pos, "La variable "+m.refName(fr, ins.A)+" no es un vector o matriz")
+				fr.ip = ins.B
+			}
+
+		case OpIndexInt:
+			if m.peek().Type() != value.IVOR_INT {
+				m.semanticError(pos, "Los índices deben ser enteros")
+				m.popN(ins.C + 1)
+				fr.ip = ins.B
+			}
+
+		case OpIndexGet:
+			m.push(m.indexGet(fr, ins, pos))
+
+		case OpIndexSet:
+			m.indexSet(fr, ins, pos)
+
+		case OpUnary:
+			ok, msg, result := m.program.Unary[ins.A].Validate(m.pop())
+			if !ok {
+				m.semanticError(pos, msg)
+				m.push(value.DefaultNilValue)
+				continue
+			}
+			m.push(result)
+
+		case OpBinary:
+			right := m.pop()
+			left := m.pop()
+
+			ok, msg, result := m.program.Binary[ins.A].Validate(left, right)
+			if !ok {
+				m.semanticError(pos, msg)
+				m.push(value.DefaultNilValue)
+				continue
+			}
+			m.checkString(pos, result)
+			m.push(result)
+
+		case OpEarly:
+			if ok, _, result := m.program.Early[ins.A].Validate(m.peek()); ok {
+				m.stack[len(m.stack)-1] = result
+				fr.ip = ins.B
+			}
+
+		case OpInterpolate:
+			m.push(m.interpolate(fr, m.program.Interpolations[ins.A], pos))
+
+		case OpMakeVector:
+			m.push(m.makeVector(m.popN(ins.A), pos))
+
+		case OpMakeMatrix:
+			m.push(m.makeMatrix(m.popN(ins.A), pos))
+
+		case OpJump:
+			fr.ip = ins.A
+
+		case OpJumpIfFalse:
+			condition := m.pop()
+			if condition.Type() != value.IVOR_BOOL {
+				m.semanticError(pos, m.program.Names[ins.B])
+				fr.ip = ins.A
+				continue
+			}
+			if !condition.(*value.BoolValue).InternalValue {
+				fr.ip = ins.A
+			}
+
+		case OpCaseJump:
+			caseValue := m.pop()
+			mainValue := fr.locals[ins.A].Value
+			if caseValue.Type() == mainValue.Type() && caseValue.Value() == mainValue.Value() {
+				fr.ip = ins.B
+			}
+
+		case OpIterInit:
+			iterable := m.pop()
+			var iterableItem *repl.VectorValue
+
+			if repl.IsVectorType(iterable.Type()) {
+				iterableItem = iterable.(*repl.VectorValue)
+			} else if iterable.Type() == value.IVOR_STRING {
+				iterableItem = repl.StringToVector(iterable.(*value.StringValue))
+			} else {
+				m.semanticError(pos, "El valor del for debe ser un vector o una cadena")
+				fr.ip = ins.B
+				continue
+			}
+
+			if iterableItem.Size() == 0 {
+				fr.ip = ins.B
+				continue
+			}
+			fr.locals[ins.A] = &repl.Variable{Value: iterableItem}
+
+		case OpIterBind:
+			iterableItem := fr.locals[ins.A].Value.(*repl.VectorValue)
+			if ins.C == 0 {
+				m.declare(fr, ins.B, value.IVOR_INT, &value.IntValue{InternalValue: 0}, true, pos)
+			} else {
+				m.declare(fr, ins.B, iterableItem.ItemType, iterableItem.Current(), true, pos)
+			}
+
+		case OpIterTest:
+			iterableItem := fr.locals[ins.A].Value.(*repl.VectorValue)
+			if iterableItem.CurrentIndex >= iterableItem.Size() {
+				fr.ip = ins.B
+			}
+
+		case OpIterSet:
+			iterableItem := fr.locals[ins.A].Value.(*repl.VectorValue)
+			fr.locals[ins.B].Value = &value.IntValue{InternalValue: iterableItem.CurrentIndex}
+			fr.locals[ins.C].Value = iterableItem.Current()
+
+		case OpIterAdvance:
+			fr.locals[ins.A].Value.(*repl.VectorValue).Next()
+
+		case OpIterReset:
+			fr.locals[ins.A].Value.(*repl.VectorValue).Reset()
+
+		case OpArg:
+			argValue := m.pop()
+			var variableRef *repl.Variable
+
+			if previous := fr.chunk.Code[fr.ip-2].Op; previous == OpLoadArg || previous == OpLoadProp {
+				variableRef = m.lastRef
+			}
+
+			argName := ""
+			if ins.A >= 0 {
+				argName = m.program.Names[ins.A]
+			}
+
+			m.args = append(m.args, &repl.Argument{
+				Name:        argName,
+				Value:       argValue,
+				Pos:         pos,
+				VariableRef: variableRef,
+			})
+
+		case OpCall:
+			if callee := m.call(m.program.Functions[ins.A], m.popArgs(ins.B), pos); callee != nil {
+				fr = callee
+			}
+
+		case OpCallNative:
+			builtin := m.program.Builtins[ins.A]
+			returnValue, ok, msg := builtin.Exec(m.context, m.popArgs(ins.B))
+
+			if !ok {
+				if msg != "" {
+					m.semanticError(pos, msg)
+				}
+				m.push(value.DefaultNilValue)
+				continue
+			}
+			m.push(returnValue)
+
+		case OpMethod:
+			method, msg := m.method(fr, ins)
+			if method == nil {
+				m.semanticError(pos, msg+"La estructura "+m.refName(fr, ins.A)+"."+m.program.Names[ins.B]+" no existe")
+				m.push(value.DefaultNilValue)
+				fr.ip = ins.C
+				continue
+			}
+			m.push(method)
+
+		case OpCallMethod:
+			args := m.popArgs(ins.B)
+			method := m.pop().(*repl.ObjectBuiltInFunction)
+			method.Exec(m.shim, args, pos)
+			m.push(method.ReturnValue)
+
+		case OpReturn, OpReturnNil:
+			var returnValue value.IVOR = value.DefaultNilValue
+			if ins.Op == OpReturn {
+				returnValue = m.pop()
+			}
+
+			// fin del programa principal
+			if fr.proto == nil {
+				return
+			}
+
+			f := fr.proto.Decl
+			f.ValidateReturn(m.context, returnValue, fr.pos)
+			returnValue = f.ReturnValue
+
+			m.stack = m.stack[:fr.base]
+			m.frames = m.frames[:len(m.frames)-1]
+			fr = m.frames[len(m.frames)-1]
+			m.push(returnValue)
+
+		default:
+			panic(fmt.Sprintf("instrucción desconocida %s", ins.Op))
+		}
+	}
+}
+
+// * Llamadas
+
+// call valida los argumentos como Function.Exec y crea el frame de la función.
+// Si los argumentos no son válidos retorna nil y deja nil en la pila.
+func (m *Machine) call(proto *FunctionProto, args []*repl.Argument, pos ast.Pos) *frame {
+
+	f := proto.Decl
+
+	argsOk, argsMap := f.ValidateArgs(m.context, args, pos)
+	if !argsOk {
+		f.ReturnValue = value.DefaultNilValue
+		m.push(value.DefaultNilValue)
+		return nil
+	}
+
+	m.enterCall(pos)
+	if len(m.frames) >= MaxFrames {
+		panic("Desbordamiento de pila: se excedió el máximo de " + strconv.Itoa(MaxFrames) + " llamadas anidadas")
+	}
+
+	callee := &frame{
+		chunk:  proto.Chunk,
+		proto:  proto,
+		locals: make([]*repl.Variable, len(proto.Chunk.Locals)),
+		base:   len(m.stack),
+		pos:    pos,
+	}
+
+	for i, param := range f.Param {
+		arg := argsMap[param.InnerName]
+		variable := &repl.Variable{
+			Name:  param.InnerName,
+			Type:  arg.Value.Type(),
+			Value: arg.Value.Copy(),
+			Pos:   arg.Pos,
+		}
+		variable.TypeValidation()
+		callee.locals[i] = variable
+	}
+
+	m.frames = append(m.frames, callee)
+	return callee
+}
+
+// method replica BaseScopeTrace.searchObjectFunction para "variable.metodo"
+func (m *Machine) method(fr *frame, ins Instruction) (value.IVOR, string) {
+
+	name := m.refName(fr, ins.A)
+	variable := m.lookup(fr, ins.A)
+
+	if variable == nil {
+		return nil, "No se puede acceder a la propiedad " + name
+	}
+
+	var object *repl.ObjectValue
+
+	switch obj := variable.Value.(type) {
+	case *repl.ObjectValue:
+		object = obj
+	case *repl.VectorValue:
+		object = obj.ObjectValue
+	default:
+		return nil, "La propiedad '" + variable.Name + "' de tipo " + obj.Type() + " no tiene propiedades"
+	}
+
+	return object.InternalScope.GetFunction(m.program.Names[ins.B])
+}
+
+// * Variables
+
+func (m *Machine) loadPath(fr *frame, ins Instruction, pos ast.Pos) value.IVOR {
+
+	ids := m.program.Paths[ins.B]
+	variable := m.lookup(fr, ins.A)
+
+	if variable == nil {
+		m.semanticError(pos, "Variable '"+ids[0]+"' no encontrada")
+		return value.DefaultNilValue
+	}
+
+	valueRef := variable.Value
+
+	for i := 1; i < len(ids); i++ {
+		attr := ids[i]
+
+		structVal, ok := valueRef.(*value.StructValue)
+		if !ok {
+			m.semanticError(pos, "No se puede acceder a '"+attr+"' porque '"+ids[i-1]+"' no es un struct")
+			return value.DefaultNilValue
+		}
+
+		val, ok := structVal.Instance.Fields[attr]
+		if !ok {
+			m.semanticError(pos, "El atributo '"+attr+"' no existe en el struct '"+structVal.Instance.StructName+"'")
+			return value.DefaultNilValue
+		}
+
+		valueRef = val
+	}
+
+	return valueRef
+}
+
+// loadProp replica BaseScopeTrace.searchObjectVariable para "variable.propiedad"
+func (m *Machine) loadProp(fr *frame, ins Instruction) *repl.Variable {
+
+	variable := m.lookup(fr, ins.A)
+	if variable == nil {
+		return nil
+	}
+
+	switch obj := variable.Value.(type) {
+	case *repl.ObjectValue:
+		return obj.InternalScope.GetVariable(m.program.Names[ins.B])
+	case *repl.VectorValue:
+		return obj.ObjectValue.InternalScope.GetVariable(m.program.Names[ins.B])
+	}
+
+	return nil
+}
+
+func (m *Machine) declareValue(fr *frame, ins Instruction, pos ast.Pos) {
+
+	varValue := m.pop()
+	varType := varValue.Type()
+
+	if ins.B >= 0 {
+		varType = m.program.Names[ins.B]
+	} else if varType == "[]" {
+		m.semanticError(pos, "No se puede inferir el tipo de un vector vacio '"+m.refName(fr, ins.A)+"'")
+		return
+	}
+
+	if obj, ok := varValue.(*repl.ObjectValue); ok {
+		varValue = obj.Copy()
+	}
+
+	if variable, msg := m.declare(fr, ins.A, varType, varValue, false, pos); variable == nil {
+		m.semanticError(pos, msg)
+	}
+}
+
+func (m *Machine) declareVector(fr *frame, ins Instruction, pos ast.Pos) {
+
+	vectorValue := m.pop()
+	vectorType := m.program.Names[ins.B]
+
+	if !repl.IsVectorType(vectorType) {
+		m.semanticError(pos, "El tipo '"+vectorType+"' no es un tipo de vector válido")
+		return
+	}
+
+	if vectorValue.Type() != vectorType && vectorValue.Type() != "[]" {
+		if repl.IsVectorType(vectorValue.Type()) {
+			if repl.RemoveBrackets(vectorType) != repl.RemoveBrackets(vectorValue.Type()) {
+				m.semanticError(pos, "No se puede asignar un vector de tipo '"+vectorValue.Type()+"' a una variable de tipo '"+vectorType+"'")
+				return
+			}
+		} else {
+			m.semanticError(pos, "No se puede asignar un valor de tipo '"+vectorValue.Type()+"' a una variable de tipo '"+vectorType+"'")
+			return
+		}
+	}
+
+	if repl.IsVectorType(vectorValue.Type()) {
+		vectorValue = vectorValue.Copy()
+	}
+
+	if variable, msg := m.declare(fr, ins.A, vectorType, vectorValue, false, pos); variable == nil {
+		m.semanticError(pos, msg)
+	}
+}
+
+func (m *Machine) declareMatrix(fr *frame, ins Instruction, pos ast.Pos) {
+
+	matrixValue := m.pop()
+	matrixType := m.program.Names[ins.B]
+
+	if matrixValue.Type() != matrixType && matrixValue.Type() != "[][]" {
+		if repl.IsMatrixType(matrixValue.Type()) {
+			if repl.RemoveMatrixBrackets(matrixType) != repl.RemoveMatrixBrackets(matrixValue.Type()) {
+				m.semanticError(pos, "No se puede asignar una matriz de tipo '"+matrixValue.Type()+"' a '"+matrixType+"'")
+				return
+			}
+		} else {
+			m.semanticError(pos, "No se puede asignar un valor de tipo '"+matrixValue.Type()+"' a '"+matrixType+"'")
+			return
+		}
+	}
+
+	if repl.IsMatrixType(matrixValue.Type()) {
+		matrixValue = matrixValue.Copy()
+	}
+
+	if variable, msg := m.declare(fr, ins.A, matrixType, matrixValue, false, pos); variable == nil {
+		m.semanticError(pos, msg)
+	}
+}
+
+func (m *Machine) assign(fr *frame, ins Instruction, pos ast.Pos) {
+
+	varValue := m.pop()
+	varName := m.refName(fr, ins.A)
+	variable := m.lookup(fr, ins.A)
+
+	if variable == nil {
+		m.semanticError(pos, "Variable '"+varName+"' no encontrada")
+		return
+	}
+
+	if repl.IsVectorType(variable.Type) {
+		if repl.IsVectorType(varValue.Type()) {
+			if repl.RemoveBrackets(variable.Type) != repl.RemoveBrackets(varValue.Type()) {
+				m.semanticError(pos, "No se puede asignar un vector de tipo '"+varValue.Type()+"' a una variable de tipo '"+variable.Type+"'")
+				return
+			}
+		} else if varValue.Type() != "[]" {
+			m.semanticError(pos, "No se puede asignar un valor de tipo '"+varValue.Type()+"' a una variable vector de tipo '"+variable.Type+"'")
+			return
+		}
+	}
+
+	if obj, ok := varValue.(*repl.ObjectValue); ok {
+		varValue = obj.Copy()
+	}
+
+	if repl.IsVectorType(varValue.Type()) {
+		varValue = varValue.Copy()
+	}
+
+	if ok, msg := variable.AssignValue(varValue, true); !ok {
+		m.semanticError(pos, msg)
+	}
+}
+
+func (m *Machine) assignField(fr *frame, ins Instruction, pos ast.Pos) {
+
+	varValue := m.pop()
+	baseName := m.refName(fr, ins.A)
+	fieldName := m.program.Names[ins.B]
+
+	baseVar := m.lookup(fr, ins.A)
+	if baseVar == nil {
+		m.semanticError(pos, "Variable '"+baseName+"' no encontrada")
+		return
+	}
+
+	structVal, ok := baseVar.Value.(*value.StructValue)
+	if !ok {
+		m.semanticError(pos, "Variable '"+baseName+"' no es un struct")
+		return
+	}
+
+	if _, exists := structVal.Instance.Fields[fieldName]; !exists {
+		m.semanticError(pos, "El campo '"+fieldName+"' no existe en el struct '"+baseName+"'")
+		return
+	}
+
+	structVal.Instance.Fields[fieldName] = varValue
+}
+
+func (m *Machine) incDec(fr *frame, ins Instruction, pos ast.Pos) value.IVOR {
+
+	operator, action := "++", "incrementar"
+	if ins.B < 0 {
+		operator, action = "--", "decrementar"
+	}
+
+	variable := m.lookup(fr, ins.A)
+	if variable == nil {
+		m.semanticError(pos, "Variable '"+m.refName(fr, ins.A)+"' no encontrada")
+		return value.DefaultNilValue
+	}
+
+	if variable.Value.Type() != value.IVOR_INT {
+		m.semanticError(pos, "El operador "+operator+" solo puede aplicarse a variables de tipo int")
+		return value.DefaultNilValue
+	}
+
+	if variable.IsConst {
+		m.semanticError(pos, "No se puede "+action+" una variable constante")
+		return value.DefaultNilValue
+	}
+
+	currentValue := variable.Value.(*value.IntValue).InternalValue
+
+	if ok, msg := variable.AssignValue(&value.IntValue{InternalValue: currentValue + ins.B}, true); !ok {
+		m.semanticError(pos, msg)
+		return value.DefaultNilValue
+	}
+
+	return &value.IntValue{InternalValue: currentValue}
+}
+
+// * Vectores y matrices
+
+// itemReference replica ReplVisitor.VisitVectorItem una vez validados la
+// variable y los índices. Retorna nil si el acceso no es válido.
+func (m *Machine) itemReference(fr *frame, ref int, indexValues []value.IVOR, pos ast.Pos) interface{} {
+
+	varName := m.refName(fr, ref)
+	variable := m.lookup(fr, ref)
+
+	indexes := make([]int, len(indexValues))
+	for i, index := range indexValues {
+		indexes[i] = index.Value().(int)
+	}
+
+	if len(indexes) == 1 {
+		index := indexes[0]
+
+		if vectorValue, ok := variable.Value.(*repl.VectorValue); ok {
+			if !vectorValue.ValidIndex(index) {
+				m.semanticError(pos, "Índice "+strconv.Itoa(index)+" fuera de rango")
+				return nil
+			}
+			return &repl.VectorItemReference{
+				Vector: vectorValue,
+				Index:  index,
+				Value:  vectorValue.Get(index),
+			}
+		}
+
+		if matrixValue, ok := variable.Value.(*repl.MatrixValue); ok {
+			if index < 0 || index >= len(matrixValue.Items) {
+				m.semanticError(pos, "Fila "+strconv.Itoa(index)+" fuera de rango")
+				return nil
+			}
+
+			// copia independiente de la fila
+			row := make([]value.IVOR, 0, len(matrixValue.Items[index]))
+			for _, item := range matrixValue.Items[index] {
+				row = append(row, item.Copy())
+			}
+
+			return &repl.VectorValue{
+				InternalValue: row,
+				ItemType:      matrixValue.ItemType,
+				FullType:      "[]" + matrixValue.ItemType,
+				SizeValue:     &value.IntValue{InternalValue: len(row)},
+				IsEmpty:       &value.BoolValue{InternalValue: len(row) == 0},
+			}
+		}
+
+		m.semanticError(pos, "Acceso inválido con un solo índice a variable "+varName)
+		return nil
+	}
+
+	if len(indexes) == 2 {
+		i, j := indexes[0], indexes[1]
+
+		matrixValue, ok := variable.Value.(*repl.MatrixValue)
+		if !ok {
+			m.semanticError(pos, "La variable "+varName+" no es una matriz")
+			return nil
+		}
+		if i < 0 || i >= len(matrixValue.Items) {
+			m.semanticError(pos, "Fila "+strconv.Itoa(i)+" fuera de rango")
+			return nil
+		}
+		if j < 0 || j >= len(matrixValue.Items[i]) {
+			m.semanticError(pos, "Columna "+strconv.Itoa(j)+" fuera de rango")
+			return nil
+		}
+
+		return &repl.MatrixItemReference{
+			Matrix: matrixValue,
+			Index:  []int{i, j},
+			Value:  matrixValue.Items[i][j],
+		}
+	}
+
+	m.semanticError(pos, "Número de índices inválido")
+	return nil
+}
+
+func (m *Machine) indexGet(fr *frame, ins Instruction, pos ast.Pos) value.IVOR {
+
+	switch itemRef := m.itemReference(fr, ins.A, m.popN(ins.B), pos).(type) {
+	case *repl.VectorItemReference:
+		return itemRef.Value
+	case *repl.MatrixItemReference:
+		return itemRef.Value
+	case *repl.VectorValue:
+		return itemRef
+	}
+
+	return value.DefaultNilValue
+}
+
+func (m *Machine) indexSet(fr *frame, ins Instruction, pos ast.Pos) {
+
+	indexes := m.popN(ins.B)
+	rightValue := m.pop()
+
+	switch itemRef := m.itemReference(fr, ins.A, indexes, pos).(type) {
+	case *repl.VectorItemReference:
+		if rightValue.Type() != itemRef.Vector.ItemType {
+			m.semanticError(pos, "No se puede asignar un valor de tipo "+rightValue.Type()+" a un vector de tipo "+itemRef.Vector.ItemType)
+			return
+		}
+
+		if ins.C < 0 {
+			itemRef.Vector.InternalValue[itemRef.Index] = rightValue
+			return
+		}
+
+		ok, msg, varValue := m.program.Binary[ins.C].Validate(itemRef.Value, rightValue)
+		if !ok {
+			m.semanticError(pos, msg)
+			return
+		}
+		itemRef.Vector.InternalValue[itemRef.Index] = varValue
+
+	case *repl.MatrixItemReference:
+		itemType := repl.RemoveBrackets(itemRef.Matrix.Type())
+
+		if rightValue.Type() != itemType {
+			m.semanticError(pos, "No se puede asignar un valor de tipo "+rightValue.Type()+" a una matriz de tipo "+itemType)
+			return
+		}
+
+		if ins.C < 0 {
+			itemRef.Matrix.Set(itemRef.Index, rightValue)
+			return
+		}
+
+		ok, msg, varValue := m.program.Binary[ins.C].Validate(itemRef.Value, rightValue)
+		if !ok {
+			m.semanticError(pos, msg)
+			return
+		}
+		itemRef.Matrix.Set(itemRef.Index, varValue)
+	}
+}
+
+func (m *Machine) makeVector(items []value.IVOR, pos ast.Pos) value.IVOR {
+
+	if len(items) == 0 {
+		return repl.NewVectorValue(nil, "[]", value.IVOR_ANY)
+	}
+
+	itemType := items[0].Type()
+	for _, item := range items {
+		if item.Type() != itemType {
+			m.semanticError(pos, "Todos los items de la coleccion deben ser del mismo tipo")
+			return value.DefaultNilValue
+		}
+	}
+
+	_type := "[]" + itemType
+	if repl.IsVectorType(_type) {
+		return repl.NewVectorValue(items, _type, itemType)
+	}
+
+	m.semanticError(pos, "Tipo "+_type+" no encontrado")
+	return value.DefaultNilValue
+}
+
+func (m *Machine) makeMatrix(rows []value.IVOR, pos ast.Pos) value.IVOR {
+
+	var matrixItems [][]value.IVOR
+	innerType := value.IVOR_NIL
+
+	for i, row := range rows {
+		rowValue := row.(*repl.VectorValue)
+		matrixItems = append(matrixItems, rowValue.InternalValue)
+
+		if i == 0 {
+			innerType = rowValue.ItemType
+		} else if rowValue.ItemType != innerType {
+			m.semanticError(pos, "Todos los elementos de la matriz deben ser del mismo tipo")
+			return value.DefaultNilValue
+		}
+	}
+
+	return repl.NewMatrixValue(matrixItems, "[[]]"+innerType, innerType)
+}
+
+// interpolate replica ReplVisitor.InterpolateString con las variables
+// resueltas en compilación
+func (m *Machine) interpolate(fr *frame, interpolation *Interpolation, pos ast.Pos) value.IVOR {
+
+	result := interpolation.Text
+
+	for _, pattern := range interpolationPatterns {
+		result = pattern.ReplaceAllStringFunc(result, func(match string) string {
+			var varName string
+			if strings.HasPrefix(match, "${") {
+				varName = match[2 : len(match)-1]
+			} else {
+				varName = match[1:]
+			}
+
+			var variable *repl.Variable
+			if ref, ok := interpolation.Refs[varName]; ok {
+				variable = m.lookup(fr, ref)
+			} else {
+				// el nombre surgió de un valor ya interpolado
+				variable = m.globalByName(varName)
+			}
+
+			if variable == nil {
+				m.semanticError(pos, fmt.Sprintf("Variable '%s' no encontrada en interpolación de string", varName))
+				return match
+			}
+
+			return repl.ValueToString(variable.Value)
+		})
+	}
+
+	if len(result) == 1 {
+		return &value.CharacterValue{InternalValue: result}
+	}
+
+	return &value.StringValue{InternalValue: result}
+}
@@ -0,0 +1,211 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+
+	"main.go/ast"
+	"main.go/repl"
+	"main.go/value"
+)
+
+// Opcode identifica una instrucción de la máquina virtual.
+type Opcode byte
+
+const (
+	OpNil   Opcode = iota // push nil
+	OpConst               // push Constants[A]
+	OpPop                 // descarta el tope de la pila
+	OpError               // reporta Names[A] como error semántico
+	OpStep                // cuenta un paso: una sentencia o una iteración
+
+	OpLoad     // push valor de la variable A
+	OpLoadPath // push valor de la variable A accediendo a los atributos Paths[B]
+	OpLoadArg  // push valor de la variable A usada como argumento
+	OpLoadProp // push propiedad Names[B] de la variable A usada como argumento
+
+	OpDeclare       // declara la variable A con tipo Names[B] (B < 0: tipo inferido)
+	OpDeclareVector // declara la variable A como vector de tipo Names[B]
+	OpDeclareEmpty  // declara la variable A como vector vacío de tipo Names[B]
+	OpDeclareMatrix // declara la variable A como matriz de tipo Names[B]
+	OpClear         // limpia los slots locales [A, B)
+	OpStoreHidden   // guarda el tope de la pila en el slot interno A
+
+	OpAssign        // asigna el tope de la pila a la variable A
+	OpAssignField   // asignación a un atributo Names[B] de la variable A
+	OpCompoundLoad  // push valor de la variable A o salta a B si no existe
+	OpCompoundStore // variable A = tope-1 <op Binary[B]> tope
+	OpIncDec        // post incremento (B = 1) o decremento (B = -1) de la variable A
+
+	OpIndexBegin // valida que la variable A sea indexable o salta a B
+	OpIndexInt   // valida que el índice sea entero o descarta C valores y salta a B
+	OpIndexGet   // push elemento de la variable A con B índices
+	OpIndexSet   // asigna al elemento de la variable A con B índices (C: operador o -1)
+
+	OpUnary       // aplica Unary[A] al tope
+	OpBinary      // aplica Binary[A] a los dos valores del tope
+	OpEarly       // corto circuito Early[A]; si aplica salta a B
+	OpInterpolate // push la cadena Interpolations[A] ya interpolada
+	OpMakeVector  // construye un vector con los A valores del tope
+	OpMakeMatrix  // construye una matriz con las A filas del tope
+
+	OpJump        // salta a A
+	OpJumpIfFalse // salta a A si el tope es falso; reporta Names[B] si no es booleano
+	OpCaseJump    // salta a B si el tope es igual al valor del slot interno A
+
+	OpIterInit    // prepara el iterable del tope en el slot interno A o salta a B
+	OpIterBind    // declara índice (B) y valor (C) del iterable en el slot interno A
+	OpIterTest    // salta a B si el iterable del slot interno A terminó
+	OpIterSet     // actualiza índice (B) y valor (C) con el elemento actual
+	OpIterAdvance // avanza el iterable del slot interno A
+	OpIterReset   // reinicia el iterable del slot interno A
+
+	OpArg        // convierte el tope en argumento con nombre Names[A] (A < 0: sin nombre)
+	OpCall       // llama a Functions[A] con B argumentos
+	OpCallNative // llama a Builtins[A] con B argumentos
+	OpMethod     // busca el método Names[B] de la variable A o salta a C
+	OpCallMethod // llama al método del tope con B argumentos
+	OpReturn     // retorna el tope de la pila
+	OpReturnNil  // retorna nil
+)
+
+var opcodeNames = [...]string{
+	OpNil:           "NIL",
+	OpConst:         "CONST",
+	OpPop:           "POP",
+	OpError:         "ERROR",
+	OpStep:          "STEP",
+	OpLoad:          "LOAD",
+	OpLoadPath:      "LOAD_PATH",
+	OpLoadArg:       "LOAD_ARG",
+	OpLoadProp:      "LOAD_PROP",
+	OpDeclare:       "DECLARE",
+	OpDeclareVector: "DECLARE_VECTOR",
+	OpDeclareEmpty:  "DECLARE_EMPTY",
+	OpDeclareMatrix: "DECLARE_MATRIX",
+	OpClear:         "CLEAR",
+	OpStoreHidden:   "STORE_HIDDEN",
+	OpAssign:        "ASSIGN",
+	OpAssignField:   "ASSIGN_FIELD",
+	OpCompoundLoad:  "COMPOUND_LOAD",
+	OpCompoundStore: "COMPOUND_STORE",
+	OpIncDec:        "INC_DEC",
+	OpIndexBegin:    "INDEX_BEGIN",
+	OpIndexInt:      "INDEX_INT",
+	OpIndexGet:      "INDEX_GET",
+	OpIndexSet:      "INDEX_SET",
+	OpUnary:         "UNARY",
+	OpBinary:        "BINARY",
+	OpEarly:         "EARLY",
+	OpInterpolate:   "INTERPOLATE",
+	OpMakeVector:    "MAKE_VECTOR",
+	OpMakeMatrix:    "MAKE_MATRIX",
+	OpJump:          "JUMP",
+	OpJumpIfFalse:   "JUMP_IF_FALSE",
+	OpCaseJump:      "CASE_JUMP",
+	OpIterInit:      "ITER_INIT",
+	OpIterBind:      "ITER_BIND",
+	OpIterTest:      "ITER_TEST",
+	OpIterSet:       "ITER_SET",
+	OpIterAdvance:   "ITER_ADVANCE",
+	OpIterReset:     "ITER_RESET",
+	OpArg:           "ARG",
+	OpCall:          "CALL",
+	OpCallNative:    "CALL_NATIVE",
+	OpMethod:        "METHOD",
+	OpCallMethod:    "CALL_METHOD",
+	OpReturn:        "RETURN",
+	OpReturnNil:     "RETURN_NIL",
+}
+
+func (op Opcode) String() string {
+	if int(op) < len(opcodeNames) && opcodeNames[op] != "" {
+		return opcodeNames[op]
+	}
+	return fmt.Sprintf("OP(%d)", op)
+}
+
+// Instruction es una instrucción con hasta tres operandos enteros.
+// Las referencias a variables se codifican como slot local (>= 0) o
+// global (-(slot+1)), ver localRef y globalRef.
+type Instruction struct {
+	Op Opcode
+	A  int
+	B  int
+	C  int
+}
+
+func localRef(slot int) int  { return slot }
+func globalRef(slot int) int { return -(slot + 1) }
+
+// isGlobalRef indica si la referencia apunta a una variable global y retorna su slot.
+func isGlobalRef(ref int) (bool, int) {
+	if ref < 0 {
+		return true, -ref - 1
+	}
+	return false, ref
+}
+
+// Chunk es una secuencia de instrucciones con su posición de origen, que se
+// usa para reportar los errores en la misma posición que el intérprete.
+type Chunk struct {
+	Name   string
+	Code   []Instruction
+	Pos    []ast.Pos
+	Locals []string // nombre de cada slot local ("" para slots internos)
+}
+
+func (c *Chunk) emit(op Opcode, a, b, cc int, pos ast.Pos) int {
+	c.Code = append(c.Code, Instruction{Op: op, A: a, B: b, C: cc})
+	c.Pos = append(c.Pos, pos)
+	return len(c.Code) - 1
+}
+
+// FunctionProto es una función de usuario compilada.
+type FunctionProto struct {
+	Decl  *repl.Function
+	Chunk *Chunk
+}
+
+// Interpolation describe una cadena con patrones $var o ${var} y las
+// variables a las que se resuelven.
+type Interpolation struct {
+	Text string
+	Refs map[string]int
+}
+
+// Program es el resultado de compilar un programa: el chunk principal, las
+// funciones y las tablas compartidas (constantes, nombres y operadores).
+type Program struct {
+	Main           *Chunk
+	Functions      []*FunctionProto
+	Constants      []value.IVOR
+	Names          []string
+	Paths          [][]string
+	Globals        []string
+	Builtins       []*repl.BuiltInFunction
+	Binary         []*value.BinaryStrategy
+	Unary          []*value.UnaryStrategy
+	Early          []*value.UnaryStrategy
+	Interpolations []*Interpolation
+}
+
+// Disassemble retorna una representación legible del bytecode del programa.
+func (p *Program) Disassemble() string {
+	var sb strings.Builder
+
+	p.disassembleChunk(&sb, p.Main)
+	for _, fn := range p.Functions {
+		p.disassembleChunk(&sb, fn.Chunk)
+	}
+
+	return sb.String()
+}
+
+func (p *Program) disassembleChunk(sb *strings.Builder, chunk *Chunk) {
+	fmt.Fprintf(sb, "== %s (%d locales) ==\n", chunk.Name, len(chunk.Locals))
+
+	for i, ins := range chunk.Code {
+		fmt.Fprintf(sb, "%04d %4d %-15s %d %d %d\n", i, chunk.Pos[i].Line, ins.Op, ins.A, ins.B, ins.C)
+	}
+}
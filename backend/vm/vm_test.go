@@ -0,0 +1,175 @@
+package vm_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"main.go/analysis"
+	"main.go/ast"
+	interpeter "main.go/grammar"
+	"main.go/repl"
+	"main.go/vm"
+)
+
+// compile declara las funciones de code con el DclVisitor y lo compila.
+func compile(code string) (*vm.Program, *repl.ErrorTable, error) {
+	lexer := interpeter.NewVLangLexer(antlr.NewInputStream(code))
+	parser := interpeter.NewVLangGrammar(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	typed := ast.Lower(parser.Program())
+
+	errorTable := repl.NewErrorTable()
+	dclVisitor := repl.NewDclVisitor(errorTable)
+	dclVisitor.VisitProgram(typed)
+	program, err := vm.Compile(typed, dclVisitor)
+	return program, errorTable, err
+}
+
+func TestMachine(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"aritmética", "mut x = 7\nmut y = 2\nprintln(x + y * 3, x / y, x % y)\n", "13 3 1\n\n"},
+		{"funciones y recursión", "fn fib(n int) int {\n    if n < 2 {\n        return n\n    }\n    return fib((n - 1)) + fib((n - 2))\n}\nmut r = fib(10)\nprintln(r)\n", "55\n\n"},
+		{"for con break y continue", "mut s = 0\nmut i = 0\nfor i = 0; i < 10; i++ {\n    if i == 2 {\n        continue\n    }\n    if i == 5 {\n        break\n    }\n    s += i\n}\nprintln(s)\n", "8\n\n"},
+		{"interpolación", "mut n = 3\nprintln(\"n vale $n\")\n", "n vale 3\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, errorTable, err := compile(tt.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			machine := vm.NewMachine(program, errorTable)
+			if err := machine.Run(); err != nil {
+				t.Fatal(err)
+			}
+			if got := machine.Console.GetOutput(); got != tt.want {
+				t.Errorf("salida %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Lo que la VM no compila se informa con la construcción y su posición, para
+// que quien la llama avise que ejecuta con el intérprete.
+func TestCompileUnsupported(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      string
+		construct string
+		line      int
+	}{
+		{"struct", "mut a = 1\nstruct Punto {\n    int x\n}\n", "struct", 2},
+		{"while", "mut i = 0\nwhile i < 3 {\n    i += 1\n}\n", "while", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := compile(tt.code)
+			var unsupported *vm.UnsupportedError
+			if !errors.As(err, &unsupported) {
+				t.Fatalf("se esperaba un UnsupportedError, se obtuvo %v", err)
+			}
+			if unsupported.Construct != tt.construct || unsupported.Line != tt.line {
+				t.Errorf("se obtuvo %q en la línea %d, se esperaba %q en la línea %d", unsupported.Construct, unsupported.Line, tt.construct, tt.line)
+			}
+		})
+	}
+}
+
+// La máquina respeta los mismos límites que el intérprete y los reporta
+// con el mismo mensaje y en la misma línea.
+func TestLimits(t *testing.T) {
+	limits := repl.Limits{MaxSteps: 1000, MaxCallDepth: 50, MaxStringLength: 1 << 10}
+	tests := []struct {
+		name string
+		code string
+		want string // fragmento del mensaje
+	}{
+		{"ciclo infinito", "mut i = 0\nfor true {\n    i += 1\n}\n", "pasos de ejecución"},
+		{"ciclo vacío", "for true {\n}\n", "pasos de ejecución"},
+		{"cadena que se duplica", "mut s = \"ab\"\nfor true {\n    s += s\n}\n", "largo máximo"},
+		{"concatenación", "mut s = \"ab\"\nmut i = 0\nfor i < 20 {\n    s = s + s\n    i += 1\n}\n", "largo máximo"},
+		{"recursión infinita", "fn f(n int) int {\n    return f((n + 1))\n}\nmut r = f(0)\n", "profundidad máxima"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, errorTable, err := compile(tt.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			machine := vm.NewMachine(program, errorTable)
+			machine.Limits = limits
+			var limit *repl.LimitExceeded
+			if err := machine.Run(); !errors.As(err, &limit) {
+				t.Fatalf("se esperaba un LimitExceeded, se obtuvo %v", err)
+			}
+
+			analyzed := analysis.Analyze(tt.code)
+			visitor := repl.NewVisitor(analyzed.DclVisitor)
+			visitor.Limits = limits
+			visitor.VisitProgram(analyzed.Program)
+
+			got, want := errorTable.Errors, visitor.ErrorTable.Errors
+			if len(got) != 1 || len(want) != 1 {
+				t.Fatalf("errores de la VM %+v, del intérprete %+v", got, want)
+			}
+			if got[0].Msg != want[0].Msg || got[0].Line != want[0].Line || !strings.Contains(got[0].Msg, tt.want) {
+				t.Errorf("la VM reportó %d: %q, el intérprete %d: %q", got[0].Line, got[0].Msg, want[0].Line, want[0].Msg)
+			}
+		})
+	}
+}
+
+// Sin límites la máquina corre lo que haga falta.
+func TestNoLimits(t *testing.T) {
+	program, errorTable, err := compile("mut i = 0\nfor i < 100000 {\n    i += 1\n}\nprintln(i)\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	machine := vm.NewMachine(program, errorTable)
+	if err := machine.Run(); err != nil || machine.Console.GetOutput() != "100000\n\n" {
+		t.Errorf("error %v, salida %q", err, machine.Console.GetOutput())
+	}
+}
+
+// La máquina cuenta los pasos igual que el intérprete: con cualquier
+// presupuesto los dos terminan, o los dos se detienen en la misma línea.
+func TestStepParity(t *testing.T) {
+	programs := map[string]string{
+		"for con condición":  "mut i = 0\nfor i < 5 {\n    i += 1\n    if i == 2 {\n        continue\n    }\n    if i == 4 {\n        break\n    }\n}\n",
+		"for con asignación": "mut s = 0\nmut i = 0\nfor i = 0; i < 4; i++ {\n    s += i\n}\n",
+		"for in":             "mut v []int = {1, 2, 3}\nmut s = 0\nfor i, e in v {\n    s += e\n}\n",
+		"funciones":          "fn f(n int) int {\n    if n < 2 {\n        return n\n    }\n    return f((n - 1)) + f((n - 2))\n}\nmut r = f(5)\n",
+		"bloques":            "mut a = 1\n{\n    mut b = 2\n    a = b\n}\n",
+	}
+	for name, code := range programs {
+		t.Run(name, func(t *testing.T) {
+			for steps := 1; steps <= 200; steps++ {
+				limits := repl.Limits{MaxSteps: steps}
+
+				program, errorTable, err := compile(code)
+				if err != nil {
+					t.Fatal(err)
+				}
+				machine := vm.NewMachine(program, errorTable)
+				machine.Limits = limits
+				machine.Run()
+
+				analyzed := analysis.Analyze(code)
+				visitor := repl.NewVisitor(analyzed.DclVisitor)
+				visitor.Limits = limits
+				visitor.VisitProgram(analyzed.Program)
+
+				got, want := errorTable.Errors, visitor.ErrorTable.Errors
+				if len(got) != len(want) || (len(got) > 0 && got[0].Line != want[0].Line) {
+					t.Fatalf("con %d pasos: la VM reportó %+v, el intérprete %+v", steps, got, want)
+				}
+			}
+		})
+	}
+}
@@ -270,7 +270,7 @@ class CodeEditor {
             endLineNumber: error.line,
             endColumn: (error.column || 1) + (error.length || 1),
             message: error.message,
-            severity: this.getSeverity(error.type || error.severity)
+            severity: this.getSeverity(error.severity || error.type)
         }));
 
         monaco.editor.setModelMarkers(this.editor.getModel(), 'vlancherry', markers);
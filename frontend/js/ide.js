@@ -134,6 +134,12 @@ class IDEController {
         if (result.success) {
             this.addConsoleMessage('✅ Ejecución completada exitosamente', 'success');
 
+            // Las advertencias del linter no impiden la ejecución
+            if (result.errors && result.errors.length > 0) {
+                this.editor.markErrors(result.errors);
+                this.addConsoleMessage(`Advertencias: ${result.errors.length}`, 'warning');
+            }
+
             // Mostrar salida del programa usando los nuevos formatos
             this.displayProgramOutput(result);
 
@@ -277,6 +283,7 @@ class IDEController {
         if (errorSummary.syntax) parts.push(`${errorSummary.syntax} sintácticos`);
         if (errorSummary.semantic) parts.push(`${errorSummary.semantic} semánticos`);
         if (errorSummary.runtime) parts.push(`${errorSummary.runtime} de ejecución`);
+        if (errorSummary.lint) parts.push(`${errorSummary.lint} advertencias`);
         
         return parts.length > 0 ? parts.join(', ') : '0';
     }
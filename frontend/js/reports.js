@@ -1122,17 +1122,8 @@ class ReportsManager {
             return;
         }
 
-        // LIMPIAR TODO EL HTML CORRUPTO DEL BACKEND
-        let cleanCode = this.currentReports.arm64Code
-            .replace(/"asm-[^"]*">/g, '')           // Remover cualquier "asm-X">
-            .replace(/<span[^>]*>/g, '')            // Remover <span>
-            .replace(/<\/span>/g, '')               // Remover </span>
-            .replace(/&lt;/g, '<')                  // Decodificar HTML entities
-            .replace(/&gt;/g, '>')
-            .replace(/&amp;/g, '&')
-            .replace(/&quot;/g, '"');
-
-        // GUARDAR EL CÓDIGO COMPLETAMENTE LIMPIO
+        // El backend entrega el ensamblador tal cual, sin HTML
+        const cleanCode = this.currentReports.arm64Code;
         this.rawARM64Code = cleanCode;
         
         console.log('🔧 Código ARM64 LIMPIO guardado:', this.rawARM64Code);